@@ -77,6 +77,40 @@ func (meta *AccountMeta) SIGNER() *AccountMeta {
 	return meta
 }
 
+// READONLY sets IsWritable to false.
+func (meta *AccountMeta) READONLY() *AccountMeta {
+	meta.IsWritable = false
+	return meta
+}
+
+// AsWritable sets IsWritable to the provided value.
+func (meta *AccountMeta) AsWritable(writable bool) *AccountMeta {
+	meta.IsWritable = writable
+	return meta
+}
+
+// AsSigner sets IsSigner to the provided value.
+func (meta *AccountMeta) AsSigner(signer bool) *AccountMeta {
+	meta.IsSigner = signer
+	return meta
+}
+
+// String returns the pubkey followed by the flags,
+// e.g. "9WWfC3y4uCNofr2qEFHSVUXkCxW99JiYkMWmSZvVt8j3 [WRITE, SIGN]".
+func (meta AccountMeta) String() string {
+	flags := ""
+	if meta.IsWritable {
+		flags += "WRITE"
+	}
+	if meta.IsSigner {
+		if meta.IsWritable {
+			flags += ", "
+		}
+		flags += "SIGN"
+	}
+	return meta.PublicKey.String() + " [" + flags + "]"
+}
+
 func NewAccountMeta(
 	pubKey PublicKey,
 	WRITE bool,
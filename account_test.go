@@ -157,6 +157,37 @@ func TestMeta(t *testing.T) {
 	require.True(t, meta.IsWritable)
 }
 
+func TestMeta_conditionalFlags(t *testing.T) {
+	pkey := MustPublicKeyFromBase58("SysvarS1otHashes111111111111111111111111111")
+
+	meta := Meta(pkey).AsWritable(true).AsSigner(true)
+	require.True(t, meta.IsWritable)
+	require.True(t, meta.IsSigner)
+
+	meta.AsSigner(false)
+	require.True(t, meta.IsWritable)
+	require.False(t, meta.IsSigner)
+
+	meta.READONLY()
+	require.False(t, meta.IsWritable)
+	require.False(t, meta.IsSigner)
+
+	isPayer := false
+	require.Equal(t,
+		&AccountMeta{PublicKey: pkey, IsWritable: true},
+		Meta(pkey).WRITE().AsSigner(isPayer),
+	)
+}
+
+func TestAccountMeta_String(t *testing.T) {
+	pkey := MustPublicKeyFromBase58("SysvarS1otHashes111111111111111111111111111")
+
+	require.Equal(t, "SysvarS1otHashes111111111111111111111111111 []", Meta(pkey).String())
+	require.Equal(t, "SysvarS1otHashes111111111111111111111111111 [WRITE]", Meta(pkey).WRITE().String())
+	require.Equal(t, "SysvarS1otHashes111111111111111111111111111 [SIGN]", Meta(pkey).SIGNER().String())
+	require.Equal(t, "SysvarS1otHashes111111111111111111111111111 [WRITE, SIGN]", Meta(pkey).SIGNER().WRITE().String())
+}
+
 func TestSplitFrom(t *testing.T) {
 	slice := make(AccountMetaSlice, 0)
 	slice = append(slice, Meta(BPFLoaderDeprecatedProgramID))
@@ -0,0 +1,98 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/faucet"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var airdropCmd = &cobra.Command{
+	Use:   "airdrop {address} {sol}",
+	Short: "Request an airdrop of SOL, and wait for its confirmation",
+	Long: `Request an airdrop of SOL (e.g. 1.5), and wait for its confirmation.
+
+Amounts larger than the max of a single airdrop are requested in several airdrops.
+When the faucet rejects a request (e.g. because of its rate limit),
+the request is retried with an exponential backoff (see --retries and --retry-delay).`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, err := solana.PublicKeyFromBase58(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account address %q: %w", args[0], err)
+		}
+		lamports, err := token.ParseUiAmount(args[1], solDecimals)
+		if err != nil {
+			return fmt.Errorf("invalid SOL amount %q: %w", args[1], err)
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		result, err := faucet.New(getClient(), &faucet.Options{
+			Commitment:     rpc.CommitmentConfirmed,
+			Attempts:       viper.GetInt("airdrop-cmd-retries") + 1,
+			InitialBackoff: viper.GetDuration("airdrop-cmd-retry-delay"),
+			ConfirmTimeout: viper.GetDuration("airdrop-cmd-timeout"),
+			PollInterval:   viper.GetDuration("airdrop-cmd-poll-interval"),
+		}).Airdrop(ctx, address, lamports)
+		if err != nil {
+			return err
+		}
+
+		if viper.GetBool("airdrop-cmd-json") {
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(airdropOutput{
+				Address:    address,
+				Lamports:   result.Lamports,
+				Signatures: result.Signatures,
+			})
+		}
+		for _, sig := range result.Signatures {
+			fmt.Fprintf(cmd.OutOrStdout(), "Airdrop transaction: %s\n", sig)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Airdrop of %s SOL to %s confirmed\n", args[1], address)
+		return nil
+	},
+}
+
+type airdropOutput struct {
+	Address    solana.PublicKey   `json:"address"`
+	Lamports   uint64             `json:"lamports"`
+	Signatures []solana.Signature `json:"signatures"`
+}
+
+// solDecimals is the number of decimals of the amounts of SOL (1 SOL is 10^9 lamports).
+const solDecimals = 9
+
+func init() {
+	RootCmd.AddCommand(airdropCmd)
+
+	airdropCmd.Flags().Bool("json", false, "Print the result as JSON")
+	airdropCmd.Flags().Int("retries", 5, "Number of retries of each airdrop request rejected by the faucet")
+	airdropCmd.Flags().Duration("retry-delay", 2*time.Second, "Delay before the first retry (doubled at each following retry)")
+	airdropCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait for the confirmation of each airdrop")
+	airdropCmd.Flags().Duration("poll-interval", 500*time.Millisecond, "Interval between the checks of the confirmation")
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/solanatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRPCMethods responds to each request with the response member
+// ("result" or "error") returned by respond for the method
+// and the number of previous calls of the method.
+func mockRPCMethods(t *testing.T, respond func(method string, call int) string) string {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		mu.Lock()
+		call := calls[req.Method]
+		calls[req.Method]++
+		mu.Unlock()
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%v,%s}`, req.ID, respond(req.Method, call))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestAirdropCmd(t *testing.T) {
+	address := solanatest.PublicKeyFromSeedString("alice")
+	sigs := []solana.Signature{{1, 2, 3}, {4, 5, 6}}
+	url := mockRPCMethods(t, func(method string, call int) string {
+		switch method {
+		case "requestAirdrop":
+			if call == 0 {
+				return `"error":{"code":429,"message":"You've either reached your airdrop limit today or the airdrop faucet has run dry."}`
+			}
+			return fmt.Sprintf(`"result":%q`, sigs[call-1])
+		case "getSignatureStatuses":
+			if call == 0 {
+				return `"result":{"context":{"slot":1},"value":[null]}`
+			}
+			return `"result":{"context":{"slot":2},"value":[{"slot":2,"confirmations":0,"err":null,"confirmationStatus":"confirmed"}]}`
+		}
+		return `"error":{"code":-32601,"message":"Method not found"}`
+	})
+
+	// 3.5 SOL are requested in two airdrops, the first one retried once.
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{
+		"airdrop", address.String(), "3.5",
+		"--rpc-url", url,
+		"--json",
+		"--retry-delay", "1ms",
+		"--poll-interval", "1ms",
+	})
+	require.NoError(t, RootCmd.Execute())
+
+	var result airdropOutput
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, airdropOutput{
+		Address:    address,
+		Lamports:   3_500_000_000,
+		Signatures: sigs,
+	}, result)
+}
+
+func TestAirdropCmd_invalidAmount(t *testing.T) {
+	address := solanatest.PublicKeyFromSeedString("alice")
+	for _, amount := range []string{"", "1.0000000001", "1e9", "1,000", "18446744074"} {
+		RootCmd.SetOut(new(bytes.Buffer))
+		RootCmd.SetErr(new(bytes.Buffer))
+		RootCmd.SetArgs([]string{"airdrop", address.String(), amount})
+		err := RootCmd.Execute()
+		require.Error(t, err, amount)
+		assert.Contains(t, err.Error(), "invalid SOL amount", amount)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 
 	"github.com/gagliardetto/solana-go/vault"
@@ -26,6 +27,11 @@ import (
 )
 
 func getClient() *rpc.Client {
+	return getClientForURL(sanitizeAPIURL(viper.GetString("global-rpc-url")))
+}
+
+// getClientForURL returns a client of the endpoint, with the configured HTTP headers.
+func getClientForURL(url string) *rpc.Client {
 	httpHeaders := viper.GetStringSlice("global-http-header")
 
 	for i := 0; i < 25; i++ {
@@ -42,7 +48,7 @@ func getClient() *rpc.Client {
 		}
 		headers[headerArray[0]] = headerArray[1]
 	}
-	api := rpc.NewWithHeaders(sanitizeAPIURL(viper.GetString("global-rpc-url")), headers)
+	api := rpc.NewWithHeaders(url, headers)
 	return api
 }
 
@@ -54,10 +60,25 @@ func sanitizeAPIURL(input string) string {
 		return "https://api.testnet.solana.com"
 	case "mainnet":
 		return "https://api.mainnet-beta.solana.com"
+	case "localnet":
+		return localnetRPCURL
 	}
 	return strings.TrimRight(input, "/")
 }
 
+// parseProgramID parses a program address, or the name
+// of a well-known program (e.g. "token" or "System Program").
+func parseProgramID(in string) (solana.PublicKey, error) {
+	if id, ok := solana.ProgramID(in); ok {
+		return id, nil
+	}
+	id, err := solana.PublicKeyFromBase58(in)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("%q is neither an address nor the name of a well-known program: %w", in, err)
+	}
+	return id, nil
+}
+
 func errorCheck(prefix string, err error) {
 	if err != nil {
 		fmt.Printf("ERROR: %s: %s\n", prefix, err)
@@ -15,47 +15,21 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
+	"errors"
 
-	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/programs/token"
+
+	// Programs register their account decoders on import.
+	_ "github.com/gagliardetto/solana-go/programs/token"
+	_ "github.com/gagliardetto/solana-go/programs/token-2022"
 )
 
+// decode decodes the account data using the account decoder registered
+// for the owner program; it returns nil if no decoder is registered.
 func decode(owner solana.PublicKey, data []byte) (interface{}, error) {
-	bdx, _ := solana.PublicKeyFromBase58("BdxDnkFufu8tjAE5gdPkWdjGfQ3Lz2v6ozfiBDMKxDFW")
-	tkn, _ := solana.PublicKeyFromBase58("TokenSVp5gheXUvJ6jGWGeCsgPKgnE3YgdGKRVCMY9o")
-	switch owner {
-	case bdx, tkn:
-		return decodeAsToken(data)
-	}
-	return nil, nil
-}
-
-func decodeAsToken(data []byte) (out interface{}, err error) {
-
-	switch len(data) {
-	case 120:
-		var tokenAcct token.Account
-
-		if err := bin.NewBinDecoder(data).Decode(&tokenAcct); err != nil {
-			return nil, fmt.Errorf("failed unpacking: %w", err)
-		}
-
-		return tokenAcct, nil
-	case 40:
-		var mint token.Mint
-		if err := bin.NewBinDecoder(data).Decode(&mint); err != nil {
-			log.Fatalln("failed unpack", err)
-		}
-
-		return mint, nil
-
-		// cnt, _ := json.MarshalIndent(mint, "", "  ")
-		// fmt.Println(string(cnt))
-	case 7777:
-		// decode the Multisig struct
+	out, err := solana.DecodeAccount(owner, data)
+	if errors.Is(err, solana.ErrAccountDecoderNotFound) {
+		return nil, nil
 	}
-	return
+	return out, err
 }
@@ -18,12 +18,15 @@
 package cmd
 
 import (
-	"context"
-	"encoding/json"
+	"encoding/hex"
 	"fmt"
+	"io"
 
 	"github.com/gagliardetto/solana-go"
-
+	"github.com/gagliardetto/solana-go/detect"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/spf13/cobra"
 )
 
@@ -33,38 +36,52 @@ var getAccountCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
-		ctx := context.Background()
+		ctx := cmd.Context()
 
-		resp, err := client.GetAccountInfo(ctx, solana.MustPublicKeyFromBase58(args[0]))
+		address, err := solana.PublicKeyFromBase58(args[0])
 		if err != nil {
-			return err
-		}
-
-		acct := resp.Value
-		var data []byte
-		if data, err = json.MarshalIndent(acct, "", "  "); err != nil {
-			return fmt.Errorf("unable to marshall account information: %w", err)
+			return fmt.Errorf("invalid account address: %w", err)
 		}
 
-		fmt.Println(string(data))
-
-		obj, err := decode(acct.Owner, acct.Data.GetBinary())
+		resp, err := client.GetAccountInfoWithOpts(ctx, address, &rpc.GetAccountInfoOpts{
+			Encoding: solana.EncodingBase64,
+		})
 		if err != nil {
 			return err
 		}
 
-		if obj != nil {
-			cnt, err := json.MarshalIndent(obj, "", "  ")
-			if err != nil {
-				return err
-			}
-			fmt.Printf("Data %T: %s\n", obj, string(cnt))
-		}
-
-		return nil
+		return printAccount(cmd.OutOrStdout(), address, resp.Value)
 	},
 }
 
 func init() {
 	getCmd.AddCommand(getAccountCmd)
 }
+
+// printAccount prints the account info and its detected kind (see detect.AccountKind),
+// followed by its data decoded with the decoder of the kind or of the owner program,
+// or as a hex dump if it can't be decoded.
+func printAccount(out io.Writer, address solana.PublicKey, acct *rpc.Account) error {
+	data := acct.Data.GetBinary()
+
+	fmt.Fprintln(out, format.Account("Account", address))
+	fmt.Fprintln(out, format.LamportsParam("Lamports", acct.Lamports))
+	fmt.Fprintln(out, format.Account("Owner", acct.Owner))
+	fmt.Fprintln(out, format.Param("Executable", acct.Executable))
+	fmt.Fprintln(out, format.Param("RentEpoch", acct.RentEpoch))
+	if kind, confidence := detect.AccountKind(acct.Owner, data); confidence != detect.ConfidenceNone {
+		fmt.Fprintln(out, text.Shakespeare("Kind")+": "+kind.String()+" (confidence: "+confidence.String()+")")
+	}
+
+	if obj := decodeProgramAccount(acct.Owner, data); obj != nil {
+		fmt.Fprintf(out, "Data %T:", obj)
+		if err := text.NewEncoder(out).Encode(obj, nil); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(out)
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "Data (%d bytes):\n%s", len(data), hex.Dump(data))
+	return err
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +45,7 @@ var getBalanceCmd = &cobra.Command{
 			return fmt.Errorf("account not found")
 		}
 
-		fmt.Println(resp.Value, "lamports")
+		fmt.Println(format.Lamports(resp.Value))
 
 		return nil
 	},
@@ -18,7 +18,6 @@
 package cmd
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -32,7 +31,7 @@ var getConfirmedBlockCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		client := getClient()
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		var slot int64
 		if slot, err = strconv.ParseInt(args[0], 10, 64); err != nil {
@@ -18,62 +18,213 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-
-	"github.com/gagliardetto/solana-go/text"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/gagliardetto/solana-go"
-
+	"github.com/gagliardetto/solana-go/detect"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/text/format"
+	"github.com/mr-tron/base58"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var getProgramAccountsCmd = &cobra.Command{
 	Use:   "program-accounts {program_addr}",
-	Short: "Retrieve info about an account",
-	Args:  cobra.ExactArgs(1),
+	Short: "Retrieve the accounts owned by a program",
+	Long: `Retrieve the accounts owned by a program.
+
+The program is an address, or the name of a well-known program
+(e.g. "token", "token-2022" or "stake").`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
 
-		resp, err := client.GetProgramAccounts(
-			cmd.Context(),
-			solana.MustPublicKeyFromBase58(args[0]),
-		)
+		programID, err := parseProgramID(args[0])
 		if err != nil {
-			return err
+			return fmt.Errorf("invalid program address: %w", err)
 		}
 
-		if resp == nil {
-			return fmt.Errorf("program account not found")
+		output := viper.GetString("get-program-accounts-cmd-output")
+		if output != "text" && output != "json" {
+			return fmt.Errorf("invalid output %q, must be one of: text, json", output)
 		}
+		limit := viper.GetInt("get-program-accounts-cmd-limit")
 
-		for _, keyedAcct := range resp {
-			acct := keyedAcct.Account
-
-			obj, err := decode(acct.Owner, acct.Data.GetBinary())
+		opts := &rpc.GetProgramAccountsOpts{
+			Encoding: solana.EncodingBase64,
+		}
+		if dataSize := viper.GetUint64("get-program-accounts-cmd-datasize"); dataSize > 0 {
+			opts.Filters = append(opts.Filters, rpc.RPCFilter{DataSize: dataSize})
+		}
+		for _, memcmp := range viper.GetStringSlice("get-program-accounts-cmd-memcmp") {
+			filter, err := parseMemcmpFilter(memcmp)
 			if err != nil {
 				return err
 			}
+			opts.Filters = append(opts.Filters, rpc.RPCFilter{Memcmp: filter})
+		}
+		if dataSlice := viper.GetString("get-program-accounts-cmd-data-slice"); dataSlice != "" {
+			opts.DataSlice, err = parseDataSlice(dataSlice)
+			if err != nil {
+				return err
+			}
+		}
 
-			if obj != nil {
-				cnt, err := json.MarshalIndent(obj, "", "  ")
-				if err != nil {
+		// The accounts are printed as they are decoded from the response,
+		// which is not read any further once the limit is reached.
+		out := cmd.OutOrStdout()
+		printed := 0
+		err = client.GetProgramAccountsCallback(
+			cmd.Context(),
+			programID,
+			opts,
+			func(keyedAcct *rpc.KeyedAccount) error {
+				if keyedAcct == nil || keyedAcct.Account == nil {
+					return nil
+				}
+				// Partial data can't be decoded.
+				if err := printProgramAccount(out, output, keyedAcct, opts.DataSlice == nil); err != nil {
 					return err
 				}
-				fmt.Printf("Data %T: %s\n", obj, string(cnt))
+				printed++
+				if limit > 0 && printed >= limit {
+					return errLimitReached
+				}
 				return nil
-			}
-
-			if err := text.NewEncoder(os.Stdout).Encode(acct, nil); err != nil {
-				return fmt.Errorf("unable to text encode account: %w", err)
-			}
+			},
+		)
+		if err != nil && !errors.Is(err, errLimitReached) {
+			return err
 		}
 
 		return nil
 	},
 }
 
+// errLimitReached stops reading the accounts once --limit accounts were printed.
+var errLimitReached = errors.New("limit reached")
+
 func init() {
 	getCmd.AddCommand(getProgramAccountsCmd)
+
+	getProgramAccountsCmd.Flags().Uint64("datasize", 0, "Only return the accounts with this data size")
+	getProgramAccountsCmd.Flags().StringSlice("memcmp", []string{}, "Only return the accounts with these base58 bytes at this offset, as offset:base58 (can be repeated)")
+	getProgramAccountsCmd.Flags().String("data-slice", "", "Only return this slice of the account data, as offset:length")
+	getProgramAccountsCmd.Flags().Int("limit", 0, "Max number of accounts to print (0 for no limit)")
+	getProgramAccountsCmd.Flags().StringP("output", "o", "text", "Output format. One of: text, json")
+}
+
+func parseMemcmpFilter(in string) (*rpc.RPCFilterMemcmp, error) {
+	parts := strings.SplitN(in, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid memcmp filter %q, expected offset:base58", in)
+	}
+	offset, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memcmp offset %q: %w", parts[0], err)
+	}
+	bytes, err := base58.Decode(parts[1])
+	if err != nil || len(bytes) == 0 {
+		return nil, fmt.Errorf("invalid memcmp bytes %q, must be base58", parts[1])
+	}
+	return &rpc.RPCFilterMemcmp{
+		Offset: offset,
+		Bytes:  solana.Base58(bytes),
+	}, nil
+}
+
+func parseDataSlice(in string) (*rpc.DataSlice, error) {
+	parts := strings.SplitN(in, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid data slice %q, expected offset:length", in)
+	}
+	offset, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data slice offset %q: %w", parts[0], err)
+	}
+	length, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data slice length %q: %w", parts[1], err)
+	}
+	return &rpc.DataSlice{
+		Offset: &offset,
+		Length: &length,
+	}, nil
+}
+
+// decodeProgramAccount decodes the account data with the decoder of the kind
+// of the account (see detect.AccountKind), or else with the decoder registered
+// for the owner program; it returns nil if the data can't be decoded.
+func decodeProgramAccount(owner solana.PublicKey, data []byte) interface{} {
+	if account, _ := detect.AccountKind(owner, data); account.Decode != nil {
+		obj, err := account.Decode(data)
+		if err != nil {
+			return nil
+		}
+		return obj
+	}
+	obj, err := decode(owner, data)
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+type programAccountOutput struct {
+	Pubkey   solana.PublicKey `json:"pubkey"`
+	Owner    solana.PublicKey `json:"owner"`
+	Lamports uint64           `json:"lamports"`
+	Length   int              `json:"length"`
+	Type     string           `json:"type,omitempty"`
+	Decoded  interface{}      `json:"decoded,omitempty"`
+	Data     string           `json:"data,omitempty"`
+}
+
+func printProgramAccount(out io.Writer, outputFormat string, keyedAcct *rpc.KeyedAccount, tryDecode bool) error {
+	acct := keyedAcct.Account
+	data := acct.Data.GetBinary()
+
+	entry := programAccountOutput{
+		Pubkey:   keyedAcct.Pubkey,
+		Owner:    acct.Owner,
+		Lamports: acct.Lamports,
+		Length:   len(data),
+	}
+	if tryDecode {
+		entry.Decoded = decodeProgramAccount(acct.Owner, data)
+	}
+	if entry.Decoded != nil {
+		entry.Type = fmt.Sprintf("%T", entry.Decoded)
+	} else {
+		entry.Data = base64.StdEncoding.EncodeToString(data)
+	}
+
+	if outputFormat == "json" {
+		// One JSON object per line.
+		cnt, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(cnt))
+		return err
+	}
+
+	fmt.Fprintf(out, "Account %s (owner %s, %s)\n", entry.Pubkey, format.ShortPubkey(entry.Owner), format.Lamports(entry.Lamports))
+	if entry.Decoded != nil {
+		cnt, err := json.MarshalIndent(entry.Decoded, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "Data %s: %s\n", entry.Type, string(cnt))
+		return err
+	}
+	_, err := fmt.Fprintf(out, "Data (%d bytes): %s\n", entry.Length, entry.Data)
+	return err
 }
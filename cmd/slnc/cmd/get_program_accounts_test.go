@@ -0,0 +1,289 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/solanatest"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRPC responds to every request with the provided result,
+// and records the last request body.
+func mockRPC(t *testing.T, result string) (url string, lastRequest *map[string]interface{}) {
+	var req map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &req))
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%v,"result":%s}`, req["id"], result)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &req
+}
+
+func TestGetProgramAccountsCmd(t *testing.T) {
+	mint := solanatest.PublicKeyFromSeedString("mint")
+	tokenAccount := solanatest.PublicKeyFromSeedString("token account")
+	invalid := solanatest.PublicKeyFromSeedString("invalid")
+	overLimit := solanatest.PublicKeyFromSeedString("over limit")
+	invalidAccount := solanatest.TokenAccountFixture(mint, invalid, 0)
+	invalidAccount.Data = rpc.DataBytesOrJSONFromBytes([]byte{1, 2, 3})
+	url, lastRequest := mockRPC(t, "["+strings.Join([]string{
+		solanatest.KeyedAccountJSON(tokenAccount, solanatest.TokenAccountFixture(mint, tokenAccount, 10)),
+		solanatest.KeyedAccountJSON(invalid, invalidAccount),
+		solanatest.KeyedAccountJSON(overLimit, solanatest.TokenAccountFixture(mint, overLimit, 20)),
+	}, ",")+"]")
+
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{
+		"get", "program-accounts", solana.TokenProgramID.String(),
+		"--rpc-url", url,
+		"--datasize", "165",
+		"--memcmp", "32:" + tokenAccount.String(),
+		"--limit", "2",
+		"--output", "json",
+	})
+	require.NoError(t, RootCmd.Execute())
+
+	assert.Equal(t,
+		[]interface{}{
+			solana.TokenProgramID.String(),
+			map[string]interface{}{
+				"encoding": "base64",
+				"filters": []interface{}{
+					map[string]interface{}{"dataSize": float64(165)},
+					map[string]interface{}{"memcmp": map[string]interface{}{"offset": float64(32), "bytes": tokenAccount.String()}},
+				},
+			},
+		},
+		(*lastRequest)["params"],
+	)
+
+	// Both accounts are printed (not only the first decoded one), up to the limit.
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second programAccountOutput
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+
+	assert.Equal(t, tokenAccount, first.Pubkey)
+	assert.Equal(t, "*token.Account", first.Type)
+	assert.NotNil(t, first.Decoded)
+	assert.Empty(t, first.Data)
+
+	// Not a valid token account: printed as base64.
+	assert.Equal(t, invalid, second.Pubkey)
+	assert.Empty(t, second.Type)
+	assert.Equal(t, 3, second.Length)
+	assert.Equal(t, "AQID", second.Data)
+}
+
+func TestGetProgramAccountsCmd_streamsUpToLimit(t *testing.T) {
+	mint := solanatest.PublicKeyFromSeedString("mint")
+	first := solanatest.PublicKeyFromSeedString("first")
+	// The response is truncated after the first account: it is printed,
+	// and the rest is not read with a limit of 1.
+	url, _ := mockRPC(t, "["+solanatest.KeyedAccountJSON(first, solanatest.TokenAccountFixture(mint, first, 10))+`,{"pubkey":`)
+
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{
+		"get", "program-accounts", solana.TokenProgramID.String(),
+		"--rpc-url", url,
+		"--limit", "1",
+		"--output", "json",
+	})
+	require.NoError(t, RootCmd.Execute())
+	var printed programAccountOutput
+	require.NoError(t, json.Unmarshal(out.Bytes(), &printed))
+	assert.Equal(t, first, printed.Pubkey)
+
+	// Without a limit, the accounts decoded before the error are printed.
+	out.Reset()
+	RootCmd.SetArgs([]string{
+		"get", "program-accounts", solana.TokenProgramID.String(),
+		"--rpc-url", url,
+		"--limit", "0",
+		"--output", "json",
+	})
+	require.Error(t, RootCmd.Execute())
+	require.NoError(t, json.Unmarshal([]byte(strings.SplitN(out.String(), "\n", 2)[0]), &printed))
+	assert.Equal(t, first, printed.Pubkey)
+}
+
+func TestParseProgramID(t *testing.T) {
+	for in, expected := range map[string]solana.PublicKey{
+		"token":                        solana.TokenProgramID,
+		"Token-2022 Program":           solana.Token2022ProgramID,
+		solana.StakeProgramID.String(): solana.StakeProgramID,
+		"9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin": solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin"),
+	} {
+		id, err := parseProgramID(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, expected, id, in)
+	}
+
+	_, err := parseProgramID("not a program")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"not a program" is neither an address nor the name of a well-known program`)
+}
+
+func TestPrintProgramAccount_text(t *testing.T) {
+	text.DisableColors = true
+	defer func() { text.DisableColors = false }()
+
+	pubkey := solana.NewWallet().PublicKey()
+	nonce := make([]byte, 80)
+	nonce[4] = 1 // initialized
+
+	out := new(bytes.Buffer)
+	require.NoError(t, printProgramAccount(out, "text", &rpc.KeyedAccount{
+		Pubkey: pubkey,
+		Account: &rpc.Account{
+			Owner:    solana.SystemProgramID,
+			Lamports: 10,
+			Data:     rpc.DataBytesOrJSONFromBytes(nonce),
+		},
+	}, true))
+	assert.True(t, strings.HasPrefix(out.String(), fmt.Sprintf("Account %s (owner 1111…111, 0.00000001 SOL (10 lamports))\nData *system.NonceAccount: {", pubkey)), out.String())
+
+	// With a data slice, the data is not decoded.
+	out.Reset()
+	require.NoError(t, printProgramAccount(out, "text", &rpc.KeyedAccount{
+		Pubkey: pubkey,
+		Account: &rpc.Account{
+			Owner:    solana.SystemProgramID,
+			Lamports: 10,
+			Data:     rpc.DataBytesOrJSONFromBytes(nonce),
+		},
+	}, false))
+	assert.Equal(t,
+		fmt.Sprintf("Account %s (owner 1111…111, 0.00000001 SOL (10 lamports))\nData (80 bytes): %s\n", pubkey, base64.StdEncoding.EncodeToString(nonce)),
+		out.String(),
+	)
+}
+
+func TestParseProgramAccountsFlags(t *testing.T) {
+	memcmp, err := parseMemcmpFilter("13:" + solana.TokenProgramID.String())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13), memcmp.Offset)
+	assert.Equal(t, solana.Base58(solana.TokenProgramID[:]), memcmp.Bytes)
+
+	for _, invalid := range []string{"", "13", "x:abc", "13:0OIl"} {
+		_, err := parseMemcmpFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	slice, err := parseDataSlice("4:32")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), *slice.Offset)
+	assert.Equal(t, uint64(32), *slice.Length)
+
+	for _, invalid := range []string{"", "4", "4:", "a:32"} {
+		_, err := parseDataSlice(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGetAccountCmd(t *testing.T) {
+	text.DisableColors = true
+	defer func() { text.DisableColors = false }()
+
+	address := solanatest.PublicKeyFromSeedString("token account")
+	url, lastRequest := mockRPC(t, `{"context":{"slot":1},"value":`+solanatest.AccountJSON(
+		solanatest.TokenAccountFixture(solanatest.PublicKeyFromSeedString("mint"), address, 10),
+	)+`}`)
+
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{"get", "account", address.String(), "--rpc-url", url})
+	require.NoError(t, RootCmd.Execute())
+
+	assert.Equal(t,
+		[]interface{}{address.String(), map[string]interface{}{"encoding": "base64"}},
+		(*lastRequest)["params"],
+	)
+	assert.Contains(t, out.String(), "Account: "+address.String()+"\n")
+	assert.Contains(t, out.String(), "Lamports: 0.00203928 SOL (2,039,280 lamports)\n")
+	assert.Contains(t, out.String(), "Owner: "+solana.TokenProgramID.String()+"\n")
+	assert.Contains(t, out.String(), "Kind: token-account (confidence: high)\n")
+	assert.Contains(t, out.String(), "Data *token.Account:")
+}
+
+func TestPrintAccount_detectedKind(t *testing.T) {
+	text.DisableColors = true
+	defer func() { text.DisableColors = false }()
+
+	address := solana.NewWallet().PublicKey()
+	lookupTable, err := bin.MarshalBin(&addresslookuptable.AddressLookupTableState{
+		TypeIndex:        1,
+		DeactivationSlot: ^uint64(0),
+		Addresses:        solana.PublicKeySlice{address},
+	})
+	require.NoError(t, err)
+
+	out := new(bytes.Buffer)
+	require.NoError(t, printAccount(out, address, &rpc.Account{
+		Owner: solana.AddressLookupTableProgramID,
+		Data:  rpc.DataBytesOrJSONFromBytes(lookupTable),
+	}))
+	assert.Contains(t, out.String(), "Kind: lookup-table (confidence: high)\n")
+	assert.Contains(t, out.String(), "Data *addresslookuptable.AddressLookupTableState:")
+
+	// The accounts of the other programs are reported with their Anchor discriminator.
+	out.Reset()
+	require.NoError(t, printAccount(out, address, &rpc.Account{
+		Owner: solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc"),
+		Data:  rpc.DataBytesOrJSONFromBytes([]byte{0x3f, 0x95, 0xd1, 0x0c, 0xe1, 0x80, 0x63, 0x09, 1}),
+	}))
+	assert.Contains(t, out.String(), "Kind: anchor:3f95d10ce1806309 (confidence: low)\n")
+	assert.Contains(t, out.String(), "Data (9 bytes):\n")
+}
+
+func TestPrintAccount_hexDump(t *testing.T) {
+	text.DisableColors = true
+	defer func() { text.DisableColors = false }()
+
+	address := solana.NewWallet().PublicKey()
+	out := new(bytes.Buffer)
+	require.NoError(t, printAccount(out, address, &rpc.Account{
+		Owner:      solana.BPFLoaderProgramID,
+		Lamports:   10,
+		Executable: true,
+		RentEpoch:  206,
+		Data:       rpc.DataBytesOrJSONFromBytes([]byte{1, 2, 3}),
+	}))
+	assert.Contains(t, out.String(), "Executable: (bool) true\n")
+	assert.Contains(t, out.String(), "RentEpoch: (uint64) 206\n")
+	assert.True(t, strings.HasSuffix(out.String(), "Data (3 bytes):\n00000000  01 02 03                                          |...|\n"), out.String())
+}
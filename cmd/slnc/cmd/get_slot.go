@@ -18,9 +18,9 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/spf13/cobra"
 )
 
@@ -30,14 +30,14 @@ var getSlotCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		resp, err := client.GetSlot(ctx, "")
 		if err != nil {
 			return err
 		}
 
-		fmt.Println(resp)
+		fmt.Println(format.Slot(resp))
 
 		return nil
 	},
@@ -27,6 +27,7 @@ import (
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/spf13/cobra"
 )
 
@@ -72,7 +73,7 @@ var getSPLTokenCmd = &cobra.Command{
 			fmt.Println(keyedAcct.Account.Owner.String())
 
 			text.EncoderColorCyan.Print("Lamports: ")
-			fmt.Println(keyedAcct.Account.Lamports)
+			fmt.Println(format.Lamports(keyedAcct.Account.Lamports))
 
 			if err := text.NewEncoder(os.Stdout).Encode(mint, nil); err != nil {
 				log.Fatalln("failed string encode", err)
@@ -23,12 +23,15 @@ import (
 	"os"
 
 	"github.com/gagliardetto/solana-go"
+	_ "github.com/gagliardetto/solana-go/programs/memo"
 	_ "github.com/gagliardetto/solana-go/programs/serum"
 	_ "github.com/gagliardetto/solana-go/programs/system"
-	_ "github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/programs/token"
+	token2022 "github.com/gagliardetto/solana-go/programs/token-2022"
 	_ "github.com/gagliardetto/solana-go/programs/tokenregistry"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/spf13/cobra"
 )
 
@@ -38,7 +41,7 @@ var getTransactionsCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		address := args[0]
 		pubKey, err := solana.PublicKeyFromBase58(address)
@@ -56,13 +59,14 @@ var getTransactionsCmd = &cobra.Command{
 			return fmt.Errorf("unable to retrieve confirmed transaction signatures for account: %w", err)
 		}
 
+		summaryContext := newSummaryContext(ctx, client)
 		for _, cs := range csList {
 			fmt.Println("-----------------------------------------------------------------------------------------------")
 			text.EncoderColorCyan.Print("Transaction: ")
 			fmt.Println(cs.Signature)
 
 			text.EncoderColorGreen.Print("Slot: ")
-			fmt.Println(cs.Slot)
+			fmt.Println(format.Slot(cs.Slot))
 			text.EncoderColorGreen.Print("Memo: ")
 			fmt.Println(cs.Memo)
 
@@ -75,7 +79,7 @@ var getTransactionsCmd = &cobra.Command{
 				return fmt.Errorf("unable to get confirmed transaction with signature %q: %s", cs.Signature, ct.Meta.Err)
 			}
 
-			_, err = ct.MustGetTransaction().EncodeTree(text.NewTreeEncoder(os.Stdout, text.Bold("INSTRUCTIONS")))
+			_, err = ct.MustGetTransaction().EncodeTreeWithSummaryContext(text.NewTreeEncoder(os.Stdout, text.Bold("INSTRUCTIONS")), summaryContext)
 			if err != nil {
 				panic(err)
 			}
@@ -85,6 +89,56 @@ var getTransactionsCmd = &cobra.Command{
 	},
 }
 
+// newSummaryContext returns a context that resolves the decimals of the tokens
+// from the mints (or the token accounts, via their mint) fetched from the cluster;
+// the results (also the failures) are cached.
+func newSummaryContext(ctx context.Context, client *rpc.Client) *solana.SummaryContext {
+	cache := make(map[solana.PublicKey]*solana.TokenInfo)
+	var resolve func(key solana.PublicKey, followMint bool) *solana.TokenInfo
+	resolve = func(key solana.PublicKey, followMint bool) *solana.TokenInfo {
+		if info, ok := cache[key]; ok {
+			return info
+		}
+		cache[key] = nil
+		resp, err := client.GetAccountInfoWithOpts(ctx, key, &rpc.GetAccountInfoOpts{
+			Encoding: solana.EncodingBase64,
+		})
+		if err != nil || resp.Value == nil {
+			return nil
+		}
+		decoded, err := decode(resp.Value.Owner, resp.Value.Data.GetBinary())
+		if err != nil {
+			return nil
+		}
+		var info *solana.TokenInfo
+		switch acct := decoded.(type) {
+		case *token.Mint:
+			info = &solana.TokenInfo{Decimals: acct.Decimals}
+		case *token2022.Mint:
+			info = &solana.TokenInfo{Decimals: acct.Decimals}
+		case *token.Account:
+			if followMint {
+				info = resolve(acct.Mint, false)
+			}
+		case *token2022.Account:
+			if followMint {
+				info = resolve(acct.Mint, false)
+			}
+		}
+		cache[key] = info
+		return info
+	}
+	return &solana.SummaryContext{
+		ResolveToken: func(mintOrTokenAccount solana.PublicKey) (solana.TokenInfo, bool) {
+			info := resolve(mintOrTokenAccount, true)
+			if info == nil {
+				return solana.TokenInfo{}, false
+			}
+			return *info, true
+		},
+	}
+}
+
 func init() {
 	getCmd.AddCommand(getTransactionsCmd)
 }
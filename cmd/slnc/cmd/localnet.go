@@ -0,0 +1,130 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const localnetRPCURL = "http://localhost:8899"
+
+var localnetCmd = &cobra.Command{
+	Use:   "localnet",
+	Short: "Helpers for a local test validator",
+}
+
+var localnetWaitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait until the local test validator is ready",
+	Long: `Wait until the local test validator is ready: its health is "ok",
+and it returns its version.
+
+The validator is expected at ` + localnetRPCURL + `, unless --rpc-url is set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		url := localnetRPCURL
+		if viper.IsSet("global-rpc-url") {
+			url = sanitizeAPIURL(viper.GetString("global-rpc-url"))
+		}
+		client := getClientForURL(url)
+
+		timeout := viper.GetDuration("localnet-wait-cmd-timeout")
+		start := time.Now()
+		version, err := waitForNode(ctx, client, timeout, viper.GetDuration("localnet-wait-cmd-poll-interval"))
+		if err != nil {
+			return fmt.Errorf("validator at %s is not ready after %s: %w", url, timeout, err)
+		}
+
+		if viper.GetBool("localnet-wait-cmd-json") {
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(localnetWaitOutput{
+				URL:        url,
+				Ready:      true,
+				SolanaCore: version.SolanaCore,
+				FeatureSet: version.FeatureSet,
+				ElapsedMs:  time.Since(start).Milliseconds(),
+			})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Validator at %s is ready (solana-core %s)\n", url, version.SolanaCore)
+		return nil
+	},
+}
+
+type localnetWaitOutput struct {
+	URL        string `json:"url"`
+	Ready      bool   `json:"ready"`
+	SolanaCore string `json:"solanaCore"`
+	FeatureSet int64  `json:"featureSet"`
+	ElapsedMs  int64  `json:"elapsedMs"`
+}
+
+// waitForNode polls the health and the version of the node
+// until both succeed, or the timeout expires;
+// it returns the last error of the node on timeout.
+func waitForNode(
+	ctx context.Context,
+	client *rpc.Client,
+	timeout time.Duration,
+	interval time.Duration,
+) (*rpc.GetVersionResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var lastErr error
+	for {
+		version, err := checkNode(ctx, client)
+		if err == nil {
+			return version, nil
+		}
+		// Keep the error of the node rather than the one of the expired context.
+		if ctx.Err() == nil || lastErr == nil {
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, lastErr
+		case <-time.After(interval):
+		}
+	}
+}
+
+func checkNode(ctx context.Context, client *rpc.Client) (*rpc.GetVersionResult, error) {
+	health, err := client.GetHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if health != rpc.HealthOk {
+		return nil, fmt.Errorf("health is %q", health)
+	}
+	return client.GetVersion(ctx)
+}
+
+func init() {
+	RootCmd.AddCommand(localnetCmd)
+	localnetCmd.AddCommand(localnetWaitCmd)
+
+	localnetWaitCmd.Flags().Bool("json", false, "Print the result as JSON")
+	localnetWaitCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait for the validator")
+	localnetWaitCmd.Flags().Duration("poll-interval", 500*time.Millisecond, "Interval between the checks")
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalnetWaitCmd(t *testing.T) {
+	url := mockRPCMethods(t, func(method string, call int) string {
+		switch method {
+		case "getHealth":
+			if call < 2 {
+				return `"error":{"code":-32005,"message":"Node is behind by 42 slots"}`
+			}
+			return `"result":"ok"`
+		case "getVersion":
+			return `"result":{"solana-core":"1.18.1","feature-set":123}`
+		}
+		return `"error":{"code":-32601,"message":"Method not found"}`
+	})
+
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{
+		"localnet", "wait",
+		"--rpc-url", url,
+		"--json",
+		"--poll-interval", "1ms",
+	})
+	require.NoError(t, RootCmd.Execute())
+
+	var result localnetWaitOutput
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, url, result.URL)
+	assert.True(t, result.Ready)
+	assert.Equal(t, "1.18.1", result.SolanaCore)
+	assert.Equal(t, int64(123), result.FeatureSet)
+}
+
+func TestWaitForNode_timeout(t *testing.T) {
+	url := mockRPCMethods(t, func(method string, call int) string {
+		return `"error":{"code":-32005,"message":"Node is unhealthy"}`
+	})
+	_, err := waitForNode(context.Background(), getClientForURL(url), 20*time.Millisecond, time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Node is unhealthy")
+}
@@ -18,7 +18,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"strconv"
 
@@ -46,7 +45,7 @@ var requestCmd = &cobra.Command{
 		}
 
 		airDrop, err := client.RequestAirdrop(
-			context.Background(),
+			cmd.Context(),
 			address,
 			uint64(lamport),
 			rpc.CommitmentMax,
@@ -18,10 +18,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
+	"github.com/fatih/color"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -41,7 +46,10 @@ var RootCmd = &cobra.Command{
 
 // Execute executes the configured RootCmd
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	// Cancel the commands' context on ctrl-C, so that the pending requests are aborted.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := RootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -55,13 +63,27 @@ func init() {
 	RootCmd.PersistentFlags().StringP("rpc-url", "u", defaultRPCURL, "API endpoint of eos.io blockchain node")
 	RootCmd.PersistentFlags().StringSliceP("http-header", "H", []string{}, "HTTP header to add to JSON-RPC requests")
 	RootCmd.PersistentFlags().StringP("kms-gcp-keypath", "", "", "Path to the cryptoKeys within a keyRing on GCP")
+	RootCmd.PersistentFlags().BoolP("no-color", "", false, "Disable colors in the output (they are also disabled when the NO_COLOR env var is set)")
+	RootCmd.PersistentFlags().BoolP("full-pubkeys", "", false, "Print full pubkeys instead of shortened ones")
 
 	RootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		SetupLogger()
+		setupOutput()
 		return nil
 	}
 }
 
+// setupOutput applies the output flags to the text formatting packages.
+func setupOutput() {
+	if viper.GetBool("global-no-color") {
+		text.DisableColors = true
+	}
+	if text.DisableColors {
+		color.NoColor = true
+	}
+	format.FullPubkeys = viper.GetBool("global-full-pubkeys")
+}
+
 func initConfig() {
 	viper.SetEnvPrefix("SLNC")
 	viper.AutomaticEnv()
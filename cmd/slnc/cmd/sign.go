@@ -0,0 +1,131 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var signCmd = &cobra.Command{
+	Use:   "sign {base64-tx}",
+	Short: "Sign a serialized transaction offline, and print it re-serialized in base64",
+	Long: `Sign a serialized transaction offline, and print it re-serialized in base64.
+
+The transaction is not sent, and no RPC request is made: this allows building
+a transaction on an online machine, and signing it on an air-gapped one.
+If the transaction requires other signatures, it is signed partially,
+and the missing signers are listed on stderr.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		privateKey, err := readKeypairFile(viper.GetString("sign-cmd-keypair"))
+		if err != nil {
+			return err
+		}
+
+		tx, err := signTransaction(args[0], privateKey)
+		if err != nil {
+			return err
+		}
+
+		out, err := tx.ToBase64(solana.EncodeAllowUnsigned())
+		if err != nil {
+			return fmt.Errorf("unable to encode transaction: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), out)
+
+		printMissingSigners(cmd, tx)
+		return nil
+	},
+}
+
+// readKeypairFile reads the private key of the keypair file (as written by solana-keygen).
+func readKeypairFile(keypairFile string) (solana.PrivateKey, error) {
+	if keypairFile == "" {
+		return nil, fmt.Errorf("--keypair is required")
+	}
+	privateKey, err := solana.PrivateKeyFromSolanaKeygenFile(keypairFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keypair: %w", err)
+	}
+	if _, err := solana.PrivateKeyFromBytes(privateKey); err != nil {
+		return nil, fmt.Errorf("invalid keypair %q: %w", keypairFile, err)
+	}
+	return privateKey, nil
+}
+
+// signTransaction decodes the base64 transaction, and signs it with the key,
+// which must be one of its required signers.
+func signTransaction(encoded string, privateKey solana.PrivateKey) (*solana.Transaction, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode base64 transaction: %w", err)
+	}
+	tx, err := solana.TransactionFromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction: %w", err)
+	}
+	if err := addSignature(tx, privateKey); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// addSignature signs the transaction with the key, which must be one of its required signers,
+// keeping the signatures of the other signers.
+func addSignature(tx *solana.Transaction, privateKey solana.PrivateKey) error {
+	numRequired := int(tx.Message.Header.NumRequiredSignatures)
+	if numRequired > len(tx.Message.AccountKeys) {
+		return fmt.Errorf("transaction requires %d signatures, but has only %d account keys", numRequired, len(tx.Message.AccountKeys))
+	}
+	signer := privateKey.PublicKey()
+	isSigner := false
+	for _, key := range tx.Message.AccountKeys[:numRequired] {
+		if key.Equals(signer) {
+			isSigner = true
+		}
+	}
+	if !isSigner {
+		return fmt.Errorf("%s is not a required signer of the transaction", signer)
+	}
+
+	// Make room for all the signatures, so that the new one
+	// is set in the slot of the signer.
+	if len(tx.Signatures) < numRequired {
+		signatures := make([]solana.Signature, numRequired)
+		copy(signatures, tx.Signatures)
+		tx.Signatures = signatures
+	}
+	_, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(signer) {
+			return &privateKey
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to sign transaction: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(signCmd)
+
+	signCmd.Flags().StringP("keypair", "k", "", "Keypair file (as written by solana-keygen) of the signer")
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/solanatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeypairFile(t *testing.T, privateKey solana.PrivateKey) string {
+	values := make([]int, len(privateKey))
+	for i, b := range privateKey {
+		values[i] = int(b)
+	}
+	content, err := json.Marshal(values)
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "keypair.json")
+	require.NoError(t, ioutil.WriteFile(file, content, 0600))
+	return file
+}
+
+func TestSignCmd(t *testing.T) {
+	payer := solanatest.KeypairFromSeedString("payer")
+	from := solanatest.KeypairFromSeedString("from")
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1000, from.PublicKey(), solanatest.PublicKeyFromSeedString("to")).Build(),
+		},
+		solana.Hash{1, 2, 3},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	require.NoError(t, err)
+	unsigned := tx.MustToBase64(solana.EncodeAllowUnsigned())
+
+	sign := func(encoded string, privateKey solana.PrivateKey) (string, string) {
+		out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+		RootCmd.SetOut(out)
+		RootCmd.SetErr(errOut)
+		RootCmd.SetArgs([]string{"sign", encoded, "--keypair", writeKeypairFile(t, privateKey)})
+		require.NoError(t, RootCmd.Execute())
+		return strings.TrimSpace(out.String()), errOut.String()
+	}
+	decode := func(encoded string) *solana.Transaction {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		tx, err := solana.TransactionFromBytes(raw)
+		require.NoError(t, err)
+		return tx
+	}
+
+	// The second signer signs first: its signature goes in its own slot.
+	partial, stderr := sign(unsigned, from)
+	assert.Contains(t, stderr, payer.PublicKey().String())
+	partialTx := decode(partial)
+	require.Len(t, partialTx.Signatures, 2)
+	assert.True(t, partialTx.Signatures[0].IsZero())
+	message, err := partialTx.Message.MarshalBinary()
+	require.NoError(t, err)
+	assert.True(t, partialTx.Signatures[1].Verify(from.PublicKey(), message))
+
+	signed, stderr := sign(partial, payer)
+	assert.Empty(t, stderr)
+	signedTx := decode(signed)
+	require.NoError(t, signedTx.VerifySignatures())
+	assert.Equal(t, partialTx.Signatures[1], signedTx.Signatures[1])
+}
+
+func TestSignTransaction_notASigner(t *testing.T) {
+	payer := solanatest.KeypairFromSeedString("payer")
+	tx := solanatest.SignedTransferTxFixture(payer, solanatest.PublicKeyFromSeedString("to"), 1000, solana.Hash{1, 2, 3})
+
+	_, err := signTransaction(tx.MustToBase64(), solanatest.KeypairFromSeedString("mallory"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a required signer")
+
+	_, err = signTransaction("not base64!", payer)
+	require.Error(t, err)
+}
@@ -18,7 +18,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -31,7 +30,7 @@ var systemTransferCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
 		// v := mustGetWallet()
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		from := args[0]
 		to := args[1]
@@ -0,0 +1,180 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tokenCreateMintCmd = &cobra.Command{
+	Use:   "create-mint",
+	Short: "Create a mint whose mint authority is a new M-of-N multisig account",
+	Long: `Create a mint whose mint authority is a new M-of-N multisig account.
+
+The multisig (e.g. --multisig 2of3) and the mint are created in the same transaction,
+paid by the --payer. Each --signer of the multisig is a keypair file (as written
+by solana-keygen) or a public key; there must be N of them. The signers don't sign
+the creation: minting will require the signatures of M of them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, n, err := parseMultisigSpec(viper.GetString("token-create-mint-cmd-multisig"))
+		if err != nil {
+			return err
+		}
+		signers, err := parseSignerKeys(viper.GetStringSlice("token-create-mint-cmd-signer"))
+		if err != nil {
+			return err
+		}
+		if len(signers) != n {
+			return fmt.Errorf("a %dof%d multisig requires %d --signer, got %d", m, n, n, len(signers))
+		}
+		payerFile := viper.GetString("token-create-mint-cmd-payer")
+		if payerFile == "" {
+			return fmt.Errorf("--payer is required")
+		}
+		payer, err := readKeypairFile(payerFile)
+		if err != nil {
+			return err
+		}
+		decimals := viper.GetUint("token-create-mint-cmd-decimals")
+		if decimals > 255 {
+			return fmt.Errorf("invalid decimals %d", decimals)
+		}
+
+		multisigAccount, err := solana.NewRandomPrivateKey()
+		if err != nil {
+			return err
+		}
+		mintAccount, err := solana.NewRandomPrivateKey()
+		if err != nil {
+			return err
+		}
+		workflow, err := token.NewMultisigMintWorkflow(
+			multisigAccount.PublicKey(),
+			mintAccount.PublicKey(),
+			uint8(m),
+			signers,
+			uint8(decimals),
+		)
+		if err != nil {
+			return err
+		}
+
+		client := getClient()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		multisigRent, err := client.GetMinimumBalanceForRentExemption(ctx, token.MULTISIG_SIZE, rpc.CommitmentFinalized)
+		if err != nil {
+			return fmt.Errorf("unable to get the rent of the multisig: %w", err)
+		}
+		mintRent, err := client.GetMinimumBalanceForRentExemption(ctx, token.MINT_SIZE, rpc.CommitmentFinalized)
+		if err != nil {
+			return fmt.Errorf("unable to get the rent of the mint: %w", err)
+		}
+		latest, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return fmt.Errorf("unable to get the latest blockhash: %w", err)
+		}
+
+		tx, err := workflow.CreateTransaction(latest.Value.Blockhash, payer, multisigAccount, mintAccount, multisigRent, mintRent)
+		if err != nil {
+			return err
+		}
+		sig, err := client.SendTransaction(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+		statuses, err := client.ConfirmTransactionsWithOpts(ctx, []solana.Signature{sig}, &rpc.ConfirmTransactionsOpts{
+			Commitment:   rpc.CommitmentConfirmed,
+			Timeout:      viper.GetDuration("token-create-mint-cmd-timeout"),
+			PollInterval: viper.GetDuration("token-create-mint-cmd-poll-interval"),
+		})
+		if err != nil {
+			return fmt.Errorf("transaction %s not confirmed: %w", sig, err)
+		}
+		if status := statuses[sig]; status.Err != nil {
+			return fmt.Errorf("transaction %s failed: %v", sig, status.Err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Mint: %s\n", workflow.Mint)
+		fmt.Fprintf(out, "Multisig (%d of %d): %s\n", m, n, workflow.Multisig)
+		fmt.Fprintf(out, "Transaction: %s\n", sig)
+		return nil
+	},
+}
+
+// parseMultisigSpec parses an M-of-N multisig, written as "MofN" (e.g. "2of3").
+func parseMultisigSpec(in string) (m int, n int, err error) {
+	if in == "" {
+		return 0, 0, fmt.Errorf("--multisig is required")
+	}
+	parts := strings.Split(strings.ToLower(in), "of")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid multisig %q, expected MofN (e.g. 2of3)", in)
+	}
+	m, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid multisig %q: %w", in, err)
+	}
+	n, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid multisig %q: %w", in, err)
+	}
+	if m < 1 || m > n || n > token.MAX_SIGNERS {
+		return 0, 0, fmt.Errorf("invalid multisig %q: 1 <= M <= N <= %d is required", in, token.MAX_SIGNERS)
+	}
+	return m, n, nil
+}
+
+// parseSignerKeys returns the public keys of the signers, given as public keys or keypair files.
+func parseSignerKeys(in []string) ([]solana.PublicKey, error) {
+	keys := make([]solana.PublicKey, len(in))
+	for i, signer := range in {
+		if key, err := solana.PublicKeyFromBase58(signer); err == nil {
+			keys[i] = key
+			continue
+		}
+		privateKey, err := readKeypairFile(signer)
+		if err != nil {
+			return nil, fmt.Errorf("--signer %q is neither a public key nor a keypair file: %w", signer, err)
+		}
+		keys[i] = privateKey.PublicKey()
+	}
+	return keys, nil
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenCreateMintCmd)
+
+	tokenCreateMintCmd.Flags().String("multisig", "", "The multisig mint authority, as MofN (e.g. 2of3)")
+	tokenCreateMintCmd.Flags().StringSlice("signer", []string{}, "Keypair file or public key of a signer of the multisig (repeat N times)")
+	tokenCreateMintCmd.Flags().String("payer", "", "Keypair file (as written by solana-keygen) of the payer")
+	tokenCreateMintCmd.Flags().Uint("decimals", 9, "Number of decimals of the token")
+	tokenCreateMintCmd.Flags().Duration("timeout", time.Minute, "Maximum time to wait for the confirmation")
+	tokenCreateMintCmd.Flags().Duration("poll-interval", 500*time.Millisecond, "Interval between the checks of the confirmation")
+}
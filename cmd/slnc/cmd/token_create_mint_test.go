@@ -0,0 +1,116 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/solanatest"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runTokenCreateMintCmd(t *testing.T, args ...string) (stdout string, err error) {
+	tokenCreateMintCmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			require.NoError(t, slice.Replace(nil))
+		} else {
+			require.NoError(t, f.Value.Set(f.DefValue))
+		}
+		f.Changed = false
+	})
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetErr(new(bytes.Buffer))
+	RootCmd.SetArgs(append([]string{"token", "create-mint"}, args...))
+	err = RootCmd.Execute()
+	return out.String(), err
+}
+
+func TestTokenCreateMintCmd(t *testing.T) {
+	payer := solanatest.KeypairFromSeedString("payer")
+	alice := solanatest.KeypairFromSeedString("alice")
+	bob := solanatest.KeypairFromSeedString("bob")
+	carol := solanatest.PublicKeyFromSeedString("carol")
+	sig := solana.Signature{1, 2, 3}
+
+	var rents []string
+	url := mockRPCMethods(t, func(method string, call int) string {
+		switch method {
+		case "getMinimumBalanceForRentExemption":
+			rents = append(rents, method)
+			return `"result":1461600`
+		case "getLatestBlockhash":
+			return fmt.Sprintf(`"result":{"context":{"slot":1},"value":{"blockhash":%q,"lastValidBlockHeight":100}}`, solana.Hash{7})
+		case "sendTransaction":
+			return fmt.Sprintf(`"result":%q`, sig)
+		case "getSignatureStatuses":
+			return `"result":{"context":{"slot":2},"value":[{"slot":2,"confirmations":0,"err":null,"confirmationStatus":"confirmed"}]}`
+		}
+		return `"error":{"code":-32601,"message":"Method not found"}`
+	})
+
+	// The signers are keypair files or public keys.
+	signers := []string{
+		"--signer", writeKeypairFile(t, alice),
+		"--signer", writeKeypairFile(t, bob),
+		"--signer", carol.String(),
+	}
+	out, err := runTokenCreateMintCmd(t, append([]string{
+		"--multisig", "2of3",
+		"--payer", writeKeypairFile(t, payer),
+		"--decimals", "6",
+		"--poll-interval", "1ms",
+		"-u", url,
+	}, signers...)...)
+	require.NoError(t, err)
+	assert.Len(t, rents, 2)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "Mint: "), out)
+	assert.True(t, strings.HasPrefix(lines[1], "Multisig (2 of 3): "), out)
+	assert.Equal(t, "Transaction: "+sig.String(), lines[2])
+
+	_, err = runTokenCreateMintCmd(t, append([]string{"--multisig", "2of2", "--payer", writeKeypairFile(t, payer), "-u", url}, signers...)...)
+	assert.EqualError(t, err, "a 2of2 multisig requires 2 --signer, got 3")
+	_, err = runTokenCreateMintCmd(t, append([]string{"--multisig", "4of3", "--payer", writeKeypairFile(t, payer), "-u", url}, signers...)...)
+	assert.EqualError(t, err, `invalid multisig "4of3": 1 <= M <= N <= 11 is required`)
+	_, err = runTokenCreateMintCmd(t, append([]string{"--multisig", "2of3", "-u", url}, signers...)...)
+	assert.EqualError(t, err, "--payer is required")
+	_, err = runTokenCreateMintCmd(t, "--multisig", "2of3", "--payer", writeKeypairFile(t, payer), "-u", url,
+		"--signer", alice.PublicKey().String(), "--signer", alice.PublicKey().String(), "--signer", carol.String())
+	assert.EqualError(t, err, "duplicate multisig signer "+alice.PublicKey().String())
+}
+
+func TestParseMultisigSpec(t *testing.T) {
+	for in, expected := range map[string][2]int{
+		"2of3":   {2, 3},
+		"1OF1":   {1, 1},
+		"11of11": {11, 11},
+	} {
+		m, n, err := parseMultisigSpec(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, expected, [2]int{m, n}, in)
+	}
+	for _, in := range []string{"", "2", "3of2", "0of3", "2of12", "aofb", "2of3of4", "-1of2"} {
+		_, _, err := parseMultisigSpec(in)
+		assert.Error(t, err, in)
+	}
+}
@@ -19,9 +19,11 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 )
@@ -57,7 +59,7 @@ var tokenGetMintCmd = &cobra.Command{
 
 		var out []string
 
-		out = append(out, fmt.Sprintf("Supply | %d", mint.Supply))
+		out = append(out, fmt.Sprintf("Supply | %s", format.TokenAmount(strconv.FormatUint(mint.Supply, 10), mint.Decimals)))
 		out = append(out, fmt.Sprintf("Decimals | %d", mint.Decimals))
 
 		if mint.MintAuthority != nil {
@@ -19,9 +19,11 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/text/format"
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 )
@@ -39,7 +41,7 @@ var tokenListMintsCmd = &cobra.Command{
 		out := []string{"Mint | Decimals | Supply | Token Authority | Freeze Authority"}
 		for _, m := range mints {
 			line := []string{
-				fmt.Sprintf("%d", m.Supply),
+				format.TokenAmount(strconv.FormatUint(m.Supply, 10), m.Decimals),
 				fmt.Sprintf("%d", m.Decimals),
 			}
 			if m.MintAuthority != nil {
@@ -0,0 +1,86 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+)
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Build, sign, combine and submit transactions offline",
+	Long: `Build, sign, combine and submit transactions offline.
+
+The transactions are exchanged as files holding their base64 serialization,
+so that each signer can sign a copy on their own (possibly air-gapped) machine:
+
+  slnc tx build --file transfer.yaml --out tx.b64
+  slnc tx sign --keypair alice.json --in tx.b64 --out tx.alice.b64
+  slnc tx sign --keypair bob.json --in tx.b64 --out tx.bob.b64
+  slnc tx combine tx.alice.b64 tx.bob.b64 --out tx.signed.b64
+  slnc tx submit --in tx.signed.b64`,
+}
+
+// readTransactionFile reads a base64 transaction from the file.
+func readTransactionFile(file string) (*solana.Transaction, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read transaction: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode base64 transaction from %q: %w", file, err)
+	}
+	tx, err := solana.TransactionFromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction from %q: %w", file, err)
+	}
+	return tx, nil
+}
+
+// writeTransaction writes the transaction in base64, with a zero signature
+// for each missing signer, to the file, or to the output of the command
+// when the file is empty.
+func writeTransaction(cmd *cobra.Command, file string, tx *solana.Transaction) error {
+	out, err := tx.ToBase64(solana.EncodeAllowUnsigned())
+	if err != nil {
+		return fmt.Errorf("unable to encode transaction: %w", err)
+	}
+	if file == "" {
+		fmt.Fprintln(cmd.OutOrStdout(), out)
+		return nil
+	}
+	if err := ioutil.WriteFile(file, []byte(out+"\n"), 0644); err != nil {
+		return fmt.Errorf("unable to write transaction: %w", err)
+	}
+	return nil
+}
+
+// printMissingSigners lists the signers that still have to sign the transaction on stderr.
+func printMissingSigners(cmd *cobra.Command, tx *solana.Transaction) {
+	if missing := tx.MissingSigners(); len(missing) > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Partially signed; missing signatures of: %s\n", solana.PublicKeySlice(missing).ToBase58())
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(txCmd)
+}
@@ -0,0 +1,277 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build an unsigned transaction, and write it in base64",
+	Long: `Build an unsigned transaction, and write it in base64.
+
+The instructions are read from a YAML or JSON description file (see --file):
+
+  payer: 7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932
+  nonceAccount: 9DRhhoLGCCZTqPEwfQYjStfGAFQGpRHZgPjLNbXiK7an
+  instructions:
+    - programId: memo
+      accounts:
+        - pubkey: 7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932
+          signer: true
+          writable: false
+      data: aGVsbG8= # base64
+
+or, for a simple SOL transfer, from the --from, --to and --amount flags.
+The program of an instruction is an address or the name of a well-known
+program (e.g. "system", "token" or "memo").
+
+The transaction uses a durable nonce when a nonce account is given
+(the advance-nonce instruction is prepended); otherwise it uses the blockhash
+of --blockhash, or the latest blockhash of the cluster. As a durable nonce
+doesn't expire, the transaction can be signed offline at leisure.
+With both --nonce-account and --blockhash (the current nonce),
+the transaction is built without any RPC request.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var desc txDescription
+		if file := viper.GetString("tx-build-cmd-file"); file != "" {
+			var err error
+			desc, err = readTxDescription(file)
+			if err != nil {
+				return err
+			}
+		}
+		for key, field := range map[string]*string{
+			"tx-build-cmd-payer":           &desc.Payer,
+			"tx-build-cmd-blockhash":       &desc.Blockhash,
+			"tx-build-cmd-nonce-account":   &desc.NonceAccount,
+			"tx-build-cmd-nonce-authority": &desc.NonceAuthority,
+		} {
+			if value := viper.GetString(key); value != "" {
+				*field = value
+			}
+		}
+
+		instructions, err := desc.instructions()
+		if err != nil {
+			return err
+		}
+		if from := viper.GetString("tx-build-cmd-from"); from != "" {
+			transfer, err := transferInstruction(from, viper.GetString("tx-build-cmd-to"), viper.GetString("tx-build-cmd-amount"))
+			if err != nil {
+				return err
+			}
+			instructions = append(instructions, transfer)
+			if desc.Payer == "" {
+				desc.Payer = from
+			}
+		}
+		if len(instructions) == 0 {
+			return fmt.Errorf("no instructions: either --file, or --from, --to and --amount are required")
+		}
+		if desc.Payer == "" {
+			return fmt.Errorf("--payer is required")
+		}
+		payer, err := solana.PublicKeyFromBase58(desc.Payer)
+		if err != nil {
+			return fmt.Errorf("invalid payer %q: %w", desc.Payer, err)
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		blockhash, advanceNonce, err := desc.recentBlockhash(ctx, payer)
+		if err != nil {
+			return err
+		}
+		if advanceNonce != nil {
+			instructions = append([]solana.Instruction{advanceNonce}, instructions...)
+		}
+
+		tx, err := solana.NewTransaction(instructions, blockhash, solana.TransactionPayer(payer))
+		if err != nil {
+			return fmt.Errorf("unable to build transaction: %w", err)
+		}
+		return writeTransaction(cmd, viper.GetString("tx-build-cmd-out"), tx)
+	},
+}
+
+// txDescription is the description of a transaction read by "slnc tx build".
+type txDescription struct {
+	Payer          string                     `mapstructure:"payer"`
+	Blockhash      string                     `mapstructure:"blockhash"`
+	NonceAccount   string                     `mapstructure:"nonceAccount"`
+	NonceAuthority string                     `mapstructure:"nonceAuthority"`
+	Instructions   []txDescriptionInstruction `mapstructure:"instructions"`
+}
+
+type txDescriptionInstruction struct {
+	ProgramID string `mapstructure:"programId"`
+	Accounts  []struct {
+		Pubkey   string `mapstructure:"pubkey"`
+		Signer   bool   `mapstructure:"signer"`
+		Writable bool   `mapstructure:"writable"`
+	} `mapstructure:"accounts"`
+	// The base64 data of the instruction.
+	Data string `mapstructure:"data"`
+}
+
+// readTxDescription reads a YAML or JSON transaction description;
+// the format is given by the extension of the file.
+func readTxDescription(file string) (txDescription, error) {
+	var desc txDescription
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return desc, fmt.Errorf("unable to read transaction description: %w", err)
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	switch format {
+	case "yaml", "yml", "json":
+	default:
+		return desc, fmt.Errorf("unsupported transaction description format %q: expected a .yaml, .yml or .json file", format)
+	}
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return desc, fmt.Errorf("unable to parse transaction description %q: %w", file, err)
+	}
+	if err := v.Unmarshal(&desc); err != nil {
+		return desc, fmt.Errorf("unable to parse transaction description %q: %w", file, err)
+	}
+	return desc, nil
+}
+
+func (desc txDescription) instructions() ([]solana.Instruction, error) {
+	var out []solana.Instruction
+	for i, inst := range desc.Instructions {
+		programID, err := parseProgramID(inst.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: invalid program: %w", i, err)
+		}
+		accounts := make(solana.AccountMetaSlice, len(inst.Accounts))
+		for j, account := range inst.Accounts {
+			key, err := solana.PublicKeyFromBase58(account.Pubkey)
+			if err != nil {
+				return nil, fmt.Errorf("instruction %d: invalid account %d %q: %w", i, j, account.Pubkey, err)
+			}
+			accounts[j] = solana.NewAccountMeta(key, account.Writable, account.Signer)
+		}
+		data, err := base64.StdEncoding.DecodeString(inst.Data)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: invalid base64 data: %w", i, err)
+		}
+		out = append(out, solana.NewInstruction(programID, accounts, data))
+	}
+	return out, nil
+}
+
+// recentBlockhash returns the blockhash of the transaction, and the advance-nonce
+// instruction to prepend to the instructions when the transaction uses a durable nonce.
+func (desc txDescription) recentBlockhash(ctx context.Context, payer solana.PublicKey) (solana.Hash, solana.Instruction, error) {
+	var blockhash solana.Hash
+	if desc.Blockhash != "" {
+		var err error
+		blockhash, err = solana.HashFromBase58(desc.Blockhash)
+		if err != nil {
+			return blockhash, nil, fmt.Errorf("invalid blockhash %q: %w", desc.Blockhash, err)
+		}
+	}
+
+	if desc.NonceAccount == "" {
+		if blockhash.IsZero() {
+			latest, err := getClient().GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+			if err != nil {
+				return blockhash, nil, fmt.Errorf("unable to get the latest blockhash: %w", err)
+			}
+			blockhash = latest.Value.Blockhash
+		}
+		return blockhash, nil, nil
+	}
+
+	nonceAccount, err := solana.PublicKeyFromBase58(desc.NonceAccount)
+	if err != nil {
+		return blockhash, nil, fmt.Errorf("invalid nonce account %q: %w", desc.NonceAccount, err)
+	}
+	authority := payer
+	if blockhash.IsZero() {
+		var nonce system.NonceAccount
+		if err := getClient().GetAccountDataInto(ctx, nonceAccount, &nonce); err != nil {
+			return blockhash, nil, fmt.Errorf("unable to get nonce account %s: %w", nonceAccount, err)
+		}
+		if nonce.State != 1 {
+			return blockhash, nil, fmt.Errorf("nonce account %s is not initialized", nonceAccount)
+		}
+		blockhash = solana.Hash(nonce.Nonce)
+		authority = nonce.AuthorizedPubkey
+	}
+	if desc.NonceAuthority != "" {
+		authority, err = solana.PublicKeyFromBase58(desc.NonceAuthority)
+		if err != nil {
+			return blockhash, nil, fmt.Errorf("invalid nonce authority %q: %w", desc.NonceAuthority, err)
+		}
+	}
+	advanceNonce := system.NewAdvanceNonceAccountInstruction(nonceAccount, solana.SysVarRecentBlockHashesPubkey, authority).Build()
+	return blockhash, advanceNonce, nil
+}
+
+// transferInstruction returns the instruction of a transfer of the amount of SOL (e.g. 1.5).
+func transferInstruction(from, to, amount string) (solana.Instruction, error) {
+	fromKey, err := solana.PublicKeyFromBase58(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --from address %q: %w", from, err)
+	}
+	if to == "" {
+		return nil, fmt.Errorf("--to is required with --from")
+	}
+	toKey, err := solana.PublicKeyFromBase58(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --to address %q: %w", to, err)
+	}
+	lamports, err := token.ParseUiAmount(amount, solDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOL amount %q: %w", amount, err)
+	}
+	return system.NewTransferInstruction(lamports, fromKey, toKey).Build(), nil
+}
+
+func init() {
+	txCmd.AddCommand(txBuildCmd)
+
+	txBuildCmd.Flags().StringP("file", "f", "", "YAML or JSON description of the transaction")
+	txBuildCmd.Flags().String("payer", "", "Fee payer (default: the payer of the description, or --from)")
+	txBuildCmd.Flags().String("from", "", "Sender of a SOL transfer")
+	txBuildCmd.Flags().String("to", "", "Recipient of a SOL transfer")
+	txBuildCmd.Flags().String("amount", "", "Amount of SOL of the transfer (e.g. 1.5)")
+	txBuildCmd.Flags().String("blockhash", "", "Recent blockhash of the transaction, or the current nonce with --nonce-account")
+	txBuildCmd.Flags().String("nonce-account", "", "Nonce account of a durable-nonce transaction")
+	txBuildCmd.Flags().String("nonce-authority", "", "Authority of the nonce account (default: the authority of the fetched nonce account, or the payer)")
+	txBuildCmd.Flags().String("out", "", "File to write the base64 transaction to (default: stdout)")
+}
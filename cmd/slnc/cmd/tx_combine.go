@@ -0,0 +1,59 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txCombineCmd = &cobra.Command{
+	Use:   "combine {tx-file} {tx-file}...",
+	Short: "Merge the signatures of partially signed copies of a transaction",
+	Long: `Merge the signatures of partially signed copies of a transaction.
+
+All the copies must be of the exact same message (e.g. signed from the same
+"slnc tx build" output), and all their signatures must be valid.
+The missing signers are listed on stderr.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		txs := make([]*solana.Transaction, len(args))
+		for i, file := range args {
+			tx, err := readTransactionFile(file)
+			if err != nil {
+				return err
+			}
+			txs[i] = tx
+		}
+		tx, err := solana.CombineSignatures(txs...)
+		if err != nil {
+			return fmt.Errorf("unable to combine signatures: %w", err)
+		}
+		if err := writeTransaction(cmd, viper.GetString("tx-combine-cmd-out"), tx); err != nil {
+			return err
+		}
+		printMissingSigners(cmd, tx)
+		return nil
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txCombineCmd)
+
+	txCombineCmd.Flags().String("out", "", "File to write the combined base64 transaction to (default: stdout)")
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Partially sign a transaction file offline",
+	Long: `Partially sign a transaction file offline.
+
+The signature is set in the slot of the signer, and the signatures
+of the other signers are kept. No RPC request is made.
+The missing signers are listed on stderr.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		privateKey, err := readKeypairFile(viper.GetString("tx-sign-cmd-keypair"))
+		if err != nil {
+			return err
+		}
+		in := viper.GetString("tx-sign-cmd-in")
+		if in == "" {
+			return fmt.Errorf("--in is required")
+		}
+		tx, err := readTransactionFile(in)
+		if err != nil {
+			return err
+		}
+		if err := addSignature(tx, privateKey); err != nil {
+			return err
+		}
+		if err := writeTransaction(cmd, viper.GetString("tx-sign-cmd-out"), tx); err != nil {
+			return err
+		}
+		printMissingSigners(cmd, tx)
+		return nil
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txSignCmd)
+
+	txSignCmd.Flags().StringP("keypair", "k", "", "Keypair file (as written by solana-keygen) of the signer")
+	txSignCmd.Flags().String("in", "", "File of the base64 transaction to sign")
+	txSignCmd.Flags().String("out", "", "File to write the signed base64 transaction to (default: stdout)")
+}
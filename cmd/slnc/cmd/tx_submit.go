@@ -0,0 +1,66 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txSubmitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Send a fully signed transaction file, and print its signature",
+	Long: `Send a fully signed transaction file, and print its signature.
+
+The transaction is not sent if any signature is missing or invalid.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in := viper.GetString("tx-submit-cmd-in")
+		if in == "" {
+			return fmt.Errorf("--in is required")
+		}
+		tx, err := readTransactionFile(in)
+		if err != nil {
+			return err
+		}
+		if missing := tx.MissingSigners(); len(missing) > 0 {
+			return fmt.Errorf("%w: %s", solana.ErrMissingSignatures, solana.PublicKeySlice(missing).ToBase58())
+		}
+		if err := tx.VerifySignatures(); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		sig, err := getClient().SendTransaction(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), sig)
+		return nil
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txSubmitCmd)
+
+	txSubmitCmd.Flags().String("in", "", "File of the signed base64 transaction to send")
+}
@@ -0,0 +1,258 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/solanatest"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTxCmd runs the tx subcommand, with its flags reset
+// (the flags keep their values between executions of the command).
+func runTxCmd(t *testing.T, args ...string) (stdout string, stderr string, err error) {
+	for _, cmd := range txCmd.Commands() {
+		cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+			require.NoError(t, f.Value.Set(f.DefValue))
+			f.Changed = false
+		})
+	}
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetErr(errOut)
+	RootCmd.SetArgs(append([]string{"tx"}, args...))
+	err = RootCmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func readTxFile(t *testing.T, file string) *solana.Transaction {
+	tx, err := readTransactionFile(file)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestTxCmd_multisig(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	payer := solanatest.KeypairFromSeedString("payer")
+	treasury := solanatest.KeypairFromSeedString("treasury")
+	recipient := solanatest.PublicKeyFromSeedString("recipient")
+	nonceAccount := solanatest.PublicKeyFromSeedString("nonce account")
+	nonce := solana.Hash{1, 2, 3}
+
+	// Offline: with the current nonce, no RPC request is made.
+	_, _, err := runTxCmd(t, "build",
+		"--from", treasury.PublicKey().String(),
+		"--to", recipient.String(),
+		"--amount", "1.5",
+		"--payer", payer.PublicKey().String(),
+		"--nonce-account", nonceAccount.String(),
+		"--blockhash", nonce.String(),
+		"--out", path("tx.b64"),
+		"-u", "http://127.0.0.1:1",
+	)
+	require.NoError(t, err)
+	unsigned := readTxFile(t, path("tx.b64"))
+	assert.Equal(t, nonce, unsigned.Message.RecentBlockhash)
+	assert.Equal(t, []solana.PublicKey{payer.PublicKey(), treasury.PublicKey()}, unsigned.MissingSigners())
+	require.Len(t, unsigned.Message.Instructions, 2)
+	advance, err := unsigned.Message.ResolveProgramIDIndex(unsigned.Message.Instructions[0].ProgramIDIndex)
+	require.NoError(t, err)
+	assert.Equal(t, solana.SystemProgramID, advance)
+	assert.Equal(t, []byte{4, 0, 0, 0}, []byte(unsigned.Message.Instructions[0].Data), "AdvanceNonceAccount")
+
+	// Each signer signs a copy.
+	_, stderr, err := runTxCmd(t, "sign", "--keypair", writeKeypairFile(t, payer), "--in", path("tx.b64"), "--out", path("tx.payer.b64"))
+	require.NoError(t, err)
+	assert.Contains(t, stderr, treasury.PublicKey().String())
+	_, stderr, err = runTxCmd(t, "sign", "-k", writeKeypairFile(t, treasury), "--in", path("tx.b64"), "--out", path("tx.treasury.b64"))
+	require.NoError(t, err)
+	assert.Contains(t, stderr, payer.PublicKey().String())
+
+	_, _, err = runTxCmd(t, "sign", "--keypair", writeKeypairFile(t, solanatest.KeypairFromSeedString("other")), "--in", path("tx.b64"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a required signer")
+
+	// A partially signed transaction is not sent.
+	_, _, err = runTxCmd(t, "submit", "--in", path("tx.payer.b64"), "-u", "http://127.0.0.1:1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), treasury.PublicKey().String())
+
+	out, stderr, err := runTxCmd(t, "combine", path("tx.treasury.b64"), path("tx.payer.b64"), "--out", path("tx.signed.b64"))
+	require.NoError(t, err)
+	assert.Empty(t, out)
+	assert.Empty(t, stderr)
+	signed := readTxFile(t, path("tx.signed.b64"))
+	require.NoError(t, signed.VerifySignatures())
+
+	var sent []string
+	url := mockRPCMethods(t, func(method string, call int) string {
+		sent = append(sent, method)
+		return fmt.Sprintf(`"result":%q`, signed.Signatures[0])
+	})
+	out, _, err = runTxCmd(t, "submit", "--in", path("tx.signed.b64"), "-u", url)
+	require.NoError(t, err)
+	assert.Equal(t, signed.Signatures[0].String(), strings.TrimSpace(out))
+	assert.Equal(t, []string{"sendTransaction"}, sent)
+
+	t.Run("combine copies of different transactions", func(t *testing.T) {
+		_, _, err := runTxCmd(t, "build",
+			"--from", treasury.PublicKey().String(),
+			"--to", recipient.String(),
+			"--amount", "2",
+			"--payer", payer.PublicKey().String(),
+			"--nonce-account", nonceAccount.String(),
+			"--blockhash", nonce.String(),
+			"--out", path("other.b64"),
+		)
+		require.NoError(t, err)
+		_, _, err = runTxCmd(t, "combine", path("tx.payer.b64"), path("other.b64"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "different message")
+	})
+
+	t.Run("description file", func(t *testing.T) {
+		data, err := system.NewTransferInstruction(1500000000, treasury.PublicKey(), recipient).Build().Data()
+		require.NoError(t, err)
+		description := fmt.Sprintf(`
+payer: %s
+nonceAccount: %s
+blockhash: %s
+instructions:
+  - programId: system
+    accounts:
+      - pubkey: %s
+        signer: true
+        writable: true
+      - pubkey: %s
+        writable: true
+    data: %s
+`, payer.PublicKey(), nonceAccount, nonce, treasury.PublicKey(), recipient, base64.StdEncoding.EncodeToString(data))
+		require.NoError(t, ioutil.WriteFile(path("tx.yaml"), []byte(description), 0644))
+
+		out, _, err := runTxCmd(t, "build", "--file", path("tx.yaml"))
+		require.NoError(t, err)
+		expected, err := ioutil.ReadFile(path("tx.b64"))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), out)
+
+		require.NoError(t, ioutil.WriteFile(path("tx.json"), []byte(fmt.Sprintf(
+			`{"payer":%q,"nonceAccount":%q,"blockhash":%q,"instructions":[{"programId":%q,"accounts":[{"pubkey":%q,"signer":true,"writable":true},{"pubkey":%q,"writable":true}],"data":%q}]}`,
+			payer.PublicKey(), nonceAccount, nonce, solana.SystemProgramID, treasury.PublicKey(), recipient, base64.StdEncoding.EncodeToString(data),
+		)), 0644))
+		out, _, err = runTxCmd(t, "build", "-f", path("tx.json"))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), out)
+	})
+}
+
+func TestTxBuildCmd_fetchBlockhash(t *testing.T) {
+	payer := solanatest.PublicKeyFromSeedString("payer")
+	recipient := solanatest.PublicKeyFromSeedString("recipient")
+	nonceAccount := solanatest.PublicKeyFromSeedString("nonce account")
+	nonceAuthority := solanatest.PublicKeyFromSeedString("nonce authority")
+	nonce := solana.Hash{4, 5, 6}
+	latest := solana.Hash{7, 8, 9}
+	nonceData, err := bin.MarshalBin(&system.NonceAccount{
+		State:            1,
+		AuthorizedPubkey: nonceAuthority,
+		Nonce:            solana.PublicKey(nonce),
+	})
+	require.NoError(t, err)
+	url := mockRPCMethods(t, func(method string, call int) string {
+		switch method {
+		case "getLatestBlockhash":
+			return fmt.Sprintf(`"result":{"context":{"slot":1},"value":{"blockhash":%q,"lastValidBlockHeight":100}}`, latest)
+		case "getAccountInfo":
+			return fmt.Sprintf(`"result":{"context":{"slot":1},"value":%s}`, solanatest.AccountJSON(&rpc.Account{
+				Lamports: 1447680,
+				Owner:    solana.SystemProgramID,
+				Data:     rpc.DataBytesOrJSONFromBytes(nonceData),
+			}))
+		}
+		return `"error":{"code":-32601,"message":"Method not found"}`
+	})
+	build := func(args ...string) *solana.Transaction {
+		out, _, err := runTxCmd(t, append([]string{"build",
+			"--from", payer.String(),
+			"--to", recipient.String(),
+			"--amount", "0.001",
+			"-u", url,
+		}, args...)...)
+		require.NoError(t, err)
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+		require.NoError(t, err)
+		tx, err := solana.TransactionFromBytes(raw)
+		require.NoError(t, err)
+		return tx
+	}
+
+	t.Run("latest blockhash", func(t *testing.T) {
+		tx := build()
+		assert.Equal(t, latest, tx.Message.RecentBlockhash)
+		assert.Len(t, tx.Message.Instructions, 1)
+		assert.Equal(t, []solana.PublicKey{payer}, tx.MissingSigners())
+	})
+
+	t.Run("pinned blockhash", func(t *testing.T) {
+		tx := build("--blockhash", nonce.String())
+		assert.Equal(t, nonce, tx.Message.RecentBlockhash)
+	})
+
+	t.Run("nonce account", func(t *testing.T) {
+		tx := build("--nonce-account", nonceAccount.String())
+		assert.Equal(t, nonce, tx.Message.RecentBlockhash)
+		require.Len(t, tx.Message.Instructions, 2)
+		accounts, err := tx.Message.Instructions[0].ResolveInstructionAccounts(&tx.Message)
+		require.NoError(t, err)
+		require.Len(t, accounts, 3)
+		assert.Equal(t, nonceAccount, accounts[0].PublicKey)
+		assert.Equal(t, nonceAuthority, accounts[2].PublicKey)
+		// The nonce authority of the account must sign too.
+		assert.Equal(t, []solana.PublicKey{payer, nonceAuthority}, tx.MissingSigners())
+	})
+}
+
+func TestTxBuildCmd_errors(t *testing.T) {
+	dir := t.TempDir()
+	_, _, err := runTxCmd(t, "build", "--payer", solanatest.PublicKeyFromSeedString("payer").String())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no instructions")
+
+	file := filepath.Join(dir, "tx.toml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`payer = "x"`), 0644))
+	_, _, err = runTxCmd(t, "build", "--file", file)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported transaction description format")
+
+	file = filepath.Join(dir, "tx.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("instructions:\n  - programId: nope\n"), 0644))
+	_, _, err = runTxCmd(t, "build", "--file", file, "--payer", solanatest.PublicKeyFromSeedString("payer").String())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "instruction 0: invalid program")
+}
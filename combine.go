@@ -0,0 +1,71 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CombineSignatures merges the signatures of copies of the same transaction,
+// each one signed by some of the signers (e.g. with PartialSign, on different machines),
+// into a new transaction. The copies must have the exact same message,
+// and each of their signatures must be valid; a copy can have no signatures at all.
+// The returned transaction has a slot for each required signature,
+// zero for the signatures that are still missing (see Transaction.MissingSigners).
+func CombineSignatures(txs ...*Transaction) (*Transaction, error) {
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("no transactions to combine")
+	}
+	message, err := txs[0].Message.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode the message of transaction 0: %w", err)
+	}
+	numRequired := int(txs[0].Message.Header.NumRequiredSignatures)
+	if numRequired > len(txs[0].Message.AccountKeys) {
+		return nil, fmt.Errorf("transaction requires %d signatures, but has only %d account keys", numRequired, len(txs[0].Message.AccountKeys))
+	}
+	signers := txs[0].Message.AccountKeys[:numRequired]
+
+	out := &Transaction{
+		Signatures:           make([]Signature, numRequired),
+		Message:              txs[0].Message,
+		LastValidBlockHeight: txs[0].LastValidBlockHeight,
+	}
+	for i, tx := range txs {
+		if i > 0 {
+			other, err := tx.Message.MarshalBinary()
+			if err != nil {
+				return nil, fmt.Errorf("unable to encode the message of transaction %d: %w", i, err)
+			}
+			if !bytes.Equal(message, other) {
+				return nil, fmt.Errorf("transaction %d has a different message than transaction 0", i)
+			}
+		}
+		if len(tx.Signatures) != 0 && len(tx.Signatures) != numRequired {
+			return nil, fmt.Errorf("transaction %d has %d signatures, expected %d", i, len(tx.Signatures), numRequired)
+		}
+		for slot, signature := range tx.Signatures {
+			if signature.IsZero() {
+				continue
+			}
+			if !signature.Verify(signers[slot], message) {
+				return nil, fmt.Errorf("transaction %d has an invalid signature for %s", i, signers[slot])
+			}
+			out.Signatures[slot] = signature
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type multisigFixture struct {
+	keys []PrivateKey
+	// The unsigned transaction, serialized with a zero signature per signer.
+	unsigned []byte
+}
+
+// newMultisigFixture returns a transaction that requires the signatures
+// of a payer and two co-signers.
+func newMultisigFixture(t *testing.T) *multisigFixture {
+	f := &multisigFixture{}
+	for i := 0; i < 3; i++ {
+		f.keys = append(f.keys, NewWallet().PrivateKey)
+	}
+	tx, err := NewTransaction(
+		[]Instruction{
+			NewInstruction(
+				SystemProgramID,
+				AccountMetaSlice{
+					Meta(f.keys[1].PublicKey()).SIGNER().WRITE(),
+					Meta(f.keys[2].PublicKey()).SIGNER(),
+				},
+				[]byte{1, 2, 3},
+			),
+		},
+		MustHashFromBase58("dv4ACNkpYPcE3aKmYDqZm9G5EB3J4MRoeE7WNDRBVJB"),
+		TransactionPayer(f.keys[0].PublicKey()),
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint8(3), tx.Message.Header.NumRequiredSignatures)
+	// As exported for offline signing: with a zero signature per signer.
+	tx.Signatures = make([]Signature, 3)
+	f.unsigned, err = tx.MarshalBinary()
+	require.NoError(t, err)
+	return f
+}
+
+// signedBy decodes a copy of the transaction, and signs it with the keys.
+func (f *multisigFixture) signedBy(t *testing.T, keys ...PrivateKey) *Transaction {
+	tx, err := TransactionFromBytes(f.unsigned)
+	require.NoError(t, err)
+	_, err = tx.PartialSign(func(key PublicKey) *PrivateKey {
+		for i := range keys {
+			if keys[i].PublicKey().Equals(key) {
+				return &keys[i]
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestCombineSignatures(t *testing.T) {
+	f := newMultisigFixture(t)
+	payer := f.signedBy(t, f.keys[0])
+	first := f.signedBy(t, f.keys[1])
+	second := f.signedBy(t, f.keys[2])
+
+	combined, err := CombineSignatures(payer, first, second)
+	require.NoError(t, err)
+	require.Len(t, combined.Signatures, 3)
+	require.NoError(t, combined.VerifySignatures())
+	assert.Empty(t, combined.MissingSigners())
+	assert.Equal(t, payer.Signatures[0], combined.Signatures[0])
+	assert.Equal(t, first.Signatures[1], combined.Signatures[1])
+	assert.Equal(t, second.Signatures[2], combined.Signatures[2])
+
+	// The inputs are not modified.
+	assert.Equal(t, []PublicKey{f.keys[1].PublicKey(), f.keys[2].PublicKey()}, payer.MissingSigners())
+
+	t.Run("any order", func(t *testing.T) {
+		reordered, err := CombineSignatures(second, payer, first)
+		require.NoError(t, err)
+		assert.Equal(t, combined.Signatures, reordered.Signatures)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		partial, err := CombineSignatures(first, payer)
+		require.NoError(t, err)
+		require.Len(t, partial.Signatures, 3)
+		assert.Equal(t, []PublicKey{f.keys[2].PublicKey()}, partial.MissingSigners())
+		assert.True(t, partial.Signatures[2].IsZero())
+	})
+
+	t.Run("overlapping", func(t *testing.T) {
+		// The same signatures in several copies are merged.
+		both := f.signedBy(t, f.keys[0], f.keys[1])
+		merged, err := CombineSignatures(both, payer, first, second)
+		require.NoError(t, err)
+		assert.Equal(t, combined.Signatures, merged.Signatures)
+	})
+
+	t.Run("copy without signatures", func(t *testing.T) {
+		unsigned, err := TransactionFromBytes(f.unsigned)
+		require.NoError(t, err)
+		unsigned.Signatures = nil
+		merged, err := CombineSignatures(unsigned, first)
+		require.NoError(t, err)
+		assert.Equal(t, []PublicKey{f.keys[0].PublicKey(), f.keys[2].PublicKey()}, merged.MissingSigners())
+	})
+
+	t.Run("different message", func(t *testing.T) {
+		other := f.signedBy(t, f.keys[2])
+		other.Message.RecentBlockhash = MustHashFromBase58("9L8FEB81LfZ67ejxpMaaZmC9EmXBpV38dhNaiF9UbzZi")
+		_, err := CombineSignatures(payer, other)
+		require.EqualError(t, err, "transaction 1 has a different message than transaction 0")
+
+		// Even with the same signers and accounts, the instruction data must match.
+		other = f.signedBy(t, f.keys[2])
+		other.Message.Instructions[0].Data = Base58{1, 2, 4}
+		_, err = CombineSignatures(payer, other)
+		require.EqualError(t, err, "transaction 1 has a different message than transaction 0")
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		// A signature of another message, in the slot of the signer.
+		forged := f.signedBy(t, f.keys[1])
+		signature, err := f.keys[1].Sign([]byte("another message"))
+		require.NoError(t, err)
+		forged.Signatures[1] = signature
+		_, err = CombineSignatures(payer, forged)
+		require.EqualError(t, err, "transaction 1 has an invalid signature for "+f.keys[1].PublicKey().String())
+
+		// A valid signature, in the slot of another signer.
+		swapped := f.signedBy(t, f.keys[1])
+		swapped.Signatures[1], swapped.Signatures[2] = swapped.Signatures[2], swapped.Signatures[1]
+		_, err = CombineSignatures(swapped)
+		require.Error(t, err)
+	})
+
+	t.Run("wrong signature count", func(t *testing.T) {
+		short := f.signedBy(t, f.keys[0])
+		short.Signatures = short.Signatures[:1]
+		_, err := CombineSignatures(short, first)
+		require.EqualError(t, err, "transaction 0 has 1 signatures, expected 3")
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		_, err := CombineSignatures()
+		require.Error(t, err)
+	})
+}
+
+func TestTransaction_MissingSigners(t *testing.T) {
+	f := newMultisigFixture(t)
+	all := []PublicKey{f.keys[0].PublicKey(), f.keys[1].PublicKey(), f.keys[2].PublicKey()}
+
+	unsigned, err := TransactionFromBytes(f.unsigned)
+	require.NoError(t, err)
+	assert.Equal(t, all, unsigned.MissingSigners())
+
+	// Without signature slots at all.
+	unsigned.Signatures = nil
+	assert.Equal(t, all, unsigned.MissingSigners())
+
+	assert.Equal(t, all[1:], f.signedBy(t, f.keys[0]).MissingSigners())
+	assert.Empty(t, f.signedBy(t, f.keys...).MissingSigners())
+}
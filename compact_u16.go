@@ -0,0 +1,75 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// ErrInvalidCompactU16 is returned (wrapped) when a compact-u16 value
+// is longer than 3 bytes, overflows a u16, or is not in its canonical (shortest) form.
+var ErrInvalidCompactU16 = errors.New("invalid compact-u16")
+
+const maxCompactU16Size = 3
+
+// DecodeCompactU16 decodes a compact-u16 ("shortvec") value from the start of `data`,
+// and returns the value and the number of bytes it occupies.
+//
+// Unlike bin.DecodeCompactU16, it rejects the encodings that the runtime rejects:
+// values longer than 3 bytes, values greater than math.MaxUint16,
+// and non-canonical encodings (e.g. 0x80 0x00 for 0).
+//
+// See https://github.com/solana-labs/solana/blob/v1.14.10/sdk/program/src/short_vec.rs
+func DecodeCompactU16(data []byte) (value int, size int, err error) {
+	for size < maxCompactU16Size {
+		if size >= len(data) {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		elem := int(data[size])
+		if size > 0 && elem == 0 {
+			return 0, 0, fmt.Errorf("%w: non-canonical encoding", ErrInvalidCompactU16)
+		}
+		value |= (elem & 0x7f) << (size * 7)
+		size++
+		if elem&0x80 == 0 {
+			if value > 0xffff {
+				return 0, 0, fmt.Errorf("%w: value %d overflows u16", ErrInvalidCompactU16, value)
+			}
+			return value, size, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%w: longer than %d bytes", ErrInvalidCompactU16, maxCompactU16Size)
+}
+
+// readCompactU16 reads a compact-u16 value from the decoder (see DecodeCompactU16).
+func readCompactU16(decoder *bin.Decoder) (int, error) {
+	n := decoder.Remaining()
+	if n > maxCompactU16Size {
+		n = maxCompactU16Size
+	}
+	data, err := decoder.Peek(n)
+	if err != nil {
+		return 0, err
+	}
+	value, size, err := DecodeCompactU16(data)
+	if err != nil {
+		return 0, err
+	}
+	return value, decoder.SkipBytes(uint(size))
+}
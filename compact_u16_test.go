@@ -0,0 +1,65 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCompactU16(t *testing.T) {
+	valid := []struct {
+		data  []byte
+		value int
+		size  int
+	}{
+		{[]byte{0x00}, 0, 1},
+		{[]byte{0x7f}, 0x7f, 1},
+		{[]byte{0x80, 0x01}, 0x80, 2},
+		{[]byte{0xff, 0x7f}, 0x3fff, 2},
+		{[]byte{0x80, 0x80, 0x01}, 0x4000, 3},
+		{[]byte{0xff, 0xff, 0x03}, 0xffff, 3},
+		// Trailing bytes are not consumed.
+		{[]byte{0x05, 0xff, 0xff}, 5, 1},
+	}
+	for _, c := range valid {
+		value, size, err := DecodeCompactU16(c.data)
+		require.NoError(t, err, "%x", c.data)
+		require.Equal(t, c.value, value, "%x", c.data)
+		require.Equal(t, c.size, size, "%x", c.data)
+	}
+
+	invalid := [][]byte{
+		// non-canonical
+		{0x80, 0x00},
+		{0xff, 0x80, 0x00},
+		// overflow
+		{0x80, 0x80, 0x04},
+		{0xff, 0xff, 0xff, 0x01},
+		// would be a negative int with an unbounded decoder
+		{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01},
+	}
+	for _, data := range invalid {
+		_, _, err := DecodeCompactU16(data)
+		require.ErrorIs(t, err, ErrInvalidCompactU16, "%x", data)
+	}
+
+	for _, data := range [][]byte{nil, {0x80}, {0x80, 0x80}} {
+		_, _, err := DecodeCompactU16(data)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF, "%x", data)
+	}
+}
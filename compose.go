@@ -0,0 +1,147 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// MaxTransactionSize is the maximum size of a serialized transaction,
+// signatures included: the size of a packet (1280 bytes, the IPv6 minimum MTU)
+// minus the IPv6 and the fragment headers.
+const MaxTransactionSize = 1280 - 40 - 8
+
+// InstructionRange is a range of consecutive instructions,
+// from Start (included) to End (excluded).
+type InstructionRange struct {
+	Start int
+	End   int
+}
+
+type ComposeTransactionsOpts struct {
+	// The ranges of instructions that must be in the same transaction
+	// (e.g. a create account and its initialization);
+	// they must be in order, and must not overlap.
+	AtomicGroups []InstructionRange
+
+	// The maximum size of a serialized transaction.
+	// Defaults to MaxTransactionSize.
+	MaxSize int
+
+	// Options of the transactions, e.g. TransactionAddressTables.
+	// The payer is always the one passed to ComposeTransactions.
+	TransactionOptions []TransactionOption
+}
+
+// ComposeTransactions packs the instructions, in order, into as few transactions
+// as possible: each transaction takes as many of the next instructions as fit
+// in the maximum size, measured on the serialized transaction
+// (so the new accounts and signatures of each instruction are accounted for).
+// The instructions of an atomic group are never split across transactions.
+// The transactions are not signed.
+// An error is returned if an instruction (or atomic group) doesn't fit
+// in a transaction by itself.
+func ComposeTransactions(
+	instructions []Instruction,
+	recentBlockHash Hash,
+	payer PublicKey,
+	opts *ComposeTransactionsOpts,
+) ([]*Transaction, error) {
+	if opts == nil {
+		opts = &ComposeTransactionsOpts{}
+	}
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = MaxTransactionSize
+	}
+	units, err := composeUnits(len(instructions), opts.AtomicGroups)
+	if err != nil {
+		return nil, err
+	}
+	txOpts := append(append([]TransactionOption{}, opts.TransactionOptions...), TransactionPayer(payer))
+	build := func(r InstructionRange) (*Transaction, error) {
+		tx, err := NewTransaction(instructions[r.Start:r.End], recentBlockHash, txOpts...)
+		if err != nil {
+			return nil, err
+		}
+		size, err := serializedSize(tx)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxSize {
+			return nil, fmt.Errorf("transaction size %d exceeds the maximum of %d bytes", size, maxSize)
+		}
+		return tx, nil
+	}
+
+	var out []*Transaction
+	var current *Transaction
+	currentRange := InstructionRange{}
+	for _, unit := range units {
+		if current != nil {
+			candidate, err := build(InstructionRange{Start: currentRange.Start, End: unit.End})
+			if err == nil {
+				current, currentRange.End = candidate, unit.End
+				continue
+			}
+			out = append(out, current)
+		}
+		current, err = build(unit)
+		if err != nil {
+			return nil, fmt.Errorf("instructions %d to %d don't fit in a transaction: %w", unit.Start, unit.End-1, err)
+		}
+		currentRange = unit
+	}
+	if current != nil {
+		out = append(out, current)
+	}
+	return out, nil
+}
+
+// composeUnits splits the instructions into the units of ComposeTransactions:
+// the atomic groups, and each of the other instructions.
+func composeUnits(count int, groups []InstructionRange) ([]InstructionRange, error) {
+	var units []InstructionRange
+	next := 0
+	for _, group := range groups {
+		if group.Start < next || group.End <= group.Start || group.End > count {
+			return nil, fmt.Errorf("invalid atomic group [%d, %d): groups must be non-empty, within the %d instructions, in order and not overlapping", group.Start, group.End, count)
+		}
+		for ; next < group.Start; next++ {
+			units = append(units, InstructionRange{Start: next, End: next + 1})
+		}
+		units = append(units, group)
+		next = group.End
+	}
+	for ; next < count; next++ {
+		units = append(units, InstructionRange{Start: next, End: next + 1})
+	}
+	return units, nil
+}
+
+// serializedSize returns the size of the transaction once signed
+// by all the required signers.
+func serializedSize(tx *Transaction) (int, error) {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	numSignatures := int(tx.Message.Header.NumRequiredSignatures)
+	var signatureCount []byte
+	bin.EncodeCompactU16Length(&signatureCount, numSignatures)
+	return len(signatureCount) + numSignatures*SignatureLength + len(message), nil
+}
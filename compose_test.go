@@ -0,0 +1,143 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// composeTestInstructions returns instructions with 1 to 6 new accounts each,
+// some of them with an extra signer.
+func composeTestInstructions(count int, payer PublicKey) []Instruction {
+	programID := NewWallet().PublicKey()
+	instructions := make([]Instruction, count)
+	for i := range instructions {
+		accounts := AccountMetaSlice{Meta(payer).WRITE().SIGNER()}
+		for j := 0; j <= i%6; j++ {
+			accounts.Append(Meta(NewWallet().PublicKey()).WRITE())
+		}
+		if i%4 == 0 {
+			accounts.Append(Meta(NewWallet().PublicKey()).SIGNER())
+		}
+		instructions[i] = NewInstruction(programID, accounts, []byte{byte(i), 1, 2, 3})
+	}
+	return instructions
+}
+
+func TestComposeTransactions(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	instructions := composeTestInstructions(50, payer)
+	blockhash := Hash{1, 2, 3}
+
+	txs, err := ComposeTransactions(instructions, blockhash, payer, nil)
+	require.NoError(t, err)
+	require.Greater(t, len(txs), 1)
+	require.Less(t, len(txs), 50)
+
+	next := 0
+	for i, tx := range txs {
+		assert.Equal(t, payer, tx.Message.AccountKeys[0])
+		assert.Equal(t, blockhash, tx.Message.RecentBlockhash)
+		size, err := serializedSize(tx)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, size, MaxTransactionSize)
+
+		// The size is the one of the transaction once signed.
+		tx.Signatures = make([]Signature, tx.Message.Header.NumRequiredSignatures)
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, size, len(raw))
+
+		// The instructions are in order.
+		for _, inst := range tx.Message.Instructions {
+			assert.Equal(t, []byte{byte(next), 1, 2, 3}, []byte(inst.Data))
+			next++
+		}
+		// Greedy: the next instruction didn't fit.
+		if i < len(txs)-1 {
+			start := next - len(tx.Message.Instructions)
+			bigger, err := NewTransaction(instructions[start:next+1], blockhash, TransactionPayer(payer))
+			require.NoError(t, err)
+			size, err := serializedSize(bigger)
+			require.NoError(t, err)
+			assert.Greater(t, size, MaxTransactionSize)
+		}
+	}
+	assert.Equal(t, 50, next)
+}
+
+func TestComposeTransactions_atomicGroups(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	instructions := composeTestInstructions(50, payer)
+	blockhash := Hash{1, 2, 3}
+
+	// Find where the instructions are split without groups,
+	// and put a group across the first split.
+	txs, err := ComposeTransactions(instructions, blockhash, payer, nil)
+	require.NoError(t, err)
+	split := len(txs[0].Message.Instructions)
+	group := InstructionRange{Start: split - 2, End: split + 2}
+
+	txs, err = ComposeTransactions(instructions, blockhash, payer, &ComposeTransactionsOpts{
+		AtomicGroups: []InstructionRange{group},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, split-2, len(txs[0].Message.Instructions))
+	assert.Equal(t, []byte{byte(split - 2), 1, 2, 3}, []byte(txs[1].Message.Instructions[0].Data))
+	for _, tx := range txs {
+		size, err := serializedSize(tx)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, size, MaxTransactionSize)
+	}
+
+	t.Run("invalid groups", func(t *testing.T) {
+		for _, groups := range [][]InstructionRange{
+			{{Start: 3, End: 3}},
+			{{Start: 40, End: 51}},
+			{{Start: 5, End: 10}, {Start: 8, End: 12}},
+			{{Start: 8, End: 12}, {Start: 1, End: 2}},
+		} {
+			_, err := ComposeTransactions(instructions, blockhash, payer, &ComposeTransactionsOpts{AtomicGroups: groups})
+			assert.Error(t, err, groups)
+		}
+	})
+	t.Run("group too large", func(t *testing.T) {
+		_, err := ComposeTransactions(instructions, blockhash, payer, &ComposeTransactionsOpts{
+			AtomicGroups: []InstructionRange{{Start: 10, End: 40}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "instructions 10 to 39 don't fit in a transaction")
+	})
+}
+
+func TestComposeTransactions_instructionTooLarge(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	instructions := []Instruction{
+		NewInstruction(NewWallet().PublicKey(), AccountMetaSlice{Meta(payer).WRITE().SIGNER()}, []byte{1}),
+		NewInstruction(NewWallet().PublicKey(), AccountMetaSlice{Meta(payer).WRITE().SIGNER()}, make([]byte, MaxTransactionSize)),
+	}
+	_, err := ComposeTransactions(instructions, Hash{}, payer, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "instructions 1 to 1 don't fit")
+
+	// With a custom maximum size.
+	txs, err := ComposeTransactions(instructions, Hash{}, payer, &ComposeTransactionsOpts{MaxSize: 2 * MaxTransactionSize})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Len(t, txs[0].Message.Instructions, 2)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// MaxComputeUnitLimit is the max compute unit limit of a transaction.
+const MaxComputeUnitLimit = 1400000
+
+// The index of the SetComputeUnitLimit instruction of the compute budget program.
+const setComputeUnitLimitInstruction = 2
+
+// ComputeUnitsSimulator simulates a transaction, which doesn't need to be signed,
+// and returns the compute units it consumed; it's implemented by *rpc.Client.
+type ComputeUnitsSimulator interface {
+	SimulateComputeUnits(ctx context.Context, tx *Transaction) (uint64, error)
+}
+
+// EstimateComputeUnits simulates the transaction, which doesn't need to be signed,
+// and returns the compute units it consumed, e.g. to set a tight compute unit limit
+// (the units consumed plus a margin) with a SetComputeUnitLimit instruction.
+// The simulation is bound by the compute unit limit of the transaction:
+// TransactionBuilder.WithComputeUnitMargin simulates the transaction with
+// the max limit instead, and then sets the limit.
+func (tx *Transaction) EstimateComputeUnits(ctx context.Context, cl ComputeUnitsSimulator) (uint64, error) {
+	return cl.SimulateComputeUnits(ctx, tx)
+}
+
+type computeUnitMargin struct {
+	simulator ComputeUnitsSimulator
+	percent   uint64
+}
+
+// newSetComputeUnitLimitInstruction is like computebudget.NewSetComputeUnitLimitInstruction,
+// which can't be used here without an import cycle.
+func newSetComputeUnitLimitInstruction(units uint32) Instruction {
+	data := make([]byte, 5)
+	data[0] = setComputeUnitLimitInstruction
+	binary.LittleEndian.PutUint32(data[1:], units)
+	return NewInstruction(ComputeBudgetProgramID, nil, data)
+}
+
+func isSetComputeUnitLimitInstruction(inst Instruction) bool {
+	if !inst.ProgramID().Equals(ComputeBudgetProgramID) {
+		return false
+	}
+	data, err := inst.Data()
+	return err == nil && len(data) > 0 && data[0] == setComputeUnitLimitInstruction
+}
+
+// withComputeUnitLimit returns the instructions with a SetComputeUnitLimit instruction
+// for the units first.
+func withComputeUnitLimit(instructions []Instruction, units uint32) []Instruction {
+	return append([]Instruction{newSetComputeUnitLimitInstruction(units)}, instructions...)
+}
+
+// buildWithComputeUnitMargin builds the transaction with the max compute unit limit,
+// simulates it, and then builds it again with the units consumed plus the margin as limit.
+func (builder *TransactionBuilder) buildWithComputeUnitMargin(ctx context.Context) (*Transaction, error) {
+	margin := builder.computeUnitMargin
+	var instructions []Instruction
+	for _, inst := range builder.instructions {
+		if !isSetComputeUnitLimitInstruction(inst) {
+			instructions = append(instructions, inst)
+		}
+	}
+	if len(instructions) == 0 {
+		return nil, fmt.Errorf("requires at-least one instruction to create a transaction")
+	}
+
+	// The fee payer defaults to the first signer of the first instruction,
+	// ignoring the SetComputeUnitLimit instructions.
+	var opts []TransactionOption
+	options := transactionOptions{}
+	for _, opt := range builder.opts {
+		opt.apply(&options)
+	}
+	if options.payer.IsZero() {
+		for _, account := range instructions[0].Accounts() {
+			if account.IsSigner {
+				opts = append(opts, TransactionPayer(account.PublicKey))
+				break
+			}
+		}
+	}
+
+	simulated, err := builder.build(withComputeUnitLimit(instructions, MaxComputeUnitLimit), opts...)
+	if err != nil {
+		return nil, err
+	}
+	consumed, err := simulated.EstimateComputeUnits(ctx, margin.simulator)
+	if err != nil {
+		return nil, fmt.Errorf("unable to estimate the compute units: %w", err)
+	}
+	limit := consumed + consumed*margin.percent/100
+	if limit > MaxComputeUnitLimit {
+		limit = MaxComputeUnitLimit
+	}
+	return builder.build(withComputeUnitLimit(instructions, uint32(limit)), opts...)
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeComputeUnitsSimulator struct {
+	consumed  uint64
+	err       error
+	simulated []*Transaction
+}
+
+func (s *fakeComputeUnitsSimulator) SimulateComputeUnits(ctx context.Context, tx *Transaction) (uint64, error) {
+	s.simulated = append(s.simulated, tx)
+	return s.consumed, s.err
+}
+
+func TestTransactionBuilder_WithComputeUnitMargin(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	program := NewWallet().PublicKey()
+	blockhash := Hash{1}
+	instruction := &testTransactionInstructions{
+		accounts:  []*AccountMeta{{PublicKey: payer, IsSigner: true, IsWritable: true}},
+		data:      []byte{0xaa},
+		programID: program,
+	}
+	limitOf := func(t *testing.T, tx *Transaction) uint32 {
+		inst := tx.Message.Instructions[0]
+		programID, err := tx.Message.Program(inst.ProgramIDIndex)
+		require.NoError(t, err)
+		require.Equal(t, ComputeBudgetProgramID, programID)
+		require.Len(t, inst.Data, 5)
+		require.Equal(t, byte(setComputeUnitLimitInstruction), inst.Data[0])
+		return binary.LittleEndian.Uint32(inst.Data[1:])
+	}
+
+	t.Run("limit is the units consumed plus the margin", func(t *testing.T) {
+		simulator := &fakeComputeUnitsSimulator{consumed: 20000}
+		tx, err := NewTransactionBuilder().
+			AddInstruction(newSetComputeUnitLimitInstruction(200000)).
+			AddInstruction(instruction).
+			SetRecentBlockHash(blockhash).
+			WithComputeUnitMargin(simulator, 25).
+			Build()
+		require.NoError(t, err)
+
+		require.Len(t, simulator.simulated, 1)
+		assert.Equal(t, uint32(MaxComputeUnitLimit), limitOf(t, simulator.simulated[0]))
+		assert.Equal(t, uint32(25000), limitOf(t, tx))
+		// The limit set by the builder instructions is replaced.
+		require.Len(t, tx.Message.Instructions, 2)
+		assert.Equal(t, []byte{0xaa}, []byte(tx.Message.Instructions[1].Data))
+		// The default fee payer is the first signer of the first instruction added.
+		assert.Equal(t, payer, tx.Message.AccountKeys[0])
+	})
+	t.Run("limit is capped", func(t *testing.T) {
+		simulator := &fakeComputeUnitsSimulator{consumed: MaxComputeUnitLimit - 1}
+		tx, err := NewTransactionBuilder().
+			AddInstruction(instruction).
+			SetRecentBlockHash(blockhash).
+			WithComputeUnitMargin(simulator, 10).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(MaxComputeUnitLimit), limitOf(t, tx))
+	})
+	t.Run("explicit fee payer", func(t *testing.T) {
+		feePayer := NewWallet().PublicKey()
+		tx, err := NewTransactionBuilder().
+			AddInstruction(instruction).
+			SetRecentBlockHash(blockhash).
+			SetFeePayer(feePayer).
+			WithComputeUnitMargin(&fakeComputeUnitsSimulator{consumed: 100}, 10).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, feePayer, tx.Message.AccountKeys[0])
+		assert.Equal(t, uint32(110), limitOf(t, tx))
+	})
+	t.Run("simulation error", func(t *testing.T) {
+		simulationErr := errors.New("simulation failed")
+		_, err := NewTransactionBuilder().
+			AddInstruction(instruction).
+			SetRecentBlockHash(blockhash).
+			WithComputeUnitMargin(&fakeComputeUnitsSimulator{err: simulationErr}, 10).
+			Build()
+		require.True(t, errors.Is(err, simulationErr), err)
+	})
+}
@@ -0,0 +1,255 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detect guesses the kind of an account from its owner program
+// and its data (size, and discriminator or state tag), e.g. to decode
+// and pretty-print an arbitrary account in a CLI or while debugging.
+package detect
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	token2022 "github.com/gagliardetto/solana-go/programs/token-2022"
+)
+
+// Kind is the kind of an account.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	// A token account of the token or the Token-2022 program.
+	KindTokenAccount
+	// A mint of the token or the Token-2022 program.
+	KindMint
+	// A multisig of the token or the Token-2022 program.
+	KindMultisig
+	// A stake account of the stake program.
+	KindStake
+	// A vote account of the vote program.
+	KindVote
+	// A durable nonce account of the system program.
+	KindNonce
+	// An address lookup table.
+	KindLookupTable
+	// The metadata of a token, of the token metadata program.
+	KindMetadata
+	// An account of a (likely) Anchor program: the data starts
+	// with the 8-byte discriminator of the account type.
+	KindAnchor
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindTokenAccount:
+		return "token-account"
+	case KindMint:
+		return "mint"
+	case KindMultisig:
+		return "multisig"
+	case KindStake:
+		return "stake"
+	case KindVote:
+		return "vote"
+	case KindNonce:
+		return "nonce"
+	case KindLookupTable:
+		return "lookup-table"
+	case KindMetadata:
+		return "metadata"
+	case KindAnchor:
+		return "anchor"
+	default:
+		return "unknown"
+	}
+}
+
+// Confidence is how likely the kind of an account is right.
+type Confidence int
+
+const (
+	// The kind is unknown.
+	ConfidenceNone Confidence = iota
+	// A guess from the data alone, e.g. an Anchor discriminator.
+	ConfidenceLow
+	// The owner matches, but the data doesn't have the expected size or state tag.
+	ConfidenceMedium
+	// The owner, the size and the state tag (if any) of the data all match.
+	ConfidenceHigh
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// Account is the detected kind of an account.
+type Account struct {
+	Kind Kind
+	// The first 8 bytes of the data, for KindAnchor.
+	Discriminator [8]byte
+	// Decode decodes the data of the account (e.g. into a *token.Account),
+	// or is nil if this package has no decoder for the kind.
+	Decode func(data []byte) (interface{}, error)
+}
+
+// String returns the kind of the account, e.g. "mint",
+// or "anchor:<discriminator hex>" for KindAnchor.
+func (a Account) String() string {
+	if a.Kind == KindAnchor {
+		return "anchor:" + hex.EncodeToString(a.Discriminator[:])
+	}
+	return a.Kind.String()
+}
+
+// Sizes of the accounts of the native programs.
+const (
+	nonceAccountSize = 80
+	stakeAccountSize = 200
+	// The size of the vote accounts before and after the V3 vote state.
+	voteAccountSizeV2 = 3731
+	voteAccountSizeV3 = 3762
+	// The offset of the account type of the Token-2022 accounts with extensions.
+	token2022AccountTypeOffset = token.ACCOUNT_SIZE
+	// The Key of the token metadata accounts (MetadataV1).
+	metadataKey = 4
+	// The size of an Anchor discriminator.
+	discriminatorSize = 8
+)
+
+// nativeOwners are the programs whose accounts are never Anchor accounts.
+var nativeOwners = map[solana.PublicKey]bool{
+	solana.SystemProgramID:               true,
+	solana.ConfigProgramID:               true,
+	solana.StakeProgramID:                true,
+	solana.VoteProgramID:                 true,
+	solana.BPFLoaderDeprecatedProgramID:  true,
+	solana.BPFLoaderProgramID:            true,
+	solana.BPFLoaderUpgradeableProgramID: true,
+	solana.FeatureProgramID:              true,
+	solana.AddressLookupTableProgramID:   true,
+	solana.TokenProgramID:                true,
+	solana.Token2022ProgramID:            true,
+	solana.TokenMetadataProgramID:        true,
+}
+
+// AccountKind returns the best guess of the kind of an account
+// with the provided owner program and data, and how confident the guess is.
+// The accounts of the token programs, and the nonce, stake, vote, lookup table
+// and token metadata accounts are recognized by their owner and their data;
+// the accounts of the other programs with at least 8 bytes of data are
+// reported as Anchor accounts (with ConfidenceLow), with their discriminator.
+func AccountKind(owner solana.PublicKey, data []byte) (Account, Confidence) {
+	switch {
+	case owner.Equals(solana.TokenProgramID):
+		return tokenAccountKind(data, false)
+	case owner.Equals(solana.Token2022ProgramID):
+		return tokenAccountKind(data, true)
+	case owner.Equals(solana.SystemProgramID):
+		if len(data) != nonceAccountSize {
+			return Account{}, ConfidenceNone
+		}
+		account := Account{Kind: KindNonce, Decode: decodeNonceAccount}
+		// The version (legacy or current) and the state (initialized).
+		if version, state := binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[4:]); version <= 1 && state == 1 {
+			return account, ConfidenceHigh
+		}
+		return account, ConfidenceMedium
+	case owner.Equals(solana.StakeProgramID):
+		// The state: uninitialized, initialized, stake, or rewards pool.
+		if len(data) == stakeAccountSize && binary.LittleEndian.Uint32(data) <= 3 {
+			return Account{Kind: KindStake}, ConfidenceHigh
+		}
+		return Account{Kind: KindStake}, ConfidenceMedium
+	case owner.Equals(solana.VoteProgramID):
+		// The version of the vote state: v0.23.5, v1.14.11, or current.
+		if (len(data) == voteAccountSizeV2 || len(data) == voteAccountSizeV3) && binary.LittleEndian.Uint32(data) <= 2 {
+			return Account{Kind: KindVote}, ConfidenceHigh
+		}
+		return Account{Kind: KindVote}, ConfidenceMedium
+	case owner.Equals(solana.AddressLookupTableProgramID):
+		account := Account{Kind: KindLookupTable, Decode: decodeLookupTable}
+		// The state (lookup table), and whole addresses after the metadata.
+		if len(data) >= addresslookuptable.LOOKUP_TABLE_META_SIZE &&
+			(len(data)-addresslookuptable.LOOKUP_TABLE_META_SIZE)%solana.PublicKeyLength == 0 &&
+			binary.LittleEndian.Uint32(data) == 1 {
+			return account, ConfidenceHigh
+		}
+		return account, ConfidenceMedium
+	case owner.Equals(solana.TokenMetadataProgramID):
+		if len(data) > 0 && data[0] == metadataKey {
+			return Account{Kind: KindMetadata}, ConfidenceHigh
+		}
+		return Account{}, ConfidenceNone
+	}
+	if nativeOwners[owner] || len(data) < discriminatorSize {
+		return Account{}, ConfidenceNone
+	}
+	account := Account{Kind: KindAnchor}
+	copy(account.Discriminator[:], data)
+	return account, ConfidenceLow
+}
+
+// tokenAccountKind infers the kind of an account of the token program,
+// or of the Token-2022 program, from its size (and its account type, for the
+// Token-2022 accounts with extensions).
+func tokenAccountKind(data []byte, isToken2022 bool) (Account, Confidence) {
+	decode := token.DecodeAccount
+	if isToken2022 {
+		decode = token2022.DecodeAccount
+	}
+	switch len(data) {
+	case token.ACCOUNT_SIZE:
+		return Account{Kind: KindTokenAccount, Decode: decode}, ConfidenceHigh
+	case token.MINT_SIZE:
+		return Account{Kind: KindMint, Decode: decode}, ConfidenceHigh
+	case token.MULTISIG_SIZE:
+		return Account{Kind: KindMultisig, Decode: decode}, ConfidenceHigh
+	}
+	if isToken2022 && len(data) > token2022AccountTypeOffset {
+		switch token2022.AccountType(data[token2022AccountTypeOffset]) {
+		case token2022.AccountTypeMint:
+			return Account{Kind: KindMint, Decode: decode}, ConfidenceHigh
+		case token2022.AccountTypeAccount:
+			return Account{Kind: KindTokenAccount, Decode: decode}, ConfidenceHigh
+		}
+	}
+	return Account{}, ConfidenceNone
+}
+
+func decodeNonceAccount(data []byte) (interface{}, error) {
+	out := new(system.NonceAccount)
+	if err := bin.NewBinDecoder(data).Decode(out); err != nil {
+		return nil, fmt.Errorf("unable to decode nonce account: %w", err)
+	}
+	return out, nil
+}
+
+func decodeLookupTable(data []byte) (interface{}, error) {
+	return addresslookuptable.DecodeAddressLookupTableState(data)
+}
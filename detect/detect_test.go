@@ -0,0 +1,107 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	token2022 "github.com/gagliardetto/solana-go/programs/token-2022"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := bin.MarshalBin(v)
+	require.NoError(t, err)
+	return data
+}
+
+// tagged returns size bytes of data starting with the little-endian u32 tag.
+func tagged(size int, tag byte) []byte {
+	data := make([]byte, size)
+	data[0] = tag
+	return data
+}
+
+func TestAccountKind(t *testing.T) {
+	mint := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	wallet := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	anchorProgram := solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+
+	tokenAccount := mustMarshal(t, &token.Account{Mint: mint, Owner: wallet, Amount: 42, State: token.Initialized})
+	mintAccount := mustMarshal(t, &token.Mint{Supply: 1000, Decimals: 6, IsInitialized: true})
+	multisig := mustMarshal(t, &token.Multisig{M: 1, N: 1, IsInitialized: true})
+	// A Token-2022 mint with extensions: padded to the size of a token account,
+	// followed by the account type and the extensions.
+	mintWithExtensions := append(append(append([]byte{}, mintAccount...), make([]byte, token.ACCOUNT_SIZE-token.MINT_SIZE)...), byte(token2022.AccountTypeMint), 0, 0, 0, 0)
+	nonce := mustMarshal(t, &system.NonceAccount{Version: 1, State: 1, AuthorizedPubkey: wallet})
+	lookupTable := mustMarshal(t, &addresslookuptable.AddressLookupTableState{
+		TypeIndex:        1,
+		DeactivationSlot: ^uint64(0),
+		Authority:        &wallet,
+		Addresses:        solana.PublicKeySlice{mint, wallet},
+	})
+	metadata := append([]byte{metadataKey}, make([]byte, 678)...)
+	anchorData := append([]byte{0x3f, 0x95, 0xd1, 0x0c, 0xe1, 0x80, 0x63, 0x09}, make([]byte, 645)...)
+
+	tests := []struct {
+		name       string
+		owner      solana.PublicKey
+		data       []byte
+		kind       string
+		confidence Confidence
+		decoded    interface{}
+	}{
+		{name: "token account", owner: solana.TokenProgramID, data: tokenAccount, kind: "token-account", confidence: ConfidenceHigh, decoded: &token.Account{}},
+		{name: "mint", owner: solana.TokenProgramID, data: mintAccount, kind: "mint", confidence: ConfidenceHigh, decoded: &token.Mint{}},
+		{name: "multisig", owner: solana.TokenProgramID, data: multisig, kind: "multisig", confidence: ConfidenceHigh, decoded: &token.Multisig{}},
+		{name: "token-2022 account", owner: solana.Token2022ProgramID, data: tokenAccount, kind: "token-account", confidence: ConfidenceHigh, decoded: &token2022.Account{}},
+		{name: "token-2022 mint with extensions", owner: solana.Token2022ProgramID, data: mintWithExtensions, kind: "mint", confidence: ConfidenceHigh, decoded: &token2022.Mint{}},
+		{name: "token account of the wrong size", owner: solana.TokenProgramID, data: make([]byte, 100), kind: "unknown", confidence: ConfidenceNone},
+		{name: "nonce", owner: solana.SystemProgramID, data: nonce, kind: "nonce", confidence: ConfidenceHigh, decoded: &system.NonceAccount{}},
+		{name: "uninitialized nonce", owner: solana.SystemProgramID, data: make([]byte, nonceAccountSize), kind: "nonce", confidence: ConfidenceMedium, decoded: &system.NonceAccount{}},
+		{name: "wallet", owner: solana.SystemProgramID, data: nil, kind: "unknown", confidence: ConfidenceNone},
+		{name: "stake", owner: solana.StakeProgramID, data: tagged(stakeAccountSize, 2), kind: "stake", confidence: ConfidenceHigh},
+		{name: "stake of the wrong size", owner: solana.StakeProgramID, data: tagged(100, 2), kind: "stake", confidence: ConfidenceMedium},
+		{name: "vote", owner: solana.VoteProgramID, data: tagged(voteAccountSizeV3, 2), kind: "vote", confidence: ConfidenceHigh},
+		{name: "legacy vote", owner: solana.VoteProgramID, data: tagged(voteAccountSizeV2, 1), kind: "vote", confidence: ConfidenceHigh},
+		{name: "lookup table", owner: solana.AddressLookupTableProgramID, data: lookupTable, kind: "lookup-table", confidence: ConfidenceHigh, decoded: &addresslookuptable.AddressLookupTableState{}},
+		{name: "metadata", owner: solana.TokenMetadataProgramID, data: metadata, kind: "metadata", confidence: ConfidenceHigh},
+		{name: "master edition", owner: solana.TokenMetadataProgramID, data: []byte{6, 0, 0}, kind: "unknown", confidence: ConfidenceNone},
+		{name: "anchor", owner: anchorProgram, data: anchorData, kind: "anchor:3f95d10ce1806309", confidence: ConfidenceLow},
+		{name: "too short for a discriminator", owner: anchorProgram, data: []byte{1, 2, 3}, kind: "unknown", confidence: ConfidenceNone},
+		{name: "program", owner: solana.BPFLoaderUpgradeableProgramID, data: tagged(36, 2), kind: "unknown", confidence: ConfidenceNone},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			account, confidence := AccountKind(test.owner, test.data)
+			assert.Equal(t, test.kind, account.String())
+			assert.Equal(t, test.confidence, confidence)
+			if test.decoded == nil {
+				assert.Nil(t, account.Decode)
+				return
+			}
+			require.NotNil(t, account.Decode)
+			decoded, err := account.Decode(test.data)
+			require.NoError(t, err)
+			assert.IsType(t, test.decoded, decoded)
+		})
+	}
+}
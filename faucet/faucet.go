@@ -0,0 +1,255 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package faucet requests airdrops of SOL on the clusters that have a faucet
+// (devnet, testnet, and the local test validator), working around the rate limits
+// of the public faucets: the requests are retried with an exponential backoff,
+// can fall back to alternative HTTP faucets, and are split in chunks
+// of the max amount allowed by a single airdrop.
+package faucet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// MaxAirdropLamports is the max amount of a single airdrop of the public faucets.
+	MaxAirdropLamports = 2 * solana.LAMPORTS_PER_SOL
+
+	DefaultAttempts       = 5
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultConfirmTimeout = time.Minute
+	DefaultPollInterval   = time.Second
+)
+
+type Options struct {
+	// The commitment at which the airdrops are considered received.
+	// Defaults to "confirmed".
+	Commitment rpc.CommitmentType
+
+	// Max amount of a single airdrop request; larger amounts are split in several requests.
+	// Defaults to MaxAirdropLamports.
+	MaxLamportsPerRequest uint64
+
+	// Max number of attempts of each airdrop request. Defaults to DefaultAttempts.
+	// An attempt fails when the requestAirdrop RPC method and all the HTTPFaucets fail.
+	Attempts int
+
+	// Delay before the second attempt, doubled at each following attempt up to MaxBackoff.
+	// Default to DefaultInitialBackoff and DefaultMaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Alternative faucets, tried in order when the requestAirdrop RPC method fails.
+	// The faucets are sent a POST request with the JSON body {"pubkey": <address>, "lamports": <amount>},
+	// and must respond with a 2xx status and the JSON body {"signature": <airdrop transaction>}.
+	HTTPFaucets []string
+
+	// The client of the requests to the HTTPFaucets. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// How long to wait for the confirmation of each airdrop, and how often its status is checked.
+	// Default to DefaultConfirmTimeout and DefaultPollInterval.
+	ConfirmTimeout time.Duration
+	PollInterval   time.Duration
+}
+
+// Faucet requests airdrops with the RPC client and the HTTP faucets of its options.
+type Faucet struct {
+	client *rpc.Client
+	opts   Options
+}
+
+// New returns a Faucet requesting the airdrops with the provided RPC client;
+// opts can be nil.
+func New(client *rpc.Client, opts *Options) *Faucet {
+	f := &Faucet{client: client}
+	if opts != nil {
+		f.opts = *opts
+	}
+	if f.opts.Commitment == "" {
+		f.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if f.opts.MaxLamportsPerRequest == 0 {
+		f.opts.MaxLamportsPerRequest = MaxAirdropLamports
+	}
+	if f.opts.Attempts <= 0 {
+		f.opts.Attempts = DefaultAttempts
+	}
+	if f.opts.InitialBackoff <= 0 {
+		f.opts.InitialBackoff = DefaultInitialBackoff
+	}
+	if f.opts.MaxBackoff <= 0 {
+		f.opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if f.opts.HTTPClient == nil {
+		f.opts.HTTPClient = http.DefaultClient
+	}
+	if f.opts.ConfirmTimeout <= 0 {
+		f.opts.ConfirmTimeout = DefaultConfirmTimeout
+	}
+	if f.opts.PollInterval <= 0 {
+		f.opts.PollInterval = DefaultPollInterval
+	}
+	return f
+}
+
+// Result is the outcome of Faucet.Airdrop.
+type Result struct {
+	// The lamports received, i.e. the sum of the confirmed airdrops.
+	Lamports uint64
+	// The transactions of the confirmed airdrops, in order.
+	Signatures []solana.Signature
+}
+
+// Airdrop requests the lamports to the account, in airdrops of at most
+// Options.MaxLamportsPerRequest, one after the other, and waits for the confirmation
+// of each one before requesting the next.
+// On error, the result has the airdrops that were confirmed before the error.
+func (f *Faucet) Airdrop(ctx context.Context, account solana.PublicKey, lamports uint64) (*Result, error) {
+	out := &Result{}
+	for out.Lamports < lamports {
+		amount := lamports - out.Lamports
+		if amount > f.opts.MaxLamportsPerRequest {
+			amount = f.opts.MaxLamportsPerRequest
+		}
+		sig, err := f.request(ctx, account, amount)
+		if err != nil {
+			return out, fmt.Errorf("airdrop of %d lamports: %w", amount, err)
+		}
+		if err := f.waitForConfirmation(ctx, sig); err != nil {
+			return out, fmt.Errorf("airdrop %s: %w", sig, err)
+		}
+		out.Lamports += amount
+		out.Signatures = append(out.Signatures, sig)
+	}
+	return out, nil
+}
+
+// request requests a single airdrop, retrying with an exponential backoff.
+func (f *Faucet) request(ctx context.Context, account solana.PublicKey, lamports uint64) (solana.Signature, error) {
+	backoff := f.opts.InitialBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var sig solana.Signature
+		sig, err = f.requestOnce(ctx, account, lamports)
+		if err == nil {
+			return sig, nil
+		}
+		if attempt >= f.opts.Attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return solana.Signature{}, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > f.opts.MaxBackoff {
+			backoff = f.opts.MaxBackoff
+		}
+	}
+	return solana.Signature{}, fmt.Errorf("failed after %d attempts: %w", f.opts.Attempts, err)
+}
+
+// requestOnce requests the airdrop with the RPC client, then with each HTTP faucet,
+// until one of them succeeds; it returns the error of the last one.
+func (f *Faucet) requestOnce(ctx context.Context, account solana.PublicKey, lamports uint64) (solana.Signature, error) {
+	sig, err := f.client.RequestAirdrop(ctx, account, lamports, f.opts.Commitment)
+	if err == nil {
+		return sig, nil
+	}
+	for _, url := range f.opts.HTTPFaucets {
+		if ctx.Err() != nil {
+			break
+		}
+		sig, err = f.requestHTTP(ctx, url, account, lamports)
+		if err == nil {
+			return sig, nil
+		}
+	}
+	return solana.Signature{}, err
+}
+
+func (f *Faucet) requestHTTP(ctx context.Context, url string, account solana.PublicKey, lamports uint64) (solana.Signature, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"pubkey":   account,
+		"lamports": lamports,
+	})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.opts.HTTPClient.Do(req)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("faucet %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("faucet %s: unable to read response: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return solana.Signature{}, fmt.Errorf("faucet %s: %s: %q", url, resp.Status, respBody)
+	}
+	var out struct {
+		Signature solana.Signature `json:"signature"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return solana.Signature{}, fmt.Errorf("faucet %s: unable to decode response: %w", url, err)
+	}
+	if out.Signature.IsZero() {
+		return solana.Signature{}, fmt.Errorf("faucet %s: no signature in response: %q", url, respBody)
+	}
+	return out.Signature, nil
+}
+
+// waitForConfirmation polls the status of the airdrop transaction
+// until it is confirmed at the commitment, it fails, or the timeout expires.
+func (f *Faucet) waitForConfirmation(ctx context.Context, sig solana.Signature) error {
+	ctx, cancel := context.WithTimeout(ctx, f.opts.ConfirmTimeout)
+	defer cancel()
+	for {
+		// Errors are transient (the node may not know the transaction yet):
+		// try again until the timeout.
+		statuses, err := f.client.GetSignatureStatuses(ctx, false, sig)
+		if err == nil && len(statuses.Value) == 1 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			if status.Err != nil {
+				return fmt.Errorf("transaction failed: %v", status.Err)
+			}
+			if status.ConfirmationStatus.IsAtLeast(f.opts.Commitment) {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not confirmed after %s: %w", f.opts.ConfirmTimeout, ctx.Err())
+		case <-time.After(f.opts.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package faucet
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNode is a RPC node whose faucet rejects the airdrop requests for which rateLimited
+// (called with the number of the request, from 1) returns true,
+// and whose airdrops have the provided status.
+type mockNode struct {
+	rateLimited func(request int) bool
+	status      string
+
+	mu       sync.Mutex
+	requests int
+	airdrops []uint64
+	nextSig  byte
+}
+
+func (n *mockNode) serve(t *testing.T) *rpc.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     stdjson.RawMessage `json:"id"`
+			Method string             `json:"method"`
+			Params []interface{}      `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		switch req.Method {
+		case "requestAirdrop":
+			n.requests++
+			if n.rateLimited != nil && n.rateLimited(n.requests) {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":429,"message":"Too many requests for a specific RPC call"}}`, req.ID)
+				return
+			}
+			n.airdrops = append(n.airdrops, uint64(req.Params[1].(float64)))
+			n.nextSig++
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%q}`, req.ID, solana.Signature{n.nextSig})
+		case "getSignatureStatuses":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"context":{"slot":1},"value":[%s]}}`, req.ID, n.status)
+		default:
+			t.Errorf("unexpected method %s", req.Method)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return rpc.New(server.URL)
+}
+
+// testOptions returns options without delays.
+func testOptions() *Options {
+	return &Options{
+		InitialBackoff: time.Millisecond,
+		PollInterval:   time.Millisecond,
+		ConfirmTimeout: time.Second,
+	}
+}
+
+const confirmed = `{"slot":1,"confirmations":0,"err":null,"confirmationStatus":"confirmed"}`
+
+func TestFaucet_Airdrop(t *testing.T) {
+	account := solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+
+	t.Run("split and retried", func(t *testing.T) {
+		node := &mockNode{rateLimited: func(request int) bool { return request <= 2 }, status: confirmed}
+		faucet := New(node.serve(t), testOptions())
+
+		out, err := faucet.Airdrop(context.Background(), account, 5*solana.LAMPORTS_PER_SOL)
+		require.NoError(t, err)
+		assert.Equal(t, 5*solana.LAMPORTS_PER_SOL, out.Lamports)
+		assert.Equal(t, []solana.Signature{{1}, {2}, {3}}, out.Signatures)
+		assert.Equal(t, []uint64{2 * solana.LAMPORTS_PER_SOL, 2 * solana.LAMPORTS_PER_SOL, solana.LAMPORTS_PER_SOL}, node.airdrops)
+	})
+
+	t.Run("http faucet", func(t *testing.T) {
+		node := &mockNode{rateLimited: func(int) bool { return true }, status: confirmed}
+		var requests []map[string]interface{}
+		calls := 0
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		}))
+		defer down.Close()
+		up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, body)
+			fmt.Fprintf(w, `{"signature":%q}`, solana.Signature{42})
+		}))
+		defer up.Close()
+		opts := testOptions()
+		opts.HTTPFaucets = []string{down.URL, up.URL}
+		opts.MaxLamportsPerRequest = solana.LAMPORTS_PER_SOL
+		faucet := New(node.serve(t), opts)
+
+		out, err := faucet.Airdrop(context.Background(), account, 1500000000)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1500000000), out.Lamports)
+		assert.Equal(t, []solana.Signature{{42}, {42}}, out.Signatures)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t,
+			[]map[string]interface{}{
+				{"pubkey": account.String(), "lamports": float64(solana.LAMPORTS_PER_SOL)},
+				{"pubkey": account.String(), "lamports": float64(500000000)},
+			},
+			requests,
+		)
+	})
+
+	t.Run("all attempts fail", func(t *testing.T) {
+		node := &mockNode{rateLimited: func(request int) bool { return request != 2 }, status: confirmed}
+		opts := testOptions()
+		opts.Attempts = 2
+		faucet := New(node.serve(t), opts)
+
+		// The first airdrop is received after 2 attempts, then the second one fails.
+		out, err := faucet.Airdrop(context.Background(), account, 3*solana.LAMPORTS_PER_SOL)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "airdrop of 1000000000 lamports: failed after 2 attempts")
+		assert.Contains(t, err.Error(), "Too many requests")
+		assert.Equal(t, 2*solana.LAMPORTS_PER_SOL, out.Lamports)
+		assert.Len(t, out.Signatures, 1)
+	})
+
+	t.Run("failed airdrop", func(t *testing.T) {
+		node := &mockNode{status: `{"slot":1,"confirmations":0,"err":{"InstructionError":[0,"Custom"]},"confirmationStatus":"confirmed"}`}
+		faucet := New(node.serve(t), testOptions())
+
+		out, err := faucet.Airdrop(context.Background(), account, solana.LAMPORTS_PER_SOL)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "transaction failed")
+		assert.Zero(t, out.Lamports)
+	})
+
+	t.Run("not confirmed", func(t *testing.T) {
+		node := &mockNode{status: `null`}
+		opts := testOptions()
+		opts.ConfirmTimeout = 20 * time.Millisecond
+		faucet := New(node.serve(t), opts)
+
+		_, err := faucet.Airdrop(context.Background(), account, solana.LAMPORTS_PER_SOL)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package base58codec encodes and decodes the 32-byte (public keys, hashes)
+// and 64-byte (signatures) values in base58, with the bitcoin alphabet,
+// without intermediate allocations.
+//
+// The output is the same as the one of github.com/mr-tron/base58;
+// the conversion is done on 32-bit limbs, 5 base58 digits at a time,
+// in buffers on the stack.
+package base58codec
+
+import (
+	"encoding/binary"
+)
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+const (
+	// 58^5, the largest power of 58 that fits in 32 bits.
+	base58Pow5 = 58 * 58 * 58 * 58 * 58
+
+	// MaxEncodedLen32 is the max length of the encoding of 32 bytes.
+	MaxEncodedLen32 = 44
+	// MaxEncodedLen64 is the max length of the encoding of 64 bytes.
+	MaxEncodedLen64 = 88
+)
+
+// decodeMap maps the characters of the alphabet to their value, and the others to -1.
+var decodeMap [256]int8
+
+func init() {
+	for i := range decodeMap {
+		decodeMap[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		decodeMap[alphabet[i]] = int8(i)
+	}
+}
+
+// EncodeBase58_32 returns the base58 encoding of the 32 bytes.
+func EncodeBase58_32(in *[32]byte) string {
+	var buf [MaxEncodedLen32]byte
+	return string(appendEncoded(buf[:0], in[:]))
+}
+
+// EncodeBase58_64 returns the base58 encoding of the 64 bytes.
+func EncodeBase58_64(in *[64]byte) string {
+	var buf [MaxEncodedLen64]byte
+	return string(appendEncoded(buf[:0], in[:]))
+}
+
+// AppendBase58_32 appends the base58 encoding of the 32 bytes to dst.
+func AppendBase58_32(dst []byte, in *[32]byte) []byte {
+	return appendEncoded(dst, in[:])
+}
+
+// AppendBase58_64 appends the base58 encoding of the 64 bytes to dst.
+func AppendBase58_64(dst []byte, in *[64]byte) []byte {
+	return appendEncoded(dst, in[:])
+}
+
+// DecodeBase58_32 decodes s into out, and reports whether s is
+// the base58 encoding of exactly 32 bytes; out is not modified if it isn't.
+func DecodeBase58_32(out *[32]byte, s string) bool {
+	if len(s) > MaxEncodedLen32 {
+		return false
+	}
+	var tmp [32]byte
+	if !decode(tmp[:], s) {
+		return false
+	}
+	*out = tmp
+	return true
+}
+
+// DecodeBase58_64 decodes s into out, and reports whether s is
+// the base58 encoding of exactly 64 bytes; out is not modified if it isn't.
+func DecodeBase58_64(out *[64]byte, s string) bool {
+	if len(s) > MaxEncodedLen64 {
+		return false
+	}
+	var tmp [64]byte
+	if !decode(tmp[:], s) {
+		return false
+	}
+	*out = tmp
+	return true
+}
+
+// appendEncoded appends the encoding of in (at most 64 bytes, a multiple of 4) to dst.
+func appendEncoded(dst []byte, in []byte) []byte {
+	zeros := 0
+	for zeros < len(in) && in[zeros] == 0 {
+		zeros++
+	}
+
+	// Big-endian limbs.
+	var limbs [16]uint32
+	numLimbs := len(in) / 4
+	for i := 0; i < numLimbs; i++ {
+		limbs[i] = binary.BigEndian.Uint32(in[4*i:])
+	}
+
+	// The digits, least significant first: each division of the limbs
+	// by 58^5 yields 5 digits. 90 digits are enough for 64 bytes.
+	var digits [90]byte
+	numDigits := 0
+	first := zeros / 4 // The limbs before are zero.
+	for first < numLimbs {
+		var rem uint64
+		for i := first; i < numLimbs; i++ {
+			cur := rem<<32 | uint64(limbs[i])
+			limbs[i] = uint32(cur / base58Pow5)
+			rem = cur % base58Pow5
+		}
+		r := uint32(rem)
+		for j := 0; j < 5; j++ {
+			digits[numDigits] = byte(r % 58)
+			r /= 58
+			numDigits++
+		}
+		for first < numLimbs && limbs[first] == 0 {
+			first++
+		}
+	}
+	for numDigits > 0 && digits[numDigits-1] == 0 {
+		numDigits--
+	}
+
+	// Each leading zero byte is encoded as a '1'.
+	for i := 0; i < zeros; i++ {
+		dst = append(dst, alphabet[0])
+	}
+	for i := numDigits - 1; i >= 0; i-- {
+		dst = append(dst, alphabet[digits[i]])
+	}
+	return dst
+}
+
+// decode decodes s into out (at most 64 bytes, a multiple of 4), and reports
+// whether s is the encoding of exactly len(out) bytes.
+func decode(out []byte, s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+	if zeros > len(out) {
+		return false
+	}
+
+	// Big-endian limbs.
+	var limbs [16]uint32
+	numLimbs := len(out) / 4
+	rest := s[zeros:]
+	for len(rest) > 0 {
+		// Up to 5 digits at a time, so that the chunks after the first are aligned.
+		n := len(rest) % 5
+		if n == 0 {
+			n = 5
+		}
+		var chunk, mul uint64 = 0, 1
+		for i := 0; i < n; i++ {
+			digit := decodeMap[rest[i]]
+			if digit < 0 {
+				return false
+			}
+			chunk = chunk*58 + uint64(digit)
+			mul *= 58
+		}
+		rest = rest[n:]
+
+		// limbs = limbs*mul + chunk
+		carry := chunk
+		for i := numLimbs - 1; i >= 0; i-- {
+			cur := uint64(limbs[i])*mul + carry
+			limbs[i] = uint32(cur)
+			carry = cur >> 32
+		}
+		if carry != 0 {
+			// Too large.
+			return false
+		}
+	}
+	for i := 0; i < numLimbs; i++ {
+		binary.BigEndian.PutUint32(out[4*i:], limbs[i])
+	}
+
+	// The number of leading zero bytes must be the number of leading '1's,
+	// otherwise the decoded value has another size.
+	leading := 0
+	for leading < len(out) && out[leading] == 0 {
+		leading++
+	}
+	return leading == zeros
+}
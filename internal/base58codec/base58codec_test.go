@@ -0,0 +1,221 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base58codec
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/require"
+)
+
+// randomBytes fills b with random bytes, with a random number of leading zeros
+// (and sometimes only zeros, or only 0xff), to cover the edge cases of the encoding.
+func randomBytes(rng *rand.Rand, b []byte) {
+	rng.Read(b)
+	switch rng.Intn(8) {
+	case 0:
+		for i := range b {
+			b[i] = 0
+		}
+	case 1:
+		for i := range b {
+			b[i] = 0xff
+		}
+	case 2, 3:
+		for i := rng.Intn(len(b) + 1); i > 0; i-- {
+			b[i-1] = 0
+		}
+	}
+}
+
+func TestEncodeDecode_matchesMrTron(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		var in32 [32]byte
+		randomBytes(rng, in32[:])
+		expected := base58.Encode(in32[:])
+		require.Equal(t, expected, EncodeBase58_32(&in32), "%x", in32)
+		require.Equal(t, "prefix"+expected, string(AppendBase58_32([]byte("prefix"), &in32)))
+		var out32 [32]byte
+		require.True(t, DecodeBase58_32(&out32, expected), expected)
+		require.Equal(t, in32, out32)
+
+		var in64 [64]byte
+		randomBytes(rng, in64[:])
+		expected = base58.Encode(in64[:])
+		require.Equal(t, expected, EncodeBase58_64(&in64), "%x", in64)
+		require.Equal(t, "prefix"+expected, string(AppendBase58_64([]byte("prefix"), &in64)))
+		var out64 [64]byte
+		require.True(t, DecodeBase58_64(&out64, expected), expected)
+		require.Equal(t, in64, out64)
+	}
+}
+
+func TestDecode_matchesMrTron(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	check := func(s string) {
+		decoded, err := base58.Decode(s)
+
+		var out32 [32]byte
+		ok := DecodeBase58_32(&out32, s)
+		require.Equal(t, err == nil && len(decoded) == 32, ok, "%q", s)
+		if ok {
+			require.Equal(t, decoded, out32[:])
+		} else {
+			// Not modified.
+			require.Equal(t, [32]byte{}, out32)
+		}
+
+		var out64 [64]byte
+		ok = DecodeBase58_64(&out64, s)
+		require.Equal(t, err == nil && len(decoded) == 64, ok, "%q", s)
+		if ok {
+			require.Equal(t, decoded, out64[:])
+		}
+	}
+
+	for _, s := range []string{
+		"",
+		"1",
+		strings.Repeat("1", 31),
+		strings.Repeat("1", 32),
+		strings.Repeat("1", 33),
+		strings.Repeat("1", 64),
+		strings.Repeat("z", 44),
+		strings.Repeat("z", 43),
+		strings.Repeat("z", 88),
+		strings.Repeat("z", 87),
+		"11111111111111111111111111111112",
+		"4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw",
+		"4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vig0",
+		"4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4VigwI",
+		"1" + "4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw",
+	} {
+		check(s)
+	}
+
+	// Random strings of the lengths around the ones of the encodings.
+	for i := 0; i < 20000; i++ {
+		n := 30 + rng.Intn(60)
+		b := make([]byte, n)
+		for j := range b {
+			b[j] = alphabet[rng.Intn(len(alphabet))]
+		}
+		for j := rng.Intn(4); j > 0; j-- {
+			b[rng.Intn(n)] = '1'
+		}
+		if rng.Intn(50) == 0 {
+			b[rng.Intn(n)] = "0OIl+"[rng.Intn(5)]
+		}
+		check(string(b))
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	var in32 [32]byte
+	var in64 [64]byte
+	rand.New(rand.NewSource(3)).Read(in64[:])
+	copy(in32[:], in64[:])
+	s32 := base58.Encode(in32[:])
+	s64 := base58.Encode(in64[:])
+	buf := make([]byte, 0, MaxEncodedLen64)
+
+	// Only the string.
+	require.Equal(t, float64(1), testing.AllocsPerRun(100, func() { EncodeBase58_32(&in32) }))
+	require.Equal(t, float64(1), testing.AllocsPerRun(100, func() { EncodeBase58_64(&in64) }))
+	require.Equal(t, float64(0), testing.AllocsPerRun(100, func() { AppendBase58_32(buf[:0], &in32) }))
+	require.Equal(t, float64(0), testing.AllocsPerRun(100, func() { AppendBase58_64(buf[:0], &in64) }))
+	require.Equal(t, float64(0), testing.AllocsPerRun(100, func() { DecodeBase58_32(&in32, s32) }))
+	require.Equal(t, float64(0), testing.AllocsPerRun(100, func() { DecodeBase58_64(&in64, s64) }))
+	require.True(t, bytes.Equal(in64[:32], in32[:]))
+}
+
+var benchSink string
+
+func BenchmarkEncode32(b *testing.B) {
+	var in [32]byte
+	rand.New(rand.NewSource(4)).Read(in[:])
+	b.Run("base58codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchSink = EncodeBase58_32(&in)
+		}
+	})
+	b.Run("mr-tron", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchSink = base58.Encode(in[:])
+		}
+	})
+}
+
+func BenchmarkEncode64(b *testing.B) {
+	var in [64]byte
+	rand.New(rand.NewSource(4)).Read(in[:])
+	b.Run("base58codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchSink = EncodeBase58_64(&in)
+		}
+	})
+	b.Run("mr-tron", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchSink = base58.Encode(in[:])
+		}
+	})
+}
+
+func BenchmarkDecode32(b *testing.B) {
+	var in [32]byte
+	rand.New(rand.NewSource(4)).Read(in[:])
+	s := base58.Encode(in[:])
+	b.Run("base58codec", func(b *testing.B) {
+		b.ReportAllocs()
+		var out [32]byte
+		for i := 0; i < b.N; i++ {
+			DecodeBase58_32(&out, s)
+		}
+	})
+	b.Run("mr-tron", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			base58.Decode(s)
+		}
+	})
+}
+
+func BenchmarkDecode64(b *testing.B) {
+	var in [64]byte
+	rand.New(rand.NewSource(4)).Read(in[:])
+	s := base58.Encode(in[:])
+	b.Run("base58codec", func(b *testing.B) {
+		b.ReportAllocs()
+		var out [64]byte
+		for i := 0; i < b.N; i++ {
+			DecodeBase58_64(&out, s)
+		}
+	})
+	b.Run("mr-tron", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			base58.Decode(s)
+		}
+	})
+}
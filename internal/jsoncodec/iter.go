@@ -0,0 +1,66 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !solana_stdjson
+// +build !solana_stdjson
+
+package jsoncodec
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Iter is the Codec that uses json-iterator,
+// configured to be compatible with encoding/json.
+var Iter Codec = iterCodec{api: jsoniter.ConfigCompatibleWithStandardLibrary}
+
+var defaultCodec = Iter
+
+type iterCodec struct {
+	api jsoniter.API
+}
+
+func (c iterCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.api.Marshal(v)
+}
+
+// MarshalIndent indents the output of Marshal like encoding/json does
+// (json-iterator doesn't indent the content of json.RawMessage values).
+func (c iterCodec) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	out, err := c.api.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := stdjson.Indent(&buf, out, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c iterCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.api.Unmarshal(data, v)
+}
+
+func (c iterCodec) NewDecoder(r io.Reader) Decoder {
+	return c.api.NewDecoder(r)
+}
+
+func (c iterCodec) NewEncoder(w io.Writer) Encoder {
+	return c.api.NewEncoder(w)
+}
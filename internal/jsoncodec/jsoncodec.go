@@ -0,0 +1,100 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsoncodec holds the JSON implementation used by the solana-go packages.
+//
+// The default is json-iterator (configured to be compatible with encoding/json),
+// or encoding/json when building with the `solana_stdjson` tag.
+// It can be replaced at runtime with Set (see solana.SetJSONCodec).
+package jsoncodec
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Codec marshals and unmarshals JSON, with the same semantics as encoding/json.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	MarshalIndent(v interface{}, prefix, indent string) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewDecoder(r io.Reader) Decoder
+	NewEncoder(w io.Writer) Encoder
+}
+
+// Decoder is the subset of the methods of encoding/json.Decoder used by solana-go.
+type Decoder interface {
+	Decode(v interface{}) error
+	More() bool
+	UseNumber()
+	DisallowUnknownFields()
+	Buffered() io.Reader
+}
+
+// Encoder is the subset of the methods of encoding/json.Encoder used by solana-go.
+type Encoder interface {
+	Encode(v interface{}) error
+	SetEscapeHTML(on bool)
+}
+
+// holder keeps the type stored in `current` the same for all codecs.
+type holder struct {
+	codec Codec
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(holder{codec: defaultCodec})
+}
+
+// Set replaces the codec; if nil, the default one is restored.
+// It is meant to be called once, before any JSON is (un)marshaled:
+// operations that are in progress may use either codec.
+func Set(codec Codec) {
+	if codec == nil {
+		codec = defaultCodec
+	}
+	current.Store(holder{codec: codec})
+}
+
+// Get returns the codec in use.
+func Get() Codec {
+	return current.Load().(holder).codec
+}
+
+// JSON is a Codec that uses the codec in use when each of its methods is called.
+var JSON Codec = dynamic{}
+
+type dynamic struct{}
+
+func (dynamic) Marshal(v interface{}) ([]byte, error) {
+	return Get().Marshal(v)
+}
+
+func (dynamic) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return Get().MarshalIndent(v, prefix, indent)
+}
+
+func (dynamic) Unmarshal(data []byte, v interface{}) error {
+	return Get().Unmarshal(data, v)
+}
+
+func (dynamic) NewDecoder(r io.Reader) Decoder {
+	return Get().NewDecoder(r)
+}
+
+func (dynamic) NewEncoder(w io.Writer) Encoder {
+	return Get().NewEncoder(w)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsoncodec
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	defer Set(nil)
+
+	assert.Equal(t, defaultCodec, Get())
+	Set(Std)
+	assert.Equal(t, Std, Get())
+
+	out, err := JSON.Marshal(map[string]int{"b": 2, "a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":2}`, string(out))
+
+	Set(nil)
+	assert.Equal(t, defaultCodec, Get())
+}
+
+type conformance struct {
+	Name    string             `json:"name"`
+	Skipped string             `json:"-"`
+	Empty   string             `json:"empty,omitempty"`
+	Bytes   []byte             `json:"bytes"`
+	Raw     stdjson.RawMessage `json:"raw"`
+	Float   float64            `json:"float"`
+	Ptr     *uint64            `json:"ptr"`
+	Map     map[string]int     `json:"map"`
+	HTML    string             `json:"html"`
+}
+
+func TestCodecsConformance(t *testing.T) {
+	big := uint64(18446744073709551615)
+	value := conformance{
+		Name:    "name",
+		Skipped: "skipped",
+		Bytes:   []byte{0, 1, 2, 0xff},
+		Raw:     stdjson.RawMessage(`{"x":[1,2]}`),
+		Float:   1e21,
+		Ptr:     &big,
+		Map:     map[string]int{"z": 1, "a": 2, "m": 3},
+		HTML:    "<a&b>",
+	}
+	codecs := map[string]Codec{"default": defaultCodec, "std": Std}
+
+	encoded := make(map[string]string)
+	for name, codec := range codecs {
+		out, err := codec.Marshal(value)
+		require.NoError(t, err, name)
+		encoded[name] = string(out)
+
+		indented, err := codec.MarshalIndent(value, "", "  ")
+		require.NoError(t, err, name)
+		encoded[name+" indented"] = string(indented)
+
+		var buf bytes.Buffer
+		require.NoError(t, codec.NewEncoder(&buf).Encode(value), name)
+		encoded[name+" encoder"] = buf.String()
+	}
+	assert.Equal(t, encoded["std"], encoded["default"])
+	assert.Equal(t, encoded["std indented"], encoded["default indented"])
+	assert.Equal(t, encoded["std encoder"], encoded["default encoder"])
+
+	input := `{"name":"name","bytes":"AAEC/w==","raw":{"x":[1,2]},"float":1e21,"ptr":18446744073709551615,"map":{"a":1},"html":"<a&b>","other":[1.5,{"y":null}]}`
+	decoded := make(map[string]conformance)
+	generic := make(map[string]interface{})
+	for name, codec := range codecs {
+		var out conformance
+		require.NoError(t, codec.Unmarshal([]byte(input), &out), name)
+		decoded[name] = out
+
+		var anything interface{}
+		decoder := codec.NewDecoder(bytes.NewReader([]byte(input)))
+		decoder.UseNumber()
+		require.NoError(t, decoder.Decode(&anything), name)
+		generic[name] = anything
+
+		strict := codec.NewDecoder(bytes.NewReader([]byte(input)))
+		strict.DisallowUnknownFields()
+		assert.Error(t, strict.Decode(&out), name)
+	}
+	assert.Equal(t, decoded["std"], decoded["default"])
+	assert.Equal(t, generic["std"], generic["default"])
+}
@@ -15,7 +15,39 @@
 package solana
 
 import (
-	jsoniter "github.com/json-iterator/go"
+	"github.com/gagliardetto/solana-go/internal/jsoncodec"
 )
 
-var json = jsoniter.ConfigCompatibleWithStandardLibrary
+var json = jsoncodec.JSON
+
+// JSONCodec is a JSON implementation (see SetJSONCodec).
+type JSONCodec = jsoncodec.Codec
+
+// JSONDecoder is the decoder returned by a JSONCodec.
+type JSONDecoder = jsoncodec.Decoder
+
+// JSONEncoder is the encoder returned by a JSONCodec.
+type JSONEncoder = jsoncodec.Encoder
+
+// StdJSONCodec is the JSONCodec that uses encoding/json.
+var StdJSONCodec JSONCodec = jsoncodec.Std
+
+// SetJSONCodec sets the JSON implementation used by this module's packages
+// (solana, rpc, rpc/ws, rpc/jsonrpc and the program packages); if nil, the default one is restored.
+// The default is json-iterator, or encoding/json when building with the `solana_stdjson` tag
+// (in which case json-iterator isn't linked by these packages).
+// Other implementations with the same semantics as encoding/json can be plugged
+// by implementing JSONCodec.
+//
+// The methods of the rpc package that decode a response as it is received
+// (e.g. GetProgramAccountsCallback) always use encoding/json.
+//
+// It is meant to be called once, before using the other packages.
+func SetJSONCodec(codec JSONCodec) {
+	jsoncodec.Set(codec)
+}
+
+// GetJSONCodec returns the JSON implementation in use.
+func GetJSONCodec() JSONCodec {
+	return jsoncodec.Get()
+}
@@ -30,6 +30,7 @@ import (
 	"sort"
 
 	"filippo.io/edwards25519"
+	"github.com/gagliardetto/solana-go/internal/base58codec"
 	"github.com/mr-tron/base58"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
@@ -53,6 +54,22 @@ func PrivateKeyFromBase58(privkey string) (PrivateKey, error) {
 	return res, nil
 }
 
+// PrivateKeyFromBytes returns a copy of the provided 64-byte private key
+// (the 32-byte seed followed by the 32-byte public key).
+// It returns an error if the length is wrong, or if the public key
+// doesn't match the one derived from the seed
+// (e.g. the key is truncated or its halves are swapped).
+func PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key length: expected %d bytes, got %d", ed25519.PrivateKeySize, len(b))
+	}
+	derived := ed25519.NewKeyFromSeed(b[:ed25519.SeedSize])
+	if !bytes.Equal(derived[ed25519.SeedSize:], b[ed25519.SeedSize:]) {
+		return nil, errors.New("invalid private key: public key doesn't match the seed")
+	}
+	return PrivateKey(derived), nil
+}
+
 func PrivateKeyFromSolanaKeygenFile(file string) (PrivateKey, error) {
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
@@ -144,6 +161,10 @@ func MustPublicKeyFromBase58(in string) PublicKey {
 }
 
 func PublicKeyFromBase58(in string) (out PublicKey, err error) {
+	if base58codec.DecodeBase58_32((*[PublicKeyLength]byte)(&out), in) {
+		return out, nil
+	}
+	// Not a valid public key: decode it again, to return the same errors as before.
 	val, err := base58.Decode(in)
 	if err != nil {
 		return out, fmt.Errorf("decode: %w", err)
@@ -158,7 +179,7 @@ func PublicKeyFromBase58(in string) (out PublicKey, err error) {
 }
 
 func (p PublicKey) MarshalText() ([]byte, error) {
-	return []byte(base58.Encode(p[:])), nil
+	return base58codec.AppendBase58_32(make([]byte, 0, base58codec.MaxEncodedLen32), (*[PublicKeyLength]byte)(&p)), nil
 }
 
 func (p *PublicKey) UnmarshalText(data []byte) error {
@@ -166,7 +187,16 @@ func (p *PublicKey) UnmarshalText(data []byte) error {
 }
 
 func (p PublicKey) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(p[:]))
+	return marshalJSONBase58_32((*[PublicKeyLength]byte)(&p)), nil
+}
+
+// marshalJSONBase58_32 returns the base58 encoding of the 32 bytes, as a JSON string
+// (the base58 alphabet doesn't need escaping).
+func marshalJSONBase58_32(in *[32]byte) []byte {
+	out := make([]byte, 0, base58codec.MaxEncodedLen32+2)
+	out = append(out, '"')
+	out = base58codec.AppendBase58_32(out, in)
+	return append(out, '"')
 }
 
 func (p *PublicKey) UnmarshalJSON(data []byte) (err error) {
@@ -265,7 +295,7 @@ func (p *PublicKey) Set(s string) (err error) {
 }
 
 func (p PublicKey) String() string {
-	return base58.Encode(p[:])
+	return base58codec.EncodeBase58_32((*[PublicKeyLength]byte)(&p))
 }
 
 // Short returns a shortened pubkey string,
@@ -276,6 +306,13 @@ func (p PublicKey) Short(n int) string {
 	return formatShortPubkey(n, p)
 }
 
+// Less reports whether p sorts before other.
+// The order is a plain byte-wise comparison of the two keys,
+// i.e. the same order as the Rust SDK's Pubkey.
+func (p PublicKey) Less(other PublicKey) bool {
+	return bytes.Compare(p[:], other[:]) < 0
+}
+
 func formatShortPubkey(n int, pubkey PublicKey) string {
 	str := pubkey.String()
 	if n > (len(str)/2)-1 {
@@ -318,14 +355,16 @@ func (slice PublicKeySlice) Len() int {
 }
 
 func (slice PublicKeySlice) Less(i, j int) bool {
-	return bytes.Compare(slice[i][:], slice[j][:]) < 0
+	return slice[i].Less(slice[j])
 }
 
 func (slice PublicKeySlice) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
-// Sort sorts the slice.
+var _ sort.Interface = PublicKeySlice{}
+
+// Sort sorts the slice (see PublicKey.Less for the order).
 func (slice PublicKeySlice) Sort() {
 	sort.Sort(slice)
 }
@@ -641,13 +680,45 @@ func FindProgramAddress(seed [][]byte, programID PublicKey) (PublicKey, uint8, e
 	return PublicKey{}, bumpSeed, errors.New("unable to find a valid program address")
 }
 
+// FindAssociatedTokenAddress returns the associated token account address
+// of the wallet for a mint owned by the SPL Token program.
+// For Token-2022 mints, use FindAssociatedTokenAddress2022.
 func FindAssociatedTokenAddress(
 	wallet PublicKey,
 	mint PublicKey,
+) (PublicKey, uint8, error) {
+	return FindAssociatedTokenAddressForProgram(
+		wallet,
+		mint,
+		TokenProgramID,
+	)
+}
+
+// FindAssociatedTokenAddress2022 returns the associated token account address
+// of the wallet for a mint owned by the Token-2022 program.
+func FindAssociatedTokenAddress2022(
+	wallet PublicKey,
+	mint PublicKey,
+) (PublicKey, uint8, error) {
+	return FindAssociatedTokenAddressForProgram(
+		wallet,
+		mint,
+		Token2022ProgramID,
+	)
+}
+
+// FindAssociatedTokenAddressForProgram returns the associated token account address
+// of the wallet for a mint owned by the provided token program.
+// The token program is part of the seeds, so it must be the owner of the mint.
+func FindAssociatedTokenAddressForProgram(
+	wallet PublicKey,
+	mint PublicKey,
+	tokenProgramID PublicKey,
 ) (PublicKey, uint8, error) {
 	return findAssociatedTokenAddressAndBumpSeed(
 		wallet,
 		mint,
+		tokenProgramID,
 		SPLAssociatedTokenAccountProgramID,
 	)
 }
@@ -655,11 +726,12 @@ func FindAssociatedTokenAddress(
 func findAssociatedTokenAddressAndBumpSeed(
 	walletAddress PublicKey,
 	splTokenMintAddress PublicKey,
+	tokenProgramID PublicKey,
 	programID PublicKey,
 ) (PublicKey, uint8, error) {
 	return FindProgramAddress([][]byte{
 		walletAddress[:],
-		TokenProgramID[:],
+		tokenProgramID[:],
 		splTokenMintAddress[:],
 	},
 		programID,
@@ -675,3 +747,57 @@ func FindTokenMetadataAddress(mint PublicKey) (PublicKey, uint8, error) {
 	}
 	return FindProgramAddress(seed, TokenMetadataProgramID)
 }
+
+// FindMasterEditionAddress returns the token metadata master edition program-derived address
+// given a SPL token mint address (e.g. of a NFT).
+func FindMasterEditionAddress(mint PublicKey) (PublicKey, uint8, error) {
+	seed := [][]byte{
+		[]byte("metadata"),
+		TokenMetadataProgramID[:],
+		mint[:],
+		[]byte("edition"),
+	}
+	return FindProgramAddress(seed, TokenMetadataProgramID)
+}
+
+// MintAddresses are the program-derived addresses related to a mint (see MintPDAs).
+type MintAddresses struct {
+	Mint PublicKey
+	// The token metadata account (see FindTokenMetadataAddress).
+	Metadata     PublicKey
+	MetadataBump uint8
+	// The token metadata master edition account (see FindMasterEditionAddress).
+	MasterEdition     PublicKey
+	MasterEditionBump uint8
+	// The associated token accounts of the owners passed to MintPDAs, by owner
+	// (see FindAssociatedTokenAddress).
+	AssociatedTokenAccounts map[PublicKey]PublicKey
+}
+
+// MintPDAs returns the metadata and master edition addresses of the mint,
+// and the associated token account addresses of the provided owners,
+// for a mint owned by the SPL Token program (e.g. a NFT).
+// For Token-2022 mints, use FindAssociatedTokenAddress2022 for the associated token accounts.
+func MintPDAs(mint PublicKey, owners ...PublicKey) (*MintAddresses, error) {
+	out := &MintAddresses{
+		Mint:                    mint,
+		AssociatedTokenAccounts: make(map[PublicKey]PublicKey, len(owners)),
+	}
+	var err error
+	out.Metadata, out.MetadataBump, err = FindTokenMetadataAddress(mint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the metadata address: %w", err)
+	}
+	out.MasterEdition, out.MasterEditionBump, err = FindMasterEditionAddress(mint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the master edition address: %w", err)
+	}
+	for _, owner := range owners {
+		address, _, err := FindAssociatedTokenAddress(owner, mint)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the associated token address of %s: %w", owner, err)
+		}
+		out.AssociatedTokenAccounts[owner] = address
+	}
+	return out, nil
+}
@@ -18,10 +18,12 @@
 package solana
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"flag"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -131,6 +133,41 @@ func TestPrivateKeyFromSolanaKeygenFile(t *testing.T) {
 	}
 }
 
+func TestPrivateKeyFromBytes(t *testing.T) {
+	key := MustPrivateKeyFromBase58("66cDvko73yAf8LYvFMM3r8vF5vJtkk7JKMgEKwkmBC86oHdq41C7i1a2vS3zE1yCcdLLk6VUatUb32ZzVjSBXtRs")
+
+	t.Run("valid", func(t *testing.T) {
+		raw := append([]byte{}, key...)
+		actual, err := PrivateKeyFromBytes(raw)
+		require.NoError(t, err)
+		assert.Equal(t, key, actual)
+		assert.Equal(t, MustPublicKeyFromBase58("F8UvVsKnzWyp2nF8aDcqvQ2GVcRpqT91WDsAtvBKCMt9"), actual.PublicKey())
+
+		// The returned key doesn't share memory with the input.
+		raw[0]++
+		assert.Equal(t, key, actual)
+	})
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := PrivateKeyFromBytes(key[:63])
+		require.EqualError(t, err, "invalid private key length: expected 64 bytes, got 63")
+		_, err = PrivateKeyFromBytes(key[:32])
+		require.EqualError(t, err, "invalid private key length: expected 64 bytes, got 32")
+		_, err = PrivateKeyFromBytes(nil)
+		require.EqualError(t, err, "invalid private key length: expected 64 bytes, got 0")
+	})
+	t.Run("swapped halves", func(t *testing.T) {
+		swapped := append(append([]byte{}, key[32:]...), key[:32]...)
+		_, err := PrivateKeyFromBytes(swapped)
+		require.EqualError(t, err, "invalid private key: public key doesn't match the seed")
+	})
+	t.Run("wrong public key", func(t *testing.T) {
+		other := NewWallet().PrivateKey
+		mixed := append(append([]byte{}, key[:32]...), other[32:]...)
+		_, err := PrivateKeyFromBytes(mixed)
+		require.EqualError(t, err, "invalid private key: public key doesn't match the seed")
+	})
+}
+
 func TestPublicKey_MarshalText(t *testing.T) {
 	keyString := "4wBqpZM9k69W87zdYXT2bRtLViWqTiJV3i2Kn9q7S6j"
 	keyParsed := MustPublicKeyFromBase58(keyString)
@@ -174,6 +211,37 @@ func TestPublicKey_Flag(t *testing.T) {
 	}, key)
 }
 
+func TestPublicKey_Less(t *testing.T) {
+	keyWithBytes := func(b ...byte) PublicKey {
+		var key PublicKey
+		copy(key[:], b)
+		return key
+	}
+	// Same cases as the Rust SDK's derived Ord for Pubkey (byte-wise comparison).
+	zero := PublicKey{}
+	lastByte := keyWithBytes(0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1)
+	firstByte := keyWithBytes(1)
+	highTail := keyWithBytes(0, 0xff, 0xff, 0xff)
+
+	assert.True(t, zero.Less(lastByte))
+	assert.True(t, lastByte.Less(highTail))
+	assert.True(t, highTail.Less(firstByte))
+	assert.False(t, firstByte.Less(highTail))
+	assert.False(t, zero.Less(zero))
+
+	// The byte order is not the order of the base58 strings
+	// (which would put "9xQe..." first).
+	a := MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	b := MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	c := MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	slice := PublicKeySlice{a, b, c, SystemProgramID}
+	sort.Sort(slice)
+	assert.Equal(t, PublicKeySlice{SystemProgramID, a, b, c}, slice)
+	for i := 1; i < len(slice); i++ {
+		assert.Equal(t, -1, bytes.Compare(slice[i-1][:], slice[i][:]))
+	}
+}
+
 func TestPublicKeySlice(t *testing.T) {
 	{
 		slice := make(PublicKeySlice, 0)
@@ -618,3 +686,75 @@ func TestFindTokenMetadataAddress(t *testing.T) {
 	assert.Equal(t, metadataPDA, MustPublicKeyFromBase58("GfihrEYCPrvUyrMyMQPdhGEStxa9nKEK2Wfn9iK4AZq2"))
 	assert.Equal(t, bumpSeed, uint8(0xfd))
 }
+
+func TestMintPDAs(t *testing.T) {
+	mint := MustPublicKeyFromBase58("77K8mr457qxUSSNSfi4sSj5euP8DyuJJWHAUQVW8QCp3")
+	wallet := MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	other := MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+
+	pdas, err := MintPDAs(mint, wallet, other)
+	require.NoError(t, err)
+	assert.Equal(t, mint, pdas.Mint)
+	assert.Equal(t, MustPublicKeyFromBase58("GfihrEYCPrvUyrMyMQPdhGEStxa9nKEK2Wfn9iK4AZq2"), pdas.Metadata)
+	assert.Equal(t, uint8(0xfd), pdas.MetadataBump)
+
+	// ["metadata", program, mint, "edition"]
+	masterEdition, err := CreateProgramAddress(
+		[][]byte{[]byte("metadata"), TokenMetadataProgramID[:], mint[:], []byte("edition"), {pdas.MasterEditionBump}},
+		TokenMetadataProgramID,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, masterEdition, pdas.MasterEdition)
+	address, bumpSeed, err := FindMasterEditionAddress(mint)
+	require.NoError(t, err)
+	assert.Equal(t, pdas.MasterEdition, address)
+	assert.Equal(t, pdas.MasterEditionBump, bumpSeed)
+	assert.NotEqual(t, pdas.Metadata, pdas.MasterEdition)
+
+	require.Len(t, pdas.AssociatedTokenAccounts, 2)
+	for _, owner := range []PublicKey{wallet, other} {
+		ata, _, err := FindAssociatedTokenAddress(owner, mint)
+		require.NoError(t, err)
+		assert.Equal(t, ata, pdas.AssociatedTokenAccounts[owner])
+	}
+
+	pdas, err = MintPDAs(mint)
+	require.NoError(t, err)
+	assert.Empty(t, pdas.AssociatedTokenAccounts)
+}
+
+func TestFindAssociatedTokenAddress(t *testing.T) {
+	wallet := MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	// PayPal USD (PYUSD), a Token-2022 mint
+	// https://solscan.io/token/2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo
+	pyusd := MustPublicKeyFromBase58("2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo")
+	// USD Coin (USDC), a SPL Token mint
+	usdc := MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+
+	{
+		address, bumpSeed, err := FindAssociatedTokenAddress2022(wallet, pyusd)
+		require.NoError(t, err)
+		assert.Equal(t, MustPublicKeyFromBase58("897krAvWH3RbymaCYE3o9emopUwocieHuKTUk9nySpq6"), address)
+		assert.Equal(t, uint8(255), bumpSeed)
+
+		got, gotBumpSeed, err := FindAssociatedTokenAddressForProgram(wallet, pyusd, Token2022ProgramID)
+		require.NoError(t, err)
+		assert.Equal(t, address, got)
+		assert.Equal(t, bumpSeed, gotBumpSeed)
+
+		// The token program is part of the seeds.
+		legacy, _, err := FindAssociatedTokenAddress(wallet, pyusd)
+		require.NoError(t, err)
+		assert.Equal(t, MustPublicKeyFromBase58("FmhJoVUEFyAg8M4AYT9FpWdiKyH3Rtd1iq5hcPuuKdDP"), legacy)
+	}
+	{
+		address, bumpSeed, err := FindAssociatedTokenAddress(wallet, usdc)
+		require.NoError(t, err)
+		assert.Equal(t, MustPublicKeyFromBase58("FGETo8T8wMcN2wCjav8VK6eh3dLk63evNDPxzLSJra8B"), address)
+		assert.Equal(t, uint8(254), bumpSeed)
+
+		got, _, err := FindAssociatedTokenAddressForProgram(wallet, usdc, TokenProgramID)
+		require.NoError(t, err)
+		assert.Equal(t, address, got)
+	}
+}
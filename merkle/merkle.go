@@ -0,0 +1,139 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package merkle verifies the proofs of the SPL concurrent merkle trees
+// (the account compression program), which hold the compressed NFTs of Bubblegum,
+// e.g. the proofs returned by the getAssetProof method of the DAS API.
+//
+// As in the on-chain programs, the nodes are hashed with keccak256 (not sha256),
+// and the pairs are not sorted: the parent of two nodes is keccak256(left || right),
+// where the side of each node of the path is given by the bits of the leaf index.
+// An empty leaf is 32 zero bytes.
+package merkle
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/crypto/sha3"
+)
+
+// BubblegumProgramID is the ID of the Bubblegum program (compressed NFTs).
+var BubblegumProgramID = solana.MustPublicKeyFromBase58("BGUMAp9Gq7iTEuizy4pqaxsTyUCBK68MDfK752saRPUY")
+
+// Keccak256 returns the keccak256 hash of the concatenation of the values.
+func Keccak256(values ...[]byte) solana.Hash {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, value := range values {
+		hasher.Write(value)
+	}
+	var out solana.Hash
+	copy(out[:], hasher.Sum(nil))
+	return out
+}
+
+// HashPair returns the parent of two sibling nodes.
+func HashPair(left, right solana.Hash) solana.Hash {
+	return Keccak256(left[:], right[:])
+}
+
+// EmptyNode returns the node of an empty subtree of the given height
+// (0 for an empty leaf).
+func EmptyNode(level int) solana.Hash {
+	var node solana.Hash
+	for i := 0; i < level; i++ {
+		node = HashPair(node, node)
+	}
+	return node
+}
+
+// ComputeRoot returns the root of the tree that contains the leaf at the index,
+// given the proof of the leaf: its sibling, then the sibling of its parent, and so on
+// up to the children of the root (the depth of the tree is the length of the proof).
+func ComputeRoot(leaf solana.Hash, proof []solana.Hash, index uint32) solana.Hash {
+	node := leaf
+	for i, sibling := range proof {
+		if (index>>uint(i))&1 == 0 {
+			node = HashPair(node, sibling)
+		} else {
+			node = HashPair(sibling, node)
+		}
+	}
+	return node
+}
+
+// VerifyProof tells whether the proof of the leaf at the index leads to the root.
+func VerifyProof(root solana.Hash, leaf solana.Hash, proof []solana.Hash, index uint32) bool {
+	if len(proof) < 32 && uint64(index) >= uint64(1)<<uint(len(proof)) {
+		// The index doesn't fit in a tree of that depth.
+		return false
+	}
+	return ComputeRoot(leaf, proof, index) == root
+}
+
+// LeafIndex returns the index of a leaf given its node index, as returned
+// by getAssetProof (the index of the node in the whole tree,
+// counting from the root at 1), and the depth of the tree.
+func LeafIndex(nodeIndex uint64, depth int) (uint32, error) {
+	first := uint64(1) << uint(depth)
+	if nodeIndex < first || nodeIndex-first > uint64(^uint32(0)) || nodeIndex-first >= first {
+		return 0, fmt.Errorf("node index %d is not a leaf of a tree of depth %d", nodeIndex, depth)
+	}
+	return uint32(nodeIndex - first), nil
+}
+
+// LeafSchema is the content of a leaf of a Bubblegum tree (version 1).
+type LeafSchema struct {
+	// The asset ID (see FindAssetID).
+	ID       solana.PublicKey
+	Owner    solana.PublicKey
+	Delegate solana.PublicKey
+	// The index of the mint in the tree.
+	Nonce       uint64
+	DataHash    solana.Hash
+	CreatorHash solana.Hash
+}
+
+// leafSchemaVersionV1 is the version byte of LeafSchema::V1.
+const leafSchemaVersionV1 = 1
+
+// Hash returns the leaf node: the keccak256 hash of the version,
+// the ID, the owner, the delegate, the nonce (u64, little-endian),
+// the data hash and the creator hash.
+func (leaf LeafSchema) Hash() solana.Hash {
+	nonce := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nonce, leaf.Nonce)
+	return Keccak256(
+		[]byte{leafSchemaVersionV1},
+		leaf.ID[:],
+		leaf.Owner[:],
+		leaf.Delegate[:],
+		nonce,
+		leaf.DataHash[:],
+		leaf.CreatorHash[:],
+	)
+}
+
+// FindAssetID returns the ID of the compressed NFT minted
+// with the nonce in the tree.
+func FindAssetID(tree solana.PublicKey, nonce uint64) (solana.PublicKey, error) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, nonce)
+	id, _, err := solana.FindProgramAddress(
+		[][]byte{[]byte("asset"), tree[:], buf},
+		BubblegumProgramID,
+	)
+	return id, err
+}
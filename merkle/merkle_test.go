@@ -0,0 +1,123 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkle
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The vectors below were computed with an independent implementation
+// of keccak256 and of the hashing scheme of the programs.
+
+func hashes(values ...string) []solana.Hash {
+	out := make([]solana.Hash, len(values))
+	for i, value := range values {
+		out[i] = solana.MustHashFromBase58(value)
+	}
+	return out
+}
+
+func TestKeccak256(t *testing.T) {
+	empty := Keccak256()
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(empty[:]))
+	abc := Keccak256([]byte("a"), []byte("bc"))
+	assert.Equal(t, "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45", hex.EncodeToString(abc[:]))
+}
+
+func TestVerifyProof(t *testing.T) {
+	// A tree of depth 3, with 5 leaves (keccak256 of the bytes 0 to 4) and 3 empty leaves.
+	root := solana.MustHashFromBase58("B5xeCy7zmVXWDqtpWp7jnEEtB56w58RgzbPr26uBbu1V")
+
+	leaf2 := Keccak256([]byte{2})
+	assert.Equal(t, solana.MustHashFromBase58("HMJEAYsRzRD3Xa9dBNMb61npjMsfT1t5QgDsccz9rMr1"), leaf2)
+	proof2 := hashes(
+		"87rPVVJ7Eq3gY9sogZZ3UcP4rkDLrAxizoZpUULKZsFL",
+		"6uu1dhLGpdnr1hHMegaJ4XPQ9BJeucaLxZkzG3iQZSDb",
+		"AZ9YZi6Som2z4zPkT26KuoymTQEPfKW3LcRFNbsrymu3",
+	)
+	assert.Equal(t, root, ComputeRoot(leaf2, proof2, 2))
+	assert.True(t, VerifyProof(root, leaf2, proof2, 2))
+
+	// An empty leaf.
+	proof5 := hashes(
+		"HNbgkDYAxm2iWbWFA5K86zdkibiof7PE3GYizjmMhGGJ",
+		"Cf5tmmFZ4D31tviuJezHdFLf5WF7yFvzfxNyftKsqTwr",
+		"Ftvp9zkWkqpi4Fo5ndz1N2TZ8jPHxfSE8adtH3TbNLoS",
+	)
+	assert.Equal(t, EmptyNode(1), proof5[1])
+	assert.True(t, VerifyProof(root, solana.Hash{}, proof5, 5))
+
+	// The side of the nodes matters.
+	assert.False(t, VerifyProof(root, leaf2, proof2, 3))
+	assert.False(t, VerifyProof(root, leaf2, proof2, 2+8))
+	assert.False(t, VerifyProof(root, Keccak256([]byte{3}), proof2, 2))
+	assert.False(t, VerifyProof(root, leaf2, proof2[:2], 2))
+}
+
+func TestEmptyNode(t *testing.T) {
+	assert.Equal(t, solana.Hash{}, EmptyNode(0))
+	assert.Equal(t,
+		hashes(
+			"Cf5tmmFZ4D31tviuJezHdFLf5WF7yFvzfxNyftKsqTwr",
+			"DAbAU9srHpEUogXWuhy5VZ7g8UX9STymELtndcx1xgP1",
+			"3HCYqQRcQSChEuAw1ybNYHibrTNNjzbYzm56cmEmivB6",
+		),
+		[]solana.Hash{EmptyNode(1), EmptyNode(2), EmptyNode(3)},
+	)
+}
+
+func TestLeafSchema_Hash(t *testing.T) {
+	leaf := LeafSchema{
+		ID:          solana.MustPublicKeyFromBase58("FNiKANejAn3VguZYF6vGtHiWXEYWsoMLEiGh1xr6sB6F"),
+		Owner:       solana.MustPublicKeyFromBase58("67vHA8qZGCJKw1UNGUJZME4MwEWDRGWzp7MGvsut43A8"),
+		Delegate:    solana.MustPublicKeyFromBase58("8UopbHH1AeJB4b2pYYtK1d1dDVwGut9yqKjTVfvzU3f9"),
+		Nonce:       42,
+		DataHash:    solana.MustHashFromBase58("4w6YMt6nH3oSsA1uTk6Vmqvqsa9eSquKSAxWtDWPrzTg"),
+		CreatorHash: solana.MustHashFromBase58("3DirommdiGK6tuQdxWJLs5ChRV9C7HxPjhpXJDyCcxcu"),
+	}
+	assert.Equal(t, solana.MustHashFromBase58("GL1QpVTjMW5gxiv1tTQnDozz8vAb7SoBbbbmUY8MA2xz"), leaf.Hash())
+}
+
+func TestLeafIndex(t *testing.T) {
+	index, err := LeafIndex(8+5, 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), index)
+	index, err = LeafIndex(1<<14, 14)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), index)
+
+	_, err = LeafIndex(7, 3)
+	require.Error(t, err)
+	_, err = LeafIndex(16, 3)
+	require.Error(t, err)
+}
+
+func TestFindAssetID(t *testing.T) {
+	tree := solana.MustPublicKeyFromBase58("FNiKANejAn3VguZYF6vGtHiWXEYWsoMLEiGh1xr6sB6F")
+	for nonce, expected := range map[uint64]string{
+		0:  "7bpyrucnNAZuXmrNW5pw8JYNUAKQ8LBbdoAqSTe7Akdt",
+		42: "4kzaYRPNDw4nEyiDparf9WT49tEjtVfhvNmUGZiaogt1",
+		43: "55siUSMtRbPhECrEmLVerHxc5ZJu8Y86SNSnGGyZsVqb",
+	} {
+		id, err := FindAssetID(tree, nonce)
+		require.NoError(t, err)
+		assert.Equal(t, solana.MustPublicKeyFromBase58(expected), id, nonce)
+	}
+}
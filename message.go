@@ -79,6 +79,27 @@ func (slice Uint8SliceAsNum) MarshalJSON() ([]byte, error) {
 	return json.Marshal(out)
 }
 
+// UnmarshalJSON implements json.Unmarshaler.
+func (slice *Uint8SliceAsNum) UnmarshalJSON(data []byte) error {
+	var in []uint16
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in == nil {
+		*slice = nil
+		return nil
+	}
+	out := make(Uint8SliceAsNum, len(in))
+	for i, idx := range in {
+		if idx > 255 {
+			return fmt.Errorf("index %d out of uint8 range: %d", i, idx)
+		}
+		out[i] = uint8(idx)
+	}
+	*slice = out
+	return nil
+}
+
 type MessageVersion int
 
 const (
@@ -244,12 +265,15 @@ func (mx *Message) EncodeToTree(txTree treeout.Branches) {
 			accountKeysBranch.Child(text.RedBG(fmt.Sprintf("AccountMetaList: %s", err)))
 		} else {
 			for keyIndex, key := range accountKeys {
+				line := text.ColorizeBG(key.PublicKey.String())
+				if name, ok := WellKnownName(key.PublicKey); ok {
+					line += " " + text.Sf("(%s)", name)
+				}
 				isFromTable := mx.IsVersioned() && keyIndex >= mx.numStaticAccounts()
 				if isFromTable {
-					accountKeysBranch.Child(text.Sf("%s (from Address Table Lookup)", text.ColorizeBG(key.PublicKey.String())))
-				} else {
-					accountKeysBranch.Child(text.ColorizeBG(key.PublicKey.String()))
+					line += " (from Address Table Lookup)"
 				}
+				accountKeysBranch.Child(line)
 			}
 		}
 	})
@@ -463,6 +487,57 @@ func (mx *Message) ResolveLookups() (err error) {
 	return nil
 }
 
+// SetLoadedAddresses resolves the address table lookups with the addresses loaded
+// from the tables, as returned by the node in the metadata of the transaction
+// (meta.loadedAddresses): the writable addresses of all the lookups, in order,
+// and then the readonly ones. The address tables are not needed.
+//
+// Afterwards, the account keys of the message are the static keys, followed by
+// the loaded writable and then readonly addresses: the order of the account indexes
+// of the instructions (see CompiledInstruction.ResolveInstructionAccounts).
+func (mx *Message) SetLoadedAddresses(writable, readonly PublicKeySlice) error {
+	if mx.resolved {
+		return nil
+	}
+	lookups := mx.AddressTableLookups
+	if len(writable) != lookups.NumWritableLookups() ||
+		len(writable)+len(readonly) != lookups.NumLookups() {
+		return fmt.Errorf(
+			"loaded addresses (%d writable, %d readonly) don't match the address table lookups",
+			len(writable), len(readonly),
+		)
+	}
+	if lookups.NumLookups() == 0 {
+		return nil
+	}
+	// Rebuild the (relevant part of the) tables.
+	tables := make(map[PublicKey]PublicKeySlice)
+	set := func(table PublicKey, index uint8, address PublicKey) {
+		entries := tables[table]
+		for len(entries) <= int(index) {
+			entries = append(entries, PublicKey{})
+		}
+		entries[index] = address
+		tables[table] = entries
+	}
+	for _, lookup := range lookups {
+		for _, index := range lookup.WritableIndexes {
+			set(lookup.AccountKey, index, writable[0])
+			writable = writable[1:]
+		}
+	}
+	for _, lookup := range lookups {
+		for _, index := range lookup.ReadonlyIndexes {
+			set(lookup.AccountKey, index, readonly[0])
+			readonly = readonly[1:]
+		}
+	}
+	if err := mx.SetAddressTables(tables); err != nil {
+		return err
+	}
+	return mx.ResolveLookups()
+}
+
 // GetAllKeys returns ALL the message's account keys (including the keys from resolved address lookup tables).
 func (mx Message) GetAllKeys() (keys PublicKeySlice, err error) {
 	if mx.resolved {
@@ -493,8 +568,10 @@ func (mx *Message) UnmarshalV0(decoder *bin.Decoder) (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to read message version: %w", err)
 	}
-	// TODO: check version
-	mx.version = MessageVersion(version - 127)
+	if version != 127+byte(MessageVersionV0) {
+		return fmt.Errorf("unsupported message version prefix: %#x", version)
+	}
+	mx.version = MessageVersionV0
 
 	// The middle of the message is the same as the legacy message:
 	err = mx.UnmarshalLegacy(decoder)
@@ -507,6 +584,10 @@ func (mx *Message) UnmarshalV0(decoder *bin.Decoder) (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to read address table lookups length: %w", err)
 	}
+	// Each lookup is at least a pubkey and two empty index arrays.
+	if int(addressTableLookupsLen) > decoder.Remaining()/(32+1+1) {
+		return fmt.Errorf("address table lookups length %d is too large for remaining bytes %d", addressTableLookupsLen, decoder.Remaining())
+	}
 	if addressTableLookupsLen > 0 {
 		mx.AddressTableLookups = make([]MessageAddressTableLookup, addressTableLookupsLen)
 		for i := 0; i < int(addressTableLookupsLen); i++ {
@@ -517,7 +598,7 @@ func (mx *Message) UnmarshalV0(decoder *bin.Decoder) (err error) {
 			}
 
 			// read writable indexes
-			writableIndexesLen, err := decoder.ReadCompactU16()
+			writableIndexesLen, err := readCompactU16(decoder)
 			if err != nil {
 				return fmt.Errorf("failed to read writable indexes length: %w", err)
 			}
@@ -531,7 +612,7 @@ func (mx *Message) UnmarshalV0(decoder *bin.Decoder) (err error) {
 			}
 
 			// read readonly indexes
-			readonlyIndexesLen, err := decoder.ReadCompactU16()
+			readonlyIndexesLen, err := readCompactU16(decoder)
 			if err != nil {
 				return fmt.Errorf("failed to read readonly indexes length: %w", err)
 			}
@@ -564,7 +645,7 @@ func (mx *Message) UnmarshalLegacy(decoder *bin.Decoder) (err error) {
 		}
 	}
 	{
-		numAccountKeys, err := decoder.ReadCompactU16()
+		numAccountKeys, err := readCompactU16(decoder)
 		if err != nil {
 			return fmt.Errorf("unable to decode numAccountKeys: %w", err)
 		}
@@ -586,7 +667,7 @@ func (mx *Message) UnmarshalLegacy(decoder *bin.Decoder) (err error) {
 		}
 	}
 	{
-		numInstructions, err := decoder.ReadCompactU16()
+		numInstructions, err := readCompactU16(decoder)
 		if err != nil {
 			return fmt.Errorf("unable to decode numInstructions: %w", err)
 		}
@@ -602,7 +683,7 @@ func (mx *Message) UnmarshalLegacy(decoder *bin.Decoder) (err error) {
 			mx.Instructions[instructionIndex].ProgramIDIndex = uint16(programIDIndex)
 
 			{
-				numAccounts, err := decoder.ReadCompactU16()
+				numAccounts, err := readCompactU16(decoder)
 				if err != nil {
 					return fmt.Errorf("unable to decode numAccounts for ix[%d]: %w", instructionIndex, err)
 				}
@@ -619,7 +700,7 @@ func (mx *Message) UnmarshalLegacy(decoder *bin.Decoder) (err error) {
 				}
 			}
 			{
-				dataLen, err := decoder.ReadCompactU16()
+				dataLen, err := readCompactU16(decoder)
 				if err != nil {
 					return fmt.Errorf("unable to decode dataLen for ix[%d]: %w", instructionIndex, err)
 				}
@@ -772,12 +853,19 @@ func (m Message) IsSigner(account PublicKey) bool {
 	// signers always in AccountKeys
 	for idx, acc := range m.AccountKeys {
 		if acc.Equals(account) {
-			return idx < int(m.Header.NumRequiredSignatures)
+			return m.IsSignerIndex(idx)
 		}
 	}
 	return false
 }
 
+// IsSignerIndex reports whether the account at the provided index
+// of the account keys of the message is a signer,
+// i.e. whether it is one of the first `NumRequiredSignatures` keys.
+func (m Message) IsSignerIndex(index int) bool {
+	return index >= 0 && index < int(m.Header.NumRequiredSignatures) && index < len(m.AccountKeys)
+}
+
 // numStaticAccounts returns the number of accounts that are always present in the
 // account keys list (i.e. all the accounts that are NOT in the lookup table).
 func (m Message) numStaticAccounts() int {
@@ -815,15 +903,36 @@ func (m Message) IsWritable(account PublicKey) (bool, error) {
 	if !found {
 		return false, err
 	}
-	h := m.Header
+	return m.IsWritableIndex(index), nil
+}
 
-	if index >= m.numStaticAccounts() {
-		return m.isWritableInLookups(index), nil
-	} else if index >= int(h.NumRequiredSignatures) {
-		// unsignedAccountIndex < numWritableUnsignedAccounts
-		return index-int(h.NumRequiredSignatures) < (m.numStaticAccounts()-int(h.NumRequiredSignatures))-int(h.NumReadonlyUnsignedAccounts), nil
+// IsWritableIndex reports whether the account at the provided index is writable.
+// The index is in the list of all the accounts of the message: the account keys,
+// followed by the writable and then the readonly accounts loaded from the address tables
+// (the address tables are not needed).
+//
+// The account keys are partitioned by the header:
+//
+//	[ writable signers | readonly signers | writable non-signers | readonly non-signers ]
+//	  <------ NumRequiredSignatures ----->
+//	                     <-- NumReadonlySigned -->          <-- NumReadonlyUnsigned -->
+//
+// This is only what the message requests: the runtime also treats
+// program IDs and reserved accounts as readonly.
+func (m Message) IsWritableIndex(index int) bool {
+	numStatic := m.numStaticAccounts()
+	if index < 0 || index >= numStatic+m.NumLookups() {
+		return false
+	}
+	if index >= numStatic {
+		return m.isWritableInLookups(index)
+	}
+	h := m.Header
+	numSigners := int(h.NumRequiredSignatures)
+	if index < numSigners {
+		return index < numSigners-int(h.NumReadonlySignedAccounts)
 	}
-	return index < int(h.NumRequiredSignatures-h.NumReadonlySignedAccounts), nil
+	return index < numStatic-int(h.NumReadonlyUnsignedAccounts)
 }
 
 func (m Message) signerKeys() []PublicKey {
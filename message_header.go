@@ -0,0 +1,304 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RecomputeHeader rebuilds the header of the message from the roles of its accounts,
+// e.g. after a signer changed in a built (or decoded) message.
+// The account keys are reordered as expected by the header
+// (writable signers, read-only signers, writable non-signers, read-only non-signers),
+// and the account indexes of the instructions are remapped accordingly.
+//
+// The role of an account is the one given by its position according to the current header,
+// except that the programs invoked by the instructions are read-only non-signers,
+// merged with its metas in `metas`: it is a signer or writable if any of them is.
+// The fee payer (the first account key) stays first, as a writable signer.
+//
+// As the header counts the read-only non-signers from the end of the account keys,
+// appending keys shifts them: to add an instruction, use AddInstruction instead.
+func (mx *Message) RecomputeHeader(metas ...*AccountMeta) error {
+	roles, err := mx.accountRoles()
+	if err != nil {
+		return err
+	}
+	for _, meta := range metas {
+		if err := roles.merge(meta); err != nil {
+			return err
+		}
+	}
+	return mx.applyRoles(roles)
+}
+
+// AddInstruction compiles the instruction into the message: its new accounts
+// (and program) are added to the account keys, and the header is recomputed
+// with the privileges requested by the instruction (see RecomputeHeader).
+func (mx *Message) AddInstruction(inst Instruction) error {
+	if mx.NumLookups() > 0 {
+		return fmt.Errorf("cannot add an instruction to a message with address table lookups")
+	}
+	roles, err := mx.accountRoles()
+	if err != nil {
+		return err
+	}
+	keys := mx.AccountKeys
+	programID := inst.ProgramID()
+	for _, key := range append([]PublicKey{programID}, AccountMetaSlice(inst.Accounts()).GetKeys()...) {
+		if _, ok := roles.index[key]; !ok {
+			roles.index[key] = len(keys)
+			keys = append(keys, key)
+			roles.signers = append(roles.signers, false)
+			roles.writable = append(roles.writable, false)
+		}
+	}
+	programIndex := roles.index[programID]
+	roles.signers[programIndex], roles.writable[programIndex] = false, false
+	for _, meta := range inst.Accounts() {
+		if err := roles.merge(meta); err != nil {
+			return err
+		}
+	}
+
+	if len(keys) > 256 {
+		return fmt.Errorf("message would have %d account keys, more than the maximum of 256", len(keys))
+	}
+	accountIndex := make(map[PublicKey]uint16, len(roles.index))
+	for key, i := range roles.index {
+		accountIndex[key] = uint16(i)
+	}
+	compiled, err := CompileInstruction(inst, accountIndex)
+	if err != nil {
+		return err
+	}
+	mx.AccountKeys = keys
+	mx.Instructions = append(mx.Instructions, compiled)
+	return mx.applyRoles(roles)
+}
+
+// accountRoles are the signer and writable flags of the account keys of a message.
+type accountRoles struct {
+	index    map[PublicKey]int
+	signers  []bool
+	writable []bool
+}
+
+func (roles *accountRoles) merge(meta *AccountMeta) error {
+	i, ok := roles.index[meta.PublicKey]
+	if !ok {
+		return fmt.Errorf("account %s is not in the account keys of the message", meta.PublicKey)
+	}
+	roles.signers[i] = roles.signers[i] || meta.IsSigner
+	roles.writable[i] = roles.writable[i] || meta.IsWritable
+	return nil
+}
+
+// accountRoles returns the roles of the account keys according to the header,
+// with the invoked programs as read-only non-signers.
+func (mx *Message) accountRoles() (*accountRoles, error) {
+	if mx.resolved {
+		return nil, fmt.Errorf("cannot recompute the header of a message with resolved address table lookups")
+	}
+	numKeys := len(mx.AccountKeys)
+	if numKeys == 0 {
+		return nil, fmt.Errorf("message has no account keys")
+	}
+	roles := &accountRoles{
+		index:    make(map[PublicKey]int, numKeys),
+		signers:  make([]bool, numKeys),
+		writable: make([]bool, numKeys),
+	}
+	for i, key := range mx.AccountKeys {
+		if _, ok := roles.index[key]; ok {
+			return nil, fmt.Errorf("account key %s appears more than once", key)
+		}
+		roles.index[key] = i
+		roles.signers[i] = mx.IsSignerIndex(i)
+		roles.writable[i] = mx.IsWritableIndex(i)
+	}
+	for _, inst := range mx.Instructions {
+		if int(inst.ProgramIDIndex) < numKeys {
+			roles.signers[inst.ProgramIDIndex] = false
+			roles.writable[inst.ProgramIDIndex] = false
+		}
+	}
+	return roles, nil
+}
+
+// applyRoles reorders the account keys by role, remaps the account indexes
+// of the instructions, and sets the header accordingly.
+func (mx *Message) applyRoles(roles *accountRoles) error {
+	numKeys := len(mx.AccountKeys)
+	if numKeys > 256 {
+		return fmt.Errorf("message has %d account keys, more than the maximum of 256", numKeys)
+	}
+	signers, writable := roles.signers, roles.writable
+	signers[0], writable[0] = true, true
+
+	// A stable partition, so that the fee payer stays first,
+	// and the relative order of the other accounts is preserved.
+	order := make([]int, 0, numKeys)
+	for _, class := range []struct{ signer, writable bool }{{true, true}, {true, false}, {false, true}, {false, false}} {
+		for i := range mx.AccountKeys {
+			if signers[i] == class.signer && writable[i] == class.writable {
+				order = append(order, i)
+			}
+		}
+	}
+
+	var header MessageHeader
+	newIndex := make([]uint16, numKeys)
+	keys := make([]PublicKey, numKeys)
+	for newPos, oldPos := range order {
+		newIndex[oldPos] = uint16(newPos)
+		keys[newPos] = mx.AccountKeys[oldPos]
+		if signers[oldPos] {
+			header.NumRequiredSignatures++
+			if !writable[oldPos] {
+				header.NumReadonlySignedAccounts++
+			}
+		} else if !writable[oldPos] {
+			header.NumReadonlyUnsignedAccounts++
+		}
+	}
+	remap := func(i uint16) uint16 {
+		// The accounts loaded from the address tables come after the account keys, and don't move.
+		if int(i) < numKeys {
+			return newIndex[i]
+		}
+		return i
+	}
+	for i := range mx.Instructions {
+		inst := &mx.Instructions[i]
+		inst.ProgramIDIndex = remap(inst.ProgramIDIndex)
+		accounts := make([]uint16, len(inst.Accounts))
+		for j, account := range inst.Accounts {
+			accounts[j] = remap(account)
+		}
+		inst.Accounts = accounts
+	}
+	mx.AccountKeys = keys
+	mx.Header = header
+	return nil
+}
+
+var (
+	// ErrInvalidMessageHeader is a violation of Message.Validate:
+	// the counts of the header don't fit the account keys.
+	ErrInvalidMessageHeader = errors.New("invalid message header")
+	// ErrInvalidFeePayer is a violation of Message.Validate:
+	// the first account key is not a writable signer.
+	ErrInvalidFeePayer = errors.New("invalid fee payer")
+	// ErrDuplicateAccountKey is a violation of Message.Validate.
+	ErrDuplicateAccountKey = errors.New("duplicate account key")
+	// ErrAccountIndexOutOfRange is a violation of Message.Validate:
+	// an instruction references an account that is not in the message.
+	ErrAccountIndexOutOfRange = errors.New("account index out of range")
+	// ErrMissingRecentBlockhash is a violation of Message.Validate.
+	ErrMissingRecentBlockhash = errors.New("missing recent blockhash")
+)
+
+// MessageValidationError is returned by Message.Validate, with all the violations found.
+// Each violation wraps one of the ErrInvalidMessageHeader, ErrInvalidFeePayer,
+// ErrDuplicateAccountKey, ErrAccountIndexOutOfRange and ErrMissingRecentBlockhash errors,
+// and errors.Is reports whether any of the violations matches.
+type MessageValidationError struct {
+	Violations []error
+}
+
+func (e *MessageValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Error()
+	}
+	return "invalid message: " + strings.Join(messages, "; ")
+}
+
+func (e *MessageValidationError) Is(target error) bool {
+	for _, violation := range e.Violations {
+		if errors.Is(violation, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the consistency of the message, as the node would before executing it:
+// the counts of the header against the account keys, the fee payer (the first account key,
+// which must be a writable signer), the uniqueness of the account keys, the account indexes
+// of all the instructions, and the recent blockhash.
+// All the violations found are returned, in a *MessageValidationError.
+func (mx Message) Validate() error {
+	var violations []error
+	violate := func(kind error, format string, args ...interface{}) {
+		violations = append(violations, fmt.Errorf("%w: %s", kind, fmt.Sprintf(format, args...)))
+	}
+
+	numStatic := mx.numStaticAccounts()
+	h := mx.Header
+	numSigners := int(h.NumRequiredSignatures)
+	if numSigners > numStatic {
+		violate(ErrInvalidMessageHeader, "%d required signatures, but only %d account keys", numSigners, numStatic)
+	}
+	if int(h.NumReadonlySignedAccounts) > numSigners {
+		violate(ErrInvalidMessageHeader, "%d read-only signed accounts, but only %d required signatures", h.NumReadonlySignedAccounts, numSigners)
+	}
+	if numSigners <= numStatic && int(h.NumReadonlyUnsignedAccounts) > numStatic-numSigners {
+		violate(ErrInvalidMessageHeader, "%d read-only unsigned accounts, but only %d unsigned account keys", h.NumReadonlyUnsignedAccounts, numStatic-numSigners)
+	}
+
+	switch {
+	case numStatic == 0:
+		violate(ErrInvalidFeePayer, "the message has no account keys")
+	case numSigners == 0:
+		violate(ErrInvalidFeePayer, "%s is not a signer", mx.AccountKeys[0])
+	case int(h.NumReadonlySignedAccounts) == numSigners:
+		violate(ErrInvalidFeePayer, "%s is not writable", mx.AccountKeys[0])
+	}
+
+	seen := make(map[PublicKey]int, len(mx.AccountKeys))
+	for i, key := range mx.AccountKeys {
+		if first, ok := seen[key]; ok {
+			violate(ErrDuplicateAccountKey, "%s at indexes %d and %d", key, first, i)
+			continue
+		}
+		seen[key] = i
+	}
+
+	numAccounts := numStatic + mx.NumLookups()
+	for i, inst := range mx.Instructions {
+		// The programs can't be loaded from the address tables.
+		if int(inst.ProgramIDIndex) >= numStatic {
+			violate(ErrAccountIndexOutOfRange, "instruction %d: program index %d, but only %d account keys", i, inst.ProgramIDIndex, numStatic)
+		}
+		for j, account := range inst.Accounts {
+			if int(account) >= numAccounts {
+				violate(ErrAccountIndexOutOfRange, "instruction %d: account %d has index %d, but only %d accounts", i, j, account, numAccounts)
+			}
+		}
+	}
+
+	if mx.RecentBlockhash.IsZero() {
+		violate(ErrMissingRecentBlockhash, "the recent blockhash is zero")
+	}
+
+	if len(violations) > 0 {
+		return &MessageValidationError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_RecomputeHeader(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	from := NewWallet().PublicKey()
+	to := NewWallet().PublicKey()
+	program := NewWallet().PublicKey()
+	first := &testTransactionInstructions{
+		accounts: []*AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: to, IsWritable: true},
+			{PublicKey: SysVarClockPubkey},
+		},
+		programID: SystemProgramID,
+	}
+	newTx := func(t *testing.T) *Transaction {
+		tx, err := NewTransaction([]Instruction{first}, Hash{1}, TransactionPayer(payer))
+		require.NoError(t, err)
+		return tx
+	}
+
+	t.Run("unchanged message", func(t *testing.T) {
+		tx := newTx(t)
+		expected := newTx(t)
+		require.NoError(t, tx.Message.RecomputeHeader())
+		assert.Equal(t, expected.Message, tx.Message)
+	})
+
+	t.Run("instruction added", func(t *testing.T) {
+		tx := newTx(t)
+		added := &testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: to, IsWritable: true},
+				{PublicKey: NewWallet().PublicKey(), IsWritable: true},
+				{PublicKey: NewWallet().PublicKey()},
+			},
+			programID: program,
+		}
+		require.NoError(t, tx.Message.AddInstruction(added))
+		requireValidHeader(t, tx, []Instruction{first, added})
+		require.NoError(t, tx.Message.Validate())
+
+		// The invoked programs are read-only.
+		index := tx.Message.Instructions[1].ProgramIDIndex
+		assert.Equal(t, program, tx.Message.AccountKeys[index])
+		assert.False(t, tx.Message.IsWritableIndex(int(index)))
+		assert.False(t, tx.Message.IsSignerIndex(int(index)))
+	})
+
+	t.Run("signer added", func(t *testing.T) {
+		tx := newTx(t)
+		authority := NewWallet().PublicKey()
+		added := &testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: authority, IsSigner: true},
+				{PublicKey: from, IsWritable: true},
+			},
+			programID: program,
+		}
+		require.NoError(t, tx.Message.AddInstruction(added))
+		requireValidHeader(t, tx, []Instruction{first, added})
+		assert.Equal(t, MessageHeader{
+			NumRequiredSignatures:       3,
+			NumReadonlySignedAccounts:   1,
+			NumReadonlyUnsignedAccounts: 3,
+		}, tx.Message.Header)
+		assert.Equal(t, []PublicKey{payer, from, authority}, []PublicKey(tx.Message.Signers()))
+		assert.Equal(t, []PublicKey{payer, from, authority}, tx.MissingSigners())
+	})
+
+	t.Run("signer replaced", func(t *testing.T) {
+		tx := newTx(t)
+		header := tx.Message.Header
+		other := NewWallet().PublicKey()
+		tx.Message.AccountKeys[1] = other
+		require.NoError(t, tx.Message.RecomputeHeader())
+		assert.Equal(t, header, tx.Message.Header)
+		assert.Equal(t, []PublicKey{payer, other}, []PublicKey(tx.Message.Signers()))
+	})
+
+	t.Run("privileges merged", func(t *testing.T) {
+		tx := newTx(t)
+		require.NoError(t, tx.Message.RecomputeHeader(&AccountMeta{PublicKey: SysVarClockPubkey, IsWritable: true}))
+		requireValidHeader(t, tx, []Instruction{first})
+		assert.Equal(t, []PublicKey{payer, from}, []PublicKey(tx.Message.Signers()))
+		clock := tx.Message.Instructions[0].Accounts[2]
+		assert.Equal(t, SysVarClockPubkey, tx.Message.AccountKeys[clock])
+		assert.True(t, tx.Message.IsWritableIndex(int(clock)))
+	})
+
+	t.Run("fee payer", func(t *testing.T) {
+		tx := newTx(t)
+		require.NoError(t, tx.Message.RecomputeHeader(&AccountMeta{PublicKey: payer}))
+		assert.Equal(t, payer, tx.Message.AccountKeys[0])
+		assert.True(t, tx.Message.IsSignerIndex(0))
+		assert.True(t, tx.Message.IsWritableIndex(0))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tx := newTx(t)
+		require.Error(t, tx.Message.RecomputeHeader(&AccountMeta{PublicKey: NewWallet().PublicKey()}))
+
+		tx.Message.AccountKeys = append(tx.Message.AccountKeys, to)
+		require.Error(t, tx.Message.RecomputeHeader())
+
+		require.Error(t, new(Message).RecomputeHeader())
+
+		tx = newTx(t)
+		tx.Message.AddAddressTableLookup(MessageAddressTableLookup{AccountKey: NewWallet().PublicKey(), WritableIndexes: []uint8{0}})
+		require.Error(t, tx.Message.AddInstruction(first))
+	})
+}
+
+func TestMessage_Validate(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	newMessage := func() Message {
+		tx, err := NewTransaction([]Instruction{
+			&testTransactionInstructions{
+				accounts: []*AccountMeta{
+					{PublicKey: payer, IsSigner: true, IsWritable: true},
+					{PublicKey: NewWallet().PublicKey(), IsWritable: true},
+				},
+				programID: SystemProgramID,
+			},
+		}, Hash{1}, TransactionPayer(payer))
+		require.NoError(t, err)
+		return tx.Message
+	}
+	require.NoError(t, newMessage().Validate())
+
+	violations := func(t *testing.T, msg Message) []error {
+		err := msg.Validate()
+		require.Error(t, err)
+		var validationErr *MessageValidationError
+		require.True(t, errors.As(err, &validationErr))
+		return validationErr.Violations
+	}
+	tests := []struct {
+		name   string
+		mutate func(msg *Message)
+		kind   error
+	}{
+		{"too many signers", func(msg *Message) { msg.Header.NumRequiredSignatures = 4 }, ErrInvalidMessageHeader},
+		{"too many read-only signers", func(msg *Message) { msg.Header.NumReadonlySignedAccounts = 2 }, ErrInvalidMessageHeader},
+		{"too many read-only non-signers", func(msg *Message) { msg.Header.NumReadonlyUnsignedAccounts = 3 }, ErrInvalidMessageHeader},
+		{"fee payer not a signer", func(msg *Message) {
+			msg.Header.NumRequiredSignatures = 0
+			msg.Header.NumReadonlyUnsignedAccounts = 1
+		}, ErrInvalidFeePayer},
+		{"fee payer read-only", func(msg *Message) { msg.Header.NumReadonlySignedAccounts = 1 }, ErrInvalidFeePayer},
+		{"duplicate account key", func(msg *Message) { msg.AccountKeys[1] = payer }, ErrDuplicateAccountKey},
+		{"program index", func(msg *Message) { msg.Instructions[0].ProgramIDIndex = 3 }, ErrAccountIndexOutOfRange},
+		{"account index", func(msg *Message) { msg.Instructions[0].Accounts[1] = 7 }, ErrAccountIndexOutOfRange},
+		{"zero blockhash", func(msg *Message) { msg.RecentBlockhash = Hash{} }, ErrMissingRecentBlockhash},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := newMessage()
+			test.mutate(&msg)
+			found := violations(t, msg)
+			require.Len(t, found, 1, found)
+			assert.True(t, errors.Is(found[0], test.kind), found[0])
+			assert.True(t, errors.Is(msg.Validate(), test.kind))
+		})
+	}
+
+	t.Run("all the violations", func(t *testing.T) {
+		msg := newMessage()
+		msg.Header.NumReadonlySignedAccounts = 1
+		msg.Instructions[0].ProgramIDIndex = 9
+		msg.Instructions[0].Accounts[0] = 8
+		msg.RecentBlockhash = Hash{}
+		err := msg.Validate()
+		found := violations(t, msg)
+		require.Len(t, found, 4, found)
+		for _, kind := range []error{ErrInvalidFeePayer, ErrAccountIndexOutOfRange, ErrMissingRecentBlockhash} {
+			assert.True(t, errors.Is(err, kind), kind)
+		}
+		assert.False(t, errors.Is(err, ErrDuplicateAccountKey))
+		assert.Contains(t, err.Error(), "instruction 0: program index 9")
+		assert.Contains(t, err.Error(), "instruction 0: account 0 has index 8")
+	})
+
+	t.Run("no account keys", func(t *testing.T) {
+		found := violations(t, Message{RecentBlockhash: Hash{1}})
+		require.Len(t, found, 1)
+		assert.True(t, errors.Is(found[0], ErrInvalidFeePayer))
+	})
+}
+
+func TestTransactionBuilder_Build_validates(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	builder := NewTransactionBuilder().
+		AddInstruction(NewInstruction(SystemProgramID, AccountMetaSlice{Meta(payer).WRITE().SIGNER()}, []byte{0x01})).
+		SetFeePayer(payer)
+	_, err := builder.Build()
+	require.True(t, errors.Is(err, ErrMissingRecentBlockhash), err)
+
+	tx, err := builder.SetRecentBlockHash(Hash{1}).Build()
+	require.NoError(t, err)
+	require.NoError(t, tx.Message.Validate())
+}
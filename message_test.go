@@ -0,0 +1,126 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_IsWritableIndex_IsSignerIndex(t *testing.T) {
+	keys := make(PublicKeySlice, 6)
+	for i := range keys {
+		keys[i] = PublicKey{byte(i + 1)}
+	}
+	message := Message{
+		AccountKeys: keys,
+		Header: MessageHeader{
+			NumRequiredSignatures:       3,
+			NumReadonlySignedAccounts:   1,
+			NumReadonlyUnsignedAccounts: 2,
+		},
+	}
+
+	type role struct{ signer, writable bool }
+	expected := []role{
+		{signer: true, writable: true},  // writable signer (fee payer)
+		{signer: true, writable: true},  // writable signer
+		{signer: true, writable: false}, // readonly signer
+		{signer: false, writable: true}, // writable non-signer
+		{signer: false, writable: false},
+		{signer: false, writable: false},
+	}
+	for i, exp := range expected {
+		assert.Equal(t, exp.signer, message.IsSignerIndex(i), "signer %d", i)
+		assert.Equal(t, exp.writable, message.IsWritableIndex(i), "writable %d", i)
+
+		// Same as the lookups by key.
+		assert.Equal(t, exp.signer, message.IsSigner(keys[i]), "signer %d", i)
+		writable, err := message.IsWritable(keys[i])
+		require.NoError(t, err)
+		assert.Equal(t, exp.writable, writable, "writable %d", i)
+	}
+	for _, index := range []int{-1, 6, 300} {
+		assert.False(t, message.IsSignerIndex(index))
+		assert.False(t, message.IsWritableIndex(index))
+	}
+
+	t.Run("address table lookups", func(t *testing.T) {
+		v0 := message
+		v0.SetVersion(MessageVersionV0)
+		v0.SetAddressTableLookups([]MessageAddressTableLookup{
+			{AccountKey: PublicKey{0xaa}, WritableIndexes: []uint8{0, 3}, ReadonlyIndexes: []uint8{1}},
+			{AccountKey: PublicKey{0xbb}, WritableIndexes: []uint8{2}, ReadonlyIndexes: []uint8{4, 5}},
+		})
+		// Loaded accounts: 3 writable (6, 7, 8), then 3 readonly (9, 10, 11).
+		for i := 0; i < 6; i++ {
+			assert.Equal(t, expected[i].writable, v0.IsWritableIndex(i), "writable %d", i)
+		}
+		for i := 6; i < 12; i++ {
+			assert.Equal(t, i < 9, v0.IsWritableIndex(i), "writable %d", i)
+			assert.False(t, v0.IsSignerIndex(i), "signer %d", i)
+		}
+		assert.False(t, v0.IsWritableIndex(12))
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		malformed := Message{
+			AccountKeys: keys[:2],
+			Header: MessageHeader{
+				NumRequiredSignatures:       3,
+				NumReadonlySignedAccounts:   5,
+				NumReadonlyUnsignedAccounts: 9,
+			},
+		}
+		for i := 0; i < 3; i++ {
+			assert.False(t, malformed.IsWritableIndex(i), "writable %d", i)
+		}
+		assert.True(t, malformed.IsSignerIndex(1))
+		assert.False(t, malformed.IsSignerIndex(2))
+	})
+}
+
+func TestMessage_IsWritableIndex_compiledTransaction(t *testing.T) {
+	payer := PublicKey{1}
+	metas := AccountMetaSlice{
+		Meta(payer).WRITE().SIGNER(),
+		Meta(PublicKey{2}).SIGNER(),
+		Meta(PublicKey{3}).WRITE(),
+		Meta(PublicKey{4}),
+		Meta(PublicKey{5}).WRITE().SIGNER(),
+	}
+	tx, err := NewTransaction(
+		[]Instruction{NewInstruction(PublicKey{9}, metas, []byte{1})},
+		Hash{1},
+		TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+
+	// The program is a readonly non-signer.
+	metas = append(metas, Meta(PublicKey{9}))
+	require.Len(t, tx.Message.AccountKeys, len(metas))
+	byKey := make(map[PublicKey]*AccountMeta)
+	for _, meta := range metas {
+		byKey[meta.PublicKey] = meta
+	}
+	for i, key := range tx.Message.AccountKeys {
+		meta, ok := byKey[key]
+		require.True(t, ok)
+		assert.Equal(t, meta.IsSigner, tx.Message.IsSignerIndex(i), "signer %s", key)
+		assert.Equal(t, meta.IsWritable, tx.Message.IsWritableIndex(i), "writable %s", key)
+	}
+}
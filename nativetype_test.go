@@ -16,6 +16,7 @@ package solana
 
 import (
 	"encoding/base64"
+	"math/rand"
 	"strconv"
 	"testing"
 
@@ -100,6 +101,33 @@ func TestMustSignatureFromBase58(t *testing.T) {
 	})
 }
 
+func TestSignature_Short(t *testing.T) {
+	sig := MustSignatureFromBase58("5yUSwqQqeZLEEYKxnG4JC4XhaaBpV3RS4nQbK8bQTyjLX5btVq9A1Ja5nuJzV7Z3Zq8G6EVKFvN4DKUL6PSAxmTk")
+	assert.Equal(t, "5yUS...xmTk", sig.Short())
+	assert.Equal(t, "1111...1111", Signature{}.Short())
+}
+
+func TestSignatureSlice(t *testing.T) {
+	sig1 := MustSignatureFromBase58("5yUSwqQqeZLEEYKxnG4JC4XhaaBpV3RS4nQbK8bQTyjLX5btVq9A1Ja5nuJzV7Z3Zq8G6EVKFvN4DKUL6PSAxmTk")
+	sig2 := Signature{1, 2, 3}
+
+	slice := make(SignatureSlice, 0)
+	require.False(t, slice.Has(sig1))
+	require.True(t, slice.UniqueAppend(sig1))
+	require.False(t, slice.UniqueAppend(sig1))
+	require.True(t, slice.UniqueAppend(sig2))
+	require.True(t, slice.Has(sig1))
+	require.True(t, slice.Has(sig2))
+	require.Equal(t, 2, slice.Len())
+
+	slice.Append(sig1)
+	require.Equal(t, 3, slice.Len())
+	require.Equal(t,
+		[]string{sig1.String(), sig2.String(), sig1.String()},
+		slice.Strings(),
+	)
+}
+
 func TestBase58(t *testing.T) {
 	in := "RYcCwZg97M2jet84ttG8"
 
@@ -377,3 +405,103 @@ func TestSignatureVerify(t *testing.T) {
 		}
 	}
 }
+
+func TestBase58_matchesMrTron(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var pubkey PublicKey
+		var sig Signature
+		rng.Read(pubkey[:])
+		rng.Read(sig[:])
+		// With leading zeros too.
+		for j := rng.Intn(4); j > 0; j-- {
+			pubkey[j-1] = 0
+			sig[j-1] = 0
+		}
+
+		expected := base58.Encode(pubkey[:])
+		require.Equal(t, expected, pubkey.String())
+		require.Equal(t, expected, Hash(pubkey).String())
+		text, err := pubkey.MarshalText()
+		require.NoError(t, err)
+		require.Equal(t, expected, string(text))
+		text, err = Hash(pubkey).MarshalText()
+		require.NoError(t, err)
+		require.Equal(t, expected, string(text))
+		jsonOut, err := pubkey.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, strconv.Quote(expected), string(jsonOut))
+		jsonOut, err = Hash(pubkey).MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, strconv.Quote(expected), string(jsonOut))
+		decoded, err := PublicKeyFromBase58(expected)
+		require.NoError(t, err)
+		require.Equal(t, pubkey, decoded)
+
+		expected = base58.Encode(sig[:])
+		require.Equal(t, expected, sig.String())
+		text, err = sig.MarshalText()
+		require.NoError(t, err)
+		require.Equal(t, expected, string(text))
+		jsonOut, err = sig.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, strconv.Quote(expected), string(jsonOut))
+		decodedSig, err := SignatureFromBase58(expected)
+		require.NoError(t, err)
+		require.Equal(t, sig, decodedSig)
+		var fromJSON Signature
+		require.NoError(t, fromJSON.UnmarshalJSON(jsonOut))
+		require.Equal(t, sig, fromJSON)
+	}
+
+	// The errors are the same as before.
+	_, err := PublicKeyFromBase58("0")
+	require.EqualError(t, err, "decode: invalid base58 digit ('0')")
+	_, err = PublicKeyFromBase58("1")
+	require.EqualError(t, err, "invalid length, expected 32, got 1")
+	_, err = SignatureFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	require.EqualError(t, err, "invalid length, expected 64, got 32")
+	var sig Signature
+	err = sig.UnmarshalJSON([]byte(`"4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw"`))
+	require.EqualError(t, err, "invalid length for Signature, expected 64, got 32")
+	require.True(t, sig.IsZero())
+}
+
+func BenchmarkPublicKey_String(b *testing.B) {
+	pubkey := MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = pubkey.String()
+	}
+}
+
+func BenchmarkPublicKey_MarshalJSON(b *testing.B) {
+	pubkey := MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = pubkey.MarshalJSON()
+	}
+}
+
+func BenchmarkPublicKeyFromBase58(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = PublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	}
+}
+
+func BenchmarkSignature_String(b *testing.B) {
+	sig := MustSignatureFromBase58("5yUSwqQqeZLEEYKxnG4JC4XhaaBpV3RS4nQbK8bQTyjLX5btVq9A1Ja5nuJzV7Z3Zq8G6EVKFvN4DKUL6PSAxmTk")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = sig.String()
+	}
+}
+
+func BenchmarkSignature_MarshalJSON(b *testing.B) {
+	sig := MustSignatureFromBase58("5yUSwqQqeZLEEYKxnG4JC4XhaaBpV3RS4nQbK8bQTyjLX5btVq9A1Ja5nuJzV7Z3Zq8G6EVKFvN4DKUL6PSAxmTk")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = sig.MarshalJSON()
+	}
+}
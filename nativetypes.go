@@ -24,6 +24,7 @@ import (
 	"io"
 
 	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go/internal/base58codec"
 	"github.com/mostynb/zstdpool-freelist"
 	"github.com/mr-tron/base58"
 )
@@ -54,8 +55,7 @@ func HashFromBytes(in []byte) Hash {
 
 // MarshalText implements encoding.TextMarshaler.
 func (ha Hash) MarshalText() ([]byte, error) {
-	s := base58.Encode(ha[:])
-	return []byte(s), nil
+	return base58codec.AppendBase58_32(make([]byte, 0, base58codec.MaxEncodedLen32), (*[32]byte)(&ha)), nil
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
@@ -69,7 +69,7 @@ func (ha *Hash) UnmarshalText(data []byte) (err error) {
 }
 
 func (ha Hash) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(ha[:]))
+	return marshalJSONBase58_32((*[32]byte)(&ha)), nil
 }
 
 func (ha *Hash) UnmarshalJSON(data []byte) (err error) {
@@ -97,7 +97,7 @@ func (ha Hash) IsZero() bool {
 }
 
 func (ha Hash) String() string {
-	return base58.Encode(ha[:])
+	return base58codec.EncodeBase58_32((*[32]byte)(&ha))
 }
 
 type Signature [64]byte
@@ -114,6 +114,10 @@ func (sig Signature) Equals(pb Signature) bool {
 
 // SignatureFromBase58 decodes a base58 string into a Signature.
 func SignatureFromBase58(in string) (out Signature, err error) {
+	if base58codec.DecodeBase58_64((*[SignatureLength]byte)(&out), in) {
+		return out, nil
+	}
+	// Not a valid signature: decode it again, to return the same errors as before.
 	val, err := base58.Decode(in)
 	if err != nil {
 		return
@@ -154,8 +158,7 @@ func SignatureFromBytes(in []byte) (out Signature) {
 }
 
 func (p Signature) MarshalText() ([]byte, error) {
-	s := base58.Encode(p[:])
-	return []byte(s), nil
+	return base58codec.AppendBase58_64(make([]byte, 0, base58codec.MaxEncodedLen64), (*[SignatureLength]byte)(&p)), nil
 }
 
 func (p *Signature) UnmarshalText(data []byte) (err error) {
@@ -168,7 +171,11 @@ func (p *Signature) UnmarshalText(data []byte) (err error) {
 }
 
 func (p Signature) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(p[:]))
+	// The base58 alphabet doesn't need escaping.
+	out := make([]byte, 0, base58codec.MaxEncodedLen64+2)
+	out = append(out, '"')
+	out = base58codec.AppendBase58_64(out, (*[SignatureLength]byte)(&p))
+	return append(out, '"'), nil
 }
 
 func (p *Signature) UnmarshalJSON(data []byte) (err error) {
@@ -178,6 +185,11 @@ func (p *Signature) UnmarshalJSON(data []byte) (err error) {
 		return
 	}
 
+	var target Signature
+	if base58codec.DecodeBase58_64((*[SignatureLength]byte)(&target), s) {
+		*p = target
+		return nil
+	}
 	dat, err := base58.Decode(s)
 	if err != nil {
 		return err
@@ -187,7 +199,6 @@ func (p *Signature) UnmarshalJSON(data []byte) (err error) {
 		return fmt.Errorf("invalid length for Signature, expected 64, got %d", len(dat))
 	}
 
-	target := Signature{}
 	copy(target[:], dat)
 	*p = target
 	return
@@ -199,7 +210,55 @@ func (s Signature) Verify(pubkey PublicKey, msg []byte) bool {
 }
 
 func (p Signature) String() string {
-	return base58.Encode(p[:])
+	return base58codec.EncodeBase58_64((*[SignatureLength]byte)(&p))
+}
+
+// Short returns a shortened signature string,
+// only including the first 4 chars, ellipsis, and the last 4 characters.
+// NOTE: this is ONLY for visual representation for humans (e.g. logs),
+// and cannot be used for anything else.
+func (p Signature) Short() string {
+	str := p.String()
+	return str[:4] + "..." + str[len(str)-4:]
+}
+
+type SignatureSlice []Signature
+
+// UniqueAppend appends the provided signature only if it is not
+// already present in the slice.
+// Returns true when the provided signature wasn't already present.
+func (slice *SignatureSlice) UniqueAppend(sig Signature) bool {
+	if !slice.Has(sig) {
+		slice.Append(sig)
+		return true
+	}
+	return false
+}
+
+func (slice *SignatureSlice) Append(sigs ...Signature) {
+	*slice = append(*slice, sigs...)
+}
+
+func (slice SignatureSlice) Has(sig Signature) bool {
+	for _, s := range slice {
+		if s.Equals(sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func (slice SignatureSlice) Len() int {
+	return len(slice)
+}
+
+// Strings returns the base58 encoding of each signature.
+func (slice SignatureSlice) Strings() []string {
+	out := make([]string, len(slice))
+	for i, sig := range slice {
+		out[i] = sig.String()
+	}
+	return out
 }
 
 type Base58 []byte
@@ -0,0 +1,94 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TxPeek is the fixed-layout prefix of a serialized transaction (see PeekTransaction).
+type TxPeek struct {
+	// The number of signatures of the transaction.
+	NumSignatures int
+	// The first signature, i.e. the ID of the transaction.
+	Signature Signature
+	// The first account key of the message.
+	FeePayer        PublicKey
+	RecentBlockhash Hash
+	// Whether the message is versioned (v0), rather than legacy.
+	Versioned bool
+}
+
+// ErrNoSignatures is returned by PeekTransaction for a transaction without signatures.
+var ErrNoSignatures = errors.New("transaction has no signatures")
+
+// PeekTransaction reads the signature count, the first signature, the fee payer,
+// the recent blockhash and the version of a serialized transaction,
+// without decoding the whole transaction: only the prefix of the transaction
+// up to the recent blockhash is read, and nothing is allocated on success.
+// The rest of the transaction is not validated.
+func PeekTransaction(raw []byte) (TxPeek, error) {
+	var out TxPeek
+	numSignatures, size, err := DecodeCompactU16(raw)
+	if err != nil {
+		return out, fmt.Errorf("unable to read the number of signatures: %w", err)
+	}
+	if numSignatures == 0 {
+		return out, ErrNoSignatures
+	}
+	out.NumSignatures = numSignatures
+	pos := size
+	if len(raw)-pos < numSignatures*SignatureLength {
+		return out, fmt.Errorf("unable to read %d signatures: %w", numSignatures, io.ErrUnexpectedEOF)
+	}
+	copy(out.Signature[:], raw[pos:])
+	pos += numSignatures * SignatureLength
+
+	if pos >= len(raw) {
+		return out, fmt.Errorf("unable to read the message: %w", io.ErrUnexpectedEOF)
+	}
+	if raw[pos]&0x80 != 0 {
+		version := raw[pos] & 0x7f
+		if version != 0 {
+			return out, fmt.Errorf("unsupported message version: %d", version)
+		}
+		out.Versioned = true
+		pos++
+	}
+
+	// The header: number of required signatures, of read-only signed accounts,
+	// and of read-only unsigned accounts.
+	pos += 3
+	if pos > len(raw) {
+		return out, fmt.Errorf("unable to read the message header: %w", io.ErrUnexpectedEOF)
+	}
+	numAccountKeys, size, err := DecodeCompactU16(raw[pos:])
+	if err != nil {
+		return out, fmt.Errorf("unable to read the number of account keys: %w", err)
+	}
+	if numAccountKeys == 0 {
+		return out, fmt.Errorf("message has no account keys")
+	}
+	pos += size
+	if len(raw)-pos < numAccountKeys*PublicKeyLength+len(out.RecentBlockhash) {
+		return out, fmt.Errorf("unable to read %d account keys and the recent blockhash: %w", numAccountKeys, io.ErrUnexpectedEOF)
+	}
+	copy(out.FeePayer[:], raw[pos:])
+	pos += numAccountKeys * PublicKeyLength
+	copy(out.RecentBlockhash[:], raw[pos:])
+	return out, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var peekTransactions = map[string]string{
+	"legacy":                 "AfjEs3XhTc3hrxEvlnMPkm/cocvAUbFNbCl00qKnrFue6J53AhEqIFmcJJlJW3EDP5RmcMz+cNTTcZHW/WJYwAcBAAEDO8hh4VddzfcO5jbCt95jryl6y8ff65UcgukHNLWH+UQGgxCGGpgyfQVQV02EQYqm4QwzUt2qf9f1gVLM7rI4hwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6ANIF55zOZWROWRkeh+lExxZBnKFqbvIxZDLE7EijjoBAgIAAQwCAAAAOTAAAAAAAAA=",
+	"legacy, two signatures": "Ak8jvC3ch5hq3lhOHPkACoFepIUON2zEN4KRcw4lDS6GBsQfnSdzNGPETm/yi0hPKk75/i2VXFj0FLUWnGR64ADyUbqnirFjFtaSNgcGi02+Tm7siT4CPpcaTq0jxfYQK/h9FdxXXPnLry74J+RE8yji/BtJ/Cjxbx+TIHigeIYJAgEBBByE1Y6EqCJKsr7iEupU6lsBHtBdtI4SK3yWMCFA0iEKeFPgnGmtp+1SIX1Ak+sN65iBaR7v4Iim5m1OEuFQTgi9N57UnhNpCNuUePaTt7HJaFBmyeZB3deXeKWVudpY3gAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWVECK/n3a7QR6OKWYR4DuAVjS6FXgZj82W0dJpSIPnEBAwQAAgEDDAIAAABAQg8AAAAAAA==",
+	"v0":                     "ARZsk8+AvvT9onUT8FU1VRaiC8Sp+FKveOwhdPoigWHA+MGNcIOqbow6mwSILEYvvyOB/fi3UQ/xKQCjEtxBRgIBAAIFKIX92BRrkgEfrLEXAvXtw7OgPPhHU+62C8DB5QPoMgNSbKXgdub0sr7Yp3Nvdrsp6SDoJ4gdoyRad2AV+Japj0dRtYW4OxE78FvRZTeqHFy2My/m12/afGIPS8iUnMGlBqfVFxjHdMkoVmOYaR1etoteuKObS21cc1VbIQAAAAC/jt8clGtWu0PSX5i4e2vlERcwCmEmGvn5+U7telqAiK4hdAN78GteFjqtJrxLXxpVNKsu1lfdcFPXa/Kcg4e5AQQEAQADAicmMiQQAiGujz0xoTQSQCgAMPOroDk5F0hQ/BgzEkBBvVKWIY41EkA=",
+}
+
+func TestPeekTransaction(t *testing.T) {
+	for name, b64 := range peekTransactions {
+		t.Run(name, func(t *testing.T) {
+			raw, err := base64.StdEncoding.DecodeString(b64)
+			require.NoError(t, err)
+			tx, err := TransactionFromBytes(raw)
+			require.NoError(t, err)
+
+			peek, err := PeekTransaction(raw)
+			require.NoError(t, err)
+			assert.Equal(t, len(tx.Signatures), peek.NumSignatures)
+			assert.Equal(t, tx.Signatures[0], peek.Signature)
+			assert.Equal(t, tx.Message.AccountKeys[0], peek.FeePayer)
+			assert.Equal(t, tx.Message.RecentBlockhash, peek.RecentBlockhash)
+			assert.Equal(t, (tx.Message.GetVersion() == MessageVersionV0), peek.Versioned)
+
+			allocs := testing.AllocsPerRun(10, func() {
+				PeekTransaction(raw)
+			})
+			assert.Zero(t, allocs)
+		})
+	}
+}
+
+func TestPeekTransaction_truncated(t *testing.T) {
+	for name, b64 := range peekTransactions {
+		t.Run(name, func(t *testing.T) {
+			raw, err := base64.StdEncoding.DecodeString(b64)
+			require.NoError(t, err)
+			tx, err := TransactionFromBytes(raw)
+			require.NoError(t, err)
+
+			// The prefix ends with the recent blockhash.
+			prefix := 1 + len(tx.Signatures)*SignatureLength + 3 + 1 + len(tx.Message.AccountKeys)*PublicKeyLength + 32
+			if tx.Message.GetVersion() == MessageVersionV0 {
+				prefix++
+			}
+			for i := 0; i < prefix; i++ {
+				_, err := PeekTransaction(raw[:i])
+				require.Error(t, err, "prefix of %d bytes", i)
+				assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), err)
+			}
+			peek, err := PeekTransaction(raw[:prefix])
+			require.NoError(t, err)
+			assert.Equal(t, tx.Message.RecentBlockhash, peek.RecentBlockhash)
+		})
+	}
+}
+
+func TestPeekTransaction_malformed(t *testing.T) {
+	signature := make([]byte, SignatureLength)
+	key := make([]byte, PublicKeyLength)
+	concat := func(parts ...[]byte) []byte {
+		var out []byte
+		for _, part := range parts {
+			out = append(out, part...)
+		}
+		return out
+	}
+
+	_, err := PeekTransaction([]byte{0})
+	assert.Equal(t, ErrNoSignatures, err)
+
+	_, err = PeekTransaction([]byte{0x80, 0x00})
+	assert.True(t, errors.Is(err, ErrInvalidCompactU16), err)
+
+	// More signatures than bytes.
+	_, err = PeekTransaction(concat([]byte{0xff, 0xff, 0x03}, signature))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), err)
+
+	// Unsupported message version.
+	_, err = PeekTransaction(concat([]byte{1}, signature, []byte{0x81, 1, 0, 0, 1}, key, key))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported message version: 1")
+
+	// No account keys.
+	_, err = PeekTransaction(concat([]byte{1}, signature, []byte{1, 0, 0, 0}, key))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no account keys")
+
+	// More account keys than bytes.
+	_, err = PeekTransaction(concat([]byte{1}, signature, []byte{1, 0, 0, 0xff, 0x01}, key, key))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), err)
+}
+
+func BenchmarkPeekTransaction(b *testing.B) {
+	raw, err := base64.StdEncoding.DecodeString(peekTransactions["legacy, two signatures"])
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := PeekTransaction(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPeekTransaction_fullDecode(b *testing.B) {
+	raw, err := base64.StdEncoding.DecodeString(peekTransactions["legacy, two signatures"])
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := TransactionFromBytes(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
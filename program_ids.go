@@ -14,6 +14,10 @@
 
 package solana
 
+import (
+	"strings"
+)
+
 var (
 	// Create new accounts, allocate account data, assign accounts to owning programs,
 	// transfer lamports from System Program owned accounts and pay transacation fees.
@@ -38,7 +42,15 @@ var (
 
 	FeatureProgramID = MustPublicKeyFromBase58("Feature111111111111111111111111111111111111")
 
-	ComputeBudget = MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+	// Verify ed25519 signatures.
+	Ed25519ProgramID = MustPublicKeyFromBase58("Ed25519SigVerify111111111111111111111111111")
+
+	// Request compute units and set the priority fee of transactions.
+	ComputeBudgetProgramID = MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+	ComputeBudget          = ComputeBudgetProgramID
+
+	// Create and manage address lookup tables for v0 transactions.
+	AddressLookupTableProgramID = MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
 )
 
 // SPL:
@@ -47,6 +59,10 @@ var (
 	// This program defines a common implementation for Fungible and Non Fungible tokens.
 	TokenProgramID = MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
 
+	// The Token-2022 program (a.k.a. Token Extensions), a superset
+	// of the Token program that supports extensions.
+	Token2022ProgramID = MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+
 	// A Uniswap-like exchange for the Token program on the Solana blockchain,
 	// implementing multiple automated market maker (AMM) curves.
 	TokenSwapProgramID = MustPublicKeyFromBase58("SwaPpA9LAaLfeLi3a68M4DjnLqgtticKg6CnyNwgAC8")
@@ -66,14 +82,118 @@ var (
 	// and know they were approved by zero or more addresses
 	// by inspecting the transaction log from a trusted provider.
 	MemoProgramID = MustPublicKeyFromBase58("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	// MemoV2ProgramID is the current version of the Memo program (same as MemoProgramID).
+	MemoV2ProgramID = MemoProgramID
+	// MemoV1ProgramID is the legacy version of the Memo program.
+	MemoV1ProgramID = MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
 )
 
 var (
 	// The Mint for native SOL Token accounts
-	SolMint    = MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
-	WrappedSol = SolMint
+	SolMint        = MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	WrappedSol     = SolMint
+	WrappedSOLMint = SolMint
 )
 
 var (
 	TokenMetadataProgramID = MustPublicKeyFromBase58("metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s")
 )
+
+type wellKnownAddress struct {
+	name     string
+	isSysvar bool
+}
+
+// wellKnownAddresses is the registry of the human names
+// of the well-known programs and sysvars.
+var wellKnownAddresses = map[PublicKey]wellKnownAddress{
+	SystemProgramID:                    {name: "System Program"},
+	ConfigProgramID:                    {name: "Config Program"},
+	StakeProgramID:                     {name: "Stake Program"},
+	VoteProgramID:                      {name: "Vote Program"},
+	BPFLoaderDeprecatedProgramID:       {name: "BPF Loader (deprecated)"},
+	BPFLoaderProgramID:                 {name: "BPF Loader"},
+	BPFLoaderUpgradeableProgramID:      {name: "BPF Loader Upgradeable"},
+	Secp256k1ProgramID:                 {name: "Secp256k1 Program"},
+	Ed25519ProgramID:                   {name: "Ed25519 Program"},
+	FeatureProgramID:                   {name: "Feature Program"},
+	ComputeBudgetProgramID:             {name: "Compute Budget Program"},
+	AddressLookupTableProgramID:        {name: "Address Lookup Table Program"},
+	TokenProgramID:                     {name: "Token Program"},
+	Token2022ProgramID:                 {name: "Token-2022 Program"},
+	TokenSwapProgramID:                 {name: "Token Swap Program"},
+	TokenLendingProgramID:              {name: "Token Lending Program"},
+	SPLAssociatedTokenAccountProgramID: {name: "Associated Token Account Program"},
+	MemoV1ProgramID:                    {name: "Memo Program v1"},
+	MemoV2ProgramID:                    {name: "Memo Program"},
+	TokenMetadataProgramID:             {name: "Token Metadata Program"},
+
+	SysVarClockPubkey:             {name: "Clock Sysvar", isSysvar: true},
+	SysVarEpochSchedulePubkey:     {name: "Epoch Schedule Sysvar", isSysvar: true},
+	SysVarFeesPubkey:              {name: "Fees Sysvar", isSysvar: true},
+	SysVarInstructionsPubkey:      {name: "Instructions Sysvar", isSysvar: true},
+	SysVarRecentBlockHashesPubkey: {name: "Recent Blockhashes Sysvar", isSysvar: true},
+	SysVarRentPubkey:              {name: "Rent Sysvar", isSysvar: true},
+	SysVarRewardsPubkey:           {name: "Rewards Sysvar", isSysvar: true},
+	SysVarSlotHashesPubkey:        {name: "Slot Hashes Sysvar", isSysvar: true},
+	SysVarSlotHistoryPubkey:       {name: "Slot History Sysvar", isSysvar: true},
+	SysVarStakeHistoryPubkey:      {name: "Stake History Sysvar", isSysvar: true},
+}
+
+// IsSysvar returns true if the provided address is a known sysvar.
+func IsSysvar(pubkey PublicKey) bool {
+	return wellKnownAddresses[pubkey].isSysvar
+}
+
+// IsWellKnownProgram returns the human name of the provided
+// address if it's a well-known (native or SPL) program; see ProgramName.
+func IsWellKnownProgram(pubkey PublicKey) (name string, ok bool) {
+	return ProgramName(pubkey)
+}
+
+// WellKnownName returns the human name of the provided address
+// if it's a well-known program or sysvar.
+func WellKnownName(pubkey PublicKey) (name string, ok bool) {
+	address, ok := wellKnownAddresses[pubkey]
+	return address.name, ok
+}
+
+// ProgramName returns the human name of the provided address
+// if it's a well-known (native or SPL) program, e.g. "System Program".
+func ProgramName(id PublicKey) (string, bool) {
+	address, ok := wellKnownAddresses[id]
+	if !ok || address.isSysvar {
+		return "", false
+	}
+	return address.name, true
+}
+
+// wellKnownProgramsByName indexes the well-known programs by normalized name.
+var wellKnownProgramsByName = func() map[string]PublicKey {
+	out := make(map[string]PublicKey)
+	for key, address := range wellKnownAddresses {
+		if !address.isSysvar {
+			out[normalizeProgramName(address.name)] = key
+		}
+	}
+	return out
+}()
+
+// ProgramID returns the address of the well-known program with the provided name,
+// as returned by ProgramName. The name is matched loosely, ignoring case,
+// punctuation and the word "program": "System Program", "system"
+// and "token-2022" are all valid names.
+func ProgramID(name string) (PublicKey, bool) {
+	id, ok := wellKnownProgramsByName[normalizeProgramName(name)]
+	return id, ok
+}
+
+func normalizeProgramName(name string) string {
+	var out strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			out.WriteRune(r)
+		}
+	}
+	return strings.Replace(out.String(), "program", "", -1)
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWellKnownAddresses(t *testing.T) {
+	expected := map[string]PublicKey{
+		"11111111111111111111111111111111":             SystemProgramID,
+		"ComputeBudget111111111111111111111111111111":  ComputeBudgetProgramID,
+		"AddressLookupTab1e1111111111111111111111111":  AddressLookupTableProgramID,
+		"Ed25519SigVerify111111111111111111111111111":  Ed25519ProgramID,
+		"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA":  TokenProgramID,
+		"TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb":  Token2022ProgramID,
+		"Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo":  MemoV1ProgramID,
+		"MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr":  MemoV2ProgramID,
+		"So11111111111111111111111111111111111111112":  WrappedSOLMint,
+		"SysvarC1ock11111111111111111111111111111111":  SysVarClockPubkey,
+		"SysvarRent111111111111111111111111111111111":  SysVarRentPubkey,
+		"ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL": SPLAssociatedTokenAccountProgramID,
+	}
+	for b58, key := range expected {
+		parsed, err := PublicKeyFromBase58(b58)
+		require.NoError(t, err)
+		require.Equal(t, key, parsed)
+		require.Equal(t, b58, key.String())
+	}
+
+	require.Equal(t, ComputeBudget, ComputeBudgetProgramID)
+	require.Equal(t, MemoProgramID, MemoV2ProgramID)
+	require.Equal(t, SolMint, WrappedSOLMint)
+
+	for key, address := range wellKnownAddresses {
+		parsed, err := PublicKeyFromBase58(key.String())
+		require.NoError(t, err)
+		require.Equal(t, key, parsed)
+		require.NotEmpty(t, address.name)
+	}
+}
+
+func TestIsSysvar(t *testing.T) {
+	require.True(t, IsSysvar(SysVarClockPubkey))
+	require.True(t, IsSysvar(SysVarInstructionsPubkey))
+	require.False(t, IsSysvar(SystemProgramID))
+	require.False(t, IsSysvar(NewWallet().PublicKey()))
+}
+
+func TestIsWellKnownProgram(t *testing.T) {
+	name, ok := IsWellKnownProgram(Token2022ProgramID)
+	require.True(t, ok)
+	require.Equal(t, "Token-2022 Program", name)
+
+	name, ok = IsWellKnownProgram(ComputeBudgetProgramID)
+	require.True(t, ok)
+	require.Equal(t, "Compute Budget Program", name)
+
+	_, ok = IsWellKnownProgram(SysVarRentPubkey)
+	require.False(t, ok)
+	_, ok = IsWellKnownProgram(NewWallet().PublicKey())
+	require.False(t, ok)
+
+	name, ok = WellKnownName(SysVarRentPubkey)
+	require.True(t, ok)
+	require.Equal(t, "Rent Sysvar", name)
+}
+
+func TestProgramName(t *testing.T) {
+	name, ok := ProgramName(SystemProgramID)
+	require.True(t, ok)
+	require.Equal(t, "System Program", name)
+
+	_, ok = ProgramName(SysVarClockPubkey)
+	require.False(t, ok)
+	_, ok = ProgramName(NewWallet().PublicKey())
+	require.False(t, ok)
+}
+
+func TestProgramID(t *testing.T) {
+	for _, name := range []string{"System Program", "system", "SYSTEM_PROGRAM", " system-program "} {
+		id, ok := ProgramID(name)
+		require.True(t, ok, name)
+		require.Equal(t, SystemProgramID, id, name)
+	}
+	for name, expected := range map[string]PublicKey{
+		"token":                    TokenProgramID,
+		"token-2022":               Token2022ProgramID,
+		"associated-token":         PublicKey{},
+		"associated-token-account": SPLAssociatedTokenAccountProgramID,
+		"memo":                     MemoProgramID,
+		"memo-v1":                  MemoV1ProgramID,
+		"compute-budget":           ComputeBudgetProgramID,
+		"bpf-loader-upgradeable":   BPFLoaderUpgradeableProgramID,
+		"rent-sysvar":              PublicKey{},
+		"":                         PublicKey{},
+	} {
+		id, ok := ProgramID(name)
+		require.Equal(t, !expected.IsZero(), ok, name)
+		require.Equal(t, expected, id, name)
+	}
+
+	// Every program can be found by its name, and no two names collide.
+	programs := 0
+	for key, address := range wellKnownAddresses {
+		if address.isSysvar {
+			continue
+		}
+		programs++
+		id, ok := ProgramID(address.name)
+		require.True(t, ok, address.name)
+		require.Equal(t, key, id, address.name)
+	}
+	require.Equal(t, programs, len(wellKnownProgramsByName))
+}
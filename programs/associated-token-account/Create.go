@@ -28,6 +28,8 @@ type Create struct {
 	Payer  solana.PublicKey `bin:"-" borsh_skip:"true"`
 	Wallet solana.PublicKey `bin:"-" borsh_skip:"true"`
 	Mint   solana.PublicKey `bin:"-" borsh_skip:"true"`
+	// The token program that owns the mint; defaults to the SPL Token program.
+	TokenProgram solana.PublicKey `bin:"-" borsh_skip:"true"`
 
 	// [0] = [WRITE, SIGNER] Payer
 	// ··········· Funding account
@@ -45,7 +47,7 @@ type Create struct {
 	// ··········· System program ID
 	//
 	// [5] = [] TokenProgram
-	// ··········· SPL token program ID
+	// ··········· SPL token program ID (or Token-2022 program ID)
 	//
 	// [6] = [] SysVarRent
 	// ··········· SysVarRentPubkey
@@ -73,12 +75,27 @@ func (inst *Create) SetMint(mint solana.PublicKey) *Create {
 	return inst
 }
 
+// SetTokenProgram sets the token program that owns the mint
+// (e.g. solana.Token2022ProgramID); defaults to solana.TokenProgramID.
+func (inst *Create) SetTokenProgram(tokenProgram solana.PublicKey) *Create {
+	inst.TokenProgram = tokenProgram
+	return inst
+}
+
+func (inst Create) tokenProgram() solana.PublicKey {
+	if inst.TokenProgram.IsZero() {
+		return solana.TokenProgramID
+	}
+	return inst.TokenProgram
+}
+
 func (inst Create) Build() *Instruction {
 
 	// Find the associatedTokenAddress;
-	associatedTokenAddress, _, _ := solana.FindAssociatedTokenAddress(
+	associatedTokenAddress, _, _ := solana.FindAssociatedTokenAddressForProgram(
 		inst.Wallet,
 		inst.Mint,
+		inst.tokenProgram(),
 	)
 
 	keys := []*solana.AccountMeta{
@@ -108,7 +125,7 @@ func (inst Create) Build() *Instruction {
 			IsWritable: false,
 		},
 		{
-			PublicKey:  solana.TokenProgramID,
+			PublicKey:  inst.tokenProgram(),
 			IsSigner:   false,
 			IsWritable: false,
 		},
@@ -147,12 +164,13 @@ func (inst *Create) Validate() error {
 	if inst.Mint.IsZero() {
 		return errors.New("Mint not set")
 	}
-	_, _, err := solana.FindAssociatedTokenAddress(
+	_, _, err := solana.FindAssociatedTokenAddressForProgram(
 		inst.Wallet,
 		inst.Mint,
+		inst.tokenProgram(),
 	)
 	if err != nil {
-		return fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
+		return fmt.Errorf("error while FindAssociatedTokenAddressForProgram: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,51 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package associatedtokenaccount
+
+import (
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate_tokenProgram(t *testing.T) {
+	payer := solana.MustPublicKeyFromBase58("7xKXtg2CW87d97TXJSDpbD5jBkheTqA83TZRuJosgAsU")
+	wallet := solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+
+	t.Run("Token-2022", func(t *testing.T) {
+		// PayPal USD (PYUSD)
+		mint := solana.MustPublicKeyFromBase58("2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo")
+		inst, err := NewCreateInstruction(payer, wallet, mint).
+			SetTokenProgram(solana.Token2022ProgramID).
+			ValidateAndBuild()
+		require.NoError(t, err)
+
+		accounts := inst.Accounts()
+		require.Len(t, accounts, 7)
+		require.Equal(t, solana.MustPublicKeyFromBase58("897krAvWH3RbymaCYE3o9emopUwocieHuKTUk9nySpq6"), accounts[1].PublicKey)
+		require.Equal(t, solana.Token2022ProgramID, accounts[5].PublicKey)
+	})
+	t.Run("SPL Token by default", func(t *testing.T) {
+		// USD Coin (USDC)
+		mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+		inst, err := NewCreateInstruction(payer, wallet, mint).ValidateAndBuild()
+		require.NoError(t, err)
+
+		accounts := inst.Accounts()
+		require.Equal(t, solana.MustPublicKeyFromBase58("FGETo8T8wMcN2wCjav8VK6eh3dLk63evNDPxzLSJra8B"), accounts[1].PublicKey)
+		require.Equal(t, solana.TokenProgramID, accounts[5].PublicKey)
+	})
+}
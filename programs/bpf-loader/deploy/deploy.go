@@ -0,0 +1,585 @@
+// Package deploy deploys and upgrades programs owned by the
+// BPF Loader Upgradeable, the same way `solana program deploy` does:
+// the program is first written to a buffer account in chunks,
+// and then deployed (or upgraded) from that buffer.
+//
+// Writing the buffer is resumable: if the same buffer keypair is used
+// again after a failure, the chunks that are already in the buffer are not sent again.
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	DefaultConcurrency    = 8
+	DefaultMaxRetries     = 5
+	DefaultPollInterval   = 500 * time.Millisecond
+	DefaultConfirmTimeout = 30 * time.Second
+)
+
+// ErrConfirmationTimeout is returned (wrapped) when a transaction
+// is not confirmed within ConfirmTimeout, nor before its blockhash expired,
+// for any of the attempts.
+var ErrConfirmationTimeout = errors.New("transaction confirmation timeout")
+
+type Stage string
+
+const (
+	StageCreateBuffer Stage = "create-buffer"
+	StageWrite        Stage = "write"
+	StageDeploy       Stage = "deploy"
+	StageUpgrade      Stage = "upgrade"
+)
+
+type Progress struct {
+	Stage Stage
+
+	// Number of Write chunks needed for the whole program.
+	ChunksTotal int
+	// Number of chunks that are in the buffer so far (including the skipped ones).
+	ChunksDone int
+	// Number of chunks that were already in the buffer (when resuming).
+	ChunksSkipped int
+
+	// The signature of the confirmed transaction that completed the step;
+	// zero for progress reports that didn't send a transaction.
+	Signature solana.Signature
+}
+
+type Deployer struct {
+	Client *rpc.Client
+
+	// Pays for the transactions and for the rent of the new accounts.
+	Payer solana.PrivateKey
+	// The authority of the buffer and the upgrade authority of the program.
+	// Defaults to Payer.
+	Authority solana.PrivateKey
+
+	// Max number of Write transactions in flight at the same time.
+	Concurrency int
+	// Max number of times a transaction is re-sent (with a new blockhash)
+	// after a send error or a confirmation timeout.
+	MaxRetries int
+	// Commitment used for reads, preflight and confirmation.
+	// Defaults to "confirmed".
+	Commitment rpc.CommitmentType
+	// How often the signature status is polled while waiting for confirmation.
+	PollInterval time.Duration
+	// How long to wait for the confirmation of a single attempt;
+	// past it, the transaction is sent again once its blockhash expired.
+	ConfirmTimeout time.Duration
+
+	// Called (never concurrently) after every step.
+	OnProgress func(Progress)
+
+	progressMu sync.Mutex
+}
+
+// New creates a Deployer with the default settings.
+func New(client *rpc.Client, payer solana.PrivateKey) *Deployer {
+	return &Deployer{
+		Client:         client,
+		Payer:          payer,
+		Authority:      payer,
+		Concurrency:    DefaultConcurrency,
+		MaxRetries:     DefaultMaxRetries,
+		Commitment:     rpc.CommitmentConfirmed,
+		PollInterval:   DefaultPollInterval,
+		ConfirmTimeout: DefaultConfirmTimeout,
+	}
+}
+
+// ReadProgramFile reads a program (a `.so` ELF file) from disk.
+func ReadProgramFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read program file: %w", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x7fELF")) {
+		return nil, fmt.Errorf("%q is not an ELF file", path)
+	}
+	return data, nil
+}
+
+// DeployFile is like Deploy, reading the program from the provided `.so` file.
+func (d *Deployer) DeployFile(
+	ctx context.Context,
+	program solana.PrivateKey,
+	buffer solana.PrivateKey,
+	path string,
+	maxDataLen int,
+) (solana.Signature, error) {
+	programData, err := ReadProgramFile(path)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	return d.Deploy(ctx, program, buffer, programData, maxDataLen)
+}
+
+// UpgradeFile is like Upgrade, reading the program from the provided `.so` file.
+func (d *Deployer) UpgradeFile(
+	ctx context.Context,
+	program solana.PublicKey,
+	buffer solana.PrivateKey,
+	path string,
+) (solana.Signature, error) {
+	programData, err := ReadProgramFile(path)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	return d.Upgrade(ctx, program, buffer, programData)
+}
+
+// Deploy writes the program to the buffer (see WriteBuffer), and then
+// creates the program account and deploys the buffer to it with DeployWithMaxDataLen.
+// If maxDataLen is zero, twice the program length is used (same as the solana CLI).
+func (d *Deployer) Deploy(
+	ctx context.Context,
+	program solana.PrivateKey,
+	buffer solana.PrivateKey,
+	programData []byte,
+	maxDataLen int,
+) (solana.Signature, error) {
+	if maxDataLen == 0 {
+		maxDataLen = len(programData) * 2
+	}
+	if maxDataLen < len(programData) {
+		return solana.Signature{}, fmt.Errorf("max data length %d is smaller than the program (%d bytes)", maxDataLen, len(programData))
+	}
+	if err := d.WriteBuffer(ctx, buffer, programData); err != nil {
+		return solana.Signature{}, err
+	}
+
+	programDataAddress, _, err := bpfloader.FindProgramDataAddress(program.PublicKey())
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("unable to derive programdata address: %w", err)
+	}
+	programLamports, err := d.Client.GetMinimumBalanceForRentExemption(
+		ctx,
+		uint64(bpfloader.UPGRADEABLE_PROGRAM_SIZE),
+		d.commitment(),
+	)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("unable to get rent for the program account: %w", err)
+	}
+
+	sig, err := d.sendAndConfirm(
+		ctx,
+		[]solana.Instruction{
+			system.NewCreateAccountInstruction(
+				programLamports,
+				uint64(bpfloader.UPGRADEABLE_PROGRAM_SIZE),
+				solana.BPFLoaderUpgradeableProgramID,
+				d.Payer.PublicKey(),
+				program.PublicKey(),
+			).Build(),
+			bpfloader.NewDeployWithMaxDataLenInstruction(
+				d.Payer.PublicKey(),
+				programDataAddress,
+				program.PublicKey(),
+				buffer.PublicKey(),
+				d.authority().PublicKey(),
+				uint64(maxDataLen),
+			),
+		},
+		d.Payer, d.authority(), program,
+	)
+	if err != nil {
+		return sig, fmt.Errorf("unable to deploy program: %w", err)
+	}
+	d.report(Progress{Stage: StageDeploy, Signature: sig})
+	return sig, nil
+}
+
+// Upgrade writes the program to the buffer (see WriteBuffer), and then
+// upgrades the existing program with it; the lamports of the buffer
+// are returned to the payer.
+func (d *Deployer) Upgrade(
+	ctx context.Context,
+	program solana.PublicKey,
+	buffer solana.PrivateKey,
+	programData []byte,
+) (solana.Signature, error) {
+	if err := d.WriteBuffer(ctx, buffer, programData); err != nil {
+		return solana.Signature{}, err
+	}
+
+	programDataAddress, _, err := bpfloader.FindProgramDataAddress(program)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("unable to derive programdata address: %w", err)
+	}
+
+	sig, err := d.sendAndConfirm(
+		ctx,
+		[]solana.Instruction{
+			bpfloader.NewUpgradeInstruction(
+				programDataAddress,
+				program,
+				buffer.PublicKey(),
+				d.Payer.PublicKey(),
+				d.authority().PublicKey(),
+			),
+		},
+		d.Payer, d.authority(),
+	)
+	if err != nil {
+		return sig, fmt.Errorf("unable to upgrade program: %w", err)
+	}
+	d.report(Progress{Stage: StageUpgrade, Signature: sig})
+	return sig, nil
+}
+
+type chunk struct {
+	offset int
+	data   []byte
+}
+
+// WriteBuffer creates (if it doesn't exist yet) the buffer account,
+// and writes the program to it.
+// If the buffer already exists (e.g. a previous deploy failed midway),
+// its content is fetched and only the chunks that differ are written.
+func (d *Deployer) WriteBuffer(
+	ctx context.Context,
+	buffer solana.PrivateKey,
+	programData []byte,
+) error {
+	if len(programData) == 0 {
+		return errors.New("program is empty")
+	}
+	chunkSize, err := bpfloader.MaxWriteChunkSize(
+		d.Payer.PublicKey(),
+		buffer.PublicKey(),
+		d.authority().PublicKey(),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to calculate chunk size: %w", err)
+	}
+
+	existing, err := d.getBuffer(ctx, buffer.PublicKey(), len(programData))
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		if err := d.createBuffer(ctx, buffer, len(programData)); err != nil {
+			return err
+		}
+		// A new buffer is zero-filled.
+		existing = make([]byte, len(programData))
+	}
+
+	var pending []chunk
+	total := 0
+	for offset := 0; offset < len(programData); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(programData) {
+			end = len(programData)
+		}
+		total++
+		if bytes.Equal(existing[offset:end], programData[offset:end]) {
+			continue
+		}
+		pending = append(pending, chunk{offset: offset, data: programData[offset:end]})
+	}
+
+	progress := Progress{
+		Stage:         StageWrite,
+		ChunksTotal:   total,
+		ChunksDone:    total - len(pending),
+		ChunksSkipped: total - len(pending),
+	}
+	d.report(progress)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := d.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for _, c := range pending {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(c chunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sig, err := d.sendAndConfirm(
+				ctx,
+				[]solana.Instruction{
+					bpfloader.NewWriteInstruction(
+						buffer.PublicKey(),
+						d.authority().PublicKey(),
+						uint32(c.offset),
+						c.data,
+					),
+				},
+				d.Payer, d.authority(),
+			)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("unable to write chunk at offset %d: %w", c.offset, err)
+					cancel()
+				})
+				return
+			}
+
+			d.progressMu.Lock()
+			defer d.progressMu.Unlock()
+			progress.ChunksDone++
+			progress.Signature = sig
+			if d.OnProgress != nil {
+				d.OnProgress(progress)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// getBuffer returns the program bytes currently in the buffer,
+// or nil if the buffer doesn't exist.
+func (d *Deployer) getBuffer(ctx context.Context, buffer solana.PublicKey, programLen int) ([]byte, error) {
+	out, err := d.Client.GetAccountInfoWithOpts(
+		ctx,
+		buffer,
+		&rpc.GetAccountInfoOpts{
+			Encoding:   solana.EncodingBase64,
+			Commitment: d.commitment(),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get buffer account %s: %w", buffer, err)
+	}
+
+	account := out.Value
+	if !account.Owner.Equals(solana.BPFLoaderUpgradeableProgramID) {
+		return nil, fmt.Errorf("buffer account %s is not owned by the upgradeable loader (owner is %s)", buffer, account.Owner)
+	}
+	data := account.Data.GetBinary()
+	if len(data) != bpfloader.UpgradeableBufferLen(programLen) {
+		return nil, fmt.Errorf(
+			"buffer account %s has size %d, but the program needs %d; may have been for a different program?",
+			buffer,
+			len(data),
+			bpfloader.UpgradeableBufferLen(programLen),
+		)
+	}
+	if binary.LittleEndian.Uint32(data[0:4]) != 1 {
+		return nil, fmt.Errorf("account %s is not a buffer", buffer)
+	}
+	authority := d.authority().PublicKey()
+	if data[4] != 1 || !bytes.Equal(data[5:37], authority[:]) {
+		return nil, fmt.Errorf("buffer account %s has a different authority than %s", buffer, authority)
+	}
+	return data[bpfloader.UPGRADEABLE_BUFFER_METADATA_SIZE:], nil
+}
+
+func (d *Deployer) createBuffer(ctx context.Context, buffer solana.PrivateKey, programLen int) error {
+	lamports, err := d.Client.GetMinimumBalanceForRentExemption(
+		ctx,
+		uint64(bpfloader.UpgradeableBufferLen(programLen)),
+		d.commitment(),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to get rent for the buffer account: %w", err)
+	}
+	sig, err := d.sendAndConfirm(
+		ctx,
+		bpfloader.NewCreateBufferInstructions(
+			d.Payer.PublicKey(),
+			buffer.PublicKey(),
+			d.authority().PublicKey(),
+			lamports,
+			programLen,
+		),
+		d.Payer, buffer,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to create buffer account: %w", err)
+	}
+	d.report(Progress{Stage: StageCreateBuffer, Signature: sig})
+	return nil
+}
+
+// errExecution is returned when the transaction was confirmed but failed;
+// such transactions are not retried.
+type errExecution struct {
+	sig solana.Signature
+	err interface{}
+}
+
+func (e *errExecution) Error() string {
+	return fmt.Sprintf("transaction %s failed: %v", e.sig, e.err)
+}
+
+// sendAndConfirm sends the transaction and waits for its confirmation;
+// on send errors and confirmation timeouts the transaction is
+// re-signed with a new blockhash and sent again, up to MaxRetries times.
+//
+// After a confirmation timeout, the transaction is sent again only once its
+// blockhash has expired without it landing: before then it can still be processed,
+// and the steps that create an account (the buffer, or the program) would fail
+// with "already in use" if sent twice.
+func (d *Deployer) sendAndConfirm(
+	ctx context.Context,
+	instructions []solana.Instruction,
+	signers ...solana.PrivateKey,
+) (solana.Signature, error) {
+	var lastErr error
+	for attempt := 0; attempt <= d.MaxRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return solana.Signature{}, err
+		}
+		sig, lastValidBlockHeight, err := d.send(ctx, instructions, signers)
+		if err == nil {
+			err = d.waitForConfirmation(ctx, sig)
+			if errors.Is(err, ErrConfirmationTimeout) {
+				err = d.waitForExpiry(ctx, sig, lastValidBlockHeight)
+			}
+			if err == nil {
+				return sig, nil
+			}
+		}
+		var execErr *errExecution
+		if errors.As(err, &execErr) || ctx.Err() != nil {
+			return sig, err
+		}
+		lastErr = err
+	}
+	return solana.Signature{}, fmt.Errorf("giving up after %d attempts: %w", d.MaxRetries+1, lastErr)
+}
+
+// send sends the transaction with the latest blockhash, and returns
+// its signature and the last block height at which it can be processed.
+func (d *Deployer) send(
+	ctx context.Context,
+	instructions []solana.Instruction,
+	signers []solana.PrivateKey,
+) (solana.Signature, uint64, error) {
+	recent, err := d.Client.GetLatestBlockhash(ctx, d.commitment())
+	if err != nil {
+		return solana.Signature{}, 0, fmt.Errorf("unable to get latest blockhash: %w", err)
+	}
+	_, sig, err := d.Client.BuildAndSend(
+		ctx,
+		instructions,
+		*recent.Value,
+		d.Payer.PublicKey(),
+		rpc.TransactionOpts{
+			PreflightCommitment: d.commitment(),
+		},
+		signers...,
+	)
+	return sig, recent.Value.LastValidBlockHeight, err
+}
+
+func (d *Deployer) waitForConfirmation(ctx context.Context, sig solana.Signature) error {
+	timeout := d.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = DefaultConfirmTimeout
+	}
+	interval := d.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		out, err := d.Client.GetSignatureStatuses(ctx, false, sig)
+		if err != nil && !errors.Is(err, rpc.ErrNotFound) {
+			return fmt.Errorf("unable to get signature status: %w", err)
+		}
+		if err == nil && len(out.Value) > 0 && out.Value[0] != nil {
+			status := out.Value[0]
+			if status.Err != nil {
+				return &errExecution{sig: sig, err: status.Err}
+			}
+			if status.ConfirmationStatus.IsAtLeast(d.commitment()) {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", ErrConfirmationTimeout, sig)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// waitForExpiry waits, after a confirmation timeout, until the transaction
+// either lands or can't land anymore because its blockhash expired;
+// it returns nil if the transaction was confirmed.
+func (d *Deployer) waitForExpiry(ctx context.Context, sig solana.Signature, lastValidBlockHeight uint64) error {
+	statuses, err := d.Client.ConfirmTransactionsWithOpts(ctx, []solana.Signature{sig}, &rpc.ConfirmTransactionsOpts{
+		Commitment:            d.commitment(),
+		PollInterval:          d.PollInterval,
+		LastValidBlockHeights: map[solana.Signature]uint64{sig: lastValidBlockHeight},
+	})
+	if err != nil {
+		if errors.Is(err, solana.ErrBlockhashExpired) {
+			return fmt.Errorf("%w: %s (blockhash expired)", ErrConfirmationTimeout, sig)
+		}
+		return err
+	}
+	if status := statuses[sig]; status.Err != nil {
+		return &errExecution{sig: sig, err: status.Err}
+	}
+	return nil
+}
+
+func (d *Deployer) authority() solana.PrivateKey {
+	if d.Authority == nil {
+		return d.Payer
+	}
+	return d.Authority
+}
+
+func (d *Deployer) commitment() rpc.CommitmentType {
+	if d.Commitment == "" {
+		return rpc.CommitmentConfirmed
+	}
+	return d.Commitment
+}
+
+func (d *Deployer) report(progress Progress) {
+	if d.OnProgress == nil {
+		return
+	}
+	d.progressMu.Lock()
+	defer d.progressMu.Unlock()
+	d.OnProgress(progress)
+}
@@ -0,0 +1,419 @@
+package deploy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	bpfloader "github.com/gagliardetto/solana-go/programs/bpf-loader"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCluster is a minimal JSON-RPC server that keeps the state
+// of a single buffer account and applies the loader instructions to it.
+type mockCluster struct {
+	t *testing.T
+
+	mu     sync.Mutex
+	buffer []byte // nil if the buffer doesn't exist
+
+	// offset => number of times sendTransaction must fail for a write at that offset.
+	failWrites map[uint32]int
+
+	// Number of sendTransaction calls that are accepted but never land.
+	dropSends int
+	// Number of getSignatureStatuses calls that don't see the transactions yet.
+	pendingPolls int
+
+	writes       []uint32 // offsets of the applied writes
+	writeSends   map[uint32]int
+	final        []solana.Instruction
+	createBuffer int
+	landed       map[solana.Signature]bool
+	blockHeight  uint64
+}
+
+func newMockCluster(t *testing.T) *mockCluster {
+	return &mockCluster{
+		t:          t,
+		failWrites: make(map[uint32]int),
+		writeSends: make(map[uint32]int),
+		landed:     make(map[solana.Signature]bool),
+	}
+}
+
+func (m *mockCluster) serve() (*httptest.Server, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var call struct {
+			ID     stdjson.RawMessage `json:"id"`
+			Method string             `json:"method"`
+			Params []stdjson.RawMessage
+		}
+		require.NoError(m.t, stdjson.NewDecoder(req.Body).Decode(&call))
+
+		result, rpcErr := m.handle(call.Method, call.Params)
+		resp := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      call.ID,
+		}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		require.NoError(m.t, stdjson.NewEncoder(rw).Encode(resp))
+	}))
+	return server, server.Close
+}
+
+func (m *mockCluster) handle(method string, params []stdjson.RawMessage) (interface{}, interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx := map[string]interface{}{"slot": 1}
+	switch method {
+	case "getLatestBlockhash":
+		return map[string]interface{}{
+			"context": ctx,
+			"value": map[string]interface{}{
+				"blockhash":            solana.Hash{1}.String(),
+				"lastValidBlockHeight": m.blockHeight + 150,
+			},
+		}, nil
+	case "getBlockHeight":
+		m.blockHeight++
+		return m.blockHeight, nil
+	case "getMinimumBalanceForRentExemption":
+		return 1000, nil
+	case "getAccountInfo":
+		if m.buffer == nil {
+			return map[string]interface{}{"context": ctx, "value": nil}, nil
+		}
+		return map[string]interface{}{
+			"context": ctx,
+			"value": map[string]interface{}{
+				"data":       []string{base64.StdEncoding.EncodeToString(m.buffer), "base64"},
+				"executable": false,
+				"lamports":   1000,
+				"owner":      solana.BPFLoaderUpgradeableProgramID.String(),
+				"rentEpoch":  0,
+			},
+		}, nil
+	case "getSignatureStatuses":
+		var sigs []solana.Signature
+		require.NoError(m.t, stdjson.Unmarshal(params[0], &sigs))
+		pending := m.pendingPolls > 0
+		if pending {
+			m.pendingPolls--
+		}
+		statuses := make([]interface{}, len(sigs))
+		for i, sig := range sigs {
+			if pending || !m.landed[sig] {
+				continue
+			}
+			statuses[i] = map[string]interface{}{
+				"slot":               1,
+				"confirmations":      nil,
+				"err":                nil,
+				"confirmationStatus": "confirmed",
+			}
+		}
+		return map[string]interface{}{
+			"context": ctx,
+			"value":   statuses,
+		}, nil
+	case "sendTransaction":
+		var encoded string
+		require.NoError(m.t, stdjson.Unmarshal(params[0], &encoded))
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		require.NoError(m.t, err)
+		tx, err := solana.TransactionFromDecoder(bin.NewBinDecoder(raw))
+		require.NoError(m.t, err)
+		require.NoError(m.t, tx.VerifySignatures())
+		if m.dropSends > 0 {
+			m.dropSends--
+			return tx.Signatures[0].String(), nil
+		}
+		if rpcErr := m.apply(tx); rpcErr != nil {
+			return nil, rpcErr
+		}
+		m.landed[tx.Signatures[0]] = true
+		return tx.Signatures[0].String(), nil
+	}
+	m.t.Fatalf("unexpected method %q", method)
+	return nil, nil
+}
+
+func (m *mockCluster) apply(tx *solana.Transaction) interface{} {
+	for _, compiled := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(compiled.ProgramIDIndex)
+		require.NoError(m.t, err)
+		accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+		require.NoError(m.t, err)
+		data := []byte(compiled.Data)
+
+		if programID.Equals(solana.SystemProgramID) {
+			space := binary.LittleEndian.Uint64(data[12:20])
+			if int(space) == bpfloader.UPGRADEABLE_PROGRAM_SIZE {
+				continue
+			}
+			if m.buffer != nil {
+				return map[string]interface{}{"code": -32002, "message": "Allocate: account already in use"}
+			}
+			m.createBuffer++
+			m.buffer = make([]byte, space)
+			continue
+		}
+		require.Equal(m.t, solana.BPFLoaderUpgradeableProgramID, programID)
+
+		switch binary.LittleEndian.Uint32(data[0:4]) {
+		case bpfloader.Upgradeable_InitializeBuffer:
+			binary.LittleEndian.PutUint32(m.buffer[0:4], 1)
+			m.buffer[4] = 1
+			copy(m.buffer[5:37], accounts[1].PublicKey[:])
+		case bpfloader.Upgradeable_Write:
+			offset := binary.LittleEndian.Uint32(data[4:8])
+			length := binary.LittleEndian.Uint64(data[8:16])
+			m.writeSends[offset]++
+			if m.failWrites[offset] > 0 {
+				m.failWrites[offset]--
+				return map[string]interface{}{"code": -32005, "message": "Node is behind"}
+			}
+			require.Len(m.t, data[16:], int(length))
+			copy(m.buffer[bpfloader.UPGRADEABLE_BUFFER_METADATA_SIZE+int(offset):], data[16:])
+			m.writes = append(m.writes, offset)
+		default:
+			m.final = append(m.final, solana.NewInstruction(programID, accounts, data))
+		}
+	}
+	return nil
+}
+
+func (m *mockCluster) programBytes() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buffer[bpfloader.UPGRADEABLE_BUFFER_METADATA_SIZE:]
+}
+
+func newTestDeployer(t *testing.T, url string) *Deployer {
+	payer, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	d := New(rpc.New(url), payer)
+	d.Concurrency = 4
+	d.PollInterval = time.Millisecond
+	d.ConfirmTimeout = time.Second
+	return d
+}
+
+func testProgram(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i%251) + 1
+	}
+	return data
+}
+
+func sortedOffsets(offsets []uint32) []uint32 {
+	out := append([]uint32(nil), offsets...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func TestDeployer_Deploy(t *testing.T) {
+	cluster := newMockCluster(t)
+	server, closer := cluster.serve()
+	defer closer()
+
+	d := newTestDeployer(t, server.URL)
+	var progress []Progress
+	d.OnProgress = func(p Progress) {
+		progress = append(progress, p)
+	}
+
+	program := solana.NewWallet().PrivateKey
+	buffer := solana.NewWallet().PrivateKey
+	programData := testProgram(5000)
+
+	chunkSize, err := bpfloader.MaxWriteChunkSize(d.Payer.PublicKey(), buffer.PublicKey(), d.Payer.PublicKey())
+	require.NoError(t, err)
+	numChunks := (len(programData) + chunkSize - 1) / chunkSize
+	require.Greater(t, numChunks, 1)
+
+	sig, err := d.Deploy(context.Background(), program, buffer, programData, 0)
+	require.NoError(t, err)
+	require.False(t, sig.IsZero())
+
+	require.Equal(t, 1, cluster.createBuffer)
+	require.Equal(t, programData, cluster.programBytes())
+
+	// Every chunk is written exactly once, at the expected offset.
+	require.Len(t, cluster.writes, numChunks)
+	for i, offset := range sortedOffsets(cluster.writes) {
+		require.Equal(t, uint32(i*chunkSize), offset)
+	}
+
+	require.Len(t, cluster.final, 1)
+	deployInst := cluster.final[0]
+	data, err := deployInst.Data()
+	require.NoError(t, err)
+	require.Equal(t, bpfloader.Upgradeable_DeployWithMaxDataLen, binary.LittleEndian.Uint32(data[0:4]))
+	require.Equal(t, uint64(len(programData)*2), binary.LittleEndian.Uint64(data[4:12]))
+	programDataAddress, _, err := bpfloader.FindProgramDataAddress(program.PublicKey())
+	require.NoError(t, err)
+	accounts := deployInst.Accounts()
+	require.Equal(t, d.Payer.PublicKey(), accounts[0].PublicKey)
+	require.Equal(t, programDataAddress, accounts[1].PublicKey)
+	require.Equal(t, program.PublicKey(), accounts[2].PublicKey)
+	require.Equal(t, buffer.PublicKey(), accounts[3].PublicKey)
+
+	require.Equal(t, StageCreateBuffer, progress[0].Stage)
+	require.Equal(t, Progress{Stage: StageWrite, ChunksTotal: numChunks}, progress[1])
+	for i, p := range progress[2 : 2+numChunks] {
+		require.Equal(t, StageWrite, p.Stage)
+		require.Equal(t, i+1, p.ChunksDone)
+		require.False(t, p.Signature.IsZero())
+	}
+	require.Equal(t, Progress{Stage: StageDeploy, Signature: sig}, progress[len(progress)-1])
+}
+
+func TestDeployer_Upgrade_resume(t *testing.T) {
+	cluster := newMockCluster(t)
+	server, closer := cluster.serve()
+	defer closer()
+
+	d := newTestDeployer(t, server.URL)
+	var last Progress
+	d.OnProgress = func(p Progress) {
+		last = p
+		if p.Stage == StageWrite && p.Signature.IsZero() {
+			require.Equal(t, 2, p.ChunksSkipped)
+		}
+	}
+
+	program := solana.NewWallet().PublicKey()
+	buffer := solana.NewWallet().PrivateKey
+	programData := testProgram(5000)
+
+	chunkSize, err := bpfloader.MaxWriteChunkSize(d.Payer.PublicKey(), buffer.PublicKey(), d.Payer.PublicKey())
+	require.NoError(t, err)
+	numChunks := (len(programData) + chunkSize - 1) / chunkSize
+
+	// A previous attempt wrote the first two chunks, then failed.
+	authority := d.Payer.PublicKey()
+	cluster.buffer = make([]byte, bpfloader.UpgradeableBufferLen(len(programData)))
+	binary.LittleEndian.PutUint32(cluster.buffer[0:4], 1)
+	cluster.buffer[4] = 1
+	copy(cluster.buffer[5:37], authority[:])
+	copy(cluster.buffer[bpfloader.UPGRADEABLE_BUFFER_METADATA_SIZE:], programData[:2*chunkSize])
+
+	sig, err := d.Upgrade(context.Background(), program, buffer, programData)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, cluster.createBuffer)
+	require.Equal(t, programData, cluster.programBytes())
+	require.Len(t, cluster.writes, numChunks-2)
+	for i, offset := range sortedOffsets(cluster.writes) {
+		require.Equal(t, uint32((i+2)*chunkSize), offset)
+	}
+
+	require.Len(t, cluster.final, 1)
+	data, err := cluster.final[0].Data()
+	require.NoError(t, err)
+	require.Equal(t, bpfloader.Upgradeable_Upgrade, binary.LittleEndian.Uint32(data[0:4]))
+	require.Equal(t, program, cluster.final[0].Accounts()[1].PublicKey)
+	require.Equal(t, Progress{Stage: StageUpgrade, Signature: sig}, last)
+}
+
+func TestDeployer_WriteBuffer_retry(t *testing.T) {
+	cluster := newMockCluster(t)
+	server, closer := cluster.serve()
+	defer closer()
+
+	d := newTestDeployer(t, server.URL)
+	buffer := solana.NewWallet().PrivateKey
+	programData := testProgram(3000)
+
+	chunkSize, err := bpfloader.MaxWriteChunkSize(d.Payer.PublicKey(), buffer.PublicKey(), d.Payer.PublicKey())
+	require.NoError(t, err)
+
+	cluster.failWrites[uint32(chunkSize)] = 2
+
+	require.NoError(t, d.WriteBuffer(context.Background(), buffer, programData))
+	require.Equal(t, programData, cluster.programBytes())
+	require.Equal(t, 3, cluster.writeSends[uint32(chunkSize)])
+	require.Equal(t, 1, cluster.writeSends[0])
+
+	// Too many failures: the write is given up.
+	other := solana.NewWallet().PrivateKey
+	cluster.buffer = nil
+	cluster.failWrites[0] = d.MaxRetries + 1
+	err = d.WriteBuffer(context.Background(), other, programData)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unable to write chunk at offset 0")
+}
+
+func TestDeployer_WriteBuffer_lateConfirmation(t *testing.T) {
+	cluster := newMockCluster(t)
+	server, closer := cluster.serve()
+	defer closer()
+
+	d := newTestDeployer(t, server.URL)
+	d.ConfirmTimeout = 5 * time.Millisecond
+	programData := testProgram(100)
+
+	// The buffer is created after the confirmation timeout:
+	// it is not created again.
+	cluster.pendingPolls = 50
+	require.NoError(t, d.WriteBuffer(context.Background(), solana.NewWallet().PrivateKey, programData))
+	require.Equal(t, 1, cluster.createBuffer)
+	require.Equal(t, programData, cluster.programBytes())
+	require.Less(t, cluster.blockHeight, uint64(150))
+}
+
+func TestDeployer_WriteBuffer_expired(t *testing.T) {
+	cluster := newMockCluster(t)
+	server, closer := cluster.serve()
+	defer closer()
+
+	d := newTestDeployer(t, server.URL)
+	d.ConfirmTimeout = 5 * time.Millisecond
+	programData := testProgram(100)
+
+	// The first transaction creating the buffer never lands:
+	// it is sent again once its blockhash expired.
+	cluster.dropSends = 1
+	require.NoError(t, d.WriteBuffer(context.Background(), solana.NewWallet().PrivateKey, programData))
+	require.Equal(t, 1, cluster.createBuffer)
+	require.Equal(t, programData, cluster.programBytes())
+	require.Greater(t, cluster.blockHeight, uint64(150))
+}
+
+func TestDeployer_WriteBuffer_wrongAuthority(t *testing.T) {
+	cluster := newMockCluster(t)
+	server, closer := cluster.serve()
+	defer closer()
+
+	d := newTestDeployer(t, server.URL)
+	programData := testProgram(100)
+
+	cluster.buffer = make([]byte, bpfloader.UpgradeableBufferLen(len(programData)))
+	binary.LittleEndian.PutUint32(cluster.buffer[0:4], 1)
+	cluster.buffer[4] = 1
+	other := solana.NewWallet().PublicKey()
+	copy(cluster.buffer[5:37], other[:])
+
+	err := d.WriteBuffer(context.Background(), solana.NewWallet().PrivateKey, programData)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "different authority")
+	require.Empty(t, cluster.writes)
+}
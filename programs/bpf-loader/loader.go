@@ -17,7 +17,8 @@ const (
 func calculateMaxChunkSize(
 	createBuilder func(offset int, data []byte) *solana.TransactionBuilder,
 ) (size int, err error) {
-	transaction, err := createBuilder(0, []byte{}).Build()
+	// Any blockhash will do, only the size of the transaction matters.
+	transaction, err := createBuilder(0, []byte{}).SetRecentBlockHash(solana.Hash{1}).Build()
 	if err != nil {
 		return
 	}
@@ -0,0 +1,196 @@
+package bpfloader
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// Instruction builders for the BPF Loader Upgradeable program
+// (BPFLoaderUpgradeab1e11111111111111111111111).
+//
+// https://github.com/solana-labs/solana/blob/v1.14.10/sdk/program/src/loader_upgradeable_instruction.rs
+
+const (
+	Upgradeable_InitializeBuffer uint32 = iota
+	Upgradeable_Write
+	Upgradeable_DeployWithMaxDataLen
+	Upgradeable_Upgrade
+	Upgradeable_SetAuthority
+	Upgradeable_Close
+)
+
+// Sizes of the metadata (i.e. the serialized UpgradeableLoaderState)
+// that precedes the program bytes in the loader accounts.
+//
+// https://github.com/solana-labs/solana/blob/v1.14.10/sdk/program/src/bpf_loader_upgradeable.rs
+const (
+	// enum tag (4) + Option<Pubkey> authority (1 + 32)
+	UPGRADEABLE_BUFFER_METADATA_SIZE int = 4 + 1 + 32
+	// enum tag (4) + programdata address (32)
+	UPGRADEABLE_PROGRAM_SIZE int = 4 + 32
+	// enum tag (4) + slot (8) + Option<Pubkey> authority (1 + 32)
+	UPGRADEABLE_PROGRAMDATA_METADATA_SIZE int = 4 + 8 + 1 + 32
+)
+
+// UpgradeableBufferLen returns the size of a buffer account
+// that can hold a program of `programLen` bytes.
+func UpgradeableBufferLen(programLen int) int {
+	return UPGRADEABLE_BUFFER_METADATA_SIZE + programLen
+}
+
+// UpgradeableProgramDataLen returns the size of a programdata account
+// that can hold a program of up to `maxDataLen` bytes.
+func UpgradeableProgramDataLen(maxDataLen int) int {
+	return UPGRADEABLE_PROGRAMDATA_METADATA_SIZE + maxDataLen
+}
+
+// FindProgramDataAddress returns the address of the programdata account
+// of the provided upgradeable program.
+func FindProgramDataAddress(program solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return solana.FindProgramAddress(
+		[][]byte{program[:]},
+		solana.BPFLoaderUpgradeableProgramID,
+	)
+}
+
+func upgradeableInstruction(discriminator uint32, accounts solana.AccountMetaSlice, args []byte) solana.Instruction {
+	data := make([]byte, 4+len(args))
+	binary.LittleEndian.PutUint32(data[0:], discriminator)
+	copy(data[4:], args)
+	return solana.NewInstruction(
+		solana.BPFLoaderUpgradeableProgramID,
+		accounts,
+		data,
+	)
+}
+
+// NewCreateBufferInstructions returns the instructions that create
+// (and fund with `lamports`) a buffer account big enough to hold
+// a program of `programLen` bytes, and initialize it with the provided authority.
+func NewCreateBufferInstructions(
+	payer solana.PublicKey,
+	buffer solana.PublicKey,
+	authority solana.PublicKey,
+	lamports uint64,
+	programLen int,
+) []solana.Instruction {
+	return []solana.Instruction{
+		system.NewCreateAccountInstruction(
+			lamports,
+			uint64(UpgradeableBufferLen(programLen)),
+			solana.BPFLoaderUpgradeableProgramID,
+			payer,
+			buffer,
+		).Build(),
+		NewInitializeBufferInstruction(buffer, authority),
+	}
+}
+
+// NewInitializeBufferInstruction initializes a buffer account
+// (that must be already allocated and owned by the loader).
+func NewInitializeBufferInstruction(
+	buffer solana.PublicKey,
+	authority solana.PublicKey,
+) solana.Instruction {
+	return upgradeableInstruction(
+		Upgradeable_InitializeBuffer,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(buffer, true, false),
+			solana.NewAccountMeta(authority, false, false),
+		},
+		nil,
+	)
+}
+
+// NewWriteInstruction writes `bytes` at `offset` (relative to the
+// start of the program bytes, i.e. after the buffer metadata) in the buffer.
+func NewWriteInstruction(
+	buffer solana.PublicKey,
+	authority solana.PublicKey,
+	offset uint32,
+	bytes []byte,
+) solana.Instruction {
+	args := make([]byte, 4+8+len(bytes))
+	binary.LittleEndian.PutUint32(args[0:], offset)
+	binary.LittleEndian.PutUint64(args[4:], uint64(len(bytes)))
+	copy(args[12:], bytes)
+	return upgradeableInstruction(
+		Upgradeable_Write,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(buffer, true, false),
+			solana.NewAccountMeta(authority, false, true),
+		},
+		args,
+	)
+}
+
+// NewDeployWithMaxDataLenInstruction deploys the program in the buffer
+// to the (already created, empty) program account.
+// The program account must be created in the same transaction,
+// with UPGRADEABLE_PROGRAM_SIZE bytes of space and owned by the loader.
+func NewDeployWithMaxDataLenInstruction(
+	payer solana.PublicKey,
+	programData solana.PublicKey,
+	program solana.PublicKey,
+	buffer solana.PublicKey,
+	authority solana.PublicKey,
+	maxDataLen uint64,
+) solana.Instruction {
+	args := make([]byte, 8)
+	binary.LittleEndian.PutUint64(args, maxDataLen)
+	return upgradeableInstruction(
+		Upgradeable_DeployWithMaxDataLen,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(payer, true, true),
+			solana.NewAccountMeta(programData, true, false),
+			solana.NewAccountMeta(program, true, false),
+			solana.NewAccountMeta(buffer, true, false),
+			solana.NewAccountMeta(solana.SysVarRentPubkey, false, false),
+			solana.NewAccountMeta(solana.SysVarClockPubkey, false, false),
+			solana.NewAccountMeta(solana.SystemProgramID, false, false),
+			solana.NewAccountMeta(authority, false, true),
+		},
+		args,
+	)
+}
+
+// NewUpgradeInstruction replaces the program with the content of the buffer;
+// the lamports of the buffer are sent to the `spill` account.
+func NewUpgradeInstruction(
+	programData solana.PublicKey,
+	program solana.PublicKey,
+	buffer solana.PublicKey,
+	spill solana.PublicKey,
+	authority solana.PublicKey,
+) solana.Instruction {
+	return upgradeableInstruction(
+		Upgradeable_Upgrade,
+		solana.AccountMetaSlice{
+			solana.NewAccountMeta(programData, true, false),
+			solana.NewAccountMeta(program, true, false),
+			solana.NewAccountMeta(buffer, true, false),
+			solana.NewAccountMeta(spill, true, false),
+			solana.NewAccountMeta(solana.SysVarRentPubkey, false, false),
+			solana.NewAccountMeta(solana.SysVarClockPubkey, false, false),
+			solana.NewAccountMeta(authority, false, true),
+		},
+		nil,
+	)
+}
+
+// MaxWriteChunkSize returns the max number of program bytes that can
+// be written with a single Write instruction, in a transaction
+// paid by `payer`.
+func MaxWriteChunkSize(
+	payer solana.PublicKey,
+	buffer solana.PublicKey,
+	authority solana.PublicKey,
+) (int, error) {
+	return calculateMaxChunkSize(func(offset int, data []byte) *solana.TransactionBuilder {
+		return solana.NewTransactionBuilder().
+			AddInstruction(NewWriteInstruction(buffer, authority, uint32(offset), data)).
+			SetFeePayer(payer)
+	})
+}
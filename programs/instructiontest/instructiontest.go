@@ -0,0 +1,131 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package instructiontest runs a standard battery of tests
+// against an instruction builder.
+package instructiontest
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/treeout"
+	"github.com/stretchr/testify/require"
+)
+
+// Builder is an instruction builder that can validate its parameters and accounts.
+type Builder interface {
+	Validate() error
+}
+
+// Battery describes the builder to test.
+type Battery struct {
+	// New returns a new builder with all the parameters and accounts set.
+	New func() Builder
+
+	// Build builds the instruction from a builder returned by New
+	// (e.g. by calling its ValidateAndBuild method).
+	Build func(Builder) (solana.Instruction, error)
+
+	// Decode decodes an instruction; if nil, the decoder registered
+	// for the program ID of the instruction is used.
+	// The decoded instruction must implement solana.Instruction.
+	Decode func(accounts []*solana.AccountMeta, data []byte) (interface{}, error)
+
+	// Unset maps each error message expected from Validate
+	// to a function that unsets the corresponding parameter or account
+	// on a builder returned by New.
+	Unset map[string]func(Builder)
+}
+
+// Run runs the battery:
+//   - the instruction is built, and has a program ID and no nil accounts;
+//   - Data() is decoded back into an instruction with the same program ID, accounts and data;
+//   - Validate returns the expected error for each unset parameter or account;
+//   - EncodeToTree (when implemented) doesn't panic, on both complete and incomplete builders,
+//     and on the built and decoded instructions.
+func Run(t *testing.T, battery Battery) {
+	t.Helper()
+	require.NotNil(t, battery.New, "Battery.New is not set")
+	require.NotNil(t, battery.Build, "Battery.Build is not set")
+
+	builder := battery.New()
+	require.NoError(t, builder.Validate())
+	inst, err := battery.Build(builder)
+	require.NoError(t, err)
+	require.NotNil(t, inst)
+
+	t.Run("Accounts", func(t *testing.T) {
+		require.False(t, inst.ProgramID().IsZero(), "program ID is not set")
+		accounts := inst.Accounts()
+		require.NotEmpty(t, accounts)
+		for i, account := range accounts {
+			require.NotNil(t, account, "account %d is nil", i)
+		}
+	})
+
+	t.Run("Data round-trip", func(t *testing.T) {
+		data, err := inst.Data()
+		require.NoError(t, err)
+
+		decode := battery.Decode
+		if decode == nil {
+			require.True(t, solana.HasInstructionDecoder(inst.ProgramID()), "no instruction decoder registered for %s", inst.ProgramID())
+			decode = func(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+				return solana.DecodeInstruction(inst.ProgramID(), accounts, data)
+			}
+		}
+		decoded, err := decode(inst.Accounts(), data)
+		require.NoError(t, err)
+		decodedInst, ok := decoded.(solana.Instruction)
+		require.True(t, ok, "decoded instruction %T doesn't implement solana.Instruction", decoded)
+
+		require.Equal(t, inst.ProgramID(), decodedInst.ProgramID())
+		require.Equal(t, inst.Accounts(), decodedInst.Accounts())
+		decodedData, err := decodedInst.Data()
+		require.NoError(t, err)
+		require.Equal(t, data, decodedData)
+
+		encodeToTree(t, decoded)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		for message, unset := range battery.Unset {
+			message, unset := message, unset
+			t.Run(message, func(t *testing.T) {
+				builder := battery.New()
+				unset(builder)
+				require.EqualError(t, builder.Validate(), message)
+				encodeToTree(t, builder)
+			})
+		}
+	})
+
+	t.Run("EncodeToTree", func(t *testing.T) {
+		encodeToTree(t, builder)
+		encodeToTree(t, inst)
+	})
+}
+
+func encodeToTree(t *testing.T, v interface{}) {
+	t.Helper()
+	encodable, ok := v.(text.EncodableToTree)
+	if !ok {
+		return
+	}
+	require.NotPanics(t, func() {
+		encodable.EncodeToTree(treeout.New(""))
+	})
+}
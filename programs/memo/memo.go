@@ -0,0 +1,138 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memo builds and decodes the instructions of the SPL Memo program,
+// whose data is the UTF-8 memo and whose accounts are the signers that must
+// sign the transaction.
+package memo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/text/format"
+	"github.com/gagliardetto/treeout"
+)
+
+var ProgramID solana.PublicKey = solana.MemoProgramID
+
+const ProgramName = "Memo"
+
+func init() {
+	// Both versions of the program have the same instruction.
+	solana.RegisterInstructionDecoder(solana.MemoV2ProgramID, registryDecodeInstruction(solana.MemoV2ProgramID))
+	solana.RegisterInstructionDecoder(solana.MemoV1ProgramID, registryDecodeInstruction(solana.MemoV1ProgramID))
+}
+
+type Instruction struct {
+	// The UTF-8 memo.
+	Message []byte
+
+	// The accounts that must sign the transaction; optional.
+	Signers solana.AccountMetaSlice
+
+	programID solana.PublicKey
+}
+
+var _ solana.Instruction = &Instruction{}
+
+// NewMemoInstruction creates a memo instruction for ProgramID,
+// that the provided signers (if any) must sign.
+func NewMemoInstruction(message []byte, signers ...solana.PublicKey) *Instruction {
+	inst := &Instruction{
+		Message:   message,
+		programID: ProgramID,
+	}
+	for _, signer := range signers {
+		inst.Signers = append(inst.Signers, solana.Meta(signer).SIGNER())
+	}
+	return inst
+}
+
+// Validate checks that the memo is valid UTF-8, as required by the program.
+func (inst *Instruction) Validate() error {
+	if len(inst.Message) == 0 {
+		return errors.New("memo is empty")
+	}
+	if !utf8.Valid(inst.Message) {
+		return errors.New("memo is not valid UTF-8")
+	}
+	return nil
+}
+
+func (inst *Instruction) ProgramID() solana.PublicKey {
+	if inst.programID.IsZero() {
+		return ProgramID
+	}
+	return inst.programID
+}
+
+func (inst *Instruction) Accounts() []*solana.AccountMeta {
+	return inst.Signers
+}
+
+func (inst *Instruction) Data() ([]byte, error) {
+	return inst.Message, nil
+}
+
+// Summary returns e.g. `Memo "hello"`.
+func (inst *Instruction) Summary(ctx *solana.SummaryContext) string {
+	return "Memo " + strconv.Quote(string(inst.Message))
+}
+
+func (inst *Instruction) EncodeToTree(parent treeout.Branches) {
+	parent.Child(format.Program(ProgramName, inst.ProgramID())).
+		//
+		ParentFunc(func(programBranch treeout.Branches) {
+			programBranch.Child(format.Instruction("Memo")).
+				//
+				ParentFunc(func(instructionBranch treeout.Branches) {
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch treeout.Branches) {
+						paramsBranch.Child(format.Param("Message", string(inst.Message)))
+					})
+					instructionBranch.Child(fmt.Sprintf("Signers[len=%v]", len(inst.Signers))).ParentFunc(func(signersBranch treeout.Branches) {
+						for i, signer := range inst.Signers {
+							signersBranch.Child(format.Meta(fmt.Sprintf("[%v]", i), signer))
+						}
+					})
+				})
+		})
+}
+
+func registryDecodeInstruction(programID solana.PublicKey) solana.InstructionDecoder {
+	return func(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+		inst, err := DecodeInstruction(accounts, data)
+		if err != nil {
+			return nil, err
+		}
+		inst.programID = programID
+		return inst, nil
+	}
+}
+
+// DecodeInstruction decodes a memo instruction of ProgramID.
+func DecodeInstruction(accounts []*solana.AccountMeta, data []byte) (*Instruction, error) {
+	inst := &Instruction{
+		Message:   append([]byte(nil), data...),
+		Signers:   accounts,
+		programID: ProgramID,
+	}
+	if err := inst.Validate(); err != nil {
+		return nil, fmt.Errorf("unable to decode instruction: %w", err)
+	}
+	return inst, nil
+}
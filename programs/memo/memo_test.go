@@ -0,0 +1,99 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	signer := solana.NewWallet().PublicKey()
+	inst := NewMemoInstruction([]byte("hello"), signer)
+	require.NoError(t, inst.Validate())
+
+	data, err := inst.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+	assert.Equal(t, solana.AccountMetaSlice{solana.Meta(signer).SIGNER()}, solana.AccountMetaSlice(inst.Accounts()))
+	assert.Equal(t, `Memo "hello"`, inst.Summary(nil))
+
+	// Registered for both versions of the program.
+	for _, programID := range []solana.PublicKey{solana.MemoV1ProgramID, solana.MemoV2ProgramID} {
+		decoded, err := solana.DecodeInstruction(programID, inst.Accounts(), data)
+		require.NoError(t, err)
+		assert.Equal(t, &Instruction{Message: data, Signers: inst.Signers, programID: programID}, decoded)
+	}
+
+	_, err = DecodeInstruction(nil, nil)
+	assert.EqualError(t, err, "unable to decode instruction: memo is empty")
+	_, err = DecodeInstruction(nil, []byte{0xff, 0xfe})
+	assert.EqualError(t, err, "unable to decode instruction: memo is not valid UTF-8")
+}
+
+func TestTransaction_Summaries(t *testing.T) {
+	text.DisableColors = true
+	defer func() { text.DisableColors = false }()
+
+	usdc := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	payer := solana.MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	recipient := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	source := solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1_000_000_000, payer, recipient).Build(),
+			token.NewTransferCheckedInstruction(2_500_000, 6, source, usdc, recipient, payer, nil).Build(),
+			NewMemoInstruction([]byte("invoice #42"), payer),
+			// Not summarized.
+			token.NewSyncNativeInstruction(source).Build(),
+		},
+		solana.Hash{},
+		solana.TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+
+	ctx := &solana.SummaryContext{
+		ResolveToken: func(key solana.PublicKey) (solana.TokenInfo, bool) {
+			if key.Equals(usdc) {
+				return solana.TokenInfo{Decimals: 6, Symbol: "USDC"}, true
+			}
+			return solana.TokenInfo{}, false
+		},
+	}
+	expected := []string{
+		"Transfer 1 SOL from 4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw to 7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+		"Transfer 2.5 USDC from 9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin to 7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+		`Memo "invoice #42"`,
+		"",
+	}
+	assert.Equal(t, expected, tx.Summaries(ctx))
+
+	// The tree prints the summaries before the decoded instructions.
+	buf := new(bytes.Buffer)
+	_, err = tx.EncodeTreeWithSummaryContext(text.NewTreeEncoder(buf, "tx"), ctx)
+	require.NoError(t, err)
+	for _, summary := range expected[:3] {
+		assert.Contains(t, buf.String(), "Summary: "+summary+"\n")
+	}
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("Summary: ")))
+}
@@ -0,0 +1,218 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serum
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// PriceLevel is the aggregated quantity of all the orders at a price.
+// Price and quantity are in lots.
+type PriceLevel struct {
+	Price    uint64
+	Quantity uint64
+	Orders   int
+}
+
+type LevelChangeType string
+
+const (
+	LevelAdded       LevelChangeType = "added"
+	LevelRemoved     LevelChangeType = "removed"
+	LevelSizeChanged LevelChangeType = "size_changed"
+)
+
+// LevelChange describes how a price level changed between two snapshots of a side.
+type LevelChange struct {
+	Type  LevelChangeType
+	Side  Side
+	Price uint64
+	// Zero for added levels.
+	OldQuantity uint64
+	// Zero for removed levels.
+	NewQuantity uint64
+}
+
+type bookSide struct {
+	hash   [sha256.Size]byte
+	levels map[uint64]PriceLevel
+	// Levels sorted from the best to the worst price.
+	sorted []PriceLevel
+}
+
+// BookKeeper maintains the decoded orderbook of a market from successive
+// snapshots of the raw data of its bids and asks accounts
+// (e.g. from accountSubscribe notifications).
+// Snapshots identical to the previous one are detected by hash and not decoded again.
+//
+// All the methods are safe for concurrent use.
+type BookKeeper struct {
+	mu   sync.RWMutex
+	bids bookSide
+	asks bookSide
+}
+
+func NewBookKeeper() *BookKeeper {
+	return &BookKeeper{
+		bids: bookSide{levels: map[uint64]PriceLevel{}},
+		asks: bookSide{levels: map[uint64]PriceLevel{}},
+	}
+}
+
+// Update applies a new snapshot of the raw data of the bids or asks account
+// (the side is detected from the account flags), and returns the changed levels,
+// sorted from the best to the worst price.
+// If the data is unchanged, it returns no changes without decoding it.
+func (k *BookKeeper) Update(data []byte) ([]LevelChange, error) {
+	hash := sha256.Sum256(data)
+
+	k.mu.RLock()
+	unchanged := hash == k.bids.hash || hash == k.asks.hash
+	k.mu.RUnlock()
+	if unchanged {
+		return nil, nil
+	}
+
+	var ob *Orderbook
+	if err := bin.NewBinDecoder(data).Decode(&ob); err != nil {
+		return nil, fmt.Errorf("unable to decode orderbook: %w", err)
+	}
+	var side Side
+	switch {
+	case ob.AccountFlags.Is(AccountFlagBids):
+		side = SideBid
+	case ob.AccountFlags.Is(AccountFlagAsks):
+		side = SideAsk
+	default:
+		return nil, fmt.Errorf("account is not a bids or asks account: %s", ob.AccountFlags.String())
+	}
+
+	levels := map[uint64]PriceLevel{}
+	err := ob.Items(side == SideBid, func(node *SlabLeafNode) error {
+		price := node.Key.Hi
+		level := levels[price]
+		level.Price = price
+		level.Quantity += uint64(node.Quantity)
+		level.Orders++
+		levels[price] = level
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sorted := sortLevels(levels, side)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	current := &k.asks
+	if side == SideBid {
+		current = &k.bids
+	}
+	changes := diffLevels(side, current.levels, levels)
+	*current = bookSide{
+		hash:   hash,
+		levels: levels,
+		sorted: sorted,
+	}
+	return changes, nil
+}
+
+// BestBidAsk returns the best bid and the best ask;
+// they are nil if the side is empty (or was never updated).
+func (k *BookKeeper) BestBidAsk() (bid *PriceLevel, ask *PriceLevel) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.bids.sorted) > 0 {
+		best := k.bids.sorted[0]
+		bid = &best
+	}
+	if len(k.asks.sorted) > 0 {
+		best := k.asks.sorted[0]
+		ask = &best
+	}
+	return bid, ask
+}
+
+// Levels returns a copy of the levels of the side,
+// sorted from the best to the worst price.
+func (k *BookKeeper) Levels(side Side) []PriceLevel {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	sorted := k.asks.sorted
+	if side == SideBid {
+		sorted = k.bids.sorted
+	}
+	return append([]PriceLevel(nil), sorted...)
+}
+
+func sortLevels(levels map[uint64]PriceLevel, side Side) []PriceLevel {
+	sorted := make([]PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		sorted = append(sorted, level)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if side == SideBid {
+			return sorted[i].Price > sorted[j].Price
+		}
+		return sorted[i].Price < sorted[j].Price
+	})
+	return sorted
+}
+
+func diffLevels(side Side, prev, next map[uint64]PriceLevel) []LevelChange {
+	var changes []LevelChange
+	for price, level := range next {
+		old, ok := prev[price]
+		switch {
+		case !ok:
+			changes = append(changes, LevelChange{
+				Type:        LevelAdded,
+				Side:        side,
+				Price:       price,
+				NewQuantity: level.Quantity,
+			})
+		case old.Quantity != level.Quantity:
+			changes = append(changes, LevelChange{
+				Type:        LevelSizeChanged,
+				Side:        side,
+				Price:       price,
+				OldQuantity: old.Quantity,
+				NewQuantity: level.Quantity,
+			})
+		}
+	}
+	for price, old := range prev {
+		if _, ok := next[price]; !ok {
+			changes = append(changes, LevelChange{
+				Type:        LevelRemoved,
+				Side:        side,
+				Price:       price,
+				OldQuantity: old.Quantity,
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if side == SideBid {
+			return changes[i].Price > changes[j].Price
+		}
+		return changes[i].Price < changes[j].Price
+	})
+	return changes
+}
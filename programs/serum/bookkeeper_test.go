@@ -0,0 +1,254 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serum
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	orderbookNodesOffset   = 45 // serum padding + header
+	slabNodeSize           = 72 // variant tag + 68 bytes
+	slabLeafTag            = 2
+	slabLeafPriceOffset    = 4 + 4 + 8
+	slabLeafQuantityOffset = 4 + 4 + 16 + 32
+)
+
+func readOrderbookFixture(t testing.TB) []byte {
+	cnt, err := ioutil.ReadFile("./testdata/orderbook.hex")
+	require.NoError(t, err)
+	data, err := hex.DecodeString(string(cnt))
+	require.NoError(t, err)
+	return data
+}
+
+// leafOffsets returns the offsets of the leaf nodes in the raw orderbook data.
+func leafOffsets(data []byte) (offsets []int) {
+	bumpIndex := int(binary.LittleEndian.Uint32(data[13:17]))
+	for i := 0; i < bumpIndex; i++ {
+		offset := orderbookNodesOffset + i*slabNodeSize
+		if binary.LittleEndian.Uint32(data[offset:]) == slabLeafTag {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+func withLeafQuantity(data []byte, leafOffset int, quantity uint64) []byte {
+	out := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(out[leafOffset+slabLeafQuantityOffset:], quantity)
+	return out
+}
+
+func withLeafPrice(data []byte, leafOffset int, price uint64) []byte {
+	out := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(out[leafOffset+slabLeafPriceOffset:], price)
+	return out
+}
+
+func leafAt(data []byte, leafOffset int) (price uint64, quantity uint64) {
+	return binary.LittleEndian.Uint64(data[leafOffset+slabLeafPriceOffset:]),
+		binary.LittleEndian.Uint64(data[leafOffset+slabLeafQuantityOffset:])
+}
+
+func TestBookKeeper(t *testing.T) {
+	data := readOrderbookFixture(t)
+	leaves := leafOffsets(data)
+	require.Len(t, leaves, 17)
+
+	keeper := NewBookKeeper()
+	bid, ask := keeper.BestBidAsk()
+	require.Nil(t, bid)
+	require.Nil(t, ask)
+
+	changes, err := keeper.Update(data)
+	require.NoError(t, err)
+	require.NotEmpty(t, changes)
+	for _, change := range changes {
+		require.Equal(t, LevelAdded, change.Type)
+		require.Equal(t, Side(SideBid), change.Side)
+	}
+
+	// The fixture is a bids account.
+	levels := keeper.Levels(SideBid)
+	require.Len(t, levels, 16)
+	require.Len(t, changes, 16)
+	require.Empty(t, keeper.Levels(SideAsk))
+	for i := 1; i < len(levels); i++ {
+		require.Greater(t, levels[i-1].Price, levels[i].Price)
+	}
+	require.Equal(t, PriceLevel{Price: 1861, Quantity: 37502, Orders: 2}, levelAt(levels, 1861))
+
+	bid, ask = keeper.BestBidAsk()
+	require.Nil(t, ask)
+	require.Equal(t, &PriceLevel{Price: 1869, Quantity: 3000, Orders: 1}, bid)
+
+	t.Run("unchanged", func(t *testing.T) {
+		changes, err := keeper.Update(data)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("size changed", func(t *testing.T) {
+		price, quantity := leafAt(data, leaves[0])
+		before := levelAt(keeper.Levels(SideBid), price)
+
+		changes, err := keeper.Update(withLeafQuantity(data, leaves[0], quantity+100))
+		require.NoError(t, err)
+		require.Equal(t,
+			[]LevelChange{
+				{
+					Type:        LevelSizeChanged,
+					Side:        SideBid,
+					Price:       price,
+					OldQuantity: before.Quantity,
+					NewQuantity: before.Quantity + 100,
+				},
+			},
+			changes,
+		)
+
+		// Back to the original.
+		changes, err = keeper.Update(data)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, before.Quantity, changes[0].NewQuantity)
+	})
+
+	t.Run("added and removed", func(t *testing.T) {
+		// Move the best bid to a new (worse) price.
+		var bestLeaf int
+		for _, leaf := range leaves {
+			if price, _ := leafAt(data, leaf); price == 1869 {
+				bestLeaf = leaf
+			}
+		}
+		require.NotZero(t, bestLeaf)
+
+		changes, err := keeper.Update(withLeafPrice(data, bestLeaf, 1000))
+		require.NoError(t, err)
+		require.Equal(t,
+			[]LevelChange{
+				{Type: LevelRemoved, Side: SideBid, Price: 1869, OldQuantity: 3000},
+				{Type: LevelAdded, Side: SideBid, Price: 1000, NewQuantity: 3000},
+			},
+			changes,
+		)
+		bid, _ := keeper.BestBidAsk()
+		require.Equal(t, uint64(1868), bid.Price)
+	})
+
+	t.Run("not an orderbook side", func(t *testing.T) {
+		notSide := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint64(notSide[5:13], uint64(AccountFlagInitialized|AccountFlagMarket))
+		_, err := NewBookKeeper().Update(notSide)
+		require.Error(t, err)
+	})
+}
+
+func TestBookKeeper_concurrentReads(t *testing.T) {
+	data := readOrderbookFixture(t)
+	leaves := leafOffsets(data)
+	keeper := NewBookKeeper()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					keeper.BestBidAsk()
+					keeper.Levels(SideBid)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		_, err := keeper.Update(withLeafQuantity(data, leaves[i%len(leaves)], uint64(i+1)))
+		require.NoError(t, err)
+	}
+	close(done)
+	wg.Wait()
+}
+
+func levelAt(levels []PriceLevel, price uint64) PriceLevel {
+	for _, level := range levels {
+		if level.Price == price {
+			return level
+		}
+	}
+	return PriceLevel{}
+}
+
+// notificationStream simulates a stream of accountSubscribe notifications
+// for the bids account of the recorded orderbook: most notifications
+// carry the same data (the account was written, but the book didn't change),
+// the others change the size of one order.
+func notificationStream(b *testing.B) [][]byte {
+	data := readOrderbookFixture(b)
+	leaves := leafOffsets(data)
+	var stream [][]byte
+	current := data
+	for i := 0; i < 100; i++ {
+		if i%4 == 0 {
+			current = withLeafQuantity(current, leaves[i%len(leaves)], uint64(1000+i))
+		}
+		stream = append(stream, current)
+	}
+	return stream
+}
+
+func BenchmarkOrderbook_fullDecode(b *testing.B) {
+	stream := notificationStream(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, data := range stream {
+			var ob *Orderbook
+			if err := bin.NewBinDecoder(data).Decode(&ob); err != nil {
+				b.Fatal(err)
+			}
+			levels := map[uint64]uint64{}
+			ob.Items(false, func(node *SlabLeafNode) error {
+				levels[node.Key.Hi] += uint64(node.Quantity)
+				return nil
+			})
+		}
+	}
+}
+
+func BenchmarkOrderbook_bookKeeper(b *testing.B) {
+	stream := notificationStream(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		keeper := NewBookKeeper()
+		for _, data := range stream {
+			if _, err := keeper.Update(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
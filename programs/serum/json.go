@@ -15,7 +15,7 @@
 package serum
 
 import (
-	jsoniter "github.com/json-iterator/go"
+	"github.com/gagliardetto/solana-go/internal/jsoncodec"
 )
 
-var json = jsoniter.ConfigCompatibleWithStandardLibrary
+var json = jsoncodec.JSON
@@ -18,6 +18,7 @@
 package serum
 
 import (
+	"fmt"
 	"strings"
 
 	bin "github.com/gagliardetto/binary"
@@ -64,24 +65,48 @@ func (q *RequestQueue) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
 		return err
 	}
 
-	ringbufStartByte := decoder.Position()
-	ringbufByteSize := uint(decoder.Remaining() - 7)
-	ringbugLength := ringbufByteSize / EVENT_BYTE_SIZE
-
-	q.Requests = make([]*Request, q.Count)
-
-	for i := uint(0); i < uint(q.Count); i++ {
-		itemIndex := ((uint(q.Head) + i) % ringbugLength)
-		offset := ringbufStartByte + (itemIndex * EVENT_BYTE_SIZE)
-		if err = decoder.SetPosition(offset); err != nil {
+	q.Requests = make([]*Request, 0)
+	return decodeRingBuffer(decoder, uint64(q.Head), uint64(q.Count), REQUEST_BYTE_SIZE, func() error {
+		var request *Request
+		if err := decoder.Decode(&request); err != nil {
 			return err
 		}
+		q.Requests = append(q.Requests, request)
+		return nil
+	})
+}
 
-		if err = decoder.Decode(&q.Requests[i]); err != nil {
+// decodeRingBuffer calls `decodeItem` for each of the `count` items of the ring buffer
+// that starts at the current position of the decoder and takes all the remaining data
+// (minus the serum end padding), starting from the item at index `head` and wrapping around.
+// Before each call, the decoder is positioned at the start of the item.
+func decodeRingBuffer(decoder *bin.Decoder, head uint64, count uint64, itemSize uint, decodeItem func() error) error {
+	if decoder.Remaining() < 7 {
+		return fmt.Errorf("queue is too short: %d bytes remaining", decoder.Remaining())
+	}
+	start := uint(decoder.Position())
+	capacity := uint64(uint(decoder.Remaining()-7) / itemSize)
+	if capacity == 0 {
+		if count != 0 {
+			return fmt.Errorf("queue has no room for items, but count is %d", count)
+		}
+		return nil
+	}
+	if head >= capacity {
+		return fmt.Errorf("queue head %d is out of range (capacity %d)", head, capacity)
+	}
+	if count > capacity {
+		return fmt.Errorf("queue count %d is greater than its capacity %d", count, capacity)
+	}
+	for i := uint64(0); i < count; i++ {
+		index := (head + i) % capacity
+		if err := decoder.SetPosition(start + uint(index)*itemSize); err != nil {
 			return err
 		}
+		if err := decodeItem(); err != nil {
+			return fmt.Errorf("unable to decode item %d (at index %d): %w", i, index, err)
+		}
 	}
-
 	return nil
 }
 
@@ -151,6 +176,8 @@ func (r RequestFlag) IsDecrementTakeOnSelfTrade() bool {
 	return Has(uint8(r), uint8(RequestFlagDecrementTakeOnSelfTrade))
 }
 
+const REQUEST_BYTE_SIZE = uint(80)
+
 // Size 80 byte
 type Request struct {
 	RequestFlags         RequestFlag
@@ -218,25 +245,36 @@ func (q *EventQueue) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
 	if err = decoder.Decode(&q.SeqNum); err != nil {
 		return err
 	}
+	if q.Count > q.SeqNum {
+		return fmt.Errorf("event queue count %d is greater than its sequence number %d", q.Count, q.SeqNum)
+	}
 
-	ringbufStartByte := decoder.Position()
-	ringbufByteSize := uint(decoder.Remaining() - 7)
-	ringbugLength := ringbufByteSize / EVENT_BYTE_SIZE
-
-	q.Events = make([]*Event, q.Count)
-
-	for i := uint(0); i < uint(q.Count); i++ {
-		itemIndex := ((uint(q.Head) + i) % ringbugLength)
-		offset := ringbufStartByte + (itemIndex * EVENT_BYTE_SIZE)
-		if err = decoder.SetPosition(offset); err != nil {
+	q.Events = make([]*Event, 0)
+	return decodeRingBuffer(decoder, uint64(q.Head), uint64(q.Count), EVENT_BYTE_SIZE, func() error {
+		var event *Event
+		if err := decoder.Decode(&event); err != nil {
 			return err
 		}
+		q.Events = append(q.Events, event)
+		return nil
+	})
+}
 
-		if err = decoder.Decode(&q.Events[i]); err != nil {
+// Items calls `f` for each event in the queue, from the oldest (the next one
+// to be consumed by ConsumeEvents) to the newest, with its sequence number.
+// Sequence numbers are assigned when the events are pushed, so they can be used
+// to tell which events were already seen in a previous snapshot of the queue.
+// An error is returned if the queue has more events than its sequence number.
+func (q *EventQueue) Items(f func(seqNum uint64, event *Event) error) error {
+	if uint64(len(q.Events)) > uint64(q.SeqNum) {
+		return fmt.Errorf("event queue has %d events, more than its sequence number %d", len(q.Events), q.SeqNum)
+	}
+	firstSeqNum := uint64(q.SeqNum) - uint64(len(q.Events))
+	for i, event := range q.Events {
+		if err := f(firstSeqNum+uint64(i), event); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
 
@@ -19,6 +19,7 @@ package serum
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"strings"
@@ -172,3 +173,116 @@ func TestDecoder_EventQueue_DiffManual(t *testing.T) {
 		}
 	}))
 }
+
+func encodeTestEvent(flag EventFlag, released uint64, orderIDLo uint64, owner solana.PublicKey) []byte {
+	buf := make([]byte, EVENT_BYTE_SIZE)
+	buf[0] = byte(flag)
+	binary.LittleEndian.PutUint64(buf[8:], released)
+	binary.LittleEndian.PutUint64(buf[32:], orderIDLo)
+	copy(buf[48:], owner[:])
+	return buf
+}
+
+func encodeTestEventQueue(head, count, seqNum uint64, slots [][]byte) []byte {
+	buf := []byte("serum")
+	buf = append(buf, make([]byte, 8)...) // account flags
+	buf = append(buf, make([]byte, 24)...)
+	binary.LittleEndian.PutUint64(buf[13:], head)
+	binary.LittleEndian.PutUint64(buf[21:], count)
+	binary.LittleEndian.PutUint64(buf[29:], seqNum)
+	for _, slot := range slots {
+		buf = append(buf, slot...)
+	}
+	return append(buf, []byte("padding")...)
+}
+
+func TestEventQueue_Items_wrapping(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	empty := make([]byte, EVENT_BYTE_SIZE)
+	// Capacity 4, head at 2, 3 events: the third one wrapped around to index 0.
+	data := encodeTestEventQueue(2, 3, 10, [][]byte{
+		encodeTestEvent(EventFlagOut, 3, 3, owner),
+		empty,
+		encodeTestEvent(EventFlagFill|EventFlagBid, 1, 1, owner),
+		encodeTestEvent(EventFlagFill|EventFlagMaker, 2, 2, owner),
+	})
+
+	q := &EventQueue{}
+	require.NoError(t, q.Decode(data))
+	require.Len(t, q.Events, 3)
+
+	var seqNums []uint64
+	var released []uint64
+	require.NoError(t, q.Items(func(seqNum uint64, event *Event) error {
+		seqNums = append(seqNums, seqNum)
+		released = append(released, event.NativeQtyReleased)
+		assert.Equal(t, owner, event.Owner)
+		assert.Equal(t, event.NativeQtyReleased, event.OrderID.Lo)
+		return nil
+	}))
+	assert.Equal(t, []uint64{7, 8, 9}, seqNums)
+	assert.Equal(t, []uint64{1, 2, 3}, released)
+
+	assert.True(t, q.Events[0].Filled())
+	assert.Equal(t, Side(SideBid), q.Events[0].Side())
+	assert.True(t, q.Events[1].Flag.IsMaker())
+	assert.Equal(t, Side(SideAsk), q.Events[1].Side())
+	assert.True(t, q.Events[2].Flag.IsOut())
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err := q.Items(func(seqNum uint64, event *Event) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	// More events than the sequence number: the first one would wrap around.
+	q.SeqNum = 2
+	err = q.Items(func(seqNum uint64, event *Event) error {
+		t.Fatalf("unexpected event %d", seqNum)
+		return nil
+	})
+	assert.EqualError(t, err, "event queue has 3 events, more than its sequence number 2")
+}
+
+func TestEventQueue_Decode_malformed(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	slots := [][]byte{
+		encodeTestEvent(EventFlagFill, 1, 1, owner),
+		encodeTestEvent(EventFlagFill, 2, 2, owner),
+	}
+	tests := map[string][]byte{
+		"head out of range":   encodeTestEventQueue(2, 1, 1, slots),
+		"count over capacity": encodeTestEventQueue(0, 3, 3, slots),
+		"count over seq num":  encodeTestEventQueue(0, 2, 1, slots),
+		"no room for events":  encodeTestEventQueue(0, 1, 1, nil),
+		"missing padding":     encodeTestEventQueue(0, 0, 0, nil)[:37],
+		"truncated header":    []byte("serum"),
+	}
+	for name, data := range tests {
+		q := &EventQueue{}
+		assert.Error(t, q.Decode(data), name)
+	}
+}
+
+func TestEventQueue_Items_fixture(t *testing.T) {
+	q := &EventQueue{}
+	require.NoError(t, q.Decode(readCompressedFile(t, "testdata/serum-event-queue-new.bin.zst")))
+
+	count := 0
+	var lastSeqNum uint64
+	require.NoError(t, q.Items(func(seqNum uint64, event *Event) error {
+		if count > 0 {
+			require.Equal(t, lastSeqNum+1, seqNum)
+		}
+		lastSeqNum = seqNum
+		count++
+		return nil
+	}))
+	require.Equal(t, int(q.Count), count)
+	if count > 0 {
+		require.Equal(t, uint64(q.SeqNum)-1, lastSeqNum)
+	}
+}
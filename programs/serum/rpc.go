@@ -25,6 +25,7 @@ import (
 	rice "github.com/GeertJohan/go.rice"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/rpc/ws"
 	"go.uber.org/zap"
@@ -101,17 +102,77 @@ func FetchMarket(ctx context.Context, rpcCli *rpc.Client, marketAddr solana.Publ
 	return meta, nil
 }
 
+// FetchVaultBalances fetches the base and quote vault token accounts of the market
+// (in one request), and returns their amounts, i.e. the base and quote tokens
+// held by the market, in the smallest units of their mints.
+func (m *MarketMeta) FetchVaultBalances(ctx context.Context, rpcCli *rpc.Client) (base uint64, quote uint64, err error) {
+	vaults := []solana.PublicKey{m.MarketV2.BaseVault, m.MarketV2.QuoteVault}
+	resp, err := rpcCli.GetMultipleAccountsWithOpts(ctx, vaults, &rpc.GetMultipleAccountsOpts{
+		Encoding: solana.EncodingBase64,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to get vault accounts: %w", err)
+	}
+	if len(resp.Value) != len(vaults) {
+		return 0, 0, fmt.Errorf("expected %d vault accounts, got %d", len(vaults), len(resp.Value))
+	}
+
+	var amounts [2]uint64
+	for i, acct := range resp.Value {
+		if acct == nil {
+			return 0, 0, fmt.Errorf("vault %s: %w", vaults[i], rpc.ErrAccountNotFound)
+		}
+		if err := rpc.ExpectOwner(acct, token.ProgramID); err != nil {
+			return 0, 0, fmt.Errorf("vault %s: %w", vaults[i], err)
+		}
+		if err := rpc.ExpectDataLen(acct, token.ACCOUNT_SIZE); err != nil {
+			return 0, 0, fmt.Errorf("vault %s: %w", vaults[i], err)
+		}
+		var vault token.Account
+		if err := bin.NewBinDecoder(acct.Data.GetBinary()).Decode(&vault); err != nil {
+			return 0, 0, fmt.Errorf("decoding vault %s: %w", vaults[i], err)
+		}
+		amounts[i] = vault.Amount
+	}
+	return amounts[0], amounts[1], nil
+}
+
 func StreamOpenOrders(client *ws.Client) error {
+	return StreamOpenOrdersWithContext(context.Background(), client)
+}
+
+// StreamOpenOrdersWithContext is like StreamOpenOrders, but it unsubscribes
+// and returns ctx.Err() as soon as the context is done.
+func StreamOpenOrdersWithContext(ctx context.Context, client *ws.Client) error {
 	sub, err := client.ProgramSubscribe(DEXProgramIDV2, rpc.CommitmentSingleGossip)
 	if err != nil {
 		return fmt.Errorf("unable to subscribe to programID %q: %w", DEXProgramIDV2, err)
 	}
+	defer sub.Unsubscribe()
+
+	// Recv doesn't take a context: unsubscribing makes it return.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Unsubscribe()
+		case <-stop:
+		}
+	}()
+
 	count := 0
 	for {
 		d, err := sub.Recv()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			return fmt.Errorf("received error from programID subscription: %w", err)
 		}
+		if d == nil {
+			return fmt.Errorf("programID subscription closed")
+		}
 		res := d
 
 		var f *AccountFlag
@@ -19,13 +19,23 @@ package serum
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gagliardetto/solana-go/rpc/ws"
 
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/solanatest"
 
 	"github.com/stretchr/testify/require"
 
@@ -69,3 +79,82 @@ func TestStreamOpenOrders(t *testing.T) {
 	err = StreamOpenOrders(client)
 	require.NoError(t, err)
 }
+
+func TestFetchMarket_contextTimeout(t *testing.T) {
+	market := make([]byte, 388)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Consume the body, so that the server notices when the client goes away.
+		io.Copy(ioutil.Discard, r.Body)
+		if atomic.AddInt32(&requests, 1) == 1 {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":0,"result":{"context":{"slot":1},"value":{"data":[%q,"base64"],"executable":false,"lamports":1,"owner":%q,"rentEpoch":1}}}`,
+				base64.StdEncoding.EncodeToString(market),
+				DEXProgramIDV2,
+			)
+			return
+		}
+		// The mint requests hang until the client gives up.
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+	client := rpc.New(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := FetchMarket(ctx, client, solana.NewWallet().PublicKey())
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Less(t, int64(time.Since(start)), int64(2*time.Second))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// An already cancelled context doesn't send any request.
+	atomic.StoreInt32(&requests, 0)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = FetchMarket(ctx, client, solana.NewWallet().PublicKey())
+	require.True(t, errors.Is(err, context.Canceled), err)
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+}
+
+func TestMarketMeta_FetchVaultBalances(t *testing.T) {
+	baseMint := solanatest.PublicKeyFromSeedString("base mint")
+	quoteMint := solanatest.PublicKeyFromSeedString("quote mint")
+	market := &MarketMeta{
+		MarketV2: MarketV2{
+			BaseVault:  solanatest.PublicKeyFromSeedString("base vault"),
+			QuoteVault: solanatest.PublicKeyFromSeedString("quote vault"),
+		},
+	}
+	serve := func(t *testing.T, accounts ...string) *rpc.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(body), `"getMultipleAccounts"`)
+			require.Contains(t, string(body), market.MarketV2.BaseVault.String())
+			require.Contains(t, string(body), market.MarketV2.QuoteVault.String())
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":0,"result":{"context":{"slot":1},"value":[%s]}}`, strings.Join(accounts, ","))
+		}))
+		t.Cleanup(server.Close)
+		return rpc.New(server.URL)
+	}
+	baseVault := solanatest.AccountJSON(solanatest.TokenAccountFixture(baseMint, DEXProgramIDV2, 1500))
+	quoteVault := solanatest.AccountJSON(solanatest.TokenAccountFixture(quoteMint, DEXProgramIDV2, 42000))
+
+	base, quote, err := market.FetchVaultBalances(context.Background(), serve(t, baseVault, quoteVault))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1500), base)
+	require.Equal(t, uint64(42000), quote)
+
+	_, _, err = market.FetchVaultBalances(context.Background(), serve(t, baseVault, "null"))
+	require.True(t, errors.Is(err, rpc.ErrAccountNotFound), err)
+	require.Contains(t, err.Error(), market.MarketV2.QuoteVault.String())
+
+	notAVault := solanatest.AccountJSON(solanatest.FundedAccountFixture(1000))
+	_, _, err = market.FetchVaultBalances(context.Background(), serve(t, notAVault, quoteVault))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected account owner")
+}
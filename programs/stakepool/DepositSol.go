@@ -0,0 +1,358 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Deposits SOL into the reserve of the pool, in exchange for pool tokens.
+type DepositSol struct {
+	// The amount of lamports to deposit.
+	Lamports *uint64
+
+	// [0] = [WRITE] stakePool
+	// ··········· The stake pool.
+	//
+	// [1] = [] withdrawAuthority
+	// ··········· The withdraw authority PDA of the pool.
+	//
+	// [2] = [WRITE] reserveStake
+	// ··········· The reserve stake account of the pool.
+	//
+	// [3] = [WRITE, SIGNER] lamportsFrom
+	// ··········· The account providing the lamports.
+	//
+	// [4] = [WRITE] poolTokensTo
+	// ··········· The pool token account receiving the pool tokens.
+	//
+	// [5] = [WRITE] managerFeeAccount
+	// ··········· The pool token account receiving the fees.
+	//
+	// [6] = [WRITE] referrerPoolTokens
+	// ··········· The pool token account receiving the referral fees.
+	//
+	// [7] = [WRITE] poolMint
+	// ··········· The pool token mint.
+	//
+	// [8] = [] systemProgram
+	// ··········· The system program.
+	//
+	// [9] = [] tokenProgram
+	// ··········· The token program of the pool mint.
+	//
+	// [10] = [SIGNER] solDepositAuthority
+	// ··········· (optional) The SOL deposit authority, if the pool has one.
+	Accounts ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+func (obj *DepositSol) SetAccounts(accounts []*ag_solanago.AccountMeta) error {
+	obj.Accounts = ag_solanago.AccountMetaSlice(accounts)
+	return nil
+}
+
+func (slice DepositSol) GetAccounts() (accounts []*ag_solanago.AccountMeta) {
+	accounts = append(accounts, slice.Accounts...)
+	return
+}
+
+// NewDepositSolInstructionBuilder creates a new `DepositSol` instruction builder.
+func NewDepositSolInstructionBuilder() *DepositSol {
+	nd := &DepositSol{
+		Accounts: make(ag_solanago.AccountMetaSlice, 10),
+	}
+	nd.Accounts[8] = ag_solanago.Meta(ag_solanago.SystemProgramID)
+	nd.Accounts[9] = ag_solanago.Meta(ag_solanago.TokenProgramID)
+	return nd
+}
+
+// SetLamports sets the "lamports" parameter.
+// The amount of lamports to deposit.
+func (inst *DepositSol) SetLamports(lamports uint64) *DepositSol {
+	inst.Lamports = &lamports
+	return inst
+}
+
+// SetStakePoolAccount sets the "stakePool" account.
+func (inst *DepositSol) SetStakePoolAccount(stakePool ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[0] = ag_solanago.Meta(stakePool).WRITE()
+	return inst
+}
+
+// GetStakePoolAccount gets the "stakePool" account.
+func (inst *DepositSol) GetStakePoolAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[0]
+}
+
+// SetWithdrawAuthorityAccount sets the "withdrawAuthority" account.
+func (inst *DepositSol) SetWithdrawAuthorityAccount(withdrawAuthority ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[1] = ag_solanago.Meta(withdrawAuthority)
+	return inst
+}
+
+// GetWithdrawAuthorityAccount gets the "withdrawAuthority" account.
+func (inst *DepositSol) GetWithdrawAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[1]
+}
+
+// SetReserveStakeAccount sets the "reserveStake" account.
+func (inst *DepositSol) SetReserveStakeAccount(reserveStake ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[2] = ag_solanago.Meta(reserveStake).WRITE()
+	return inst
+}
+
+// GetReserveStakeAccount gets the "reserveStake" account.
+func (inst *DepositSol) GetReserveStakeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[2]
+}
+
+// SetLamportsFromAccount sets the "lamportsFrom" account.
+func (inst *DepositSol) SetLamportsFromAccount(lamportsFrom ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[3] = ag_solanago.Meta(lamportsFrom).WRITE().SIGNER()
+	return inst
+}
+
+// GetLamportsFromAccount gets the "lamportsFrom" account.
+func (inst *DepositSol) GetLamportsFromAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[3]
+}
+
+// SetPoolTokensToAccount sets the "poolTokensTo" account.
+func (inst *DepositSol) SetPoolTokensToAccount(poolTokensTo ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[4] = ag_solanago.Meta(poolTokensTo).WRITE()
+	return inst
+}
+
+// GetPoolTokensToAccount gets the "poolTokensTo" account.
+func (inst *DepositSol) GetPoolTokensToAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[4]
+}
+
+// SetManagerFeeAccount sets the "managerFeeAccount" account.
+func (inst *DepositSol) SetManagerFeeAccount(managerFeeAccount ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[5] = ag_solanago.Meta(managerFeeAccount).WRITE()
+	return inst
+}
+
+// GetManagerFeeAccount gets the "managerFeeAccount" account.
+func (inst *DepositSol) GetManagerFeeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[5]
+}
+
+// SetReferrerPoolTokensAccount sets the "referrerPoolTokens" account.
+func (inst *DepositSol) SetReferrerPoolTokensAccount(referrerPoolTokens ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[6] = ag_solanago.Meta(referrerPoolTokens).WRITE()
+	return inst
+}
+
+// GetReferrerPoolTokensAccount gets the "referrerPoolTokens" account.
+func (inst *DepositSol) GetReferrerPoolTokensAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[6]
+}
+
+// SetPoolMintAccount sets the "poolMint" account.
+func (inst *DepositSol) SetPoolMintAccount(poolMint ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[7] = ag_solanago.Meta(poolMint).WRITE()
+	return inst
+}
+
+// GetPoolMintAccount gets the "poolMint" account.
+func (inst *DepositSol) GetPoolMintAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[7]
+}
+
+// SetSystemProgramAccount sets the "systemProgram" account.
+func (inst *DepositSol) SetSystemProgramAccount(systemProgram ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[8] = ag_solanago.Meta(systemProgram)
+	return inst
+}
+
+// GetSystemProgramAccount gets the "systemProgram" account.
+func (inst *DepositSol) GetSystemProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[8]
+}
+
+// SetTokenProgramAccount sets the "tokenProgram" account.
+// Defaults to the SPL Token program.
+func (inst *DepositSol) SetTokenProgramAccount(tokenProgram ag_solanago.PublicKey) *DepositSol {
+	inst.Accounts[9] = ag_solanago.Meta(tokenProgram)
+	return inst
+}
+
+// GetTokenProgramAccount gets the "tokenProgram" account.
+func (inst *DepositSol) GetTokenProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[9]
+}
+
+// SetSolDepositAuthorityAccount sets the optional "solDepositAuthority" account,
+// required when the pool has a SOL deposit authority.
+func (inst *DepositSol) SetSolDepositAuthorityAccount(solDepositAuthority ag_solanago.PublicKey) *DepositSol {
+	meta := ag_solanago.Meta(solDepositAuthority).SIGNER()
+	if len(inst.Accounts) > 10 {
+		inst.Accounts[10] = meta
+	} else {
+		inst.Accounts = append(inst.Accounts, meta)
+	}
+	return inst
+}
+
+// GetSolDepositAuthorityAccount gets the "solDepositAuthority" account (nil if not set).
+func (inst *DepositSol) GetSolDepositAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts.Get(10)
+}
+
+func (inst DepositSol) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint8(Instruction_DepositSol),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst DepositSol) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *DepositSol) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.Lamports == nil {
+			return errors.New("Lamports parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if len(inst.Accounts) < 10 || len(inst.Accounts) > 11 {
+			return fmt.Errorf("expected 10 or 11 accounts, got %v", len(inst.Accounts))
+		}
+		if inst.Accounts[0] == nil {
+			return errors.New("accounts.StakePool is not set")
+		}
+		if inst.Accounts[1] == nil {
+			return errors.New("accounts.WithdrawAuthority is not set")
+		}
+		if inst.Accounts[2] == nil {
+			return errors.New("accounts.ReserveStake is not set")
+		}
+		if inst.Accounts[3] == nil {
+			return errors.New("accounts.LamportsFrom is not set")
+		}
+		if inst.Accounts[4] == nil {
+			return errors.New("accounts.PoolTokensTo is not set")
+		}
+		if inst.Accounts[5] == nil {
+			return errors.New("accounts.ManagerFeeAccount is not set")
+		}
+		if inst.Accounts[6] == nil {
+			return errors.New("accounts.ReferrerPoolTokens is not set")
+		}
+		if inst.Accounts[7] == nil {
+			return errors.New("accounts.PoolMint is not set")
+		}
+		if inst.Accounts[8] == nil {
+			return errors.New("accounts.SystemProgram is not set")
+		}
+		if inst.Accounts[9] == nil {
+			return errors.New("accounts.TokenProgram is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *DepositSol) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("DepositSol")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("          stakePool", inst.Accounts.Get(0)))
+						accountsBranch.Child(ag_format.Meta("  withdrawAuthority", inst.Accounts.Get(1)))
+						accountsBranch.Child(ag_format.Meta("       reserveStake", inst.Accounts.Get(2)))
+						accountsBranch.Child(ag_format.Meta("       lamportsFrom", inst.Accounts.Get(3)))
+						accountsBranch.Child(ag_format.Meta("       poolTokensTo", inst.Accounts.Get(4)))
+						accountsBranch.Child(ag_format.Meta("  managerFeeAccount", inst.Accounts.Get(5)))
+						accountsBranch.Child(ag_format.Meta(" referrerPoolTokens", inst.Accounts.Get(6)))
+						accountsBranch.Child(ag_format.Meta("           poolMint", inst.Accounts.Get(7)))
+						accountsBranch.Child(ag_format.Meta("      systemProgram", inst.Accounts.Get(8)))
+						accountsBranch.Child(ag_format.Meta("       tokenProgram", inst.Accounts.Get(9)))
+						accountsBranch.Child(ag_format.Meta("solDepositAuthority", inst.Accounts.Get(10)))
+					})
+				})
+		})
+}
+
+func (obj DepositSol) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `Lamports` param:
+	err = encoder.Encode(obj.Lamports)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *DepositSol) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `Lamports`:
+	err = decoder.Decode(&obj.Lamports)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewDepositSolInstruction declares a new DepositSol instruction with the provided parameters and accounts.
+// For a pool with a SOL deposit authority, also call SetSolDepositAuthorityAccount.
+func NewDepositSolInstruction(
+	// Parameters:
+	lamports uint64,
+	// Accounts:
+	stakePool ag_solanago.PublicKey,
+	withdrawAuthority ag_solanago.PublicKey,
+	reserveStake ag_solanago.PublicKey,
+	lamportsFrom ag_solanago.PublicKey,
+	poolTokensTo ag_solanago.PublicKey,
+	managerFeeAccount ag_solanago.PublicKey,
+	referrerPoolTokens ag_solanago.PublicKey,
+	poolMint ag_solanago.PublicKey) *DepositSol {
+	return NewDepositSolInstructionBuilder().
+		SetLamports(lamports).
+		SetStakePoolAccount(stakePool).
+		SetWithdrawAuthorityAccount(withdrawAuthority).
+		SetReserveStakeAccount(reserveStake).
+		SetLamportsFromAccount(lamportsFrom).
+		SetPoolTokensToAccount(poolTokensTo).
+		SetManagerFeeAccount(managerFeeAccount).
+		SetReferrerPoolTokensAccount(referrerPoolTokens).
+		SetPoolMintAccount(poolMint)
+}
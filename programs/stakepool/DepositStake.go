@@ -0,0 +1,404 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Deposits a stake account, delegated to a validator of the pool,
+// in exchange for pool tokens.
+// The staker and withdrawer authorities of the stake account
+// must have been set to the deposit authority and to the withdraw authority
+// of the pool first (see DepositStakeInstructions).
+type DepositStake struct {
+	// [0] = [WRITE] stakePool
+	// ··········· The stake pool.
+	//
+	// [1] = [WRITE] validatorList
+	// ··········· The validator list of the pool.
+	//
+	// [2] = [] depositAuthority
+	// ··········· The stake deposit authority of the pool (a signer if it is not the default PDA).
+	//
+	// [3] = [] withdrawAuthority
+	// ··········· The withdraw authority PDA of the pool.
+	//
+	// [4] = [WRITE] depositStake
+	// ··········· The stake account to deposit.
+	//
+	// [5] = [WRITE] validatorStake
+	// ··········· The stake account of the validator in the pool.
+	//
+	// [6] = [WRITE] reserveStake
+	// ··········· The reserve stake account of the pool.
+	//
+	// [7] = [WRITE] poolTokensTo
+	// ··········· The pool token account receiving the pool tokens.
+	//
+	// [8] = [WRITE] managerFeeAccount
+	// ··········· The pool token account receiving the fees.
+	//
+	// [9] = [WRITE] referrerPoolTokens
+	// ··········· The pool token account receiving the referral fees.
+	//
+	// [10] = [WRITE] poolMint
+	// ··········· The pool token mint.
+	//
+	// [11] = [] clockSysvar
+	// ··········· The clock sysvar.
+	//
+	// [12] = [] stakeHistorySysvar
+	// ··········· The stake history sysvar.
+	//
+	// [13] = [] tokenProgram
+	// ··········· The token program of the pool mint.
+	//
+	// [14] = [] stakeProgram
+	// ··········· The stake program.
+	Accounts ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+func (obj *DepositStake) SetAccounts(accounts []*ag_solanago.AccountMeta) error {
+	obj.Accounts = ag_solanago.AccountMetaSlice(accounts)
+	return nil
+}
+
+func (slice DepositStake) GetAccounts() (accounts []*ag_solanago.AccountMeta) {
+	accounts = append(accounts, slice.Accounts...)
+	return
+}
+
+// NewDepositStakeInstructionBuilder creates a new `DepositStake` instruction builder.
+func NewDepositStakeInstructionBuilder() *DepositStake {
+	nd := &DepositStake{
+		Accounts: make(ag_solanago.AccountMetaSlice, 15),
+	}
+	nd.Accounts[11] = ag_solanago.Meta(ag_solanago.SysVarClockPubkey)
+	nd.Accounts[12] = ag_solanago.Meta(ag_solanago.SysVarStakeHistoryPubkey)
+	nd.Accounts[13] = ag_solanago.Meta(ag_solanago.TokenProgramID)
+	nd.Accounts[14] = ag_solanago.Meta(ag_solanago.StakeProgramID)
+	return nd
+}
+
+// SetStakePoolAccount sets the "stakePool" account.
+func (inst *DepositStake) SetStakePoolAccount(stakePool ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[0] = ag_solanago.Meta(stakePool).WRITE()
+	return inst
+}
+
+// GetStakePoolAccount gets the "stakePool" account.
+func (inst *DepositStake) GetStakePoolAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[0]
+}
+
+// SetValidatorListAccount sets the "validatorList" account.
+func (inst *DepositStake) SetValidatorListAccount(validatorList ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[1] = ag_solanago.Meta(validatorList).WRITE()
+	return inst
+}
+
+// GetValidatorListAccount gets the "validatorList" account.
+func (inst *DepositStake) GetValidatorListAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[1]
+}
+
+// SetDepositAuthorityAccount sets the "depositAuthority" account.
+// A custom deposit authority must sign: mark it with GetDepositAuthorityAccount().SIGNER().
+func (inst *DepositStake) SetDepositAuthorityAccount(depositAuthority ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[2] = ag_solanago.Meta(depositAuthority)
+	return inst
+}
+
+// GetDepositAuthorityAccount gets the "depositAuthority" account.
+func (inst *DepositStake) GetDepositAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[2]
+}
+
+// SetWithdrawAuthorityAccount sets the "withdrawAuthority" account.
+func (inst *DepositStake) SetWithdrawAuthorityAccount(withdrawAuthority ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[3] = ag_solanago.Meta(withdrawAuthority)
+	return inst
+}
+
+// GetWithdrawAuthorityAccount gets the "withdrawAuthority" account.
+func (inst *DepositStake) GetWithdrawAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[3]
+}
+
+// SetDepositStakeAccount sets the "depositStake" account.
+func (inst *DepositStake) SetDepositStakeAccount(depositStake ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[4] = ag_solanago.Meta(depositStake).WRITE()
+	return inst
+}
+
+// GetDepositStakeAccount gets the "depositStake" account.
+func (inst *DepositStake) GetDepositStakeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[4]
+}
+
+// SetValidatorStakeAccount sets the "validatorStake" account.
+func (inst *DepositStake) SetValidatorStakeAccount(validatorStake ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[5] = ag_solanago.Meta(validatorStake).WRITE()
+	return inst
+}
+
+// GetValidatorStakeAccount gets the "validatorStake" account.
+func (inst *DepositStake) GetValidatorStakeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[5]
+}
+
+// SetReserveStakeAccount sets the "reserveStake" account.
+func (inst *DepositStake) SetReserveStakeAccount(reserveStake ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[6] = ag_solanago.Meta(reserveStake).WRITE()
+	return inst
+}
+
+// GetReserveStakeAccount gets the "reserveStake" account.
+func (inst *DepositStake) GetReserveStakeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[6]
+}
+
+// SetPoolTokensToAccount sets the "poolTokensTo" account.
+func (inst *DepositStake) SetPoolTokensToAccount(poolTokensTo ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[7] = ag_solanago.Meta(poolTokensTo).WRITE()
+	return inst
+}
+
+// GetPoolTokensToAccount gets the "poolTokensTo" account.
+func (inst *DepositStake) GetPoolTokensToAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[7]
+}
+
+// SetManagerFeeAccount sets the "managerFeeAccount" account.
+func (inst *DepositStake) SetManagerFeeAccount(managerFeeAccount ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[8] = ag_solanago.Meta(managerFeeAccount).WRITE()
+	return inst
+}
+
+// GetManagerFeeAccount gets the "managerFeeAccount" account.
+func (inst *DepositStake) GetManagerFeeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[8]
+}
+
+// SetReferrerPoolTokensAccount sets the "referrerPoolTokens" account.
+func (inst *DepositStake) SetReferrerPoolTokensAccount(referrerPoolTokens ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[9] = ag_solanago.Meta(referrerPoolTokens).WRITE()
+	return inst
+}
+
+// GetReferrerPoolTokensAccount gets the "referrerPoolTokens" account.
+func (inst *DepositStake) GetReferrerPoolTokensAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[9]
+}
+
+// SetPoolMintAccount sets the "poolMint" account.
+func (inst *DepositStake) SetPoolMintAccount(poolMint ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[10] = ag_solanago.Meta(poolMint).WRITE()
+	return inst
+}
+
+// GetPoolMintAccount gets the "poolMint" account.
+func (inst *DepositStake) GetPoolMintAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[10]
+}
+
+// SetClockSysvarAccount sets the "clockSysvar" account.
+func (inst *DepositStake) SetClockSysvarAccount(clockSysvar ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[11] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+// GetClockSysvarAccount gets the "clockSysvar" account.
+func (inst *DepositStake) GetClockSysvarAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[11]
+}
+
+// SetStakeHistorySysvarAccount sets the "stakeHistorySysvar" account.
+func (inst *DepositStake) SetStakeHistorySysvarAccount(stakeHistorySysvar ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[12] = ag_solanago.Meta(stakeHistorySysvar)
+	return inst
+}
+
+// GetStakeHistorySysvarAccount gets the "stakeHistorySysvar" account.
+func (inst *DepositStake) GetStakeHistorySysvarAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[12]
+}
+
+// SetTokenProgramAccount sets the "tokenProgram" account.
+// Defaults to the SPL Token program.
+func (inst *DepositStake) SetTokenProgramAccount(tokenProgram ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[13] = ag_solanago.Meta(tokenProgram)
+	return inst
+}
+
+// GetTokenProgramAccount gets the "tokenProgram" account.
+func (inst *DepositStake) GetTokenProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[13]
+}
+
+// SetStakeProgramAccount sets the "stakeProgram" account.
+func (inst *DepositStake) SetStakeProgramAccount(stakeProgram ag_solanago.PublicKey) *DepositStake {
+	inst.Accounts[14] = ag_solanago.Meta(stakeProgram)
+	return inst
+}
+
+// GetStakeProgramAccount gets the "stakeProgram" account.
+func (inst *DepositStake) GetStakeProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[14]
+}
+
+func (inst DepositStake) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint8(Instruction_DepositStake),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst DepositStake) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *DepositStake) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if len(inst.Accounts) != 15 {
+			return fmt.Errorf("expected 15 accounts, got %v", len(inst.Accounts))
+		}
+		if inst.Accounts[0] == nil {
+			return errors.New("accounts.StakePool is not set")
+		}
+		if inst.Accounts[1] == nil {
+			return errors.New("accounts.ValidatorList is not set")
+		}
+		if inst.Accounts[2] == nil {
+			return errors.New("accounts.DepositAuthority is not set")
+		}
+		if inst.Accounts[3] == nil {
+			return errors.New("accounts.WithdrawAuthority is not set")
+		}
+		if inst.Accounts[4] == nil {
+			return errors.New("accounts.DepositStake is not set")
+		}
+		if inst.Accounts[5] == nil {
+			return errors.New("accounts.ValidatorStake is not set")
+		}
+		if inst.Accounts[6] == nil {
+			return errors.New("accounts.ReserveStake is not set")
+		}
+		if inst.Accounts[7] == nil {
+			return errors.New("accounts.PoolTokensTo is not set")
+		}
+		if inst.Accounts[8] == nil {
+			return errors.New("accounts.ManagerFeeAccount is not set")
+		}
+		if inst.Accounts[9] == nil {
+			return errors.New("accounts.ReferrerPoolTokens is not set")
+		}
+		if inst.Accounts[10] == nil {
+			return errors.New("accounts.PoolMint is not set")
+		}
+		if inst.Accounts[11] == nil {
+			return errors.New("accounts.ClockSysvar is not set")
+		}
+		if inst.Accounts[12] == nil {
+			return errors.New("accounts.StakeHistorySysvar is not set")
+		}
+		if inst.Accounts[13] == nil {
+			return errors.New("accounts.TokenProgram is not set")
+		}
+		if inst.Accounts[14] == nil {
+			return errors.New("accounts.StakeProgram is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *DepositStake) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("DepositStake")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("         stakePool", inst.Accounts.Get(0)))
+						accountsBranch.Child(ag_format.Meta("     validatorList", inst.Accounts.Get(1)))
+						accountsBranch.Child(ag_format.Meta("  depositAuthority", inst.Accounts.Get(2)))
+						accountsBranch.Child(ag_format.Meta(" withdrawAuthority", inst.Accounts.Get(3)))
+						accountsBranch.Child(ag_format.Meta("      depositStake", inst.Accounts.Get(4)))
+						accountsBranch.Child(ag_format.Meta("    validatorStake", inst.Accounts.Get(5)))
+						accountsBranch.Child(ag_format.Meta("      reserveStake", inst.Accounts.Get(6)))
+						accountsBranch.Child(ag_format.Meta("      poolTokensTo", inst.Accounts.Get(7)))
+						accountsBranch.Child(ag_format.Meta(" managerFeeAccount", inst.Accounts.Get(8)))
+						accountsBranch.Child(ag_format.Meta("referrerPoolTokens", inst.Accounts.Get(9)))
+						accountsBranch.Child(ag_format.Meta("          poolMint", inst.Accounts.Get(10)))
+						accountsBranch.Child(ag_format.Meta("       clockSysvar", inst.Accounts.Get(11)))
+						accountsBranch.Child(ag_format.Meta("stakeHistorySysvar", inst.Accounts.Get(12)))
+						accountsBranch.Child(ag_format.Meta("      tokenProgram", inst.Accounts.Get(13)))
+						accountsBranch.Child(ag_format.Meta("      stakeProgram", inst.Accounts.Get(14)))
+					})
+				})
+		})
+}
+
+func (obj DepositStake) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	return nil
+}
+func (obj *DepositStake) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	return nil
+}
+
+// NewDepositStakeInstruction declares a new DepositStake instruction with the provided parameters and accounts.
+func NewDepositStakeInstruction(
+	// Accounts:
+	stakePool ag_solanago.PublicKey,
+	validatorList ag_solanago.PublicKey,
+	depositAuthority ag_solanago.PublicKey,
+	withdrawAuthority ag_solanago.PublicKey,
+	depositStake ag_solanago.PublicKey,
+	validatorStake ag_solanago.PublicKey,
+	reserveStake ag_solanago.PublicKey,
+	poolTokensTo ag_solanago.PublicKey,
+	managerFeeAccount ag_solanago.PublicKey,
+	referrerPoolTokens ag_solanago.PublicKey,
+	poolMint ag_solanago.PublicKey) *DepositStake {
+	return NewDepositStakeInstructionBuilder().
+		SetStakePoolAccount(stakePool).
+		SetValidatorListAccount(validatorList).
+		SetDepositAuthorityAccount(depositAuthority).
+		SetWithdrawAuthorityAccount(withdrawAuthority).
+		SetDepositStakeAccount(depositStake).
+		SetValidatorStakeAccount(validatorStake).
+		SetReserveStakeAccount(reserveStake).
+		SetPoolTokensToAccount(poolTokensTo).
+		SetManagerFeeAccount(managerFeeAccount).
+		SetReferrerPoolTokensAccount(referrerPoolTokens).
+		SetPoolMintAccount(poolMint)
+}
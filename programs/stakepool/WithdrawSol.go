@@ -0,0 +1,396 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Burns pool tokens in exchange for SOL from the reserve of the pool.
+type WithdrawSol struct {
+	// The amount of pool tokens to burn.
+	PoolTokens *uint64
+
+	// [0] = [WRITE] stakePool
+	// ··········· The stake pool.
+	//
+	// [1] = [] withdrawAuthority
+	// ··········· The withdraw authority PDA of the pool.
+	//
+	// [2] = [SIGNER] userTransferAuthority
+	// ··········· The owner (or delegate) of the pool token account.
+	//
+	// [3] = [WRITE] poolTokensFrom
+	// ··········· The pool token account to burn the pool tokens from.
+	//
+	// [4] = [WRITE] reserveStake
+	// ··········· The reserve stake account of the pool.
+	//
+	// [5] = [WRITE] lamportsTo
+	// ··········· The account receiving the lamports.
+	//
+	// [6] = [WRITE] managerFeeAccount
+	// ··········· The pool token account receiving the fees.
+	//
+	// [7] = [WRITE] poolMint
+	// ··········· The pool token mint.
+	//
+	// [8] = [] clockSysvar
+	// ··········· The clock sysvar.
+	//
+	// [9] = [] stakeHistorySysvar
+	// ··········· The stake history sysvar.
+	//
+	// [10] = [] stakeProgram
+	// ··········· The stake program.
+	//
+	// [11] = [] tokenProgram
+	// ··········· The token program of the pool mint.
+	//
+	// [12] = [SIGNER] solWithdrawAuthority
+	// ··········· (optional) The SOL withdraw authority, if the pool has one.
+	Accounts ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+func (obj *WithdrawSol) SetAccounts(accounts []*ag_solanago.AccountMeta) error {
+	obj.Accounts = ag_solanago.AccountMetaSlice(accounts)
+	return nil
+}
+
+func (slice WithdrawSol) GetAccounts() (accounts []*ag_solanago.AccountMeta) {
+	accounts = append(accounts, slice.Accounts...)
+	return
+}
+
+// NewWithdrawSolInstructionBuilder creates a new `WithdrawSol` instruction builder.
+func NewWithdrawSolInstructionBuilder() *WithdrawSol {
+	nd := &WithdrawSol{
+		Accounts: make(ag_solanago.AccountMetaSlice, 12),
+	}
+	nd.Accounts[8] = ag_solanago.Meta(ag_solanago.SysVarClockPubkey)
+	nd.Accounts[9] = ag_solanago.Meta(ag_solanago.SysVarStakeHistoryPubkey)
+	nd.Accounts[10] = ag_solanago.Meta(ag_solanago.StakeProgramID)
+	nd.Accounts[11] = ag_solanago.Meta(ag_solanago.TokenProgramID)
+	return nd
+}
+
+// SetPoolTokens sets the "poolTokens" parameter.
+// The amount of pool tokens to burn.
+func (inst *WithdrawSol) SetPoolTokens(poolTokens uint64) *WithdrawSol {
+	inst.PoolTokens = &poolTokens
+	return inst
+}
+
+// SetStakePoolAccount sets the "stakePool" account.
+func (inst *WithdrawSol) SetStakePoolAccount(stakePool ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[0] = ag_solanago.Meta(stakePool).WRITE()
+	return inst
+}
+
+// GetStakePoolAccount gets the "stakePool" account.
+func (inst *WithdrawSol) GetStakePoolAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[0]
+}
+
+// SetWithdrawAuthorityAccount sets the "withdrawAuthority" account.
+func (inst *WithdrawSol) SetWithdrawAuthorityAccount(withdrawAuthority ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[1] = ag_solanago.Meta(withdrawAuthority)
+	return inst
+}
+
+// GetWithdrawAuthorityAccount gets the "withdrawAuthority" account.
+func (inst *WithdrawSol) GetWithdrawAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[1]
+}
+
+// SetUserTransferAuthorityAccount sets the "userTransferAuthority" account.
+func (inst *WithdrawSol) SetUserTransferAuthorityAccount(userTransferAuthority ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[2] = ag_solanago.Meta(userTransferAuthority).SIGNER()
+	return inst
+}
+
+// GetUserTransferAuthorityAccount gets the "userTransferAuthority" account.
+func (inst *WithdrawSol) GetUserTransferAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[2]
+}
+
+// SetPoolTokensFromAccount sets the "poolTokensFrom" account.
+func (inst *WithdrawSol) SetPoolTokensFromAccount(poolTokensFrom ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[3] = ag_solanago.Meta(poolTokensFrom).WRITE()
+	return inst
+}
+
+// GetPoolTokensFromAccount gets the "poolTokensFrom" account.
+func (inst *WithdrawSol) GetPoolTokensFromAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[3]
+}
+
+// SetReserveStakeAccount sets the "reserveStake" account.
+func (inst *WithdrawSol) SetReserveStakeAccount(reserveStake ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[4] = ag_solanago.Meta(reserveStake).WRITE()
+	return inst
+}
+
+// GetReserveStakeAccount gets the "reserveStake" account.
+func (inst *WithdrawSol) GetReserveStakeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[4]
+}
+
+// SetLamportsToAccount sets the "lamportsTo" account.
+func (inst *WithdrawSol) SetLamportsToAccount(lamportsTo ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[5] = ag_solanago.Meta(lamportsTo).WRITE()
+	return inst
+}
+
+// GetLamportsToAccount gets the "lamportsTo" account.
+func (inst *WithdrawSol) GetLamportsToAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[5]
+}
+
+// SetManagerFeeAccount sets the "managerFeeAccount" account.
+func (inst *WithdrawSol) SetManagerFeeAccount(managerFeeAccount ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[6] = ag_solanago.Meta(managerFeeAccount).WRITE()
+	return inst
+}
+
+// GetManagerFeeAccount gets the "managerFeeAccount" account.
+func (inst *WithdrawSol) GetManagerFeeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[6]
+}
+
+// SetPoolMintAccount sets the "poolMint" account.
+func (inst *WithdrawSol) SetPoolMintAccount(poolMint ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[7] = ag_solanago.Meta(poolMint).WRITE()
+	return inst
+}
+
+// GetPoolMintAccount gets the "poolMint" account.
+func (inst *WithdrawSol) GetPoolMintAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[7]
+}
+
+// SetClockSysvarAccount sets the "clockSysvar" account.
+func (inst *WithdrawSol) SetClockSysvarAccount(clockSysvar ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[8] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+// GetClockSysvarAccount gets the "clockSysvar" account.
+func (inst *WithdrawSol) GetClockSysvarAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[8]
+}
+
+// SetStakeHistorySysvarAccount sets the "stakeHistorySysvar" account.
+func (inst *WithdrawSol) SetStakeHistorySysvarAccount(stakeHistorySysvar ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[9] = ag_solanago.Meta(stakeHistorySysvar)
+	return inst
+}
+
+// GetStakeHistorySysvarAccount gets the "stakeHistorySysvar" account.
+func (inst *WithdrawSol) GetStakeHistorySysvarAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[9]
+}
+
+// SetStakeProgramAccount sets the "stakeProgram" account.
+func (inst *WithdrawSol) SetStakeProgramAccount(stakeProgram ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[10] = ag_solanago.Meta(stakeProgram)
+	return inst
+}
+
+// GetStakeProgramAccount gets the "stakeProgram" account.
+func (inst *WithdrawSol) GetStakeProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[10]
+}
+
+// SetTokenProgramAccount sets the "tokenProgram" account.
+// Defaults to the SPL Token program.
+func (inst *WithdrawSol) SetTokenProgramAccount(tokenProgram ag_solanago.PublicKey) *WithdrawSol {
+	inst.Accounts[11] = ag_solanago.Meta(tokenProgram)
+	return inst
+}
+
+// GetTokenProgramAccount gets the "tokenProgram" account.
+func (inst *WithdrawSol) GetTokenProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[11]
+}
+
+// SetSolWithdrawAuthorityAccount sets the optional "solWithdrawAuthority" account,
+// required when the pool has a SOL withdraw authority.
+func (inst *WithdrawSol) SetSolWithdrawAuthorityAccount(solWithdrawAuthority ag_solanago.PublicKey) *WithdrawSol {
+	meta := ag_solanago.Meta(solWithdrawAuthority).SIGNER()
+	if len(inst.Accounts) > 12 {
+		inst.Accounts[12] = meta
+	} else {
+		inst.Accounts = append(inst.Accounts, meta)
+	}
+	return inst
+}
+
+// GetSolWithdrawAuthorityAccount gets the "solWithdrawAuthority" account (nil if not set).
+func (inst *WithdrawSol) GetSolWithdrawAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts.Get(12)
+}
+
+func (inst WithdrawSol) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint8(Instruction_WithdrawSol),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst WithdrawSol) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *WithdrawSol) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.PoolTokens == nil {
+			return errors.New("PoolTokens parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if len(inst.Accounts) < 12 || len(inst.Accounts) > 13 {
+			return fmt.Errorf("expected 12 or 13 accounts, got %v", len(inst.Accounts))
+		}
+		if inst.Accounts[0] == nil {
+			return errors.New("accounts.StakePool is not set")
+		}
+		if inst.Accounts[1] == nil {
+			return errors.New("accounts.WithdrawAuthority is not set")
+		}
+		if inst.Accounts[2] == nil {
+			return errors.New("accounts.UserTransferAuthority is not set")
+		}
+		if inst.Accounts[3] == nil {
+			return errors.New("accounts.PoolTokensFrom is not set")
+		}
+		if inst.Accounts[4] == nil {
+			return errors.New("accounts.ReserveStake is not set")
+		}
+		if inst.Accounts[5] == nil {
+			return errors.New("accounts.LamportsTo is not set")
+		}
+		if inst.Accounts[6] == nil {
+			return errors.New("accounts.ManagerFeeAccount is not set")
+		}
+		if inst.Accounts[7] == nil {
+			return errors.New("accounts.PoolMint is not set")
+		}
+		if inst.Accounts[8] == nil {
+			return errors.New("accounts.ClockSysvar is not set")
+		}
+		if inst.Accounts[9] == nil {
+			return errors.New("accounts.StakeHistorySysvar is not set")
+		}
+		if inst.Accounts[10] == nil {
+			return errors.New("accounts.StakeProgram is not set")
+		}
+		if inst.Accounts[11] == nil {
+			return errors.New("accounts.TokenProgram is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *WithdrawSol) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("WithdrawSol")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("PoolTokens", *inst.PoolTokens))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("            stakePool", inst.Accounts.Get(0)))
+						accountsBranch.Child(ag_format.Meta("    withdrawAuthority", inst.Accounts.Get(1)))
+						accountsBranch.Child(ag_format.Meta("userTransferAuthority", inst.Accounts.Get(2)))
+						accountsBranch.Child(ag_format.Meta("       poolTokensFrom", inst.Accounts.Get(3)))
+						accountsBranch.Child(ag_format.Meta("         reserveStake", inst.Accounts.Get(4)))
+						accountsBranch.Child(ag_format.Meta("           lamportsTo", inst.Accounts.Get(5)))
+						accountsBranch.Child(ag_format.Meta("    managerFeeAccount", inst.Accounts.Get(6)))
+						accountsBranch.Child(ag_format.Meta("             poolMint", inst.Accounts.Get(7)))
+						accountsBranch.Child(ag_format.Meta("          clockSysvar", inst.Accounts.Get(8)))
+						accountsBranch.Child(ag_format.Meta("   stakeHistorySysvar", inst.Accounts.Get(9)))
+						accountsBranch.Child(ag_format.Meta("         stakeProgram", inst.Accounts.Get(10)))
+						accountsBranch.Child(ag_format.Meta("         tokenProgram", inst.Accounts.Get(11)))
+						accountsBranch.Child(ag_format.Meta(" solWithdrawAuthority", inst.Accounts.Get(12)))
+					})
+				})
+		})
+}
+
+func (obj WithdrawSol) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `PoolTokens` param:
+	err = encoder.Encode(obj.PoolTokens)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *WithdrawSol) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `PoolTokens`:
+	err = decoder.Decode(&obj.PoolTokens)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewWithdrawSolInstruction declares a new WithdrawSol instruction with the provided parameters and accounts.
+// For a pool with a SOL withdraw authority, also call SetSolWithdrawAuthorityAccount.
+func NewWithdrawSolInstruction(
+	// Parameters:
+	poolTokens uint64,
+	// Accounts:
+	stakePool ag_solanago.PublicKey,
+	withdrawAuthority ag_solanago.PublicKey,
+	userTransferAuthority ag_solanago.PublicKey,
+	poolTokensFrom ag_solanago.PublicKey,
+	reserveStake ag_solanago.PublicKey,
+	lamportsTo ag_solanago.PublicKey,
+	managerFeeAccount ag_solanago.PublicKey,
+	poolMint ag_solanago.PublicKey) *WithdrawSol {
+	return NewWithdrawSolInstructionBuilder().
+		SetPoolTokens(poolTokens).
+		SetStakePoolAccount(stakePool).
+		SetWithdrawAuthorityAccount(withdrawAuthority).
+		SetUserTransferAuthorityAccount(userTransferAuthority).
+		SetPoolTokensFromAccount(poolTokensFrom).
+		SetReserveStakeAccount(reserveStake).
+		SetLamportsToAccount(lamportsTo).
+		SetManagerFeeAccount(managerFeeAccount).
+		SetPoolMintAccount(poolMint)
+}
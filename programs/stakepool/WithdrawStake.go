@@ -0,0 +1,396 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Burns pool tokens in exchange for a stake account, split from
+// a validator stake account of the pool (or from the reserve).
+type WithdrawStake struct {
+	// The amount of pool tokens to burn.
+	PoolTokens *uint64
+
+	// [0] = [WRITE] stakePool
+	// ··········· The stake pool.
+	//
+	// [1] = [WRITE] validatorList
+	// ··········· The validator list of the pool.
+	//
+	// [2] = [] withdrawAuthority
+	// ··········· The withdraw authority PDA of the pool.
+	//
+	// [3] = [WRITE] stakeToSplit
+	// ··········· The validator (or reserve) stake account to split.
+	//
+	// [4] = [WRITE] stakeToReceive
+	// ··········· The uninitialized stake account receiving the split stake.
+	//
+	// [5] = [] userStakeAuthority
+	// ··········· The staker and withdrawer authority of the new stake account.
+	//
+	// [6] = [SIGNER] userTransferAuthority
+	// ··········· The owner (or delegate) of the pool token account.
+	//
+	// [7] = [WRITE] poolTokensFrom
+	// ··········· The pool token account to burn the pool tokens from.
+	//
+	// [8] = [WRITE] managerFeeAccount
+	// ··········· The pool token account receiving the fees.
+	//
+	// [9] = [WRITE] poolMint
+	// ··········· The pool token mint.
+	//
+	// [10] = [] clockSysvar
+	// ··········· The clock sysvar.
+	//
+	// [11] = [] tokenProgram
+	// ··········· The token program of the pool mint.
+	//
+	// [12] = [] stakeProgram
+	// ··········· The stake program.
+	Accounts ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+func (obj *WithdrawStake) SetAccounts(accounts []*ag_solanago.AccountMeta) error {
+	obj.Accounts = ag_solanago.AccountMetaSlice(accounts)
+	return nil
+}
+
+func (slice WithdrawStake) GetAccounts() (accounts []*ag_solanago.AccountMeta) {
+	accounts = append(accounts, slice.Accounts...)
+	return
+}
+
+// NewWithdrawStakeInstructionBuilder creates a new `WithdrawStake` instruction builder.
+func NewWithdrawStakeInstructionBuilder() *WithdrawStake {
+	nd := &WithdrawStake{
+		Accounts: make(ag_solanago.AccountMetaSlice, 13),
+	}
+	nd.Accounts[10] = ag_solanago.Meta(ag_solanago.SysVarClockPubkey)
+	nd.Accounts[11] = ag_solanago.Meta(ag_solanago.TokenProgramID)
+	nd.Accounts[12] = ag_solanago.Meta(ag_solanago.StakeProgramID)
+	return nd
+}
+
+// SetPoolTokens sets the "poolTokens" parameter.
+// The amount of pool tokens to burn.
+func (inst *WithdrawStake) SetPoolTokens(poolTokens uint64) *WithdrawStake {
+	inst.PoolTokens = &poolTokens
+	return inst
+}
+
+// SetStakePoolAccount sets the "stakePool" account.
+func (inst *WithdrawStake) SetStakePoolAccount(stakePool ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[0] = ag_solanago.Meta(stakePool).WRITE()
+	return inst
+}
+
+// GetStakePoolAccount gets the "stakePool" account.
+func (inst *WithdrawStake) GetStakePoolAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[0]
+}
+
+// SetValidatorListAccount sets the "validatorList" account.
+func (inst *WithdrawStake) SetValidatorListAccount(validatorList ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[1] = ag_solanago.Meta(validatorList).WRITE()
+	return inst
+}
+
+// GetValidatorListAccount gets the "validatorList" account.
+func (inst *WithdrawStake) GetValidatorListAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[1]
+}
+
+// SetWithdrawAuthorityAccount sets the "withdrawAuthority" account.
+func (inst *WithdrawStake) SetWithdrawAuthorityAccount(withdrawAuthority ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[2] = ag_solanago.Meta(withdrawAuthority)
+	return inst
+}
+
+// GetWithdrawAuthorityAccount gets the "withdrawAuthority" account.
+func (inst *WithdrawStake) GetWithdrawAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[2]
+}
+
+// SetStakeToSplitAccount sets the "stakeToSplit" account.
+func (inst *WithdrawStake) SetStakeToSplitAccount(stakeToSplit ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[3] = ag_solanago.Meta(stakeToSplit).WRITE()
+	return inst
+}
+
+// GetStakeToSplitAccount gets the "stakeToSplit" account.
+func (inst *WithdrawStake) GetStakeToSplitAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[3]
+}
+
+// SetStakeToReceiveAccount sets the "stakeToReceive" account.
+func (inst *WithdrawStake) SetStakeToReceiveAccount(stakeToReceive ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[4] = ag_solanago.Meta(stakeToReceive).WRITE()
+	return inst
+}
+
+// GetStakeToReceiveAccount gets the "stakeToReceive" account.
+func (inst *WithdrawStake) GetStakeToReceiveAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[4]
+}
+
+// SetUserStakeAuthorityAccount sets the "userStakeAuthority" account.
+func (inst *WithdrawStake) SetUserStakeAuthorityAccount(userStakeAuthority ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[5] = ag_solanago.Meta(userStakeAuthority)
+	return inst
+}
+
+// GetUserStakeAuthorityAccount gets the "userStakeAuthority" account.
+func (inst *WithdrawStake) GetUserStakeAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[5]
+}
+
+// SetUserTransferAuthorityAccount sets the "userTransferAuthority" account.
+func (inst *WithdrawStake) SetUserTransferAuthorityAccount(userTransferAuthority ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[6] = ag_solanago.Meta(userTransferAuthority).SIGNER()
+	return inst
+}
+
+// GetUserTransferAuthorityAccount gets the "userTransferAuthority" account.
+func (inst *WithdrawStake) GetUserTransferAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[6]
+}
+
+// SetPoolTokensFromAccount sets the "poolTokensFrom" account.
+func (inst *WithdrawStake) SetPoolTokensFromAccount(poolTokensFrom ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[7] = ag_solanago.Meta(poolTokensFrom).WRITE()
+	return inst
+}
+
+// GetPoolTokensFromAccount gets the "poolTokensFrom" account.
+func (inst *WithdrawStake) GetPoolTokensFromAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[7]
+}
+
+// SetManagerFeeAccount sets the "managerFeeAccount" account.
+func (inst *WithdrawStake) SetManagerFeeAccount(managerFeeAccount ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[8] = ag_solanago.Meta(managerFeeAccount).WRITE()
+	return inst
+}
+
+// GetManagerFeeAccount gets the "managerFeeAccount" account.
+func (inst *WithdrawStake) GetManagerFeeAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[8]
+}
+
+// SetPoolMintAccount sets the "poolMint" account.
+func (inst *WithdrawStake) SetPoolMintAccount(poolMint ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[9] = ag_solanago.Meta(poolMint).WRITE()
+	return inst
+}
+
+// GetPoolMintAccount gets the "poolMint" account.
+func (inst *WithdrawStake) GetPoolMintAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[9]
+}
+
+// SetClockSysvarAccount sets the "clockSysvar" account.
+func (inst *WithdrawStake) SetClockSysvarAccount(clockSysvar ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[10] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+// GetClockSysvarAccount gets the "clockSysvar" account.
+func (inst *WithdrawStake) GetClockSysvarAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[10]
+}
+
+// SetTokenProgramAccount sets the "tokenProgram" account.
+// Defaults to the SPL Token program.
+func (inst *WithdrawStake) SetTokenProgramAccount(tokenProgram ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[11] = ag_solanago.Meta(tokenProgram)
+	return inst
+}
+
+// GetTokenProgramAccount gets the "tokenProgram" account.
+func (inst *WithdrawStake) GetTokenProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[11]
+}
+
+// SetStakeProgramAccount sets the "stakeProgram" account.
+func (inst *WithdrawStake) SetStakeProgramAccount(stakeProgram ag_solanago.PublicKey) *WithdrawStake {
+	inst.Accounts[12] = ag_solanago.Meta(stakeProgram)
+	return inst
+}
+
+// GetStakeProgramAccount gets the "stakeProgram" account.
+func (inst *WithdrawStake) GetStakeProgramAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[12]
+}
+
+func (inst WithdrawStake) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint8(Instruction_WithdrawStake),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst WithdrawStake) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *WithdrawStake) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.PoolTokens == nil {
+			return errors.New("PoolTokens parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if len(inst.Accounts) != 13 {
+			return fmt.Errorf("expected 13 accounts, got %v", len(inst.Accounts))
+		}
+		if inst.Accounts[0] == nil {
+			return errors.New("accounts.StakePool is not set")
+		}
+		if inst.Accounts[1] == nil {
+			return errors.New("accounts.ValidatorList is not set")
+		}
+		if inst.Accounts[2] == nil {
+			return errors.New("accounts.WithdrawAuthority is not set")
+		}
+		if inst.Accounts[3] == nil {
+			return errors.New("accounts.StakeToSplit is not set")
+		}
+		if inst.Accounts[4] == nil {
+			return errors.New("accounts.StakeToReceive is not set")
+		}
+		if inst.Accounts[5] == nil {
+			return errors.New("accounts.UserStakeAuthority is not set")
+		}
+		if inst.Accounts[6] == nil {
+			return errors.New("accounts.UserTransferAuthority is not set")
+		}
+		if inst.Accounts[7] == nil {
+			return errors.New("accounts.PoolTokensFrom is not set")
+		}
+		if inst.Accounts[8] == nil {
+			return errors.New("accounts.ManagerFeeAccount is not set")
+		}
+		if inst.Accounts[9] == nil {
+			return errors.New("accounts.PoolMint is not set")
+		}
+		if inst.Accounts[10] == nil {
+			return errors.New("accounts.ClockSysvar is not set")
+		}
+		if inst.Accounts[11] == nil {
+			return errors.New("accounts.TokenProgram is not set")
+		}
+		if inst.Accounts[12] == nil {
+			return errors.New("accounts.StakeProgram is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *WithdrawStake) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("WithdrawStake")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("PoolTokens", *inst.PoolTokens))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("            stakePool", inst.Accounts.Get(0)))
+						accountsBranch.Child(ag_format.Meta("        validatorList", inst.Accounts.Get(1)))
+						accountsBranch.Child(ag_format.Meta("    withdrawAuthority", inst.Accounts.Get(2)))
+						accountsBranch.Child(ag_format.Meta("         stakeToSplit", inst.Accounts.Get(3)))
+						accountsBranch.Child(ag_format.Meta("       stakeToReceive", inst.Accounts.Get(4)))
+						accountsBranch.Child(ag_format.Meta("   userStakeAuthority", inst.Accounts.Get(5)))
+						accountsBranch.Child(ag_format.Meta("userTransferAuthority", inst.Accounts.Get(6)))
+						accountsBranch.Child(ag_format.Meta("       poolTokensFrom", inst.Accounts.Get(7)))
+						accountsBranch.Child(ag_format.Meta("    managerFeeAccount", inst.Accounts.Get(8)))
+						accountsBranch.Child(ag_format.Meta("             poolMint", inst.Accounts.Get(9)))
+						accountsBranch.Child(ag_format.Meta("          clockSysvar", inst.Accounts.Get(10)))
+						accountsBranch.Child(ag_format.Meta("         tokenProgram", inst.Accounts.Get(11)))
+						accountsBranch.Child(ag_format.Meta("         stakeProgram", inst.Accounts.Get(12)))
+					})
+				})
+		})
+}
+
+func (obj WithdrawStake) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `PoolTokens` param:
+	err = encoder.Encode(obj.PoolTokens)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *WithdrawStake) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `PoolTokens`:
+	err = decoder.Decode(&obj.PoolTokens)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewWithdrawStakeInstruction declares a new WithdrawStake instruction with the provided parameters and accounts.
+func NewWithdrawStakeInstruction(
+	// Parameters:
+	poolTokens uint64,
+	// Accounts:
+	stakePool ag_solanago.PublicKey,
+	validatorList ag_solanago.PublicKey,
+	withdrawAuthority ag_solanago.PublicKey,
+	stakeToSplit ag_solanago.PublicKey,
+	stakeToReceive ag_solanago.PublicKey,
+	userStakeAuthority ag_solanago.PublicKey,
+	userTransferAuthority ag_solanago.PublicKey,
+	poolTokensFrom ag_solanago.PublicKey,
+	managerFeeAccount ag_solanago.PublicKey,
+	poolMint ag_solanago.PublicKey) *WithdrawStake {
+	return NewWithdrawStakeInstructionBuilder().
+		SetPoolTokens(poolTokens).
+		SetStakePoolAccount(stakePool).
+		SetValidatorListAccount(validatorList).
+		SetWithdrawAuthorityAccount(withdrawAuthority).
+		SetStakeToSplitAccount(stakeToSplit).
+		SetStakeToReceiveAccount(stakeToReceive).
+		SetUserStakeAuthorityAccount(userStakeAuthority).
+		SetUserTransferAuthorityAccount(userTransferAuthority).
+		SetPoolTokensFromAccount(poolTokensFrom).
+		SetManagerFeeAccount(managerFeeAccount).
+		SetPoolMintAccount(poolMint)
+}
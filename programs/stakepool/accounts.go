@@ -0,0 +1,326 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// AccountType is the first byte of the accounts of the stake pool program.
+type AccountType uint8
+
+const (
+	AccountTypeUninitialized AccountType = iota
+	AccountTypeStakePool
+	AccountTypeValidatorList
+)
+
+func (t AccountType) String() string {
+	switch t {
+	case AccountTypeUninitialized:
+		return "Uninitialized"
+	case AccountTypeStakePool:
+		return "StakePool"
+	case AccountTypeValidatorList:
+		return "ValidatorList"
+	default:
+		return fmt.Sprintf("AccountType(%d)", uint8(t))
+	}
+}
+
+// Fee is a fraction: Numerator / Denominator.
+type Fee struct {
+	Denominator uint64
+	Numerator   uint64
+}
+
+// Apply returns the fee on the amount, rounded up
+// (0 if the denominator is 0).
+func (f Fee) Apply(amount uint64) uint64 {
+	if f.Denominator == 0 || f.Numerator == 0 || amount == 0 {
+		return 0
+	}
+	fee, ok := mulDivCeil(amount, f.Numerator, f.Denominator)
+	if !ok {
+		return amount
+	}
+	return fee
+}
+
+// FutureEpochFee is a fee change that takes effect in a future epoch.
+type FutureEpochFee struct {
+	// Number of epochs before the fee takes effect (1 or 2),
+	// or 0 if no change is scheduled.
+	Epochs uint8
+	Fee    Fee
+}
+
+// IsSet tells whether a fee change is scheduled.
+func (f FutureEpochFee) IsSet() bool {
+	return f.Epochs != 0
+}
+
+func (f FutureEpochFee) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	err = encoder.WriteUint8(f.Epochs)
+	if err != nil {
+		return err
+	}
+	if f.Epochs == 0 {
+		return nil
+	}
+	return encoder.Encode(f.Fee)
+}
+
+func (f *FutureEpochFee) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	f.Epochs, err = decoder.ReadUint8()
+	if err != nil {
+		return err
+	}
+	switch f.Epochs {
+	case 0:
+		f.Fee = Fee{}
+		return nil
+	case 1, 2:
+		return decoder.Decode(&f.Fee)
+	default:
+		return fmt.Errorf("invalid future epoch fee variant: %d", f.Epochs)
+	}
+}
+
+// Lockup of the stake accounts of the pool.
+type Lockup struct {
+	UnixTimestamp int64
+	Epoch         uint64
+	Custodian     ag_solanago.PublicKey
+}
+
+// StakePool is the main account of a stake pool.
+type StakePool struct {
+	AccountType AccountType
+	// Can update the fees and the staker, and receives the fees.
+	Manager ag_solanago.PublicKey
+	// Manages the validators and the stake of the pool.
+	Staker ag_solanago.PublicKey
+	// Must sign the stake deposits; by default, the deposit authority PDA
+	// (see FindDepositAuthorityAddress), meaning that anyone can deposit.
+	StakeDepositAuthority ag_solanago.PublicKey
+	// Bump seed of the withdraw authority PDA.
+	StakeWithdrawBumpSeed uint8
+	ValidatorList         ag_solanago.PublicKey
+	ReserveStake          ag_solanago.PublicKey
+	PoolMint              ag_solanago.PublicKey
+	ManagerFeeAccount     ag_solanago.PublicKey
+	TokenProgramID        ag_solanago.PublicKey
+	// Lamports under management, as of LastUpdateEpoch.
+	TotalLamports   uint64
+	PoolTokenSupply uint64
+	LastUpdateEpoch uint64
+	Lockup          Lockup
+	// Fee taken on the rewards of each epoch.
+	EpochFee                              Fee
+	NextEpochFee                          FutureEpochFee
+	PreferredDepositValidatorVoteAddress  *ag_solanago.PublicKey `bin:"optional"`
+	PreferredWithdrawValidatorVoteAddress *ag_solanago.PublicKey `bin:"optional"`
+	StakeDepositFee                       Fee
+	StakeWithdrawalFee                    Fee
+	NextStakeWithdrawalFee                FutureEpochFee
+	// Percentage (0-100) of the stake deposit fee that goes to the referrer.
+	StakeReferralFee uint8
+	// If set, must sign the SOL deposits.
+	SolDepositAuthority *ag_solanago.PublicKey `bin:"optional"`
+	SolDepositFee       Fee
+	// Percentage (0-100) of the SOL deposit fee that goes to the referrer.
+	SolReferralFee uint8
+	// If set, must sign the SOL withdrawals.
+	SolWithdrawAuthority     *ag_solanago.PublicKey `bin:"optional"`
+	SolWithdrawalFee         Fee
+	NextSolWithdrawalFee     FutureEpochFee
+	LastEpochPoolTokenSupply uint64
+	LastEpochTotalLamports   uint64
+}
+
+// DecodeStakePool decodes the data of a stake pool account.
+func DecodeStakePool(data []byte) (*StakePool, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("unable to decode stake pool: no data")
+	}
+	if accountType := AccountType(data[0]); accountType != AccountTypeStakePool {
+		return nil, fmt.Errorf("not a stake pool account: account type is %s", accountType)
+	}
+	pool := new(StakePool)
+	if err := ag_binary.NewBorshDecoder(data).Decode(pool); err != nil {
+		return nil, fmt.Errorf("unable to decode stake pool: %w", err)
+	}
+	return pool, nil
+}
+
+// ExchangeRate returns the number of lamports per pool token
+// (1 if the pool has no tokens yet).
+func (pool *StakePool) ExchangeRate() float64 {
+	if pool.TotalLamports == 0 || pool.PoolTokenSupply == 0 {
+		return 1
+	}
+	return float64(pool.TotalLamports) / float64(pool.PoolTokenSupply)
+}
+
+// CalcPoolTokensForDeposit returns the pool tokens that are worth
+// the lamports, before fees.
+func (pool *StakePool) CalcPoolTokensForDeposit(lamports uint64) uint64 {
+	if pool.TotalLamports == 0 || pool.PoolTokenSupply == 0 {
+		return lamports
+	}
+	tokens, _ := mulDiv(lamports, pool.PoolTokenSupply, pool.TotalLamports)
+	return tokens
+}
+
+// CalcLamportsWithdrawAmount returns the lamports that the pool tokens
+// are worth, before fees.
+func (pool *StakePool) CalcLamportsWithdrawAmount(poolTokens uint64) uint64 {
+	if pool.PoolTokenSupply == 0 {
+		return 0
+	}
+	lamports, _ := mulDiv(poolTokens, pool.TotalLamports, pool.PoolTokenSupply)
+	return lamports
+}
+
+// PoolTokensForSolDeposit returns the pool tokens that the depositor receives
+// for a DepositSol of the lamports, after the SOL deposit fee.
+func (pool *StakePool) PoolTokensForSolDeposit(lamports uint64) uint64 {
+	tokens := pool.CalcPoolTokensForDeposit(lamports)
+	return tokens - pool.SolDepositFee.Apply(tokens)
+}
+
+// LamportsForSolWithdrawal returns the lamports that the withdrawer receives
+// for a WithdrawSol of the pool tokens, after the SOL withdrawal fee.
+func (pool *StakePool) LamportsForSolWithdrawal(poolTokens uint64) uint64 {
+	return pool.CalcLamportsWithdrawAmount(poolTokens - pool.SolWithdrawalFee.Apply(poolTokens))
+}
+
+// StakeStatus is the status of a validator stake account in the pool.
+type StakeStatus uint8
+
+const (
+	StakeStatusActive StakeStatus = iota
+	StakeStatusDeactivatingTransient
+	StakeStatusReadyForRemoval
+	StakeStatusDeactivatingValidator
+	StakeStatusDeactivatingAll
+)
+
+func (s StakeStatus) String() string {
+	switch s {
+	case StakeStatusActive:
+		return "Active"
+	case StakeStatusDeactivatingTransient:
+		return "DeactivatingTransient"
+	case StakeStatusReadyForRemoval:
+		return "ReadyForRemoval"
+	case StakeStatusDeactivatingValidator:
+		return "DeactivatingValidator"
+	case StakeStatusDeactivatingAll:
+		return "DeactivatingAll"
+	default:
+		return fmt.Sprintf("StakeStatus(%d)", uint8(s))
+	}
+}
+
+// ValidatorStakeInfoSize is the size of an entry of the validator list.
+const ValidatorStakeInfoSize = 73
+
+// ValidatorStakeInfo is an entry of the validator list.
+type ValidatorStakeInfo struct {
+	// Lamports in the validator stake account, including the rent exemption.
+	ActiveStakeLamports uint64
+	// Lamports in the transient stake account, including the rent exemption.
+	TransientStakeLamports uint64
+	LastUpdateEpoch        uint64
+	// Seed of the transient stake account address.
+	TransientSeedSuffix uint64
+	Unused              uint32
+	// Seed of the validator stake account address (0 means no seed).
+	ValidatorSeedSuffix uint32
+	Status              StakeStatus
+	VoteAccountAddress  ag_solanago.PublicKey
+}
+
+// StakeLamports returns the active and transient lamports of the validator.
+func (info *ValidatorStakeInfo) StakeLamports() uint64 {
+	return info.ActiveStakeLamports + info.TransientStakeLamports
+}
+
+// ValidatorList is the list of the validators of a stake pool.
+type ValidatorList struct {
+	AccountType AccountType
+	// Capacity of the list.
+	MaxValidators uint32
+	Validators    []ValidatorStakeInfo
+}
+
+// DecodeValidatorList decodes the data of a validator list account.
+func DecodeValidatorList(data []byte) (*ValidatorList, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("unable to decode validator list: no data")
+	}
+	if accountType := AccountType(data[0]); accountType != AccountTypeValidatorList {
+		return nil, fmt.Errorf("not a validator list account: account type is %s", accountType)
+	}
+	list := new(ValidatorList)
+	if err := ag_binary.NewBorshDecoder(data).Decode(list); err != nil {
+		return nil, fmt.Errorf("unable to decode validator list: %w", err)
+	}
+	return list, nil
+}
+
+// Find returns the entry of the validator with the vote account, or nil.
+func (list *ValidatorList) Find(voteAccount ag_solanago.PublicKey) *ValidatorStakeInfo {
+	for i := range list.Validators {
+		if list.Validators[i].VoteAccountAddress == voteAccount {
+			return &list.Validators[i]
+		}
+	}
+	return nil
+}
+
+// mulDiv returns a*b/c computed on 128 bits, and false
+// if the result overflows (or c is 0).
+func mulDiv(a, b, c uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		return math.MaxUint64, false
+	}
+	quo, _ := bits.Div64(hi, lo, c)
+	return quo, true
+}
+
+// mulDivCeil is like mulDiv, but rounds up.
+func mulDivCeil(a, b, c uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		return math.MaxUint64, false
+	}
+	quo, rem := bits.Div64(hi, lo, c)
+	if rem != 0 {
+		if quo == math.MaxUint64 {
+			return quo, false
+		}
+		quo++
+	}
+	return quo, true
+}
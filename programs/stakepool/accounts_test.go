@@ -0,0 +1,161 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	jitoSOLPool = ag_solanago.MustPublicKeyFromBase58("Jito4APyf642JPZPx3hGc6WWJ8zPKtRbRs4P815Awbb")
+	jitoSOLMint = ag_solanago.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn")
+)
+
+// The fixtures follow the layout and the configuration of the jitoSOL pool
+// (mint, deposit authority, fees), with placeholder keys and balances.
+func readFixture(t *testing.T, name string) []byte {
+	encoded, err := ioutil.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodeStakePool(t *testing.T) {
+	data := readFixture(t, "jitosol_stake_pool.b64")
+	require.Len(t, data, 611)
+
+	pool, err := DecodeStakePool(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, AccountTypeStakePool, pool.AccountType)
+	assert.Equal(t, ag_solanago.MustPublicKeyFromBase58("8Tt4RqwgGuweFmQmbabQgFmnGLFSzrcbN9NA9Wm3ggCe"), pool.Manager)
+	assert.Equal(t, ag_solanago.MustPublicKeyFromBase58("FHhuW58hQLbJrfbNCT3f9U2j6u8nPUzdfDAn9S3KCEAN"), pool.Staker)
+	assert.Equal(t, uint8(255), pool.StakeWithdrawBumpSeed)
+	assert.Equal(t, ag_solanago.MustPublicKeyFromBase58("3mrnTFCwoNvUbLa5nEgbd4dVkacSCEexMzqhSDJtAGfA"), pool.ValidatorList)
+	assert.Equal(t, ag_solanago.MustPublicKeyFromBase58("2ayYUnznAENDNonS3jiTtJBBRkDHEzvLtVv7iktjvtgZ"), pool.ReserveStake)
+	assert.Equal(t, jitoSOLMint, pool.PoolMint)
+	assert.Equal(t, ag_solanago.MustPublicKeyFromBase58("9DRhhoLGCCZTqPEwfQYjStfGAFQGpRHZgPjLNbXiK7an"), pool.ManagerFeeAccount)
+	assert.Equal(t, ag_solanago.TokenProgramID, pool.TokenProgramID)
+	assert.Equal(t, uint64(14_562_305_117_209_011), pool.TotalLamports)
+	assert.Equal(t, uint64(12_271_459_093_347_554), pool.PoolTokenSupply)
+	assert.Equal(t, uint64(702), pool.LastUpdateEpoch)
+	assert.Equal(t, Lockup{}, pool.Lockup)
+
+	assert.Equal(t, Fee{Denominator: 100, Numerator: 4}, pool.EpochFee)
+	assert.False(t, pool.NextEpochFee.IsSet())
+	assert.Nil(t, pool.PreferredDepositValidatorVoteAddress)
+	assert.Nil(t, pool.PreferredWithdrawValidatorVoteAddress)
+	assert.Equal(t, Fee{}, pool.StakeDepositFee)
+	assert.Equal(t, Fee{Denominator: 1000, Numerator: 1}, pool.StakeWithdrawalFee)
+	assert.False(t, pool.NextStakeWithdrawalFee.IsSet())
+	assert.Nil(t, pool.SolDepositAuthority)
+	assert.Nil(t, pool.SolWithdrawAuthority)
+	assert.Equal(t, Fee{Denominator: 1000, Numerator: 1}, pool.SolWithdrawalFee)
+	assert.Equal(t, FutureEpochFee{Epochs: 2, Fee: Fee{Denominator: 1000, Numerator: 3}}, pool.NextSolWithdrawalFee)
+	assert.Equal(t, uint64(12_268_101_553_920_385), pool.LastEpochPoolTokenSupply)
+	assert.Equal(t, uint64(14_554_310_120_093_553), pool.LastEpochTotalLamports)
+
+	// The default deposit authority.
+	depositAuthority, _, err := FindDepositAuthorityAddress(jitoSOLPool)
+	require.NoError(t, err)
+	assert.Equal(t, depositAuthority, pool.StakeDepositAuthority)
+
+	t.Run("wrong account type", func(t *testing.T) {
+		_, err := DecodeStakePool(readFixture(t, "jitosol_validator_list.b64"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ValidatorList")
+	})
+	t.Run("truncated", func(t *testing.T) {
+		_, err := DecodeStakePool(data[:200])
+		require.Error(t, err)
+	})
+}
+
+func TestStakePool_ExchangeRate(t *testing.T) {
+	pool, err := DecodeStakePool(readFixture(t, "jitosol_stake_pool.b64"))
+	require.NoError(t, err)
+
+	assert.InDelta(t, 1.18668, pool.ExchangeRate(), 0.00001)
+	assert.Equal(t, uint64(842_686_579), pool.CalcPoolTokensForDeposit(1_000_000_000))
+	assert.Equal(t, uint64(1_186_680_818), pool.CalcLamportsWithdrawAmount(1_000_000_000))
+
+	// No SOL deposit fee.
+	assert.Equal(t, uint64(842_686_579), pool.PoolTokensForSolDeposit(1_000_000_000))
+	// A 0.1% SOL withdrawal fee: 1_000_000 pool tokens.
+	assert.Equal(t, uint64(1_185_494_137), pool.LamportsForSolWithdrawal(1_000_000_000))
+
+	empty := &StakePool{}
+	assert.Equal(t, float64(1), empty.ExchangeRate())
+	assert.Equal(t, uint64(5), empty.CalcPoolTokensForDeposit(5))
+	assert.Equal(t, uint64(0), empty.CalcLamportsWithdrawAmount(5))
+
+	// 128-bit intermediate results.
+	big := &StakePool{TotalLamports: math.MaxUint64 / 2, PoolTokenSupply: math.MaxUint64 / 4}
+	assert.Equal(t, uint64(math.MaxUint64/2), big.CalcLamportsWithdrawAmount(math.MaxUint64/4))
+}
+
+func TestFee_Apply(t *testing.T) {
+	assert.Equal(t, uint64(0), Fee{}.Apply(1000))
+	assert.Equal(t, uint64(0), Fee{Denominator: 100}.Apply(1000))
+	assert.Equal(t, uint64(40), Fee{Denominator: 100, Numerator: 4}.Apply(1000))
+	// Rounded up.
+	assert.Equal(t, uint64(1), Fee{Denominator: 1000, Numerator: 1}.Apply(1))
+	assert.Equal(t, uint64(2), Fee{Denominator: 3, Numerator: 1}.Apply(4))
+}
+
+func TestDecodeValidatorList(t *testing.T) {
+	data := readFixture(t, "jitosol_validator_list.b64")
+
+	list, err := DecodeValidatorList(data)
+	require.NoError(t, err)
+	assert.Equal(t, AccountTypeValidatorList, list.AccountType)
+	assert.Equal(t, uint32(3), list.MaxValidators)
+	require.Len(t, list.Validators, 2)
+
+	vote1 := ag_solanago.MustPublicKeyFromBase58("39WyJL5vjrtzzPFRz2UicWw4DZ5u5XSPun2653vV154Z")
+	vote2 := ag_solanago.MustPublicKeyFromBase58("EWoBy9APauBpYxNPLta9yJsHDbL3X9K5Gp94DY2KjZG6")
+	assert.Equal(t, ValidatorStakeInfo{
+		ActiveStakeLamports: 152_345_678_901_234,
+		LastUpdateEpoch:     702,
+		Status:              StakeStatusActive,
+		VoteAccountAddress:  vote1,
+	}, list.Validators[0])
+	assert.Equal(t, ValidatorStakeInfo{
+		ActiveStakeLamports:    98_765_432_109_876,
+		TransientStakeLamports: 2_282_880,
+		LastUpdateEpoch:        702,
+		TransientSeedSuffix:    7,
+		ValidatorSeedSuffix:    3,
+		Status:                 StakeStatusDeactivatingTransient,
+		VoteAccountAddress:     vote2,
+	}, list.Validators[1])
+	assert.Equal(t, "DeactivatingTransient", list.Validators[1].Status.String())
+	assert.Equal(t, uint64(98_765_434_392_756), list.Validators[1].StakeLamports())
+
+	assert.Equal(t, &list.Validators[1], list.Find(vote2))
+	assert.Nil(t, list.Find(jitoSOLPool))
+
+	_, err = DecodeValidatorList(readFixture(t, "jitosol_stake_pool.b64"))
+	require.Error(t, err)
+}
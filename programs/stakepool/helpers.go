@@ -0,0 +1,205 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// The helpers below fill the accounts of the instructions
+// from the decoded state of the pool (see DecodeStakePool).
+
+// NewDepositSolInstructionForPool returns a DepositSol of the lamports
+// from the `from` account, minting the pool tokens to poolTokensTo;
+// referrer is the pool token account receiving the referral fees
+// (usually poolTokensTo itself).
+// If the pool has a SOL deposit authority, it must sign the transaction too.
+func NewDepositSolInstructionForPool(
+	poolAddress ag_solanago.PublicKey,
+	pool *StakePool,
+	lamports uint64,
+	from ag_solanago.PublicKey,
+	poolTokensTo ag_solanago.PublicKey,
+	referrer ag_solanago.PublicKey,
+) (*DepositSol, error) {
+	withdrawAuthority, _, err := FindWithdrawAuthorityAddress(poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive withdraw authority: %w", err)
+	}
+	inst := NewDepositSolInstruction(
+		lamports,
+		poolAddress,
+		withdrawAuthority,
+		pool.ReserveStake,
+		from,
+		poolTokensTo,
+		pool.ManagerFeeAccount,
+		referrer,
+		pool.PoolMint,
+	).SetTokenProgramAccount(pool.TokenProgramID)
+	if pool.SolDepositAuthority != nil {
+		inst.SetSolDepositAuthorityAccount(*pool.SolDepositAuthority)
+	}
+	return inst, nil
+}
+
+// NewWithdrawSolInstructionForPool returns a WithdrawSol of the pool tokens
+// from poolTokensFrom (owned or delegated to userTransferAuthority),
+// sending the lamports to lamportsTo.
+// If the pool has a SOL withdraw authority, it must sign the transaction too.
+func NewWithdrawSolInstructionForPool(
+	poolAddress ag_solanago.PublicKey,
+	pool *StakePool,
+	poolTokens uint64,
+	userTransferAuthority ag_solanago.PublicKey,
+	poolTokensFrom ag_solanago.PublicKey,
+	lamportsTo ag_solanago.PublicKey,
+) (*WithdrawSol, error) {
+	withdrawAuthority, _, err := FindWithdrawAuthorityAddress(poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive withdraw authority: %w", err)
+	}
+	inst := NewWithdrawSolInstruction(
+		poolTokens,
+		poolAddress,
+		withdrawAuthority,
+		userTransferAuthority,
+		poolTokensFrom,
+		pool.ReserveStake,
+		lamportsTo,
+		pool.ManagerFeeAccount,
+		pool.PoolMint,
+	).SetTokenProgramAccount(pool.TokenProgramID)
+	if pool.SolWithdrawAuthority != nil {
+		inst.SetSolWithdrawAuthorityAccount(*pool.SolWithdrawAuthority)
+	}
+	return inst, nil
+}
+
+// DepositStakeInstructions returns the instructions that deposit the stake account,
+// delegated to the validator, into the pool: two stake program Authorize
+// instructions, that give the staker and withdrawer authorities of the stake account
+// (currently stakeAuthority, which must sign) to the pool, then the DepositStake.
+// If the pool has a custom stake deposit authority, it must sign the transaction too.
+func DepositStakeInstructions(
+	poolAddress ag_solanago.PublicKey,
+	pool *StakePool,
+	validator *ValidatorStakeInfo,
+	depositStake ag_solanago.PublicKey,
+	stakeAuthority ag_solanago.PublicKey,
+	poolTokensTo ag_solanago.PublicKey,
+	referrer ag_solanago.PublicKey,
+) ([]ag_solanago.Instruction, error) {
+	withdrawAuthority, _, err := FindWithdrawAuthorityAddress(poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive withdraw authority: %w", err)
+	}
+	defaultDepositAuthority, _, err := FindDepositAuthorityAddress(poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive deposit authority: %w", err)
+	}
+	validatorStake, _, err := FindValidatorStakeAddress(validator.VoteAccountAddress, poolAddress, validator.ValidatorSeedSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive validator stake address: %w", err)
+	}
+
+	deposit := NewDepositStakeInstruction(
+		poolAddress,
+		pool.ValidatorList,
+		pool.StakeDepositAuthority,
+		withdrawAuthority,
+		depositStake,
+		validatorStake,
+		pool.ReserveStake,
+		poolTokensTo,
+		pool.ManagerFeeAccount,
+		referrer,
+		pool.PoolMint,
+	).SetTokenProgramAccount(pool.TokenProgramID)
+	if pool.StakeDepositAuthority != defaultDepositAuthority {
+		deposit.GetDepositAuthorityAccount().SIGNER()
+	}
+	return []ag_solanago.Instruction{
+		newStakeAuthorizeInstruction(depositStake, stakeAuthority, pool.StakeDepositAuthority, stakeAuthorizeStaker),
+		newStakeAuthorizeInstruction(depositStake, stakeAuthority, withdrawAuthority, stakeAuthorizeWithdrawer),
+		deposit.Build(),
+	}, nil
+}
+
+// NewWithdrawStakeInstructionForPool returns a WithdrawStake of the pool tokens
+// from poolTokensFrom (owned or delegated to userTransferAuthority),
+// splitting stakeToSplit (a validator stake account, see FindValidatorStakeAddress,
+// or the reserve) into stakeToReceive, an uninitialized stake account
+// whose authorities will be userStakeAuthority.
+func NewWithdrawStakeInstructionForPool(
+	poolAddress ag_solanago.PublicKey,
+	pool *StakePool,
+	poolTokens uint64,
+	stakeToSplit ag_solanago.PublicKey,
+	stakeToReceive ag_solanago.PublicKey,
+	userStakeAuthority ag_solanago.PublicKey,
+	userTransferAuthority ag_solanago.PublicKey,
+	poolTokensFrom ag_solanago.PublicKey,
+) (*WithdrawStake, error) {
+	withdrawAuthority, _, err := FindWithdrawAuthorityAddress(poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive withdraw authority: %w", err)
+	}
+	return NewWithdrawStakeInstruction(
+		poolTokens,
+		poolAddress,
+		pool.ValidatorList,
+		withdrawAuthority,
+		stakeToSplit,
+		stakeToReceive,
+		userStakeAuthority,
+		userTransferAuthority,
+		poolTokensFrom,
+		pool.ManagerFeeAccount,
+		pool.PoolMint,
+	).SetTokenProgramAccount(pool.TokenProgramID), nil
+}
+
+const (
+	stakeInstructionAuthorize uint32 = 1
+
+	stakeAuthorizeStaker     uint32 = 0
+	stakeAuthorizeWithdrawer uint32 = 1
+)
+
+// newStakeAuthorizeInstruction builds a stake program Authorize instruction.
+func newStakeAuthorizeInstruction(
+	stake ag_solanago.PublicKey,
+	authority ag_solanago.PublicKey,
+	newAuthority ag_solanago.PublicKey,
+	authorizeType uint32,
+) ag_solanago.Instruction {
+	data := make([]byte, 4+32+4)
+	binary.LittleEndian.PutUint32(data[0:4], stakeInstructionAuthorize)
+	copy(data[4:36], newAuthority[:])
+	binary.LittleEndian.PutUint32(data[36:40], authorizeType)
+	return ag_solanago.NewInstruction(
+		ag_solanago.StakeProgramID,
+		ag_solanago.AccountMetaSlice{
+			ag_solanago.Meta(stake).WRITE(),
+			ag_solanago.Meta(ag_solanago.SysVarClockPubkey),
+			ag_solanago.Meta(authority).SIGNER(),
+		},
+		data,
+	)
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"encoding/binary"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindWithdrawAuthorityAddress(t *testing.T) {
+	// The withdraw authority of jitoSOL.
+	authority, _, err := FindWithdrawAuthorityAddress(jitoSOLPool)
+	require.NoError(t, err)
+	assert.Equal(t, ag_solanago.MustPublicKeyFromBase58("6iQKfEyhr3bZMotVkW6beNZz5CPAkiwvgV2CTje9pVSS"), authority)
+}
+
+func TestFindValidatorStakeAddress(t *testing.T) {
+	vote := ag_solanago.NewWallet().PublicKey()
+	withoutSeed, _, err := FindValidatorStakeAddress(vote, jitoSOLPool, 0)
+	require.NoError(t, err)
+	expected, _, err := ag_solanago.FindProgramAddress([][]byte{vote[:], jitoSOLPool[:]}, ProgramID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, withoutSeed)
+
+	withSeed, _, err := FindValidatorStakeAddress(vote, jitoSOLPool, 3)
+	require.NoError(t, err)
+	expected, _, err = ag_solanago.FindProgramAddress([][]byte{vote[:], jitoSOLPool[:], {3, 0, 0, 0}}, ProgramID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, withSeed)
+
+	transient, _, err := FindTransientStakeAddress(vote, jitoSOLPool, 7)
+	require.NoError(t, err)
+	expected, _, err = ag_solanago.FindProgramAddress([][]byte{[]byte("transient"), vote[:], jitoSOLPool[:], {7, 0, 0, 0, 0, 0, 0, 0}}, ProgramID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, transient)
+}
+
+func TestNewDepositSolInstructionForPool(t *testing.T) {
+	pool, err := DecodeStakePool(readFixture(t, "jitosol_stake_pool.b64"))
+	require.NoError(t, err)
+	from := ag_solanago.NewWallet().PublicKey()
+	poolTokensTo := ag_solanago.NewWallet().PublicKey()
+
+	inst, err := NewDepositSolInstructionForPool(jitoSOLPool, pool, 1_000_000_000, from, poolTokensTo, poolTokensTo)
+	require.NoError(t, err)
+	built, err := inst.ValidateAndBuild()
+	require.NoError(t, err)
+
+	withdrawAuthority, _, err := FindWithdrawAuthorityAddress(jitoSOLPool)
+	require.NoError(t, err)
+	assert.Equal(t, ag_solanago.AccountMetaSlice{
+		ag_solanago.Meta(jitoSOLPool).WRITE(),
+		ag_solanago.Meta(withdrawAuthority),
+		ag_solanago.Meta(pool.ReserveStake).WRITE(),
+		ag_solanago.Meta(from).WRITE().SIGNER(),
+		ag_solanago.Meta(poolTokensTo).WRITE(),
+		ag_solanago.Meta(pool.ManagerFeeAccount).WRITE(),
+		ag_solanago.Meta(poolTokensTo).WRITE(),
+		ag_solanago.Meta(jitoSOLMint).WRITE(),
+		ag_solanago.Meta(ag_solanago.SystemProgramID),
+		ag_solanago.Meta(ag_solanago.TokenProgramID),
+	}, ag_solanago.AccountMetaSlice(built.Accounts()))
+
+	data, err := built.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{14, 0x00, 0xca, 0x9a, 0x3b, 0, 0, 0, 0}, data)
+
+	// With a SOL deposit authority.
+	authority := ag_solanago.NewWallet().PublicKey()
+	pool.SolDepositAuthority = &authority
+	inst, err = NewDepositSolInstructionForPool(jitoSOLPool, pool, 1, from, poolTokensTo, poolTokensTo)
+	require.NoError(t, err)
+	require.NoError(t, inst.Validate())
+	require.Len(t, inst.Accounts, 11)
+	assert.Equal(t, ag_solanago.Meta(authority).SIGNER(), inst.GetSolDepositAuthorityAccount())
+}
+
+func TestNewWithdrawSolInstructionForPool(t *testing.T) {
+	pool, err := DecodeStakePool(readFixture(t, "jitosol_stake_pool.b64"))
+	require.NoError(t, err)
+	owner := ag_solanago.NewWallet().PublicKey()
+	poolTokensFrom := ag_solanago.NewWallet().PublicKey()
+
+	inst, err := NewWithdrawSolInstructionForPool(jitoSOLPool, pool, 5, owner, poolTokensFrom, owner)
+	require.NoError(t, err)
+	built, err := inst.ValidateAndBuild()
+	require.NoError(t, err)
+
+	accounts := built.Accounts()
+	require.Len(t, accounts, 12)
+	assert.Equal(t, ag_solanago.Meta(owner).SIGNER(), accounts[2])
+	assert.Equal(t, ag_solanago.Meta(pool.ReserveStake).WRITE(), accounts[4])
+	assert.Equal(t, ag_solanago.Meta(owner).WRITE(), accounts[5])
+	assert.Equal(t, ag_solanago.Meta(ag_solanago.SysVarClockPubkey), accounts[8])
+	assert.Equal(t, ag_solanago.Meta(ag_solanago.SysVarStakeHistoryPubkey), accounts[9])
+	assert.Equal(t, ag_solanago.Meta(ag_solanago.StakeProgramID), accounts[10])
+	assert.Equal(t, ag_solanago.Meta(ag_solanago.TokenProgramID), accounts[11])
+
+	data, err := built.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{16, 5, 0, 0, 0, 0, 0, 0, 0}, data)
+}
+
+func TestDepositStakeInstructions(t *testing.T) {
+	pool, err := DecodeStakePool(readFixture(t, "jitosol_stake_pool.b64"))
+	require.NoError(t, err)
+	list, err := DecodeValidatorList(readFixture(t, "jitosol_validator_list.b64"))
+	require.NoError(t, err)
+	validator := &list.Validators[1]
+	stake := ag_solanago.NewWallet().PublicKey()
+	staker := ag_solanago.NewWallet().PublicKey()
+	poolTokensTo := ag_solanago.NewWallet().PublicKey()
+
+	instructions, err := DepositStakeInstructions(jitoSOLPool, pool, validator, stake, staker, poolTokensTo, poolTokensTo)
+	require.NoError(t, err)
+	require.Len(t, instructions, 3)
+
+	withdrawAuthority, _, err := FindWithdrawAuthorityAddress(jitoSOLPool)
+	require.NoError(t, err)
+	for i, newAuthority := range []ag_solanago.PublicKey{pool.StakeDepositAuthority, withdrawAuthority} {
+		authorize := instructions[i]
+		assert.Equal(t, ag_solanago.StakeProgramID, authorize.ProgramID())
+		assert.Equal(t, []*ag_solanago.AccountMeta{
+			ag_solanago.Meta(stake).WRITE(),
+			ag_solanago.Meta(ag_solanago.SysVarClockPubkey),
+			ag_solanago.Meta(staker).SIGNER(),
+		}, authorize.Accounts())
+		data, err := authorize.Data()
+		require.NoError(t, err)
+		require.Len(t, data, 40)
+		assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(data[:4]))
+		assert.Equal(t, newAuthority[:], data[4:36])
+		assert.Equal(t, uint32(i), binary.LittleEndian.Uint32(data[36:]))
+	}
+
+	deposit := instructions[2]
+	assert.Equal(t, ProgramID, deposit.ProgramID())
+	data, err := deposit.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{9}, data)
+	validatorStake, _, err := FindValidatorStakeAddress(validator.VoteAccountAddress, jitoSOLPool, 3)
+	require.NoError(t, err)
+	accounts := deposit.Accounts()
+	require.Len(t, accounts, 15)
+	assert.Equal(t, ag_solanago.Meta(pool.ValidatorList).WRITE(), accounts[1])
+	// The default deposit authority doesn't sign.
+	assert.Equal(t, ag_solanago.Meta(pool.StakeDepositAuthority), accounts[2])
+	assert.Equal(t, ag_solanago.Meta(withdrawAuthority), accounts[3])
+	assert.Equal(t, ag_solanago.Meta(validatorStake).WRITE(), accounts[5])
+	assert.Equal(t, ag_solanago.Meta(ag_solanago.StakeProgramID), accounts[14])
+
+	// A custom deposit authority signs.
+	pool.StakeDepositAuthority = ag_solanago.NewWallet().PublicKey()
+	instructions, err = DepositStakeInstructions(jitoSOLPool, pool, validator, stake, staker, poolTokensTo, poolTokensTo)
+	require.NoError(t, err)
+	assert.True(t, instructions[2].Accounts()[2].IsSigner)
+}
+
+func TestNewWithdrawStakeInstructionForPool(t *testing.T) {
+	pool, err := DecodeStakePool(readFixture(t, "jitosol_stake_pool.b64"))
+	require.NoError(t, err)
+	owner := ag_solanago.NewWallet().PublicKey()
+	poolTokensFrom := ag_solanago.NewWallet().PublicKey()
+	newStake := ag_solanago.NewWallet().PublicKey()
+
+	inst, err := NewWithdrawStakeInstructionForPool(jitoSOLPool, pool, 42, pool.ReserveStake, newStake, owner, owner, poolTokensFrom)
+	require.NoError(t, err)
+	built, err := inst.ValidateAndBuild()
+	require.NoError(t, err)
+
+	accounts := built.Accounts()
+	require.Len(t, accounts, 13)
+	assert.Equal(t, ag_solanago.Meta(pool.ReserveStake).WRITE(), accounts[3])
+	assert.Equal(t, ag_solanago.Meta(newStake).WRITE(), accounts[4])
+	assert.Equal(t, ag_solanago.Meta(owner), accounts[5])
+	assert.Equal(t, ag_solanago.Meta(owner).SIGNER(), accounts[6])
+	assert.Equal(t, ag_solanago.Meta(poolTokensFrom).WRITE(), accounts[7])
+
+	data, err := built.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{10, 42, 0, 0, 0, 0, 0, 0, 0}, data)
+}
+
+func TestDecodeInstruction_unsupported(t *testing.T) {
+	_, err := DecodeInstruction(nil, []byte{Instruction_UpdateStakePoolBalance})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "UpdateStakePoolBalance is not supported")
+
+	_, err = DecodeInstruction(nil, []byte{200})
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"encoding/binary"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// FindWithdrawAuthorityAddress returns the withdraw authority PDA of the pool,
+// which owns the stake accounts and mints the pool tokens.
+func FindWithdrawAuthorityAddress(stakePool ag_solanago.PublicKey) (ag_solanago.PublicKey, uint8, error) {
+	return ag_solanago.FindProgramAddress(
+		[][]byte{stakePool[:], []byte("withdraw")},
+		ProgramID,
+	)
+}
+
+// FindDepositAuthorityAddress returns the default stake deposit authority PDA of the pool.
+func FindDepositAuthorityAddress(stakePool ag_solanago.PublicKey) (ag_solanago.PublicKey, uint8, error) {
+	return ag_solanago.FindProgramAddress(
+		[][]byte{stakePool[:], []byte("deposit")},
+		ProgramID,
+	)
+}
+
+// FindValidatorStakeAddress returns the address of the stake account
+// of the validator in the pool; seed is the ValidatorSeedSuffix
+// of its entry in the validator list (0 for none).
+func FindValidatorStakeAddress(
+	voteAccount ag_solanago.PublicKey,
+	stakePool ag_solanago.PublicKey,
+	seed uint32,
+) (ag_solanago.PublicKey, uint8, error) {
+	seeds := [][]byte{voteAccount[:], stakePool[:]}
+	if seed != 0 {
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, seed)
+		seeds = append(seeds, buf)
+	}
+	return ag_solanago.FindProgramAddress(seeds, ProgramID)
+}
+
+// FindTransientStakeAddress returns the address of the transient stake account
+// of the validator in the pool; seed is the TransientSeedSuffix
+// of its entry in the validator list.
+func FindTransientStakeAddress(
+	voteAccount ag_solanago.PublicKey,
+	stakePool ag_solanago.PublicKey,
+	seed uint64,
+) (ag_solanago.PublicKey, uint8, error) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, seed)
+	return ag_solanago.FindProgramAddress(
+		[][]byte{[]byte("transient"), voteAccount[:], stakePool[:], buf},
+		ProgramID,
+	)
+}
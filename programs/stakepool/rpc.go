@@ -0,0 +1,54 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"context"
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// FetchStakePool fetches and decodes the stake pool; an error is returned
+// if the account is not owned by the stake pool program.
+func FetchStakePool(ctx context.Context, rpcCli *rpc.Client, poolAddress ag_solanago.PublicKey) (*StakePool, error) {
+	data, err := fetchProgramAccountData(ctx, rpcCli, poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch stake pool: %w", err)
+	}
+	return DecodeStakePool(data)
+}
+
+// FetchValidatorList fetches and decodes the validator list; an error is returned
+// if the account is not owned by the stake pool program.
+func FetchValidatorList(ctx context.Context, rpcCli *rpc.Client, listAddress ag_solanago.PublicKey) (*ValidatorList, error) {
+	data, err := fetchProgramAccountData(ctx, rpcCli, listAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch validator list: %w", err)
+	}
+	return DecodeValidatorList(data)
+}
+
+func fetchProgramAccountData(ctx context.Context, rpcCli *rpc.Client, address ag_solanago.PublicKey) ([]byte, error) {
+	resp, err := rpcCli.GetAccountInfo(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ExpectOwner(resp.Value, ProgramID); err != nil {
+		return nil, fmt.Errorf("account %s: %w", address, err)
+	}
+	return resp.Value.Data.GetBinary(), nil
+}
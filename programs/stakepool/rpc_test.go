@@ -0,0 +1,55 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stakepool
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchStakePool(t *testing.T) {
+	data := readFixture(t, "jitosol_stake_pool.b64")
+	serve := func(owner ag_solanago.PublicKey) *rpc.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":0,"result":{"context":{"slot":1},"value":{"data":[%q,"base64"],"executable":false,"lamports":1,"owner":%q,"rentEpoch":1}}}`,
+				base64.StdEncoding.EncodeToString(data),
+				owner,
+			)
+		}))
+		t.Cleanup(server.Close)
+		return rpc.New(server.URL)
+	}
+
+	pool, err := FetchStakePool(context.Background(), serve(ProgramID), jitoSOLPool)
+	require.NoError(t, err)
+	assert.Equal(t, jitoSOLMint, pool.PoolMint)
+
+	_, err = FetchStakePool(context.Background(), serve(ag_solanago.TokenProgramID), jitoSOLPool)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("expected %s, got %s", ProgramID, ag_solanago.TokenProgramID))
+
+	// A stake pool is not a validator list, even with the right owner.
+	_, err = FetchValidatorList(context.Background(), serve(ProgramID), jitoSOLPool)
+	require.Error(t, err)
+}
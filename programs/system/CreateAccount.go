@@ -140,7 +140,7 @@ func (inst *CreateAccount) EncodeToTree(parent ag_treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
-						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+						paramsBranch.Child(ag_format.LamportsParam("Lamports", *inst.Lamports))
 						paramsBranch.Child(ag_format.Param("   Space", *inst.Space))
 						paramsBranch.Child(ag_format.Param("   Owner", *inst.Owner))
 					})
@@ -154,6 +154,23 @@ func (inst *CreateAccount) EncodeToTree(parent ag_treeout.Branches) {
 		})
 }
 
+// Summary returns e.g. "Create account <new account> (165 bytes, 0.00203928 SOL)
+// owned by <owner>, funded by <funding account>".
+func (inst CreateAccount) Summary(ctx *ag_solanago.SummaryContext) string {
+	funding, newAccount := inst.AccountMetaSlice.Get(0), inst.AccountMetaSlice.Get(1)
+	if inst.Lamports == nil || inst.Space == nil || inst.Owner == nil || funding == nil || newAccount == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Create account %s (%d bytes, %s) owned by %s, funded by %s",
+		newAccount.PublicKey,
+		*inst.Space,
+		ag_format.SummaryLamports(*inst.Lamports),
+		*inst.Owner,
+		funding.PublicKey,
+	)
+}
+
 func (inst CreateAccount) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
 	// Serialize `Lamports` param:
 	{
@@ -187,7 +187,7 @@ func (inst *CreateAccountWithSeed) EncodeToTree(parent ag_treeout.Branches) {
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
 						paramsBranch.Child(ag_format.Param("    Base", *inst.Base))
 						paramsBranch.Child(ag_format.Param("    Seed", *inst.Seed))
-						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+						paramsBranch.Child(ag_format.LamportsParam("Lamports", *inst.Lamports))
 						paramsBranch.Child(ag_format.Param("   Space", *inst.Space))
 						paramsBranch.Child(ag_format.Param("   Owner", *inst.Owner))
 					})
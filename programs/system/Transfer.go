@@ -116,7 +116,7 @@ func (inst *Transfer) EncodeToTree(parent ag_treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
-						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+						paramsBranch.Child(ag_format.LamportsParam("Lamports", *inst.Lamports))
 					})
 
 					// Accounts of the instruction:
@@ -128,6 +128,20 @@ func (inst *Transfer) EncodeToTree(parent ag_treeout.Branches) {
 		})
 }
 
+// Summary returns e.g. "Transfer 1.5 SOL from <funding account> to <recipient>".
+func (inst Transfer) Summary(ctx *ag_solanago.SummaryContext) string {
+	from, to := inst.AccountMetaSlice.Get(0), inst.AccountMetaSlice.Get(1)
+	if inst.Lamports == nil || from == nil || to == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Transfer %s from %s to %s",
+		ag_format.SummaryLamports(*inst.Lamports),
+		from.PublicKey,
+		to.PublicKey,
+	)
+}
+
 func (inst Transfer) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
 	// Serialize `Lamports` param:
 	{
@@ -16,10 +16,14 @@ package system
 
 import (
 	"bytes"
+	"io/ioutil"
 	"strconv"
 	"testing"
 
 	ag_gofuzz "github.com/gagliardetto/gofuzz"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_text "github.com/gagliardetto/solana-go/text"
+	ag_treeout "github.com/gagliardetto/treeout"
 	ag_require "github.com/stretchr/testify/require"
 )
 
@@ -44,3 +48,21 @@ func TestEncodeDecode_Transfer(t *testing.T) {
 		})
 	}
 }
+
+func TestTransfer_EncodeToTree(t *testing.T) {
+	ag_text.DisableColors = true
+	defer func() { ag_text.DisableColors = false }()
+
+	inst := NewTransferInstruction(
+		1_500_000_000,
+		ag_solanago.MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw"),
+		ag_solanago.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"),
+	)
+
+	tree := ag_treeout.New("")
+	inst.EncodeToTree(tree)
+
+	golden, err := ioutil.ReadFile("testdata/transfer-tree.golden")
+	ag_require.NoError(t, err)
+	ag_require.Equal(t, string(golden), tree.String())
+}
@@ -157,7 +157,7 @@ func (inst *WithdrawNonceAccount) EncodeToTree(parent ag_treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
-						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+						paramsBranch.Child(ag_format.LamportsParam("Lamports", *inst.Lamports))
 					})
 
 					// Accounts of the instruction:
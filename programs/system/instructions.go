@@ -124,6 +124,15 @@ func (inst *Instruction) EncodeToTree(parent ag_treeout.Branches) {
 	}
 }
 
+// Summary returns the summary of the instruction (see ag_solanago.Summarizer),
+// or an empty string if the instruction has no summary.
+func (inst *Instruction) Summary(ctx *ag_solanago.SummaryContext) string {
+	if summarizer, ok := inst.Impl.(ag_solanago.Summarizer); ok {
+		return summarizer.Summary(ctx)
+	}
+	return ""
+}
+
 var InstructionImplDef = ag_binary.NewVariantDefinition(
 	ag_binary.Uint32TypeIDEncoding,
 	[]ag_binary.VariantType{
@@ -0,0 +1,55 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_require "github.com/stretchr/testify/require"
+)
+
+func TestSummary_golden(t *testing.T) {
+	from := ag_solanago.MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	to := ag_solanago.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+
+	// The system instructions don't need the context.
+	withContext := &ag_solanago.SummaryContext{
+		ResolveToken: func(ag_solanago.PublicKey) (ag_solanago.TokenInfo, bool) {
+			return ag_solanago.TokenInfo{Decimals: 6, Symbol: "USDC"}, true
+		},
+	}
+	instructions := []ag_solanago.Summarizer{
+		NewTransferInstruction(1_500_000_000, from, to).Build(),
+		NewCreateAccountInstruction(2_039_280, 165, ag_solanago.TokenProgramID, from, to).Build(),
+		// No summary.
+		NewAdvanceNonceAccountInstruction(to, ag_solanago.SysVarRecentBlockHashesPubkey, from).Build(),
+		// Missing lamports.
+		NewTransferInstructionBuilder().SetFundingAccount(from).SetRecipientAccount(to).Build(),
+	}
+
+	var lines []string
+	for _, ctx := range []*ag_solanago.SummaryContext{nil, withContext} {
+		for _, inst := range instructions {
+			lines = append(lines, inst.Summary(ctx))
+		}
+	}
+
+	golden, err := ioutil.ReadFile("testdata/summaries.golden")
+	ag_require.NoError(t, err)
+	ag_require.Equal(t, string(golden), strings.Join(lines, "\n")+"\n")
+}
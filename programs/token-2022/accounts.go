@@ -0,0 +1,221 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package token2022 decodes the accounts of the Token-2022 program
+// (a.k.a. Token Extensions): the base layout is the one of the token program,
+// followed by the TLV (type-length-value) data of the extensions.
+package token2022
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+var ProgramID solana.PublicKey = solana.Token2022ProgramID
+
+func SetProgramID(pubkey solana.PublicKey) {
+	ProgramID = pubkey
+	solana.RegisterAccountDecoder(ProgramID, registryDecodeAccount)
+}
+
+func init() {
+	if !ProgramID.IsZero() {
+		solana.RegisterAccountDecoder(ProgramID, registryDecodeAccount)
+	}
+}
+
+// The AccountType byte that follows the base layout
+// of the mints and accounts with extensions.
+type AccountType uint8
+
+const (
+	AccountTypeUninitialized AccountType = iota
+	AccountTypeMint
+	AccountTypeAccount
+)
+
+// The AccountType byte is at the same offset for mints and accounts:
+// the mints are padded to the size of the accounts.
+const accountTypeOffset = token.ACCOUNT_SIZE
+
+// Extensions are the decoded extensions of a mint or an account, by type.
+// The value is a pointer to the struct of the extension (e.g. *TransferFeeConfig),
+// or the raw bytes ([]byte) for the extensions that are not decoded.
+type Extensions map[ExtensionType]interface{}
+
+type Mint struct {
+	token.Mint
+	Extensions Extensions
+}
+
+// UnmarshalWithDecoder decodes all the remaining data as a mint (see DecodeMint).
+func (mint *Mint) UnmarshalWithDecoder(dec *bin.Decoder) error {
+	data, err := dec.ReadNBytes(dec.Remaining())
+	if err != nil {
+		return err
+	}
+	out, err := DecodeMint(data)
+	if err != nil {
+		return err
+	}
+	*mint = *out
+	return nil
+}
+
+// MarshalWithEncoder encodes the base layout of the mint;
+// encoding the extensions is not supported.
+func (mint Mint) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if len(mint.Extensions) > 0 {
+		return errors.New("unable to encode mint: extensions are not supported")
+	}
+	return mint.Mint.MarshalWithEncoder(encoder)
+}
+
+type Account struct {
+	token.Account
+	Extensions Extensions
+}
+
+// UnmarshalWithDecoder decodes all the remaining data as a token account (see DecodeTokenAccount).
+func (account *Account) UnmarshalWithDecoder(dec *bin.Decoder) error {
+	data, err := dec.ReadNBytes(dec.Remaining())
+	if err != nil {
+		return err
+	}
+	out, err := DecodeTokenAccount(data)
+	if err != nil {
+		return err
+	}
+	*account = *out
+	return nil
+}
+
+// MarshalWithEncoder encodes the base layout of the token account;
+// encoding the extensions is not supported.
+func (account Account) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if len(account.Extensions) > 0 {
+		return errors.New("unable to encode token account: extensions are not supported")
+	}
+	return account.Account.MarshalWithEncoder(encoder)
+}
+
+func registryDecodeAccount(data []byte) (interface{}, error) {
+	return DecodeAccount(data)
+}
+
+// DecodeAccount decodes the data of an account owned by the Token-2022 program.
+// It returns a *Mint or an *Account (with their extensions, if any),
+// or a *token.Multisig.
+func DecodeAccount(data []byte) (interface{}, error) {
+	switch len(data) {
+	case token.MINT_SIZE:
+		return DecodeMint(data)
+	case token.ACCOUNT_SIZE:
+		return DecodeTokenAccount(data)
+	case token.MULTISIG_SIZE:
+		// Never the size of an account with extensions.
+		out := new(token.Multisig)
+		if err := bin.NewBinDecoder(data).Decode(out); err != nil {
+			return nil, fmt.Errorf("unable to decode multisig: %w", err)
+		}
+		return out, nil
+	}
+	if len(data) <= accountTypeOffset {
+		return nil, fmt.Errorf("unknown token account type for data size %d", len(data))
+	}
+	switch AccountType(data[accountTypeOffset]) {
+	case AccountTypeMint:
+		return DecodeMint(data)
+	case AccountTypeAccount:
+		return DecodeTokenAccount(data)
+	default:
+		return nil, fmt.Errorf("unknown account type %d", data[accountTypeOffset])
+	}
+}
+
+// DecodeMint decodes a mint, with its extensions if any.
+func DecodeMint(data []byte) (*Mint, error) {
+	if len(data) < token.MINT_SIZE {
+		return nil, fmt.Errorf("mint is too short: %d bytes", len(data))
+	}
+	out := new(Mint)
+	if err := bin.NewBinDecoder(data[:token.MINT_SIZE]).Decode(&out.Mint); err != nil {
+		return nil, fmt.Errorf("unable to decode mint: %w", err)
+	}
+	if len(data) == token.MINT_SIZE {
+		return out, nil
+	}
+	extensions, err := decodeExtensions(data, AccountTypeMint)
+	if err != nil {
+		return nil, err
+	}
+	out.Extensions = extensions
+	return out, nil
+}
+
+// DecodeTokenAccount decodes a token account, with its extensions if any.
+func DecodeTokenAccount(data []byte) (*Account, error) {
+	if len(data) < token.ACCOUNT_SIZE {
+		return nil, fmt.Errorf("token account is too short: %d bytes", len(data))
+	}
+	out := new(Account)
+	if err := bin.NewBinDecoder(data[:token.ACCOUNT_SIZE]).Decode(&out.Account); err != nil {
+		return nil, fmt.Errorf("unable to decode token account: %w", err)
+	}
+	if len(data) == token.ACCOUNT_SIZE {
+		return out, nil
+	}
+	extensions, err := decodeExtensions(data, AccountTypeAccount)
+	if err != nil {
+		return nil, err
+	}
+	out.Extensions = extensions
+	return out, nil
+}
+
+// decodeExtensions checks the AccountType byte, and decodes the TLV data after it.
+func decodeExtensions(data []byte, expected AccountType) (Extensions, error) {
+	if len(data) <= accountTypeOffset {
+		return nil, fmt.Errorf("data is too short for extensions: %d bytes", len(data))
+	}
+	if got := AccountType(data[accountTypeOffset]); got != expected {
+		return nil, fmt.Errorf("expected account type %d, got %d", expected, got)
+	}
+	extensions := make(Extensions)
+	tlv := data[accountTypeOffset+1:]
+	for len(tlv) >= 4 {
+		typ := ExtensionType(binary.LittleEndian.Uint16(tlv[0:2]))
+		if typ == ExtensionUninitialized {
+			// The rest is padding.
+			break
+		}
+		length := int(binary.LittleEndian.Uint16(tlv[2:4]))
+		if len(tlv) < 4+length {
+			return nil, fmt.Errorf("extension %s is truncated: %d bytes, need %d", typ, len(tlv)-4, length)
+		}
+		value := tlv[4 : 4+length]
+		decoded, err := typ.decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode extension %s: %w", typ, err)
+		}
+		extensions[typ] = decoded
+		tlv = tlv[4+length:]
+	}
+	return extensions, nil
+}
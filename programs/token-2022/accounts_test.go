@@ -0,0 +1,165 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, enc func(*bytes.Buffer) *bin.Encoder, v interface{}) []byte {
+	buf := new(bytes.Buffer)
+	require.NoError(t, enc(buf).Encode(v))
+	return buf.Bytes()
+}
+
+func binEncode(t *testing.T, v interface{}) []byte {
+	return encode(t, func(buf *bytes.Buffer) *bin.Encoder { return bin.NewBinEncoder(buf) }, v)
+}
+
+func tlv(typ ExtensionType, value []byte) []byte {
+	out := make([]byte, 4, 4+len(value))
+	binary.LittleEndian.PutUint16(out[0:2], uint16(typ))
+	binary.LittleEndian.PutUint16(out[2:4], uint16(len(value)))
+	return append(out, value...)
+}
+
+func TestDecodeMint_extensions(t *testing.T) {
+	authority := solana.NewWallet().PublicKey()
+	mintAddress := solana.NewWallet().PublicKey()
+	base := token.Mint{
+		MintAuthority: authority.ToPointer(),
+		Supply:        1000,
+		Decimals:      6,
+		IsInitialized: true,
+	}
+	transferFee := TransferFeeConfig{
+		TransferFeeConfigAuthority: authority,
+		WithheldAmount:             7,
+		OlderTransferFee:           TransferFee{Epoch: 1, MaximumFee: 10, TransferFeeBasisPoints: 50},
+		NewerTransferFee:           TransferFee{Epoch: 500, MaximumFee: 20, TransferFeeBasisPoints: 100},
+	}
+	metadataPointer := MetadataPointer{Authority: authority, MetadataAddress: mintAddress}
+	metadata := TokenMetadata{
+		UpdateAuthority: authority,
+		Mint:            mintAddress,
+		Name:            "Example",
+		Symbol:          "EX",
+		URI:             "https://example.com/ex.json",
+		AdditionalMetadata: []TokenMetadataField{
+			{Key: "color", Value: "blue"},
+		},
+	}
+
+	data := binEncode(t, base)
+	// Padded to the size of an account, then the account type.
+	data = append(data, make([]byte, token.ACCOUNT_SIZE-token.MINT_SIZE)...)
+	data = append(data, byte(AccountTypeMint))
+	data = append(data, tlv(ExtensionTransferFeeConfig, binEncode(t, transferFee))...)
+	data = append(data, tlv(ExtensionMetadataPointer, binEncode(t, metadataPointer))...)
+	data = append(data, tlv(ExtensionTokenMetadata, encode(t, func(buf *bytes.Buffer) *bin.Encoder { return bin.NewBorshEncoder(buf) }, metadata))...)
+	data = append(data, tlv(ExtensionType(999), []byte{1, 2, 3})...)
+	assert.Len(t, binEncode(t, transferFee), 108)
+
+	// Dispatched by owner.
+	decoded, err := solana.DecodeAccount(solana.Token2022ProgramID, data)
+	require.NoError(t, err)
+	mint, ok := decoded.(*Mint)
+	require.True(t, ok, "%T", decoded)
+
+	assert.Equal(t, base, mint.Mint)
+	assert.Equal(t, uint8(6), mint.Decimals)
+	assert.Equal(t, Extensions{
+		ExtensionTransferFeeConfig: &transferFee,
+		ExtensionMetadataPointer:   &metadataPointer,
+		ExtensionTokenMetadata:     &metadata,
+		ExtensionType(999):         []byte{1, 2, 3},
+	}, mint.Extensions)
+	assert.Equal(t, uint16(50), transferFee.Fee(499).TransferFeeBasisPoints)
+	assert.Equal(t, uint16(100), transferFee.Fee(500).TransferFeeBasisPoints)
+	assert.Equal(t, "ExtensionType(999)", ExtensionType(999).String())
+
+	// Also with the binary decoder.
+	var fromDecoder Mint
+	require.NoError(t, bin.NewBinDecoder(data).Decode(&fromDecoder))
+	assert.Equal(t, mint, &fromDecoder)
+
+	// Extensions can't be encoded.
+	_, err = bin.MarshalBin(fromDecoder)
+	assert.Error(t, err)
+
+	// Truncated extension.
+	_, err = DecodeMint(data[:len(data)-2])
+	assert.EqualError(t, err, "extension ExtensionType(999) is truncated: 1 bytes, need 3")
+}
+
+func TestDecodeAccount(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	base := token.Account{
+		Mint:   solana.NewWallet().PublicKey(),
+		Owner:  owner,
+		Amount: 42,
+		State:  token.Initialized,
+	}
+	baseData := binEncode(t, base)
+	require.Len(t, baseData, token.ACCOUNT_SIZE)
+
+	// Without extensions.
+	decoded, err := DecodeAccount(baseData)
+	require.NoError(t, err)
+	assert.Equal(t, &Account{Account: base}, decoded)
+
+	// With extensions, followed by padding.
+	data := append([]byte{}, baseData...)
+	data = append(data, byte(AccountTypeAccount))
+	data = append(data, tlv(ExtensionImmutableOwner, nil)...)
+	data = append(data, tlv(ExtensionTransferFeeAmount, []byte{5, 0, 0, 0, 0, 0, 0, 0})...)
+	data = append(data, tlv(ExtensionMemoTransfer, []byte{1})...)
+	data = append(data, make([]byte, 8)...)
+	decoded, err = DecodeAccount(data)
+	require.NoError(t, err)
+	assert.Equal(t, &Account{
+		Account: base,
+		Extensions: Extensions{
+			ExtensionImmutableOwner:    &ImmutableOwner{},
+			ExtensionTransferFeeAmount: &TransferFeeAmount{WithheldAmount: 5},
+			ExtensionMemoTransfer:      &MemoTransfer{RequireIncomingTransferMemos: true},
+		},
+	}, decoded)
+
+	// A mint without extensions.
+	decoded, err = DecodeAccount(binEncode(t, token.Mint{Decimals: 9, IsInitialized: true}))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(9), decoded.(*Mint).Decimals)
+	assert.Nil(t, decoded.(*Mint).Extensions)
+
+	// Wrong account type.
+	data[token.ACCOUNT_SIZE] = byte(AccountTypeMint)
+	_, err = DecodeTokenAccount(data)
+	assert.EqualError(t, err, "expected account type 2, got 1")
+	data[token.ACCOUNT_SIZE] = 7
+	_, err = DecodeAccount(data)
+	assert.EqualError(t, err, "unknown account type 7")
+
+	_, err = DecodeAccount([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
@@ -0,0 +1,293 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+type ExtensionType uint16
+
+const (
+	ExtensionUninitialized ExtensionType = iota
+	ExtensionTransferFeeConfig
+	ExtensionTransferFeeAmount
+	ExtensionMintCloseAuthority
+	ExtensionConfidentialTransferMint
+	ExtensionConfidentialTransferAccount
+	ExtensionDefaultAccountState
+	ExtensionImmutableOwner
+	ExtensionMemoTransfer
+	ExtensionNonTransferable
+	ExtensionInterestBearingConfig
+	ExtensionCpiGuard
+	ExtensionPermanentDelegate
+	ExtensionNonTransferableAccount
+	ExtensionTransferHook
+	ExtensionTransferHookAccount
+	ExtensionConfidentialTransferFeeConfig
+	ExtensionConfidentialTransferFeeAmount
+	ExtensionMetadataPointer
+	ExtensionTokenMetadata
+	ExtensionGroupPointer
+	ExtensionTokenGroup
+	ExtensionGroupMemberPointer
+	ExtensionTokenGroupMember
+)
+
+var extensionNames = map[ExtensionType]string{
+	ExtensionUninitialized:                 "Uninitialized",
+	ExtensionTransferFeeConfig:             "TransferFeeConfig",
+	ExtensionTransferFeeAmount:             "TransferFeeAmount",
+	ExtensionMintCloseAuthority:            "MintCloseAuthority",
+	ExtensionConfidentialTransferMint:      "ConfidentialTransferMint",
+	ExtensionConfidentialTransferAccount:   "ConfidentialTransferAccount",
+	ExtensionDefaultAccountState:           "DefaultAccountState",
+	ExtensionImmutableOwner:                "ImmutableOwner",
+	ExtensionMemoTransfer:                  "MemoTransfer",
+	ExtensionNonTransferable:               "NonTransferable",
+	ExtensionInterestBearingConfig:         "InterestBearingConfig",
+	ExtensionCpiGuard:                      "CpiGuard",
+	ExtensionPermanentDelegate:             "PermanentDelegate",
+	ExtensionNonTransferableAccount:        "NonTransferableAccount",
+	ExtensionTransferHook:                  "TransferHook",
+	ExtensionTransferHookAccount:           "TransferHookAccount",
+	ExtensionConfidentialTransferFeeConfig: "ConfidentialTransferFeeConfig",
+	ExtensionConfidentialTransferFeeAmount: "ConfidentialTransferFeeAmount",
+	ExtensionMetadataPointer:               "MetadataPointer",
+	ExtensionTokenMetadata:                 "TokenMetadata",
+	ExtensionGroupPointer:                  "GroupPointer",
+	ExtensionTokenGroup:                    "TokenGroup",
+	ExtensionGroupMemberPointer:            "GroupMemberPointer",
+	ExtensionTokenGroupMember:              "TokenGroupMember",
+}
+
+func (typ ExtensionType) String() string {
+	if name, ok := extensionNames[typ]; ok {
+		return name
+	}
+	return fmt.Sprintf("ExtensionType(%d)", uint16(typ))
+}
+
+// decode decodes the value of an extension of this type;
+// the extensions without a known layout are returned as raw bytes.
+func (typ ExtensionType) decode(value []byte) (interface{}, error) {
+	var out interface{}
+	switch typ {
+	case ExtensionTransferFeeConfig:
+		out = new(TransferFeeConfig)
+	case ExtensionTransferFeeAmount:
+		out = new(TransferFeeAmount)
+	case ExtensionMintCloseAuthority:
+		out = new(MintCloseAuthority)
+	case ExtensionDefaultAccountState:
+		out = new(DefaultAccountState)
+	case ExtensionImmutableOwner:
+		out = new(ImmutableOwner)
+	case ExtensionMemoTransfer:
+		out = new(MemoTransfer)
+	case ExtensionNonTransferable:
+		out = new(NonTransferable)
+	case ExtensionInterestBearingConfig:
+		out = new(InterestBearingConfig)
+	case ExtensionCpiGuard:
+		out = new(CpiGuard)
+	case ExtensionPermanentDelegate:
+		out = new(PermanentDelegate)
+	case ExtensionNonTransferableAccount:
+		out = new(NonTransferableAccount)
+	case ExtensionTransferHook:
+		out = new(TransferHook)
+	case ExtensionTransferHookAccount:
+		out = new(TransferHookAccount)
+	case ExtensionMetadataPointer:
+		out = new(MetadataPointer)
+	case ExtensionTokenMetadata:
+		// Variable length, borsh encoded.
+		metadata := new(TokenMetadata)
+		if err := bin.NewBorshDecoder(value).Decode(metadata); err != nil {
+			return nil, err
+		}
+		return metadata, nil
+	case ExtensionGroupPointer:
+		out = new(GroupPointer)
+	case ExtensionTokenGroup:
+		out = new(TokenGroup)
+	case ExtensionGroupMemberPointer:
+		out = new(GroupMemberPointer)
+	case ExtensionTokenGroupMember:
+		out = new(TokenGroupMember)
+	default:
+		raw := make([]byte, len(value))
+		copy(raw, value)
+		return raw, nil
+	}
+	if err := bin.NewBinDecoder(value).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// In the extensions, an optional authority or address is zero when not set.
+
+type TransferFee struct {
+	// First epoch where the transfer fee takes effect.
+	Epoch uint64
+	// Maximum fee assessed on transfers, in base units of the token.
+	MaximumFee uint64
+	// Amount of transfer collected as fees, expressed as basis points of the transfer amount.
+	TransferFeeBasisPoints uint16
+}
+
+type TransferFeeConfig struct {
+	// Optional authority to set the fee.
+	TransferFeeConfigAuthority solana.PublicKey
+	// Withdraw from mint instructions must be signed by this key.
+	WithdrawWithheldAuthority solana.PublicKey
+	// Withheld transfer fee tokens that have been moved to the mint for withdrawal.
+	WithheldAmount uint64
+	// Older transfer fee, used if the current epoch < NewerTransferFee.Epoch.
+	OlderTransferFee TransferFee
+	// Newer transfer fee, used if the current epoch >= NewerTransferFee.Epoch.
+	NewerTransferFee TransferFee
+}
+
+// Fee returns the transfer fee that applies at the epoch.
+func (c *TransferFeeConfig) Fee(epoch uint64) TransferFee {
+	if epoch >= c.NewerTransferFee.Epoch {
+		return c.NewerTransferFee
+	}
+	return c.OlderTransferFee
+}
+
+type TransferFeeAmount struct {
+	// Amount withheld during transfers, to be harvested to the mint.
+	WithheldAmount uint64
+}
+
+type MintCloseAuthority struct {
+	CloseAuthority solana.PublicKey
+}
+
+type DefaultAccountState struct {
+	// The state of the new token accounts of the mint.
+	State token.AccountState
+}
+
+type ImmutableOwner struct{}
+
+type MemoTransfer struct {
+	// Require transfers into this account to be accompanied by a memo.
+	RequireIncomingTransferMemos bool
+}
+
+type NonTransferable struct{}
+
+type InterestBearingConfig struct {
+	RateAuthority solana.PublicKey
+	// Unix timestamp of the initialization.
+	InitializationTimestamp int64
+	// Average rate from the initialization until the last update, in basis points.
+	PreUpdateAverageRate int16
+	// Unix timestamp of the last update.
+	LastUpdateTimestamp int64
+	// Current rate, in basis points.
+	CurrentRate int16
+}
+
+type CpiGuard struct {
+	// Lock privileged token operations from happening via CPI.
+	LockCpi bool
+}
+
+type PermanentDelegate struct {
+	Delegate solana.PublicKey
+}
+
+type NonTransferableAccount struct{}
+
+type TransferHook struct {
+	// Authority that can set the transfer hook program id.
+	Authority solana.PublicKey
+	// Program that authorizes the transfers.
+	ProgramID solana.PublicKey
+}
+
+type TransferHookAccount struct {
+	// Whether the account is in the middle of a transfer.
+	Transferring bool
+}
+
+type MetadataPointer struct {
+	// Authority that can set the metadata address.
+	Authority solana.PublicKey
+	// Account address that holds the metadata.
+	MetadataAddress solana.PublicKey
+}
+
+type TokenMetadataField struct {
+	Key   string
+	Value string
+}
+
+type TokenMetadata struct {
+	// The authority that can sign to update the metadata.
+	UpdateAuthority solana.PublicKey
+	// The associated mint, used to counter spoofing to be sure that metadata belongs to a particular mint.
+	Mint   solana.PublicKey
+	Name   string
+	Symbol string
+	URI    string
+	// Any additional metadata about the token as key-value pairs.
+	AdditionalMetadata []TokenMetadataField
+}
+
+type GroupPointer struct {
+	// Authority that can set the group address.
+	Authority solana.PublicKey
+	// Account address that holds the group.
+	GroupAddress solana.PublicKey
+}
+
+type TokenGroup struct {
+	// The authority that can sign to update the group.
+	UpdateAuthority solana.PublicKey
+	// The associated mint, used to counter spoofing to be sure that group belongs to a particular mint.
+	Mint solana.PublicKey
+	// The current number of group members.
+	Size uint64
+	// The maximum number of group members.
+	MaxSize uint64
+}
+
+type GroupMemberPointer struct {
+	// Authority that can set the member address.
+	Authority solana.PublicKey
+	// Account address that holds the member.
+	MemberAddress solana.PublicKey
+}
+
+type TokenGroupMember struct {
+	// The associated mint, used to counter spoofing to be sure that member belongs to a particular mint.
+	Mint solana.PublicKey
+	// The pubkey of the TokenGroup.
+	Group solana.PublicKey
+	// The member number.
+	MemberNumber uint64
+}
@@ -173,7 +173,11 @@ func (inst *Burn) EncodeToTree(parent ag_treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
-						paramsBranch.Child(ag_format.Param("Amount", *inst.Amount))
+						if inst.Amount != nil {
+							paramsBranch.Child(ag_format.Param("Amount", *inst.Amount))
+						} else {
+							paramsBranch.Child(ag_format.Param("Amount", nil))
+						}
 					})
 
 					// Accounts of the instruction:
@@ -195,6 +199,19 @@ func (inst *Burn) EncodeToTree(parent ag_treeout.Branches) {
 		})
 }
 
+// Summary returns e.g. "Burn 1.5 USDC from <source>".
+func (inst Burn) Summary(ctx *ag_solanago.SummaryContext) string {
+	source, mint := inst.Accounts.Get(0), inst.Accounts.Get(1)
+	if inst.Amount == nil || source == nil || mint == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Burn %s from %s",
+		ag_format.SummaryMintAmount(ctx, *inst.Amount, mint.PublicKey, nil),
+		source.PublicKey,
+	)
+}
+
 func (obj Burn) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
 	// Serialize `Amount` param:
 	err = encoder.Encode(obj.Amount)
@@ -191,7 +191,7 @@ func (inst *BurnChecked) EncodeToTree(parent ag_treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
-						paramsBranch.Child(ag_format.Param("  Amount", *inst.Amount))
+						paramsBranch.Child(ag_format.TokenAmountParam("  Amount", *inst.Amount, *inst.Decimals))
 						paramsBranch.Child(ag_format.Param("Decimals", *inst.Decimals))
 					})
 
@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	ag_gofuzz "github.com/gagliardetto/gofuzz"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/instructiontest"
 	ag_require "github.com/stretchr/testify/require"
 )
 
@@ -46,3 +48,37 @@ func TestEncodeDecode_Burn(t *testing.T) {
 		})
 	}
 }
+
+func TestBurn_instructiontest(t *testing.T) {
+	source := ag_solanago.MustPublicKeyFromBase58("BxNVRzNr2dYpuEYoprJWTGiUs6X7X7u3XhbZi2vMXcE6")
+	mint := ag_solanago.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	owner := ag_solanago.MustPublicKeyFromBase58("7xKXtg2CW87d97TXJSDpbD5jBkheTqA83TZRuJosgAsU")
+
+	instructiontest.Run(t, instructiontest.Battery{
+		New: func() instructiontest.Builder {
+			return NewBurnInstruction(42, source, mint, owner, nil)
+		},
+		Build: func(b instructiontest.Builder) (ag_solanago.Instruction, error) {
+			return b.(*Burn).ValidateAndBuild()
+		},
+		Unset: map[string]func(instructiontest.Builder){
+			"Amount parameter is not set": func(b instructiontest.Builder) { b.(*Burn).Amount = nil },
+			"accounts.Source is not set":  func(b instructiontest.Builder) { b.(*Burn).Accounts[0] = nil },
+			"accounts.Mint is not set":    func(b instructiontest.Builder) { b.(*Burn).Accounts[1] = nil },
+			"accounts.Owner is not set":   func(b instructiontest.Builder) { b.(*Burn).Accounts[2] = nil },
+			"accounts.Signers is not set": func(b instructiontest.Builder) { b.(*Burn).Accounts[2] = ag_solanago.Meta(owner) },
+		},
+	})
+
+	t.Run("multisig", func(t *testing.T) {
+		signers := []ag_solanago.PublicKey{source, mint}
+		instructiontest.Run(t, instructiontest.Battery{
+			New: func() instructiontest.Builder {
+				return NewBurnInstruction(42, source, mint, owner, signers)
+			},
+			Build: func(b instructiontest.Builder) (ag_solanago.Instruction, error) {
+				return b.(*Burn).ValidateAndBuild()
+			},
+		})
+	})
+}
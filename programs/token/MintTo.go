@@ -195,6 +195,19 @@ func (inst *MintTo) EncodeToTree(parent ag_treeout.Branches) {
 		})
 }
 
+// Summary returns e.g. "Mint 1.5 USDC to <destination>".
+func (inst MintTo) Summary(ctx *ag_solanago.SummaryContext) string {
+	mint, destination := inst.Accounts.Get(0), inst.Accounts.Get(1)
+	if inst.Amount == nil || mint == nil || destination == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Mint %s to %s",
+		ag_format.SummaryMintAmount(ctx, *inst.Amount, mint.PublicKey, nil),
+		destination.PublicKey,
+	)
+}
+
 func (obj MintTo) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
 	// Serialize `Amount` param:
 	err = encoder.Encode(obj.Amount)
@@ -197,6 +197,21 @@ func (inst *Transfer) EncodeToTree(parent ag_treeout.Branches) {
 		})
 }
 
+// Summary returns e.g. "Transfer 1.5 USDC from <source> to <destination>";
+// the token is resolved from the source account.
+func (inst Transfer) Summary(ctx *ag_solanago.SummaryContext) string {
+	source, destination := inst.Accounts.Get(0), inst.Accounts.Get(1)
+	if inst.Amount == nil || source == nil || destination == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Transfer %s from %s to %s",
+		ag_format.SummaryTokenAccountAmount(ctx, *inst.Amount, source.PublicKey),
+		source.PublicKey,
+		destination.PublicKey,
+	)
+}
+
 func (obj Transfer) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
 	// Serialize `Amount` param:
 	err = encoder.Encode(obj.Amount)
@@ -211,7 +211,7 @@ func (inst *TransferChecked) EncodeToTree(parent ag_treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
-						paramsBranch.Child(ag_format.Param("  Amount", *inst.Amount))
+						paramsBranch.Child(ag_format.TokenAmountParam("  Amount", *inst.Amount, *inst.Decimals))
 						paramsBranch.Child(ag_format.Param("Decimals", *inst.Decimals))
 					})
 
@@ -235,6 +235,20 @@ func (inst *TransferChecked) EncodeToTree(parent ag_treeout.Branches) {
 		})
 }
 
+// Summary returns e.g. "Transfer 1.5 USDC from <source> to <destination>".
+func (inst TransferChecked) Summary(ctx *ag_solanago.SummaryContext) string {
+	source, mint, destination := inst.Accounts.Get(0), inst.Accounts.Get(1), inst.Accounts.Get(2)
+	if inst.Amount == nil || source == nil || mint == nil || destination == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"Transfer %s from %s to %s",
+		ag_format.SummaryMintAmount(ctx, *inst.Amount, mint.PublicKey, inst.Decimals),
+		source.PublicKey,
+		destination.PublicKey,
+	)
+}
+
 func (obj TransferChecked) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
 	// Serialize `Amount` param:
 	err = encoder.Encode(obj.Amount)
@@ -16,6 +16,7 @@ package token
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
@@ -395,3 +396,35 @@ type Multisig struct {
 	// Signer public keys
 	Signers [MAX_SIGNERS]solana.PublicKey
 }
+
+func registryDecodeAccount(data []byte) (interface{}, error) {
+	return DecodeAccount(data)
+}
+
+// DecodeAccount decodes the data of an account owned by the token program;
+// the type of the account (Mint, Account, or Multisig) is inferred from the data size.
+func DecodeAccount(data []byte) (interface{}, error) {
+	dec := bin.NewBinDecoder(data)
+	switch len(data) {
+	case MINT_SIZE:
+		out := new(Mint)
+		if err := dec.Decode(out); err != nil {
+			return nil, fmt.Errorf("unable to decode mint: %w", err)
+		}
+		return out, nil
+	case ACCOUNT_SIZE:
+		out := new(Account)
+		if err := dec.Decode(out); err != nil {
+			return nil, fmt.Errorf("unable to decode token account: %w", err)
+		}
+		return out, nil
+	case MULTISIG_SIZE:
+		out := new(Multisig)
+		if err := dec.Decode(out); err != nil {
+			return nil, fmt.Errorf("unable to decode multisig: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown token account type for data size %d", len(data))
+	}
+}
@@ -94,3 +94,20 @@ func TestAccount(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeAccount_registry(t *testing.T) {
+	data := make([]byte, ACCOUNT_SIZE)
+	copy(data[:32], solana.WrappedSol[:])
+
+	decoded, err := solana.DecodeAccount(ProgramID, data)
+	require.NoError(t, err)
+	require.IsType(t, &Account{}, decoded)
+	require.Equal(t, solana.WrappedSol, decoded.(*Account).Mint)
+
+	decoded, err = solana.DecodeAccount(ProgramID, make([]byte, MINT_SIZE))
+	require.NoError(t, err)
+	require.IsType(t, &Mint{}, decoded)
+
+	_, err = solana.DecodeAccount(ProgramID, make([]byte, 7))
+	require.Error(t, err)
+}
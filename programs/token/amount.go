@@ -0,0 +1,76 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrTooManyDecimals is returned by ParseUiAmount when the amount
+// has more (non-zero) fractional digits than the decimals of the token.
+var ErrTooManyDecimals = errors.New("amount has more fractional digits than the token decimals")
+
+// ParseUiAmount parses a human-readable token amount (e.g. "1.5")
+// into the raw amount for a mint with the provided decimals (e.g. 1500000 for 6 decimals).
+// Leading and trailing whitespace is ignored.
+// Unlike a float conversion, it never rounds or truncates: an amount with more
+// fractional digits than decimals returns ErrTooManyDecimals (trailing zeros
+// are allowed, since they don't change the amount).
+// Signs, exponents and thousands separators (e.g. "1,000" or "1_000") are rejected.
+func ParseUiAmount(s string, decimals uint8) (uint64, error) {
+	amount := strings.TrimSpace(s)
+	if amount == "" {
+		return 0, errors.New("amount is empty")
+	}
+	integer, fraction := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		integer, fraction = amount[:i], amount[i+1:]
+		if fraction == "" {
+			return 0, fmt.Errorf("invalid amount %q: no digits after the decimal point", s)
+		}
+	}
+	if integer == "" {
+		return 0, fmt.Errorf("invalid amount %q: no digits before the decimal point", s)
+	}
+	for _, part := range []string{integer, fraction} {
+		for _, c := range part {
+			switch {
+			case c >= '0' && c <= '9':
+			case c == ',' || c == '_' || c == '\'' || c == ' ':
+				return 0, fmt.Errorf("invalid amount %q: thousands separators are not allowed", s)
+			default:
+				return 0, fmt.Errorf("invalid amount %q: unexpected character %q", s, c)
+			}
+		}
+	}
+
+	significant := strings.TrimRight(fraction, "0")
+	if len(significant) > int(decimals) {
+		return 0, fmt.Errorf("%w: %q has %d, but the token has %d", ErrTooManyDecimals, s, len(significant), decimals)
+	}
+	digits := integer + significant + strings.Repeat("0", int(decimals)-len(significant))
+
+	raw, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if !raw.IsUint64() {
+		return 0, fmt.Errorf("amount %q is too large: the raw amount doesn't fit into a uint64", s)
+	}
+	return raw.Uint64(), nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUiAmount(t *testing.T) {
+	valid := []struct {
+		in       string
+		decimals uint8
+		expected uint64
+	}{
+		{"1", 6, 1_000_000},
+		{"1.5", 6, 1_500_000},
+		{"0.000001", 6, 1},
+		{"1.123456", 6, 1_123_456},
+		{"  42.1\n", 9, 42_100_000_000},
+		{"1.50000000", 1, 15},
+		{"007", 2, 700},
+		{"0", 0, 0},
+		{"12", 0, 12},
+		{"18446744073709551615", 0, 18446744073709551615},
+		{"18446744073.709551615", 9, 18446744073709551615},
+	}
+	for _, test := range valid {
+		got, err := ParseUiAmount(test.in, test.decimals)
+		require.NoError(t, err, test.in)
+		require.Equal(t, test.expected, got, test.in)
+	}
+
+	{
+		_, err := ParseUiAmount("1.123456789123", 6)
+		require.ErrorIs(t, err, ErrTooManyDecimals)
+		require.EqualError(t, err, `amount has more fractional digits than the token decimals: "1.123456789123" has 12, but the token has 6`)
+
+		_, err = ParseUiAmount("1.5", 0)
+		require.ErrorIs(t, err, ErrTooManyDecimals)
+	}
+
+	invalid := []struct {
+		in       string
+		decimals uint8
+		expected string
+	}{
+		{"", 9, `amount is empty`},
+		{"   ", 9, `amount is empty`},
+		{"1,000", 9, `invalid amount "1,000": thousands separators are not allowed`},
+		{"1_000.5", 9, `invalid amount "1_000.5": thousands separators are not allowed`},
+		{"1 000", 9, `invalid amount "1 000": thousands separators are not allowed`},
+		{"-1", 9, `invalid amount "-1": unexpected character '-'`},
+		{"+1", 9, `invalid amount "+1": unexpected character '+'`},
+		{"1e6", 9, `invalid amount "1e6": unexpected character 'e'`},
+		{"1.2.3", 9, `invalid amount "1.2.3": unexpected character '.'`},
+		{"1.", 9, `invalid amount "1.": no digits after the decimal point`},
+		{".5", 9, `invalid amount ".5": no digits before the decimal point`},
+		{"18446744073709551616", 0, `amount "18446744073709551616" is too large: the raw amount doesn't fit into a uint64`},
+		{"18446744073.709551616", 9, `amount "18446744073.709551616" is too large: the raw amount doesn't fit into a uint64`},
+	}
+	for _, test := range invalid {
+		_, err := ParseUiAmount(test.in, test.decimals)
+		require.EqualError(t, err, test.expected, test.in)
+	}
+}
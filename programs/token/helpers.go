@@ -0,0 +1,90 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// CreateTokenAccountInstructions returns the instructions that create a token account
+// of ACCOUNT_SIZE bytes (funded by the payer, with rentLamports) and initialize it
+// for the mint and the owner, in this order.
+// The new account must sign the transaction, as well as the payer.
+//
+// rentLamports should be the minimum balance for rent exemption of ACCOUNT_SIZE bytes
+// (see rpc.Client.GetMinimumBalanceForRentExemption, or sysvar.Rent.MinimumBalance).
+func CreateTokenAccountInstructions(
+	payer ag_solanago.PublicKey,
+	newAccount ag_solanago.PublicKey,
+	mint ag_solanago.PublicKey,
+	owner ag_solanago.PublicKey,
+	rentLamports uint64,
+) []ag_solanago.Instruction {
+	return []ag_solanago.Instruction{
+		system.NewCreateAccountInstruction(
+			rentLamports,
+			ACCOUNT_SIZE,
+			ProgramID,
+			payer,
+			newAccount,
+		).Build(),
+		NewInitializeAccountInstruction(
+			newAccount,
+			mint,
+			owner,
+			ag_solanago.SysVarRentPubkey,
+		).Build(),
+	}
+}
+
+// CreateTokenAccountWithSeedInstructions is like CreateTokenAccountInstructions,
+// but the address of the token account is derived from the base, the seed
+// and the token program ID (see solana.CreateWithSeed); it is returned
+// with the instructions. The base must sign the transaction instead of the new account.
+func CreateTokenAccountWithSeedInstructions(
+	payer ag_solanago.PublicKey,
+	base ag_solanago.PublicKey,
+	seed string,
+	mint ag_solanago.PublicKey,
+	owner ag_solanago.PublicKey,
+	rentLamports uint64,
+) (instructions []ag_solanago.Instruction, account ag_solanago.PublicKey, err error) {
+	account, err = ag_solanago.CreateWithSeed(base, seed, ProgramID)
+	if err != nil {
+		return nil, ag_solanago.PublicKey{}, fmt.Errorf("unable to derive token account address: %w", err)
+	}
+	instructions = []ag_solanago.Instruction{
+		system.NewCreateAccountWithSeedInstruction(
+			base,
+			seed,
+			rentLamports,
+			ACCOUNT_SIZE,
+			ProgramID,
+			payer,
+			account,
+			base,
+		).Build(),
+		NewInitializeAccountInstruction(
+			account,
+			mint,
+			owner,
+			ag_solanago.SysVarRentPubkey,
+		).Build(),
+	}
+	return instructions, account, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/require"
+)
+
+// requireCreateTokenAccount checks that the instructions create (with the system program)
+// and then initialize the token account.
+func requireCreateTokenAccount(t *testing.T, instructions []ag_solanago.Instruction, account, mint, owner ag_solanago.PublicKey) *system.Instruction {
+	t.Helper()
+	require.Len(t, instructions, 2)
+
+	require.Equal(t, system.ProgramID, instructions[0].ProgramID())
+	data, err := instructions[0].Data()
+	require.NoError(t, err)
+	create, err := system.DecodeInstruction(instructions[0].Accounts(), data)
+	require.NoError(t, err)
+
+	require.Equal(t, ProgramID, instructions[1].ProgramID())
+	data, err = instructions[1].Data()
+	require.NoError(t, err)
+	decoded, err := DecodeInstruction(instructions[1].Accounts(), data)
+	require.NoError(t, err)
+	initialize, ok := decoded.Impl.(*InitializeAccount)
+	require.True(t, ok)
+	require.Equal(t, account, initialize.GetAccount().PublicKey)
+	require.Equal(t, mint, initialize.GetMintAccount().PublicKey)
+	require.Equal(t, owner, initialize.GetOwnerAccount().PublicKey)
+	require.Equal(t, ag_solanago.SysVarRentPubkey, initialize.GetSysVarRentPubkeyAccount().PublicKey)
+	return create
+}
+
+func TestCreateTokenAccountInstructions(t *testing.T) {
+	payer := ag_solanago.NewWallet().PublicKey()
+	account := ag_solanago.NewWallet().PublicKey()
+	mint := ag_solanago.NewWallet().PublicKey()
+	owner := ag_solanago.NewWallet().PublicKey()
+
+	instructions := CreateTokenAccountInstructions(payer, account, mint, owner, 2039280)
+	decoded := requireCreateTokenAccount(t, instructions, account, mint, owner)
+
+	create, ok := decoded.Impl.(*system.CreateAccount)
+	require.True(t, ok)
+	require.Equal(t, uint64(2039280), *create.Lamports)
+	require.Equal(t, uint64(ACCOUNT_SIZE), *create.Space)
+	require.Equal(t, ProgramID, *create.Owner)
+	require.Equal(t, ag_solanago.Meta(payer).WRITE().SIGNER(), create.GetFundingAccount())
+	require.Equal(t, ag_solanago.Meta(account).WRITE().SIGNER(), create.GetNewAccount())
+
+	// Both the payer and the new account sign.
+	tx, err := ag_solanago.NewTransaction(instructions, ag_solanago.Hash{}, ag_solanago.TransactionPayer(payer))
+	require.NoError(t, err)
+	require.Equal(t, uint8(2), tx.Message.Header.NumRequiredSignatures)
+	require.Equal(t, []ag_solanago.PublicKey{payer, account}, tx.Message.AccountKeys[:2])
+}
+
+func TestCreateTokenAccountWithSeedInstructions(t *testing.T) {
+	payer := ag_solanago.NewWallet().PublicKey()
+	mint := ag_solanago.NewWallet().PublicKey()
+	owner := ag_solanago.NewWallet().PublicKey()
+
+	instructions, account, err := CreateTokenAccountWithSeedInstructions(payer, payer, "usdc", mint, owner, 2039280)
+	require.NoError(t, err)
+	expected, err := ag_solanago.CreateWithSeed(payer, "usdc", ProgramID)
+	require.NoError(t, err)
+	require.Equal(t, expected, account)
+	decoded := requireCreateTokenAccount(t, instructions, account, mint, owner)
+
+	create, ok := decoded.Impl.(*system.CreateAccountWithSeed)
+	require.True(t, ok)
+	require.Equal(t, payer, *create.Base)
+	require.Equal(t, "usdc", *create.Seed)
+	require.Equal(t, uint64(2039280), *create.Lamports)
+	require.Equal(t, uint64(ACCOUNT_SIZE), *create.Space)
+	require.Equal(t, ProgramID, *create.Owner)
+	require.Equal(t, account, create.GetCreatedAccount().PublicKey)
+
+	// Only the payer (the base) signs.
+	tx, err := ag_solanago.NewTransaction(instructions, ag_solanago.Hash{}, ag_solanago.TransactionPayer(payer))
+	require.NoError(t, err)
+	require.Equal(t, uint8(1), tx.Message.Header.NumRequiredSignatures)
+
+	_, _, err = CreateTokenAccountWithSeedInstructions(payer, payer, string(make([]byte, 33)), mint, owner, 2039280)
+	require.Error(t, err)
+}
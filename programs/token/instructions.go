@@ -36,6 +36,7 @@ var ProgramID ag_solanago.PublicKey = ag_solanago.TokenProgramID
 func SetProgramID(pubkey ag_solanago.PublicKey) {
 	ProgramID = pubkey
 	ag_solanago.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+	ag_solanago.RegisterAccountDecoder(ProgramID, registryDecodeAccount)
 }
 
 const ProgramName = "Token"
@@ -43,6 +44,7 @@ const ProgramName = "Token"
 func init() {
 	if !ProgramID.IsZero() {
 		ag_solanago.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+		ag_solanago.RegisterAccountDecoder(ProgramID, registryDecodeAccount)
 	}
 }
 
@@ -237,6 +239,15 @@ func (inst *Instruction) EncodeToTree(parent ag_treeout.Branches) {
 	}
 }
 
+// Summary returns the summary of the instruction (see ag_solanago.Summarizer),
+// or an empty string if the instruction has no summary.
+func (inst *Instruction) Summary(ctx *ag_solanago.SummaryContext) string {
+	if summarizer, ok := inst.Impl.(ag_solanago.Summarizer); ok {
+		return summarizer.Summary(ctx)
+	}
+	return ""
+}
+
 var InstructionImplDef = ag_binary.NewVariantDefinition(
 	ag_binary.Uint8TypeIDEncoding,
 	[]ag_binary.VariantType{
@@ -306,6 +317,8 @@ var InstructionImplDef = ag_binary.NewVariantDefinition(
 	},
 )
 
+var _ ag_solanago.Instruction = &Instruction{}
+
 func (inst *Instruction) ProgramID() ag_solanago.PublicKey {
 	return ProgramID
 }
@@ -0,0 +1,264 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// Signer signs transactions on behalf of a public key; a solana.PrivateKey is a Signer.
+type Signer interface {
+	PublicKey() ag_solanago.PublicKey
+	Sign(payload []byte) (ag_solanago.Signature, error)
+}
+
+// ValidateMultisig checks that an M-of-N multisig is valid for the token program:
+// 1 <= M <= N <= MAX_SIGNERS, without duplicate signers.
+func ValidateMultisig(m int, signers []ag_solanago.PublicKey) error {
+	n := len(signers)
+	switch {
+	case n == 0:
+		return fmt.Errorf("multisig has no signers")
+	case n > MAX_SIGNERS:
+		return fmt.Errorf("multisig has %d signers, the maximum is %d", n, MAX_SIGNERS)
+	case m < 1:
+		return fmt.Errorf("multisig requires %d signatures, the minimum is 1", m)
+	case m > n:
+		return fmt.Errorf("multisig requires %d signatures, but has only %d signers", m, n)
+	}
+	seen := make(map[ag_solanago.PublicKey]struct{}, n)
+	for _, signer := range signers {
+		if _, ok := seen[signer]; ok {
+			return fmt.Errorf("duplicate multisig signer %s", signer)
+		}
+		seen[signer] = struct{}{}
+	}
+	return nil
+}
+
+// MultisigMintWorkflow creates a mint whose mint authority is an M-of-N multisig
+// account (e.g. a 2-of-3 treasury), and mints tokens with M of the signers.
+type MultisigMintWorkflow struct {
+	// The multisig account, the mint authority.
+	Multisig ag_solanago.PublicKey
+	// The mint account.
+	Mint ag_solanago.PublicKey
+	// The number of signatures required to mint.
+	M uint8
+	// The N signers of the multisig.
+	Signers []ag_solanago.PublicKey
+	// Number of base 10 digits to the right of the decimal place.
+	Decimals uint8
+	// The freeze authority of the mint (e.g. the multisig), if any.
+	FreezeAuthority *ag_solanago.PublicKey
+}
+
+// NewMultisigMintWorkflow returns the workflow of the mint governed
+// by the M-of-N multisig; see ValidateMultisig.
+func NewMultisigMintWorkflow(
+	multisig ag_solanago.PublicKey,
+	mint ag_solanago.PublicKey,
+	m uint8,
+	signers []ag_solanago.PublicKey,
+	decimals uint8,
+) (*MultisigMintWorkflow, error) {
+	if err := ValidateMultisig(int(m), signers); err != nil {
+		return nil, err
+	}
+	return &MultisigMintWorkflow{
+		Multisig: multisig,
+		Mint:     mint,
+		M:        m,
+		Signers:  append([]ag_solanago.PublicKey{}, signers...),
+		Decimals: decimals,
+	}, nil
+}
+
+// CreateInstructions returns the instructions that create and initialize
+// the multisig account (of MULTISIG_SIZE bytes, with multisigRentLamports),
+// and then the mint (of MINT_SIZE bytes, with mintRentLamports), in this order;
+// both accounts are funded by the payer.
+// The payer, the multisig account and the mint account must sign the transaction
+// (but not the signers of the multisig).
+//
+// The rent lamports should be the minimum balances for rent exemption
+// (see rpc.Client.GetMinimumBalanceForRentExemption, or sysvar.Rent.MinimumBalance).
+func (w *MultisigMintWorkflow) CreateInstructions(
+	payer ag_solanago.PublicKey,
+	multisigRentLamports uint64,
+	mintRentLamports uint64,
+) ([]ag_solanago.Instruction, error) {
+	if err := ValidateMultisig(int(w.M), w.Signers); err != nil {
+		return nil, err
+	}
+
+	initializeMultisig := NewInitializeMultisigInstructionBuilder().
+		SetM(w.M).
+		SetAccount(w.Multisig)
+	// The signers of the multisig are only registered: they don't sign its creation.
+	for _, signer := range w.Signers {
+		initializeMultisig.Signers = append(initializeMultisig.Signers, ag_solanago.Meta(signer))
+	}
+
+	initializeMint := NewInitializeMintInstructionBuilder().
+		SetDecimals(w.Decimals).
+		SetMintAuthority(w.Multisig).
+		SetMintAccount(w.Mint)
+	if w.FreezeAuthority != nil {
+		initializeMint.SetFreezeAuthority(*w.FreezeAuthority)
+	}
+
+	return []ag_solanago.Instruction{
+		system.NewCreateAccountInstruction(
+			multisigRentLamports,
+			MULTISIG_SIZE,
+			ProgramID,
+			payer,
+			w.Multisig,
+		).Build(),
+		initializeMultisig.Build(),
+		system.NewCreateAccountInstruction(
+			mintRentLamports,
+			MINT_SIZE,
+			ProgramID,
+			payer,
+			w.Mint,
+		).Build(),
+		initializeMint.Build(),
+	}, nil
+}
+
+// MintToInstruction returns the MintTo instruction minting the amount to the destination
+// token account, signed by (at least M) signers of the multisig. Its accounts are the mint,
+// the destination, the multisig (which doesn't sign) and then the signers, in the given order.
+func (w *MultisigMintWorkflow) MintToInstruction(
+	destination ag_solanago.PublicKey,
+	amount uint64,
+	signers ...ag_solanago.PublicKey,
+) (*Instruction, error) {
+	if err := w.checkMintSigners(signers); err != nil {
+		return nil, err
+	}
+	return NewMintToInstructionBuilder().
+		SetAmount(amount).
+		SetMintAccount(w.Mint).
+		SetDestinationAccount(destination).
+		SetAuthorityAccount(w.Multisig, signers...).
+		ValidateAndBuild()
+}
+
+// checkMintSigners checks that the signers are at least M distinct signers of the multisig.
+func (w *MultisigMintWorkflow) checkMintSigners(signers []ag_solanago.PublicKey) error {
+	members := make(map[ag_solanago.PublicKey]struct{}, len(w.Signers))
+	for _, signer := range w.Signers {
+		members[signer] = struct{}{}
+	}
+	seen := make(map[ag_solanago.PublicKey]struct{}, len(signers))
+	for _, signer := range signers {
+		if _, ok := members[signer]; !ok {
+			return fmt.Errorf("%s is not a signer of multisig %s", signer, w.Multisig)
+		}
+		if _, ok := seen[signer]; ok {
+			return fmt.Errorf("duplicate signer %s", signer)
+		}
+		seen[signer] = struct{}{}
+	}
+	if len(signers) < int(w.M) {
+		return fmt.Errorf("multisig %s requires %d signatures, got %d", w.Multisig, w.M, len(signers))
+	}
+	return nil
+}
+
+// CreateTransaction returns the transaction of CreateInstructions, paid and signed by the payer,
+// and signed by the new multisig and mint accounts.
+func (w *MultisigMintWorkflow) CreateTransaction(
+	recentBlockhash ag_solanago.Hash,
+	payer Signer,
+	multisigAccount Signer,
+	mintAccount Signer,
+	multisigRentLamports uint64,
+	mintRentLamports uint64,
+) (*ag_solanago.Transaction, error) {
+	if !multisigAccount.PublicKey().Equals(w.Multisig) {
+		return nil, fmt.Errorf("multisig account %s is not %s", multisigAccount.PublicKey(), w.Multisig)
+	}
+	if !mintAccount.PublicKey().Equals(w.Mint) {
+		return nil, fmt.Errorf("mint account %s is not %s", mintAccount.PublicKey(), w.Mint)
+	}
+	instructions, err := w.CreateInstructions(payer.PublicKey(), multisigRentLamports, mintRentLamports)
+	if err != nil {
+		return nil, err
+	}
+	return newSignedTransaction(instructions, recentBlockhash, payer, multisigAccount, mintAccount)
+}
+
+// MintToTransaction returns the transaction of MintToInstruction, paid and signed by the payer,
+// and signed by the signers of the multisig.
+func (w *MultisigMintWorkflow) MintToTransaction(
+	recentBlockhash ag_solanago.Hash,
+	payer Signer,
+	destination ag_solanago.PublicKey,
+	amount uint64,
+	signers ...Signer,
+) (*ag_solanago.Transaction, error) {
+	keys := make([]ag_solanago.PublicKey, len(signers))
+	for i, signer := range signers {
+		keys[i] = signer.PublicKey()
+	}
+	instruction, err := w.MintToInstruction(destination, amount, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return newSignedTransaction([]ag_solanago.Instruction{instruction}, recentBlockhash, payer, signers...)
+}
+
+// newSignedTransaction returns the transaction of the instructions paid by the payer,
+// signed by the payer and the signers, which must be all its required signers.
+func newSignedTransaction(
+	instructions []ag_solanago.Instruction,
+	recentBlockhash ag_solanago.Hash,
+	payer Signer,
+	signers ...Signer,
+) (*ag_solanago.Transaction, error) {
+	tx, err := ag_solanago.NewTransaction(instructions, recentBlockhash, ag_solanago.TransactionPayer(payer.PublicKey()))
+	if err != nil {
+		return nil, err
+	}
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode message for signing: %w", err)
+	}
+
+	byKey := map[ag_solanago.PublicKey]Signer{payer.PublicKey(): payer}
+	for _, signer := range signers {
+		byKey[signer.PublicKey()] = signer
+	}
+	numRequired := int(tx.Message.Header.NumRequiredSignatures)
+	tx.Signatures = make([]ag_solanago.Signature, numRequired)
+	for i, key := range tx.Message.AccountKeys[:numRequired] {
+		signer, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ag_solanago.ErrMissingSignatures, key)
+		}
+		tx.Signatures[i], err = signer.Sign(message)
+		if err != nil {
+			return nil, fmt.Errorf("unable to sign with %s: %w", key, err)
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeys(n int) []ag_solanago.PrivateKey {
+	keys := make([]ag_solanago.PrivateKey, n)
+	for i := range keys {
+		keys[i] = ag_solanago.NewWallet().PrivateKey
+	}
+	return keys
+}
+
+func publicKeys(keys []ag_solanago.PrivateKey) []ag_solanago.PublicKey {
+	out := make([]ag_solanago.PublicKey, len(keys))
+	for i, key := range keys {
+		out[i] = key.PublicKey()
+	}
+	return out
+}
+
+func TestValidateMultisig(t *testing.T) {
+	signers := publicKeys(newTestKeys(MAX_SIGNERS + 1))
+
+	require.NoError(t, ValidateMultisig(2, signers[:3]))
+	require.NoError(t, ValidateMultisig(1, signers[:1]))
+	require.NoError(t, ValidateMultisig(MAX_SIGNERS, signers[:MAX_SIGNERS]))
+
+	assert.EqualError(t, ValidateMultisig(4, signers[:3]), "multisig requires 4 signatures, but has only 3 signers")
+	assert.EqualError(t, ValidateMultisig(2, signers), "multisig has 12 signers, the maximum is 11")
+	assert.EqualError(t, ValidateMultisig(0, signers[:3]), "multisig requires 0 signatures, the minimum is 1")
+	assert.EqualError(t, ValidateMultisig(1, nil), "multisig has no signers")
+	assert.EqualError(t,
+		ValidateMultisig(2, []ag_solanago.PublicKey{signers[0], signers[1], signers[0]}),
+		"duplicate multisig signer "+signers[0].String(),
+	)
+
+	_, err := NewMultisigMintWorkflow(signers[0], signers[1], 3, signers[:2], 6)
+	require.Error(t, err)
+}
+
+func TestMultisigMintWorkflow(t *testing.T) {
+	payer := ag_solanago.NewWallet().PrivateKey
+	multisig := ag_solanago.NewWallet().PrivateKey
+	mint := ag_solanago.NewWallet().PrivateKey
+	destination := ag_solanago.NewWallet().PublicKey()
+	keys := newTestKeys(3)
+	signers := publicKeys(keys)
+
+	w, err := NewMultisigMintWorkflow(multisig.PublicKey(), mint.PublicKey(), 2, signers, 6)
+	require.NoError(t, err)
+
+	t.Run("create", func(t *testing.T) {
+		instructions, err := w.CreateInstructions(payer.PublicKey(), 3730560, 1461600)
+		require.NoError(t, err)
+		require.Len(t, instructions, 4)
+
+		decodeSystem := func(instruction ag_solanago.Instruction) *system.CreateAccount {
+			require.Equal(t, system.ProgramID, instruction.ProgramID())
+			data, err := instruction.Data()
+			require.NoError(t, err)
+			decoded, err := system.DecodeInstruction(instruction.Accounts(), data)
+			require.NoError(t, err)
+			create, ok := decoded.Impl.(*system.CreateAccount)
+			require.True(t, ok)
+			return create
+		}
+		decodeToken := func(instruction ag_solanago.Instruction) interface{} {
+			require.Equal(t, ProgramID, instruction.ProgramID())
+			data, err := instruction.Data()
+			require.NoError(t, err)
+			decoded, err := DecodeInstruction(instruction.Accounts(), data)
+			require.NoError(t, err)
+			return decoded.Impl
+		}
+
+		createMultisig := decodeSystem(instructions[0])
+		assert.Equal(t, uint64(MULTISIG_SIZE), *createMultisig.Space)
+		assert.Equal(t, uint64(3730560), *createMultisig.Lamports)
+		assert.Equal(t, multisig.PublicKey(), createMultisig.GetNewAccount().PublicKey)
+
+		initializeMultisig, ok := decodeToken(instructions[1]).(*InitializeMultisig)
+		require.True(t, ok)
+		assert.Equal(t, uint8(2), *initializeMultisig.M)
+		assert.Equal(t, ag_solanago.Meta(multisig.PublicKey()).WRITE(), initializeMultisig.GetAccount())
+		assert.Equal(t, ag_solanago.AccountMetaSlice{
+			ag_solanago.Meta(signers[0]),
+			ag_solanago.Meta(signers[1]),
+			ag_solanago.Meta(signers[2]),
+		}, initializeMultisig.Signers)
+
+		createMint := decodeSystem(instructions[2])
+		assert.Equal(t, uint64(MINT_SIZE), *createMint.Space)
+		assert.Equal(t, uint64(1461600), *createMint.Lamports)
+		assert.Equal(t, mint.PublicKey(), createMint.GetNewAccount().PublicKey)
+
+		initializeMint, ok := decodeToken(instructions[3]).(*InitializeMint)
+		require.True(t, ok)
+		assert.Equal(t, uint8(6), *initializeMint.Decimals)
+		assert.Equal(t, multisig.PublicKey(), *initializeMint.MintAuthority)
+		assert.Nil(t, initializeMint.FreezeAuthority)
+		assert.Equal(t, mint.PublicKey(), initializeMint.GetMintAccount().PublicKey)
+
+		// Only the payer and the new accounts sign.
+		tx, err := w.CreateTransaction(ag_solanago.Hash{1}, payer, multisig, mint, 3730560, 1461600)
+		require.NoError(t, err)
+		require.Equal(t, uint8(3), tx.Message.Header.NumRequiredSignatures)
+		assert.Equal(t,
+			[]ag_solanago.PublicKey{payer.PublicKey(), multisig.PublicKey(), mint.PublicKey()},
+			[]ag_solanago.PublicKey(tx.Message.AccountKeys[:3]),
+		)
+		require.NoError(t, tx.VerifySignatures())
+
+		_, err = w.CreateTransaction(ag_solanago.Hash{1}, payer, mint, multisig, 3730560, 1461600)
+		require.Error(t, err)
+	})
+
+	t.Run("mint to", func(t *testing.T) {
+		instruction, err := w.MintToInstruction(destination, 1000, signers[2], signers[0])
+		require.NoError(t, err)
+		// The mint, the destination, the multisig (not a signer), and then the signers.
+		assert.Equal(t, []*ag_solanago.AccountMeta{
+			ag_solanago.Meta(mint.PublicKey()).WRITE(),
+			ag_solanago.Meta(destination).WRITE(),
+			ag_solanago.Meta(multisig.PublicKey()),
+			ag_solanago.Meta(signers[2]).SIGNER(),
+			ag_solanago.Meta(signers[0]).SIGNER(),
+		}, instruction.Accounts())
+
+		tx, err := w.MintToTransaction(ag_solanago.Hash{1}, payer, destination, 1000, keys[2], keys[0])
+		require.NoError(t, err)
+		require.Equal(t, uint8(3), tx.Message.Header.NumRequiredSignatures)
+		require.NoError(t, tx.VerifySignatures())
+		assert.Equal(t, payer.PublicKey(), tx.Message.AccountKeys[0])
+		assert.NotContains(t, tx.Message.AccountKeys[:3], multisig.PublicKey())
+
+		_, err = w.MintToInstruction(destination, 1000, signers[0])
+		assert.EqualError(t, err, "multisig "+multisig.PublicKey().String()+" requires 2 signatures, got 1")
+		_, err = w.MintToInstruction(destination, 1000, signers[0], signers[0])
+		assert.EqualError(t, err, "duplicate signer "+signers[0].String())
+		_, err = w.MintToInstruction(destination, 1000, signers[0], payer.PublicKey())
+		assert.EqualError(t, err, payer.PublicKey().String()+" is not a signer of multisig "+multisig.PublicKey().String())
+
+		// Not enough signers to sign the transaction.
+		_, err = w.MintToTransaction(ag_solanago.Hash{1}, payer, destination, 1000, keys[0])
+		require.Error(t, err)
+	})
+}
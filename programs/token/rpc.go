@@ -22,10 +22,15 @@ import (
 	"fmt"
 
 	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
-const MINT_SIZE = 82
+const (
+	MINT_SIZE     = 82
+	ACCOUNT_SIZE  = 165
+	MULTISIG_SIZE = 355
+)
 
 func (mint *Mint) Decode(data []byte) error {
 	mint = new(Mint)
@@ -67,3 +72,33 @@ func FetchMints(ctx context.Context, rpcCli *rpc.Client) (out []*Mint, err error
 	}
 	return
 }
+
+// FetchMint fetches and decodes the mint; an error is returned
+// if the account is not a mint of the token program.
+func FetchMint(ctx context.Context, rpcCli *rpc.Client, mintAddress solana.PublicKey) (*Mint, error) {
+	out := new(Mint)
+	size := MINT_SIZE
+	err := rpcCli.GetAccountDataIntoWithOpts(ctx, mintAddress, out, &rpc.GetAccountDataOpts{
+		ExpectedOwner:   &ProgramID,
+		ExpectedDataLen: &size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch mint: %w", err)
+	}
+	return out, nil
+}
+
+// FetchAccount fetches and decodes the token account; an error is returned
+// if the account is not a token account of the token program.
+func FetchAccount(ctx context.Context, rpcCli *rpc.Client, accountAddress solana.PublicKey) (*Account, error) {
+	out := new(Account)
+	size := ACCOUNT_SIZE
+	err := rpcCli.GetAccountDataIntoWithOpts(ctx, accountAddress, out, &rpc.GetAccountDataOpts{
+		ExpectedOwner:   &ProgramID,
+		ExpectedDataLen: &size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch token account: %w", err)
+	}
+	return out, nil
+}
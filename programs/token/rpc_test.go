@@ -0,0 +1,228 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAccount serves the account with the data and the owner for all the getAccountInfo requests.
+func mockAccount(t *testing.T, data []byte, owner solana.PublicKey) *rpc.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":0,"result":{"context":{"slot":1},"value":{"data":[%q,"base64"],"executable":false,"lamports":2039280,"owner":%q,"rentEpoch":1}}}`,
+			base64.StdEncoding.EncodeToString(data),
+			owner,
+		)
+	}))
+	t.Cleanup(server.Close)
+	return rpc.New(server.URL)
+}
+
+func TestFetchAccount(t *testing.T) {
+	mint := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	owner := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	data, err := bin.MarshalBin(&Account{
+		Mint:   mint,
+		Owner:  owner,
+		Amount: 42,
+		State:  Initialized,
+	})
+	require.NoError(t, err)
+	require.Len(t, data, ACCOUNT_SIZE)
+	address := solana.MustPublicKeyFromBase58("9DRhhoLGCCZTqPEwfQYjStfGAFQGpRHZgPjLNbXiK7an")
+
+	t.Run("token account", func(t *testing.T) {
+		account, err := FetchAccount(context.Background(), mockAccount(t, data, ProgramID), address)
+		require.NoError(t, err)
+		assert.Equal(t, mint, account.Mint)
+		assert.Equal(t, owner, account.Owner)
+		assert.Equal(t, uint64(42), account.Amount)
+	})
+
+	t.Run("token account fetched as a mint", func(t *testing.T) {
+		_, err := FetchMint(context.Background(), mockAccount(t, data, ProgramID), address)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected 82 bytes, got 165")
+	})
+
+	t.Run("wrong owner", func(t *testing.T) {
+		_, err := FetchAccount(context.Background(), mockAccount(t, data, solana.SystemProgramID), address)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("expected %s, got %s", ProgramID, solana.SystemProgramID))
+	})
+}
+
+func TestFetchMint(t *testing.T) {
+	data, err := bin.MarshalBin(&Mint{
+		Supply:        1890000009537801,
+		Decimals:      6,
+		IsInitialized: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, data, MINT_SIZE)
+	address := solana.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn")
+
+	mint, err := FetchMint(context.Background(), mockAccount(t, data, ProgramID), address)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(6), mint.Decimals)
+	assert.Equal(t, uint64(1890000009537801), mint.Supply)
+
+	_, err = FetchAccount(context.Background(), mockAccount(t, data, ProgramID), address)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 165 bytes, got 82")
+}
+
+func TestFetch_notFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":0,"result":{"context":{"slot":1},"value":null}}`)
+	}))
+	defer server.Close()
+	client := rpc.New(server.URL)
+	address := solana.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn")
+
+	_, err := FetchMint(context.Background(), client, address)
+	assert.True(t, errors.Is(err, rpc.ErrAccountNotFound), err)
+
+	_, err = FetchAccount(context.Background(), client, address)
+	assert.True(t, errors.Is(err, rpc.ErrAccountNotFound), err)
+}
+
+func TestDecodeEach(t *testing.T) {
+	mint := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	var results rpc.GetProgramAccountsResult
+	var expected []Account
+	for i := 0; i < 10; i++ {
+		account := Account{
+			Mint:   mint,
+			Owner:  solana.NewWallet().PublicKey(),
+			Amount: uint64(i),
+			State:  Initialized,
+		}
+		data, err := bin.MarshalBin(&account)
+		require.NoError(t, err)
+		results = append(results, &rpc.KeyedAccount{
+			Pubkey:  solana.NewWallet().PublicKey(),
+			Account: &rpc.Account{Owner: ProgramID, Data: rpc.DataBytesOrJSONFromBytes(data)},
+		})
+		expected = append(expected, account)
+	}
+	mintData, err := bin.MarshalBin(&Mint{Supply: 1000, Decimals: 9, IsInitialized: true})
+	require.NoError(t, err)
+	mintAddress := solana.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn")
+	results = append(results, &rpc.KeyedAccount{
+		Pubkey:  mintAddress,
+		Account: &rpc.Account{Owner: ProgramID, Data: rpc.DataBytesOrJSONFromBytes(mintData)},
+	})
+
+	t.Run("accounts", func(t *testing.T) {
+		var decoded []Account
+		err := rpc.DecodeEachWithOpts(results, new(Account), &rpc.DecodeEachOpts{ExactSize: true}, func(pubkey solana.PublicKey, val interface{}) error {
+			decoded = append(decoded, *val.(*Account))
+			return nil
+		})
+		assert.Equal(t, expected, decoded)
+
+		// The mint is too short to be a token account.
+		var eachErr *rpc.DecodeEachError
+		require.True(t, errors.As(err, &eachErr), err)
+		require.Len(t, eachErr.Errors, 1)
+		assert.Equal(t, mintAddress, eachErr.Errors[0].Pubkey)
+	})
+
+	t.Run("mints", func(t *testing.T) {
+		var decoded []Mint
+		err := rpc.DecodeEachWithOpts(results, new(Mint), &rpc.DecodeEachOpts{ExactSize: true}, func(pubkey solana.PublicKey, val interface{}) error {
+			assert.Equal(t, mintAddress, pubkey)
+			decoded = append(decoded, *val.(*Mint))
+			return nil
+		})
+		require.Len(t, decoded, 1)
+		assert.Equal(t, uint64(1000), decoded[0].Supply)
+		assert.Equal(t, uint8(9), decoded[0].Decimals)
+
+		// The token accounts are too long to be mints.
+		var eachErr *rpc.DecodeEachError
+		require.True(t, errors.As(err, &eachErr), err)
+		assert.Len(t, eachErr.Errors, 10)
+	})
+}
+
+// tokenAccountsFixture returns n token accounts, as returned by getProgramAccounts.
+func tokenAccountsFixture(b *testing.B, n int) rpc.GetProgramAccountsResult {
+	mint := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	owner := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	results := make(rpc.GetProgramAccountsResult, n)
+	for i := range results {
+		data, err := bin.MarshalBin(&Account{
+			Mint:   mint,
+			Owner:  owner,
+			Amount: uint64(i),
+			State:  Initialized,
+		})
+		require.NoError(b, err)
+		var pubkey solana.PublicKey
+		binary.LittleEndian.PutUint64(pubkey[:], uint64(i))
+		results[i] = &rpc.KeyedAccount{
+			Pubkey:  pubkey,
+			Account: &rpc.Account{Owner: ProgramID, Data: rpc.DataBytesOrJSONFromBytes(data)},
+		}
+	}
+	return results
+}
+
+func BenchmarkDecodeEach_naive(b *testing.B) {
+	results := tokenAccountsFixture(b, 50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var total uint64
+		for _, result := range results {
+			account := new(Account)
+			if err := bin.NewBinDecoder(result.Account.Data.GetBinary()).Decode(account); err != nil {
+				b.Fatal(err)
+			}
+			total += account.Amount
+		}
+	}
+}
+
+func BenchmarkDecodeEach(b *testing.B) {
+	results := tokenAccountsFixture(b, 50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var total uint64
+		err := rpc.DecodeEach(results, new(Account), func(pubkey solana.PublicKey, val interface{}) error {
+			total += val.(*Account).Amount
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_require "github.com/stretchr/testify/require"
+)
+
+func TestSummary_golden(t *testing.T) {
+	usdc := ag_solanago.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	source := ag_solanago.MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+	destination := ag_solanago.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	owner := ag_solanago.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+
+	withContext := &ag_solanago.SummaryContext{
+		ResolveToken: func(key ag_solanago.PublicKey) (ag_solanago.TokenInfo, bool) {
+			// The mint, or one of its token accounts.
+			if key.Equals(usdc) || key.Equals(source) {
+				return ag_solanago.TokenInfo{Decimals: 6, Symbol: "USDC"}, true
+			}
+			return ag_solanago.TokenInfo{}, false
+		},
+	}
+	instructions := []ag_solanago.Summarizer{
+		NewTransferInstruction(1_500_000, source, destination, owner, nil).Build(),
+		NewTransferCheckedInstruction(1_500_000, 6, source, usdc, destination, owner, nil).Build(),
+		NewMintToInstruction(2_000_000, usdc, destination, owner, nil).Build(),
+		NewBurnInstruction(250_000, source, usdc, owner, nil).Build(),
+		// No summary.
+		NewSyncNativeInstruction(source).Build(),
+		// Missing amount.
+		NewTransferInstructionBuilder().SetSourceAccount(source).SetDestinationAccount(destination).Build(),
+	}
+
+	var lines []string
+	for _, ctx := range []*ag_solanago.SummaryContext{nil, withContext} {
+		for _, inst := range instructions {
+			lines = append(lines, inst.Summary(ctx))
+		}
+	}
+
+	golden, err := ioutil.ReadFile("testdata/summaries.golden")
+	ag_require.NoError(t, err)
+	ag_require.Equal(t, string(golden), strings.Join(lines, "\n")+"\n")
+}
@@ -20,6 +20,7 @@ package tokenregistry
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/gagliardetto/solana-go/text"
@@ -43,7 +44,7 @@ func registryDecodeInstruction(accounts []*solana.AccountMeta, data []byte) (int
 func DecodeInstruction(accounts []*solana.AccountMeta, data []byte) (*Instruction, error) {
 	var inst Instruction
 	if err := bin.NewBinDecoder(data).Decode(&inst); err != nil {
-		return nil, fmt.Errorf("unable to decode instruction for serum program: %w", err)
+		return nil, fmt.Errorf("unable to decode instruction for token registry program: %w", err)
 	}
 
 	if v, ok := inst.Impl.(solana.AccountsSettable); ok {
@@ -57,22 +58,17 @@ func DecodeInstruction(accounts []*solana.AccountMeta, data []byte) (*Instructio
 }
 
 func NewRegisterTokenInstruction(logo Logo, name Name, symbol Symbol, website Website, tokenMetaKey, ownerKey, tokenKey solana.PublicKey) *Instruction {
-	return &Instruction{
-		BaseVariant: bin.BaseVariant{
-			TypeID: bin.TypeIDFromUint32(0, bin.LE),
-			Impl: &RegisterToken{
-				Logo:    logo,
-				Name:    name,
-				Website: website,
-				Symbol:  symbol,
-				Accounts: &RegisterTokenAccounts{
-					TokenMeta: &solana.AccountMeta{tokenMetaKey, false, true},
-					Owner:     &solana.AccountMeta{ownerKey, true, false},
-					Token:     &solana.AccountMeta{tokenKey, false, false},
-				},
-			},
+	return (&RegisterToken{
+		Logo:    logo,
+		Name:    name,
+		Website: website,
+		Symbol:  symbol,
+		Accounts: &RegisterTokenAccounts{
+			TokenMeta: &solana.AccountMeta{PublicKey: tokenMetaKey, IsSigner: false, IsWritable: true},
+			Owner:     &solana.AccountMeta{PublicKey: ownerKey, IsSigner: true, IsWritable: false},
+			Token:     &solana.AccountMeta{PublicKey: tokenKey, IsSigner: false, IsWritable: false},
 		},
-	}
+	}).Build()
 }
 
 type Instruction struct {
@@ -80,11 +76,15 @@ type Instruction struct {
 }
 
 var _ bin.EncoderDecoder = &Instruction{}
+var _ solana.Instruction = &Instruction{}
 
 func (i *Instruction) Accounts() (out []*solana.AccountMeta) {
 	switch i.TypeID {
 	case bin.TypeIDFromUint32(0, bin.LE):
 		accounts := i.Impl.(*RegisterToken).Accounts
+		if accounts == nil {
+			return nil
+		}
 		out = []*solana.AccountMeta{accounts.TokenMeta, accounts.Owner, accounts.Token}
 	}
 	return
@@ -137,8 +137,8 @@ type RegisterToken struct {
 }
 
 func (i *RegisterToken) SetAccounts(accounts []*solana.AccountMeta) error {
-	if len(accounts) < 9 {
-		return fmt.Errorf("insufficient account")
+	if len(accounts) < 3 {
+		return fmt.Errorf("insufficient accounts: expected 3, got %d", len(accounts))
 	}
 	i.Accounts = &RegisterTokenAccounts{
 		TokenMeta: accounts[0],
@@ -148,3 +148,38 @@ func (i *RegisterToken) SetAccounts(accounts []*solana.AccountMeta) error {
 
 	return nil
 }
+
+func (i *RegisterToken) Build() *Instruction {
+	return &Instruction{
+		BaseVariant: bin.BaseVariant{
+			TypeID: bin.TypeIDFromUint32(0, bin.LE),
+			Impl:   i,
+		},
+	}
+}
+
+// ValidateAndBuild validates the instruction accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (i *RegisterToken) ValidateAndBuild() (*Instruction, error) {
+	if err := i.Validate(); err != nil {
+		return nil, err
+	}
+	return i.Build(), nil
+}
+
+func (i *RegisterToken) Validate() error {
+	if i.Accounts == nil {
+		return errors.New("accounts are not set")
+	}
+	if i.Accounts.TokenMeta == nil {
+		return errors.New("accounts.TokenMeta is not set")
+	}
+	if i.Accounts.Owner == nil {
+		return errors.New("accounts.Owner is not set")
+	}
+	if i.Accounts.Token == nil {
+		return errors.New("accounts.Token is not set")
+	}
+	return nil
+}
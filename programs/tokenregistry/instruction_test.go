@@ -0,0 +1,53 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenregistry
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/instructiontest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterToken_instructiontest(t *testing.T) {
+	logo, err := LogoFromString("https://example.com/logo.png")
+	require.NoError(t, err)
+	name, err := NameFromString("Wrapped SOL")
+	require.NoError(t, err)
+	symbol, err := SymbolFromString("SOL")
+	require.NoError(t, err)
+	website, err := WebsiteFromString("https://solana.com")
+	require.NoError(t, err)
+
+	tokenMeta := solana.MustPublicKeyFromBase58("BxNVRzNr2dYpuEYoprJWTGiUs6X7X7u3XhbZi2vMXcE6")
+	owner := solana.MustPublicKeyFromBase58("7xKXtg2CW87d97TXJSDpbD5jBkheTqA83TZRuJosgAsU")
+	token := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+
+	instructiontest.Run(t, instructiontest.Battery{
+		New: func() instructiontest.Builder {
+			return NewRegisterTokenInstruction(logo, name, symbol, website, tokenMeta, owner, token).Impl.(*RegisterToken)
+		},
+		Build: func(b instructiontest.Builder) (solana.Instruction, error) {
+			return b.(*RegisterToken).ValidateAndBuild()
+		},
+		Unset: map[string]func(instructiontest.Builder){
+			"accounts are not set":          func(b instructiontest.Builder) { b.(*RegisterToken).Accounts = nil },
+			"accounts.TokenMeta is not set": func(b instructiontest.Builder) { b.(*RegisterToken).Accounts.TokenMeta = nil },
+			"accounts.Owner is not set":     func(b instructiontest.Builder) { b.(*RegisterToken).Accounts.Owner = nil },
+			"accounts.Token is not set":     func(b instructiontest.Builder) { b.(*RegisterToken).Accounts.Token = nil },
+		},
+	})
+}
@@ -114,7 +114,7 @@ func (inst *Withdraw) EncodeToTree(parent treeout.Branches) {
 
 					// Parameters of the instruction:
 					instructionBranch.Child("Params").ParentFunc(func(paramsBranch treeout.Branches) {
-						paramsBranch.Child(format.Param("Lamports", inst.Lamports))
+						paramsBranch.Child(format.LamportsParam("Lamports", *inst.Lamports))
 					})
 
 					// Accounts of the instruction:
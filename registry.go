@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
 	"sync"
 )
 
@@ -77,23 +78,53 @@ func (reg *decoderRegistry) RegisterIfNew(programID PublicKey, decoder Instructi
 	return true
 }
 
+// RegisterInstructionDecoder registers the decoder for the instructions
+// of the provided program.
+// Registering the same decoder more than once is allowed;
+// registering a different decoder for a program that already has one panics
+// (the panic message names both decoders), so that conflicts between packages
+// surface at init time instead of one decoder silently replacing the other.
 func RegisterInstructionDecoder(programID PublicKey, decoder InstructionDecoder) {
-	prev, has := instructionDecoderRegistry.Get(programID)
+	instructionDecoderRegistry.mu.Lock()
+	defer instructionDecoderRegistry.mu.Unlock()
+
+	prev, has := instructionDecoderRegistry.decoders[programID]
 	if has {
 		// If it's the same function, then OK (tollerate multiple calls with same params).
 		if isSameFunction(prev, decoder) {
 			return
 		}
 		// If it's another decoder for the same pubkey, then panic.
-		panic(fmt.Sprintf("unable to re-register instruction decoder for program %s", programID))
+		panic(fmt.Sprintf(
+			"unable to re-register instruction decoder for program %s: already registered %s, tried to register %s",
+			programID,
+			functionName(prev),
+			functionName(decoder),
+		))
 	}
-	instructionDecoderRegistry.RegisterIfNew(programID, decoder)
+	instructionDecoderRegistry.decoders[programID] = decoder
+}
+
+// HasInstructionDecoder returns true if an instruction decoder
+// is registered for the provided program.
+func HasInstructionDecoder(programID PublicKey) bool {
+	return instructionDecoderRegistry.Has(programID)
 }
 
 func isSameFunction(f1 interface{}, f2 interface{}) bool {
 	return reflect.ValueOf(f1).Pointer() == reflect.ValueOf(f2).Pointer()
 }
 
+// functionName returns the fully qualified name of the function
+// (e.g. "github.com/gagliardetto/solana-go/programs/token.registryDecodeInstruction").
+func functionName(f interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "<unknown>"
+	}
+	return fn.Name()
+}
+
 func DecodeInstruction(programID PublicKey, accounts []*AccountMeta, data []byte) (interface{}, error) {
 	decoder, found := instructionDecoderRegistry.Get(programID)
 	if !found {
@@ -101,3 +132,75 @@ func DecodeInstruction(programID PublicKey, accounts []*AccountMeta, data []byte
 	}
 	return decoder(accounts, data)
 }
+
+var ErrAccountDecoderNotFound = errors.New("account decoder not found")
+
+// AccountDecoder decodes the data of an account owned by a program.
+type AccountDecoder func(data []byte) (interface{}, error)
+
+var accountDecoderRegistry = newAccountDecoderRegistry()
+
+type accountDecoders struct {
+	mu       *sync.RWMutex
+	decoders map[PublicKey]AccountDecoder
+}
+
+func newAccountDecoderRegistry() *accountDecoders {
+	return &accountDecoders{
+		mu:       &sync.RWMutex{},
+		decoders: make(map[PublicKey]AccountDecoder),
+	}
+}
+
+func (reg *accountDecoders) Has(owner PublicKey) bool {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	_, ok := reg.decoders[owner]
+	return ok
+}
+
+func (reg *accountDecoders) Get(owner PublicKey) (AccountDecoder, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	decoder, ok := reg.decoders[owner]
+	return decoder, ok
+}
+
+func (reg *accountDecoders) RegisterIfNew(owner PublicKey, decoder AccountDecoder) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	_, ok := reg.decoders[owner]
+	if ok {
+		return false
+	}
+	reg.decoders[owner] = decoder
+	return true
+}
+
+// RegisterAccountDecoder registers the decoder for the accounts
+// owned by the provided program.
+func RegisterAccountDecoder(owner PublicKey, decoder AccountDecoder) {
+	prev, has := accountDecoderRegistry.Get(owner)
+	if has {
+		// If it's the same function, then OK (tollerate multiple calls with same params).
+		if isSameFunction(prev, decoder) {
+			return
+		}
+		// If it's another decoder for the same pubkey, then panic.
+		panic(fmt.Sprintf("unable to re-register account decoder for program %s", owner))
+	}
+	accountDecoderRegistry.RegisterIfNew(owner, decoder)
+}
+
+// DecodeAccount decodes the data of an account using the decoder
+// registered for the owner program of the account.
+func DecodeAccount(owner PublicKey, data []byte) (interface{}, error) {
+	decoder, found := accountDecoderRegistry.Get(owner)
+	if !found {
+		return nil, ErrAccountDecoderNotFound
+	}
+	return decoder(data)
+}
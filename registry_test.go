@@ -25,3 +25,54 @@ func TestRegisterInstructionDecoder(t *testing.T) {
 		RegisterInstructionDecoder(BPFLoaderProgramID, decoderAnother)
 	})
 }
+
+func TestRegisterAccountDecoder(t *testing.T) {
+	decoder := func(data []byte) (interface{}, error) {
+		return len(data), nil
+	}
+	decoderAnother := func(data []byte) (interface{}, error) {
+		return nil, nil
+	}
+
+	assert.NotPanics(t, func() {
+		RegisterAccountDecoder(BPFLoaderProgramID, decoder)
+	})
+	assert.NotPanics(t, func() {
+		RegisterAccountDecoder(BPFLoaderProgramID, decoder)
+	})
+	assert.Panics(t, func() {
+		RegisterAccountDecoder(BPFLoaderProgramID, decoderAnother)
+	})
+
+	decoded, err := DecodeAccount(BPFLoaderProgramID, []byte{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, decoded)
+
+	_, err = DecodeAccount(VoteProgramID, []byte{1, 2, 3})
+	assert.Equal(t, ErrAccountDecoderNotFound, err)
+}
+
+func TestHasInstructionDecoder(t *testing.T) {
+	// A random program, so that the test doesn't register
+	// a decoder for a real program in the global registry.
+	programID := NewWallet().PublicKey()
+	assert.False(t, HasInstructionDecoder(programID))
+
+	decoder := func(instructionAccounts []*AccountMeta, data []byte) (interface{}, error) {
+		return nil, nil
+	}
+	decoderAnother := func(instructionAccounts []*AccountMeta, data []byte) (interface{}, error) {
+		return nil, nil
+	}
+	RegisterInstructionDecoder(programID, decoder)
+	assert.True(t, HasInstructionDecoder(programID))
+
+	assert.PanicsWithValue(t,
+		"unable to re-register instruction decoder for program "+programID.String()+":"+
+			" already registered github.com/gagliardetto/solana-go.TestHasInstructionDecoder.func1,"+
+			" tried to register github.com/gagliardetto/solana-go.TestHasInstructionDecoder.func2",
+		func() {
+			RegisterInstructionDecoder(programID, decoderAnother)
+		},
+	)
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// GetMintTokenProgram returns the token program that owns the provided mint,
+// i.e. solana.TokenProgramID or solana.Token2022ProgramID.
+func (cl *Client) GetMintTokenProgram(
+	ctx context.Context,
+	mint solana.PublicKey,
+) (solana.PublicKey, error) {
+	account, err := cl.GetAccountInfo(ctx, mint)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("unable to get mint %s: %w", mint, err)
+	}
+	owner := account.Value.Owner
+	if !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return solana.PublicKey{}, fmt.Errorf("account %s is not a token mint: owned by %s", mint, owner)
+	}
+	return owner, nil
+}
+
+// FindAssociatedTokenAddress returns the associated token account address
+// of the wallet for the provided mint, and the token program that owns the mint
+// (fetched with GetMintTokenProgram), which is needed to derive the address.
+func (cl *Client) FindAssociatedTokenAddress(
+	ctx context.Context,
+	wallet solana.PublicKey,
+	mint solana.PublicKey,
+) (address solana.PublicKey, tokenProgram solana.PublicKey, err error) {
+	tokenProgram, err = cl.GetMintTokenProgram(ctx, mint)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, err
+	}
+	address, _, err = solana.FindAssociatedTokenAddressForProgram(wallet, mint, tokenProgram)
+	if err != nil {
+		return solana.PublicKey{}, solana.PublicKey{}, err
+	}
+	return address, tokenProgram, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// EachTransaction calls fn for each transaction of the block, in order.
+// It stops at the first error returned by fn, and returns it.
+func (res *GetBlockResult) EachTransaction(fn func(tx *TransactionWithMeta) error) error {
+	for i := range res.Transactions {
+		if err := fn(&res.Transactions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignatureList returns the signatures of the transactions of the block, in order.
+// With TransactionDetailsSignatures, they are the Signatures of the result;
+// with TransactionDetailsFull, they are the first signature of each transaction,
+// read without decoding the whole transactions (see solana.PeekTransaction).
+// It returns nil with TransactionDetailsNone, i.e. when the block has neither.
+func (res *GetBlockResult) SignatureList() ([]solana.Signature, error) {
+	if len(res.Transactions) == 0 {
+		return res.Signatures, nil
+	}
+	out := make([]solana.Signature, len(res.Transactions))
+	for i := range res.Transactions {
+		twm := &res.Transactions[i]
+		if twm.Transaction == nil {
+			return nil, fmt.Errorf("transaction %d is nil", i)
+		}
+		raw, err := twm.Transaction.GetBinaryOrErr()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		peek, err := solana.PeekTransaction(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the signature of transaction %d: %w", i, err)
+		}
+		out[i] = peek.Signature
+	}
+	return out, nil
+}
+
+// SignatureList returns the signatures of the transactions of the block, in order;
+// see GetBlockResult.SignatureList.
+func (res *GetConfirmedBlockResult) SignatureList() ([]solana.Signature, error) {
+	block := GetBlockResult{
+		Transactions: res.Transactions,
+		Signatures:   res.Signatures,
+	}
+	return block.SignatureList()
+}
+
+// DecodedInstruction is a top-level instruction of a transaction,
+// decoded with the decoder registered for its program (see solana.RegisterInstructionDecoder).
+type DecodedInstruction struct {
+	ProgramID solana.PublicKey
+	Accounts  []*solana.AccountMeta
+	Data      []byte
+	// The decoded instruction (e.g. a *token.Instruction);
+	// nil if it could not be decoded.
+	Decoded interface{}
+	// Why the instruction could not be decoded:
+	// solana.ErrInstructionDecoderNotFound if no decoder is registered for the program,
+	// or the error of the decoder.
+	Err error
+}
+
+// DecodedTransaction is a transaction of a block, with its decoded instructions.
+type DecodedTransaction struct {
+	// Position of the transaction in the block.
+	Index        int
+	Transaction  *solana.Transaction
+	Meta         *TransactionMeta
+	Version      TransactionVersion
+	Instructions []DecodedInstruction
+}
+
+type EachDecodedTransactionOpts struct {
+	// Also call fn for the vote transactions, which are skipped by default.
+	IncludeVoteTransactions bool
+}
+
+// EachDecodedTransaction decodes each transaction of the block and its instructions,
+// and calls fn for it, in order; the vote transactions are skipped unless
+// opts.IncludeVoteTransactions is set (opts can be nil).
+// The accounts loaded from address lookup tables are resolved from the metadata
+// of the transaction.
+// It stops at the first error (of decoding a transaction, or returned by fn), and returns it;
+// the instructions that cannot be decoded are not an error (see DecodedInstruction.Err).
+func (res *GetBlockResult) EachDecodedTransaction(
+	opts *EachDecodedTransactionOpts,
+	fn func(tx *DecodedTransaction) error,
+) error {
+	includeVotes := opts != nil && opts.IncludeVoteTransactions
+	for i := range res.Transactions {
+		twm := &res.Transactions[i]
+		tx, err := twm.decodeTransaction()
+		if err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if !includeVotes && isVoteTransaction(tx) {
+			continue
+		}
+		instructions, err := decodeInstructions(tx, twm.Meta)
+		if err != nil {
+			return fmt.Errorf("unable to decode instructions of transaction %d: %w", i, err)
+		}
+		err = fn(&DecodedTransaction{
+			Index:        i,
+			Transaction:  tx,
+			Meta:         twm.Meta,
+			Version:      twm.Version,
+			Instructions: instructions,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeInstructions decodes the transaction and its top-level instructions;
+// see EachDecodedTransaction.
+func (twm *TransactionWithMeta) DecodeInstructions() (*solana.Transaction, []DecodedInstruction, error) {
+	tx, err := twm.decodeTransaction()
+	if err != nil {
+		return nil, nil, err
+	}
+	instructions, err := decodeInstructions(tx, twm.Meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, instructions, nil
+}
+
+// GetResolvedTransaction decodes the transaction, and resolves the accounts it loads
+// from address lookup tables with the metadata: the account keys of its message
+// are the static keys, followed by the loaded writable and then readonly addresses.
+func (twm *TransactionWithMeta) GetResolvedTransaction() (*solana.Transaction, error) {
+	tx, err := twm.decodeTransaction()
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveLoadedAddresses(tx, twm.Meta); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// IsVote tells whether the transaction is a vote transaction:
+// all its instructions invoke the vote program.
+func (twm *TransactionWithMeta) IsVote() (bool, error) {
+	tx, err := twm.decodeTransaction()
+	if err != nil {
+		return false, err
+	}
+	return isVoteTransaction(tx), nil
+}
+
+func (twm *TransactionWithMeta) decodeTransaction() (*solana.Transaction, error) {
+	if twm.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	tx, err := twm.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction: %w", err)
+	}
+	return tx, nil
+}
+
+func isVoteTransaction(tx *solana.Transaction) bool {
+	if len(tx.Message.Instructions) == 0 {
+		return false
+	}
+	for _, inst := range tx.Message.Instructions {
+		// Programs can't be loaded from lookup tables.
+		if int(inst.ProgramIDIndex) >= len(tx.Message.AccountKeys) ||
+			!tx.Message.AccountKeys[inst.ProgramIDIndex].Equals(solana.VoteProgramID) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeInstructions(tx *solana.Transaction, meta *TransactionMeta) ([]DecodedInstruction, error) {
+	if err := resolveLoadedAddresses(tx, meta); err != nil {
+		return nil, err
+	}
+	resolved := resolvedAccounts(tx)
+	out := make([]DecodedInstruction, len(tx.Message.Instructions))
+	for i, inst := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(inst.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve program of instruction %d: %w", i, err)
+		}
+		accounts, err := accountMetas(resolved, inst.Accounts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve accounts of instruction %d: %w", i, err)
+		}
+		decoded, err := solana.DecodeInstruction(programID, accounts, inst.Data)
+		out[i] = DecodedInstruction{
+			ProgramID: programID,
+			Accounts:  accounts,
+			Data:      inst.Data,
+			Decoded:   decoded,
+			Err:       err,
+		}
+	}
+	return out, nil
+}
+
+// resolveLoadedAddresses resolves the address table lookups of the message
+// with the addresses loaded by the node, listed in the metadata
+// (see solana.Message.SetLoadedAddresses).
+func resolveLoadedAddresses(tx *solana.Transaction, meta *TransactionMeta) error {
+	if !tx.Message.IsVersioned() || tx.Message.GetAddressTableLookups().NumLookups() == 0 {
+		return nil
+	}
+	if meta == nil {
+		return fmt.Errorf("transaction uses address lookup tables, but has no metadata")
+	}
+	return tx.Message.SetLoadedAddresses(meta.LoadedAddresses.Writable, meta.LoadedAddresses.ReadOnly)
+}
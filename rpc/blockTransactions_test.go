@@ -0,0 +1,347 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type blockFixture struct {
+	json      string
+	payer     solana.PublicKey
+	recipient solana.PublicKey
+	table     solana.PublicKey
+}
+
+// newBlockFixture returns a block with a vote transaction, a legacy transfer,
+// and a v0 transfer to an account loaded from an address lookup table.
+func newBlockFixture(t *testing.T) *blockFixture {
+	payer := solana.NewWallet()
+	f := &blockFixture{
+		payer:     payer.PublicKey(),
+		recipient: solana.NewWallet().PublicKey(),
+		table:     solana.NewWallet().PublicKey(),
+	}
+	blockhash := solana.Hash{1, 2, 3}
+	other := solana.NewWallet().PublicKey()
+
+	vote, err := solana.NewTransaction(
+		[]solana.Instruction{
+			solana.NewInstruction(
+				solana.VoteProgramID,
+				solana.AccountMetaSlice{solana.Meta(other).WRITE(), solana.Meta(f.payer).SIGNER()},
+				[]byte{12, 0, 0, 0},
+			),
+		},
+		blockhash,
+		solana.TransactionPayer(f.payer),
+	)
+	require.NoError(t, err)
+	legacy, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1000, f.payer, f.recipient).Build(),
+		},
+		blockhash,
+		solana.TransactionPayer(f.payer),
+	)
+	require.NoError(t, err)
+	v0, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(2000, f.payer, f.recipient).Build(),
+		},
+		blockhash,
+		solana.TransactionPayer(f.payer),
+		solana.TransactionAddressTables(map[solana.PublicKey]solana.PublicKeySlice{
+			f.table: {other, f.recipient},
+		}),
+	)
+	require.NoError(t, err)
+
+	encode := func(tx *solana.Transaction, version string, loadedWritable ...solana.PublicKey) string {
+		_, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+			return &payer.PrivateKey
+		})
+		require.NoError(t, err)
+		loaded, err := stdjson.Marshal(LoadedAddresses{Writable: loadedWritable, ReadOnly: solana.PublicKeySlice{}})
+		require.NoError(t, err)
+		return fmt.Sprintf(
+			`{"meta":{"err":null,"fee":5000,"loadedAddresses":%s},"transaction":[%q,"base64"],"version":%s}`,
+			loaded, tx.MustToBase64(), version,
+		)
+	}
+	f.json = `{"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":9,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","transactions":[` +
+		strings.Join([]string{
+			encode(vote, `"legacy"`),
+			encode(legacy, `"legacy"`),
+			encode(v0, `0`, f.recipient),
+		}, ",") + `]}`
+	return f
+}
+
+func TestGetBlockResult_EachDecodedTransaction(t *testing.T) {
+	f := newBlockFixture(t)
+	var block GetBlockResult
+	require.NoError(t, stdjson.Unmarshal([]byte(f.json), &block))
+	require.Len(t, block.Transactions, 3)
+
+	var all []*TransactionWithMeta
+	require.NoError(t, block.EachTransaction(func(tx *TransactionWithMeta) error {
+		all = append(all, tx)
+		return nil
+	}))
+	require.Len(t, all, 3)
+	isVote, err := all[0].IsVote()
+	require.NoError(t, err)
+	assert.True(t, isVote)
+	isVote, err = all[1].IsVote()
+	require.NoError(t, err)
+	assert.False(t, isVote)
+
+	var decoded []*DecodedTransaction
+	require.NoError(t, block.EachDecodedTransaction(nil, func(tx *DecodedTransaction) error {
+		decoded = append(decoded, tx)
+		return nil
+	}))
+	require.Len(t, decoded, 2, "the vote is skipped")
+	for i, tx := range decoded {
+		assert.Equal(t, i+1, tx.Index)
+		require.Len(t, tx.Instructions, 1)
+		inst := tx.Instructions[0]
+		require.NoError(t, inst.Err)
+		assert.Equal(t, solana.SystemProgramID, inst.ProgramID)
+		transfer, ok := inst.Decoded.(*system.Instruction).Impl.(*system.Transfer)
+		require.True(t, ok)
+		assert.Equal(t, uint64((i+1)*1000), *transfer.Lamports)
+		assert.Equal(t, f.payer, transfer.GetFundingAccount().PublicKey)
+		// Also when loaded from the lookup table.
+		assert.Equal(t, f.recipient, transfer.GetRecipientAccount().PublicKey)
+		assert.True(t, transfer.GetRecipientAccount().IsWritable)
+	}
+	assert.Equal(t, TransactionVersion(0), decoded[1].Version)
+
+	t.Run("include votes", func(t *testing.T) {
+		var decoded []*DecodedTransaction
+		require.NoError(t, block.EachDecodedTransaction(
+			&EachDecodedTransactionOpts{IncludeVoteTransactions: true},
+			func(tx *DecodedTransaction) error {
+				decoded = append(decoded, tx)
+				return nil
+			},
+		))
+		require.Len(t, decoded, 3)
+		assert.Equal(t, solana.VoteProgramID, decoded[0].Instructions[0].ProgramID)
+	})
+	t.Run("error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := block.EachDecodedTransaction(nil, func(tx *DecodedTransaction) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+		err = block.EachTransaction(func(tx *TransactionWithMeta) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("missing loaded addresses", func(t *testing.T) {
+		tx := block.Transactions[2]
+		tx.Meta = &TransactionMeta{}
+		_, _, err := tx.DecodeInstructions()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "don't match the address table lookups")
+	})
+}
+
+func TestClient_GetBlockWithOpts_skipVoteTransactions(t *testing.T) {
+	f := newBlockFixture(t)
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(f.json)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetBlockWithOpts(context.Background(), 10, &GetBlockOpts{SkipVoteTransactions: true})
+	require.NoError(t, err)
+	// The option is not sent.
+	assert.Equal(t,
+		map[string]interface{}{
+			"encoding": string(solana.EncodingBase64),
+		},
+		server.RequestBody(t)["params"].([]interface{})[1],
+	)
+	require.Len(t, out.Transactions, 2)
+	for _, tx := range out.Transactions {
+		isVote, err := tx.IsVote()
+		require.NoError(t, err)
+		assert.False(t, isVote)
+	}
+	assert.Equal(t, uint64(9), out.ParentSlot)
+
+	var indexes []int
+	_, err = client.GetBlockStreaming(context.Background(), 10, &GetBlockOpts{SkipVoteTransactions: true}, func(idx int, tx *TransactionWithMeta) error {
+		indexes = append(indexes, idx)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, indexes)
+}
+
+func TestGetBlockResult_SignatureList(t *testing.T) {
+	f := newBlockFixture(t)
+	var full GetBlockResult
+	require.NoError(t, stdjson.Unmarshal([]byte(f.json), &full))
+	var expected []solana.Signature
+	for _, twm := range full.Transactions {
+		tx, err := twm.GetTransaction()
+		require.NoError(t, err)
+		expected = append(expected, tx.Signatures[0])
+	}
+
+	t.Run("full", func(t *testing.T) {
+		sigs, err := full.SignatureList()
+		require.NoError(t, err)
+		assert.Equal(t, expected, sigs)
+
+		confirmed := GetConfirmedBlockResult{Transactions: full.Transactions}
+		sigs, err = confirmed.SignatureList()
+		require.NoError(t, err)
+		assert.Equal(t, expected, sigs)
+	})
+
+	t.Run("signatures", func(t *testing.T) {
+		sigsJSON, err := stdjson.Marshal(expected)
+		require.NoError(t, err)
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(
+			`{"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":9,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","signatures":`+string(sigsJSON)+`}`,
+		)))
+		defer closer()
+		client := New(server.URL)
+
+		for _, opts := range []*GetBlockOpts{
+			{TransactionDetails: TransactionDetailsSignatures},
+			{TransactionDetails: TransactionDetailsSignatures, SkipVoteTransactions: true},
+		} {
+			out, err := client.GetBlockWithOpts(context.Background(), 10, opts)
+			require.NoError(t, err)
+			assert.Empty(t, out.Transactions)
+			assert.Equal(t, expected, out.Signatures)
+			sigs, err := out.SignatureList()
+			require.NoError(t, err)
+			assert.Equal(t, expected, sigs)
+			require.NoError(t, out.EachDecodedTransaction(nil, func(tx *DecodedTransaction) error {
+				t.Fatal("no transactions expected")
+				return nil
+			}))
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		var block GetBlockResult
+		require.NoError(t, stdjson.Unmarshal([]byte(`{"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":9}`), &block))
+		sigs, err := block.SignatureList()
+		require.NoError(t, err)
+		assert.Nil(t, sigs)
+	})
+
+	t.Run("no transaction body", func(t *testing.T) {
+		block := GetBlockResult{Transactions: []TransactionWithMeta{{Meta: &TransactionMeta{}}}}
+		_, err := block.SignatureList()
+		assert.EqualError(t, err, "transaction 0 is nil")
+		_, err = block.Transactions[0].GetTransaction()
+		assert.EqualError(t, err, "transaction is nil")
+	})
+}
+
+func TestGetTransactionResult_DecodeInstructions(t *testing.T) {
+	f := newBlockFixture(t)
+	var block struct {
+		Transactions []stdjson.RawMessage `json:"transactions"`
+	}
+	require.NoError(t, stdjson.Unmarshal([]byte(f.json), &block))
+	// The v0 transfer, as returned by getTransaction.
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(string(block.Transactions[2]))))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetTransaction(context.Background(), solana.Signature{}, nil)
+	require.NoError(t, err)
+
+	unresolved, err := out.Transaction.GetTransaction()
+	require.NoError(t, err)
+	tx, err := out.GetResolvedTransaction()
+	require.NoError(t, err)
+	// The static keys, followed by the loaded addresses.
+	assert.Equal(t,
+		append(solana.PublicKeySlice(unresolved.Message.AccountKeys), f.recipient),
+		solana.PublicKeySlice(tx.Message.AccountKeys),
+	)
+	accounts, err := tx.Message.Instructions[0].ResolveInstructionAccounts(&tx.Message)
+	require.NoError(t, err)
+	assert.Equal(t, []*solana.AccountMeta{
+		solana.Meta(f.payer).WRITE().SIGNER(),
+		solana.Meta(f.recipient).WRITE(),
+	}, accounts)
+
+	tx, instructions, err := out.DecodeInstructions()
+	require.NoError(t, err)
+	require.Len(t, tx.Message.AccountKeys, len(unresolved.Message.AccountKeys)+1)
+	require.Len(t, instructions, 1)
+	require.NoError(t, instructions[0].Err)
+	transfer, ok := instructions[0].Decoded.(*system.Instruction).Impl.(*system.Transfer)
+	require.True(t, ok)
+	assert.Equal(t, f.recipient, transfer.GetRecipientAccount().PublicKey)
+
+	t.Run("without metadata", func(t *testing.T) {
+		res := *out
+		res.Meta = nil
+		_, err := res.GetResolvedTransaction()
+		assert.EqualError(t, err, "transaction uses address lookup tables, but has no metadata")
+		_, err = (&GetTransactionResult{}).GetResolvedTransaction()
+		assert.EqualError(t, err, "transaction is nil")
+	})
+}
+
+func TestTransactionWithMeta_GetResolvedTransaction(t *testing.T) {
+	f := newBlockFixture(t)
+	var block GetBlockResult
+	require.NoError(t, stdjson.Unmarshal([]byte(f.json), &block))
+
+	for i, twm := range block.Transactions {
+		unresolved, err := twm.GetTransaction()
+		require.NoError(t, err)
+		tx, err := twm.GetResolvedTransaction()
+		require.NoError(t, err)
+		if i < 2 {
+			assert.Equal(t, unresolved.Message.AccountKeys, tx.Message.AccountKeys, "legacy")
+			continue
+		}
+		assert.Equal(t,
+			append(solana.PublicKeySlice(unresolved.Message.AccountKeys), f.recipient),
+			solana.PublicKeySlice(tx.Message.AccountKeys),
+		)
+	}
+}
@@ -23,6 +23,7 @@ func NewWithRateLimit(
 ) JSONRPCClient {
 	opts := &jsonrpc.RPCClientOpts{
 		HTTPClient: newHTTP(),
+		UserAgent:  DefaultUserAgent(),
 	}
 
 	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
@@ -25,6 +25,7 @@ func NewWithLimiter(
 ) JSONRPCClient {
 	opts := &jsonrpc.RPCClientOpts{
 		HTTPClient: newHTTP(),
+		UserAgent:  DefaultUserAgent(),
 	}
 
 	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
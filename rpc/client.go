@@ -20,9 +20,10 @@ package rpc
 import (
 	"context"
 	"errors"
-	"io"
+	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
@@ -30,13 +31,38 @@ import (
 )
 
 var ErrNotFound = errors.New("not found")
+
+// ErrAccountNotFound is returned when the requested account doesn't exist by:
+//   - GetAccountInfo and GetAccountInfoWithOpts;
+//   - GetAccountDataInto, GetAccountDataBorshInto, and their WithOpts variants;
+//   - the account fetchers of the program packages (e.g. token.FetchMint), wrapped.
+//
+// The methods that return the raw results keep the node's shape instead:
+// GetAccountInfoWithRpcContext returns a nil account, GetMultipleAccounts
+// has a nil entry for each missing account, and GetBalance returns zero.
+//
+// It wraps ErrNotFound, so errors.Is(err, ErrNotFound) also holds.
+var ErrAccountNotFound = fmt.Errorf("account %w", ErrNotFound)
 var ErrNotConfirmed = errors.New("not confirmed")
 
+// Client is safe for concurrent use by multiple goroutines,
+// as long as its JSONRPCClient is (the ones created by this package are).
+// The requests share a pool of HTTP connections: with the default HTTP client,
+// at most defaultMaxIdleConnsPerHost requests are in flight at the same time,
+// and the others wait for a free connection.
 type Client struct {
 	rpcURL    string
-	rpcClient JSONRPCClient
+	rpcClient *timeoutRPCClient
+
+	tokenDecimals *tokenDecimalsCache
+	priorityFees  *priorityFeeCache
+
+	hooksMu sync.RWMutex
+	hooks   Hooks
 }
 
+// JSONRPCClient sends the requests of a Client.
+// Implementations must be safe for concurrent use by multiple goroutines.
 type JSONRPCClient interface {
 	CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error
 	CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error
@@ -46,22 +72,42 @@ type JSONRPCClient interface {
 // New creates a new Solana JSON RPC client.
 // Client is safe for concurrent use by multiple goroutines.
 func New(rpcEndpoint string) *Client {
-	opts := &jsonrpc.RPCClientOpts{
-		HTTPClient: newHTTP(),
-	}
-
-	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
-	return NewWithCustomRPCClient(rpcClient)
+	return NewWithOptions(rpcEndpoint, nil)
 }
 
 // New creates a new Solana JSON RPC client with the provided custom headers.
 // The provided headers will be added to each RPC request sent via this RPC client.
 func NewWithHeaders(rpcEndpoint string, headers map[string]string) *Client {
-	opts := &jsonrpc.RPCClientOpts{
-		HTTPClient:    newHTTP(),
-		CustomHeaders: headers,
+	return NewWithOptions(rpcEndpoint, &ClientOptions{Headers: headers})
+}
+
+// ClientOptions configures the client created by NewWithOptions.
+type ClientOptions struct {
+	// The headers added to each request.
+	Headers map[string]string
+
+	// The User-Agent header of the requests, to identify the client
+	// to the RPC provider (default: DefaultUserAgent()).
+	// A User-Agent in Headers takes precedence, and so does the one set
+	// on the context of a request with WithUserAgent.
+	UserAgent string
+}
+
+// NewWithOptions creates a new Solana JSON RPC client with the provided options (which can be nil).
+// Client is safe for concurrent use by multiple goroutines.
+func NewWithOptions(rpcEndpoint string, opts *ClientOptions) *Client {
+	if opts == nil {
+		opts = &ClientOptions{}
+	}
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent()
 	}
-	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
+	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, &jsonrpc.RPCClientOpts{
+		HTTPClient:    newHTTP(),
+		CustomHeaders: opts.Headers,
+		UserAgent:     userAgent,
+	})
 	return NewWithCustomRPCClient(rpcClient)
 }
 
@@ -70,17 +116,16 @@ func (cl *Client) Close() error {
 	if cl.rpcClient == nil {
 		return nil
 	}
-	if c, ok := cl.rpcClient.(io.Closer); ok {
-		return c.Close()
-	}
-	return nil
+	return cl.rpcClient.Close()
 }
 
 // NewWithCustomRPCClient creates a new Solana RPC client
 // with the provided RPC client.
 func NewWithCustomRPCClient(rpcClient JSONRPCClient) *Client {
 	return &Client{
-		rpcClient: rpcClient,
+		rpcClient:     newTimeoutRPCClient(rpcClient),
+		tokenDecimals: newTokenDecimalsCache(DefaultTokenDecimalsCacheSize),
+		priorityFees:  newPriorityFeeCache(DefaultPriorityFeeCacheTTL),
 	}
 }
 
@@ -22,8 +22,17 @@ import (
 	"context"
 	"encoding/base64"
 	stdjson "encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	bin "github.com/gagliardetto/binary"
@@ -31,6 +40,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
 func TestClient_GetAccountInfo(t *testing.T) {
@@ -80,6 +92,72 @@ func TestClient_GetAccountInfo(t *testing.T) {
 		}, out)
 }
 
+func TestClient_accountNotFound(t *testing.T) {
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		switch method {
+		case "getMultipleAccounts":
+			return `"result":{"context":{"slot":1},"value":[null]}`
+		case "getBalance":
+			return `"result":{"context":{"slot":1},"value":0}`
+		default:
+			return `"result":{"context":{"slot":1},"value":null}`
+		}
+	})
+	defer closer()
+	client := New(server.URL)
+	ctx := context.Background()
+	missing := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+
+	require.ErrorIs(t, ErrAccountNotFound, ErrNotFound)
+
+	t.Run("sentinel", func(t *testing.T) {
+		var out struct{ Value uint64 }
+		owner := solana.SystemProgramID
+		for name, call := range map[string]func() error{
+			"GetAccountInfo": func() error {
+				_, err := client.GetAccountInfo(ctx, missing)
+				return err
+			},
+			"GetAccountInfoWithOpts": func() error {
+				_, err := client.GetAccountInfoWithOpts(ctx, missing, &GetAccountInfoOpts{Commitment: CommitmentFinalized})
+				return err
+			},
+			"GetAccountDataInto": func() error {
+				return client.GetAccountDataInto(ctx, missing, &out)
+			},
+			"GetAccountDataBorshInto": func() error {
+				return client.GetAccountDataBorshInto(ctx, missing, &out)
+			},
+			"GetAccountDataIntoWithOpts": func() error {
+				return client.GetAccountDataIntoWithOpts(ctx, missing, &out, &GetAccountDataOpts{ExpectedOwner: &owner})
+			},
+			"GetAccountDataBorshIntoWithOpts": func() error {
+				return client.GetAccountDataBorshIntoWithOpts(ctx, missing, &out, nil)
+			},
+		} {
+			err := call()
+			assert.True(t, errors.Is(err, ErrAccountNotFound), "%s: %v", name, err)
+			assert.True(t, errors.Is(err, ErrNotFound), name)
+		}
+	})
+
+	t.Run("raw results", func(t *testing.T) {
+		account, rpcCtx, err := client.GetAccountInfoWithRpcContext(ctx, missing, nil)
+		require.NoError(t, err)
+		assert.Nil(t, account)
+		assert.Equal(t, uint64(1), rpcCtx.Context.Slot)
+
+		accounts, err := client.GetMultipleAccounts(ctx, missing)
+		require.NoError(t, err)
+		require.Len(t, accounts.Value, 1)
+		assert.Nil(t, accounts.Value[0])
+
+		balance, err := client.GetBalance(ctx, missing, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), balance.Value)
+	})
+}
+
 func TestClient_GetAccountInfoWithOpts(t *testing.T) {
 	responseBody := `{"context":{"slot":83986105},"value":{"data":["dGVzdA==","base64"],"executable":true,"lamports":999999,"owner":"11111111111111111111111111111111","rentEpoch":207}}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -131,6 +209,34 @@ func TestClient_GetAccountInfoWithOpts(t *testing.T) {
 	)
 }
 
+func TestClient_GetAccountInfoWithOpts_defaultEncoding(t *testing.T) {
+	responseBody := `{"context":{"slot":83986105},"value":{"data":["dGVzdA==","base64"],"executable":false,"lamports":999999,"owner":"11111111111111111111111111111111","rentEpoch":207}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+	pubKey := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+
+	requestedOpts := func(opts *GetAccountInfoOpts) interface{} {
+		_, err := client.GetAccountInfoWithOpts(context.Background(), pubKey, opts)
+		require.NoError(t, err)
+		return server.RequestBody(t)["params"].([]interface{})[1]
+	}
+	assert.Equal(t,
+		map[string]interface{}{"encoding": "base64"},
+		requestedOpts(nil),
+	)
+	assert.Equal(t,
+		map[string]interface{}{"encoding": "base64", "commitment": string(CommitmentConfirmed)},
+		requestedOpts(&GetAccountInfoOpts{Commitment: CommitmentConfirmed}),
+	)
+	// An explicit encoding overrides the default.
+	assert.Equal(t,
+		map[string]interface{}{"encoding": "base58"},
+		requestedOpts(&GetAccountInfoOpts{Encoding: solana.EncodingBase58}),
+	)
+	assert.Equal(t, solana.EncodingBase64, DefaultAccountInfoEncoding)
+}
+
 func TestClient_GetConfirmedSignaturesForAddress2(t *testing.T) {
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","result":[{"err":null,"memo":null,"signature":"mgw5vw4tnbou1wVStKckVcVncbpRwfZPcMNbVBoigbSPXBMa3857CNzhwoCkRzM5K7nG32wcbpVJDHttQeBRaHB","slot":1}],"id":0}`))
 	defer closer()
@@ -279,6 +385,9 @@ func TestClient_GetRecentBlockhash(t *testing.T) {
 		server.RequestBody(t),
 	)
 
+	require.NotNil(t, out.Value.FeeCalculator)
+	assert.Equal(t, uint64(5000), out.Value.FeeCalculator.LamportsPerSignature)
+
 	expected := mustJSONToInterface([]byte(responseBody))
 
 	got := mustJSONToInterface(mustAnyToJSON(out))
@@ -286,6 +395,24 @@ func TestClient_GetRecentBlockhash(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_GetRecentBlockhash_withoutFeeCalculator(t *testing.T) {
+	// Newer nodes don't return the deprecated fee calculator.
+	responseBody := `{"context":{"slot":83986105},"value":{"blockhash":"DvLEyV2GHk86K5GojpqnRsvhfMF5kdZomKMnhVpvHyqK"}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+
+	client := New(server.URL)
+
+	out, err := client.GetRecentBlockhash(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, solana.MustHashFromBase58("DvLEyV2GHk86K5GojpqnRsvhfMF5kdZomKMnhVpvHyqK"), out.Value.Blockhash)
+	assert.Nil(t, out.Value.FeeCalculator)
+
+	expected := mustJSONToInterface([]byte(responseBody))
+	got := mustJSONToInterface(mustAnyToJSON(out))
+	assert.Equal(t, expected, got, "both deserialized values must be equal")
+}
+
 func TestClient_GetBalance(t *testing.T) {
 	responseBody := `{"context":{"slot":83987501},"value":19039980000}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -326,8 +453,81 @@ func TestClient_GetBalance(t *testing.T) {
 		}, out)
 }
 
+func TestClient_GetBalances(t *testing.T) {
+	// Every other account exists, with a balance of its index (plus one).
+	addresses := make([]solana.PublicKey, 150)
+	lamports := make(map[string]int)
+	for i := range addresses {
+		addresses[i] = solana.NewWallet().PublicKey()
+		if i%2 == 1 {
+			lamports[addresses[i].String()] = i + 1
+		}
+	}
+	var requests [][]interface{}
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		require.Equal(t, "getMultipleAccounts", method)
+		requests = append(requests, params)
+		var values []string
+		for _, address := range params[0].([]interface{}) {
+			if balance, ok := lamports[address.(string)]; ok {
+				values = append(values, fmt.Sprintf(`{"data":["","base64"],"executable":false,"lamports":%d,"owner":"11111111111111111111111111111111","rentEpoch":207}`, balance))
+			} else {
+				values = append(values, "null")
+			}
+		}
+		return `"result":{"context":{"slot":83986105},"value":[` + strings.Join(values, ",") + `]}`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	balances, exists, err := client.GetBalancesWithExistence(context.Background(), addresses, CommitmentFinalized)
+	require.NoError(t, err)
+	require.Len(t, balances, len(addresses))
+	require.Len(t, exists, len(addresses))
+	for i := range addresses {
+		if i%2 == 1 {
+			assert.Equal(t, uint64(i+1), balances[i])
+			assert.True(t, exists[i])
+		} else {
+			assert.Zero(t, balances[i])
+			assert.False(t, exists[i])
+		}
+	}
+
+	// In batches of MaxMultipleAccounts, without the account data.
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[0][0], MaxMultipleAccounts)
+	assert.Len(t, requests[1][0], 50)
+	assert.Equal(t,
+		map[string]interface{}{
+			"commitment": string(CommitmentFinalized),
+			"encoding":   string(solana.EncodingBase64),
+			"dataSlice": map[string]interface{}{
+				"offset": float64(0),
+				"length": float64(0),
+			},
+		},
+		requests[0][1],
+	)
+
+	requests = nil
+	only, err := client.GetBalances(context.Background(), addresses[1:2], "")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2}, only)
+	assert.Len(t, requests, 1)
+
+	requests = nil
+	none, err := client.GetBalances(context.Background(), nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+	assert.Empty(t, requests)
+}
+
+// getBlockResponseFixture is a getBlock result with two transactions.
+const getBlockResponseFixture = `{"blockHeight":69213636,"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[{"lamports":1595000,"postBalance":482032983798,"pubkey":"5rL3AaidKJa4ChSV3ys1SvpDg9L4amKiwYayGR5oL3dq","rewardType":"Fee"}],"transactions":[{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":["Program Vote111111111111111111111111111111111111111 invoke [1]","Program Vote111111111111111111111111111111111111111 success"],"postBalances":[441866063495,40905918933763,1,1,1],"postTokenBalances":[],"preBalances":[441866068495,40905918933763,1,1,1],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":["AQp2TH1spzjBAVM3alvnpaePFx3YEo9dvRglDuSChZUoTMD\/\/2h0HY5+89LJjCdiGJ7Ph3+Fyvbeiz1uJF8gxw0BAAMFyH0KDkXtjL1xebUYflZxYGlpV+LvjazzZCb\/mF2T67xZmkOUM\/A0iDSEkFzD5m4Ol82vsojigvqxrmp7Z1vrQgan1RcZLwqvxvJl4\/t3zHragsUp0L47E24tAFUgAAAABqfVFxjHdMkoVmOYaR1etoteuKObS21cc1VbIQAAAAAHYUgdNXR0u3xNdiTr072z2DVec9EQQ\/wNo1OAAAAAAAMFYbeqrsxJ9\/vZxtOaFi3rT2w9RF5Xi4jsyu61f3t1AQQEAQIDAAR0ZXN0","base64"]},{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":["Program Vote111111111111111111111111111111111111111 invoke [1]","Program Vote111111111111111111111111111111111111111 success"],"postBalances":[334759887662,151357332545078,1,1,1],"postTokenBalances":[],"preBalances":[334759892662,151357332545078,1,1,1],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":["ATA7DkBatbe2JB43QV+QRj2yoXSMXXttYFggDxZYOBfsRyYuGtzrbUevivclchxVccRIPlRP9PtS\/9NPXlwmhwwBAAMFSDrhjiNPuNqc4BWwitZz7xJ2NIXtv6XZtwtEOmgLj3n3NQ+OONLFlsu0LoUBSDsp40i9jOjZJBsliMtvTfdV+gan1RcZLwqvxvJl4\/t3zHragsUp0L47E24tAFUgAAAABqfVFxjHdMkoVmOYaR1etoteuKObS21cc1VbIQAAAAAHYUgdNXR0u3xNdiTr072z2DVec9EQQ\/wNo1OAAAAAAAKlcZMqS\/Oh0v+kOq2Ipg73NqbvKBRGQJDK8\/01K+MBAQQEAQIDAAR0ZXN0","base64"]}]}`
+
 func TestClient_GetBlock(t *testing.T) {
-	responseBody := `{"blockHeight":69213636,"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[{"lamports":1595000,"postBalance":482032983798,"pubkey":"5rL3AaidKJa4ChSV3ys1SvpDg9L4amKiwYayGR5oL3dq","rewardType":"Fee"}],"transactions":[{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":["Program Vote111111111111111111111111111111111111111 invoke [1]","Program Vote111111111111111111111111111111111111111 success"],"postBalances":[441866063495,40905918933763,1,1,1],"postTokenBalances":[],"preBalances":[441866068495,40905918933763,1,1,1],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":["AQp2TH1spzjBAVM3alvnpaePFx3YEo9dvRglDuSChZUoTMD\/\/2h0HY5+89LJjCdiGJ7Ph3+Fyvbeiz1uJF8gxw0BAAMFyH0KDkXtjL1xebUYflZxYGlpV+LvjazzZCb\/mF2T67xZmkOUM\/A0iDSEkFzD5m4Ol82vsojigvqxrmp7Z1vrQgan1RcZLwqvxvJl4\/t3zHragsUp0L47E24tAFUgAAAABqfVFxjHdMkoVmOYaR1etoteuKObS21cc1VbIQAAAAAHYUgdNXR0u3xNdiTr072z2DVec9EQQ\/wNo1OAAAAAAAMFYbeqrsxJ9\/vZxtOaFi3rT2w9RF5Xi4jsyu61f3t1AQQEAQIDAAR0ZXN0","base64"]},{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":["Program Vote111111111111111111111111111111111111111 invoke [1]","Program Vote111111111111111111111111111111111111111 success"],"postBalances":[334759887662,151357332545078,1,1,1],"postTokenBalances":[],"preBalances":[334759892662,151357332545078,1,1,1],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":["ATA7DkBatbe2JB43QV+QRj2yoXSMXXttYFggDxZYOBfsRyYuGtzrbUevivclchxVccRIPlRP9PtS\/9NPXlwmhwwBAAMFSDrhjiNPuNqc4BWwitZz7xJ2NIXtv6XZtwtEOmgLj3n3NQ+OONLFlsu0LoUBSDsp40i9jOjZJBsliMtvTfdV+gan1RcZLwqvxvJl4\/t3zHragsUp0L47E24tAFUgAAAABqfVFxjHdMkoVmOYaR1etoteuKObS21cc1VbIQAAAAAHYUgdNXR0u3xNdiTr072z2DVec9EQQ\/wNo1OAAAAAAAKlcZMqS\/Oh0v+kOq2Ipg73NqbvKBRGQJDK8\/01K+MBAQQEAQIDAAR0ZXN0","base64"]}]}`
+	responseBody := getBlockResponseFixture
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
 	defer closer()
 
@@ -485,6 +685,163 @@ func TestClient_GetBlock(t *testing.T) {
 		}, out)
 }
 
+func TestClient_GetBlockStreaming(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(getBlockResponseFixture)))
+	defer closer()
+	client := New(server.URL)
+
+	expected, err := client.GetBlock(context.Background(), 83987984)
+	require.NoError(t, err)
+
+	var txs []TransactionWithMeta
+	out, err := client.GetBlockStreaming(
+		context.Background(),
+		83987984,
+		nil,
+		func(idx int, tx *TransactionWithMeta) error {
+			assert.Equal(t, len(txs), idx)
+			txs = append(txs, *tx)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getBlock",
+			"params": []interface{}{
+				float64(83987984),
+				map[string]interface{}{
+					"encoding": string(solana.EncodingBase64),
+				},
+			},
+		},
+		server.RequestBody(t),
+	)
+
+	require.Len(t, txs, 2)
+	assert.Equal(t, expected.Transactions, txs)
+	assert.Nil(t, out.Transactions)
+	expected.Transactions = nil
+	assert.Equal(t, expected, out)
+
+	t.Run("callback error", func(t *testing.T) {
+		calls := 0
+		stop := errors.New("stop")
+		out, err := client.GetBlockStreaming(
+			context.Background(),
+			83987984,
+			nil,
+			func(idx int, tx *TransactionWithMeta) error {
+				calls++
+				return stop
+			},
+		)
+		require.ErrorIs(t, err, stop)
+		assert.Nil(t, out)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestClient_GetBlockStreaming_errors(t *testing.T) {
+	callback := func(idx int, tx *TransactionWithMeta) error {
+		t.Fatal("unexpected transaction")
+		return nil
+	}
+
+	{
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC("null")))
+		defer closer()
+		_, err := New(server.URL).GetBlockStreaming(context.Background(), 1, nil, callback)
+		require.ErrorIs(t, err, ErrNotConfirmed)
+	}
+	{
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32007,"message":"Slot 1 was skipped"},"id":0}`))
+		defer closer()
+		_, err := New(server.URL).GetBlockStreaming(context.Background(), 1, nil, callback)
+		var rpcErr *jsonrpc.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, -32007, rpcErr.Code)
+	}
+	{
+		_, err := New("http://localhost:0").GetBlockStreaming(
+			context.Background(),
+			1,
+			&GetBlockOpts{Encoding: solana.EncodingJSONParsed},
+			callback,
+		)
+		require.EqualError(t, err, "provided encoding is not supported: jsonParsed")
+	}
+}
+
+// largeBlockResponse returns a getBlock response with 3,000 transactions.
+func largeBlockResponse(b *testing.B) []byte {
+	var block map[string]stdjson.RawMessage
+	require.NoError(b, stdjson.Unmarshal([]byte(getBlockResponseFixture), &block))
+	var txs []stdjson.RawMessage
+	require.NoError(b, stdjson.Unmarshal(block["transactions"], &txs))
+	large := make([]stdjson.RawMessage, 0, 3000)
+	for len(large) < 3000 {
+		large = append(large, txs...)
+	}
+	var err error
+	block["transactions"], err = stdjson.Marshal(large)
+	require.NoError(b, err)
+	result, err := stdjson.Marshal(block)
+	require.NoError(b, err)
+	return []byte(wrapIntoRPC(string(result)))
+}
+
+func newBlockServer(b *testing.B) (*httptest.Server, func()) {
+	response := largeBlockResponse(b)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		io.Copy(ioutil.Discard, req.Body)
+		rw.Write(response)
+	}))
+	return server, server.Close
+}
+
+func BenchmarkGetBlock(b *testing.B) {
+	server, closer := newBlockServer(b)
+	defer closer()
+	client := New(server.URL)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out, err := client.GetBlock(context.Background(), 1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(out.Transactions) != 3000 {
+			b.Fatalf("got %d transactions", len(out.Transactions))
+		}
+	}
+}
+
+func BenchmarkGetBlockStreaming(b *testing.B) {
+	server, closer := newBlockServer(b)
+	defer closer()
+	client := New(server.URL)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		_, err := client.GetBlockStreaming(context.Background(), 1, nil, func(idx int, tx *TransactionWithMeta) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if count != 3000 {
+			b.Fatalf("got %d transactions", count)
+		}
+	}
+}
+
 func TestClient_GetBlockWithOpts(t *testing.T) {
 	responseBody := `{"blockHeight":69213636,"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[{"lamports":1595000,"postBalance":482032983798,"pubkey":"5rL3AaidKJa4ChSV3ys1SvpDg9L4amKiwYayGR5oL3dq","rewardType":"Fee"}],"transactions":[{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":["Program Vote111111111111111111111111111111111111111 invoke [1]","Program Vote111111111111111111111111111111111111111 success"],"postBalances":[441866063495,40905918933763,1,1,1],"postTokenBalances":[],"preBalances":[441866068495,40905918933763,1,1,1],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":{"message":{"accountKeys":["EVd8FFVB54svYdZdG6hH4F4hTbqre5mpQ7XyF5rKUmes","72miaovmbPqccdbAA861r2uxwB5yL1sMjrgbCnc4JfVT","SysvarS1otHashes111111111111111111111111111","SysvarC1ock11111111111111111111111111111111","Vote111111111111111111111111111111111111111"],"header":{"numReadonlySignedAccounts":0,"numReadonlyUnsignedAccounts":3,"numRequiredSignatures":1},"instructions":[{"accounts":[1,2,3,0],"data":"3yZe7d","programIdIndex":4}],"recentBlockhash":"CnyzpJmBydX1X2FyXXzsPFc5WPT9UFdLVkEhnvW33at"},"signatures":["D8emaP3CaepSGigD3TCrev7j67yPLMi82qfzTb9iZYPxHcCmm6sQBKTU4bzAee4445zbnbWduVAZ87WfbWbXoAU"]}},{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":["Program Vote111111111111111111111111111111111111111 invoke [1]","Program Vote111111111111111111111111111111111111111 success"],"postBalances":[334759887662,151357332545078,1,1,1],"postTokenBalances":[],"preBalances":[334759892662,151357332545078,1,1,1],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":{"message":{"accountKeys":["5rxRt2GVpSUFJTqQ5E4urqJCDbcBPakb46t6URyxQ5Za","HdzdTTjrmRLYVRy3umzZX4NcUmGTHu6hvYLQN2jGJo53","SysvarS1otHashes111111111111111111111111111","SysvarC1ock11111111111111111111111111111111","Vote111111111111111111111111111111111111111"],"header":{"numReadonlySignedAccounts":0,"numReadonlyUnsignedAccounts":3,"numRequiredSignatures":1},"instructions":[{"accounts":[1,2,3,0],"data":"3yZe7d","programIdIndex":4}],"recentBlockhash":"BL8oo42yoSTKUYpbXR3kdxeV5X1P8JUUZBZaeBL8K6G"},"signatures":["xvrkWXwj5h9SsJvboPMtn4jbR6XNmnHYp4MAikKFwdtkpwMxceFZ46QRzeyGUqm5P1kmCagdUubr3aPdxo7vzyq"]}}]}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -525,9 +882,94 @@ func TestClient_GetBlockWithOpts(t *testing.T) {
 		},
 		server.RequestBody(t),
 	)
+}
 
-	// TODO:
-	// - test also when requesting only signatures
+func TestClient_GetBlockWithOpts_retryUntilAvailable(t *testing.T) {
+	defer func(interval time.Duration) { getBlockRetryInterval = interval }(getBlockRetryInterval)
+	getBlockRetryInterval = time.Millisecond
+
+	const notAvailable = `"error":{"code":-32004,"message":"Block not available for slot 83987984"}`
+	const skipped = `"error":{"code":-32007,"message":"Slot 83987984 was skipped, or missing due to ledger jump to recent snapshot"}`
+	const block = `"result":{"blockHeight":69213636,"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","transactions":[]}`
+
+	t.Run("available after a few retries", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			if calls < 3 {
+				return notAvailable
+			}
+			return block
+		})
+		defer closer()
+		client := New(server.URL)
+
+		out, err := client.GetBlockWithOpts(context.Background(), 83987984, &GetBlockOpts{
+			RetryUntilAvailable: time.Minute,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(83987983), out.ParentSlot)
+		assert.Equal(t, 3, calls)
+	})
+	t.Run("skipped slot is not retried", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			return skipped
+		})
+		defer closer()
+		client := New(server.URL)
+
+		_, err := client.GetBlockWithOpts(context.Background(), 83987984, &GetBlockOpts{
+			RetryUntilAvailable: time.Minute,
+		})
+		require.ErrorIs(t, err, ErrSlotSkipped)
+		assert.False(t, errors.Is(err, ErrBlockNotAvailable))
+		var rpcErr *jsonrpc.RPCError
+		require.True(t, errors.As(err, &rpcErr))
+		assert.Equal(t, -32007, rpcErr.Code)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("not available after the timeout", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			return notAvailable
+		})
+		defer closer()
+		client := New(server.URL)
+
+		_, err := client.GetBlockWithOpts(context.Background(), 83987984, &GetBlockOpts{
+			RetryUntilAvailable: 20 * time.Millisecond,
+		})
+		require.ErrorIs(t, err, ErrBlockNotAvailable)
+		assert.Greater(t, calls, 1)
+	})
+	t.Run("no retries by default", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			return notAvailable
+		})
+		defer closer()
+		client := New(server.URL)
+
+		_, err := client.GetBlock(context.Background(), 83987984)
+		require.ErrorIs(t, err, ErrBlockNotAvailable)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("skipped slot while streaming", func(t *testing.T) {
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			return skipped
+		})
+		defer closer()
+		client := New(server.URL)
+
+		_, err := client.GetBlockWithOpts(context.Background(), 83987984, &GetBlockOpts{
+			SkipVoteTransactions: true,
+		})
+		require.ErrorIs(t, err, ErrSlotSkipped)
+	})
 }
 
 func TestClient_GetBlockHeight(t *testing.T) {
@@ -814,6 +1256,21 @@ func TestClient_GetBlockTime(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_GetConfirmedBlock_blockTime(t *testing.T) {
+	responseBody := `{"blockHeight":69213636,"blockTime":1625230849,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFvF6ivrN","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[],"transactions":[]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetConfirmedBlock(context.Background(), 83987984)
+	require.NoError(t, err)
+
+	// Block times are typed the same way in all the results.
+	var blockTime *solana.UnixTimeSeconds = out.BlockTime
+	require.NotNil(t, blockTime)
+	require.Equal(t, time.Unix(1625230849, 0), blockTime.Time())
+}
+
 func TestClient_GetClusterNodes(t *testing.T) {
 	responseBody := `[{"featureSet":743297851,"gossip":"162.55.111.250:8001","pubkey":"DMeohMfD3JzmYZA34jL9iiTXp5N7tpAR3rAoXMygdH3U","rpc":"135.181.114.15:8005","shredVersion":18122,"tpu":"162.55.111.250:8004","version":"1.7.3"},{"featureSet":743297851,"gossip":"136.243.131.82:8000","pubkey":"59TSbYfnbb4zx4xf54ApjE8fJRhwzTiSjh9vdHfgyg1U","rpc":"136.243.131.82:8899","shredVersion":18122,"tpu":"136.243.131.82:8003","version":"1.7.3"},{"featureSet":743297851,"gossip":"135.181.114.15:8001","pubkey":"7vu7Q2d4uu9V4xnySHXieeyWvoNh37321kqTd2ATuoj6","rpc":"135.181.114.15:8005","shredVersion":18122,"tpu":"135.181.114.15:8006","version":"1.7.3"}]`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -841,6 +1298,24 @@ func TestClient_GetClusterNodes(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_FindRPCNodes(t *testing.T) {
+	responseBody := `[{"featureSet":743297851,"gossip":"162.55.111.250:8001","pubkey":"DMeohMfD3JzmYZA34jL9iiTXp5N7tpAR3rAoXMygdH3U","rpc":"135.181.114.15:8005","shredVersion":18122,"tpu":"162.55.111.250:8004","version":"1.7.3"},{"featureSet":743297851,"gossip":"136.243.131.82:8000","pubkey":"59TSbYfnbb4zx4xf54ApjE8fJRhwzTiSjh9vdHfgyg1U","rpc":null,"shredVersion":18122,"tpu":"136.243.131.82:8003","version":null},{"featureSet":743297851,"gossip":"135.181.114.15:8001","pubkey":"7vu7Q2d4uu9V4xnySHXieeyWvoNh37321kqTd2ATuoj6","rpc":"135.181.114.15:8005","shredVersion":18122,"tpu":"135.181.114.15:8006","version":"1.7.3"},{"gossip":"1.2.3.4:8001","pubkey":"59TSbYfnbb4zx4xf54ApjE8fJRhwzTiSjh9vdHfgyg1U"},{"pubkey":"59TSbYfnbb4zx4xf54ApjE8fJRhwzTiSjh9vdHfgyg1U","rpc":"136.243.131.82:8899"}]`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.FindRPCNodes(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "getClusterNodes", server.RequestBody(t)["method"])
+	assert.Equal(t,
+		[]string{
+			"http://135.181.114.15:8005",
+			"http://136.243.131.82:8899",
+		},
+		out,
+	)
+}
+
 func TestClient_GetEpochInfo(t *testing.T) {
 	responseBody := `{"absoluteSlot":83994151,"blockHeight":69218302,"epoch":207,"slotIndex":93895,"slotsInEpoch":432000,"transactionCount":27287000257}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -1221,6 +1696,94 @@ func TestClient_GetInflationReward(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_GetValidatorRewards(t *testing.T) {
+	pubkeyString := "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"
+	pubKey := solana.MustPublicKeyFromBase58(pubkeyString)
+
+	t.Run("rewarded", func(t *testing.T) {
+		responseBody := `[{"amount":2500,"effectiveSlot":224,"epoch":2,"postBalance":499999442500,"commission":10}]`
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+		defer closer()
+		client := New(server.URL)
+
+		out, err := client.GetValidatorRewards(context.Background(), pubKey, 56, 58)
+		require.NoError(t, err)
+
+		// The mock records the last request.
+		assert.Equal(t,
+			map[string]interface{}{
+				"id":      float64(0),
+				"jsonrpc": "2.0",
+				"method":  "getInflationReward",
+				"params": []interface{}{
+					[]interface{}{
+						pubkeyString,
+					},
+					map[string]interface{}{
+						"epoch": float64(58),
+					},
+				},
+			},
+			server.RequestBody(t),
+		)
+
+		commission := uint8(10)
+		require.Len(t, out, 3)
+		for i, reward := range out {
+			assert.Equal(t,
+				ValidatorEpochReward{
+					Epoch:         uint64(56 + i),
+					Rewarded:      true,
+					EffectiveSlot: 224,
+					Amount:        2500,
+					PostBalance:   499999442500,
+					Commission:    &commission,
+				},
+				reward,
+			)
+		}
+	})
+	t.Run("null", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`[null]`)))
+		defer closer()
+		client := New(server.URL)
+
+		out, err := client.GetValidatorRewards(context.Background(), pubKey, 10, 11)
+		require.NoError(t, err)
+		assert.Equal(t,
+			[]ValidatorEpochReward{
+				{Epoch: 10},
+				{Epoch: 11},
+			},
+			out,
+		)
+	})
+	t.Run("invalid range", func(t *testing.T) {
+		client := New("http://127.0.0.1:0")
+		_, err := client.GetValidatorRewards(context.Background(), pubKey, 11, 10)
+		require.Error(t, err)
+		_, err = client.GetValidatorRewards(context.Background(), pubKey, 0, math.MaxUint64)
+		require.EqualError(t, err, fmt.Sprintf("epoch range 0-%d has more than %d epochs", uint64(math.MaxUint64), MaxValidatorRewardsEpochs))
+		_, err = client.GetValidatorRewards(context.Background(), pubKey, 10, 10+MaxValidatorRewardsEpochs)
+		require.Error(t, err)
+	})
+	t.Run("last epoch", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`[null]`)))
+		defer closer()
+		client := New(server.URL)
+
+		out, err := client.GetValidatorRewards(context.Background(), pubKey, math.MaxUint64-1, math.MaxUint64)
+		require.NoError(t, err)
+		assert.Equal(t,
+			[]ValidatorEpochReward{
+				{Epoch: math.MaxUint64 - 1},
+				{Epoch: math.MaxUint64},
+			},
+			out,
+		)
+	})
+}
+
 func TestClient_GetLargestAccounts(t *testing.T) {
 	responseBody := `{"context":{"slot":83995022},"value":[{"address":"4Rf9mGD7FeYknun5JczX5nGLTfQuS1GRjNVfkEMKE92b","lamports":398178060209179300},{"address":"KchK7WTjPzq9QL5aCwnV1dLsT8rFjruS1Zfzamxus9G","lamports":215100454508495000},{"address":"8oRw7qpj6XgLGXYCDuNoTMCqoJnDd6A8LTpNyqApSfkA","lamports":99999674507283220},{"address":"9oKrJ9iiEnCC7bewcRFbcdo4LKL2PhUEqcu8gH2eDbVM","lamports":97721650553633650},{"address":"3ANJb42D3pkVtntgT6VtW2cD3icGVyoHi2NGwtXYHQAs","lamports":91160815129021260},{"address":"K7DbiDcRngs4KY3KxSUcMFNEzXW7iQgi3zFzerXYYDZ","lamports":80000000000000000},{"address":"mvines9iiHiQTysrwkJjGf2gb9Ex9jXJX8ns3qwf2kN","lamports":53925298123552904},{"address":"71bhKKL89U3dNHzuZVZ7KarqV6XtHEgjXjvJTsguD11B","lamports":20949230980018784},{"address":"57DPUrAncC4BUY7KBqRMCQUt4eQeMaJWpmLQwsL35ojZ","lamports":18210921605995270},{"address":"hQBS6cu8RHkXcCzE6N8mQxhgrtbNy4kivoRjTMzF2cA","lamports":18191952118880490},{"address":"5vxoRv2P12q4K4cWPCJkvPjg6jYnuCYxzF3juJZJiwba","lamports":14225826149332328},{"address":"2tZoLFgcbeW8Howq8QMRnExvuwHFUeEnx9ZhHq2qX77E","lamports":10099331225079048},{"address":"5NH47Zk9NAzfbtqNpUtn8CQgNZeZE88aa2NRpfe7DyTD","lamports":10000060317056686},{"address":"4xxV5Svt3LPsDv81seuqKB4QXxwhdQiFXzbj9GNYXkEr","lamports":10000000000000000},{"address":"GoCxdowvFindZVAXP3QsKRP3rR2LZBNXWwp3FB1yZznF","lamports":9796480999955000},{"address":"7arfejY2YxX9QrmzHrhu3rG3HofjMqKtfBzQLf8s3Wop","lamports":5465066164230830},{"address":"5TkrtJfHoX85sti8xSVvfggVV9SDvhjYjiXe9PqMJVN9","lamports":5384143441736968},{"address":"123vij84ecQEKUvQ7gYMKxKwKF6PbYSzCzzURYA4xULY","lamports":4350560741967702},{"address":"7vYe2KRUL2sbqSqbCn4UCvn2taaTJWvo3HBsPjZcEogG","lamports":3983999997415000},{"address":"7aeNmoVKnbxUSZGukYz2Gyr3UazXpaxATNszKu8XMW1k","lamports":3324774979081580}]}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -1387,6 +1950,18 @@ func TestClient_GetLeaderSchedule(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestGetLeaderScheduleResult_Identities(t *testing.T) {
+	a := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	b := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	c := solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+
+	schedule := GetLeaderScheduleResult{c: {2}, a: {0}, b: {1}}
+	assert.Equal(t, solana.PublicKeySlice{a, b, c}, schedule.Identities())
+
+	production := IdentityToSlotsBlocks{b: {1, 1}, c: {2, 2}, a: {0, 0}}
+	assert.Equal(t, solana.PublicKeySlice{a, b, c}, production.Identities())
+}
+
 func TestClient_GetMaxRetransmitSlot(t *testing.T) {
 	responseBody := `83996101`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -1441,6 +2016,48 @@ func TestClient_GetMaxShredInsertSlot(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_GetClock(t *testing.T) {
+	responseBody := `{"context":{"slot":268435456},"value":{"data":["AAAAEAAAAABAV1dmAAAAAG0CAAAAAAAAbgIAAAAAAAAF9llmAAAAAA==","base64"],"executable":false,"lamports":1169280,"owner":"Sysvar1111111111111111111111111111111111111","rentEpoch":361}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetClock(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getAccountInfo",
+			"params": []interface{}{
+				solana.SysVarClockPubkey.String(),
+				map[string]interface{}{
+					"encoding": string(solana.EncodingBase64),
+				},
+			},
+		},
+		server.RequestBody(t),
+	)
+	assert.Equal(t, uint64(268435456), out.Slot)
+	assert.Equal(t, uint64(621), out.Epoch)
+	assert.Equal(t, int64(1717171717), out.UnixTimestamp)
+}
+
+func TestClient_GetRent(t *testing.T) {
+	responseBody := `{"context":{"slot":268435456},"value":{"data":["mA0AAAAAAAAAAAAAAAAAQDI=","base64"],"executable":false,"lamports":1009200,"owner":"Sysvar1111111111111111111111111111111111111","rentEpoch":361}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetRent(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, solana.SysVarRentPubkey.String(), server.RequestBody(t)["params"].([]interface{})[0])
+	assert.Equal(t, uint64(3480), out.LamportsPerByteYear)
+	assert.Equal(t, float64(2), out.ExemptionThreshold)
+	assert.Equal(t, uint8(50), out.BurnPercent)
+}
+
 func TestClient_GetMinimumBalanceForRentExemption(t *testing.T) {
 	responseBody := `70490880`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -1529,6 +2146,136 @@ func TestClient_GetMultipleAccounts(t *testing.T) {
 	assert.Equal(t, expected, out)
 }
 
+func TestClient_GetMultipleAccounts_mixedJSONParsed(t *testing.T) {
+	// A token account (parsed), a missing account, an account of a program
+	// without parser (base64), and one from an older node (base58 string).
+	tokenAccount := `{"parsed":{"info":{"isNative":false,"mint":"So11111111111111111111111111111111111111112","owner":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932","state":"initialized","tokenAmount":{"amount":"42","decimals":9,"uiAmount":4.2e-8,"uiAmountString":"0.000000042"}},"type":"account"},"program":"spl-token","space":165}`
+	responseBody := `{"context":{"slot":83996178},"value":[` +
+		`{"data":` + tokenAccount + `,"executable":false,"lamports":2039280,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":207},` +
+		`null,` +
+		`{"data":["AQID","base64"],"executable":false,"lamports":1000,"owner":"9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin","rentEpoch":207},` +
+		`{"data":"3yZe7d","executable":false,"lamports":1000,"owner":"9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin","rentEpoch":207}` +
+		`]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetMultipleAccountsWithOpts(
+		context.Background(),
+		[]solana.PublicKey{
+			solana.MustPublicKeyFromBase58("SRMuApVNdxXokk5GT7XD5cUUgXMBCoAz2LHeuAoKWRt"),
+			solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"),
+			solana.MustPublicKeyFromBase58("9DRhhoLGCCZTqPEwfQYjStfGAFQGpRHZgPjLNbXiK7an"),
+			solana.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn"),
+		},
+		&GetMultipleAccountsOpts{
+			Encoding: solana.EncodingJSONParsed,
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, out.Value, 4)
+
+	parsed := out.Value[0]
+	require.NotNil(t, parsed)
+	assert.Equal(t, solana.TokenProgramID, parsed.Owner)
+	assert.Nil(t, parsed.Data.GetBinary())
+	var token struct {
+		Parsed struct {
+			Info struct {
+				Mint        solana.PublicKey `json:"mint"`
+				TokenAmount struct {
+					Amount string `json:"amount"`
+				} `json:"tokenAmount"`
+			} `json:"info"`
+		} `json:"parsed"`
+		Program string `json:"program"`
+	}
+	require.NoError(t, json.Unmarshal(parsed.Data.GetRawJSON(), &token))
+	assert.Equal(t, "spl-token", token.Program)
+	assert.Equal(t, solana.SolMint, token.Parsed.Info.Mint)
+	assert.Equal(t, "42", token.Parsed.Info.TokenAmount.Amount)
+
+	assert.Nil(t, out.Value[1])
+
+	assert.Nil(t, out.Value[2].Data.GetRawJSON())
+	assert.Equal(t, []byte{1, 2, 3}, out.Value[2].Data.GetBinary())
+
+	assert.Nil(t, out.Value[3].Data.GetRawJSON())
+	assert.Equal(t, []byte("test"), out.Value[3].Data.GetBinary())
+
+	// Each element is marshaled back as it was returned.
+	data, err := json.Marshal(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, responseBody, string(data))
+}
+
+func TestClient_GetMultipleAccountsDecoded(t *testing.T) {
+	owner := solana.MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	type testAccount struct {
+		Value []byte
+	}
+	solana.RegisterAccountDecoder(owner, func(data []byte) (interface{}, error) {
+		if len(data) == 0 {
+			return nil, errors.New("empty data")
+		}
+		return &testAccount{Value: data}, nil
+	})
+
+	responseBody := `{"context":{"slot":83996178},"value":[null,{"data":["AQI=","base64"],"executable":false,"lamports":1000,"owner":"9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin","rentEpoch":207}]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	missing := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	existing := solana.MustPublicKeyFromBase58("SRMuApVNdxXokk5GT7XD5cUUgXMBCoAz2LHeuAoKWRt")
+
+	out, err := client.GetMultipleAccountsDecoded(
+		context.Background(),
+		[]solana.PublicKey{missing, existing},
+		[]solana.PublicKey{solana.TokenProgramID, owner},
+		&GetMultipleAccountsOpts{
+			Encoding: solana.EncodingJSONParsed,
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getMultipleAccounts",
+			"params": []interface{}{
+				[]interface{}{missing.String(), existing.String()},
+				map[string]interface{}{
+					"encoding": string(solana.EncodingBase64),
+				},
+			},
+		},
+		server.RequestBody(t),
+	)
+	assert.Equal(t, []interface{}{nil, &testAccount{Value: []byte{1, 2}}}, out)
+
+	{
+		// Unexpected owner.
+		_, err := client.GetMultipleAccountsDecoded(
+			context.Background(),
+			[]solana.PublicKey{missing, existing},
+			[]solana.PublicKey{owner, solana.TokenProgramID},
+			nil,
+		)
+		require.Error(t, err)
+	}
+	{
+		_, err := client.GetMultipleAccountsDecoded(
+			context.Background(),
+			[]solana.PublicKey{missing, existing},
+			[]solana.PublicKey{owner},
+			nil,
+		)
+		require.Error(t, err)
+	}
+}
+
 func TestClient_GetProgramAccounts(t *testing.T) {
 	responseBody := `[{"account":{"data":["dGVzdA==","base64"],"executable":true,"lamports":2039280,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":206},"pubkey":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"}]`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -1614,16 +2361,21 @@ func TestClient_GetProgramAccounts(t *testing.T) {
 	assert.Equal(t, expected, out)
 }
 
-func TestClient_GetRecentPerformanceSamples(t *testing.T) {
-	responseBody := `[{"numSlots":84,"numTransactions":90402,"samplePeriodSecs":60,"slot":83998844}]`
+func TestClient_GetProgramAccountsWithContext(t *testing.T) {
+	responseBody := `{"context":{"slot":83986105},"value":[{"account":{"data":["dGVzdA==","base64"],"executable":true,"lamports":2039280,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":206},"pubkey":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"}]}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
 	defer closer()
 	client := New(server.URL)
 
-	limit := uint(1002)
-	out, err := client.GetRecentPerformanceSamples(
+	program := solana.TokenProgramID
+	minContextSlot := uint64(83986100)
+	out, err := client.GetProgramAccountsWithContext(
 		context.Background(),
-		&limit,
+		program,
+		&GetProgramAccountsOpts{
+			Commitment:     CommitmentFinalized,
+			MinContextSlot: &minContextSlot,
+		},
 	)
 	require.NoError(t, err)
 
@@ -1631,29 +2383,65 @@ func TestClient_GetRecentPerformanceSamples(t *testing.T) {
 		map[string]interface{}{
 			"id":      float64(0),
 			"jsonrpc": "2.0",
-			"method":  "getRecentPerformanceSamples",
+			"method":  "getProgramAccounts",
 			"params": []interface{}{
-				float64(limit),
+				program.String(),
+				map[string]interface{}{
+					"encoding":       "base64",
+					"commitment":     string(CommitmentFinalized),
+					"withContext":    true,
+					"minContextSlot": float64(minContextSlot),
+				},
 			},
 		},
 		server.RequestBody(t),
 	)
 
-	expected := mustJSONToInterface([]byte(responseBody))
-
-	got := mustJSONToInterface(mustAnyToJSON(out))
+	assert.Equal(t, uint64(83986105), out.Context.Slot)
+	require.Len(t, out.Value, 1)
+	assert.Equal(t, solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"), out.Value[0].Pubkey)
+	assert.Equal(t, []byte("test"), out.Value[0].Account.Data.GetBinary())
 
-	assert.Equal(t, expected, got, "both deserialized values must be equal")
+	// Without MinContextSlot, the param is omitted.
+	_, err = client.GetProgramAccountsWithContext(context.Background(), program, nil)
+	require.NoError(t, err)
+	assert.Equal(t,
+		[]interface{}{
+			program.String(),
+			map[string]interface{}{
+				"encoding":    "base64",
+				"withContext": true,
+			},
+		},
+		server.RequestBody(t)["params"],
+	)
 }
 
-func TestClient_GetSnapshotSlot(t *testing.T) {
-	responseBody := `83998606`
+func TestClient_GetProgramAccountsCallback(t *testing.T) {
+	responseBody := `[{"account":{"data":["dGVzdA==","base64"],"executable":true,"lamports":2039280,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":206},"pubkey":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"},{"account":{"data":["","base64"],"executable":false,"lamports":1,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":206},"pubkey":"So11111111111111111111111111111111111111112"}]`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
 	defer closer()
 	client := New(server.URL)
 
-	out, err := client.GetSnapshotSlot(
+	program := solana.TokenProgramID
+	length := uint64(4)
+	opts := &GetProgramAccountsOpts{
+		Commitment: CommitmentFinalized,
+		DataSlice: &DataSlice{
+			Offset: new(uint64),
+			Length: &length,
+		},
+	}
+
+	var streamed GetProgramAccountsResult
+	err := client.GetProgramAccountsCallback(
 		context.Background(),
+		program,
+		opts,
+		func(account *KeyedAccount) error {
+			streamed = append(streamed, account)
+			return nil
+		},
 	)
 	require.NoError(t, err)
 
@@ -1661,14 +2449,158 @@ func TestClient_GetSnapshotSlot(t *testing.T) {
 		map[string]interface{}{
 			"id":      float64(0),
 			"jsonrpc": "2.0",
-			"method":  "getSnapshotSlot",
-		},
-		server.RequestBody(t),
-	)
-
-	expected := mustJSONToInterface([]byte(responseBody))
-
-	got := mustJSONToInterface(mustAnyToJSON(out))
+			"method":  "getProgramAccounts",
+			"params": []interface{}{
+				program.String(),
+				map[string]interface{}{
+					"encoding":   "base64",
+					"commitment": string(CommitmentFinalized),
+					"dataSlice": map[string]interface{}{
+						"offset": float64(0),
+						"length": float64(length),
+					},
+				},
+			},
+		},
+		server.RequestBody(t),
+	)
+
+	// Same result as the non-streaming version.
+	all, err := client.GetProgramAccountsWithOpts(context.Background(), program, opts)
+	require.NoError(t, err)
+	require.Len(t, streamed, 2)
+	assert.Equal(t, all, streamed)
+	assert.Equal(t, []byte("test"), streamed[0].Account.Data.GetBinary())
+	assert.Equal(t, solana.SolMint, streamed[1].Pubkey)
+}
+
+func TestClient_GetProgramAccountsCallback_errors(t *testing.T) {
+	responseBody := `[{"account":{"data":["","base64"],"executable":false,"lamports":1,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":206},"pubkey":"So11111111111111111111111111111111111111112"},{"account":{"data":["","base64"],"executable":false,"lamports":2,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":206},"pubkey":"So11111111111111111111111111111111111111112"}]`
+
+	t.Run("callback error aborts", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+		defer closer()
+		client := New(server.URL)
+
+		stop := errors.New("stop")
+		calls := 0
+		err := client.GetProgramAccountsCallback(
+			context.Background(),
+			solana.TokenProgramID,
+			nil,
+			func(account *KeyedAccount) error {
+				calls++
+				return stop
+			},
+		)
+		require.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("rpc error", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32010,"message":"excluded from account secondary indexes"},"id":0}`))
+		defer closer()
+		client := New(server.URL)
+
+		err := client.GetProgramAccountsCallback(
+			context.Background(),
+			solana.TokenProgramID,
+			nil,
+			func(account *KeyedAccount) error {
+				t.Fatal("unexpected account")
+				return nil
+			},
+		)
+		var rpcErr *jsonrpc.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, -32010, rpcErr.Code)
+	})
+	t.Run("null result", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC("null")))
+		defer closer()
+		client := New(server.URL)
+
+		err := client.GetProgramAccountsCallback(
+			context.Background(),
+			solana.TokenProgramID,
+			nil,
+			func(account *KeyedAccount) error {
+				t.Fatal("unexpected account")
+				return nil
+			},
+		)
+		require.NoError(t, err)
+	})
+	t.Run("truncated response", func(t *testing.T) {
+		body := wrapIntoRPC(responseBody)
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(body[:len(body)/2]))
+		defer closer()
+		client := New(server.URL)
+
+		err := client.GetProgramAccountsCallback(
+			context.Background(),
+			solana.TokenProgramID,
+			nil,
+			func(account *KeyedAccount) error { return nil },
+		)
+		require.Error(t, err)
+	})
+}
+
+func TestClient_GetRecentPerformanceSamples(t *testing.T) {
+	responseBody := `[{"numSlots":84,"numTransactions":90402,"samplePeriodSecs":60,"slot":83998844}]`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	limit := uint(1002)
+	out, err := client.GetRecentPerformanceSamples(
+		context.Background(),
+		&limit,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getRecentPerformanceSamples",
+			"params": []interface{}{
+				float64(limit),
+			},
+		},
+		server.RequestBody(t),
+	)
+
+	expected := mustJSONToInterface([]byte(responseBody))
+
+	got := mustJSONToInterface(mustAnyToJSON(out))
+
+	assert.Equal(t, expected, got, "both deserialized values must be equal")
+}
+
+func TestClient_GetSnapshotSlot(t *testing.T) {
+	responseBody := `83998606`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetSnapshotSlot(
+		context.Background(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getSnapshotSlot",
+		},
+		server.RequestBody(t),
+	)
+
+	expected := mustJSONToInterface([]byte(responseBody))
+
+	got := mustJSONToInterface(mustAnyToJSON(out))
 
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
@@ -1727,6 +2659,249 @@ func TestClient_GetSignaturesForAddress(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+// mockSignaturesForAddress serves the pages of getSignaturesForAddress
+// of the history (from the newest to the oldest signature),
+// and records the options of the requests.
+func mockSignaturesForAddress(t *testing.T, history []solana.Signature) (*httptest.Server, *[]map[string]interface{}, func()) {
+	var requests []map[string]interface{}
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		require.Equal(t, "getSignaturesForAddress", method)
+		conf := params[1].(map[string]interface{})
+		requests = append(requests, conf)
+		start := 0
+		if before, ok := conf["before"]; ok {
+			for i, sig := range history {
+				if sig.String() == before {
+					start = i + 1
+				}
+			}
+		}
+		var page []string
+		for i := start; i < len(history) && len(page) < int(conf["limit"].(float64)); i++ {
+			if until, ok := conf["until"]; ok && history[i].String() == until {
+				break
+			}
+			page = append(page, fmt.Sprintf(`{"signature":%q,"slot":%d,"err":null,"memo":null,"blockTime":null}`, history[i], 1000-i))
+		}
+		return `"result":[` + strings.Join(page, ",") + `]`
+	})
+	return server, &requests, closer
+}
+
+// signaturesHistoryFixture returns n signatures, from the newest to the oldest.
+func signaturesHistoryFixture(n int) []solana.Signature {
+	var history []solana.Signature
+	for i := 0; i < n; i++ {
+		var sig solana.Signature
+		sig[0] = byte(100 - i)
+		history = append(history, sig)
+	}
+	return history
+}
+
+func TestClient_SignaturesForAddressAll(t *testing.T) {
+	account := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	history := signaturesHistoryFixture(7)
+	newServer := func(t *testing.T) (*httptest.Server, *[]map[string]interface{}, func()) {
+		return mockSignaturesForAddress(t, history)
+	}
+	collect := func(pages *[][]solana.Signature) func([]*TransactionSignature) error {
+		return func(page []*TransactionSignature) error {
+			var sigs []solana.Signature
+			for _, sig := range page {
+				sigs = append(sigs, sig.Signature)
+			}
+			*pages = append(*pages, sigs)
+			return nil
+		}
+	}
+
+	t.Run("whole history", func(t *testing.T) {
+		server, requests, closer := newServer(t)
+		defer closer()
+
+		var pages [][]solana.Signature
+		err := New(server.URL).SignaturesForAddressAll(
+			context.Background(),
+			account,
+			&SignaturesForAddressAllOpts{PageSize: 3, Commitment: CommitmentConfirmed},
+			collect(&pages),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, [][]solana.Signature{history[0:3], history[3:6], history[6:7]}, pages)
+		require.Len(t, *requests, 3)
+		assert.Equal(t, map[string]interface{}{"limit": float64(3), "commitment": "confirmed"}, (*requests)[0])
+		assert.Equal(t, history[2].String(), (*requests)[1]["before"])
+		assert.Equal(t, history[5].String(), (*requests)[2]["before"])
+	})
+	t.Run("exact multiple of the page size", func(t *testing.T) {
+		server, requests, closer := newServer(t)
+		defer closer()
+
+		var pages [][]solana.Signature
+		err := New(server.URL).SignaturesForAddressAll(
+			context.Background(),
+			account,
+			&SignaturesForAddressAllOpts{PageSize: 7},
+			collect(&pages),
+		)
+		require.NoError(t, err)
+		// The last (empty) page is not passed to the callback.
+		assert.Equal(t, [][]solana.Signature{history}, pages)
+		assert.Len(t, *requests, 2)
+	})
+	t.Run("max total and until", func(t *testing.T) {
+		server, requests, closer := newServer(t)
+		defer closer()
+
+		var pages [][]solana.Signature
+		err := New(server.URL).SignaturesForAddressAll(
+			context.Background(),
+			account,
+			&SignaturesForAddressAllOpts{PageSize: 3, MaxTotal: 5, Before: history[0]},
+			collect(&pages),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, [][]solana.Signature{history[1:4], history[4:6]}, pages)
+		require.Len(t, *requests, 2)
+		assert.Equal(t, float64(2), (*requests)[1]["limit"])
+
+		pages = nil
+		err = New(server.URL).SignaturesForAddressAll(
+			context.Background(),
+			account,
+			&SignaturesForAddressAllOpts{PageSize: 2, Until: history[3]},
+			collect(&pages),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, [][]solana.Signature{history[0:2], history[2:3]}, pages)
+	})
+	t.Run("callback error and context cancellation", func(t *testing.T) {
+		server, requests, closer := newServer(t)
+		defer closer()
+		client := New(server.URL)
+
+		stop := errors.New("stop")
+		err := client.SignaturesForAddressAll(
+			context.Background(),
+			account,
+			&SignaturesForAddressAllOpts{PageSize: 2},
+			func([]*TransactionSignature) error { return stop },
+		)
+		require.ErrorIs(t, err, stop)
+		assert.Len(t, *requests, 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err = client.SignaturesForAddressAll(
+			ctx,
+			account,
+			&SignaturesForAddressAllOpts{PageSize: 2},
+			func([]*TransactionSignature) error {
+				cancel()
+				return nil
+			},
+		)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Len(t, *requests, 2)
+	})
+}
+
+func TestClient_SignaturesForAddressIterator(t *testing.T) {
+	account := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	history := signaturesHistoryFixture(7)
+	drain := func(t *testing.T, it *SignaturesForAddressIterator) []solana.Signature {
+		var sigs []solana.Signature
+		for {
+			sig, err := it.Next()
+			if err == io.EOF {
+				return sigs
+			}
+			require.NoError(t, err)
+			sigs = append(sigs, sig.Signature)
+		}
+	}
+
+	t.Run("three pages", func(t *testing.T) {
+		server, requests, closer := mockSignaturesForAddress(t, history)
+		defer closer()
+
+		it := New(server.URL).SignaturesForAddressIterator(context.Background(), account, &SignaturesForAddressAllOpts{PageSize: 3})
+		assert.Empty(t, *requests, "no request before the first Next")
+		sig, err := it.Next()
+		require.NoError(t, err)
+		assert.Equal(t, history[0], sig.Signature)
+		assert.Equal(t, uint64(1000), sig.Slot)
+		assert.Len(t, *requests, 1)
+
+		// Newest first, in the order of the RPC; the final page is short.
+		assert.Equal(t, history[1:], drain(t, it))
+		require.Len(t, *requests, 3)
+		assert.Equal(t, history[2].String(), (*requests)[1]["before"])
+		assert.Equal(t, history[5].String(), (*requests)[2]["before"])
+
+		// Still exhausted, without more requests.
+		_, err = it.Next()
+		assert.Equal(t, io.EOF, err)
+		assert.Len(t, *requests, 3)
+	})
+	t.Run("max total", func(t *testing.T) {
+		server, requests, closer := mockSignaturesForAddress(t, history)
+		defer closer()
+
+		it := New(server.URL).SignaturesForAddressIterator(context.Background(), account, &SignaturesForAddressAllOpts{PageSize: 3, MaxTotal: 4})
+		assert.Equal(t, history[0:4], drain(t, it))
+		require.Len(t, *requests, 2)
+		assert.Equal(t, float64(1), (*requests)[1]["limit"])
+	})
+	t.Run("reverse", func(t *testing.T) {
+		server, _, closer := mockSignaturesForAddress(t, history)
+		defer closer()
+		client := New(server.URL)
+
+		it := client.SignaturesForAddressIterator(context.Background(), account, &SignaturesForAddressAllOpts{PageSize: 2, Until: history[5]})
+		first, err := it.Next()
+		require.NoError(t, err)
+		assert.Equal(t, history[0], first.Signature)
+		// The remaining signatures, oldest first.
+		reversed, err := it.Reverse()
+		require.NoError(t, err)
+		var sigs []solana.Signature
+		for _, sig := range reversed {
+			sigs = append(sigs, sig.Signature)
+		}
+		assert.Equal(t, []solana.Signature{history[4], history[3], history[2], history[1]}, sigs)
+
+		_, err = client.SignaturesForAddressIterator(context.Background(), account, nil).Reverse()
+		require.Error(t, err)
+	})
+	t.Run("errors", func(t *testing.T) {
+		fail := true
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			if fail {
+				return `"error":{"code":-32005,"message":"Node is behind"}`
+			}
+			return fmt.Sprintf(`"result":[{"signature":%q,"slot":1000,"err":null,"memo":null,"blockTime":null}]`, history[0])
+		})
+		defer closer()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		it := New(server.URL).SignaturesForAddressIterator(ctx, account, &SignaturesForAddressAllOpts{PageSize: 2})
+		_, err := it.Next()
+		require.Error(t, err)
+		// The failed page is retried.
+		fail = false
+		sig, err := it.Next()
+		require.NoError(t, err)
+		assert.Equal(t, history[0], sig.Signature)
+		_, err = it.Next()
+		assert.Equal(t, io.EOF, err)
+
+		cancel()
+		_, err = New(server.URL).SignaturesForAddressIterator(ctx, account, nil).Next()
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
 func TestClient_GetSignatureStatuses(t *testing.T) {
 	responseBody := `{"context":{"slot":83999323},"value":[{"confirmationStatus":"finalized","confirmations":null,"err":null,"slot":82233105,"status":{"Ok":null}},{"confirmationStatus":"finalized","confirmations":null,"err":null,"slot":82232349,"status":{"Ok":null}}]}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -2216,6 +3391,158 @@ func TestClient_GetParsedTransaction(t *testing.T) {
 	}, out.Transaction.Message.Instructions[0].Parsed.asInstructionInfo)
 }
 
+func TestClient_GetParsedBlock(t *testing.T) {
+	responseBody := `{"blockHeight":250000000,"blockTime":1700000000,"blockhash":"9L8FEB81LfZ67ejxpMaaZmC9EmXBpV38dhNaiF9UbzZi","parentSlot":260000000,"previousBlockhash":"GcgVK9buRA7YepZh3zXuS399GJAESCisLnLDBCmR5Aoj","rewards":[],"transactions":[{"meta":{"err":null,"fee":5000,"innerInstructions":[],"loadedAddresses":{"readonly":["SysvarC1ock11111111111111111111111111111111"],"writable":["BMnsyyG6S6zkaE3K5X3nbRMKdvBS5dT6HhcMozBVL7Ly"]},"logMessages":[],"postBalances":[1,2,3,4],"postTokenBalances":[],"preBalances":[1,2,3,4],"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"transaction":{"message":{"accountKeys":[{"pubkey":"G7Hf2J55BAkHtbbXPh94UTGRCQioKPpnb5oKQMBteXo","signer":true,"source":"transaction","writable":true},{"pubkey":"11111111111111111111111111111111","signer":false,"source":"transaction","writable":false},{"pubkey":"BMnsyyG6S6zkaE3K5X3nbRMKdvBS5dT6HhcMozBVL7Ly","signer":false,"source":"lookupTable","writable":true},{"pubkey":"SysvarC1ock11111111111111111111111111111111","signer":false,"source":"lookupTable","writable":false}],"addressTableLookups":[{"accountKey":"9WWfC3y4uCNofr2qEFHSVUXkCxW99JiYkMWmSZvVt8j3","readonlyIndexes":[3],"writableIndexes":[1]}],"instructions":[{"parsed":{"info":{"destination":"BMnsyyG6S6zkaE3K5X3nbRMKdvBS5dT6HhcMozBVL7Ly","lamports":100,"source":"G7Hf2J55BAkHtbbXPh94UTGRCQioKPpnb5oKQMBteXo"},"type":"transfer"},"program":"system","programId":"11111111111111111111111111111111"}],"recentBlockhash":"9L8FEB81LfZ67ejxpMaaZmC9EmXBpV38dhNaiF9UbzZi"},"signatures":["2x1QBpfcEQetAx7zETLEmvVvjue9311s9AWroEvMAboFkqaHZVp1sUpTFXroc5Q6tkPmZK5pYfmPFteoZPVRLF89"]},"version":0}]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	slot := uint64(260000001)
+	version := uint64(0)
+	out, err := client.GetParsedBlock(
+		context.Background(),
+		slot,
+		&GetParsedBlockOpts{
+			Commitment:                     CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &version,
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getBlock",
+			"params": []interface{}{
+				float64(slot),
+				map[string]interface{}{
+					"encoding":                       string(solana.EncodingJSONParsed),
+					"commitment":                     string(CommitmentConfirmed),
+					"maxSupportedTransactionVersion": float64(0),
+				},
+			},
+		},
+		server.RequestBody(t),
+	)
+
+	assert.Equal(t, uint64(260000000), out.ParentSlot)
+	require.Len(t, out.Transactions, 1)
+	tx := out.Transactions[0]
+	assert.Equal(t, TransactionVersion(0), tx.Version)
+	assert.Equal(t,
+		[]ParsedMessageAccount{
+			{PublicKey: solana.MustPublicKeyFromBase58("G7Hf2J55BAkHtbbXPh94UTGRCQioKPpnb5oKQMBteXo"), Signer: true, Writable: true, Source: ParsedAccountSourceTransaction},
+			{PublicKey: solana.SystemProgramID, Source: ParsedAccountSourceTransaction},
+			{PublicKey: solana.MustPublicKeyFromBase58("BMnsyyG6S6zkaE3K5X3nbRMKdvBS5dT6HhcMozBVL7Ly"), Writable: true, Source: ParsedAccountSourceLookupTable},
+			{PublicKey: solana.SysVarClockPubkey, Source: ParsedAccountSourceLookupTable},
+		},
+		tx.Transaction.Message.AccountKeys,
+	)
+	assert.Equal(t,
+		[]solana.MessageAddressTableLookup{
+			{
+				AccountKey:      solana.MustPublicKeyFromBase58("9WWfC3y4uCNofr2qEFHSVUXkCxW99JiYkMWmSZvVt8j3"),
+				WritableIndexes: []uint8{1},
+				ReadonlyIndexes: []uint8{3},
+			},
+		},
+		tx.Transaction.Message.AddressTableLookups,
+	)
+	assert.Equal(t, solana.PublicKeySlice{solana.SysVarClockPubkey}, tx.Meta.LoadedAddresses.ReadOnly)
+	assert.Equal(t, "transfer", tx.Transaction.Message.Instructions[0].Parsed.asInstructionInfo.InstructionType)
+}
+
+func TestParsedMessageAccount_UnmarshalJSON(t *testing.T) {
+	var accounts []ParsedMessageAccount
+	require.NoError(t, json.Unmarshal([]byte(`[
+		"G7Hf2J55BAkHtbbXPh94UTGRCQioKPpnb5oKQMBteXo",
+		{"pubkey":"11111111111111111111111111111111","signer":true,"writable":true}
+	]`), &accounts))
+	assert.Equal(t,
+		[]ParsedMessageAccount{
+			{PublicKey: solana.MustPublicKeyFromBase58("G7Hf2J55BAkHtbbXPh94UTGRCQioKPpnb5oKQMBteXo")},
+			{PublicKey: solana.SystemProgramID, Signer: true, Writable: true},
+		},
+		accounts,
+	)
+
+	var account ParsedMessageAccount
+	assert.Error(t, json.Unmarshal([]byte(`"not a pubkey"`), &account))
+	assert.Error(t, json.Unmarshal([]byte(`42`), &account))
+}
+
+func TestClient_GetTransaction_retryUntilAvailable(t *testing.T) {
+	defer func(interval time.Duration) { getTransactionRetryInterval = interval }(getTransactionRetryInterval)
+	getTransactionRetryInterval = time.Millisecond
+
+	tx := solana.MustSignatureFromBase58("KBVcTWwgEhVzwywtunhAXRKjXYYEdPcSCpuEkg484tiE3dFGzHDu9LKKH23uBMdfYt3JCPHeaVeDTZWecboyTrd")
+
+	t.Run("available after a few retries", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			if calls < 3 {
+				return `"result":null`
+			}
+			return `"result":{"blockTime":1624821990,"meta":null,"slot":83311386,"transaction":["AQID","base64"]}`
+		})
+		defer closer()
+		client := New(server.URL)
+
+		out, err := client.GetTransaction(context.Background(), tx, &GetTransactionOpts{
+			Encoding:            solana.EncodingBase64,
+			Commitment:          CommitmentConfirmed,
+			RetryUntilAvailable: time.Minute,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(83311386), out.Slot)
+		assert.Equal(t, 3, calls)
+	})
+	t.Run("not found after the timeout", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			return `"result":null`
+		})
+		defer closer()
+		client := New(server.URL)
+
+		_, err := client.GetTransaction(context.Background(), tx, &GetTransactionOpts{
+			RetryUntilAvailable: 20 * time.Millisecond,
+		})
+		require.ErrorIs(t, err, ErrNotFound)
+		assert.Greater(t, calls, 1)
+	})
+	t.Run("no retries by default", func(t *testing.T) {
+		calls := 0
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			calls++
+			return `"result":null`
+		})
+		defer closer()
+		client := New(server.URL)
+
+		_, err := client.GetTransaction(context.Background(), tx, nil)
+		require.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("context canceled", func(t *testing.T) {
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			return `"result":null`
+		})
+		defer closer()
+		client := New(server.URL)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := client.GetTransaction(ctx, tx, &GetTransactionOpts{
+			RetryUntilAvailable: time.Minute,
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
 func TestClient_GetTransactionCount(t *testing.T) {
 	responseBody := `27293302873`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -2563,6 +3890,124 @@ func TestClient_GetTokenAccountsByOwner(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_GetTokenAccountsByOwnerByMint(t *testing.T) {
+	owner := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	mintA := solana.MustPublicKeyFromBase58("3wyAj7Rt1TWVPZVteFJPLa26JmLvdb1CAKEFZm3NY75E")
+	mintB := solana.MustPublicKeyFromBase58("E942z7FnS7GpswTvF5Vggvo7cMTbvZojjLbFgsrDVff1")
+
+	ataA, _, err := solana.FindAssociatedTokenAddress(owner, mintA)
+	require.NoError(t, err)
+	auxA := solana.MustPublicKeyFromBase58("CnPoSPKXu7wJqxe59Fs72tkBeALovhsCxYeFwPCQH9TD")
+	auxB := solana.MustPublicKeyFromBase58("BMnsyyG6S6zkaE3K5X3nbRMKdvBS5dT6HhcMozBVL7Ly")
+
+	header := func(mint solana.PublicKey, amount uint64) string {
+		buf := append(mint.Bytes(), owner.Bytes()...)
+		buf = append(buf, make([]byte, 8)...)
+		bin.LE.PutUint64(buf[64:], amount)
+		return base64.StdEncoding.EncodeToString(buf)
+	}
+	keyed := func(pubkey solana.PublicKey, data string) string {
+		return fmt.Sprintf(`{"account":{"data":[%q,"base64"],"executable":false,"lamports":2039280,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":4},"pubkey":%q}`, data, pubkey)
+	}
+	// The auxiliary account for mint A comes BEFORE the ATA.
+	responseBody := fmt.Sprintf(`{"context":{"slot":1114},"value":[%s,%s,%s]}`,
+		keyed(auxA, header(mintA, 1)),
+		keyed(ataA, header(mintA, 2)),
+		keyed(auxB, header(mintB, 3)),
+	)
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetTokenAccountsByOwnerByMint(
+		context.Background(),
+		owner,
+		&GetTokenAccountsByOwnerByMintOpts{
+			HeaderOnly: true,
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      float64(0),
+			"jsonrpc": "2.0",
+			"method":  "getTokenAccountsByOwner",
+			"params": []interface{}{
+				owner.String(),
+				map[string]interface{}{
+					"programId": solana.TokenProgramID.String(),
+				},
+				map[string]interface{}{
+					"encoding": string(solana.EncodingBase64),
+					"dataSlice": map[string]interface{}{
+						"offset": float64(0),
+						"length": float64(TokenAccountHeaderLength),
+					},
+				},
+			},
+		},
+		server.RequestBody(t),
+	)
+
+	require.Len(t, out, 2)
+
+	require.Contains(t, out, mintA)
+	assert.True(t, out[mintA].IsAssociated)
+	assert.Equal(t, ataA, out[mintA].Account.Pubkey)
+	require.Len(t, out[mintA].Auxiliary, 1)
+	assert.Equal(t, auxA, out[mintA].Auxiliary[0].Pubkey)
+
+	require.Contains(t, out, mintB)
+	assert.False(t, out[mintB].IsAssociated)
+	assert.Equal(t, auxB, out[mintB].Account.Pubkey)
+	assert.Empty(t, out[mintB].Auxiliary)
+}
+
+func TestClient_GetTokenAccountsByOwnerByMint_token2022(t *testing.T) {
+	owner := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	mint := solana.MustPublicKeyFromBase58("3wyAj7Rt1TWVPZVteFJPLa26JmLvdb1CAKEFZm3NY75E")
+
+	// The Token-2022 program is a seed of the associated token account.
+	ata, _, err := solana.FindAssociatedTokenAddress2022(owner, mint)
+	require.NoError(t, err)
+	classicATA, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	require.NoError(t, err)
+	require.NotEqual(t, classicATA, ata)
+	aux := solana.MustPublicKeyFromBase58("CnPoSPKXu7wJqxe59Fs72tkBeALovhsCxYeFwPCQH9TD")
+
+	data := base64.StdEncoding.EncodeToString(append(append(mint.Bytes(), owner.Bytes()...), make([]byte, 8)...))
+	keyed := func(pubkey solana.PublicKey) string {
+		return fmt.Sprintf(`{"account":{"data":[%q,"base64"],"executable":false,"lamports":2039280,"owner":%q,"rentEpoch":4},"pubkey":%q}`, data, solana.Token2022ProgramID, pubkey)
+	}
+	responseBody := fmt.Sprintf(`{"context":{"slot":1114},"value":[%s,%s]}`, keyed(aux), keyed(ata))
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	out, err := client.GetTokenAccountsByOwnerByMint(
+		context.Background(),
+		owner,
+		&GetTokenAccountsByOwnerByMintOpts{
+			ProgramId: &solana.Token2022ProgramID,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t,
+		map[string]interface{}{
+			"programId": solana.Token2022ProgramID.String(),
+		},
+		server.RequestBody(t)["params"].([]interface{})[1],
+	)
+
+	require.Len(t, out, 1)
+	require.Contains(t, out, mint)
+	assert.True(t, out[mint].IsAssociated)
+	assert.Equal(t, ata, out[mint].Account.Pubkey)
+	require.Len(t, out[mint].Auxiliary, 1)
+	assert.Equal(t, aux, out[mint].Auxiliary[0].Pubkey)
+}
+
 var (
 	encodedTx         string = "AfjEs3XhTc3hrxEvlnMPkm/cocvAUbFNbCl00qKnrFue6J53AhEqIFmcJJlJW3EDP5RmcMz+cNTTcZHW/WJYwAcBAAEDO8hh4VddzfcO5jbCt95jryl6y8ff65UcgukHNLWH+UQGgxCGGpgyfQVQV02EQYqm4QwzUt2qf9f1gVLM7rI4hwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6ANIF55zOZWROWRkeh+lExxZBnKFqbvIxZDLE7EijjoBAgIAAQwCAAAAOTAAAAAAAAA="
 	txSignatureString string = "5yUSwqQqeZLEEYKxnG4JC4XhaaBpV3RS4nQbK8bQTyjLX5btVq9A1Ja5nuJzV7Z3Zq8G6EVKFvN4DKUL6PSAxmTk"
@@ -2672,6 +4117,85 @@ func TestClient_SimulateTransaction(t *testing.T) {
 	// TODO
 }
 
+func TestClient_SimulateComputeUnits(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	recipient := solana.NewWallet().PublicKey()
+	blockhash := solana.MustHashFromBase58("dv4ACNkpYPcE3aKmYDqZm9G5EB3J4MRoeE7WNDRBVJB")
+
+	var simulated []*solana.Transaction
+	var unitsConsumed string
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		require.Equal(t, "simulateTransaction", method)
+		require.Equal(t, map[string]interface{}{"encoding": "base64", "replaceRecentBlockhash": true}, params[1])
+		raw, err := base64.StdEncoding.DecodeString(params[0].(string))
+		require.NoError(t, err)
+		tx, err := solana.TransactionFromBytes(raw)
+		require.NoError(t, err)
+		simulated = append(simulated, tx)
+		if unitsConsumed == "" {
+			return `"result":{"context":{"slot":1},"value":{"err":{"InstructionError":[0,"InvalidAccountData"]},"logs":["Program 11111111111111111111111111111111 failed"],"unitsConsumed":150}}`
+		}
+		return `"result":{"context":{"slot":1},"value":{"err":null,"logs":[],"unitsConsumed":` + unitsConsumed + `}}`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	builder := func() *solana.TransactionBuilder {
+		return solana.NewTransactionBuilder().
+			AddInstruction(system.NewTransferInstruction(1, payer.PublicKey(), recipient).Build()).
+			SetRecentBlockHash(blockhash)
+	}
+
+	t.Run("estimate", func(t *testing.T) {
+		simulated, unitsConsumed = nil, "450"
+		tx, err := builder().Build()
+		require.NoError(t, err)
+
+		units, err := tx.EstimateComputeUnits(context.Background(), client)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(450), units)
+		require.Len(t, simulated, 1)
+		assert.Equal(t, tx.Message, simulated[0].Message)
+	})
+	t.Run("simulation failed", func(t *testing.T) {
+		simulated, unitsConsumed = nil, ""
+		tx, err := builder().Build()
+		require.NoError(t, err)
+
+		_, err = tx.EstimateComputeUnits(context.Background(), client)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "InvalidAccountData")
+		assert.Contains(t, err.Error(), "failed")
+	})
+	t.Run("builder with margin", func(t *testing.T) {
+		simulated, unitsConsumed = nil, "1000"
+		tx, err := builder().WithComputeUnitMargin(client, 10).Build()
+		require.NoError(t, err)
+
+		// Simulated with the max limit, and then built with the units consumed plus 10%.
+		require.Len(t, simulated, 1)
+		assert.Equal(t, uint32(solana.MaxComputeUnitLimit), computeUnitLimit(t, simulated[0]))
+		assert.Equal(t, uint32(1100), computeUnitLimit(t, tx))
+		assert.Equal(t, payer.PublicKey(), tx.Message.AccountKeys[0])
+		assert.Len(t, tx.Message.Instructions, 2)
+	})
+}
+
+// computeUnitLimit returns the limit set by the first instruction of the transaction,
+// which must be a SetComputeUnitLimit instruction.
+func computeUnitLimit(t *testing.T, tx *solana.Transaction) uint32 {
+	accounts, err := tx.Message.Instructions[0].ResolveInstructionAccounts(&tx.Message)
+	require.NoError(t, err)
+	programID, err := tx.Message.Program(tx.Message.Instructions[0].ProgramIDIndex)
+	require.NoError(t, err)
+	require.Equal(t, computebudget.ProgramID, programID)
+	inst, err := computebudget.DecodeInstruction(accounts, tx.Message.Instructions[0].Data)
+	require.NoError(t, err)
+	limit, ok := inst.Impl.(*computebudget.SetComputeUnitLimit)
+	require.True(t, ok, "not a SetComputeUnitLimit instruction: %T", inst.Impl)
+	return limit.Units
+}
+
 func TestClient_GetFeeForMessage(t *testing.T) {
 	responseBody := `{"context":{"slot":5068},"value":5000}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -2707,6 +4231,58 @@ func TestClient_GetFeeForMessage(t *testing.T) {
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
 
+func TestClient_EstimateFeeForMessage(t *testing.T) {
+	msg := solana.Message{
+		Header: solana.MessageHeader{
+			NumRequiredSignatures: 2,
+		},
+		AccountKeys: []solana.PublicKey{
+			solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"),
+			solana.MustPublicKeyFromBase58("H7ATJQGhwG8Uf8sUntUognFpsKixPy2buFnXkvyNbGUb"),
+		},
+	}
+
+	t.Run("fee from node", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`{"context":{"slot":5068},"value":7500}`)))
+		defer closer()
+		client := New(server.URL)
+
+		fee, isFallback, err := client.EstimateFeeForMessage(context.Background(), &msg, CommitmentProcessed)
+		require.NoError(t, err)
+		assert.False(t, isFallback)
+		assert.Equal(t, uint64(7500), fee)
+		assert.Equal(t, "getFeeForMessage", server.RequestBody(t)["method"])
+	})
+	t.Run("null fee", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`{"context":{"slot":5068},"value":null}`)))
+		defer closer()
+		client := New(server.URL)
+
+		fee, isFallback, err := client.EstimateFeeForMessage(context.Background(), &msg, "")
+		require.NoError(t, err)
+		assert.True(t, isFallback)
+		assert.Equal(t, 2*DefaultLamportsPerSignature, fee)
+	})
+	t.Run("method not supported", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":0}`))
+		defer closer()
+		client := New(server.URL)
+
+		fee, isFallback, err := client.EstimateFeeForMessage(context.Background(), &msg, "")
+		require.NoError(t, err)
+		assert.True(t, isFallback)
+		assert.Equal(t, 2*DefaultLamportsPerSignature, fee)
+	})
+	t.Run("other errors are returned", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":0}`))
+		defer closer()
+		client := New(server.URL)
+
+		_, _, err := client.EstimateFeeForMessage(context.Background(), &msg, "")
+		require.Error(t, err)
+	})
+}
+
 func TestClient_GetHighestSnapshotSlot(t *testing.T) {
 	responseBody := `{"full":100,"incremental":110}`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -2806,3 +4382,804 @@ func TestClient_GetRecentPrioritizationFees(t *testing.T) {
 
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
+
+func newRebroadcastTestTransaction(t *testing.T, lastValidBlockHeight uint64) *solana.Transaction {
+	payer := solana.NewWallet().PrivateKey
+	tx, err := solana.NewTransactionBuilder().
+		AddInstruction(solana.NewInstruction(
+			solana.SystemProgramID,
+			solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).WRITE().SIGNER()},
+			[]byte{0x01},
+		)).
+		SetRecentBlockHash(solana.Hash{1}).
+		SetLastValidBlockHeight(lastValidBlockHeight).
+		Build()
+	require.NoError(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &payer })
+	require.NoError(t, err)
+	return tx
+}
+
+func TestClient_SendAndRebroadcast(t *testing.T) {
+	tx := newRebroadcastTestTransaction(t, 1000)
+	encodedTx := tx.MustToBase64()
+
+	var (
+		mu            sync.Mutex
+		sends         []map[string]interface{}
+		statusQueries int
+	)
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "sendTransaction":
+			require.Equal(t, encodedTx, params[0])
+			sends = append(sends, params[1].(map[string]interface{}))
+			if len(sends) > 1 {
+				// The node rejects the duplicate once it has processed the transaction.
+				return `"error":{"code":-32002,"message":"Transaction simulation failed: This transaction has already been processed","data":{"err":"AlreadyProcessed","logs":[]}}`
+			}
+			return fmt.Sprintf(`"result":%q`, tx.Signatures[0])
+		case "getSignatureStatuses":
+			statusQueries++
+			if statusQueries < 3 {
+				return `"result":{"context":{"slot":1},"value":[null]}`
+			}
+			return `"result":{"context":{"slot":1},"value":[{"slot":1,"confirmations":1,"err":null,"confirmationStatus":"confirmed"}]}`
+		case "getBlockHeight":
+			return `"result":999`
+		}
+		t.Errorf("unexpected method %q", method)
+		return `"result":null`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	sig, rebroadcasts, err := client.SendAndRebroadcast(
+		context.Background(),
+		tx,
+		RebroadcastOpts{
+			Interval:                10 * time.Millisecond,
+			SkipPreflightAfterFirst: true,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, tx.Signatures[0], sig)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, len(sends)-1, rebroadcasts)
+	require.GreaterOrEqual(t, rebroadcasts, 1)
+	require.NotEqual(t, true, sends[0]["skipPreflight"])
+	for _, opts := range sends[1:] {
+		require.Equal(t, true, opts["skipPreflight"])
+	}
+}
+
+func TestClient_SendAndRebroadcast_expired(t *testing.T) {
+	tx := newRebroadcastTestTransaction(t, 1000)
+
+	var (
+		mu    sync.Mutex
+		sends int
+	)
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "sendTransaction":
+			sends++
+			return fmt.Sprintf(`"result":%q`, tx.Signatures[0])
+		case "getSignatureStatuses":
+			return `"result":{"context":{"slot":1},"value":[null]}`
+		case "getBlockHeight":
+			// Still valid at the first check.
+			if sends <= 2 {
+				return `"result":1000`
+			}
+			return `"result":1001`
+		}
+		t.Errorf("unexpected method %q", method)
+		return `"result":null`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	_, rebroadcasts, err := client.SendAndRebroadcast(
+		context.Background(),
+		tx,
+		RebroadcastOpts{
+			Interval:   10 * time.Millisecond,
+			MaxResends: 2,
+		},
+	)
+	require.True(t, errors.Is(err, solana.ErrBlockhashExpired), err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, rebroadcasts)
+	require.Equal(t, 3, sends)
+}
+
+func TestClient_SendAndRebroadcast_failed(t *testing.T) {
+	tx := newRebroadcastTestTransaction(t, 0)
+
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		switch method {
+		case "sendTransaction":
+			return fmt.Sprintf(`"result":%q`, tx.Signatures[0])
+		case "getSignatureStatuses":
+			return `"result":{"context":{"slot":1},"value":[{"slot":1,"confirmations":null,"err":{"InstructionError":[0,"InvalidInstructionData"]},"confirmationStatus":"finalized"}]}`
+		}
+		t.Errorf("unexpected method %q", method)
+		return `"result":null`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	_, _, err := client.SendAndRebroadcast(
+		context.Background(),
+		tx,
+		RebroadcastOpts{Interval: 10 * time.Millisecond},
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "InvalidInstructionData")
+
+	// Cancellation.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = client.SendAndRebroadcast(ctx, tx, RebroadcastOpts{Interval: 10 * time.Millisecond})
+	require.Error(t, err)
+}
+
+func TestClient_TokenDecimals(t *testing.T) {
+	mintA := solana.NewWallet().PublicKey()
+	mintB := solana.NewWallet().PublicKey()
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, "getTokenSupply", method)
+		mint := params[0].(string)
+		requests = append(requests, mint)
+		decimals := 6
+		switch mint {
+		case mintB.String():
+			decimals = 9
+		case mintA.String():
+		default:
+			return `"error":{"code":-32602,"message":"Invalid param: not a Token mint"}`
+		}
+		return fmt.Sprintf(`"result":{"context":{"slot":1},"value":{"amount":"1000","decimals":%d,"uiAmount":0.001,"uiAmountString":"0.001"}}`, decimals)
+	})
+	defer closer()
+	client := New(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decimals, err := client.TokenDecimals(context.Background(), mintB)
+			assert.NoError(t, err)
+			assert.Equal(t, uint8(9), decimals)
+		}()
+	}
+	wg.Wait()
+	mu.Lock()
+	fetched := len(requests)
+	mu.Unlock()
+	require.GreaterOrEqual(t, fetched, 1)
+
+	for i := 0; i < 3; i++ {
+		decimals, err := client.TokenDecimals(context.Background(), mintA)
+		require.NoError(t, err)
+		require.Equal(t, uint8(6), decimals)
+		decimals, err = client.TokenDecimals(context.Background(), mintB)
+		require.NoError(t, err)
+		require.Equal(t, uint8(9), decimals)
+	}
+	// Only the first lookup of mintA hit the RPC.
+	assert.Len(t, requests, fetched+1)
+
+	// Errors are not cached.
+	notAMint := solana.NewWallet().PublicKey()
+	_, err := client.TokenDecimals(context.Background(), notAMint)
+	require.Error(t, err)
+	_, err = client.TokenDecimals(context.Background(), notAMint)
+	require.Error(t, err)
+	assert.Len(t, requests, fetched+3)
+}
+
+func TestTokenDecimalsCache(t *testing.T) {
+	cache := newTokenDecimalsCache(2)
+	a := solana.NewWallet().PublicKey()
+	b := solana.NewWallet().PublicKey()
+	c := solana.NewWallet().PublicKey()
+
+	cache.add(a, 1)
+	cache.add(b, 2)
+	// a is now the most recently used.
+	decimals, ok := cache.get(a)
+	require.True(t, ok)
+	require.Equal(t, uint8(1), decimals)
+
+	// b is evicted.
+	cache.add(c, 3)
+	require.Equal(t, 2, cache.order.Len())
+	_, ok = cache.get(b)
+	require.False(t, ok)
+	decimals, ok = cache.get(a)
+	require.True(t, ok)
+	require.Equal(t, uint8(1), decimals)
+	decimals, ok = cache.get(c)
+	require.True(t, ok)
+	require.Equal(t, uint8(3), decimals)
+
+	// Adding an existing mint doesn't grow the cache.
+	cache.add(c, 3)
+	require.Equal(t, 2, cache.order.Len())
+	require.Len(t, cache.entries, 2)
+}
+
+func TestClient_SuggestPriorityFee(t *testing.T) {
+	accountA := solana.NewWallet().PublicKey()
+	accountB := solana.NewWallet().PublicKey()
+	var (
+		mu       sync.Mutex
+		requests [][]interface{}
+	)
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, "getRecentPrioritizationFees", method)
+		requests = append(requests, params)
+		if params[0] == nil {
+			return `"result":[{"slot":1,"prioritizationFee":0},{"slot":2,"prioritizationFee":0}]`
+		}
+		return `"result":[
+			{"slot":1,"prioritizationFee":0},
+			{"slot":2,"prioritizationFee":500},
+			{"slot":3,"prioritizationFee":100},
+			{"slot":4,"prioritizationFee":0},
+			{"slot":5,"prioritizationFee":400},
+			{"slot":6,"prioritizationFee":200},
+			{"slot":7,"prioritizationFee":300}
+		]`
+	})
+	defer closer()
+	client := New(server.URL)
+	now := time.Unix(1700000000, 0)
+	client.priorityFees.now = func() time.Time { return now }
+
+	// The zero fees are ignored: the fees are 100, 200, 300, 400, 500.
+	for _, tc := range []struct {
+		percentile float64
+		expected   uint64
+	}{
+		{0, 100},
+		{20, 100},
+		{50, 300},
+		{75, 400},
+		{90, 500},
+		{100, 500},
+	} {
+		fee, err := client.SuggestPriorityFee(context.Background(), []solana.PublicKey{accountA, accountB}, tc.percentile)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, fee, "percentile %v", tc.percentile)
+	}
+	require.Len(t, requests, 1)
+	assert.Equal(t, []interface{}{[]interface{}{accountA.String(), accountB.String()}}, requests[0])
+
+	// Same set of accounts, in another order.
+	_, err := client.SuggestPriorityFee(context.Background(), []solana.PublicKey{accountB, accountA, accountB}, 50)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+
+	// Another set of accounts; no fee was paid.
+	fee, err := client.SuggestPriorityFee(context.Background(), nil, 50)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), fee)
+	require.Len(t, requests, 2)
+
+	// Expired.
+	now = now.Add(DefaultPriorityFeeCacheTTL)
+	_, err = client.SuggestPriorityFee(context.Background(), []solana.PublicKey{accountA, accountB}, 50)
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	// The expired entry of the other set was dropped.
+	assert.Len(t, client.priorityFees.entries, 1)
+
+	_, err = client.SuggestPriorityFee(context.Background(), nil, 101)
+	assert.EqualError(t, err, "percentile must be between 0 and 100, got 101")
+	_, err = client.SuggestPriorityFee(context.Background(), nil, -1)
+	assert.Error(t, err)
+	require.Len(t, requests, 3)
+}
+
+type recordingHooks struct {
+	deprecated [][2]string
+}
+
+func (h *recordingHooks) OnDeprecatedMethod(method string, replacement string) {
+	h.deprecated = append(h.deprecated, [2]string{method, replacement})
+}
+
+// mockLegacyNode returns a mock of a node that doesn't support
+// the methods for which `supported` returns false,
+// and that records the calls it receives.
+func mockLegacyNode(t *testing.T, supported func(method string) bool, results map[string]string) (client *Client, calls *[]map[string]interface{}, hooks *recordingHooks, close func()) {
+	calls = &[]map[string]interface{}{}
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		*calls = append(*calls, map[string]interface{}{"method": method, "params": params})
+		if !supported(method) {
+			return `"error":{"code":-32601,"message":"Method not found"}`
+		}
+		return `"result":` + results[method]
+	})
+	client = New(server.URL)
+	hooks = &recordingHooks{}
+	client.SetHooks(hooks)
+	return client, calls, hooks, closer
+}
+
+func isModernMethod(method string) bool {
+	return !strings.HasPrefix(method, "getConfirmed")
+}
+
+func TestClient_deprecatedFallback_transaction(t *testing.T) {
+	signature := solana.MustSignatureFromBase58("53hsXVoSp7GX9Yrm7EbUH5kGX7XdFH8uVo3W6VrEg2Pgi3DzVFUHb1JfvCUgXSXrBpntMbGt5tBqqBMKhW2CrtK8")
+	txResult := `{"blockTime":1624821990,"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":[],"postBalances":[],"postTokenBalances":[],"preBalances":[],"preTokenBalances":[],"status":{"Ok":null}},"slot":83311386,"transaction":["AQID","base64"]}`
+	results := map[string]string{
+		"getConfirmedTransaction": txResult,
+		"getTransaction":          txResult,
+	}
+
+	t.Run("native", func(t *testing.T) {
+		client, calls, hooks, closer := mockLegacyNode(t, func(string) bool { return true }, results)
+		defer closer()
+
+		out, err := client.GetConfirmedTransaction(context.Background(), signature)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(83311386), out.Slot)
+		require.Len(t, *calls, 1)
+		assert.Equal(t, "getConfirmedTransaction", (*calls)[0]["method"])
+		assert.Equal(t, []interface{}{signature.String(), "json"}, (*calls)[0]["params"])
+		assert.Empty(t, hooks.deprecated)
+	})
+	t.Run("fallback", func(t *testing.T) {
+		client, calls, hooks, closer := mockLegacyNode(t, isModernMethod, results)
+		defer closer()
+
+		out, err := client.GetConfirmedTransaction(context.Background(), signature)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(83311386), out.Slot)
+		assert.Equal(t, []byte{1, 2, 3}, out.Transaction.GetBinary())
+		require.Len(t, *calls, 2)
+		assert.Equal(t, "getTransaction", (*calls)[1]["method"])
+		assert.Equal(t,
+			[]interface{}{
+				signature.String(),
+				map[string]interface{}{"encoding": "json"},
+			},
+			(*calls)[1]["params"],
+		)
+		assert.Equal(t, [][2]string{{"getConfirmedTransaction", "getTransaction"}}, hooks.deprecated)
+	})
+	t.Run("fallback with opts", func(t *testing.T) {
+		client, calls, hooks, closer := mockLegacyNode(t, isModernMethod, results)
+		defer closer()
+
+		_, err := client.GetConfirmedTransactionWithOpts(context.Background(), signature, &GetTransactionOpts{
+			Encoding:   solana.EncodingBase64,
+			Commitment: CommitmentMax,
+		})
+		require.NoError(t, err)
+		require.Len(t, *calls, 2)
+		// The original request is sent as is.
+		assert.Equal(t,
+			[]interface{}{
+				signature.String(),
+				map[string]interface{}{"encoding": "base64", "commitment": "max"},
+			},
+			(*calls)[0]["params"],
+		)
+		assert.Equal(t,
+			[]interface{}{
+				signature.String(),
+				map[string]interface{}{"encoding": "base64", "commitment": "finalized"},
+			},
+			(*calls)[1]["params"],
+		)
+		assert.Len(t, hooks.deprecated, 1)
+	})
+	t.Run("other errors are returned", func(t *testing.T) {
+		server, closer := mockJSONRPC(t, stdjson.RawMessage(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":0}`))
+		defer closer()
+		client := New(server.URL)
+		hooks := &recordingHooks{}
+		client.SetHooks(hooks)
+
+		_, err := client.GetConfirmedTransaction(context.Background(), signature)
+		require.Error(t, err)
+		assert.Empty(t, hooks.deprecated)
+	})
+}
+
+func TestClient_deprecatedFallback_block(t *testing.T) {
+	blockResult := `{"blockHeight":69213636,"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFvF6dxUdd","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[],"transactions":[]}`
+	legacyResult := `{"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFvF6dxUdd","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[],"transactions":[]}`
+	results := map[string]string{
+		"getConfirmedBlock": legacyResult,
+		"getBlock":          blockResult,
+	}
+
+	native, _, _, closer := mockLegacyNode(t, func(string) bool { return true }, results)
+	defer closer()
+	expected, err := native.GetConfirmedBlock(context.Background(), 83987984)
+	require.NoError(t, err)
+
+	client, calls, hooks, closer := mockLegacyNode(t, isModernMethod, results)
+	defer closer()
+
+	out, err := client.GetConfirmedBlock(context.Background(), 83987984)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+	require.Len(t, *calls, 2)
+	assert.Equal(t, "getBlock", (*calls)[1]["method"])
+	assert.Equal(t,
+		[]interface{}{float64(83987984), map[string]interface{}{"encoding": "json"}},
+		(*calls)[1]["params"],
+	)
+
+	rewards := false
+	_, err = client.GetConfirmedBlockWithOpts(context.Background(), 83987984, &GetConfirmedBlockOpts{
+		Encoding:           solana.EncodingBase64,
+		TransactionDetails: TransactionDetailsSignatures,
+		Rewards:            &rewards,
+		Commitment:         CommitmentSingleGossip,
+	})
+	require.NoError(t, err)
+	require.Len(t, *calls, 4)
+	assert.Equal(t,
+		[]interface{}{
+			float64(83987984),
+			map[string]interface{}{
+				"encoding":           "base64",
+				"transactionDetails": "signatures",
+				"rewards":            false,
+				"commitment":         "confirmed",
+			},
+		},
+		(*calls)[3]["params"],
+	)
+	assert.Equal(t,
+		[][2]string{{"getConfirmedBlock", "getBlock"}, {"getConfirmedBlock", "getBlock"}},
+		hooks.deprecated,
+	)
+}
+
+func TestClient_deprecatedFallback_others(t *testing.T) {
+	results := map[string]string{
+		"getBlocks":               `[5,6,7]`,
+		"getBlocksWithLimit":      `[5,6]`,
+		"getSignaturesForAddress": `[{"blockTime":1624821990,"err":null,"memo":null,"signature":"53hsXVoSp7GX9Yrm7EbUH5kGX7XdFH8uVo3W6VrEg2Pgi3DzVFUHb1JfvCUgXSXrBpntMbGt5tBqqBMKhW2CrtK8","slot":83311386}]`,
+	}
+	client, calls, hooks, closer := mockLegacyNode(t, isModernMethod, results)
+	defer closer()
+	ctx := context.Background()
+
+	end := uint64(7)
+	blocks, err := client.GetConfirmedBlocks(ctx, 5, &end, CommitmentRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{5, 6, 7}, blocks)
+	assert.Equal(t, "getBlocks", (*calls)[1]["method"])
+	assert.Equal(t,
+		[]interface{}{float64(5), float64(7), map[string]interface{}{"commitment": "finalized"}},
+		(*calls)[1]["params"],
+	)
+
+	blocks, err = client.GetConfirmedBlocksWithLimit(ctx, 5, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{5, 6}, blocks)
+	assert.Equal(t, "getBlocksWithLimit", (*calls)[3]["method"])
+	assert.Equal(t, []interface{}{float64(5), float64(2)}, (*calls)[3]["params"])
+
+	limit := uint64(1)
+	account := solana.MustPublicKeyFromBase58("8tfDNiaEyrV6Q1U4DEXrEigs9DoDtkugzFbybENEbCDz")
+	signatures, err := client.GetConfirmedSignaturesForAddress2(ctx, account, &GetConfirmedSignaturesForAddress2Opts{
+		Limit:      &limit,
+		Commitment: CommitmentMax,
+	})
+	require.NoError(t, err)
+	require.Len(t, signatures, 1)
+	assert.Equal(t, uint64(83311386), signatures[0].Slot)
+	assert.Equal(t, "getSignaturesForAddress", (*calls)[5]["method"])
+	assert.Equal(t,
+		[]interface{}{account.String(), map[string]interface{}{"limit": float64(1), "commitment": "finalized"}},
+		(*calls)[5]["params"],
+	)
+
+	assert.Equal(t,
+		[][2]string{
+			{"getConfirmedBlocks", "getBlocks"},
+			{"getConfirmedBlocksWithLimit", "getBlocksWithLimit"},
+			{"getConfirmedSignaturesForAddress2", "getSignaturesForAddress"},
+		},
+		hooks.deprecated,
+	)
+}
+
+func TestClient_FindAssociatedTokenAddress(t *testing.T) {
+	wallet := solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	owners := map[string]string{
+		// PayPal USD (PYUSD)
+		"2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo": solana.Token2022ProgramID.String(),
+		// USD Coin (USDC)
+		"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": solana.TokenProgramID.String(),
+		// Not a mint.
+		"9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM": solana.SystemProgramID.String(),
+	}
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		require.Equal(t, "getAccountInfo", method)
+		return fmt.Sprintf(
+			`"result":{"context":{"slot":1},"value":{"data":["","base64"],"executable":false,"lamports":1461600,"owner":%q,"rentEpoch":0}}`,
+			owners[params[0].(string)],
+		)
+	})
+	defer closer()
+	client := New(server.URL)
+
+	address, tokenProgram, err := client.FindAssociatedTokenAddress(
+		context.Background(),
+		wallet,
+		solana.MustPublicKeyFromBase58("2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, solana.MustPublicKeyFromBase58("897krAvWH3RbymaCYE3o9emopUwocieHuKTUk9nySpq6"), address)
+	assert.Equal(t, solana.Token2022ProgramID, tokenProgram)
+
+	address, tokenProgram, err = client.FindAssociatedTokenAddress(
+		context.Background(),
+		wallet,
+		solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, solana.MustPublicKeyFromBase58("FGETo8T8wMcN2wCjav8VK6eh3dLk63evNDPxzLSJra8B"), address)
+	assert.Equal(t, solana.TokenProgramID, tokenProgram)
+
+	_, _, err = client.FindAssociatedTokenAddress(context.Background(), wallet, wallet)
+	require.EqualError(t, err, "account 9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM is not a token mint: owned by 11111111111111111111111111111111")
+}
+
+func TestClient_ResolveSignatures(t *testing.T) {
+	// 300 signatures: two getSignatureStatuses batches.
+	sigs := make([]solana.Signature, 300)
+	for i := range sigs {
+		sigs[i] = solana.Signature{byte(i), byte(i >> 8), 1}
+	}
+	finalized, confirmed, old, unknown := sigs[0], sigs[299], sigs[1], sigs[2]
+
+	var mu sync.Mutex
+	var calls []string
+	var statusBatches []int
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, method)
+		switch method {
+		case "getSignatureStatuses":
+			requested := params[0].([]interface{})
+			statusBatches = append(statusBatches, len(requested))
+			values := make([]string, len(requested))
+			for i, s := range requested {
+				switch s {
+				case finalized.String():
+					values[i] = `{"slot":100,"confirmations":null,"err":null,"confirmationStatus":"finalized"}`
+				case confirmed.String():
+					values[i] = `{"slot":101,"confirmations":3,"err":{"InstructionError":[0,"InvalidArgument"]},"confirmationStatus":"confirmed"}`
+				default:
+					values[i] = `null`
+				}
+			}
+			return `"result":{"context":{"slot":110},"value":[` + strings.Join(values, ",") + `]}`
+		case "getBlockTime":
+			switch params[0].(float64) {
+			case 100:
+				return `"result":1700000100`
+			default:
+				return `"result":1700000101`
+			}
+		case "getTransaction":
+			if params[0] == old.String() {
+				return `"result":{"slot":50,"blockTime":1700000050,"meta":{"err":null},"transaction":["AQ==","base64"]}`
+			}
+			if params[0] == unknown.String() {
+				return `"error":{"code":-32011,"message":"Transaction history is not available from this node"}`
+			}
+			return `"result":null`
+		}
+		return `"error":{"code":-32601,"message":"Method not found"}`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	blockTime := func(v int64) *solana.UnixTimeSeconds {
+		t := solana.UnixTimeSeconds(v)
+		return &t
+	}
+
+	store := NewMemorySigInfoStore()
+	out, err := client.ResolveSignaturesWithOpts(context.Background(), append(sigs, finalized), &ResolveSignaturesOpts{Store: store})
+	var unresolvedErr *UnresolvedSignaturesError
+	require.True(t, errors.As(err, &unresolvedErr), err)
+	require.Len(t, unresolvedErr.Signatures, 297)
+	assert.Contains(t, unresolvedErr.Signatures, unknown)
+
+	require.Len(t, out, 3)
+	assert.Equal(t, SigInfo{Slot: 100, BlockTime: blockTime(1700000100), Finalized: true}, out[finalized])
+	assert.Equal(t, uint64(101), out[confirmed].Slot)
+	assert.Equal(t, blockTime(1700000101), out[confirmed].BlockTime)
+	assert.NotNil(t, out[confirmed].Err)
+	assert.False(t, out[confirmed].Finalized)
+	assert.Equal(t, SigInfo{Slot: 50, BlockTime: blockTime(1700000050), Finalized: true}, out[old])
+
+	assert.Equal(t, []int{256, 44}, statusBatches)
+	count := func(method string) int {
+		n := 0
+		for _, call := range calls {
+			if call == method {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 2, count("getBlockTime"))
+	assert.Equal(t, 298, count("getTransaction"))
+
+	// The finalized infos are cached.
+	calls = nil
+	statusBatches = nil
+	out, err = client.ResolveSignaturesWithOpts(context.Background(), []solana.Signature{finalized, old, confirmed}, &ResolveSignaturesOpts{Store: store})
+	require.NoError(t, err)
+	require.Len(t, out, 3)
+	assert.Equal(t, []string{"getSignatureStatuses", "getBlockTime"}, calls)
+	assert.Equal(t, []int{1}, statusBatches)
+}
+
+func TestClient_concurrentUse(t *testing.T) {
+	// Each response depends on the request, so that a response
+	// delivered to the wrong caller is detected.
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		switch method {
+		case "getProgramAccounts":
+			program := params[0].(string)
+			return `"result":[{"pubkey":"` + program + `","account":{"data":["","base64"],"executable":false,"lamports":1,"owner":"` + program + `","rentEpoch":0}}]`
+		case "getBalance":
+			return fmt.Sprintf(`"result":{"context":{"slot":1},"value":%d}`, len(params[0].(string)))
+		}
+		return `"error":{"code":-32601,"message":"Method not found"}`
+	})
+	defer closer()
+	client := New(server.URL)
+
+	const goroutines = 32
+	const calls = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*calls)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				program := solana.PublicKey{byte(g), byte(i), 1}
+				accounts, err := client.GetProgramAccounts(context.Background(), program)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if len(accounts) != 1 || !accounts[0].Pubkey.Equals(program) || !accounts[0].Account.Owner.Equals(program) {
+					errs <- fmt.Errorf("wrong accounts for %s", program)
+				}
+
+				balance, err := client.GetBalance(context.Background(), program, CommitmentFinalized)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if balance.Value != uint64(len(program.String())) {
+					errs <- fmt.Errorf("wrong balance for %s: %d", program, balance.Value)
+				}
+
+				client.SetHooks(&recordingHooks{})
+				client.getHooks()
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestClient_LamportsPerSignature(t *testing.T) {
+	blockhash := "DvLEyV2GHk86K5GojpqnRsvhfMF5kdZomKMnhVpvHyqK"
+	methodNotFound := `"error":{"code":-32601,"message":"Method not found"}`
+
+	t.Run("fee for message", func(t *testing.T) {
+		var methods []string
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			methods = append(methods, method)
+			switch method {
+			case "getLatestBlockhash":
+				return `"result":{"context":{"slot":5068},"value":{"blockhash":"` + blockhash + `","lastValidBlockHeight":5218}}`
+			case "getFeeForMessage":
+				data, err := base64.StdEncoding.DecodeString(params[0].(string))
+				require.NoError(t, err)
+				var msg solana.Message
+				require.NoError(t, msg.UnmarshalWithDecoder(bin.NewBinDecoder(data)))
+				assert.Equal(t, uint8(1), msg.Header.NumRequiredSignatures)
+				assert.Equal(t, blockhash, msg.RecentBlockhash.String())
+				assert.Empty(t, msg.Instructions)
+				return `"result":{"context":{"slot":5068},"value":5000}`
+			}
+			t.Errorf("unexpected method %s", method)
+			return ""
+		})
+		defer closer()
+
+		out, err := New(server.URL).LamportsPerSignature(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5000), out)
+		assert.Equal(t, []string{"getLatestBlockhash", "getFeeForMessage"}, methods)
+	})
+	t.Run("falls back to the fee calculator", func(t *testing.T) {
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			switch method {
+			case "getLatestBlockhash":
+				return methodNotFound
+			case "getRecentBlockhash":
+				return `"result":{"context":{"slot":5068},"value":{"blockhash":"` + blockhash + `","feeCalculator":{"lamportsPerSignature":10000}}}`
+			}
+			t.Errorf("unexpected method %s", method)
+			return ""
+		})
+		defer closer()
+
+		out, err := New(server.URL).LamportsPerSignature(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10000), out)
+	})
+	t.Run("no fee and no fee calculator", func(t *testing.T) {
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			switch method {
+			case "getLatestBlockhash":
+				return `"result":{"context":{"slot":5068},"value":{"blockhash":"` + blockhash + `","lastValidBlockHeight":5218}}`
+			case "getFeeForMessage":
+				return `"result":{"context":{"slot":5068},"value":null}`
+			case "getRecentBlockhash":
+				return `"result":{"context":{"slot":5068},"value":{"blockhash":"` + blockhash + `"}}`
+			}
+			t.Errorf("unexpected method %s", method)
+			return ""
+		})
+		defer closer()
+
+		_, err := New(server.URL).LamportsPerSignature(context.Background())
+		require.EqualError(t, err, "unable to get lamports per signature: the node returned neither a fee for the message nor a fee calculator")
+	})
+	t.Run("other errors are returned", func(t *testing.T) {
+		server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+			return `"error":{"code":-32602,"message":"Invalid params"}`
+		})
+		defer closer()
+
+		_, err := New(server.URL).LamportsPerSignature(context.Background())
+		require.Error(t, err)
+	})
+}
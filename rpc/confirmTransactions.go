@@ -0,0 +1,190 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultConfirmPollInterval is the default interval between two polls
+// of the statuses by ConfirmTransactions.
+const DefaultConfirmPollInterval = time.Second
+
+type ConfirmTransactionsOpts struct {
+	// The commitment at which the transactions are considered confirmed.
+	// Defaults to "confirmed".
+	Commitment CommitmentType
+
+	// How long to wait for the confirmations; zero means until the context is done.
+	Timeout time.Duration
+
+	// How often the statuses are polled. Defaults to DefaultConfirmPollInterval.
+	PollInterval time.Duration
+
+	// The last valid block height of the transactions, by signature, if known
+	// (see solana.Transaction.LastValidBlockHeight): a transaction that is not
+	// processed by then is expired, and is not waited for anymore.
+	LastValidBlockHeights map[solana.Signature]uint64
+}
+
+// UnconfirmedTransactionsError is returned (together with the statuses
+// of the other transactions) by ConfirmTransactions when some transactions
+// were not confirmed.
+type UnconfirmedTransactionsError struct {
+	// The transactions still waited for when the timeout expired or the context was done.
+	Pending []solana.Signature
+	// The transactions whose blockhash expired before they were processed.
+	Expired []solana.Signature
+	// The error of the context, if it is done.
+	Err error
+}
+
+func (e *UnconfirmedTransactionsError) Error() string {
+	msg := fmt.Sprintf("%d transactions not confirmed, %d expired", len(e.Pending), len(e.Expired))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *UnconfirmedTransactionsError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, solana.ErrBlockhashExpired) true if some transactions expired.
+func (e *UnconfirmedTransactionsError) Is(target error) bool {
+	return target == solana.ErrBlockhashExpired && len(e.Expired) != 0
+}
+
+// ConfirmTransactions waits for the transactions to be confirmed at the commitment;
+// see ConfirmTransactionsWithOpts.
+func (cl *Client) ConfirmTransactions(
+	ctx context.Context,
+	sigs []solana.Signature,
+	commitment CommitmentType,
+	timeout time.Duration,
+) (map[solana.Signature]*SignatureStatusesResult, error) {
+	return cl.ConfirmTransactionsWithOpts(ctx, sigs, &ConfirmTransactionsOpts{
+		Commitment: commitment,
+		Timeout:    timeout,
+	})
+}
+
+// ConfirmTransactionsWithOpts waits for many transactions at once: it polls
+// their statuses with getSignatureStatuses (in batches of MaxSignatureStatusesBatchSize)
+// until each one is confirmed at the commitment, fails, or expires (if its last valid
+// block height is known); opts can be nil.
+//
+// It returns the final status of the transactions that were confirmed or failed
+// (see SignatureStatusesResult.Err), by signature. If some transactions expired,
+// or the timeout expired (or the context was done) before all of them were confirmed,
+// the statuses are returned together with an *UnconfirmedTransactionsError listing the others.
+func (cl *Client) ConfirmTransactionsWithOpts(
+	ctx context.Context,
+	sigs []solana.Signature,
+	opts *ConfirmTransactionsOpts,
+) (map[solana.Signature]*SignatureStatusesResult, error) {
+	if opts == nil {
+		opts = &ConfirmTransactionsOpts{}
+	}
+	commitment := opts.Commitment
+	if commitment == "" {
+		commitment = CommitmentConfirmed
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultConfirmPollInterval
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	out := make(map[solana.Signature]*SignatureStatusesResult, len(sigs))
+	seen := make(map[solana.Signature]struct{}, len(sigs))
+	var pending, expired []solana.Signature
+	for _, sig := range sigs {
+		if _, ok := seen[sig]; ok {
+			continue
+		}
+		seen[sig] = struct{}{}
+		pending = append(pending, sig)
+	}
+
+	for len(pending) != 0 {
+		// The block height is read before the statuses: a transaction without a status
+		// past its last valid block height can't be processed anymore.
+		var blockHeight uint64
+		if len(opts.LastValidBlockHeights) != 0 {
+			// Errors are transient: the expiry is checked again at the next poll.
+			blockHeight, _ = cl.GetBlockHeight(ctx, commitment)
+		}
+
+		var waiting []solana.Signature
+		for start := 0; start < len(pending); start += MaxSignatureStatusesBatchSize {
+			end := start + MaxSignatureStatusesBatchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			batch := pending[start:end]
+
+			res, err := cl.GetSignatureStatuses(ctx, false, batch...)
+			if err != nil || len(res.Value) != len(batch) {
+				// Try again at the next poll.
+				waiting = append(waiting, batch...)
+				continue
+			}
+			for i, status := range res.Value {
+				sig := batch[i]
+				switch {
+				case status != nil && (status.Err != nil || status.ConfirmationStatus.IsAtLeast(commitment)):
+					out[sig] = status
+				case status == nil && isExpiredAt(opts.LastValidBlockHeights, sig, blockHeight):
+					expired = append(expired, sig)
+				default:
+					waiting = append(waiting, sig)
+				}
+			}
+		}
+		pending = waiting
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return out, &UnconfirmedTransactionsError{
+				Pending: pending,
+				Expired: expired,
+				Err:     ctx.Err(),
+			}
+		case <-time.After(interval):
+		}
+	}
+	if len(expired) != 0 {
+		return out, &UnconfirmedTransactionsError{Expired: expired}
+	}
+	return out, nil
+}
+
+func isExpiredAt(lastValidBlockHeights map[solana.Signature]uint64, sig solana.Signature, blockHeight uint64) bool {
+	lastValid, ok := lastValidBlockHeights[sig]
+	return ok && lastValid != 0 && blockHeight > lastValid
+}
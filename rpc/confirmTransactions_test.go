@@ -0,0 +1,182 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStatuses serves getSignatureStatuses with the status returned by statusOf
+// for each signature at each poll (numbered from 1; each batch of a poll has the same number).
+func mockStatuses(
+	t *testing.T,
+	numSigs int,
+	statusOf func(poll int, sig solana.Signature) string,
+) (client *Client, batchSizes func() []int) {
+	var mu sync.Mutex
+	var sizes []int
+	requested := 0
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "getBlockHeight":
+			return `"result":1000`
+		case "getSignatureStatuses":
+		default:
+			return `"error":{"code":-32601,"message":"Method not found"}`
+		}
+		sigs := params[0].([]interface{})
+		sizes = append(sizes, len(sigs))
+		poll := requested/numSigs + 1
+		requested += len(sigs)
+		var statuses []string
+		for _, sig := range sigs {
+			statuses = append(statuses, statusOf(poll, solana.MustSignatureFromBase58(sig.(string))))
+		}
+		return fmt.Sprintf(`"result":{"context":{"slot":1},"value":[%s]}`, strings.Join(statuses, ","))
+	})
+	t.Cleanup(closer)
+	return New(server.URL), func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int{}, sizes...)
+	}
+}
+
+func testSignatures(n int) []solana.Signature {
+	sigs := make([]solana.Signature, n)
+	for i := range sigs {
+		sigs[i] = solana.Signature{byte(i), byte(i >> 8), 1}
+	}
+	return sigs
+}
+
+func testStatus(status ConfirmationStatusType, err string) string {
+	return fmt.Sprintf(`{"slot":42,"confirmations":null,"err":%s,"confirmationStatus":%q}`, err, status)
+}
+
+func TestClient_ConfirmTransactions(t *testing.T) {
+	sigs := testSignatures(500)
+	failed := sigs[7]
+	client, batchSizes := mockStatuses(t, len(sigs), func(poll int, sig solana.Signature) string {
+		switch {
+		case poll == 1:
+			return "null"
+		case sig == failed:
+			return testStatus(ConfirmationStatusProcessed, `{"InstructionError":[0,"Custom"]}`)
+		case poll == 2 && sig[0]%2 == 1:
+			return testStatus(ConfirmationStatusProcessed, "null")
+		default:
+			return testStatus(ConfirmationStatusConfirmed, "null")
+		}
+	})
+
+	out, err := client.ConfirmTransactionsWithOpts(context.Background(), append(sigs, sigs[0]), &ConfirmTransactionsOpts{
+		PollInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Len(t, out, 500)
+	for _, sig := range sigs {
+		require.NotNil(t, out[sig])
+		if sig == failed {
+			assert.NotNil(t, out[sig].Err)
+		} else {
+			assert.Equal(t, ConfirmationStatusConfirmed, out[sig].ConfirmationStatus)
+		}
+	}
+	// 2 batches for all the transactions, 2 for all of them again,
+	// then one for the odd ones that were only processed.
+	assert.Equal(t, []int{256, 244, 256, 244, 249}, batchSizes())
+}
+
+func TestClient_ConfirmTransactions_timeout(t *testing.T) {
+	sigs := testSignatures(3)
+	client, _ := mockStatuses(t, len(sigs), func(poll int, sig solana.Signature) string {
+		if sig == sigs[1] {
+			return "null"
+		}
+		return testStatus(ConfirmationStatusFinalized, "null")
+	})
+
+	out, err := client.ConfirmTransactionsWithOpts(context.Background(), sigs, &ConfirmTransactionsOpts{
+		Commitment:   CommitmentFinalized,
+		Timeout:      50 * time.Millisecond,
+		PollInterval: time.Millisecond,
+	})
+	var unconfirmed *UnconfirmedTransactionsError
+	require.True(t, errors.As(err, &unconfirmed), err)
+	assert.Equal(t, []solana.Signature{sigs[1]}, unconfirmed.Pending)
+	assert.Empty(t, unconfirmed.Expired)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.False(t, errors.Is(err, solana.ErrBlockhashExpired))
+	assert.Len(t, out, 2)
+	assert.Contains(t, out, sigs[0])
+	assert.Contains(t, out, sigs[2])
+}
+
+func TestClient_ConfirmTransactions_expired(t *testing.T) {
+	sigs := testSignatures(3)
+	client, _ := mockStatuses(t, len(sigs), func(poll int, sig solana.Signature) string {
+		if sig == sigs[0] {
+			return testStatus(ConfirmationStatusConfirmed, "null")
+		}
+		return "null"
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	// The block height is 1000: sigs[1] is expired, sigs[2] is not.
+	out, err := client.ConfirmTransactionsWithOpts(ctx, sigs, &ConfirmTransactionsOpts{
+		PollInterval: time.Millisecond,
+		LastValidBlockHeights: map[solana.Signature]uint64{
+			sigs[1]: 999,
+			sigs[2]: 1000,
+		},
+	})
+	var unconfirmed *UnconfirmedTransactionsError
+	require.True(t, errors.As(err, &unconfirmed), err)
+	assert.Equal(t, []solana.Signature{sigs[1]}, unconfirmed.Expired)
+	assert.Equal(t, []solana.Signature{sigs[2]}, unconfirmed.Pending)
+	assert.True(t, errors.Is(err, solana.ErrBlockhashExpired))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Len(t, out, 1)
+
+	t.Run("all expired or confirmed", func(t *testing.T) {
+		out, err := client.ConfirmTransactions(context.Background(), sigs[:1], "", 0)
+		require.NoError(t, err)
+		assert.Len(t, out, 1)
+
+		_, err = client.ConfirmTransactionsWithOpts(context.Background(), sigs[:2], &ConfirmTransactionsOpts{
+			LastValidBlockHeights: map[solana.Signature]uint64{sigs[1]: 999},
+		})
+		assert.EqualError(t, err, "0 transactions not confirmed, 1 expired")
+		assert.True(t, errors.Is(err, solana.ErrBlockhashExpired))
+	})
+}
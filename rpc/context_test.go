@@ -0,0 +1,159 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contextResults are all the results with a context;
+// a new one must be added here.
+var contextResults = []ResultWithContext{
+	&GetAccountInfoResult{},
+	&GetBalanceResult{},
+	&GetBlockProductionResult{},
+	&GetFeeCalculatorForBlockhashResult{},
+	&GetFeeForMessageResult{},
+	&GetFeeRateGovernorResult{},
+	&GetFeesResult{},
+	&GetLargestAccountsResult{},
+	&GetLatestBlockhashResult{},
+	&GetMultipleAccountsResult{},
+	&GetProgramAccountsWithContextResult{},
+	&GetRecentBlockhashResult{},
+	&GetSignatureStatusesResult{},
+	&GetSupplyResult{},
+	&GetTokenAccountBalanceResult{},
+	&GetTokenAccountsResult{},
+	&GetTokenLargestAccountsResult{},
+	&GetTokenSupplyResult{},
+	&IsValidBlockhashResult{},
+	&SimulateTransactionResponse{},
+}
+
+func TestResultWithContext(t *testing.T) {
+	for _, result := range contextResults {
+		name := reflect.TypeOf(result).Elem().Name()
+		t.Run(name, func(t *testing.T) {
+			// A fresh value of the type.
+			out := reflect.New(reflect.TypeOf(result).Elem()).Interface()
+			require.NoError(t, json.Unmarshal(
+				[]byte(`{"context":{"slot":123,"apiVersion":"1.18.1"},"value":null}`),
+				out,
+			))
+			assert.Equal(t,
+				Context{Slot: 123, APIVersion: "1.18.1"},
+				out.(ResultWithContext).GetContext(),
+			)
+
+			// The value is the only other field.
+			typ := reflect.TypeOf(result).Elem()
+			require.Equal(t, 2, typ.NumField())
+			assert.Equal(t, "RPCContext", typ.Field(0).Name)
+			assert.True(t, typ.Field(0).Anonymous)
+			assert.Equal(t, "Value", typ.Field(1).Name)
+			assert.Equal(t, `json:"value"`, string(typ.Field(1).Tag))
+		})
+	}
+}
+
+func TestWithContext_UnmarshalJSON(t *testing.T) {
+	// The results decoded with the shared envelope.
+	for _, result := range []stdjson.Unmarshaler{
+		&GetAccountInfoResult{},
+		&GetBalanceResult{},
+		&GetMultipleAccountsResult{},
+		&GetTokenAccountBalanceResult{},
+		&GetTokenSupplyResult{},
+	} {
+		t.Run(fmt.Sprintf("%T", result), func(t *testing.T) {
+			require.NoError(t, result.UnmarshalJSON([]byte(`{"context":{"slot":5}}`)))
+			assert.Equal(t, Context{Slot: 5}, result.(ResultWithContext).GetContext())
+			assert.Error(t, result.UnmarshalJSON([]byte(`{"context":{"slot":5},"value":"x"}`)))
+		})
+	}
+
+	var balance GetBalanceResult
+	require.NoError(t, json.Unmarshal([]byte(`{"context":{"slot":9,"apiVersion":"2.0.3"},"value":1000}`), &balance))
+	assert.Equal(t, GetBalanceResult{
+		RPCContext: RPCContext{Context: Context{Slot: 9, APIVersion: "2.0.3"}},
+		Value:      1000,
+	}, balance)
+
+	var account GetAccountInfoResult
+	require.NoError(t, json.Unmarshal([]byte(`{"context":{"slot":9},"value":null}`), &account))
+	assert.Nil(t, account.Value)
+
+	var supply GetTokenSupplyResult
+	require.NoError(t, stdjson.Unmarshal(
+		[]byte(`{"context":{"slot":9},"value":{"amount":"1000","decimals":3,"uiAmount":1,"uiAmountString":"1"}}`),
+		&supply,
+	))
+	assert.Equal(t, uint64(9), supply.Context.Slot)
+	assert.Equal(t, "1000", supply.Value.Amount)
+}
+
+func TestClient_resultsWithContext(t *testing.T) {
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		var value string
+		switch method {
+		case "getBalance":
+			value = `42`
+		case "getAccountInfo":
+			value = `{"data":["","base64"],"executable":false,"lamports":1,"owner":"11111111111111111111111111111111","rentEpoch":0}`
+		case "getMultipleAccounts":
+			value = `[null]`
+		case "getTokenAccountBalance", "getTokenSupply":
+			value = `{"amount":"1000","decimals":3,"uiAmount":1,"uiAmountString":"1"}`
+		default:
+			return `"error":{"code":-32601,"message":"Method not found"}`
+		}
+		return fmt.Sprintf(`"result":{"context":{"slot":77},"value":%s}`, value)
+	})
+	defer closer()
+	client := New(server.URL)
+	ctx := context.Background()
+	key := solana.NewWallet().PublicKey()
+
+	var results []ResultWithContext
+	balance, err := client.GetBalance(ctx, key, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), balance.Value)
+	results = append(results, balance)
+	account, err := client.GetAccountInfo(ctx, key)
+	require.NoError(t, err)
+	results = append(results, account)
+	accounts, err := client.GetMultipleAccounts(ctx, key)
+	require.NoError(t, err)
+	results = append(results, accounts)
+	tokenBalance, err := client.GetTokenAccountBalance(ctx, key, "")
+	require.NoError(t, err)
+	results = append(results, tokenBalance)
+	supply, err := client.GetTokenSupply(ctx, key, "")
+	require.NoError(t, err)
+	results = append(results, supply)
+
+	for _, result := range results {
+		assert.Equal(t, uint64(77), result.GetContext().Slot, "%T", result)
+	}
+}
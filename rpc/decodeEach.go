@@ -0,0 +1,183 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+type DecodeEachOpts struct {
+	// If true, the data is decoded as borsh instead of bin.
+	Borsh bool
+
+	// If true, the data must be fully consumed by the decoding:
+	// e.g. a token account (165 bytes) is not accepted as a mint (82 bytes).
+	ExactSize bool
+
+	// If true, DecodeEachWithOpts stops at the first account whose data
+	// can't be decoded, and returns its *AccountDecodeError.
+	StopOnError bool
+}
+
+// AccountDecodeError is the error of decoding the data of an account.
+type AccountDecodeError struct {
+	Pubkey solana.PublicKey
+	Err    error
+}
+
+func (e *AccountDecodeError) Error() string {
+	return fmt.Sprintf("unable to decode account %s: %s", e.Pubkey, e.Err)
+}
+
+func (e *AccountDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeEachError is returned by DecodeEachWithOpts when the data
+// of some accounts could not be decoded (and StopOnError is not set).
+type DecodeEachError struct {
+	Errors []*AccountDecodeError
+}
+
+func (e *DecodeEachError) Error() string {
+	return fmt.Sprintf("unable to decode %d accounts, first: %s", len(e.Errors), e.Errors[0])
+}
+
+var decoderPool = sync.Pool{
+	New: func() interface{} {
+		return bin.NewBinDecoder(nil)
+	},
+}
+
+// valuePools holds a *sync.Pool of values for each decoded type.
+var valuePools sync.Map
+
+func valuePool(typ reflect.Type) *sync.Pool {
+	if pool, ok := valuePools.Load(typ); ok {
+		return pool.(*sync.Pool)
+	}
+	pool, _ := valuePools.LoadOrStore(typ, &sync.Pool{
+		New: func() interface{} {
+			return reflect.New(typ)
+		},
+	})
+	return pool.(*sync.Pool)
+}
+
+// DecodeEach is like DecodeEachWithOpts with the default options:
+// the data is decoded as bin, and all the accounts are decoded
+// even if some of them fail.
+func DecodeEach(
+	results GetProgramAccountsResult,
+	target interface{},
+	fn func(pubkey solana.PublicKey, val interface{}) error,
+) error {
+	return DecodeEachWithOpts(results, target, nil, fn)
+}
+
+// DecodeEachWithOpts decodes the data of each account of the results
+// into a value of the type pointed to by target (e.g. new(token.Mint),
+// or (*token.Mint)(nil)), and calls fn with the account pubkey and that value,
+// which is of the same type as target.
+//
+// The decoders and the values are pooled and reused, so decoding many accounts
+// allocates much less than a new decoder and value for each account:
+// the value is only valid until fn returns, and must be copied to be kept.
+//
+// If fn returns an error, the decoding is aborted and that error is returned.
+// The accounts whose data can't be decoded are skipped, and a *DecodeEachError
+// listing them is returned at the end (see DecodeEachOpts.StopOnError).
+func DecodeEachWithOpts(
+	results GetProgramAccountsResult,
+	target interface{},
+	opts *DecodeEachOpts,
+	fn func(pubkey solana.PublicKey, val interface{}) error,
+) error {
+	typ := reflect.TypeOf(target)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return fmt.Errorf("target must be a pointer, got %T", target)
+	}
+	if opts == nil {
+		opts = &DecodeEachOpts{}
+	}
+	encoding := bin.EncodingBin
+	if opts.Borsh {
+		encoding = bin.EncodingBorsh
+	}
+
+	decoder := decoderPool.Get().(*bin.Decoder)
+	decoder.SetEncoding(encoding)
+	defer func() {
+		decoder.Reset(nil)
+		decoderPool.Put(decoder)
+	}()
+	pool := valuePool(typ.Elem())
+	val := pool.Get().(reflect.Value)
+	defer pool.Put(val)
+	zero := reflect.Zero(typ.Elem())
+	iface := val.Interface()
+
+	var failed []*AccountDecodeError
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		val.Elem().Set(zero)
+		if err := decodeAccountData(decoder, result.Account, iface, opts.ExactSize); err != nil {
+			decodeErr := &AccountDecodeError{Pubkey: result.Pubkey, Err: err}
+			if opts.StopOnError {
+				return decodeErr
+			}
+			failed = append(failed, decodeErr)
+			continue
+		}
+		if err := fn(result.Pubkey, iface); err != nil {
+			return err
+		}
+	}
+	if len(failed) != 0 {
+		return &DecodeEachError{Errors: failed}
+	}
+	return nil
+}
+
+func decodeAccountData(decoder *bin.Decoder, account *Account, into interface{}, exactSize bool) (err error) {
+	if account == nil || account.Data == nil {
+		return fmt.Errorf("no account data")
+	}
+	data, err := account.Data.GetBinaryOrErr()
+	if err != nil {
+		return err
+	}
+	// Malformed data (e.g. a huge length prefix) can make a decoder panic.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while decoding %d bytes: %v", len(data), r)
+		}
+	}()
+	decoder.Reset(data)
+	if err := decoder.Decode(into); err != nil {
+		return err
+	}
+	if exactSize && decoder.Remaining() != 0 {
+		return fmt.Errorf("%d bytes left after decoding %d bytes", decoder.Remaining(), len(data))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeEachValue struct {
+	A uint32
+	B []byte
+}
+
+func TestDecodeEach(t *testing.T) {
+	keys := []solana.PublicKey{
+		solana.NewWallet().PublicKey(),
+		solana.NewWallet().PublicKey(),
+		solana.NewWallet().PublicKey(),
+		solana.NewWallet().PublicKey(),
+	}
+	results := GetProgramAccountsResult{
+		{Pubkey: keys[0], Account: &Account{Data: DataBytesOrJSONFromBytes([]byte{1, 0, 0, 0, 2, 7, 8})}},
+		// Too short.
+		{Pubkey: keys[1], Account: &Account{Data: DataBytesOrJSONFromBytes([]byte{1, 0})}},
+		// The B of the previous value must not leak into this one.
+		{Pubkey: keys[2], Account: &Account{Data: DataBytesOrJSONFromBytes([]byte{3, 0, 0, 0, 0, 9})}},
+		{Pubkey: keys[3], Account: &Account{Data: &DataBytesOrJSON{rawDataEncoding: solana.EncodingJSONParsed}}},
+	}
+
+	t.Run("default", func(t *testing.T) {
+		var decoded []decodeEachValue
+		var pubkeys []solana.PublicKey
+		err := DecodeEach(results, (*decodeEachValue)(nil), func(pubkey solana.PublicKey, val interface{}) error {
+			pubkeys = append(pubkeys, pubkey)
+			decoded = append(decoded, *val.(*decodeEachValue))
+			return nil
+		})
+		assert.Equal(t, []solana.PublicKey{keys[0], keys[2]}, pubkeys)
+		assert.Equal(t, []decodeEachValue{{A: 1, B: []byte{7, 8}}, {A: 3, B: []byte{}}}, decoded)
+
+		var eachErr *DecodeEachError
+		require.True(t, errors.As(err, &eachErr), err)
+		require.Len(t, eachErr.Errors, 2)
+		assert.Equal(t, keys[1], eachErr.Errors[0].Pubkey)
+		assert.Equal(t, keys[3], eachErr.Errors[1].Pubkey)
+		assert.True(t, errors.Is(eachErr.Errors[1], ErrNotBinaryData))
+		assert.Contains(t, err.Error(), "unable to decode 2 accounts, first: unable to decode account "+keys[1].String())
+	})
+
+	t.Run("exact size", func(t *testing.T) {
+		var decoded []decodeEachValue
+		err := DecodeEachWithOpts(results[2:3], new(decodeEachValue), &DecodeEachOpts{ExactSize: true}, func(pubkey solana.PublicKey, val interface{}) error {
+			decoded = append(decoded, *val.(*decodeEachValue))
+			return nil
+		})
+		var eachErr *DecodeEachError
+		require.True(t, errors.As(err, &eachErr), err)
+		assert.EqualError(t, eachErr.Errors[0].Err, "1 bytes left after decoding 6 bytes")
+		assert.Empty(t, decoded)
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		calls := 0
+		err := DecodeEachWithOpts(results, new(decodeEachValue), &DecodeEachOpts{StopOnError: true}, func(pubkey solana.PublicKey, val interface{}) error {
+			calls++
+			return nil
+		})
+		var decodeErr *AccountDecodeError
+		require.True(t, errors.As(err, &decodeErr), err)
+		assert.Equal(t, keys[1], decodeErr.Pubkey)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		err := DecodeEach(results, new(decodeEachValue), func(pubkey solana.PublicKey, val interface{}) error {
+			return stop
+		})
+		assert.Equal(t, stop, err)
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		err := DecodeEach(results, decodeEachValue{}, nil)
+		assert.EqualError(t, err, "target must be a pointer, got rpc.decodeEachValue")
+	})
+}
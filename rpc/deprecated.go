@@ -19,11 +19,64 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
+// The deprecated "confirmed" methods have been removed from the newer nodes.
+// When a node replies that it doesn't know one of them, the request
+// is sent again using the method that replaces it (with equivalent params),
+// and the hooks of the client are notified.
+
+// callDeprecated calls the deprecated method, falling back to
+// the replacement method if the node doesn't support it.
+func (cl *Client) callDeprecated(
+	ctx context.Context,
+	out interface{},
+	method string,
+	params []interface{},
+	replacement string,
+	replacementParams []interface{},
+) error {
+	err := cl.rpcClient.CallForInto(ctx, out, method, params)
+	var rpcErr *jsonrpc.RPCError
+	if err == nil || !errors.As(err, &rpcErr) || rpcErr.Code != ErrorCodeMethodNotFound {
+		return err
+	}
+	cl.getHooks().OnDeprecatedMethod(method, replacement)
+	return cl.rpcClient.CallForInto(ctx, out, replacement, replacementParams)
+}
+
+// modernCommitment maps the commitment levels deprecated in v1.5.5
+// to the equivalent ones, which are the only ones supported by the newer methods.
+func modernCommitment(commitment CommitmentType) CommitmentType {
+	switch commitment {
+	case CommitmentMax, CommitmentRoot:
+		return CommitmentFinalized
+	case CommitmentSingle, CommitmentSingleGossip:
+		return CommitmentConfirmed
+	case CommitmentRecent:
+		return CommitmentProcessed
+	}
+	return commitment
+}
+
+// withModernCommitment returns a copy of the config object of a request,
+// with its commitment (if any) mapped by modernCommitment.
+func withModernCommitment(obj M) M {
+	out := M{}
+	for k, v := range obj {
+		out[k] = v
+	}
+	if commitment, ok := obj["commitment"].(CommitmentType); ok {
+		out["commitment"] = modernCommitment(commitment)
+	}
+	return out
+}
+
 // GetConfirmedBlock returns identity and transaction information about a confirmed block in the ledger.
 //
 // DEPRECATED: Please use `getBlock` instead.
@@ -64,8 +117,8 @@ func (cl *Client) GetConfirmedBlockWithOpts(
 ) (out *GetConfirmedBlockResult, err error) {
 
 	params := []interface{}{slot}
+	obj := M{}
 	if opts != nil {
-		obj := M{}
 		if opts.Encoding != "" {
 			obj["encoding"] = opts.Encoding
 		}
@@ -83,7 +136,16 @@ func (cl *Client) GetConfirmedBlockWithOpts(
 		}
 	}
 
-	err = cl.rpcClient.CallForInto(ctx, &out, "getConfirmedBlock", params)
+	// The result of getBlock is a superset of the one of getConfirmedBlock.
+	modernObj := withModernCommitment(obj)
+	if _, ok := modernObj["encoding"]; !ok {
+		modernObj["encoding"] = solana.EncodingJSON
+	}
+	err = cl.callDeprecated(
+		ctx, &out,
+		"getConfirmedBlock", params,
+		"getBlock", []interface{}{slot, modernObj},
+	)
 	return
 }
 
@@ -106,11 +168,17 @@ func (cl *Client) GetConfirmedBlocks(
 	if endSlot != nil {
 		params = append(params, endSlot)
 	}
+	modernParams := append([]interface{}{}, params...)
 	if commitment != "" {
 		params = append(params, M{"commitment": string(commitment)})
+		modernParams = append(modernParams, M{"commitment": string(modernCommitment(commitment))})
 	}
 
-	err = cl.rpcClient.CallForInto(ctx, &out, "getConfirmedBlocks", params)
+	err = cl.callDeprecated(
+		ctx, &out,
+		"getConfirmedBlocks", params,
+		"getBlocks", modernParams,
+	)
 	return
 }
 
@@ -126,11 +194,17 @@ func (cl *Client) GetConfirmedBlocksWithLimit(
 ) (out []uint64, err error) {
 
 	params := []interface{}{startSlot, limit}
+	modernParams := []interface{}{startSlot, limit}
 	if commitment != "" {
 		params = append(params, M{"commitment": string(commitment)})
+		modernParams = append(modernParams, M{"commitment": string(modernCommitment(commitment))})
 	}
 
-	err = cl.rpcClient.CallForInto(ctx, &out, "getConfirmedBlocksWithLimit", params)
+	err = cl.callDeprecated(
+		ctx, &out,
+		"getConfirmedBlocksWithLimit", params,
+		"getBlocksWithLimit", modernParams,
+	)
 	return
 }
 
@@ -146,6 +220,7 @@ func (cl *Client) GetConfirmedSignaturesForAddress2(
 ) (out GetConfirmedSignaturesForAddress2Result, err error) {
 
 	params := []interface{}{address}
+	modernParams := []interface{}{address}
 
 	if opts != nil {
 		obj := M{}
@@ -163,10 +238,15 @@ func (cl *Client) GetConfirmedSignaturesForAddress2(
 		}
 		if len(obj) > 0 {
 			params = append(params, obj)
+			modernParams = append(modernParams, withModernCommitment(obj))
 		}
 	}
 
-	err = cl.rpcClient.CallForInto(ctx, &out, "getConfirmedSignaturesForAddress2", params)
+	err = cl.callDeprecated(
+		ctx, &out,
+		"getConfirmedSignaturesForAddress2", params,
+		"getSignaturesForAddress", modernParams,
+	)
 	return
 }
 
@@ -177,7 +257,11 @@ func (cl *Client) GetConfirmedTransaction(
 ) (out *TransactionWithMeta, err error) {
 	params := []interface{}{signature, "json"}
 
-	err = cl.rpcClient.CallForInto(ctx, &out, "getConfirmedTransaction", params)
+	err = cl.callDeprecated(
+		ctx, &out,
+		"getConfirmedTransaction", params,
+		"getTransaction", []interface{}{signature, M{"encoding": solana.EncodingJSON}},
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -194,8 +278,8 @@ func (cl *Client) GetConfirmedTransactionWithOpts(
 	opts *GetTransactionOpts,
 ) (out *TransactionWithMeta, err error) {
 	params := []interface{}{signature}
+	obj := M{}
 	if opts != nil {
-		obj := M{}
 		if opts.Encoding != "" {
 			if !solana.IsAnyOfEncodingType(
 				opts.Encoding,
@@ -217,7 +301,15 @@ func (cl *Client) GetConfirmedTransactionWithOpts(
 			params = append(params, obj)
 		}
 	}
-	err = cl.rpcClient.CallForInto(ctx, &out, "getConfirmedTransaction", params)
+	modernObj := withModernCommitment(obj)
+	if _, ok := modernObj["encoding"]; !ok {
+		modernObj["encoding"] = solana.EncodingJSON
+	}
+	err = cl.callDeprecated(
+		ctx, &out,
+		"getConfirmedTransaction", params,
+		"getTransaction", []interface{}{signature, modernObj},
+	)
 	if err != nil {
 		return nil, err
 	}
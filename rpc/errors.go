@@ -23,3 +23,22 @@ package rpc
 
 // instruction error
 // - https://github.com/solana-labs/solana/blob/f6371cce176d481b4132e5061262ca015db0f8b1/sdk/program/src/instruction.rs
+
+// Codes of the JSON-RPC errors returned by the node (see rpc_custom_error.rs above),
+// to be compared with the Code of a *jsonrpc.RPCError.
+const (
+	// The node doesn't have the block (yet), e.g. for a recent slot.
+	ErrorCodeBlockNotAvailable = -32004
+	// The node is behind the cluster.
+	ErrorCodeNodeUnhealthy = -32005
+	// No block was produced for the slot.
+	ErrorCodeSlotSkipped = -32007
+	// The slot is missing from the long-term storage.
+	ErrorCodeLongTermStorageSlotSkipped = -32009
+	// The node doesn't keep the transaction history.
+	ErrorCodeTransactionHistoryNotAvailable = -32011
+	// The status of the block is not available yet.
+	ErrorCodeBlockStatusNotAvailableYet = -32014
+	// The node doesn't support the requested method.
+	ErrorCodeMethodNotFound = -32601
+)
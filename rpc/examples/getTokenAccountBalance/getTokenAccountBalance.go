@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/gagliardetto/solana-go"
@@ -36,4 +37,10 @@ func main() {
 		panic(err)
 	}
 	spew.Dump(out)
+	// The exact amount, without the float rounding of the deprecated UiAmount:
+	amount, err := out.Value.Decimal()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(amount)
 }
@@ -0,0 +1,44 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func main() {
+	endpoint := rpc.MainNetBeta_RPC
+	client := rpc.New(endpoint)
+
+	// The accounts write-locked by the transaction.
+	pubKey := solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM") // serum USDC/SOL market
+	fee, err := client.SuggestPriorityFee(
+		context.TODO(),
+		[]solana.PublicKey{pubKey},
+		75,
+	)
+	if err != nil {
+		panic(err)
+	}
+	spew.Dump(fee)
+
+	// To be added to the instructions of the transaction.
+	spew.Dump(computebudget.NewSetComputeUnitPriceInstruction(fee).Build())
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// ErrNilAccount is returned by ExpectOwner and ExpectDataLen when the account is nil.
+var ErrNilAccount = errors.New("account is nil")
+
+// ExpectOwner returns an error if the account is not owned by the program,
+// e.g. before decoding its data as an account of that program.
+func ExpectOwner(account *Account, owner solana.PublicKey) error {
+	if account == nil {
+		return ErrNilAccount
+	}
+	if !account.Owner.Equals(owner) {
+		return fmt.Errorf("unexpected account owner: expected %s, got %s", owner, account.Owner)
+	}
+	return nil
+}
+
+// ExpectDataLen returns an error if the data of the account is not n bytes long.
+func ExpectDataLen(account *Account, n int) error {
+	if account == nil {
+		return ErrNilAccount
+	}
+	size := 0
+	if account.Data != nil {
+		data, err := account.Data.GetBinaryOrErr()
+		if err != nil {
+			return err
+		}
+		size = len(data)
+	}
+	if size != n {
+		return fmt.Errorf("unexpected account data size: expected %d bytes, got %d", n, size)
+	}
+	return nil
+}
+
+type GetAccountDataOpts struct {
+	// Commitment requirement.
+	//
+	// This parameter is optional.
+	Commitment CommitmentType
+
+	// The program that must own the account.
+	//
+	// This parameter is optional.
+	ExpectedOwner *solana.PublicKey
+
+	// The exact size of the data of the account.
+	//
+	// This parameter is optional.
+	ExpectedDataLen *int
+}
+
+// GetAccountDataIntoWithOpts is like GetAccountDataInto, but it can check
+// the owner and the data size of the account before decoding its data.
+func (cl *Client) GetAccountDataIntoWithOpts(
+	ctx context.Context,
+	account solana.PublicKey,
+	inVar interface{},
+	opts *GetAccountDataOpts,
+) error {
+	data, err := cl.getExpectedAccountData(ctx, account, opts)
+	if err != nil {
+		return err
+	}
+	return bin.NewBinDecoder(data).Decode(inVar)
+}
+
+// GetAccountDataBorshIntoWithOpts is like GetAccountDataBorshInto, but it can check
+// the owner and the data size of the account before decoding its data.
+func (cl *Client) GetAccountDataBorshIntoWithOpts(
+	ctx context.Context,
+	account solana.PublicKey,
+	inVar interface{},
+	opts *GetAccountDataOpts,
+) error {
+	data, err := cl.getExpectedAccountData(ctx, account, opts)
+	if err != nil {
+		return err
+	}
+	return bin.NewBorshDecoder(data).Decode(inVar)
+}
+
+func (cl *Client) getExpectedAccountData(
+	ctx context.Context,
+	account solana.PublicKey,
+	opts *GetAccountDataOpts,
+) ([]byte, error) {
+	if opts == nil {
+		opts = &GetAccountDataOpts{}
+	}
+	resp, err := cl.GetAccountInfoWithOpts(ctx, account, &GetAccountInfoOpts{
+		Commitment: opts.Commitment,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.ExpectedOwner != nil {
+		if err := ExpectOwner(resp.Value, *opts.ExpectedOwner); err != nil {
+			return nil, fmt.Errorf("account %s: %w", account, err)
+		}
+	}
+	if opts.ExpectedDataLen != nil {
+		if err := ExpectDataLen(resp.Value, *opts.ExpectedDataLen); err != nil {
+			return nil, fmt.Errorf("account %s: %w", account, err)
+		}
+	}
+	data, err := resp.Value.Data.GetBinaryOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", account, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectOwner(t *testing.T) {
+	account := &Account{
+		Owner: solana.TokenProgramID,
+		Data:  DataBytesOrJSONFromBytes(make([]byte, 82)),
+	}
+	require.NoError(t, ExpectOwner(account, solana.TokenProgramID))
+
+	err := ExpectOwner(account, solana.SystemProgramID)
+	require.Error(t, err)
+	assert.Equal(t,
+		"unexpected account owner: expected 11111111111111111111111111111111, got TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+		err.Error(),
+	)
+
+	assert.Equal(t, ErrNilAccount, ExpectOwner(nil, solana.TokenProgramID))
+}
+
+func TestExpectDataLen(t *testing.T) {
+	account := &Account{
+		Owner: solana.TokenProgramID,
+		Data:  DataBytesOrJSONFromBytes(make([]byte, 82)),
+	}
+	require.NoError(t, ExpectDataLen(account, 82))
+
+	err := ExpectDataLen(account, 165)
+	require.Error(t, err)
+	assert.Equal(t, "unexpected account data size: expected 165 bytes, got 82", err.Error())
+
+	// No data at all.
+	err = ExpectDataLen(&Account{}, 165)
+	require.Error(t, err)
+	assert.Equal(t, "unexpected account data size: expected 165 bytes, got 0", err.Error())
+
+	assert.Equal(t, ErrNilAccount, ExpectDataLen(nil, 82))
+}
+
+func TestClient_GetAccountDataIntoWithOpts(t *testing.T) {
+	// "test", owned by the system program.
+	responseBody := `{"context":{"slot":83986105},"value":{"data":["dGVzdA==","base64"],"executable":false,"lamports":999999,"owner":"11111111111111111111111111111111","rentEpoch":207}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	pubKey := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	owner := solana.SystemProgramID
+	size := 4
+
+	t.Run("matching", func(t *testing.T) {
+		var out [4]byte
+		err := client.GetAccountDataIntoWithOpts(context.Background(), pubKey, &out, &GetAccountDataOpts{
+			ExpectedOwner:   &owner,
+			ExpectedDataLen: &size,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, [4]byte{'t', 'e', 's', 't'}, out)
+
+		err = client.GetAccountDataBorshIntoWithOpts(context.Background(), pubKey, &out, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong owner", func(t *testing.T) {
+		var out [4]byte
+		tokenProgram := solana.TokenProgramID
+		err := client.GetAccountDataIntoWithOpts(context.Background(), pubKey, &out, &GetAccountDataOpts{
+			ExpectedOwner: &tokenProgram,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+		assert.Contains(t, err.Error(), "expected TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA, got 11111111111111111111111111111111")
+		assert.Equal(t, [4]byte{}, out)
+	})
+
+	t.Run("data too short", func(t *testing.T) {
+		var out [4]byte
+		mintSize := 82
+		err := client.GetAccountDataBorshIntoWithOpts(context.Background(), pubKey, &out, &GetAccountDataOpts{
+			ExpectedOwner:   &owner,
+			ExpectedDataLen: &mintSize,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected 82 bytes, got 4")
+		assert.Equal(t, [4]byte{}, out)
+	})
+}
@@ -0,0 +1,292 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// DefaultFailoverCooldown is the default time during which
+// an endpoint that failed is tried only if all the others failed too.
+const DefaultFailoverCooldown = 30 * time.Second
+
+type FailoverOpts struct {
+	// Start each call at the next endpoint, instead of the first one.
+	RoundRobin bool
+
+	// The time during which an endpoint that failed is tried only after the others.
+	// Defaults to DefaultFailoverCooldown.
+	Cooldown time.Duration
+
+	// Headers added to the requests to all the endpoints.
+	Headers map[string]string
+}
+
+// EndpointHealth is the health of an endpoint of a FailoverRPCClient.
+type EndpointHealth struct {
+	URL string
+	// The number of failures since the last success.
+	ConsecutiveFailures int
+	LastError           error
+	// Until when the endpoint is tried only after the others (zero if it is healthy).
+	CooldownUntil time.Time
+}
+
+// Healthy tells whether the endpoint is not cooling down after a failure.
+func (h EndpointHealth) Healthy() bool {
+	return !time.Now().Before(h.CooldownUntil)
+}
+
+// FailoverRPCClient is a JSONRPCClient that sends each request to an endpoint,
+// and to the next one if the endpoint can't serve it: on transport errors,
+// HTTP 5xx and 429 responses, and node unhealthy errors.
+// The other errors (e.g. invalid params) are returned as-is.
+// An endpoint that failed cools down: for a while, it is tried
+// only after the healthy ones.
+// FailoverRPCClient is safe for concurrent use by multiple goroutines.
+type FailoverRPCClient struct {
+	endpoints  []*failoverEndpoint
+	roundRobin bool
+	cooldown   time.Duration
+	next       uint32
+	now        func() time.Time
+
+	mu sync.Mutex // protects the health of the endpoints.
+}
+
+type failoverEndpoint struct {
+	url       string
+	rpcClient jsonrpc.RPCClient
+	health    EndpointHealth
+}
+
+var _ JSONRPCClient = &FailoverRPCClient{}
+
+// NewFailover creates a new Solana JSON RPC client that fails over
+// between the endpoints (see FailoverRPCClient); opts can be nil.
+func NewFailover(endpoints []string, opts *FailoverOpts) *Client {
+	return NewWithCustomRPCClient(NewFailoverRPCClient(endpoints, opts))
+}
+
+// NewFailoverRPCClient creates a FailoverRPCClient; opts can be nil.
+func NewFailoverRPCClient(endpoints []string, opts *FailoverOpts) *FailoverRPCClient {
+	if opts == nil {
+		opts = &FailoverOpts{}
+	}
+	cooldown := opts.Cooldown
+	if cooldown <= 0 {
+		cooldown = DefaultFailoverCooldown
+	}
+	client := &FailoverRPCClient{
+		roundRobin: opts.RoundRobin,
+		cooldown:   cooldown,
+		now:        time.Now,
+	}
+	for _, endpoint := range endpoints {
+		client.endpoints = append(client.endpoints, &failoverEndpoint{
+			url: endpoint,
+			rpcClient: jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+				HTTPClient:    newHTTP(),
+				CustomHeaders: opts.Headers,
+				UserAgent:     DefaultUserAgent(),
+			}),
+			health: EndpointHealth{URL: endpoint},
+		})
+	}
+	return client
+}
+
+// Health returns the health of the endpoints, in the order of NewFailoverRPCClient.
+func (f *FailoverRPCClient) Health() []EndpointHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]EndpointHealth, len(f.endpoints))
+	for i, endpoint := range f.endpoints {
+		out[i] = endpoint.health
+	}
+	return out
+}
+
+func (f *FailoverRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return f.do(ctx, func(rpcClient jsonrpc.RPCClient) error {
+		return rpcClient.CallForInto(ctx, out, method, params)
+	})
+}
+
+// CallWithCallback calls the callback only with the response of an endpoint
+// that can serve the request; the errors of the callback are returned as-is.
+func (f *FailoverRPCClient) CallWithCallback(
+	ctx context.Context,
+	method string,
+	params []interface{},
+	callback func(*http.Request, *http.Response) error,
+) error {
+	return f.do(ctx, func(rpcClient jsonrpc.RPCClient) error {
+		var callbackErr error
+		called := false
+		err := rpcClient.CallWithCallback(ctx, method, params, func(req *http.Request, resp *http.Response) error {
+			if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+				return jsonrpc.NewHTTPError(
+					resp.StatusCode,
+					fmt.Errorf("rpc call %v() on %v status code: %v", method, req.URL.String(), resp.StatusCode),
+				)
+			}
+			called = true
+			callbackErr = callback(req, resp)
+			return callbackErr
+		})
+		if called {
+			return &failoverFinalError{err: callbackErr}
+		}
+		return err
+	})
+}
+
+func (f *FailoverRPCClient) CallBatch(
+	ctx context.Context,
+	requests jsonrpc.RPCRequests,
+) (out jsonrpc.RPCResponses, err error) {
+	err = f.do(ctx, func(rpcClient jsonrpc.RPCClient) error {
+		out, err = rpcClient.CallBatch(ctx, requests)
+		return err
+	})
+	return out, err
+}
+
+// Close closes the clients of all the endpoints.
+func (f *FailoverRPCClient) Close() error {
+	var first error
+	for _, endpoint := range f.endpoints {
+		if closer, ok := endpoint.rpcClient.(io.Closer); ok {
+			if err := closer.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// failoverFinalError is an error that must be returned without trying other endpoints.
+type failoverFinalError struct {
+	err error
+}
+
+func (e *failoverFinalError) Error() string { return fmt.Sprint(e.err) }
+
+// do calls the function with the client of each endpoint, in order,
+// until it returns an error that is not a failover error.
+func (f *FailoverRPCClient) do(ctx context.Context, call func(rpcClient jsonrpc.RPCClient) error) error {
+	endpoints := f.order()
+	if len(endpoints) == 0 {
+		return errors.New("no RPC endpoints")
+	}
+	var lastErr error
+	for _, endpoint := range endpoints {
+		err := call(endpoint.rpcClient)
+		var final *failoverFinalError
+		if errors.As(err, &final) {
+			f.markSuccess(endpoint)
+			return final.err
+		}
+		if err == nil || !isFailoverError(err) {
+			f.markSuccess(endpoint)
+			return err
+		}
+		if ctx.Err() != nil {
+			// The caller gave up: not a failure of the endpoint.
+			return err
+		}
+		f.markFailure(endpoint, err)
+		lastErr = err
+	}
+	return fmt.Errorf("all %d RPC endpoints failed, last error: %w", len(endpoints), lastErr)
+}
+
+// order returns the endpoints to try: the healthy ones, starting at the first one
+// (or at the next one with RoundRobin), then the ones that are cooling down,
+// from the one that failed first.
+func (f *FailoverRPCClient) order() []*failoverEndpoint {
+	count := len(f.endpoints)
+	if count == 0 {
+		return nil
+	}
+	start := 0
+	if f.roundRobin {
+		start = int((atomic.AddUint32(&f.next, 1) - 1) % uint32(count))
+	}
+	now := f.now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	healthy := make([]*failoverEndpoint, 0, count)
+	var coolingDown []*failoverEndpoint
+	for i := 0; i < count; i++ {
+		endpoint := f.endpoints[(start+i)%count]
+		if now.Before(endpoint.health.CooldownUntil) {
+			coolingDown = append(coolingDown, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	// Stable, so that the endpoints that failed at the same time keep their order.
+	for i := 1; i < len(coolingDown); i++ {
+		for j := i; j > 0 && coolingDown[j].health.CooldownUntil.Before(coolingDown[j-1].health.CooldownUntil); j-- {
+			coolingDown[j], coolingDown[j-1] = coolingDown[j-1], coolingDown[j]
+		}
+	}
+	return append(healthy, coolingDown...)
+}
+
+func (f *FailoverRPCClient) markSuccess(endpoint *failoverEndpoint) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	endpoint.health = EndpointHealth{URL: endpoint.url}
+}
+
+func (f *FailoverRPCClient) markFailure(endpoint *failoverEndpoint, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	endpoint.health.ConsecutiveFailures++
+	endpoint.health.LastError = err
+	endpoint.health.CooldownUntil = f.now().Add(f.cooldown)
+}
+
+// isFailoverError tells whether the error means that the endpoint
+// can't serve the request (and another one should be tried).
+func isFailoverError(err error) bool {
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code >= 500 || httpErr.Code == http.StatusTooManyRequests
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == http.StatusTooManyRequests || rpcErr.Code == ErrorCodeNodeUnhealthy
+	}
+	// Transport errors (connection refused, timeouts, etc.).
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failoverTestServer struct {
+	*httptest.Server
+	calls  int32
+	status int32 // the HTTP status of the responses
+	body   atomic.Value
+}
+
+func newFailoverTestServer(t *testing.T, status int, body string) *failoverTestServer {
+	s := &failoverTestServer{status: int32(status)}
+	s.body.Store(body)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.calls, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&s.status)))
+		fmt.Fprint(w, s.body.Load().(string))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *failoverTestServer) set(status int, body string) {
+	atomic.StoreInt32(&s.status, int32(status))
+	s.body.Store(body)
+}
+
+func (s *failoverTestServer) callCount() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+func slotResult(slot int) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":0,"result":%d}`, slot)
+}
+
+func TestFailoverRPCClient(t *testing.T) {
+	down := newFailoverTestServer(t, http.StatusServiceUnavailable, "unavailable")
+	limited := newFailoverTestServer(t, http.StatusTooManyRequests, "")
+	up := newFailoverTestServer(t, http.StatusOK, slotResult(42))
+
+	rpcClient := NewFailoverRPCClient([]string{down.URL, limited.URL, up.URL}, &FailoverOpts{Cooldown: time.Hour})
+	client := NewWithCustomRPCClient(rpcClient)
+
+	slot, err := client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), slot)
+	assert.Equal(t, []int{1, 1, 1}, []int{down.callCount(), limited.callCount(), up.callCount()})
+
+	health := rpcClient.Health()
+	require.Len(t, health, 3)
+	assert.False(t, health[0].Healthy())
+	assert.Equal(t, 1, health[0].ConsecutiveFailures)
+	var httpErr *jsonrpc.HTTPError
+	require.True(t, errors.As(health[0].LastError, &httpErr))
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.Code)
+	assert.False(t, health[1].Healthy())
+	assert.True(t, health[2].Healthy())
+
+	// The endpoints that failed are cooling down: they are not tried first.
+	_, err = client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 1, 2}, []int{down.callCount(), limited.callCount(), up.callCount()})
+
+	// When the healthy endpoint fails, the ones cooling down are tried,
+	// from the one that failed first.
+	up.set(http.StatusBadGateway, "")
+	down.set(http.StatusOK, slotResult(43))
+	slot, err = client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(43), slot)
+	assert.Equal(t, []int{2, 1, 3}, []int{down.callCount(), limited.callCount(), up.callCount()})
+	assert.True(t, rpcClient.Health()[0].Healthy())
+	assert.Equal(t, 0, rpcClient.Health()[0].ConsecutiveFailures)
+
+	t.Run("rpc errors are not retried", func(t *testing.T) {
+		down.set(http.StatusOK, `{"jsonrpc":"2.0","id":0,"error":{"code":-32602,"message":"Invalid params"}}`)
+		before := limited.callCount() + up.callCount()
+		_, err := client.GetSlot(context.Background(), "")
+		var rpcErr *jsonrpc.RPCError
+		require.True(t, errors.As(err, &rpcErr))
+		assert.Equal(t, -32602, rpcErr.Code)
+		assert.Equal(t, before, limited.callCount()+up.callCount())
+	})
+	t.Run("all endpoints fail", func(t *testing.T) {
+		down.set(http.StatusOK, `{"jsonrpc":"2.0","id":0,"error":{"code":-32005,"message":"Node is behind by 100 slots"}}`)
+		_, err := client.GetSlot(context.Background(), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "all 3 RPC endpoints failed")
+	})
+}
+
+func TestFailoverRPCClient_jsonErrorBody(t *testing.T) {
+	// Some providers return a JSON-RPC error body along with the HTTP error status.
+	down := newFailoverTestServer(t, http.StatusServiceUnavailable, `{"jsonrpc":"2.0","id":0,"error":{"code":-32603,"message":"Internal error"}}`)
+	up := newFailoverTestServer(t, http.StatusOK, slotResult(42))
+
+	rpcClient := NewFailoverRPCClient([]string{down.URL, up.URL}, &FailoverOpts{Cooldown: time.Hour})
+	slot, err := NewWithCustomRPCClient(rpcClient).GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), slot)
+	assert.Equal(t, []int{1, 1}, []int{down.callCount(), up.callCount()})
+
+	lastErr := rpcClient.Health()[0].LastError
+	var httpErr *jsonrpc.HTTPError
+	require.True(t, errors.As(lastErr, &httpErr))
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.Code)
+	var rpcErr *jsonrpc.RPCError
+	require.True(t, errors.As(lastErr, &rpcErr))
+	assert.Equal(t, -32603, rpcErr.Code)
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		bad := newFailoverTestServer(t, http.StatusBadRequest, `{"jsonrpc":"2.0","id":0,"error":{"code":-32602,"message":"Invalid params"}}`)
+		up := newFailoverTestServer(t, http.StatusOK, slotResult(42))
+		_, err := NewFailover([]string{bad.URL, up.URL}, nil).GetSlot(context.Background(), "")
+		var rpcErr *jsonrpc.RPCError
+		require.True(t, errors.As(err, &rpcErr))
+		assert.Equal(t, -32602, rpcErr.Code)
+		assert.Equal(t, 0, up.callCount())
+	})
+}
+
+func TestFailoverRPCClient_transportError(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	up := newFailoverTestServer(t, http.StatusOK, slotResult(7))
+
+	client := NewFailover([]string{closed.URL, up.URL}, nil)
+	slot, err := client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), slot)
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rpcClient := NewFailoverRPCClient([]string{up.URL, up.URL}, nil)
+		_, err := NewWithCustomRPCClient(rpcClient).GetSlot(ctx, "")
+		require.ErrorIs(t, err, context.Canceled)
+		for _, health := range rpcClient.Health() {
+			assert.True(t, health.Healthy())
+		}
+	})
+}
+
+func TestFailoverRPCClient_callback(t *testing.T) {
+	down := newFailoverTestServer(t, http.StatusInternalServerError, slotResult(1))
+	up := newFailoverTestServer(t, http.StatusOK, slotResult(2))
+	client := NewFailover([]string{down.URL, up.URL}, nil)
+
+	var bodies []string
+	err := client.RPCCallWithCallback(context.Background(), "getSlot", nil, func(req *http.Request, resp *http.Response) error {
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+		return nil
+	})
+	require.NoError(t, err)
+	// Only called with the response of the healthy endpoint.
+	assert.Equal(t, []string{slotResult(2)}, bodies)
+
+	// The errors of the callback are returned as-is, without failing over.
+	stop := errors.New("stop")
+	down.set(http.StatusOK, slotResult(1))
+	client = NewFailover([]string{down.URL, up.URL}, nil)
+	calls := up.callCount()
+	err = client.RPCCallWithCallback(context.Background(), "getSlot", nil, func(req *http.Request, resp *http.Response) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, calls, up.callCount())
+}
+
+func TestFailoverRPCClient_roundRobin(t *testing.T) {
+	servers := []*failoverTestServer{
+		newFailoverTestServer(t, http.StatusOK, slotResult(1)),
+		newFailoverTestServer(t, http.StatusOK, slotResult(2)),
+		newFailoverTestServer(t, http.StatusOK, slotResult(3)),
+	}
+	client := NewFailover([]string{servers[0].URL, servers[1].URL, servers[2].URL}, &FailoverOpts{RoundRobin: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetSlot(context.Background(), "")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	for _, server := range servers {
+		assert.Equal(t, 10, server.callCount())
+	}
+}
+
+func TestFailoverRPCClient_noEndpoints(t *testing.T) {
+	_, err := NewFailover(nil, nil).GetSlot(context.Background(), "")
+	require.Error(t, err)
+}
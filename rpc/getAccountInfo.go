@@ -19,12 +19,14 @@ package rpc
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 )
 
 // GetAccountInfo returns all information associated with the account of provided publicKey.
+// If the account doesn't exist, ErrAccountNotFound is returned.
 func (cl *Client) GetAccountInfo(ctx context.Context, account solana.PublicKey) (out *GetAccountInfoResult, err error) {
 	return cl.GetAccountInfoWithOpts(
 		ctx,
@@ -43,7 +45,11 @@ func (cl *Client) GetAccountDataInto(ctx context.Context, account solana.PublicK
 	if err != nil {
 		return err
 	}
-	return bin.NewBinDecoder(resp.Value.Data.GetBinary()).Decode(inVar)
+	data, err := resp.Value.Data.GetBinaryOrErr()
+	if err != nil {
+		return fmt.Errorf("account %s: %w", account, err)
+	}
+	return bin.NewBinDecoder(data).Decode(inVar)
 }
 
 // GetAccountDataBorshInto decodes the borsh binary data and populates
@@ -53,12 +59,23 @@ func (cl *Client) GetAccountDataBorshInto(ctx context.Context, account solana.Pu
 	if err != nil {
 		return err
 	}
-	return bin.NewBorshDecoder(resp.Value.Data.GetBinary()).Decode(inVar)
+	data, err := resp.Value.Data.GetBinaryOrErr()
+	if err != nil {
+		return fmt.Errorf("account %s: %w", account, err)
+	}
+	return bin.NewBorshDecoder(data).Decode(inVar)
 }
 
+// DefaultAccountInfoEncoding is the encoding of the account data requested
+// by GetAccountInfo and GetAccountInfoWithOpts when none is specified.
+// The node's own default, "base58", fails for accounts with more than 128 bytes
+// of data; "base64" works for accounts of any size.
+const DefaultAccountInfoEncoding = solana.EncodingBase64
+
 type GetAccountInfoOpts struct {
 	// Encoding for Account data.
 	// Either "base58" (slow), "base64", "base64+zstd", or "jsonParsed".
+	// GetAccountInfoWithOpts uses DefaultAccountInfoEncoding ("base64") if empty.
 	// - "base58" is limited to Account data of less than 129 bytes.
 	// - "base64" will return base64 encoded data for Account data of any size.
 	// - "base64+zstd" compresses the Account data using Zstandard and base64-encodes the result.
@@ -88,8 +105,10 @@ type GetAccountInfoOpts struct {
 }
 
 // GetAccountInfoWithOpts returns all information associated with the account of provided publicKey.
-// You can specify the encoding of the returned data with the encoding parameter.
+// You can specify the encoding of the returned data with the encoding parameter
+// (DefaultAccountInfoEncoding by default, even if opts is nil).
 // You can limit the returned account data with the offset and length parameters.
+// If the account doesn't exist, ErrAccountNotFound is returned.
 func (cl *Client) GetAccountInfoWithOpts(
 	ctx context.Context,
 	account solana.PublicKey,
@@ -100,7 +119,7 @@ func (cl *Client) GetAccountInfoWithOpts(
 		return nil, err
 	}
 	if out.Value == nil {
-		return nil, ErrNotFound
+		return nil, ErrAccountNotFound
 	}
 	return out, nil
 }
@@ -112,8 +131,7 @@ func (cl *Client) getAccountInfoWithOpts(
 ) (out *GetAccountInfoResult, err error) {
 
 	obj := M{
-		// default encoding:
-		"encoding": solana.EncodingBase64,
+		"encoding": DefaultAccountInfoEncoding,
 	}
 
 	if opts != nil {
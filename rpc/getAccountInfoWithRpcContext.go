@@ -23,6 +23,7 @@ import (
 )
 
 // GetAccountInfoWithRpcContext is similar to GetAccountInfoWithOpts but will return rpcContext and nil account if account is not found
+// (instead of ErrAccountNotFound).
 func (cl *Client) GetAccountInfoWithRpcContext(
 	ctx context.Context,
 	account solana.PublicKey,
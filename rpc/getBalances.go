@@ -0,0 +1,82 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AlekSi/pointer"
+	"github.com/gagliardetto/solana-go"
+)
+
+// MaxMultipleAccounts is the maximum number of accounts
+// of a getMultipleAccounts request accepted by the RPC nodes.
+const MaxMultipleAccounts = 100
+
+// GetBalances returns the balances (in lamports) of the accounts,
+// aligned by index with `addresses`. The balance of an account
+// that doesn't exist is 0 (see GetBalancesWithExistence to tell them apart).
+//
+// The balances are fetched with getMultipleAccounts (without the account data),
+// in batches of MaxMultipleAccounts accounts.
+func (cl *Client) GetBalances(
+	ctx context.Context,
+	addresses []solana.PublicKey,
+	commitment CommitmentType, // optional
+) ([]uint64, error) {
+	balances, _, err := cl.GetBalancesWithExistence(ctx, addresses, commitment)
+	return balances, err
+}
+
+// GetBalancesWithExistence is like GetBalances, and also returns whether each account exists.
+func (cl *Client) GetBalancesWithExistence(
+	ctx context.Context,
+	addresses []solana.PublicKey,
+	commitment CommitmentType, // optional
+) (balances []uint64, exists []bool, err error) {
+	balances = make([]uint64, len(addresses))
+	exists = make([]bool, len(addresses))
+	opts := &GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: commitment,
+		// Only the lamports are needed.
+		DataSlice: &DataSlice{
+			Offset: pointer.ToUint64(0),
+			Length: pointer.ToUint64(0),
+		},
+	}
+	for start := 0; start < len(addresses); start += MaxMultipleAccounts {
+		end := start + MaxMultipleAccounts
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		out, err := cl.GetMultipleAccountsWithOpts(ctx, addresses[start:end], opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(out.Value) != end-start {
+			return nil, nil, fmt.Errorf("expected %d accounts, got %d", end-start, len(out.Value))
+		}
+		for i, account := range out.Value {
+			if account == nil {
+				continue
+			}
+			balances[start+i] = account.Lamports
+			exists[start+i] = true
+		}
+	}
+	return balances, exists, nil
+}
@@ -16,9 +16,13 @@ package rpc
 
 import (
 	"context"
+	stdjson "encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
 type TransactionDetailsType string
@@ -61,6 +65,63 @@ type GetBlockOpts struct {
 	// Max transaction version to return in responses.
 	// If the requested block contains a transaction with a higher version, an error will be returned.
 	MaxSupportedTransactionVersion *uint64
+
+	// If true, the vote transactions (see TransactionWithMeta.IsVote) are dropped
+	// as the response is decoded, and are not returned.
+	// This is done by the client (the node still sends them);
+	// it only applies to the "full" transaction details.
+	SkipVoteTransactions bool
+
+	// If set, and the block is not available yet (see ErrBlockNotAvailable),
+	// or not confirmed yet (see ErrNotConfirmed), the request is retried
+	// until the block is available or this timeout expires
+	// (and then the last error is returned).
+	// A skipped slot (see ErrSlotSkipped) is not retried.
+	RetryUntilAvailable time.Duration
+}
+
+// getBlockRetryInterval is the time between the retries
+// of a GetBlockWithOpts with RetryUntilAvailable.
+var getBlockRetryInterval = 200 * time.Millisecond
+
+var (
+	// ErrBlockNotAvailable is matched (with errors.Is) by the error returned by GetBlock
+	// when the node doesn't have the block yet, e.g. for a recent slot
+	// that is not fully processed yet: the request can be retried
+	// (see GetBlockOpts.RetryUntilAvailable).
+	ErrBlockNotAvailable = errors.New("block not available")
+
+	// ErrSlotSkipped is matched (with errors.Is) by the error returned by GetBlock
+	// when no block was produced for the slot (or it is missing from the long-term storage):
+	// the slot is empty, and retrying won't help.
+	ErrSlotSkipped = errors.New("slot skipped")
+)
+
+// blockError is an RPC error of getBlock matched by ErrBlockNotAvailable or ErrSlotSkipped,
+// which still unwraps to the *jsonrpc.RPCError.
+type blockError struct {
+	err  error
+	kind error
+}
+
+func (e *blockError) Error() string        { return e.err.Error() }
+func (e *blockError) Unwrap() error        { return e.err }
+func (e *blockError) Is(target error) bool { return target == e.kind }
+
+// classifyBlockError makes the getBlock errors for a block not available yet
+// and for a skipped slot match ErrBlockNotAvailable and ErrSlotSkipped.
+func classifyBlockError(err error) error {
+	var rpcErr *jsonrpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	switch rpcErr.Code {
+	case ErrorCodeBlockNotAvailable, ErrorCodeBlockStatusNotAvailableYet:
+		return &blockError{err: err, kind: ErrBlockNotAvailable}
+	case ErrorCodeSlotSkipped, ErrorCodeLongTermStorageSlotSkipped:
+		return &blockError{err: err, kind: ErrSlotSkipped}
+	}
+	return err
 }
 
 // GetBlock returns identity and transaction information about a confirmed block in the ledger.
@@ -84,7 +145,64 @@ func (cl *Client) GetBlockWithOpts(
 	slot uint64,
 	opts *GetBlockOpts,
 ) (out *GetBlockResult, err error) {
+	var deadline time.Time
+	if opts != nil && opts.RetryUntilAvailable > 0 {
+		deadline = time.Now().Add(opts.RetryUntilAvailable)
+	}
+	for {
+		out, err = cl.getBlock(ctx, slot, opts)
+		if !errors.Is(err, ErrBlockNotAvailable) && !errors.Is(err, ErrNotConfirmed) {
+			return out, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, err
+		}
+		if remaining > getBlockRetryInterval {
+			remaining = getBlockRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(remaining):
+		}
+	}
+}
+
+func (cl *Client) getBlock(
+	ctx context.Context,
+	slot uint64,
+	opts *GetBlockOpts,
+) (out *GetBlockResult, err error) {
+	params, err := getBlockParams(slot, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.SkipVoteTransactions {
+		// Decode the transactions one at a time, to never hold the votes in memory.
+		var transactions []TransactionWithMeta
+		out, err = cl.GetBlockStreaming(ctx, slot, opts, func(_ int, tx *TransactionWithMeta) error {
+			transactions = append(transactions, *tx)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		out.Transactions = transactions
+		return out, nil
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getBlock", params)
+	if err != nil {
+		return nil, classifyBlockError(err)
+	}
+	if out == nil {
+		// Block is not confirmed.
+		return nil, ErrNotConfirmed
+	}
+	return
+}
 
+func getBlockParams(slot uint64, opts *GetBlockOpts) ([]interface{}, error) {
 	obj := M{
 		"encoding": solana.EncodingBase64,
 	}
@@ -118,18 +236,137 @@ func (cl *Client) GetBlockWithOpts(
 		}
 	}
 
-	params := []interface{}{slot, obj}
-
-	err = cl.rpcClient.CallForInto(ctx, &out, "getBlock", params)
+	return []interface{}{slot, obj}, nil
+}
 
+// GetBlockStreaming is like GetBlockWithOpts, but instead of returning
+// all the transactions of the block at once, it decodes them as the response
+// is received and calls the provided callback for each one (with its index in the block),
+// so that only one transaction at a time is held in memory.
+// The callback must not retain the transaction after it returns if memory is a concern.
+// The returned result has all the fields of the block except Transactions;
+// it is returned after all the transactions were passed to the callback.
+// If the callback returns an error, the request is aborted and that error is returned.
+// If opts.SkipVoteTransactions is set, the callback is not called for the vote transactions.
+// The response is decoded with encoding/json, whatever the codec set with solana.SetJSONCodec.
+func (cl *Client) GetBlockStreaming(
+	ctx context.Context,
+	slot uint64,
+	opts *GetBlockOpts,
+	callback func(idx int, tx *TransactionWithMeta) error,
+) (out *GetBlockResult, err error) {
+	params, err := getBlockParams(slot, opts)
 	if err != nil {
 		return nil, err
 	}
+	if opts != nil && opts.SkipVoteTransactions {
+		callback = skipVoteTransactions(callback)
+	}
+	err = cl.callStreaming(ctx, "getBlock", params, func(decoder *stdjson.Decoder) error {
+		var err error
+		out, err = decodeBlockStream(decoder, callback)
+		return err
+	})
+	if err != nil {
+		return nil, classifyBlockError(err)
+	}
 	if out == nil {
 		// Block is not confirmed.
 		return nil, ErrNotConfirmed
 	}
-	return
+	return out, nil
+}
+
+// skipVoteTransactions wraps the callback so that it is not called
+// for the vote transactions; the transactions that can't be decoded are passed through.
+func skipVoteTransactions(callback func(idx int, tx *TransactionWithMeta) error) func(idx int, tx *TransactionWithMeta) error {
+	return func(idx int, tx *TransactionWithMeta) error {
+		if isVote, err := tx.IsVote(); err == nil && isVote {
+			return nil
+		}
+		return callback(idx, tx)
+	}
+}
+
+// decodeBlockStream decodes a GetBlockResult, calling the callback for each
+// transaction instead of adding it to the result.
+// It returns nil if the result is null.
+// It uses encoding/json, as the codec can't decode a value token by token (see callStreaming).
+func decodeBlockStream(
+	decoder *stdjson.Decoder,
+	callback func(idx int, tx *TransactionWithMeta) error,
+) (*GetBlockResult, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read result: %w", err)
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if delim, ok := tok.(stdjson.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected result to be an object, got %v", tok)
+	}
+	out := &GetBlockResult{}
+	fields := map[string]interface{}{
+		"blockhash":         &out.Blockhash,
+		"previousBlockhash": &out.PreviousBlockhash,
+		"parentSlot":        &out.ParentSlot,
+		"signatures":        &out.Signatures,
+		"rewards":           &out.Rewards,
+		"blockTime":         &out.BlockTime,
+		"blockHeight":       &out.BlockHeight,
+	}
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read result: %w", err)
+		}
+		key, _ := tok.(string)
+		if key == "transactions" {
+			if err := decodeBlockTransactions(decoder, callback); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			var skip stdjson.RawMessage
+			field = &skip
+		}
+		if err := decoder.Decode(field); err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", key, err)
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeBlockTransactions(
+	decoder *stdjson.Decoder,
+	callback func(idx int, tx *TransactionWithMeta) error,
+) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("unable to read transactions: %w", err)
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(stdjson.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected transactions to be an array, got %v", tok)
+	}
+	for i := 0; decoder.More(); i++ {
+		tx := new(TransactionWithMeta)
+		if err := decoder.Decode(tx); err != nil {
+			return fmt.Errorf("unable to decode transaction %d: %w", i, err)
+		}
+		if err := callback(i, tx); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
 }
 
 type GetBlockResult struct {
@@ -145,6 +382,7 @@ type GetBlockResult struct {
 	ParentSlot uint64 `json:"parentSlot"`
 
 	// Present if "full" transaction details are requested.
+	// See SignatureList for the signatures of the transactions in any mode.
 	Transactions []TransactionWithMeta `json:"transactions"`
 
 	// Present if "signatures" are requested for transaction details;
@@ -106,6 +106,16 @@ type BlockProductionResult struct {
 // of leader slots and the number of blocks produced.
 type IdentityToSlotsBlocks map[solana.PublicKey][2]int64
 
+// Identities returns the validator identities, sorted.
+func (m IdentityToSlotsBlocks) Identities() solana.PublicKeySlice {
+	out := make(solana.PublicKeySlice, 0, len(m))
+	for identity := range m {
+		out = append(out, identity)
+	}
+	out.Sort()
+	return out
+}
+
 type SlotRangeResponse struct {
 	// First slot of the block production information (inclusive)
 	FirstSlot uint64 `json:"firstSlot"`
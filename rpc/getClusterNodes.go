@@ -16,6 +16,7 @@ package rpc
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -51,3 +52,39 @@ type GetClusterNodesResult struct {
 	// The shred version the node has been configured to use.
 	ShredVersion uint16 `json:"shredVersion,omitempty"`
 }
+
+// FindRPCNodes returns the URLs (e.g. "http://1.2.3.4:8899") of the JSON RPC endpoints
+// of the nodes visible in gossip, e.g. to discover alternative endpoints for failover.
+// The nodes without a JSON RPC service (null or empty "rpc") are skipped,
+// and each endpoint is returned only once, in the order of GetClusterNodes.
+func (cl *Client) FindRPCNodes(ctx context.Context) ([]string, error) {
+	nodes, err := cl.GetClusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rpcNodeURLs(nodes), nil
+}
+
+func rpcNodeURLs(nodes []*GetClusterNodesResult) []string {
+	out := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		if node == nil || node.RPC == nil {
+			continue
+		}
+		address := strings.TrimSpace(*node.RPC)
+		if address == "" {
+			continue
+		}
+		url := address
+		if !strings.Contains(address, "://") {
+			url = "http://" + address
+		}
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		out = append(out, url)
+	}
+	return out
+}
@@ -16,6 +16,12 @@ package rpc
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
 // Get the fee the network will charge for a particular Message.
@@ -41,3 +47,107 @@ type GetFeeForMessageResult struct {
 	// Fee corresponding to the message at the specified blockhash.
 	Value *uint64 `json:"value"`
 }
+
+// DefaultLamportsPerSignature is the fee charged for each signature
+// when the fee cannot be obtained from the node.
+const DefaultLamportsPerSignature uint64 = 5000
+
+// EstimateFeeForMessage returns the fee the network will charge for the provided message.
+//
+// If the node returns a null fee (e.g. the blockhash of the message has expired),
+// or doesn't support `getFeeForMessage`, the fee is estimated as
+// `numRequiredSignatures * DefaultLamportsPerSignature`,
+// and `isFallback` is set to true.
+func (cl *Client) EstimateFeeForMessage(
+	ctx context.Context,
+	message *solana.Message,
+	commitment CommitmentType, // optional
+) (fee uint64, isFallback bool, err error) {
+	messageContent, err := message.MarshalBinary()
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to encode message: %w", err)
+	}
+	out, err := cl.GetFeeForMessage(
+		ctx,
+		base64.StdEncoding.EncodeToString(messageContent),
+		commitment,
+	)
+	if err != nil {
+		if !isMethodNotFound(err) {
+			return 0, false, err
+		}
+	}
+	if out != nil && out.Value != nil {
+		return *out.Value, false, nil
+	}
+	return uint64(message.Header.NumRequiredSignatures) * DefaultLamportsPerSignature, true, nil
+}
+
+func isMethodNotFound(err error) bool {
+	var rpcErr *jsonrpc.RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == ErrorCodeMethodNotFound
+}
+
+// LamportsPerSignature returns the fee the network charges for each signature.
+//
+// It prefers `getFeeForMessage`, on a message with a single signer and no instructions
+// (using the latest blockhash). If the node doesn't support it, or returns a null fee,
+// it falls back to the deprecated fee calculator returned by `getRecentBlockhash`;
+// if the node doesn't return that either, an error is returned.
+func (cl *Client) LamportsPerSignature(ctx context.Context) (uint64, error) {
+	fee, err := cl.feeForSingleSignerMessage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if fee != nil {
+		return *fee, nil
+	}
+
+	recent, err := cl.GetRecentBlockhash(ctx, "")
+	if err != nil {
+		return 0, fmt.Errorf("unable to get recent blockhash: %w", err)
+	}
+	if recent == nil || recent.Value == nil || recent.Value.FeeCalculator == nil {
+		return 0, errors.New("unable to get lamports per signature: the node returned neither a fee for the message nor a fee calculator")
+	}
+	return recent.Value.FeeCalculator.LamportsPerSignature, nil
+}
+
+// feeForSingleSignerMessage returns the fee of a message with a single signer
+// and no instructions, or nil if the node doesn't support the methods
+// or doesn't return a fee.
+func (cl *Client) feeForSingleSignerMessage(ctx context.Context) (*uint64, error) {
+	latest, err := cl.GetLatestBlockhash(ctx, "")
+	if err != nil {
+		if isMethodNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get latest blockhash: %w", err)
+	}
+	if latest == nil || latest.Value == nil {
+		return nil, nil
+	}
+	message := solana.Message{
+		Header: solana.MessageHeader{
+			NumRequiredSignatures: 1,
+		},
+		// The fee doesn't depend on the fee payer.
+		AccountKeys:     []solana.PublicKey{{}},
+		RecentBlockhash: latest.Value.Blockhash,
+	}
+	messageContent, err := message.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode message: %w", err)
+	}
+	out, err := cl.GetFeeForMessage(ctx, base64.StdEncoding.EncodeToString(messageContent), "")
+	if err != nil {
+		if isMethodNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get fee for message: %w", err)
+	}
+	if out == nil {
+		return nil, nil
+	}
+	return out.Value, nil
+}
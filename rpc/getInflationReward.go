@@ -16,6 +16,7 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -72,3 +73,73 @@ type GetInflationRewardResult struct {
 	// Vote account commission when the reward was credited.
 	Commission *uint8 `json:"commission,omitempty"`
 }
+
+// ValidatorEpochReward is the reward (i.e. the commission earnings)
+// of a vote account for a single epoch.
+type ValidatorEpochReward struct {
+	Epoch uint64
+
+	// False if the vote account didn't receive rewards in the epoch;
+	// in that case all the other fields are zero.
+	Rewarded bool
+
+	// The slot in which the rewards are effective.
+	EffectiveSlot uint64
+
+	// Commission earned in lamports.
+	Amount uint64
+
+	// Post balance of the vote account in lamports.
+	PostBalance uint64
+
+	// Vote account commission when the reward was credited.
+	Commission *uint8
+}
+
+// MaxValidatorRewardsEpochs is the max number of epochs
+// of a single GetValidatorRewards call.
+const MaxValidatorRewardsEpochs = 1000
+
+// GetValidatorRewards returns the commission earnings of the provided vote account
+// for each epoch between startEpoch and endEpoch (both inclusive), in epoch order;
+// the range can't have more than MaxValidatorRewardsEpochs epochs.
+// Epochs without rewards (for which the RPC returns null) are included in the series,
+// with `Rewarded` set to false.
+func (cl *Client) GetValidatorRewards(
+	ctx context.Context,
+	votePubkey solana.PublicKey,
+	startEpoch uint64,
+	endEpoch uint64,
+) ([]ValidatorEpochReward, error) {
+	if startEpoch > endEpoch {
+		return nil, fmt.Errorf("start epoch %d is after end epoch %d", startEpoch, endEpoch)
+	}
+	if endEpoch-startEpoch >= MaxValidatorRewardsEpochs {
+		return nil, fmt.Errorf("epoch range %d-%d has more than %d epochs", startEpoch, endEpoch, MaxValidatorRewardsEpochs)
+	}
+	var out []ValidatorEpochReward
+	// The range is checked above, so the offset doesn't overflow.
+	for offset := uint64(0); offset <= endEpoch-startEpoch; offset++ {
+		epoch := startEpoch + offset
+		rewards, err := cl.GetInflationReward(
+			ctx,
+			[]solana.PublicKey{votePubkey},
+			&GetInflationRewardOpts{
+				Epoch: &epoch,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get inflation reward for epoch %d: %w", epoch, err)
+		}
+		reward := ValidatorEpochReward{Epoch: epoch}
+		if len(rewards) > 0 && rewards[0] != nil {
+			reward.Rewarded = true
+			reward.EffectiveSlot = rewards[0].EffectiveSlot
+			reward.Amount = rewards[0].Amount
+			reward.PostBalance = rewards[0].PostBalance
+			reward.Commission = rewards[0].Commission
+		}
+		out = append(out, reward)
+	}
+	return out, nil
+}
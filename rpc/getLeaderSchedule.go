@@ -78,3 +78,13 @@ func (cl *Client) GetLeaderScheduleWithOpts(
 // and their corresponding leader slot indices as values
 // (indices are relative to the first slot in the requested epoch).
 type GetLeaderScheduleResult map[solana.PublicKey][]uint64
+
+// Identities returns the validator identities of the schedule, sorted.
+func (s GetLeaderScheduleResult) Identities() solana.PublicKeySlice {
+	out := make(solana.PublicKeySlice, 0, len(s))
+	for identity := range s {
+		out = append(out, identity)
+	}
+	out.Sort()
+	return out
+}
@@ -21,6 +21,8 @@ import (
 )
 
 // GetMinimumBalanceForRentExemption returns minimum balance required to make account rent exempt.
+// To size many accounts, fetch the Rent sysvar once with GetRent
+// and use sysvar.Rent.MinimumBalance instead.
 func (cl *Client) GetMinimumBalanceForRentExemption(
 	ctx context.Context,
 	dataSize uint64,
@@ -17,6 +17,7 @@ package rpc
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -26,6 +27,10 @@ type GetMultipleAccountsResult struct {
 	Value []*Account `json:"value"`
 }
 
+func (res *GetMultipleAccountsResult) UnmarshalJSON(data []byte) error {
+	return withContext{&res.RPCContext, &res.Value}.UnmarshalJSON(data)
+}
+
 // GetMultipleAccounts returns the account information for a list of Pubkeys.
 func (cl *Client) GetMultipleAccounts(
 	ctx context.Context,
@@ -65,6 +70,9 @@ func (cl *Client) GetMultipleAccountsWithOpts(
 				return nil, errors.New("cannot use dataSlice with EncodingJSONParsed")
 			}
 		}
+		if opts.MinContextSlot != nil {
+			obj["minContextSlot"] = *opts.MinContextSlot
+		}
 		if len(obj) > 0 {
 			params = append(params, obj)
 		}
@@ -79,3 +87,56 @@ func (cl *Client) GetMultipleAccountsWithOpts(
 	}
 	return
 }
+
+// GetMultipleAccountsDecoded returns the accounts for a list of Pubkeys,
+// each one decoded with the account decoder registered (see solana.RegisterAccountDecoder)
+// for the corresponding expected owner in `owners`, which must have the same length as `accounts`.
+//
+// The result is aligned by index with `accounts`; accounts that don't exist are nil.
+// An error is returned if an account is owned by a different program than expected,
+// or if it can't be decoded.
+func (cl *Client) GetMultipleAccountsDecoded(
+	ctx context.Context,
+	accounts []solana.PublicKey,
+	owners []solana.PublicKey,
+	opts *GetMultipleAccountsOpts,
+) ([]interface{}, error) {
+	if len(accounts) != len(owners) {
+		return nil, fmt.Errorf("got %d accounts but %d owners", len(accounts), len(owners))
+	}
+	binaryOpts := GetMultipleAccountsOpts{}
+	if opts != nil {
+		binaryOpts = *opts
+	}
+	// The decoders need the raw data.
+	if binaryOpts.Encoding == "" || binaryOpts.Encoding == solana.EncodingJSONParsed || binaryOpts.Encoding == solana.EncodingJSON {
+		binaryOpts.Encoding = solana.EncodingBase64
+	}
+
+	out, err := cl.GetMultipleAccountsWithOpts(ctx, accounts, &binaryOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Value) != len(accounts) {
+		return nil, fmt.Errorf("expected %d accounts, got %d", len(accounts), len(out.Value))
+	}
+
+	decoded := make([]interface{}, len(accounts))
+	for i, account := range out.Value {
+		if account == nil {
+			continue
+		}
+		if !account.Owner.Equals(owners[i]) {
+			return nil, fmt.Errorf("account %s (index %d) is owned by %s, expected %s", accounts[i], i, account.Owner, owners[i])
+		}
+		data, err := account.Data.GetBinaryOrErr()
+		if err != nil {
+			return nil, fmt.Errorf("account %s (index %d): %w", accounts[i], i, err)
+		}
+		decoded[i], err = solana.DecodeAccount(owners[i], data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode account %s (index %d): %w", accounts[i], i, err)
+		}
+	}
+	return decoded, nil
+}
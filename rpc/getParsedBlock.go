@@ -0,0 +1,116 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+type GetParsedBlockOpts struct {
+	// Level of transaction detail to return.
+	// If parameter not provided, the default detail level is "full".
+	//
+	// This parameter is optional.
+	TransactionDetails TransactionDetailsType
+
+	// Whether to populate the rewards array.
+	// If parameter not provided, the default includes rewards.
+	//
+	// This parameter is optional.
+	Rewards *bool
+
+	// "processed" is not supported.
+	// If parameter not provided, the default is "finalized".
+	//
+	// This parameter is optional.
+	Commitment CommitmentType
+
+	// Max transaction version to return in responses.
+	// If the requested block contains a transaction with a higher version, an error will be returned.
+	MaxSupportedTransactionVersion *uint64
+}
+
+type GetParsedBlockResult struct {
+	// The blockhash of this block.
+	Blockhash solana.Hash `json:"blockhash"`
+
+	// The blockhash of this block's parent;
+	// if the parent block is not available due to ledger cleanup,
+	// this field will return "11111111111111111111111111111111".
+	PreviousBlockhash solana.Hash `json:"previousBlockhash"`
+
+	// The slot index of this block's parent.
+	ParentSlot uint64 `json:"parentSlot"`
+
+	// Present if "full" transaction details are requested.
+	Transactions []ParsedTransactionWithMeta `json:"transactions"`
+
+	// Present if "signatures" are requested for transaction details;
+	// an array of signatures, corresponding to the transaction order in the block.
+	Signatures []solana.Signature `json:"signatures"`
+
+	// Present if rewards are requested.
+	Rewards []BlockReward `json:"rewards"`
+
+	// Estimated production time, as Unix timestamp (seconds since the Unix epoch).
+	// Nil if not available.
+	BlockTime *solana.UnixTimeSeconds `json:"blockTime"`
+
+	// The number of blocks beneath this block.
+	BlockHeight *uint64 `json:"blockHeight"`
+}
+
+type ParsedTransactionWithMeta struct {
+	Transaction *ParsedTransaction     `json:"transaction"`
+	Meta        *ParsedTransactionMeta `json:"meta,omitempty"`
+	Version     TransactionVersion     `json:"version"`
+}
+
+// GetParsedBlock returns identity and transaction information about a confirmed block
+// in the ledger, with the transactions in "jsonParsed" encoding.
+func (cl *Client) GetParsedBlock(
+	ctx context.Context,
+	slot uint64,
+	opts *GetParsedBlockOpts,
+) (out *GetParsedBlockResult, err error) {
+	obj := M{
+		"encoding": solana.EncodingJSONParsed,
+	}
+	if opts != nil {
+		if opts.TransactionDetails != "" {
+			obj["transactionDetails"] = opts.TransactionDetails
+		}
+		if opts.Rewards != nil {
+			obj["rewards"] = opts.Rewards
+		}
+		if opts.Commitment != "" {
+			obj["commitment"] = opts.Commitment
+		}
+		if opts.MaxSupportedTransactionVersion != nil {
+			obj["maxSupportedTransactionVersion"] = *opts.MaxSupportedTransactionVersion
+		}
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getBlock", []interface{}{slot, obj})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		// Block is not confirmed.
+		return nil, ErrNotConfirmed
+	}
+	return
+}
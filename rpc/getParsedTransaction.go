@@ -18,10 +18,11 @@ type GetParsedTransactionOpts struct {
 }
 
 type GetParsedTransactionResult struct {
-	Slot        uint64
-	BlockTime   *solana.UnixTimeSeconds
-	Transaction *ParsedTransaction
-	Meta        *ParsedTransactionMeta
+	Slot        uint64                  `json:"slot"`
+	BlockTime   *solana.UnixTimeSeconds `json:"blockTime"`
+	Transaction *ParsedTransaction      `json:"transaction"`
+	Meta        *ParsedTransactionMeta  `json:"meta"`
+	Version     TransactionVersion      `json:"version"`
 }
 
 func (cl *Client) GetParsedTransaction(
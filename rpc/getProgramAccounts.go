@@ -18,6 +18,9 @@ package rpc
 
 import (
 	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -40,9 +43,43 @@ func (cl *Client) GetProgramAccountsWithOpts(
 	publicKey solana.PublicKey,
 	opts *GetProgramAccountsOpts,
 ) (out GetProgramAccountsResult, err error) {
+	params := getProgramAccountsParams(publicKey, opts, false)
+	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
+	return
+}
+
+// GetProgramAccountsWithContext is like GetProgramAccountsWithOpts,
+// but also returns the context (i.e. the slot) the accounts were read at.
+// Together with opts.MinContextSlot (e.g. set to the slot of the previous read + 1),
+// it makes sure that consecutive reads never go back in time,
+// even when served by different nodes.
+func (cl *Client) GetProgramAccountsWithContext(
+	ctx context.Context,
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+) (out *GetProgramAccountsWithContextResult, err error) {
+	params := getProgramAccountsParams(publicKey, opts, true)
+	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, errors.New("expected a value, got null result")
+	}
+	return
+}
+
+func getProgramAccountsParams(
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+	withContext bool,
+) []interface{} {
 	obj := M{
 		"encoding": "base64",
 	}
+	if withContext {
+		obj["withContext"] = true
+	}
 	if opts != nil {
 		if opts.Commitment != "" {
 			obj["commitment"] = string(opts.Commitment)
@@ -59,10 +96,51 @@ func (cl *Client) GetProgramAccountsWithOpts(
 				"length": opts.DataSlice.Length,
 			}
 		}
+		if opts.MinContextSlot != nil {
+			obj["minContextSlot"] = *opts.MinContextSlot
+		}
 	}
+	return []interface{}{publicKey, obj}
+}
 
-	params := []interface{}{publicKey, obj}
+// GetProgramAccountsCallback is like GetProgramAccountsWithOpts,
+// but instead of returning all the accounts at once, it decodes
+// the response as it is received and calls the provided callback
+// for each account, so that the whole result is never held in memory.
+// If the callback returns an error, the request is aborted and that error is returned.
+// The response is decoded with encoding/json, whatever the codec set with solana.SetJSONCodec.
+func (cl *Client) GetProgramAccountsCallback(
+	ctx context.Context,
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+	callback func(*KeyedAccount) error,
+) error {
+	params := getProgramAccountsParams(publicKey, opts, false)
+	return cl.callStreaming(ctx, "getProgramAccounts", params, func(decoder *stdjson.Decoder) error {
+		return decodeKeyedAccountsArray(decoder, callback)
+	})
+}
 
-	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
-	return
+func decodeKeyedAccountsArray(decoder *stdjson.Decoder, callback func(*KeyedAccount) error) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("unable to read result: %w", err)
+	}
+	if tok == nil {
+		// null result: no accounts.
+		return nil
+	}
+	if delim, ok := tok.(stdjson.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected result to be an array, got %v", tok)
+	}
+	for i := 0; decoder.More(); i++ {
+		var account *KeyedAccount
+		if err := decoder.Decode(&account); err != nil {
+			return fmt.Errorf("unable to decode account %d: %w", i, err)
+		}
+		if err := callback(account); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
 }
@@ -81,3 +81,18 @@ const (
 	ConfirmationStatusConfirmed ConfirmationStatusType = "confirmed"
 	ConfirmationStatusFinalized ConfirmationStatusType = "finalized"
 )
+
+// IsAtLeast returns true if a transaction with this status is confirmed at the commitment:
+// any status satisfies "processed", "confirmed" and "finalized" satisfy "confirmed",
+// and only "finalized" satisfies "finalized". The deprecated commitments are treated
+// as their replacements.
+func (status ConfirmationStatusType) IsAtLeast(commitment CommitmentType) bool {
+	switch commitment {
+	case CommitmentProcessed, CommitmentRecent:
+		return status != ""
+	case CommitmentFinalized, CommitmentMax, CommitmentRoot:
+		return status == ConfirmationStatusFinalized
+	default:
+		return status == ConfirmationStatusConfirmed || status == ConfirmationStatusFinalized
+	}
+}
@@ -16,6 +16,8 @@ package rpc
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -94,3 +96,173 @@ func (cl *Client) GetSignaturesForAddressWithOpts(
 	err = cl.rpcClient.CallForInto(ctx, &out, "getSignaturesForAddress", params)
 	return
 }
+
+// MaxSignaturesForAddressLimit is the max number of signatures
+// returned by a getSignaturesForAddress request.
+const MaxSignaturesForAddressLimit = 1000
+
+type SignaturesForAddressAllOpts struct {
+	// (optional) Number of signatures per request (between 1 and 1,000, default: 1,000).
+	PageSize int
+
+	// (optional) Start searching backwards from this transaction signature.
+	// If not provided the search starts from the top of the highest max confirmed block.
+	Before solana.Signature
+
+	// (optional) Search until this transaction signature (excluded).
+	Until solana.Signature
+
+	// (optional) Commitment; "processed" is not supported.
+	// If parameter not provided, the default is "finalized".
+	Commitment CommitmentType
+
+	// (optional) The minimum slot that the requests can be evaluated at.
+	MinContextSlot *uint64
+
+	// (optional) Max number of signatures passed to the callback in total;
+	// zero means no limit.
+	MaxTotal int
+}
+
+// SignaturesForAddressAll pages backwards through the signatures of the transactions
+// involving the address (from the newest to the oldest), using the last signature
+// of each page as the `before` cursor of the next request,
+// until the history is exhausted, `Until` is reached, or `MaxTotal` signatures were returned.
+// The callback is called with each (non-empty) page; if it returns an error,
+// the iteration stops and that error is returned.
+func (cl *Client) SignaturesForAddressAll(
+	ctx context.Context,
+	account solana.PublicKey,
+	opts *SignaturesForAddressAllOpts,
+	callback func([]*TransactionSignature) error,
+) error {
+	it := cl.SignaturesForAddressIterator(ctx, account, opts)
+	for {
+		page, err := it.nextPage()
+		if err != nil {
+			return err
+		}
+		if len(page) > 0 {
+			if err := callback(page); err != nil {
+				return err
+			}
+		}
+		if it.done {
+			return nil
+		}
+	}
+}
+
+// SignaturesForAddressIterator iterates over the signatures of the transactions
+// involving an address, from the newest to the oldest (in the order returned by the RPC),
+// fetching the pages (see SignaturesForAddressAll) as needed.
+// It is not safe for concurrent use.
+type SignaturesForAddressIterator struct {
+	client  *Client
+	ctx     context.Context
+	account solana.PublicKey
+	opts    SignaturesForAddressAllOpts
+
+	// The cursor of the next page.
+	before solana.Signature
+	total  int
+	// The signatures of the current page that were not returned yet.
+	page []*TransactionSignature
+	done bool
+}
+
+// SignaturesForAddressIterator returns an iterator over the signatures of the transactions
+// involving the address; the options are the ones of SignaturesForAddressAll.
+// No request is made until the first call to Next.
+func (cl *Client) SignaturesForAddressIterator(
+	ctx context.Context,
+	account solana.PublicKey,
+	opts *SignaturesForAddressAllOpts,
+) *SignaturesForAddressIterator {
+	var options SignaturesForAddressAllOpts
+	if opts != nil {
+		options = *opts
+	}
+	if options.PageSize <= 0 || options.PageSize > MaxSignaturesForAddressLimit {
+		options.PageSize = MaxSignaturesForAddressLimit
+	}
+	return &SignaturesForAddressIterator{
+		client:  cl,
+		ctx:     ctx,
+		account: account,
+		opts:    options,
+		before:  options.Before,
+	}
+}
+
+// Next returns the next (older) signature, fetching the next page when the current one
+// is exhausted. It returns io.EOF once the history is exhausted, `Until` is reached,
+// or `MaxTotal` signatures were returned.
+// After any other error (e.g. of the request), calling Next retries the same page.
+func (it *SignaturesForAddressIterator) Next() (*TransactionSignature, error) {
+	for len(it.page) == 0 {
+		if it.done {
+			return nil, io.EOF
+		}
+		page, err := it.nextPage()
+		if err != nil {
+			return nil, err
+		}
+		it.page = page
+	}
+	sig := it.page[0]
+	it.page = it.page[1:]
+	return sig, nil
+}
+
+// Reverse collects the remaining signatures, and returns them from the oldest to the newest.
+// As they are all kept in memory, the iteration must be bounded by `MaxTotal` or `Until`.
+func (it *SignaturesForAddressIterator) Reverse() ([]*TransactionSignature, error) {
+	if it.opts.MaxTotal <= 0 && it.opts.Until.IsZero() {
+		return nil, errors.New("cannot reverse an unbounded iteration: set MaxTotal or Until")
+	}
+	var out []*TransactionSignature
+	for {
+		sig, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sig)
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// nextPage fetches the next page of signatures, and advances the cursor;
+// done is set after the last page.
+func (it *SignaturesForAddressIterator) nextPage() ([]*TransactionSignature, error) {
+	if err := it.ctx.Err(); err != nil {
+		return nil, err
+	}
+	limit := it.opts.PageSize
+	if it.opts.MaxTotal > 0 && it.opts.MaxTotal-it.total < limit {
+		limit = it.opts.MaxTotal - it.total
+	}
+	page, err := it.client.GetSignaturesForAddressWithOpts(it.ctx, it.account, &GetSignaturesForAddressOpts{
+		Limit:          &limit,
+		Before:         it.before,
+		Until:          it.opts.Until,
+		Commitment:     it.opts.Commitment,
+		MinContextSlot: it.opts.MinContextSlot,
+	})
+	if err != nil {
+		return nil, err
+	}
+	it.total += len(page)
+	if len(page) < limit || (it.opts.MaxTotal > 0 && it.total >= it.opts.MaxTotal) {
+		it.done = true
+	} else {
+		it.before = page[len(page)-1].Signature
+	}
+	return page, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/sysvar"
+)
+
+// GetClock fetches and decodes the Clock sysvar account:
+// its UnixTimestamp is the current on-chain time (see also sysvar.Clock.Time).
+func (cl *Client) GetClock(ctx context.Context) (*sysvar.Clock, error) {
+	out, err := cl.GetAccountInfoWithOpts(ctx, solana.SysVarClockPubkey, &GetAccountInfoOpts{
+		Encoding: solana.EncodingBase64,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sysvar.DecodeClock(out.GetBinary())
+}
+
+// GetRent fetches and decodes the Rent sysvar account.
+func (cl *Client) GetRent(ctx context.Context) (*sysvar.Rent, error) {
+	out, err := cl.GetAccountInfoWithOpts(ctx, solana.SysVarRentPubkey, &GetAccountInfoOpts{
+		Encoding: solana.EncodingBase64,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sysvar.DecodeRent(out.GetBinary())
+}
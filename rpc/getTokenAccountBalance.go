@@ -40,3 +40,7 @@ type GetTokenAccountBalanceResult struct {
 	RPCContext
 	Value *UiTokenAmount `json:"value"`
 }
+
+func (res *GetTokenAccountBalanceResult) UnmarshalJSON(data []byte) error {
+	return withContext{&res.RPCContext, &res.Value}.UnmarshalJSON(data)
+}
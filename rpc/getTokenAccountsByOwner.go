@@ -17,6 +17,7 @@ package rpc
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -80,3 +81,111 @@ func (cl *Client) GetTokenAccountsByOwner(
 	err = cl.rpcClient.CallForInto(ctx, &out, "getTokenAccountsByOwner", params)
 	return
 }
+
+// TokenAccountHeaderLength is the length of the part of a token account
+// that contains the mint (32 bytes), the owner (32 bytes), and the amount (8 bytes).
+const TokenAccountHeaderLength = 72
+
+type GetTokenAccountsByOwnerByMintOpts struct {
+	Commitment CommitmentType
+
+	// Pubkey of the Token program ID that owns the accounts.
+	// Defaults to solana.TokenProgramID.
+	ProgramId *solana.PublicKey
+
+	// If true, only the first TokenAccountHeaderLength bytes (mint, owner, and amount)
+	// of each account are fetched; use this for wallets with many token accounts.
+	HeaderOnly bool
+}
+
+type MintTokenAccounts struct {
+	// The token account of the owner for this mint: the associated token account
+	// when present, otherwise the first account returned by the node.
+	Account *TokenAccount
+
+	// True if Account is the associated token account of the owner for this mint.
+	IsAssociated bool
+
+	// All the other token accounts of the owner for this mint.
+	Auxiliary []*TokenAccount
+}
+
+// GetTokenAccountsByOwnerByMint returns all SPL Token accounts of the owner,
+// grouped by mint. For each mint, the associated token account of the owner
+// (derived client-side, for the token program owning the account) is preferred,
+// and all other accounts for the same mint are listed under `Auxiliary`.
+func (cl *Client) GetTokenAccountsByOwnerByMint(
+	ctx context.Context,
+	owner solana.PublicKey,
+	opts *GetTokenAccountsByOwnerByMintOpts,
+) (map[solana.PublicKey]*MintTokenAccounts, error) {
+	programID := solana.TokenProgramID
+	reqOpts := &GetTokenAccountsOpts{
+		Encoding: solana.EncodingBase64,
+	}
+	if opts != nil {
+		if opts.ProgramId != nil {
+			programID = *opts.ProgramId
+		}
+		reqOpts.Commitment = opts.Commitment
+		if opts.HeaderOnly {
+			offset := uint64(0)
+			length := uint64(TokenAccountHeaderLength)
+			reqOpts.DataSlice = &DataSlice{
+				Offset: &offset,
+				Length: &length,
+			}
+		}
+	}
+
+	res, err := cl.GetTokenAccountsByOwner(
+		ctx,
+		owner,
+		&GetTokenAccountsConfig{
+			ProgramId: &programID,
+		},
+		reqOpts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[solana.PublicKey]*MintTokenAccounts)
+	for _, acc := range res.Value {
+		if acc == nil || acc.Account.Data == nil {
+			continue
+		}
+		data := acc.Account.Data.GetBinary()
+		if len(data) < solana.PublicKeyLength {
+			return nil, fmt.Errorf("token account %s: data too short: %d bytes", acc.Pubkey, len(data))
+		}
+		mint := solana.PublicKeyFromBytes(data[:solana.PublicKeyLength])
+
+		// The token program is a seed of the associated token account.
+		tokenProgramID := programID
+		if !acc.Account.Owner.IsZero() {
+			tokenProgramID = acc.Account.Owner
+		}
+		ata, _, err := solana.FindAssociatedTokenAddressForProgram(owner, mint, tokenProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive associated token address for mint %s: %w", mint, err)
+		}
+		isAssociated := acc.Pubkey.Equals(ata)
+
+		group, ok := out[mint]
+		switch {
+		case !ok:
+			out[mint] = &MintTokenAccounts{
+				Account:      acc,
+				IsAssociated: isAssociated,
+			}
+		case isAssociated:
+			group.Auxiliary = append(group.Auxiliary, group.Account)
+			group.Account = acc
+			group.IsAssociated = true
+		default:
+			group.Auxiliary = append(group.Auxiliary, acc)
+		}
+	}
+	return out, nil
+}
@@ -40,3 +40,7 @@ type GetTokenSupplyResult struct {
 	RPCContext
 	Value *UiTokenAmount `json:"value"`
 }
+
+func (res *GetTokenSupplyResult) UnmarshalJSON(data []byte) error {
+	return withContext{&res.RPCContext, &res.Value}.UnmarshalJSON(data)
+}
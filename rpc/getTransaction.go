@@ -17,6 +17,7 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"time"
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
@@ -31,8 +32,21 @@ type GetTransactionOpts struct {
 	// Max transaction version to return in responses.
 	// If the requested block contains a transaction with a higher version, an error will be returned.
 	MaxSupportedTransactionVersion *uint64
+
+	// If set, and the node returns no transaction, the request is retried
+	// until the transaction is available or this timeout expires
+	// (and then ErrNotFound is returned).
+	// Right after a transaction is confirmed, the node might not return it yet;
+	// use the same commitment used to confirm the transaction, since e.g.
+	// a confirmed transaction is not returned with the "finalized" commitment
+	// until it is finalized.
+	RetryUntilAvailable time.Duration
 }
 
+// getTransactionRetryInterval is the time between the retries
+// of a GetTransaction with RetryUntilAvailable.
+var getTransactionRetryInterval = 200 * time.Millisecond
+
 // GetTransaction returns transaction details for a confirmed transaction.
 //
 // NEW: This method is only available in solana-core v1.7 or newer.
@@ -69,14 +83,32 @@ func (cl *Client) GetTransaction(
 			params = append(params, obj)
 		}
 	}
-	err = cl.rpcClient.CallForInto(ctx, &out, "getTransaction", params)
-	if err != nil {
-		return nil, err
+	var deadline time.Time
+	if opts != nil && opts.RetryUntilAvailable > 0 {
+		deadline = time.Now().Add(opts.RetryUntilAvailable)
 	}
-	if out == nil {
-		return nil, ErrNotFound
+	for {
+		out = nil
+		err = cl.rpcClient.CallForInto(ctx, &out, "getTransaction", params)
+		if err != nil {
+			return nil, err
+		}
+		if out != nil {
+			return out, nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrNotFound
+		}
+		if remaining > getTransactionRetryInterval {
+			remaining = getTransactionRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(remaining):
+		}
 	}
-	return
 }
 
 type GetTransactionResult struct {
@@ -93,6 +125,45 @@ type GetTransactionResult struct {
 	Version     TransactionVersion         `json:"version"`
 }
 
+// GetResolvedTransaction decodes the transaction, and resolves the accounts it loads
+// from address lookup tables with the metadata: the account keys of its message
+// are the static keys, followed by the loaded writable and then readonly addresses
+// (see TransactionWithMeta.GetResolvedTransaction).
+func (res *GetTransactionResult) GetResolvedTransaction() (*solana.Transaction, error) {
+	if res.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	tx, err := res.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction: %w", err)
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
+	// Don't modify the transaction of the envelope, if it was parsed from JSON.
+	resolved := *tx
+	tx = &resolved
+	if err := resolveLoadedAddresses(tx, res.Meta); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// DecodeInstructions decodes the transaction and its top-level instructions,
+// with the accounts loaded from address lookup tables resolved
+// (see TransactionWithMeta.DecodeInstructions).
+func (res *GetTransactionResult) DecodeInstructions() (*solana.Transaction, []DecodedInstruction, error) {
+	tx, err := res.GetResolvedTransaction()
+	if err != nil {
+		return nil, nil, err
+	}
+	instructions, err := decodeInstructions(tx, res.Meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, instructions, nil
+}
+
 // TransactionResultEnvelope will contain a *solana.Transaction if the requested encoding is `solana.EncodingJSON`
 // (which is also the default when the encoding is not specified),
 // or a `solana.Data` in case of EncodingBase58, EncodingBase64.
@@ -0,0 +1,52 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"go.uber.org/zap"
+)
+
+// Hooks are notified of noteworthy events of a Client.
+type Hooks interface {
+	// OnDeprecatedMethod is called when the node doesn't support
+	// a deprecated method, and the client falls back to its replacement.
+	OnDeprecatedMethod(method string, replacement string)
+}
+
+// SetHooks sets the hooks of the client (by default, the events are logged).
+// The hooks can be called from multiple goroutines at the same time.
+func (cl *Client) SetHooks(hooks Hooks) {
+	cl.hooksMu.Lock()
+	defer cl.hooksMu.Unlock()
+	cl.hooks = hooks
+}
+
+func (cl *Client) getHooks() Hooks {
+	cl.hooksMu.RLock()
+	defer cl.hooksMu.RUnlock()
+	if cl.hooks == nil {
+		return logHooks{}
+	}
+	return cl.hooks
+}
+
+type logHooks struct{}
+
+func (logHooks) OnDeprecatedMethod(method string, replacement string) {
+	zlog.Warn("deprecated RPC method not supported by the node, using its replacement",
+		zap.String("method", method),
+		zap.String("replacement", replacement),
+	)
+}
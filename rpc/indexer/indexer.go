@@ -0,0 +1,301 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package indexer replays, in order, the historical transactions
+// that touched an address.
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const (
+	DefaultConcurrency = 4
+	// The max number of signatures returned by a getSignaturesForAddress request.
+	MaxPageSize = 1000
+)
+
+// CheckpointStore persists the last processed signature of each address,
+// so that a Walk can be resumed where the previous one stopped.
+type CheckpointStore interface {
+	// Load returns the last processed signature for the address,
+	// or a zero signature if there is none.
+	Load(ctx context.Context, address solana.PublicKey) (solana.Signature, error)
+	Save(ctx context.Context, address solana.PublicKey, sig solana.Signature) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore that keeps the checkpoints in memory.
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[solana.PublicKey]solana.Signature
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{
+		checkpoints: make(map[solana.PublicKey]solana.Signature),
+	}
+}
+
+func (s *MemoryCheckpointStore) Load(ctx context.Context, address solana.PublicKey) (solana.Signature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[address], nil
+}
+
+func (s *MemoryCheckpointStore) Save(ctx context.Context, address solana.PublicKey, sig solana.Signature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[address] = sig
+	return nil
+}
+
+// TransactionUnavailableError is returned when a signature is listed
+// in the history of the address, but the node can't return its transaction
+// (e.g. because it is older than the node's history horizon).
+type TransactionUnavailableError struct {
+	Signature solana.Signature
+	Slot      uint64
+	// The RPC error, if any; nil if the node returned no transaction.
+	Err error
+}
+
+func (e *TransactionUnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("transaction %s (slot %d) is not available: %s", e.Signature, e.Slot, e.Err)
+	}
+	return fmt.Sprintf("transaction %s (slot %d) is not available", e.Signature, e.Slot)
+}
+
+func (e *TransactionUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+type Options struct {
+	// Max number of transactions fetched at the same time. Defaults to DefaultConcurrency.
+	Concurrency int
+	// Number of signatures per getSignaturesForAddress request.
+	// Defaults to (and can't be greater than) MaxPageSize.
+	PageSize int
+	// Defaults to finalized; "processed" is not supported by the RPC methods used.
+	Commitment rpc.CommitmentType
+	// Max transaction version to fetch. Defaults to 0 (i.e. legacy and v0 transactions).
+	MaxSupportedTransactionVersion *uint64
+	// If set, a transaction that the node doesn't return yet is fetched again
+	// until it is available or this timeout expires (see rpc.GetTransactionOpts.RetryUntilAvailable).
+	RetryUntilAvailable time.Duration
+	// If set, the last processed signature is saved after each transaction,
+	// and a Walk starts from the saved checkpoint (when there is one)
+	// instead of fromSignature.
+	Store CheckpointStore
+	// Called when a transaction is not available. If it returns nil,
+	// the transaction is skipped (and checkpointed) and the walk continues;
+	// otherwise the walk stops with the returned error.
+	// If not set, the walk stops with the *TransactionUnavailableError.
+	OnUnavailable func(*TransactionUnavailableError) error
+}
+
+func (opts *Options) withDefaults() Options {
+	out := Options{}
+	if opts != nil {
+		out = *opts
+	}
+	if out.Concurrency <= 0 {
+		out.Concurrency = DefaultConcurrency
+	}
+	if out.PageSize <= 0 || out.PageSize > MaxPageSize {
+		out.PageSize = MaxPageSize
+	}
+	if out.Commitment == "" {
+		out.Commitment = rpc.CommitmentFinalized
+	}
+	if out.MaxSupportedTransactionVersion == nil {
+		version := uint64(0)
+		out.MaxSupportedTransactionVersion = &version
+	}
+	return out
+}
+
+// Walk calls the handler for each transaction that touched the address
+// after fromSignature (excluded; if zero, from the oldest available signature),
+// from the oldest to the newest.
+// The signatures are listed first (paginating backwards), then the transactions
+// are fetched concurrently, but the handler is always called in order,
+// one transaction at a time.
+// If the handler returns an error, the walk stops and that error is returned;
+// when a CheckpointStore is set, the checkpoint is the last transaction
+// for which the handler returned nil.
+func Walk(
+	ctx context.Context,
+	client *rpc.Client,
+	address solana.PublicKey,
+	fromSignature solana.Signature,
+	handler func(*rpc.TransactionWithMeta) error,
+	opts *Options,
+) error {
+	options := opts.withDefaults()
+
+	if options.Store != nil {
+		checkpoint, err := options.Store.Load(ctx, address)
+		if err != nil {
+			return fmt.Errorf("unable to load checkpoint for %s: %w", address, err)
+		}
+		if !checkpoint.IsZero() {
+			fromSignature = checkpoint
+		}
+	}
+
+	signatures, err := listSignatures(ctx, client, address, fromSignature, options)
+	if err != nil {
+		return err
+	}
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The fetches are started in order, and their results are queued in the same order;
+	// the semaphore bounds the number of transactions being fetched or waiting to be handled.
+	type result struct {
+		tx  *rpc.TransactionWithMeta
+		err error
+	}
+	sem := make(chan struct{}, options.Concurrency)
+	queue := make(chan chan result, options.Concurrency)
+	go func() {
+		defer close(queue)
+		for _, sig := range signatures {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			res := make(chan result, 1)
+			queue <- res
+			go func(sig *rpc.TransactionSignature) {
+				tx, err := getTransaction(ctx, client, sig, options)
+				res <- result{tx: tx, err: err}
+			}(sig)
+		}
+	}()
+
+	for i := range signatures {
+		res, ok := <-queue
+		if !ok {
+			return ctx.Err()
+		}
+		r := <-res
+		<-sem
+
+		sig := signatures[i].Signature
+		if r.err != nil {
+			var unavailable *TransactionUnavailableError
+			if !errors.As(r.err, &unavailable) || options.OnUnavailable == nil {
+				return r.err
+			}
+			if err := options.OnUnavailable(unavailable); err != nil {
+				return err
+			}
+		} else if err := handler(r.tx); err != nil {
+			return err
+		}
+
+		if options.Store != nil {
+			if err := options.Store.Save(ctx, address, sig); err != nil {
+				return fmt.Errorf("unable to save checkpoint %s for %s: %w", sig, address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// listSignatures returns the signatures of the transactions that touched the address
+// after `until` (excluded), from the oldest to the newest.
+func listSignatures(
+	ctx context.Context,
+	client *rpc.Client,
+	address solana.PublicKey,
+	until solana.Signature,
+	options Options,
+) ([]*rpc.TransactionSignature, error) {
+	var signatures []*rpc.TransactionSignature
+	err := client.SignaturesForAddressAll(
+		ctx,
+		address,
+		&rpc.SignaturesForAddressAllOpts{
+			PageSize:   options.PageSize,
+			Until:      until,
+			Commitment: options.Commitment,
+		},
+		func(page []*rpc.TransactionSignature) error {
+			signatures = append(signatures, page...)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get signatures for %s: %w", address, err)
+	}
+	// Newest first -> oldest first.
+	for i, j := 0, len(signatures)-1; i < j; i, j = i+1, j-1 {
+		signatures[i], signatures[j] = signatures[j], signatures[i]
+	}
+	return signatures, nil
+}
+
+func getTransaction(
+	ctx context.Context,
+	client *rpc.Client,
+	sig *rpc.TransactionSignature,
+	options Options,
+) (*rpc.TransactionWithMeta, error) {
+	res, err := client.GetTransaction(ctx, sig.Signature, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     options.Commitment,
+		MaxSupportedTransactionVersion: options.MaxSupportedTransactionVersion,
+		RetryUntilAvailable:            options.RetryUntilAvailable,
+	})
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return nil, &TransactionUnavailableError{Signature: sig.Signature, Slot: sig.Slot}
+		}
+		var rpcErr *jsonrpc.RPCError
+		if errors.As(err, &rpcErr) {
+			switch rpcErr.Code {
+			// The node doesn't have (or doesn't have anymore) the transaction.
+			case rpc.ErrorCodeSlotSkipped, rpc.ErrorCodeLongTermStorageSlotSkipped, rpc.ErrorCodeTransactionHistoryNotAvailable:
+				return nil, &TransactionUnavailableError{Signature: sig.Signature, Slot: sig.Slot, Err: err}
+			}
+		}
+		return nil, fmt.Errorf("unable to get transaction %s: %w", sig.Signature, err)
+	}
+	if res == nil || res.Transaction == nil {
+		return nil, &TransactionUnavailableError{Signature: sig.Signature, Slot: sig.Slot}
+	}
+	return &rpc.TransactionWithMeta{
+		Slot:        res.Slot,
+		BlockTime:   res.BlockTime,
+		Transaction: rpc.DataBytesOrJSONFromBytes(res.Transaction.GetBinary()),
+		Meta:        res.Meta,
+		Version:     res.Version,
+	}, nil
+}
@@ -0,0 +1,333 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHistory serves getSignaturesForAddress and getTransaction
+// for a single address whose history is `count` transactions,
+// the i-th one (oldest first) being at slot 100+i.
+type mockHistory struct {
+	mu         sync.Mutex
+	signatures []solana.Signature // oldest first
+	// Transactions the node doesn't have anymore: null, or an RPC error.
+	pruned map[solana.Signature]bool
+	errors map[solana.Signature]string
+	// Number of times a transaction is returned as null before it is available.
+	pending     map[solana.Signature]int
+	txOpts      map[string]interface{}
+	pages       int
+	inFlight    int
+	maxInFlight int
+}
+
+func newMockHistory(count int) *mockHistory {
+	m := &mockHistory{
+		pruned:  map[solana.Signature]bool{},
+		errors:  map[solana.Signature]string{},
+		pending: map[solana.Signature]int{},
+	}
+	m.add(count)
+	return m
+}
+
+func (m *mockHistory) add(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 0; i < count; i++ {
+		m.signatures = append(m.signatures, solana.Signature{byte(len(m.signatures) + 1)})
+	}
+}
+
+func (m *mockHistory) slotOf(sig solana.Signature) int {
+	return 100 + int(sig[0]) - 1
+}
+
+func (m *mockHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params []interface{}   `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	var response string
+	switch req.Method {
+	case "getSignaturesForAddress":
+		response = m.getSignaturesForAddress(req.Params[1].(map[string]interface{}))
+	case "getTransaction":
+		m.mu.Lock()
+		m.txOpts = req.Params[1].(map[string]interface{})
+		m.mu.Unlock()
+		response = m.getTransaction(solana.MustSignatureFromBase58(req.Params[0].(string)))
+	default:
+		http.Error(w, "unexpected method", http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,%s}`, req.ID, response)
+}
+
+func (m *mockHistory) getSignaturesForAddress(opts map[string]interface{}) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pages++
+
+	limit := int(opts["limit"].(float64))
+	// Newest first.
+	start := len(m.signatures) - 1
+	if before, ok := opts["before"]; ok {
+		for i, sig := range m.signatures {
+			if sig.String() == before {
+				start = i - 1
+			}
+		}
+	}
+	var out []string
+	for i := start; i >= 0 && len(out) < limit; i-- {
+		sig := m.signatures[i]
+		if until, ok := opts["until"]; ok && sig.String() == until {
+			break
+		}
+		out = append(out, fmt.Sprintf(`{"signature":%q,"slot":%d,"err":null,"memo":null,"blockTime":null}`, sig, m.slotOf(sig)))
+	}
+	return `"result":[` + strings.Join(out, ",") + `]`
+}
+
+func (m *mockHistory) getTransaction(sig solana.Signature) string {
+	m.mu.Lock()
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	pruned := m.pruned[sig] || m.pending[sig] > 0
+	if m.pending[sig] > 0 {
+		m.pending[sig]--
+	}
+	rpcErr := m.errors[sig]
+	m.mu.Unlock()
+
+	// Let the later transactions be fetched first.
+	time.Sleep(time.Duration(10-int(sig[0])%10) * time.Millisecond)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+
+	switch {
+	case pruned:
+		return `"result":null`
+	case rpcErr != "":
+		return `"error":` + rpcErr
+	}
+	return fmt.Sprintf(
+		`"result":{"slot":%d,"blockTime":null,"transaction":[%q,"base64"],"meta":{"err":null,"fee":5000,"preBalances":[],"postBalances":[]},"version":0}`,
+		m.slotOf(sig),
+		base64.StdEncoding.EncodeToString(sig[:]),
+	)
+}
+
+func collectSlots(slots *[]uint64) func(*rpc.TransactionWithMeta) error {
+	return func(tx *rpc.TransactionWithMeta) error {
+		*slots = append(*slots, tx.Slot)
+		return nil
+	}
+}
+
+func slotRange(from, to uint64) []uint64 {
+	var out []uint64
+	for slot := from; slot <= to; slot++ {
+		out = append(out, slot)
+	}
+	return out
+}
+
+func TestWalk(t *testing.T) {
+	history := newMockHistory(25)
+	server := httptest.NewServer(history)
+	defer server.Close()
+	client := rpc.New(server.URL)
+	address := solana.NewWallet().PublicKey()
+
+	var slots []uint64
+	err := Walk(
+		context.Background(),
+		client,
+		address,
+		solana.Signature{},
+		func(tx *rpc.TransactionWithMeta) error {
+			require.Equal(t, []byte{byte(tx.Slot - 99)}, tx.Transaction.GetBinary()[:1])
+			slots = append(slots, tx.Slot)
+			return nil
+		},
+		&Options{PageSize: 10, Concurrency: 3},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, slotRange(100, 124), slots)
+	// 10 + 10 + 5
+	assert.Equal(t, 3, history.pages)
+	assert.LessOrEqual(t, history.maxInFlight, 3)
+	assert.Greater(t, history.maxInFlight, 1)
+
+	// From a signature.
+	slots = nil
+	err = Walk(context.Background(), client, address, history.signatures[19], collectSlots(&slots), &Options{PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, slotRange(120, 124), slots)
+}
+
+func TestWalk_checkpoint(t *testing.T) {
+	history := newMockHistory(12)
+	server := httptest.NewServer(history)
+	defer server.Close()
+	client := rpc.New(server.URL)
+	address := solana.NewWallet().PublicKey()
+	store := NewMemoryCheckpointStore()
+
+	// The handler fails at slot 107: the checkpoint is the previous transaction.
+	stop := errors.New("stop")
+	var slots []uint64
+	err := Walk(
+		context.Background(),
+		client,
+		address,
+		solana.Signature{},
+		func(tx *rpc.TransactionWithMeta) error {
+			if tx.Slot == 107 {
+				return stop
+			}
+			slots = append(slots, tx.Slot)
+			return nil
+		},
+		&Options{PageSize: 5, Store: store},
+	)
+	require.ErrorIs(t, err, stop)
+	assert.Equal(t, slotRange(100, 106), slots)
+	checkpoint, err := store.Load(context.Background(), address)
+	require.NoError(t, err)
+	assert.Equal(t, history.signatures[6], checkpoint)
+
+	// Resume: the checkpoint takes precedence over fromSignature.
+	history.add(3)
+	slots = nil
+	err = Walk(context.Background(), client, address, history.signatures[1], collectSlots(&slots), &Options{PageSize: 5, Store: store})
+	require.NoError(t, err)
+	assert.Equal(t, slotRange(107, 114), slots)
+	checkpoint, _ = store.Load(context.Background(), address)
+	assert.Equal(t, history.signatures[14], checkpoint)
+
+	// Nothing new.
+	slots = nil
+	err = Walk(context.Background(), client, address, solana.Signature{}, collectSlots(&slots), &Options{PageSize: 5, Store: store})
+	require.NoError(t, err)
+	assert.Empty(t, slots)
+}
+
+func TestWalk_unavailable(t *testing.T) {
+	history := newMockHistory(8)
+	history.pruned[history.signatures[2]] = true
+	history.errors[history.signatures[3]] = `{"code":-32011,"message":"Transaction history is not available from this node"}`
+	server := httptest.NewServer(history)
+	defer server.Close()
+	client := rpc.New(server.URL)
+	address := solana.NewWallet().PublicKey()
+
+	// By default, the walk stops.
+	var slots []uint64
+	err := Walk(context.Background(), client, address, solana.Signature{}, collectSlots(&slots), nil)
+	var unavailable *TransactionUnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.Equal(t, history.signatures[2], unavailable.Signature)
+	assert.Equal(t, uint64(102), unavailable.Slot)
+	assert.Nil(t, unavailable.Err)
+	assert.Equal(t, slotRange(100, 101), slots)
+
+	// The unavailable transactions can be skipped.
+	var skipped []*TransactionUnavailableError
+	slots = nil
+	store := NewMemoryCheckpointStore()
+	err = Walk(context.Background(), client, address, solana.Signature{}, collectSlots(&slots), &Options{
+		Store: store,
+		OnUnavailable: func(err *TransactionUnavailableError) error {
+			skipped = append(skipped, err)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{100, 101, 104, 105, 106, 107}, slots)
+	require.Len(t, skipped, 2)
+	assert.Equal(t, uint64(102), skipped[0].Slot)
+	assert.Equal(t, uint64(103), skipped[1].Slot)
+	assert.Contains(t, skipped[1].Error(), "Transaction history is not available")
+
+	// Other RPC errors are not considered as unavailable transactions.
+	history.errors[history.signatures[3]] = `{"code":-32603,"message":"Internal error"}`
+	err = Walk(context.Background(), client, address, solana.Signature{}, collectSlots(&slots), &Options{
+		OnUnavailable: func(err *TransactionUnavailableError) error { return nil },
+	})
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &unavailable))
+}
+
+func TestWalk_retryUntilAvailable(t *testing.T) {
+	history := newMockHistory(3)
+	history.pending[history.signatures[1]] = 2
+	server := httptest.NewServer(history)
+	defer server.Close()
+	client := rpc.New(server.URL)
+
+	var slots []uint64
+	version := uint64(0)
+	err := Walk(context.Background(), client, solana.NewWallet().PublicKey(), solana.Signature{}, collectSlots(&slots), &Options{
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &version,
+		RetryUntilAvailable:            5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, slotRange(100, 102), slots)
+	assert.Equal(t, map[string]interface{}{
+		"encoding":                       "base64",
+		"commitment":                     "confirmed",
+		"maxSupportedTransactionVersion": float64(0),
+	}, history.txOpts)
+
+	// Still not available when the timeout expires.
+	history.pending[history.signatures[1]] = 1000
+	err = Walk(context.Background(), client, solana.NewWallet().PublicKey(), solana.Signature{}, collectSlots(&slots), &Options{
+		RetryUntilAvailable: time.Millisecond,
+	})
+	var unavailable *TransactionUnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.Equal(t, history.signatures[1], unavailable.Signature)
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gagliardetto/solana-go/internal/jsoncodec"
+)
+
+// TestMain runs the tests with the default JSON codec, and then again
+// with encoding/json (unless it is the default), so that the fixtures
+// are guaranteed to be decoded the same way by both.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if code == 0 && jsoncodec.Get() != jsoncodec.Std {
+		jsoncodec.Set(jsoncodec.Std)
+		code = m.Run()
+	}
+	os.Exit(code)
+}
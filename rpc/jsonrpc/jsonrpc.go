@@ -11,10 +11,10 @@ import (
 	"reflect"
 
 	"github.com/davecgh/go-spew/spew"
-	jsoniter "github.com/json-iterator/go"
+	"github.com/gagliardetto/solana-go/internal/jsoncodec"
 )
 
-var json = jsoniter.ConfigCompatibleWithStandardLibrary
+var json = jsoncodec.JSON
 
 const (
 	jsonrpcVersion = "2.0"
@@ -23,6 +23,9 @@ const (
 // RPCClient sends JSON-RPC requests over HTTP to the provided JSON-RPC backend.
 //
 // RPCClient is created using the factory function NewClient().
+// It is safe for concurrent use by multiple goroutines:
+// its configuration is never modified after it is created,
+// and the requests share the (concurrency-safe) HTTPClient.
 type RPCClient interface {
 	// Call is used to send a JSON-RPC request to the server endpoint.
 	//
@@ -84,7 +87,8 @@ type RPCClient interface {
 	// - field Params is sent as provided, so Params: 2 forms an invalid json (correct would be Params: []int{2})
 	// - you can use the helper function Params(1, 2, 3) to use the same format as in Call()
 	// - field JSONRPC is overwritten and set to value: "2.0"
-	// - field ID is overwritten and set incrementally and maps to the array position (e.g. requests[5].ID == 5)
+	// - field ID is overwritten and set incrementally and maps to the array position (e.g. requests[5].ID == 5),
+	//   so the same requests must not be passed to concurrent calls
 	//
 	//
 	// Returns RPCResponses that is of type []*RPCResponse
@@ -256,10 +260,17 @@ func (e *HTTPError) Error() string {
 	return e.err.Error()
 }
 
+// Unwrap returns the underlying error, e.g. the *RPCError
+// of a response with an HTTP error status and a JSON-RPC error body.
+func (e *HTTPError) Unwrap() error {
+	return e.err
+}
+
 type rpcClient struct {
 	endpoint      string
 	httpClient    HTTPClient
 	customHeaders map[string]string
+	userAgent     string
 }
 
 // RPCClientOpts can be provided to NewClientWithOpts() to change configuration of RPCClient.
@@ -267,9 +278,28 @@ type rpcClient struct {
 // HTTPClient: provide a custom http.Client (e.g. to set a proxy, or tls options)
 //
 // CustomHeaders: provide custom headers, e.g. to set BasicAuth
+//
+// UserAgent: the User-Agent header of the requests (the default one of Go if empty);
+// a User-Agent in CustomHeaders, or set on the context with WithUserAgent, takes precedence.
 type RPCClientOpts struct {
 	HTTPClient    HTTPClient
 	CustomHeaders map[string]string
+	UserAgent     string
+}
+
+type userAgentContextKey struct{}
+
+// WithUserAgent returns a copy of ctx with which the requests are sent
+// with the provided User-Agent header, instead of the one of the client,
+// e.g. to identify the tenant of a multi-tenant proxy.
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentContextKey{}, userAgent)
+}
+
+// UserAgentFromContext returns the User-Agent set on ctx with WithUserAgent, if any.
+func UserAgentFromContext(ctx context.Context) (string, bool) {
+	userAgent, ok := ctx.Value(userAgentContextKey{}).(string)
+	return userAgent, ok && userAgent != ""
 }
 
 // RPCResponses is of type []*RPCResponse.
@@ -343,6 +373,7 @@ func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
 			rpcClient.customHeaders[k] = v
 		}
 	}
+	rpcClient.userAgent = opts.UserAgent
 
 	return rpcClient
 }
@@ -379,13 +410,13 @@ func (client *rpcClient) CallForInto(
 		request.Params = params
 	}
 
-	rpcResponse, err := client.doCall(ctx, request)
+	rpcResponse, statusCode, err := client.doCallWithStatusCode(ctx, request)
 	if err != nil {
 		return err
 	}
 
 	if rpcResponse.Error != nil {
-		return rpcResponse.Error
+		return withHTTPStatus(rpcResponse.Error, statusCode)
 	}
 
 	return rpcResponse.GetObject(out)
@@ -418,18 +449,31 @@ func (client *rpcClient) CallRaw(ctx context.Context, request *RPCRequest) (*RPC
 }
 
 func (client *rpcClient) CallFor(ctx context.Context, out interface{}, method string, params ...interface{}) error {
-	rpcResponse, err := client.Call(ctx, method, params...)
+	rpcResponse, statusCode, err := client.doCallWithStatusCode(ctx, NewRequest(method, params...))
 	if err != nil {
 		return err
 	}
 
 	if rpcResponse.Error != nil {
-		return rpcResponse.Error
+		return withHTTPStatus(rpcResponse.Error, statusCode)
 	}
 
 	return rpcResponse.GetObject(out)
 }
 
+// withHTTPStatus wraps the RPC error of a response with an HTTP error status
+// (e.g. a 503 with a JSON-RPC error body) in an *HTTPError, so that the status is not lost;
+// the *RPCError can still be obtained with errors.As.
+func withHTTPStatus(rpcErr *RPCError, statusCode int) error {
+	if statusCode < 400 {
+		return rpcErr
+	}
+	return &HTTPError{
+		Code: statusCode,
+		err:  rpcErr,
+	}
+}
+
 func (client *rpcClient) CallBatch(ctx context.Context, requests RPCRequests) (RPCResponses, error) {
 	if len(requests) == 0 {
 		return nil, errors.New("empty request list")
@@ -464,11 +508,17 @@ func (client *rpcClient) newRequest(ctx context.Context, req interface{}) (*http
 
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "application/json")
+	if client.userAgent != "" {
+		request.Header.Set("User-Agent", client.userAgent)
+	}
 
 	// set default headers first, so that even content type and accept can be overwritten
 	for k, v := range client.customHeaders {
 		request.Header.Set(k, v)
 	}
+	if userAgent, ok := UserAgentFromContext(ctx); ok {
+		request.Header.Set("User-Agent", userAgent)
+	}
 
 	return request, nil
 }
@@ -477,11 +527,22 @@ func (client *rpcClient) doCall(
 	ctx context.Context,
 	RPCRequest *RPCRequest,
 ) (*RPCResponse, error) {
+	rpcResponse, _, err := client.doCallWithStatusCode(ctx, RPCRequest)
+	return rpcResponse, err
+}
+
+// doCallWithStatusCode is like doCall, also returning the HTTP status code of the response.
+func (client *rpcClient) doCallWithStatusCode(
+	ctx context.Context,
+	RPCRequest *RPCRequest,
+) (*RPCResponse, int, error) {
 	var rpcResponse *RPCResponse
+	var statusCode int
 	err := client.doCallWithCallbackOnHTTPResponse(
 		ctx,
 		RPCRequest,
 		func(httpRequest *http.Request, httpResponse *http.Response) error {
+			statusCode = httpResponse.StatusCode
 			decoder := json.NewDecoder(httpResponse.Body)
 			decoder.DisallowUnknownFields()
 			decoder.UseNumber()
@@ -513,10 +574,11 @@ func (client *rpcClient) doCall(
 		},
 	)
 	if err != nil {
-		return nil, err
+		return nil, statusCode, err
 	}
 
-	return rpcResponse, nil
+	normalizeNullResult(rpcResponse)
+	return rpcResponse, statusCode, nil
 }
 
 func (client *rpcClient) doCallWithCallbackOnHTTPResponse(
@@ -584,9 +646,22 @@ func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCReque
 		return nil, fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode)
 	}
 
+	for _, res := range rpcResponse {
+		normalizeNullResult(res)
+	}
 	return rpcResponse, nil
 }
 
+var jsonNull = []byte("null")
+
+// normalizeNullResult sets a `null` result to nil,
+// which is what json-iterator does but encoding/json doesn't.
+func normalizeNullResult(res *RPCResponse) {
+	if res != nil && bytes.Equal(res.Result, jsonNull) {
+		res.Result = nil
+	}
+}
+
 // Params is a helper function that uses the same parameter syntax as Call().
 // But you should consider to always use NewRequest() instead.
 //
@@ -0,0 +1,189 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// The line logged by the runtime in place of the rest of the logs,
+// when the log limit of the transaction is exceeded.
+const logTruncated = "Log truncated"
+
+// InstructionCompute is the compute units consumed by an invocation of a program
+// (a top-level instruction, or a cross-program invocation).
+type InstructionCompute struct {
+	// Index of the top-level instruction (in the message) this invocation is part of.
+	InstructionIndex int
+	ProgramID        solana.PublicKey
+	// 1 for the top-level instruction, 2 for the programs it invokes, and so on.
+	Depth int
+	// Compute units consumed by the invocation, including the ones of the invocations it makes.
+	// Zero for the builtin programs (e.g. the system program), that don't log it.
+	UnitsConsumed uint64
+	// Incomplete is true if the logs were truncated before the units consumed
+	// by the invocation were logged (or, for a top-level instruction,
+	// before it was invoked at all): UnitsConsumed is then unknown.
+	Incomplete bool
+}
+
+// ComputeUnitsByInstruction parses the "invoke" and "consumed X of Y compute units"
+// lines of the logs of the transaction, and returns the units consumed by each
+// invocation, in order of invocation, mapped to the top-level instructions of the message.
+//
+// If the logs were truncated (log limit exceeded), the invocations that were
+// not finished, and the top-level instructions that were not invoked, are returned
+// flagged as Incomplete. The instructions after a failed one are not executed,
+// and are not returned.
+func ComputeUnitsByInstruction(meta *TransactionMeta, msg *solana.Message) ([]InstructionCompute, error) {
+	if meta == nil {
+		return nil, errors.New("transaction meta is nil")
+	}
+	if msg == nil {
+		return nil, errors.New("message is nil")
+	}
+	if meta.LogMessages == nil {
+		return nil, errors.New("no log messages (log recording was not enabled for this transaction)")
+	}
+
+	var (
+		out []InstructionCompute
+		// Indexes in out of the unfinished invocations.
+		stack     []int
+		topLevel  = -1
+		truncated bool
+	)
+	for lineIndex, line := range meta.LogMessages {
+		if line == logTruncated {
+			truncated = true
+			break
+		}
+		programID, rest, ok := parseProgramLogLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(rest, "invoke ["):
+			depth, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rest, "invoke ["), "]"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid invoke depth: %q", lineIndex, line)
+			}
+			if depth != len(stack)+1 {
+				return nil, fmt.Errorf("line %d: unexpected invoke depth %d (expected %d): %q", lineIndex, depth, len(stack)+1, line)
+			}
+			if depth == 1 {
+				topLevel++
+				if err := checkTopLevelProgram(msg, topLevel, programID); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineIndex, err)
+				}
+			}
+			out = append(out, InstructionCompute{
+				InstructionIndex: topLevel,
+				ProgramID:        programID,
+				Depth:            depth,
+				Incomplete:       true,
+			})
+			stack = append(stack, len(out)-1)
+		case strings.HasPrefix(rest, "consumed "):
+			current, err := currentInvocation(out, stack, programID)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineIndex, err)
+			}
+			var consumed, limit uint64
+			if _, err := fmt.Sscanf(rest, "consumed %d of %d compute units", &consumed, &limit); err != nil {
+				return nil, fmt.Errorf("line %d: invalid consumed compute units: %q", lineIndex, line)
+			}
+			current.UnitsConsumed = consumed
+			current.Incomplete = false
+		case rest == "success" || strings.HasPrefix(rest, "failed"):
+			current, err := currentInvocation(out, stack, programID)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineIndex, err)
+			}
+			// The builtin programs don't log the units consumed.
+			current.Incomplete = false
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if !truncated {
+		if len(stack) > 0 {
+			return nil, fmt.Errorf("logs end with %d unfinished invocations, and are not truncated", len(stack))
+		}
+		return out, nil
+	}
+	// The instructions that were not logged at all.
+	for index := topLevel + 1; index < len(msg.Instructions); index++ {
+		programID, err := msg.Program(msg.Instructions[index].ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", index, err)
+		}
+		out = append(out, InstructionCompute{
+			InstructionIndex: index,
+			ProgramID:        programID,
+			Depth:            1,
+			Incomplete:       true,
+		})
+	}
+	return out, nil
+}
+
+// parseProgramLogLine parses a "Program <program ID> <rest>" line
+// (but not the "Program log: ..." and similar lines).
+func parseProgramLogLine(line string) (programID solana.PublicKey, rest string, ok bool) {
+	if !strings.HasPrefix(line, "Program ") {
+		return
+	}
+	fields := strings.SplitN(strings.TrimPrefix(line, "Program "), " ", 2)
+	if len(fields) != 2 {
+		return
+	}
+	programID, err := solana.PublicKeyFromBase58(fields[0])
+	if err != nil {
+		return
+	}
+	return programID, fields[1], true
+}
+
+func checkTopLevelProgram(msg *solana.Message, index int, programID solana.PublicKey) error {
+	if index >= len(msg.Instructions) {
+		return fmt.Errorf("invocation of top-level instruction %d, but the message has %d instructions", index, len(msg.Instructions))
+	}
+	expected, err := msg.Program(msg.Instructions[index].ProgramIDIndex)
+	if err != nil {
+		return fmt.Errorf("instruction %d: %w", index, err)
+	}
+	if !expected.Equals(programID) {
+		return fmt.Errorf("instruction %d invokes %s, but the logs show %s", index, expected, programID)
+	}
+	return nil
+}
+
+// currentInvocation returns the innermost unfinished invocation, that must be of the program.
+func currentInvocation(out []InstructionCompute, stack []int, programID solana.PublicKey) (*InstructionCompute, error) {
+	if len(stack) == 0 {
+		return nil, fmt.Errorf("program %s is not being invoked", programID)
+	}
+	current := &out[stack[len(stack)-1]]
+	if !current.ProgramID.Equals(programID) {
+		return nil, fmt.Errorf("program %s is not the one being invoked (%s)", programID, current.ProgramID)
+	}
+	return current, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	computeBudgetProgramID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+	ataProgramID           = solana.MustPublicKeyFromBase58("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
+	jupiterProgramID       = solana.MustPublicKeyFromBase58("JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4")
+	whirlpoolProgramID     = solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+)
+
+// loadTransactionFixture loads a getTransaction result (with the json encoding).
+// The fixtures are modeled on mainnet swaps: a route through an aggregator,
+// with nested CPIs.
+func loadTransactionFixture(t *testing.T, name string) (*TransactionMeta, *solana.Message) {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	var out GetTransactionResult
+	require.NoError(t, json.Unmarshal(data, &out))
+	tx, err := out.Transaction.GetTransaction()
+	require.NoError(t, err)
+	return out.Meta, &tx.Message
+}
+
+func TestComputeUnitsByInstruction_nestedCPIs(t *testing.T) {
+	meta, msg := loadTransactionFixture(t, "transaction-logs-nested-cpi.json")
+
+	out, err := ComputeUnitsByInstruction(meta, msg)
+	require.NoError(t, err)
+	assert.Equal(t, []InstructionCompute{
+		// Builtin: the units are not logged.
+		{InstructionIndex: 0, ProgramID: computeBudgetProgramID, Depth: 1},
+		{InstructionIndex: 1, ProgramID: computeBudgetProgramID, Depth: 1},
+		{InstructionIndex: 2, ProgramID: ataProgramID, Depth: 1, UnitsConsumed: 4338},
+		{InstructionIndex: 3, ProgramID: jupiterProgramID, Depth: 1, UnitsConsumed: 65042},
+		{InstructionIndex: 3, ProgramID: whirlpoolProgramID, Depth: 2, UnitsConsumed: 38947},
+		{InstructionIndex: 3, ProgramID: solana.TokenProgramID, Depth: 3, UnitsConsumed: 4645},
+		{InstructionIndex: 3, ProgramID: solana.TokenProgramID, Depth: 3, UnitsConsumed: 4736},
+		{InstructionIndex: 3, ProgramID: jupiterProgramID, Depth: 2, UnitsConsumed: 2134},
+		{InstructionIndex: 4, ProgramID: solana.TokenProgramID, Depth: 1, UnitsConsumed: 2915},
+	}, out)
+
+	// The top-level units add up to the ones of the transaction,
+	// besides the ones of the two compute budget instructions (150 each).
+	var total uint64
+	for _, c := range out {
+		if c.Depth == 1 {
+			total += c.UnitsConsumed
+		}
+	}
+	assert.Equal(t, *meta.ComputeUnitsConsumed-300, total)
+}
+
+func TestComputeUnitsByInstruction_truncated(t *testing.T) {
+	meta, msg := loadTransactionFixture(t, "transaction-logs-truncated.json")
+
+	out, err := ComputeUnitsByInstruction(meta, msg)
+	require.NoError(t, err)
+	assert.Equal(t, []InstructionCompute{
+		{InstructionIndex: 0, ProgramID: computeBudgetProgramID, Depth: 1},
+		// Unfinished when the logs were truncated.
+		{InstructionIndex: 1, ProgramID: jupiterProgramID, Depth: 1, Incomplete: true},
+		{InstructionIndex: 1, ProgramID: whirlpoolProgramID, Depth: 2, Incomplete: true},
+		{InstructionIndex: 1, ProgramID: solana.TokenProgramID, Depth: 3, UnitsConsumed: 4645},
+		{InstructionIndex: 1, ProgramID: solana.TokenProgramID, Depth: 3, Incomplete: true},
+		// Not logged at all.
+		{InstructionIndex: 2, ProgramID: solana.TokenProgramID, Depth: 1, Incomplete: true},
+	}, out)
+}
+
+func TestComputeUnitsByInstruction_failed(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	msg := &solana.Message{
+		AccountKeys: []solana.PublicKey{solana.NewWallet().PublicKey(), program, solana.SystemProgramID},
+		Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 2},
+			{ProgramIDIndex: 1},
+			// Not executed.
+			{ProgramIDIndex: 1},
+		},
+	}
+	meta := &TransactionMeta{
+		LogMessages: []string{
+			"Program 11111111111111111111111111111111 invoke [1]",
+			"Program 11111111111111111111111111111111 success",
+			"Program " + program.String() + " invoke [1]",
+			"Program log: Instruction: Crank",
+			"Program " + program.String() + " consumed 200000 of 200000 compute units",
+			"Program " + program.String() + " failed: exceeded CUs meter at BPF instruction",
+		},
+	}
+	out, err := ComputeUnitsByInstruction(meta, msg)
+	require.NoError(t, err)
+	assert.Equal(t, []InstructionCompute{
+		{InstructionIndex: 0, ProgramID: solana.SystemProgramID, Depth: 1},
+		{InstructionIndex: 1, ProgramID: program, Depth: 1, UnitsConsumed: 200000},
+	}, out)
+}
+
+func TestComputeUnitsByInstruction_errors(t *testing.T) {
+	program := solana.NewWallet().PublicKey()
+	other := solana.NewWallet().PublicKey()
+	msg := &solana.Message{
+		AccountKeys:  []solana.PublicKey{solana.NewWallet().PublicKey(), program},
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1}},
+	}
+	invoke := "Program " + program.String() + " invoke [1]"
+
+	for _, tc := range []struct {
+		name     string
+		logs     []string
+		expected string
+	}{
+		{"no logs", nil, "no log messages (log recording was not enabled for this transaction)"},
+		{"other program", []string{"Program " + other.String() + " invoke [1]"}, "line 0: instruction 0 invokes " + program.String() + ", but the logs show " + other.String()},
+		{"too many instructions", []string{invoke, "Program " + program.String() + " success", invoke}, "line 2: invocation of top-level instruction 1, but the message has 1 instructions"},
+		{"depth", []string{invoke, "Program " + other.String() + " invoke [3]"}, "line 1: unexpected invoke depth 3 (expected 2): \"Program " + other.String() + " invoke [3]\""},
+		{"not invoked", []string{"Program " + program.String() + " success"}, "line 0: program " + program.String() + " is not being invoked"},
+		{"unfinished", []string{invoke}, "logs end with 1 unfinished invocations, and are not truncated"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ComputeUnitsByInstruction(&TransactionMeta{LogMessages: tc.logs}, msg)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package poller watches accounts by polling getMultipleAccounts,
+// and reports only the accounts that changed since the previous poll.
+package poller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	DefaultInterval = time.Second
+	// The max number of accounts in a getMultipleAccounts request.
+	MaxAccountsPerRequest = 100
+)
+
+type Options struct {
+	// How often the accounts are polled. Defaults to DefaultInterval.
+	Interval time.Duration
+	// Number of accounts per getMultipleAccounts request.
+	// Defaults to (and can't be greater than) MaxAccountsPerRequest.
+	ChunkSize  int
+	Commitment rpc.CommitmentType
+	// Called by Run for every poll that failed; the poller keeps running.
+	OnError func(error)
+}
+
+// AccountChange is reported when the data, lamports or owner of an account change
+// (or when the account is seen for the first time, or stops existing).
+type AccountChange struct {
+	Pubkey solana.PublicKey
+	// The slot at which the change was observed.
+	Slot uint64
+	// Nil if the account doesn't exist (anymore).
+	Account *rpc.Account
+}
+
+// AccountState is the metadata of a watched account.
+type AccountState struct {
+	// Whether the account existed at the last poll.
+	Exists bool
+	// The slot of the last poll that included the account; zero if not polled yet.
+	LastPolledSlot uint64
+	// The slot and the time at which the last change was observed;
+	// zero if no change was observed yet.
+	LastUpdatedSlot uint64
+	LastUpdatedAt   time.Time
+}
+
+type watchedAccount struct {
+	hash  [sha256.Size]byte
+	state AccountState
+}
+
+// MultiAccountPoller polls a dynamic set of accounts, and calls the callback
+// only for the accounts whose hash of data, lamports and owner changed.
+// The methods are safe for concurrent use.
+type MultiAccountPoller struct {
+	client   *rpc.Client
+	opts     Options
+	onChange func(AccountChange)
+
+	// Serializes the polls, so that the callback is never called concurrently.
+	pollMu sync.Mutex
+
+	mu      sync.Mutex
+	watched map[solana.PublicKey]*watchedAccount
+}
+
+// NewMultiAccountPoller creates a poller that calls `onChange` (never concurrently)
+// for each account that changed; opts can be nil.
+func NewMultiAccountPoller(client *rpc.Client, opts *Options, onChange func(AccountChange)) *MultiAccountPoller {
+	p := &MultiAccountPoller{
+		client:   client,
+		onChange: onChange,
+		watched:  make(map[solana.PublicKey]*watchedAccount),
+	}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Interval <= 0 {
+		p.opts.Interval = DefaultInterval
+	}
+	if p.opts.ChunkSize <= 0 || p.opts.ChunkSize > MaxAccountsPerRequest {
+		p.opts.ChunkSize = MaxAccountsPerRequest
+	}
+	return p
+}
+
+// Add starts watching the provided accounts; the accounts that are
+// already watched are left untouched.
+func (p *MultiAccountPoller) Add(keys ...solana.PublicKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := p.watched[key]; !ok {
+			p.watched[key] = &watchedAccount{}
+		}
+	}
+}
+
+// Remove stops watching the provided accounts, and forgets their state.
+func (p *MultiAccountPoller) Remove(keys ...solana.PublicKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range keys {
+		delete(p.watched, key)
+	}
+}
+
+// Keys returns the watched accounts, sorted.
+func (p *MultiAccountPoller) Keys() []solana.PublicKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := make([]solana.PublicKey, 0, len(p.watched))
+	for key := range p.watched {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0
+	})
+	return keys
+}
+
+// State returns the metadata of a watched account.
+func (p *MultiAccountPoller) State(key solana.PublicKey) (AccountState, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	watched, ok := p.watched[key]
+	if !ok {
+		return AccountState{}, false
+	}
+	return watched.state, true
+}
+
+// Run polls the accounts every Interval until the context is done.
+func (p *MultiAccountPoller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Poll(ctx); err != nil && p.opts.OnError != nil && ctx.Err() == nil {
+			p.opts.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches all the watched accounts once (in chunks of ChunkSize),
+// and calls the callback for the ones that changed.
+// If a chunk fails, the other chunks are still processed,
+// and the first error is returned.
+func (p *MultiAccountPoller) Poll(ctx context.Context) error {
+	p.pollMu.Lock()
+	defer p.pollMu.Unlock()
+
+	keys := p.Keys()
+	var firstErr error
+	for start := 0; start < len(keys); start += p.opts.ChunkSize {
+		end := start + p.opts.ChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := p.pollChunk(ctx, keys[start:end]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (p *MultiAccountPoller) pollChunk(ctx context.Context, keys []solana.PublicKey) error {
+	out, err := p.client.GetMultipleAccountsWithOpts(
+		ctx,
+		keys,
+		&rpc.GetMultipleAccountsOpts{
+			Encoding:   solana.EncodingBase64,
+			Commitment: p.opts.Commitment,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("unable to get accounts: %w", err)
+	}
+	if len(out.Value) != len(keys) {
+		return fmt.Errorf("requested %d accounts, got %d", len(keys), len(out.Value))
+	}
+	slot := out.Context.Slot
+	now := time.Now()
+
+	var changes []AccountChange
+	p.mu.Lock()
+	for i, key := range keys {
+		watched, ok := p.watched[key]
+		if !ok {
+			// Removed while polling.
+			continue
+		}
+		if slot < watched.state.LastPolledSlot {
+			// Older than what we already have (e.g. a lagging node behind a load balancer).
+			continue
+		}
+		account := out.Value[i]
+		wasPolled := watched.state.LastPolledSlot != 0
+		watched.state.LastPolledSlot = slot
+
+		if account == nil {
+			if watched.state.Exists {
+				watched.state.Exists = false
+				watched.hash = [sha256.Size]byte{}
+				watched.state.LastUpdatedSlot = slot
+				watched.state.LastUpdatedAt = now
+				changes = append(changes, AccountChange{Pubkey: key, Slot: slot})
+			}
+			continue
+		}
+		hash := hashAccount(account)
+		if wasPolled && watched.state.Exists && hash == watched.hash {
+			continue
+		}
+		watched.hash = hash
+		watched.state.Exists = true
+		watched.state.LastUpdatedSlot = slot
+		watched.state.LastUpdatedAt = now
+		changes = append(changes, AccountChange{Pubkey: key, Slot: slot, Account: account})
+	}
+	p.mu.Unlock()
+
+	if p.onChange != nil {
+		for _, change := range changes {
+			p.onChange(change)
+		}
+	}
+	return nil
+}
+
+func hashAccount(account *rpc.Account) [sha256.Size]byte {
+	hasher := sha256.New()
+	var lamports [8]byte
+	binary.LittleEndian.PutUint64(lamports[:], account.Lamports)
+	hasher.Write(lamports[:])
+	hasher.Write(account.Owner[:])
+	if account.Data != nil {
+		hasher.Write(account.Data.GetBinary())
+	}
+	var out [sha256.Size]byte
+	copy(out[:], hasher.Sum(nil))
+	return out
+}
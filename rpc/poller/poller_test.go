@@ -0,0 +1,260 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package poller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAccount struct {
+	lamports uint64
+	owner    solana.PublicKey
+	data     []byte
+}
+
+// mockCluster serves getMultipleAccounts from a map of accounts
+// that the tests change between polls.
+type mockCluster struct {
+	mu       sync.Mutex
+	slot     uint64
+	accounts map[solana.PublicKey]*mockAccount
+	requests [][]string
+}
+
+func (m *mockCluster) set(key solana.PublicKey, account *mockAccount) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if account == nil {
+		delete(m.accounts, key)
+		return
+	}
+	m.accounts[key] = account
+}
+
+func (m *mockCluster) setSlot(slot uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slot = slot
+}
+
+func (m *mockCluster) takeRequests() [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := m.requests
+	m.requests = nil
+	return out
+}
+
+func (m *mockCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params []interface{}   `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getMultipleAccounts" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []string
+	var values []string
+	for _, key := range req.Params[0].([]interface{}) {
+		keys = append(keys, key.(string))
+		account, ok := m.accounts[solana.MustPublicKeyFromBase58(key.(string))]
+		if !ok {
+			values = append(values, "null")
+			continue
+		}
+		values = append(values, fmt.Sprintf(
+			`{"data":[%q,"base64"],"executable":false,"lamports":%d,"owner":%q,"rentEpoch":200}`,
+			base64.StdEncoding.EncodeToString(account.data),
+			account.lamports,
+			account.owner,
+		))
+	}
+	m.requests = append(m.requests, keys)
+	fmt.Fprintf(w,
+		`{"jsonrpc":"2.0","id":%s,"result":{"context":{"slot":%d},"value":[%s]}}`,
+		req.ID,
+		m.slot,
+		strings.Join(values, ","),
+	)
+}
+
+func TestMultiAccountPoller(t *testing.T) {
+	a := solana.NewWallet().PublicKey()
+	b := solana.NewWallet().PublicKey()
+	c := solana.NewWallet().PublicKey()
+	d := solana.NewWallet().PublicKey()
+
+	cluster := &mockCluster{
+		slot: 100,
+		accounts: map[solana.PublicKey]*mockAccount{
+			a: {lamports: 1, owner: solana.TokenProgramID, data: []byte{1}},
+			b: {lamports: 2, owner: solana.TokenProgramID, data: []byte{2}},
+			d: {lamports: 4, owner: solana.SystemProgramID},
+		},
+	}
+	server := httptest.NewServer(cluster)
+	defer server.Close()
+
+	var changes []AccountChange
+	poller := NewMultiAccountPoller(
+		rpc.New(server.URL),
+		&Options{ChunkSize: 2},
+		func(change AccountChange) { changes = append(changes, change) },
+	)
+	poller.Add(a, b, c)
+	poller.Add(a)
+	require.Len(t, poller.Keys(), 3)
+
+	changedKeys := func() []solana.PublicKey {
+		var keys []solana.PublicKey
+		for _, change := range changes {
+			keys = append(keys, change.Pubkey)
+		}
+		changes = nil
+		return keys
+	}
+	ctx := context.Background()
+
+	// First poll: all the existing accounts are reported, in chunks.
+	require.NoError(t, poller.Poll(ctx))
+	assert.ElementsMatch(t, []solana.PublicKey{a, b}, changedKeys())
+	requests := cluster.takeRequests()
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[0], 2)
+	assert.Len(t, requests[1], 1)
+
+	state, ok := poller.State(a)
+	require.True(t, ok)
+	assert.True(t, state.Exists)
+	assert.Equal(t, uint64(100), state.LastPolledSlot)
+	assert.Equal(t, uint64(100), state.LastUpdatedSlot)
+	state, ok = poller.State(c)
+	require.True(t, ok)
+	assert.False(t, state.Exists)
+	assert.Equal(t, uint64(0), state.LastUpdatedSlot)
+
+	// Nothing changed.
+	cluster.setSlot(101)
+	require.NoError(t, poller.Poll(ctx))
+	assert.Empty(t, changedKeys())
+	state, _ = poller.State(a)
+	assert.Equal(t, uint64(101), state.LastPolledSlot)
+	assert.Equal(t, uint64(100), state.LastUpdatedSlot)
+
+	// Data of a, lamports of b, and c is created.
+	cluster.setSlot(102)
+	cluster.set(a, &mockAccount{lamports: 1, owner: solana.TokenProgramID, data: []byte{9}})
+	cluster.set(b, &mockAccount{lamports: 3, owner: solana.TokenProgramID, data: []byte{2}})
+	cluster.set(c, &mockAccount{lamports: 5, owner: solana.SystemProgramID})
+	require.NoError(t, poller.Poll(ctx))
+	require.Len(t, changes, 3)
+	for _, change := range changes {
+		assert.Equal(t, uint64(102), change.Slot)
+		require.NotNil(t, change.Account)
+		if change.Pubkey.Equals(a) {
+			assert.Equal(t, []byte{9}, change.Account.Data.GetBinary())
+		}
+	}
+	changedKeys()
+
+	// An older response (e.g. from a lagging node) is ignored.
+	cluster.setSlot(90)
+	cluster.set(a, &mockAccount{lamports: 1, owner: solana.TokenProgramID, data: []byte{1}})
+	require.NoError(t, poller.Poll(ctx))
+	assert.Empty(t, changedKeys())
+
+	// Dynamic add/remove; a is deleted.
+	cluster.setSlot(103)
+	cluster.set(a, nil)
+	poller.Remove(b)
+	poller.Add(d)
+	require.NoError(t, poller.Poll(ctx))
+	require.Len(t, changes, 2)
+	for _, change := range changes {
+		if change.Pubkey.Equals(a) {
+			assert.Nil(t, change.Account)
+		} else {
+			assert.Equal(t, d, change.Pubkey)
+			assert.Equal(t, uint64(4), change.Account.Lamports)
+		}
+	}
+	changedKeys()
+	_, ok = poller.State(b)
+	assert.False(t, ok)
+	state, _ = poller.State(a)
+	assert.False(t, state.Exists)
+	assert.Equal(t, uint64(103), state.LastUpdatedSlot)
+
+	// Still deleted: no change.
+	cluster.setSlot(104)
+	require.NoError(t, poller.Poll(ctx))
+	assert.Empty(t, changedKeys())
+}
+
+func TestMultiAccountPoller_Run(t *testing.T) {
+	key := solana.NewWallet().PublicKey()
+	cluster := &mockCluster{
+		slot: 1,
+		accounts: map[solana.PublicKey]*mockAccount{
+			key: {lamports: 1, owner: solana.SystemProgramID},
+		},
+	}
+	server := httptest.NewServer(cluster)
+	defer server.Close()
+
+	changes := make(chan AccountChange, 10)
+	poller := NewMultiAccountPoller(
+		rpc.New(server.URL),
+		&Options{Interval: 10 * time.Millisecond},
+		func(change AccountChange) { changes <- change },
+	)
+	poller.Add(key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- poller.Run(ctx) }()
+
+	change := <-changes
+	assert.Equal(t, uint64(1), change.Slot)
+
+	cluster.setSlot(2)
+	cluster.set(key, &mockAccount{lamports: 2, owner: solana.SystemProgramID})
+	change = <-changes
+	assert.Equal(t, uint64(2), change.Slot)
+	assert.Equal(t, uint64(2), change.Account.Lamports)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Len(t, changes, 0)
+}
@@ -0,0 +1,325 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// MaxSignatureStatusesBatchSize is the max number of signatures
+// of a getSignatureStatuses request.
+const MaxSignatureStatusesBatchSize = 256
+
+// DefaultResolveSignaturesConcurrency is the default number of
+// getTransaction requests made at the same time by ResolveSignaturesWithOpts.
+const DefaultResolveSignaturesConcurrency = 4
+
+// SigInfo is where and when a transaction landed.
+type SigInfo struct {
+	// The slot the transaction was processed in.
+	Slot uint64
+
+	// Estimated production time of the block; nil if not available.
+	BlockTime *solana.UnixTimeSeconds
+
+	// Error if the transaction failed, nil if the transaction succeeded.
+	Err interface{}
+
+	// True if the slot is finalized, i.e. the info can't change anymore.
+	Finalized bool
+}
+
+// SigInfoStore caches the info of the resolved signatures.
+// Only finalized infos are saved, since they never change.
+type SigInfoStore interface {
+	// Load returns the stored info of the signature, or nil if there is none.
+	Load(ctx context.Context, sig solana.Signature) (*SigInfo, error)
+	Save(ctx context.Context, sig solana.Signature, info SigInfo) error
+}
+
+// MemorySigInfoStore is a SigInfoStore that keeps the infos in memory.
+type MemorySigInfoStore struct {
+	mu    sync.Mutex
+	infos map[solana.Signature]SigInfo
+}
+
+func NewMemorySigInfoStore() *MemorySigInfoStore {
+	return &MemorySigInfoStore{
+		infos: make(map[solana.Signature]SigInfo),
+	}
+}
+
+func (s *MemorySigInfoStore) Load(ctx context.Context, sig solana.Signature) (*SigInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.infos[sig]
+	if !ok {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func (s *MemorySigInfoStore) Save(ctx context.Context, sig solana.Signature, info SigInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.infos[sig] = info
+	return nil
+}
+
+// UnresolvedSignaturesError is returned (together with the resolved infos)
+// when some signatures could not be resolved, e.g. because the node
+// doesn't know them or doesn't have their transactions anymore.
+type UnresolvedSignaturesError struct {
+	Signatures []solana.Signature
+}
+
+func (e *UnresolvedSignaturesError) Error() string {
+	return fmt.Sprintf("unable to resolve %d signatures", len(e.Signatures))
+}
+
+type ResolveSignaturesOpts struct {
+	// If set, the infos are loaded from (and the finalized ones saved to) the store.
+	Store SigInfoStore
+
+	// Max number of getTransaction requests made at the same time.
+	// Defaults to DefaultResolveSignaturesConcurrency.
+	Concurrency int
+}
+
+// ResolveSignatures returns the slot and block time of the transactions
+// with the provided signatures, without fetching the transactions when possible.
+// See ResolveSignaturesWithOpts.
+func (cl *Client) ResolveSignatures(
+	ctx context.Context,
+	sigs []solana.Signature,
+) (map[solana.Signature]SigInfo, error) {
+	return cl.ResolveSignaturesWithOpts(ctx, sigs, nil)
+}
+
+// ResolveSignaturesWithOpts returns the slot and block time of the transactions
+// with the provided signatures.
+//
+// The signatures are first looked up in the store (if any), then with getSignatureStatuses
+// (in batches of MaxSignatureStatusesBatchSize; this only knows recent transactions)
+// and getBlockTime (once per slot); the remaining ones are fetched with getTransaction.
+//
+// If some signatures can't be resolved, the infos of the others are returned
+// together with an *UnresolvedSignaturesError listing them.
+// On any other error (e.g. the context is done, or the store fails),
+// the infos resolved so far are returned with the error.
+func (cl *Client) ResolveSignaturesWithOpts(
+	ctx context.Context,
+	sigs []solana.Signature,
+	opts *ResolveSignaturesOpts,
+) (map[solana.Signature]SigInfo, error) {
+	var store SigInfoStore
+	concurrency := DefaultResolveSignaturesConcurrency
+	if opts != nil {
+		store = opts.Store
+		if opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+	}
+
+	out := make(map[solana.Signature]SigInfo, len(sigs))
+	seen := make(map[solana.Signature]struct{}, len(sigs))
+	var pending []solana.Signature
+	for _, sig := range sigs {
+		if _, ok := seen[sig]; ok {
+			continue
+		}
+		seen[sig] = struct{}{}
+		if store != nil {
+			info, err := store.Load(ctx, sig)
+			if err != nil {
+				return out, fmt.Errorf("unable to load info of %s: %w", sig, err)
+			}
+			if info != nil {
+				out[sig] = *info
+				continue
+			}
+		}
+		pending = append(pending, sig)
+	}
+
+	misses, err := cl.resolveFromStatuses(ctx, pending, out)
+	if err != nil {
+		return out, err
+	}
+	unresolved, err := cl.resolveFromTransactions(ctx, misses, out, concurrency)
+	if err != nil {
+		return out, err
+	}
+
+	if store != nil {
+		for _, sig := range pending {
+			if info, ok := out[sig]; ok && info.Finalized {
+				if err := store.Save(ctx, sig, info); err != nil {
+					return out, fmt.Errorf("unable to save info of %s: %w", sig, err)
+				}
+			}
+		}
+	}
+
+	if len(unresolved) > 0 {
+		return out, &UnresolvedSignaturesError{Signatures: unresolved}
+	}
+	return out, nil
+}
+
+// resolveFromStatuses adds to `out` the infos of the signatures known
+// by getSignatureStatuses, and returns the other ones.
+func (cl *Client) resolveFromStatuses(
+	ctx context.Context,
+	sigs []solana.Signature,
+	out map[solana.Signature]SigInfo,
+) (misses []solana.Signature, err error) {
+	found := make(map[solana.Signature]SigInfo)
+	for start := 0; start < len(sigs); start += MaxSignatureStatusesBatchSize {
+		end := start + MaxSignatureStatusesBatchSize
+		if end > len(sigs) {
+			end = len(sigs)
+		}
+		batch := sigs[start:end]
+
+		res, err := cl.GetSignatureStatuses(ctx, false, batch...)
+		if err != nil || len(res.Value) != len(batch) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// Try with getTransaction.
+			misses = append(misses, batch...)
+			continue
+		}
+		for i, status := range res.Value {
+			if status == nil {
+				misses = append(misses, batch[i])
+				continue
+			}
+			found[batch[i]] = SigInfo{
+				Slot:      status.Slot,
+				Err:       status.Err,
+				Finalized: status.ConfirmationStatus == ConfirmationStatusFinalized,
+			}
+		}
+	}
+
+	// The statuses don't include the block time.
+	blockTimes := make(map[uint64]*solana.UnixTimeSeconds)
+	failedSlots := make(map[uint64]bool)
+	for _, sig := range sigs {
+		info, ok := found[sig]
+		if !ok {
+			continue
+		}
+		if _, ok := blockTimes[info.Slot]; !ok && !failedSlots[info.Slot] {
+			blockTime, err := cl.GetBlockTime(ctx, info.Slot)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				failedSlots[info.Slot] = true
+			} else {
+				blockTimes[info.Slot] = blockTime
+			}
+		}
+		if failedSlots[info.Slot] {
+			misses = append(misses, sig)
+			continue
+		}
+		info.BlockTime = blockTimes[info.Slot]
+		out[sig] = info
+	}
+	return misses, nil
+}
+
+// resolveFromTransactions adds to `out` the infos of the signatures
+// whose transactions are returned by getTransaction, and returns the other ones.
+func (cl *Client) resolveFromTransactions(
+	ctx context.Context,
+	sigs []solana.Signature,
+	out map[solana.Signature]SigInfo,
+	concurrency int,
+) (unresolved []solana.Signature, err error) {
+	infos := make([]*SigInfo, len(sigs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range sigs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			// Errors are reported as unresolved signatures.
+			infos[i], _ = cl.getSigInfo(ctx, sigs[i])
+		}(i)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	for i, info := range infos {
+		if info == nil {
+			unresolved = append(unresolved, sigs[i])
+			continue
+		}
+		out[sigs[i]] = *info
+	}
+	return unresolved, nil
+}
+
+// getSigInfo returns the info of the (finalized) transaction,
+// or nil if the node doesn't have it.
+func (cl *Client) getSigInfo(ctx context.Context, sig solana.Signature) (*SigInfo, error) {
+	// Only the slot, the block time and the error are decoded;
+	// base64 is the cheapest encoding for the (skipped) transaction.
+	var res *struct {
+		Slot      uint64                  `json:"slot"`
+		BlockTime *solana.UnixTimeSeconds `json:"blockTime"`
+		Meta      *struct {
+			Err interface{} `json:"err"`
+		} `json:"meta"`
+	}
+	err := cl.rpcClient.CallForInto(ctx, &res, "getTransaction", []interface{}{
+		sig,
+		M{
+			"encoding":                       solana.EncodingBase64,
+			"commitment":                     CommitmentFinalized,
+			"maxSupportedTransactionVersion": 0,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, nil
+	}
+	info := &SigInfo{
+		Slot:      res.Slot,
+		BlockTime: res.BlockTime,
+		Finalized: true,
+	}
+	if res.Meta != nil {
+		info.Err = res.Meta.Err
+	}
+	return info, nil
+}
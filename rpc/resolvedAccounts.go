@@ -0,0 +1,146 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// ResolvedAccount is an account of a transaction: one of the account keys
+// of its message, or an address loaded from an address lookup table.
+type ResolvedAccount struct {
+	PublicKey solana.PublicKey
+	Signer    bool
+	Writable  bool
+	// ParsedAccountSourceTransaction or ParsedAccountSourceLookupTable.
+	Source ParsedAccountSource
+}
+
+// Meta returns the account as an AccountMeta.
+func (acc ResolvedAccount) Meta() *solana.AccountMeta {
+	return solana.NewAccountMeta(acc.PublicKey, acc.Writable, acc.Signer)
+}
+
+// ResolvedAccounts returns all the accounts of the transaction, in the order
+// the account indexes of its (inner) instructions and the balances of its metadata
+// refer to: the account keys of the message, followed by the writable and then
+// readonly addresses loaded from address lookup tables (listed in the metadata).
+func (twm *TransactionWithMeta) ResolvedAccounts() ([]ResolvedAccount, error) {
+	tx, err := twm.GetResolvedTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return resolvedAccounts(tx), nil
+}
+
+// ResolvedAccounts returns all the accounts of the transaction;
+// see TransactionWithMeta.ResolvedAccounts.
+func (res *GetTransactionResult) ResolvedAccounts() ([]ResolvedAccount, error) {
+	tx, err := res.GetResolvedTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return resolvedAccounts(tx), nil
+}
+
+// resolvedAccounts returns the accounts of the transaction,
+// whose lookups must have been resolved (see resolveLoadedAddresses).
+func resolvedAccounts(tx *solana.Transaction) []ResolvedAccount {
+	message := &tx.Message
+	numStatic := len(message.AccountKeys)
+	if message.IsVersioned() {
+		numStatic -= message.NumLookups()
+	}
+	out := make([]ResolvedAccount, len(message.AccountKeys))
+	for i, key := range message.AccountKeys {
+		out[i] = ResolvedAccount{
+			PublicKey: key,
+			Signer:    i < int(message.Header.NumRequiredSignatures),
+			Writable:  message.IsWritableIndex(i),
+			Source:    ParsedAccountSourceTransaction,
+		}
+		if i >= numStatic {
+			out[i].Source = ParsedAccountSourceLookupTable
+		}
+	}
+	return out
+}
+
+// accountMetas returns the accounts at the indexes.
+func accountMetas(accounts []ResolvedAccount, indexes []uint16) ([]*solana.AccountMeta, error) {
+	out := make([]*solana.AccountMeta, len(indexes))
+	for i, index := range indexes {
+		if int(index) >= len(accounts) {
+			return nil, fmt.Errorf("account index %d out of range (%d accounts)", index, len(accounts))
+		}
+		out[i] = accounts[index].Meta()
+	}
+	return out, nil
+}
+
+// BalanceChange is the change of the balance (in lamports) of an account of a transaction.
+type BalanceChange struct {
+	Account ResolvedAccount
+	Pre     uint64
+	Post    uint64
+}
+
+// Delta returns the difference between the balances after and before the transaction.
+func (change BalanceChange) Delta() int64 {
+	return int64(change.Post - change.Pre)
+}
+
+// BalanceChanges returns the balances of all the accounts of the transaction
+// (see ResolvedAccounts), before and after it, from its metadata.
+func (twm *TransactionWithMeta) BalanceChanges() ([]BalanceChange, error) {
+	accounts, err := twm.ResolvedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	return balanceChanges(accounts, twm.Meta)
+}
+
+// BalanceChanges returns the balances of all the accounts of the transaction;
+// see TransactionWithMeta.BalanceChanges.
+func (res *GetTransactionResult) BalanceChanges() ([]BalanceChange, error) {
+	accounts, err := res.ResolvedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	return balanceChanges(accounts, res.Meta)
+}
+
+func balanceChanges(accounts []ResolvedAccount, meta *TransactionMeta) ([]BalanceChange, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("transaction has no metadata")
+	}
+	if len(meta.PreBalances) != len(accounts) || len(meta.PostBalances) != len(accounts) {
+		return nil, fmt.Errorf(
+			"%d pre and %d post balances don't match the %d accounts of the transaction",
+			len(meta.PreBalances), len(meta.PostBalances), len(accounts),
+		)
+	}
+	out := make([]BalanceChange, len(accounts))
+	for i, account := range accounts {
+		out[i] = BalanceChange{
+			Account: account,
+			Pre:     meta.PreBalances[i],
+			Post:    meta.PostBalances[i],
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lookupsFixture struct {
+	json         string
+	payer        solana.PublicKey
+	program      solana.PublicKey
+	recipient    solana.PublicKey
+	numStatic    int
+	writable     solana.PublicKeySlice
+	readonly     solana.PublicKeySlice
+	swapAccounts []*solana.AccountMeta
+	numLoaded    int
+	preBalance   uint64
+}
+
+// newLookupsFixture returns a getTransaction result of a v0 transaction shaped
+// like a DEX aggregator swap: a transfer to an account of an address lookup table,
+// and an instruction with 8 writable and 16 readonly accounts of the table,
+// so that 24 of its accounts are loaded from the table.
+//
+// The transaction is generated (it is not a mainnet capture), but the result
+// has all the fields of the response of a node, with its sorted keys.
+func newLookupsFixture(t *testing.T) *lookupsFixture {
+	payer := solana.NewWallet()
+	table := make(solana.PublicKeySlice, 32)
+	for i := range table {
+		table[i] = solana.NewWallet().PublicKey()
+	}
+	f := &lookupsFixture{
+		payer:      payer.PublicKey(),
+		program:    solana.NewWallet().PublicKey(),
+		recipient:  table[5],
+		preBalance: 1_000_000_000,
+	}
+	f.swapAccounts = []*solana.AccountMeta{solana.Meta(f.payer).WRITE().SIGNER()}
+	for i := 0; i < 24; i++ {
+		meta := solana.Meta(table[i])
+		if i < 8 {
+			meta.WRITE()
+		}
+		f.swapAccounts = append(f.swapAccounts, meta)
+	}
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1000, f.payer, f.recipient).Build(),
+			solana.NewInstruction(f.program, f.swapAccounts, []byte{1, 2, 3}),
+		},
+		solana.Hash{1, 2, 3},
+		solana.TransactionPayer(f.payer),
+		solana.TransactionAddressTables(map[solana.PublicKey]solana.PublicKeySlice{
+			solana.NewWallet().PublicKey(): table,
+		}),
+	)
+	require.NoError(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		return &payer.PrivateKey
+	})
+	require.NoError(t, err)
+
+	// What the node returns in the metadata.
+	loaded, err := tx.Message.GetAddressTableLookupAccounts()
+	require.NoError(t, err)
+	numWritable := tx.Message.NumWritableLookups()
+	f.writable, f.readonly = loaded[:numWritable], loaded[numWritable:]
+	f.numStatic = len(tx.Message.AccountKeys)
+	f.numLoaded = len(loaded)
+	loadedJSON, err := stdjson.Marshal(LoadedAddresses{Writable: f.writable, ReadOnly: f.readonly})
+	require.NoError(t, err)
+
+	// The payer pays the fee and the transfer; the recipient receives it.
+	pre := make([]uint64, f.numStatic+f.numLoaded)
+	post := make([]uint64, len(pre))
+	pre[0], post[0] = f.preBalance, f.preBalance-5000-1000
+	recipientIndex := f.numStatic + indexOf(f.writable, f.recipient)
+	pre[recipientIndex], post[recipientIndex] = 2000, 3000
+	preJSON, err := stdjson.Marshal(pre)
+	require.NoError(t, err)
+	postJSON, err := stdjson.Marshal(post)
+	require.NoError(t, err)
+
+	f.json = fmt.Sprintf(
+		`{"blockTime":1700000000,"meta":{"computeUnitsConsumed":2850,"err":null,"fee":5000,"innerInstructions":[],"loadedAddresses":%s,"logMessages":[],"postBalances":%s,"postTokenBalances":[],"preBalances":%s,"preTokenBalances":[],"rewards":[],"status":{"Ok":null}},"slot":250000000,"transaction":[%q,"base64"],"version":0}`,
+		loadedJSON, postJSON, preJSON, tx.MustToBase64(),
+	)
+	return f
+}
+
+func indexOf(keys solana.PublicKeySlice, key solana.PublicKey) int {
+	for i, k := range keys {
+		if k.Equals(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestGetTransactionResult_ResolvedAccounts(t *testing.T) {
+	f := newLookupsFixture(t)
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(f.json)))
+	defer closer()
+	out, err := New(server.URL).GetTransaction(context.Background(), solana.Signature{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 24, f.numLoaded)
+	require.Len(t, f.writable, 8)
+
+	accounts, err := out.ResolvedAccounts()
+	require.NoError(t, err)
+	require.Len(t, accounts, f.numStatic+f.numLoaded)
+	assert.Equal(t, ResolvedAccount{
+		PublicKey: f.payer,
+		Signer:    true,
+		Writable:  true,
+		Source:    ParsedAccountSourceTransaction,
+	}, accounts[0])
+	for i, account := range accounts[1:f.numStatic] {
+		assert.False(t, account.Signer, i)
+		assert.Equal(t, ParsedAccountSourceTransaction, account.Source, i)
+	}
+	// The static keys, then the loaded writable and readonly addresses.
+	for i, key := range append(append(solana.PublicKeySlice{}, f.writable...), f.readonly...) {
+		account := accounts[f.numStatic+i]
+		assert.Equal(t, ResolvedAccount{
+			PublicKey: key,
+			Writable:  i < len(f.writable),
+			Source:    ParsedAccountSourceLookupTable,
+		}, account, i)
+	}
+
+	changes, err := out.BalanceChanges()
+	require.NoError(t, err)
+	require.Len(t, changes, len(accounts))
+	assert.Equal(t, int64(-6000), changes[0].Delta())
+	assert.Equal(t, f.payer, changes[0].Account.PublicKey)
+	recipient := changes[f.numStatic+indexOf(f.writable, f.recipient)]
+	assert.Equal(t, f.recipient, recipient.Account.PublicKey)
+	assert.Equal(t, BalanceChange{Account: recipient.Account, Pre: 2000, Post: 3000}, recipient)
+	assert.Equal(t, int64(1000), recipient.Delta())
+
+	// The instructions refer to the loaded accounts.
+	_, instructions, err := out.DecodeInstructions()
+	require.NoError(t, err)
+	require.Len(t, instructions, 2)
+	transfer, ok := instructions[0].Decoded.(*system.Instruction).Impl.(*system.Transfer)
+	require.True(t, ok)
+	assert.Equal(t, solana.Meta(f.recipient).WRITE(), transfer.GetRecipientAccount())
+	assert.Equal(t, f.program, instructions[1].ProgramID)
+	assert.Equal(t, f.swapAccounts, instructions[1].Accounts)
+
+	t.Run("balances mismatch", func(t *testing.T) {
+		res := *out
+		meta := *out.Meta
+		meta.PostBalances = meta.PostBalances[:f.numStatic]
+		res.Meta = &meta
+		_, err := res.BalanceChanges()
+		assert.EqualError(t, err, fmt.Sprintf(
+			"%d pre and %d post balances don't match the %d accounts of the transaction",
+			f.numStatic+f.numLoaded, f.numStatic, f.numStatic+f.numLoaded,
+		))
+	})
+}
+
+func TestTransactionWithMeta_ResolvedAccounts(t *testing.T) {
+	f := newLookupsFixture(t)
+	var twm TransactionWithMeta
+	require.NoError(t, stdjson.Unmarshal([]byte(f.json), &twm))
+
+	accounts, err := twm.ResolvedAccounts()
+	require.NoError(t, err)
+	require.Len(t, accounts, f.numStatic+f.numLoaded)
+	assert.Equal(t, f.readonly[len(f.readonly)-1], accounts[len(accounts)-1].PublicKey)
+
+	changes, err := twm.BalanceChanges()
+	require.NoError(t, err)
+	assert.Equal(t, int64(-6000), changes[0].Delta())
+
+	twm.Meta = nil
+	_, err = twm.ResolvedAccounts()
+	assert.EqualError(t, err, "transaction uses address lookup tables, but has no metadata")
+}
@@ -64,3 +64,21 @@ func (s *mockJSONRPCServer) RequestBody(t *testing.T) (out map[string]interface{
 
 	return out
 }
+
+// mockJSONRPCFunc starts a server that responds to each request with
+// the JSON-RPC response body (`"result":...` or `"error":...`) returned by `handler`.
+// The handler may be called concurrently.
+func mockJSONRPCFunc(t *testing.T, handler func(method string, params []interface{}) string) (mock *httptest.Server, close func()) {
+	mock = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var request struct {
+			ID     stdjson.RawMessage `json:"id"`
+			Method string             `json:"method"`
+			Params []interface{}      `json:"params"`
+		}
+		err := stdjson.NewDecoder(req.Body).Decode(&request)
+		require.NoError(t, err)
+
+		rw.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,` + handler(request.Method, request.Params) + `}`))
+	}))
+	return mock, func() { mock.Close() }
+}
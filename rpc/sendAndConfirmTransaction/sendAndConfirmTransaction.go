@@ -79,6 +79,21 @@ func SendAndConfirmTransactionWithOpts(
 	opts rpc.TransactionOpts,
 	timeout *time.Duration,
 ) (sig solana.Signature, err error) {
+	if transaction.LastValidBlockHeight != 0 {
+		// Don't send a transaction that can't land anymore.
+		blockHeight, err := rpcClient.GetBlockHeight(ctx, opts.PreflightCommitment)
+		if err != nil {
+			return sig, fmt.Errorf("unable to get block height: %w", err)
+		}
+		if transaction.IsExpired(blockHeight) {
+			return sig, fmt.Errorf(
+				"%w: last valid block height is %d, current block height is %d",
+				solana.ErrBlockhashExpired,
+				transaction.LastValidBlockHeight,
+				blockHeight,
+			)
+		}
+	}
 	sig, err = rpcClient.SendTransactionWithOpts(
 		ctx,
 		transaction,
@@ -87,15 +102,65 @@ func SendAndConfirmTransactionWithOpts(
 	if err != nil {
 		return sig, err
 	}
-	_, err = WaitForConfirmation(
+	_, err = WaitForConfirmationWithOpts(
 		ctx,
+		rpcClient,
 		wsClient,
 		sig,
-		timeout,
+		&WaitForConfirmationOpts{
+			Timeout:              timeout,
+			LastValidBlockHeight: transaction.LastValidBlockHeight,
+		},
 	)
 	return sig, err
 }
 
+// RefreshBlockhash sets a new recent blockhash (and its last valid block height)
+// on the transaction, and signs it again with the provided signers,
+// which must include all the required signers of the transaction.
+func RefreshBlockhash(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	transaction *solana.Transaction,
+	signers ...solana.PrivateKey,
+) error {
+	recent, err := rpcClient.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return fmt.Errorf("unable to get latest blockhash: %w", err)
+	}
+	transaction.SetRecentBlockhash(recent.Value.Blockhash, recent.Value.LastValidBlockHeight)
+	_, err = transaction.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		for i := range signers {
+			if signers[i].PublicKey().Equals(key) {
+				return &signers[i]
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// DefaultBlockHeightCheckInterval is how often WaitForConfirmationWithOpts
+// checks the block height when a LastValidBlockHeight is set.
+const DefaultBlockHeightCheckInterval = 2 * time.Second
+
+type WaitForConfirmationOpts struct {
+	// Defaults to 2 minutes.
+	Timeout *time.Duration
+
+	// If not zero, the last block height at which the transaction can land
+	// (see solana.Transaction.LastValidBlockHeight). Once the finalized block height
+	// is greater than it and the transaction still has no status,
+	// the wait stops with an error wrapping solana.ErrBlockhashExpired:
+	// the transaction was dropped and can't land anymore, so it is safe to
+	// send it again with a new blockhash.
+	LastValidBlockHeight uint64
+
+	// How often the block height is checked.
+	// Defaults to DefaultBlockHeightCheckInterval.
+	BlockHeightCheckInterval time.Duration
+}
+
 // WaitForConfirmation waits for a transaction to be confirmed.
 // If the transaction was confirmed, but it failed while executing (one of the instructions failed),
 // then this function will return an error (true, error).
@@ -106,6 +171,34 @@ func WaitForConfirmation(
 	sig solana.Signature,
 	timeout *time.Duration,
 ) (confirmed bool, err error) {
+	return WaitForConfirmationWithOpts(
+		ctx,
+		nil,
+		wsClient,
+		sig,
+		&WaitForConfirmationOpts{
+			Timeout: timeout,
+		},
+	)
+}
+
+// WaitForConfirmationWithOpts is like WaitForConfirmation, but it can also stop
+// when the blockhash of the transaction expires (see WaitForConfirmationOpts.LastValidBlockHeight),
+// which requires the RPC client; otherwise rpcClient can be nil.
+func WaitForConfirmationWithOpts(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	wsClient *ws.Client,
+	sig solana.Signature,
+	opts *WaitForConfirmationOpts,
+) (confirmed bool, err error) {
+	if opts == nil {
+		opts = &WaitForConfirmationOpts{}
+	}
+	if opts.LastValidBlockHeight != 0 && rpcClient == nil {
+		return false, fmt.Errorf("an RPC client is required to check the last valid block height")
+	}
+
 	sub, err := wsClient.SignatureSubscribe(
 		sig,
 		rpc.CommitmentFinalized,
@@ -115,18 +208,45 @@ func WaitForConfirmation(
 	}
 	defer sub.Unsubscribe()
 
-	if timeout == nil {
-		t := 2 * time.Minute // random default timeout
-		timeout = &t
+	timeout := 2 * time.Minute // random default timeout
+	if opts.Timeout != nil {
+		timeout = *opts.Timeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var checkBlockHeight <-chan time.Time
+	if opts.LastValidBlockHeight != 0 {
+		interval := opts.BlockHeightCheckInterval
+		if interval <= 0 {
+			interval = DefaultBlockHeightCheckInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		checkBlockHeight = ticker.C
 	}
 
+	// Response() starts a new reader of the subscription on each call,
+	// so it must be called only once.
+	responses := sub.Response()
 	for {
 		select {
 		case <-ctx.Done():
 			return false, ctx.Err()
-		case <-time.After(*timeout):
+		case <-timer.C:
 			return false, ErrTimeout
-		case resp, ok := <-sub.Response():
+		case <-checkBlockHeight:
+			// Errors while checking are transient: try again at the next tick.
+			expired, err := isExpired(ctx, rpcClient, sig, opts.LastValidBlockHeight)
+			if err == nil && expired {
+				return false, fmt.Errorf(
+					"%w: transaction %s was not processed by block height %d",
+					solana.ErrBlockhashExpired,
+					sig,
+					opts.LastValidBlockHeight,
+				)
+			}
+		case resp, ok := <-responses:
 			if !ok {
 				return false, fmt.Errorf("subscription closed")
 			}
@@ -142,3 +262,27 @@ func WaitForConfirmation(
 		}
 	}
 }
+
+// isExpired returns true if the finalized block height is past lastValidBlockHeight
+// and the node has no status for the transaction.
+func isExpired(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	sig solana.Signature,
+	lastValidBlockHeight uint64,
+) (bool, error) {
+	blockHeight, err := rpcClient.GetBlockHeight(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return false, err
+	}
+	if blockHeight <= lastValidBlockHeight {
+		return false, nil
+	}
+	// The status is checked after the block height, so that a transaction
+	// that landed just before the expiry is not reported as expired.
+	statuses, err := rpcClient.GetSignatureStatuses(ctx, false, sig)
+	if err != nil {
+		return false, err
+	}
+	return len(statuses.Value) == 0 || statuses.Value[0] == nil, nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sendandconfirmtransaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRPC answers getBlockHeight, getLatestBlockhash and getSignatureStatuses
+// (with no status), and fails sendTransaction (so that no confirmation is awaited);
+// it records the called methods.
+func mockRPC(t *testing.T, blockHeight uint64, blockhash solana.Hash, lastValidBlockHeight uint64) (*rpc.Client, *[]string) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		methods = append(methods, req.Method)
+
+		var body string
+		switch req.Method {
+		case "getBlockHeight":
+			body = fmt.Sprintf(`"result":%d`, blockHeight)
+		case "getLatestBlockhash":
+			body = fmt.Sprintf(
+				`"result":{"context":{"slot":1},"value":{"blockhash":%q,"lastValidBlockHeight":%d}}`,
+				blockhash,
+				lastValidBlockHeight,
+			)
+		case "getSignatureStatuses":
+			body = `"result":{"context":{"slot":1},"value":[null]}`
+		default:
+			body = `"error":{"code":-32002,"message":"send failed"}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,%s}`, req.ID, body)
+	}))
+	t.Cleanup(server.Close)
+	return rpc.New(server.URL), &methods
+}
+
+func newTestTransaction(t *testing.T, payer solana.PrivateKey, lastValidBlockHeight uint64) *solana.Transaction {
+	tx, err := solana.NewTransactionBuilder().
+		AddInstruction(solana.NewInstruction(
+			solana.SystemProgramID,
+			solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).WRITE().SIGNER()},
+			[]byte{0x01},
+		)).
+		SetRecentBlockHash(solana.Hash{1}).
+		SetLastValidBlockHeight(lastValidBlockHeight).
+		Build()
+	require.NoError(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &payer })
+	require.NoError(t, err)
+	return tx
+}
+
+func TestSendAndConfirmTransaction_expiredBlockhash(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+
+	{
+		client, methods := mockRPC(t, 1001, solana.Hash{}, 0)
+		_, err := SendAndConfirmTransaction(context.Background(), client, nil, newTestTransaction(t, payer, 1000))
+		require.True(t, errors.Is(err, solana.ErrBlockhashExpired), err)
+		require.Equal(t, []string{"getBlockHeight"}, *methods)
+	}
+	{
+		// Still valid at its last valid block height.
+		client, methods := mockRPC(t, 1000, solana.Hash{}, 0)
+		_, err := SendAndConfirmTransaction(context.Background(), client, nil, newTestTransaction(t, payer, 1000))
+		require.Error(t, err)
+		require.False(t, errors.Is(err, solana.ErrBlockhashExpired))
+		require.Equal(t, []string{"getBlockHeight", "sendTransaction"}, *methods)
+	}
+	{
+		// Unknown last valid block height: no check.
+		client, methods := mockRPC(t, 1001, solana.Hash{}, 0)
+		_, err := SendAndConfirmTransaction(context.Background(), client, nil, newTestTransaction(t, payer, 0))
+		require.Error(t, err)
+		require.Equal(t, []string{"sendTransaction"}, *methods)
+	}
+}
+
+func TestRefreshBlockhash(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	tx := newTestTransaction(t, payer, 1000)
+	oldSignature := tx.Signatures[0]
+
+	client, methods := mockRPC(t, 0, solana.Hash{2}, 2000)
+	require.NoError(t, RefreshBlockhash(context.Background(), client, tx, payer))
+	require.Equal(t, []string{"getLatestBlockhash"}, *methods)
+
+	require.Equal(t, solana.Hash{2}, tx.Message.RecentBlockhash)
+	require.Equal(t, uint64(2000), tx.LastValidBlockHeight)
+	require.Len(t, tx.Signatures, 1)
+	require.NotEqual(t, oldSignature, tx.Signatures[0])
+	require.NoError(t, tx.VerifySignatures())
+
+	// Missing signers.
+	require.Error(t, RefreshBlockhash(context.Background(), client, tx))
+}
+
+// mockWS confirms the signature subscription and, if notifyAfter is not zero,
+// sends a (successful) signature notification after that delay.
+func mockWS(t *testing.T, notifyAfter time.Duration) *ws.Client {
+	done := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var subscribe struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := conn.ReadJSON(&subscribe); err != nil {
+			return
+		}
+		require.NoError(t, conn.WriteMessage(
+			websocket.TextMessage,
+			[]byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":7,"id":%s}`, subscribe.ID)),
+		))
+		if notifyAfter != 0 {
+			select {
+			case <-time.After(notifyAfter):
+			case <-done:
+				return
+			}
+			require.NoError(t, conn.WriteMessage(
+				websocket.TextMessage,
+				[]byte(`{"jsonrpc":"2.0","method":"signatureNotification","params":{"result":{"context":{"slot":5},"value":{"err":null}},"subscription":7}}`),
+			))
+		}
+		<-done
+	}))
+	client, err := ws.Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		client.Close()
+		close(done)
+		server.Close()
+	})
+	return client
+}
+
+func TestWaitForConfirmationWithOpts_lastValidBlockHeight(t *testing.T) {
+	sig := solana.Signature{1}
+	timeout := 5 * time.Second
+
+	{
+		// Past the last valid block height, with no status: expired.
+		client, methods := mockRPC(t, 1001, solana.Hash{}, 0)
+		confirmed, err := WaitForConfirmationWithOpts(context.Background(), client, mockWS(t, 0), sig, &WaitForConfirmationOpts{
+			Timeout:                  &timeout,
+			LastValidBlockHeight:     1000,
+			BlockHeightCheckInterval: 10 * time.Millisecond,
+		})
+		require.True(t, errors.Is(err, solana.ErrBlockhashExpired), err)
+		require.False(t, confirmed)
+		require.Equal(t, []string{"getBlockHeight", "getSignatureStatuses"}, *methods)
+	}
+	{
+		// Still valid: waits for the confirmation.
+		client, methods := mockRPC(t, 1000, solana.Hash{}, 0)
+		confirmed, err := WaitForConfirmationWithOpts(context.Background(), client, mockWS(t, 100*time.Millisecond), sig, &WaitForConfirmationOpts{
+			Timeout:                  &timeout,
+			LastValidBlockHeight:     1000,
+			BlockHeightCheckInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.True(t, confirmed)
+		require.NotEmpty(t, *methods)
+		for _, method := range *methods {
+			require.Equal(t, "getBlockHeight", method)
+		}
+	}
+	{
+		// No last valid block height: only the timeout applies.
+		short := 50 * time.Millisecond
+		confirmed, err := WaitForConfirmationWithOpts(context.Background(), nil, mockWS(t, 0), sig, &WaitForConfirmationOpts{
+			Timeout: &short,
+		})
+		require.Equal(t, ErrTimeout, err)
+		require.False(t, confirmed)
+	}
+	{
+		_, err := WaitForConfirmationWithOpts(context.Background(), nil, mockWS(t, 0), sig, &WaitForConfirmationOpts{
+			LastValidBlockHeight: 1000,
+		})
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const DefaultRebroadcastInterval = 2 * time.Second
+
+type RebroadcastOpts struct {
+	// How often the transaction is sent again, and its status checked.
+	// Defaults to DefaultRebroadcastInterval.
+	Interval time.Duration
+
+	// If true, only the first send runs the preflight checks.
+	SkipPreflightAfterFirst bool
+
+	// Max number of times the transaction is sent again after the first send;
+	// zero means no limit. The status is still watched after the last re-send.
+	MaxResends int
+
+	// The commitment at which the transaction is considered confirmed
+	// (also used for the preflight checks and the block height).
+	// Defaults to "confirmed".
+	Commitment CommitmentType
+}
+
+// SendAndRebroadcast sends the (signed) transaction, and then keeps sending
+// the same bytes every `opts.Interval` while watching its signature status,
+// until the transaction is confirmed, its blockhash expires
+// (if the transaction has a LastValidBlockHeight; see solana.Transaction.IsExpired),
+// or the context is done.
+//
+// It returns the signature of the transaction and the number of re-sends.
+// Re-send errors are ignored (the node reports a transaction that has already
+// been processed as an error); only the status of the transaction is authoritative.
+// If the blockhash expires before the transaction is confirmed,
+// the returned error wraps solana.ErrBlockhashExpired.
+func (cl *Client) SendAndRebroadcast(
+	ctx context.Context,
+	transaction *solana.Transaction,
+	opts RebroadcastOpts,
+) (signature solana.Signature, rebroadcasts int, err error) {
+	if len(transaction.Signatures) == 0 {
+		return solana.Signature{}, 0, errors.New("transaction is not signed")
+	}
+	signature = transaction.Signatures[0]
+
+	txData, err := transaction.MarshalBinary()
+	if err != nil {
+		return signature, 0, fmt.Errorf("send transaction: encode transaction: %w", err)
+	}
+	encodedTx := base64.StdEncoding.EncodeToString(txData)
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultRebroadcastInterval
+	}
+	commitment := opts.Commitment
+	if commitment == "" {
+		commitment = CommitmentConfirmed
+	}
+
+	sendOpts := TransactionOpts{
+		PreflightCommitment: commitment,
+	}
+	_, err = cl.SendEncodedTransactionWithOpts(ctx, encodedTx, sendOpts)
+	if err != nil && !isAlreadyProcessedError(err) {
+		return signature, 0, err
+	}
+	if opts.SkipPreflightAfterFirst {
+		sendOpts.SkipPreflight = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for opts.MaxResends <= 0 || rebroadcasts < opts.MaxResends {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cl.SendEncodedTransactionWithOpts(ctx, encodedTx, sendOpts)
+			rebroadcasts++
+		}
+	}()
+
+	err = cl.watchRebroadcast(ctx, transaction, signature, interval, commitment)
+	// Stop the re-sends before reading their count.
+	cancel()
+	wg.Wait()
+	return signature, rebroadcasts, err
+}
+
+func (cl *Client) watchRebroadcast(
+	ctx context.Context,
+	transaction *solana.Transaction,
+	signature solana.Signature,
+	interval time.Duration,
+	commitment CommitmentType,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		// Errors while watching are transient: try again at the next tick.
+		out, err := cl.GetSignatureStatuses(ctx, false, signature)
+		if err == nil && len(out.Value) > 0 && out.Value[0] != nil {
+			status := out.Value[0]
+			if status.Err != nil {
+				return fmt.Errorf("transaction %s failed: %v", signature, status.Err)
+			}
+			if status.ConfirmationStatus.IsAtLeast(commitment) {
+				return nil
+			}
+		}
+
+		if transaction.LastValidBlockHeight != 0 {
+			blockHeight, err := cl.GetBlockHeight(ctx, commitment)
+			if err == nil && transaction.IsExpired(blockHeight) {
+				return fmt.Errorf(
+					"%w: transaction %s was not confirmed by block height %d",
+					solana.ErrBlockhashExpired,
+					signature,
+					transaction.LastValidBlockHeight,
+				)
+			}
+		}
+	}
+}
+
+// isAlreadyProcessedError returns true if the error is the one returned
+// by the node when the transaction has already been processed.
+func isAlreadyProcessedError(err error) bool {
+	var rpcErr *jsonrpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if data, ok := rpcErr.Data.(map[string]interface{}); ok && data["err"] == "AlreadyProcessed" {
+		return true
+	}
+	return strings.Contains(rpcErr.Message, "already been processed")
+}
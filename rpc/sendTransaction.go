@@ -82,3 +82,45 @@ func (cl *Client) SendTransactionWithOpts(
 		opts,
 	)
 }
+
+// BuildAndSend builds a transaction with the instructions and the provided recent blockhash
+// (e.g. the result of GetLatestBlockhash), signs it with the signers, which must include
+// all the required signers, and sends it.
+// No blockhash is fetched: a blockhash fetched once can be used for many transactions,
+// as long as it is valid.
+// The returned transaction has the LastValidBlockHeight of the blockhash,
+// e.g. to wait for its confirmation.
+func (cl *Client) BuildAndSend(
+	ctx context.Context,
+	instructions []solana.Instruction,
+	recent LatestBlockhashResult,
+	payer solana.PublicKey,
+	opts TransactionOpts,
+	signers ...solana.PrivateKey,
+) (*solana.Transaction, solana.Signature, error) {
+	if recent.Blockhash.IsZero() {
+		return nil, solana.Signature{}, fmt.Errorf("build and send: a recent blockhash is required")
+	}
+	tx, err := solana.NewTransaction(
+		instructions,
+		recent.Blockhash,
+		solana.TransactionPayer(payer),
+		solana.TransactionLastValidBlockHeight(recent.LastValidBlockHeight),
+	)
+	if err != nil {
+		return nil, solana.Signature{}, fmt.Errorf("build and send: build transaction: %w", err)
+	}
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		for i := range signers {
+			if signers[i].PublicKey().Equals(key) {
+				return &signers[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, solana.Signature{}, fmt.Errorf("build and send: sign transaction: %w", err)
+	}
+	sig, err := cl.SendTransactionWithOpts(ctx, tx, opts)
+	return tx, sig, err
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSendTransaction records the methods called and the transactions sent.
+func mockSendTransaction(t *testing.T) (client *Client, methods func() []string, sent func() []*solana.Transaction) {
+	var mu sync.Mutex
+	var calls []string
+	var txs []*solana.Transaction
+	server, closer := mockJSONRPCFunc(t, func(method string, params []interface{}) string {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, method)
+		if method != "sendTransaction" {
+			return `"error":{"code":-32601,"message":"Method not found"}`
+		}
+		raw, err := base64.StdEncoding.DecodeString(params[0].(string))
+		require.NoError(t, err)
+		tx, err := solana.TransactionFromBytes(raw)
+		require.NoError(t, err)
+		txs = append(txs, tx)
+		return fmt.Sprintf(`"result":%q`, tx.Signatures[0])
+	})
+	t.Cleanup(closer)
+	methods = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, calls...)
+	}
+	sent = func() []*solana.Transaction {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*solana.Transaction{}, txs...)
+	}
+	return New(server.URL), methods, sent
+}
+
+func TestClient_SendTransaction_keepsBlockhash(t *testing.T) {
+	client, methods, sent := mockSendTransaction(t)
+	payer := solana.NewWallet().PrivateKey
+	blockhash := solana.MustHashFromBase58("dv4ACNkpYPcE3aKmYDqZm9G5EB3J4MRoeE7WNDRBVJB")
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build()},
+		blockhash,
+		solana.TransactionPayer(payer.PublicKey()),
+		solana.TransactionLastValidBlockHeight(1000),
+	)
+	require.NoError(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &payer })
+	require.NoError(t, err)
+	signatures := append([]solana.Signature{}, tx.Signatures...)
+
+	sig, err := client.SendTransaction(context.Background(), tx)
+	require.NoError(t, err)
+	assert.Equal(t, tx.Signatures[0], sig)
+
+	// No blockhash was fetched, and the transaction is unchanged.
+	assert.Equal(t, []string{"sendTransaction"}, methods())
+	assert.Equal(t, blockhash, tx.Message.RecentBlockhash)
+	assert.Equal(t, uint64(1000), tx.LastValidBlockHeight)
+	assert.Equal(t, signatures, tx.Signatures)
+	require.Len(t, sent(), 1)
+	assert.Equal(t, blockhash, sent()[0].Message.RecentBlockhash)
+}
+
+func TestClient_BuildAndSend(t *testing.T) {
+	client, methods, sent := mockSendTransaction(t)
+	payer := solana.NewWallet().PrivateKey
+	recent := LatestBlockhashResult{
+		Blockhash:            solana.MustHashFromBase58("dv4ACNkpYPcE3aKmYDqZm9G5EB3J4MRoeE7WNDRBVJB"),
+		LastValidBlockHeight: 1000,
+	}
+
+	// Many sends against one blockhash.
+	for i := 1; i <= 3; i++ {
+		instructions := []solana.Instruction{
+			system.NewTransferInstruction(uint64(i), payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		}
+		tx, sig, err := client.BuildAndSend(context.Background(), instructions, recent, payer.PublicKey(), TransactionOpts{}, payer)
+		require.NoError(t, err)
+		assert.Equal(t, tx.Signatures[0], sig)
+		assert.Equal(t, recent.Blockhash, tx.Message.RecentBlockhash)
+		assert.Equal(t, uint64(1000), tx.LastValidBlockHeight)
+		require.NoError(t, tx.VerifySignatures())
+	}
+	assert.Equal(t, []string{"sendTransaction", "sendTransaction", "sendTransaction"}, methods())
+	for _, tx := range sent() {
+		assert.Equal(t, recent.Blockhash, tx.Message.RecentBlockhash)
+	}
+
+	t.Run("no blockhash", func(t *testing.T) {
+		_, _, err := client.BuildAndSend(context.Background(), nil, LatestBlockhashResult{}, payer.PublicKey(), TransactionOpts{}, payer)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "a recent blockhash is required")
+	})
+
+	t.Run("missing signer", func(t *testing.T) {
+		instructions := []solana.Instruction{
+			system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		}
+		_, _, err := client.BuildAndSend(context.Background(), instructions, recent, payer.PublicKey(), TransactionOpts{})
+		require.Error(t, err)
+	})
+	assert.Len(t, methods(), 3)
+}
@@ -104,6 +104,14 @@ func (cl *Client) SimulateRawTransactionWithOpts(
 	ctx context.Context,
 	txData []byte,
 	opts *SimulateTransactionOpts,
+) (out *SimulateTransactionResponse, err error) {
+	return cl.simulateEncodedTransaction(ctx, base64.StdEncoding.EncodeToString(txData), opts)
+}
+
+func (cl *Client) simulateEncodedTransaction(
+	ctx context.Context,
+	b64Data string,
+	opts *SimulateTransactionOpts,
 ) (out *SimulateTransactionResponse, err error) {
 	obj := M{
 		"encoding": "base64",
@@ -126,7 +134,6 @@ func (cl *Client) SimulateRawTransactionWithOpts(
 		}
 	}
 
-	b64Data := base64.StdEncoding.EncodeToString(txData)
 	params := []interface{}{
 		b64Data,
 		obj,
@@ -135,3 +142,34 @@ func (cl *Client) SimulateRawTransactionWithOpts(
 	err = cl.rpcClient.CallForInto(ctx, &out, "simulateTransaction", params)
 	return
 }
+
+// SimulateComputeUnits simulates the transaction, which doesn't need to be signed,
+// with the most recent blockhash (see SimulateTransactionOpts.ReplaceRecentBlockhash),
+// and returns the compute units it consumed.
+// It implements solana.ComputeUnitsSimulator (see solana.Transaction.EstimateComputeUnits).
+// An error is returned if the simulation fails (with the logs of the simulation).
+func (cl *Client) SimulateComputeUnits(
+	ctx context.Context,
+	transaction *solana.Transaction,
+) (uint64, error) {
+	b64Data, err := transaction.ToBase64(solana.EncodeAllowUnsigned())
+	if err != nil {
+		return 0, fmt.Errorf("simulate compute units: encode transaction: %w", err)
+	}
+	out, err := cl.simulateEncodedTransaction(ctx, b64Data, &SimulateTransactionOpts{
+		ReplaceRecentBlockhash: true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("simulate compute units: %w", err)
+	}
+	if out == nil || out.Value == nil {
+		return 0, fmt.Errorf("simulate compute units: no simulation result")
+	}
+	if out.Value.Err != nil {
+		return 0, fmt.Errorf("simulate compute units: simulation failed: %v (logs: %q)", out.Value.Err, out.Value.Logs)
+	}
+	if out.Value.UnitsConsumed == nil {
+		return 0, fmt.Errorf("simulate compute units: the node didn't return the units consumed")
+	}
+	return *out.Value.UnitsConsumed, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// callStreaming calls the method and decodes the JSON-RPC response
+// as it is received (instead of reading it whole): decodeResult is called
+// with the decoder positioned at the start of the result value,
+// and must consume the whole value.
+// If decodeResult returns an error, the request is aborted and that error is returned.
+//
+// The response is always decoded with encoding/json, whatever the codec set with
+// solana.SetJSONCodec: streaming relies on the token API of its Decoder,
+// which the JSONCodec interface doesn't have.
+func (cl *Client) callStreaming(
+	ctx context.Context,
+	method string,
+	params []interface{},
+	decodeResult func(*stdjson.Decoder) error,
+) error {
+	return cl.rpcClient.CallWithCallback(
+		ctx,
+		method,
+		params,
+		func(httpRequest *http.Request, httpResponse *http.Response) error {
+			err := decodeResponseStream(httpResponse.Body, decodeResult)
+			if err != nil && httpResponse.StatusCode >= 400 {
+				var rpcErr *jsonrpc.RPCError
+				if !errors.As(err, &rpcErr) {
+					return jsonrpc.NewHTTPError(
+						httpResponse.StatusCode,
+						fmt.Errorf("rpc call %s() on %v status code: %v: %w", method, httpRequest.URL.String(), httpResponse.StatusCode, err),
+					)
+				}
+			}
+			return err
+		},
+	)
+}
+
+// decodeResponseStream reads a JSON-RPC response,
+// calling decodeResult to decode the result.
+func decodeResponseStream(r io.Reader, decodeResult func(*stdjson.Decoder) error) error {
+	decoder := stdjson.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	gotResult := false
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("unable to read response: %w", err)
+		}
+		switch tok {
+		case "result":
+			gotResult = true
+			if err := decodeResult(decoder); err != nil {
+				return err
+			}
+		case "error":
+			var rpcErr *jsonrpc.RPCError
+			if err := decoder.Decode(&rpcErr); err != nil {
+				return fmt.Errorf("unable to decode rpc error: %w", err)
+			}
+			if rpcErr != nil {
+				return rpcErr
+			}
+		default:
+			// jsonrpc, id, etc.
+			var skip stdjson.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return fmt.Errorf("unable to read response: %w", err)
+			}
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return err
+	}
+	if !gotResult {
+		return fmt.Errorf("rpc response missing result")
+	}
+	return nil
+}
+
+func expectDelim(decoder *stdjson.Decoder, expected stdjson.Delim) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("unable to read response: %w", err)
+	}
+	if delim, ok := tok.(stdjson.Delim); !ok || delim != expected {
+		return fmt.Errorf("unexpected token in response: expected %v, got %v", expected, tok)
+	}
+	return nil
+}
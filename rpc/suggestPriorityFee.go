@@ -0,0 +1,149 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultPriorityFeeCacheTTL is how long the recent prioritization fees
+// of a set of accounts are cached by a Client (see SuggestPriorityFee).
+const DefaultPriorityFeeCacheTTL = 2 * time.Second
+
+// SuggestPriorityFee returns the compute-unit price (in micro-lamports)
+// at the provided percentile (between 0 and 100, e.g. 75) of the non-zero
+// prioritization fees paid in the recent blocks by the transactions that
+// write-lock the provided accounts (all the transactions if none is provided);
+// it returns 0 if no such transaction paid a prioritization fee.
+//
+// The fees are fetched with getRecentPrioritizationFees, and cached by the
+// client for DefaultPriorityFeeCacheTTL for the same set of accounts
+// (regardless of their order).
+//
+// The result can be used as the price of the compute-budget SetComputeUnitPrice instruction, e.g.
+//
+//	fee, err := client.SuggestPriorityFee(ctx, writableAccounts, 75)
+//	...
+//	computebudget.NewSetComputeUnitPriceInstruction(fee).Build()
+func (cl *Client) SuggestPriorityFee(
+	ctx context.Context,
+	accounts []solana.PublicKey,
+	percentile float64,
+) (uint64, error) {
+	if math.IsNaN(percentile) || percentile < 0 || percentile > 100 {
+		return 0, fmt.Errorf("percentile must be between 0 and 100, got %v", percentile)
+	}
+	key := priorityFeeCacheKey(accounts)
+	if cl.priorityFees != nil {
+		if fees, ok := cl.priorityFees.get(key); ok {
+			return feePercentile(fees, percentile), nil
+		}
+	}
+	out, err := cl.GetRecentPrioritizationFees(ctx, accounts)
+	if err != nil {
+		return 0, err
+	}
+	fees := make([]uint64, 0, len(out))
+	for _, fee := range out {
+		if fee.PrioritizationFee > 0 {
+			fees = append(fees, fee.PrioritizationFee)
+		}
+	}
+	sort.Slice(fees, func(i, j int) bool { return fees[i] < fees[j] })
+	if cl.priorityFees != nil {
+		cl.priorityFees.add(key, fees)
+	}
+	return feePercentile(fees, percentile), nil
+}
+
+// feePercentile returns the nearest-rank percentile of the sorted fees.
+func feePercentile(sorted []uint64, percentile float64) uint64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// priorityFeeCacheKey returns the same key for the same set of accounts.
+func priorityFeeCacheKey(accounts []solana.PublicKey) string {
+	keys := make([]string, 0, len(accounts))
+	seen := make(map[solana.PublicKey]bool, len(accounts))
+	for _, account := range accounts {
+		if !seen[account] {
+			seen[account] = true
+			keys = append(keys, account.String())
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// priorityFeeCache is a concurrency-safe cache of the sorted non-zero
+// prioritization fees, by set of accounts, whose entries expire after a TTL.
+type priorityFeeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]priorityFeeEntry
+}
+
+type priorityFeeEntry struct {
+	fees    []uint64
+	expires time.Time
+}
+
+func newPriorityFeeCache(ttl time.Duration) *priorityFeeCache {
+	return &priorityFeeCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]priorityFeeEntry),
+	}
+}
+
+func (c *priorityFeeCache) get(key string) ([]uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.fees, true
+}
+
+func (c *priorityFeeCache) add(key string, fees []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	// Drop the expired entries, so that the cache doesn't grow
+	// with the sets of accounts that are not asked for anymore.
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = priorityFeeEntry{fees: fees, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,325 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tailer follows the transactions that touch an address,
+// processing only the ones that are new since the previous run.
+package tailer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const (
+	DefaultInterval = 2 * time.Second
+	// The max number of signatures returned by a getSignaturesForAddress request.
+	MaxPageSize = 1000
+)
+
+// CursorStore persists the cursor of each address: the newest finalized
+// signature that was processed (and all the ones before it).
+type CursorStore interface {
+	// Load returns the cursor of the address,
+	// or a zero signature if there is none.
+	Load(ctx context.Context, address solana.PublicKey) (solana.Signature, error)
+	Save(ctx context.Context, address solana.PublicKey, sig solana.Signature) error
+}
+
+// MemoryCursorStore is a CursorStore that keeps the cursors in memory.
+type MemoryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[solana.PublicKey]solana.Signature
+}
+
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{
+		cursors: make(map[solana.PublicKey]solana.Signature),
+	}
+}
+
+func (s *MemoryCursorStore) Load(ctx context.Context, address solana.PublicKey) (solana.Signature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursors[address], nil
+}
+
+func (s *MemoryCursorStore) Save(ctx context.Context, address solana.PublicKey, sig solana.Signature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[address] = sig
+	return nil
+}
+
+type Options struct {
+	// How often the address is polled. Defaults to DefaultInterval.
+	Interval time.Duration
+	// Number of signatures per getSignaturesForAddress request.
+	// Defaults to (and can't be greater than) MaxPageSize.
+	PageSize int
+	// Commitment of the listed signatures: confirmed (the default), or finalized
+	// to process only the finalized transactions.
+	// "processed" is not supported by getSignaturesForAddress.
+	Commitment rpc.CommitmentType
+	// Where the cursor is persisted. Defaults to a MemoryCursorStore.
+	Store CursorStore
+	// If set, and there is no cursor yet, the first poll only sets the cursor
+	// to the newest finalized signature, without processing the history of the address.
+	SkipHistory bool
+	// If set, Run also subscribes to the logs mentioning the address,
+	// and polls as soon as a notification is received instead of waiting for the next interval.
+	WS *ws.Client
+	// Called by Run for every poll that failed (and if the logs subscription fails);
+	// the tailer keeps running.
+	OnError func(error)
+	// Called when a signature that was processed before being finalized
+	// is not listed anymore (e.g. its fork was abandoned).
+	OnDropped func(*rpc.TransactionSignature)
+}
+
+// AddressTailer calls a handler for each new transaction that touched an address,
+// from the oldest to the newest.
+//
+// The cursor only advances past finalized signatures. The signatures
+// that are not finalized yet are processed as soon as they are listed,
+// and remembered (in memory) so that they are not processed again
+// when they get finalized; after a restart, they are processed again.
+// The methods are safe for concurrent use.
+type AddressTailer struct {
+	client  *rpc.Client
+	address solana.PublicKey
+	handler func(context.Context, *rpc.TransactionSignature) error
+	opts    Options
+
+	// Serializes the polls, so that the handler is never called concurrently.
+	mu sync.Mutex
+	// The processed signatures that are newer than the cursor.
+	pending map[solana.Signature]*rpc.TransactionSignature
+}
+
+// NewAddressTailer creates a tailer that calls `handler` (never concurrently)
+// for each new transaction that touched the address; opts can be nil.
+func NewAddressTailer(
+	client *rpc.Client,
+	address solana.PublicKey,
+	opts *Options,
+	handler func(context.Context, *rpc.TransactionSignature) error,
+) *AddressTailer {
+	t := &AddressTailer{
+		client:  client,
+		address: address,
+		handler: handler,
+		pending: make(map[solana.Signature]*rpc.TransactionSignature),
+	}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.Interval <= 0 {
+		t.opts.Interval = DefaultInterval
+	}
+	if t.opts.PageSize <= 0 || t.opts.PageSize > MaxPageSize {
+		t.opts.PageSize = MaxPageSize
+	}
+	if t.opts.Commitment == "" {
+		t.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if t.opts.Store == nil {
+		t.opts.Store = NewMemoryCursorStore()
+	}
+	return t
+}
+
+// Cursor returns the stored cursor of the address.
+func (t *AddressTailer) Cursor(ctx context.Context) (solana.Signature, error) {
+	return t.opts.Store.Load(ctx, t.address)
+}
+
+// Run polls the address every Interval (and, if WS is set, on every logs
+// notification) until the context is done.
+func (t *AddressTailer) Run(ctx context.Context) error {
+	wake := make(chan struct{}, 1)
+	subErr := make(chan error, 1)
+	if t.opts.WS != nil {
+		sub, err := t.opts.WS.LogsSubscribeMentions(t.address, t.opts.Commitment)
+		if err != nil {
+			t.reportError(ctx, fmt.Errorf("unable to subscribe to the logs of %s: %w", t.address, err))
+		} else {
+			defer sub.Unsubscribe()
+			go func() {
+				for {
+					got, err := sub.Recv()
+					if err != nil || got == nil {
+						// A nil error means that the subscription was closed.
+						subErr <- err
+						return
+					}
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				}
+			}()
+		}
+	}
+
+	ticker := time.NewTicker(t.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := t.Poll(ctx); err != nil {
+			t.reportError(ctx, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-wake:
+		case err := <-subErr:
+			if err != nil {
+				t.reportError(ctx, fmt.Errorf("logs subscription of %s failed: %w", t.address, err))
+			}
+			// Keep polling at the interval.
+			subErr = nil
+		}
+	}
+}
+
+func (t *AddressTailer) reportError(ctx context.Context, err error) {
+	if t.opts.OnError != nil && ctx.Err() == nil {
+		t.opts.OnError(err)
+	}
+}
+
+// Poll fetches the signatures newer than the cursor once,
+// calls the handler for the ones that were not processed yet (oldest first),
+// and advances the cursor up to the last signature before the first
+// one that is not finalized.
+// If the handler returns an error, the poll stops and that error is returned;
+// that signature (and the following ones) will be processed again by the next poll.
+func (t *AddressTailer) Poll(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cursor, err := t.opts.Store.Load(ctx, t.address)
+	if err != nil {
+		return fmt.Errorf("unable to load cursor of %s: %w", t.address, err)
+	}
+	if cursor.IsZero() && t.opts.SkipHistory {
+		return t.skipHistory(ctx)
+	}
+
+	signatures, err := t.listSignatures(ctx, cursor)
+	if err != nil {
+		return err
+	}
+
+	newCursor := cursor
+	advancing := true
+	listed := make(map[solana.Signature]bool, len(signatures))
+	for _, sig := range signatures {
+		listed[sig.Signature] = true
+		if _, processed := t.pending[sig.Signature]; !processed {
+			if err := t.handler(ctx, sig); err != nil {
+				if saveErr := t.saveCursor(ctx, cursor, newCursor); saveErr != nil {
+					return saveErr
+				}
+				return err
+			}
+		}
+		if advancing && t.isFinalized(sig) {
+			newCursor = sig.Signature
+			delete(t.pending, sig.Signature)
+			continue
+		}
+		// Everything after the first signature that is not finalized
+		// stays after the cursor, and must not be processed again.
+		advancing = false
+		t.pending[sig.Signature] = sig
+	}
+
+	for sig, pending := range t.pending {
+		if !listed[sig] {
+			delete(t.pending, sig)
+			if t.opts.OnDropped != nil {
+				t.opts.OnDropped(pending)
+			}
+		}
+	}
+	return t.saveCursor(ctx, cursor, newCursor)
+}
+
+func (t *AddressTailer) isFinalized(sig *rpc.TransactionSignature) bool {
+	if sig.ConfirmationStatus == "" {
+		// Nodes that don't return the status only list signatures at the requested commitment.
+		return t.opts.Commitment == rpc.CommitmentFinalized
+	}
+	return sig.ConfirmationStatus == rpc.ConfirmationStatusFinalized
+}
+
+func (t *AddressTailer) saveCursor(ctx context.Context, previous, cursor solana.Signature) error {
+	if cursor == previous {
+		return nil
+	}
+	if err := t.opts.Store.Save(ctx, t.address, cursor); err != nil {
+		return fmt.Errorf("unable to save cursor %s of %s: %w", cursor, t.address, err)
+	}
+	return nil
+}
+
+// skipHistory sets the cursor to the newest finalized signature of the address, if any.
+func (t *AddressTailer) skipHistory(ctx context.Context) error {
+	limit := 1
+	out, err := t.client.GetSignaturesForAddressWithOpts(ctx, t.address, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: rpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get signatures for %s: %w", t.address, err)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return t.saveCursor(ctx, solana.Signature{}, out[0].Signature)
+}
+
+// listSignatures returns the signatures of the transactions that touched the address
+// after the cursor (excluded), from the oldest to the newest.
+func (t *AddressTailer) listSignatures(ctx context.Context, cursor solana.Signature) ([]*rpc.TransactionSignature, error) {
+	var signatures []*rpc.TransactionSignature
+	err := t.client.SignaturesForAddressAll(
+		ctx,
+		t.address,
+		&rpc.SignaturesForAddressAllOpts{
+			PageSize:   t.opts.PageSize,
+			Until:      cursor,
+			Commitment: t.opts.Commitment,
+		},
+		func(page []*rpc.TransactionSignature) error {
+			signatures = append(signatures, page...)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get signatures for %s: %w", t.address, err)
+	}
+	// Newest first -> oldest first.
+	for i, j := 0, len(signatures)-1; i < j; i, j = i+1, j-1 {
+		signatures[i], signatures[j] = signatures[j], signatures[i]
+	}
+	return signatures, nil
+}
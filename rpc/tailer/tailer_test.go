@@ -0,0 +1,395 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEntry struct {
+	sig    solana.Signature
+	status rpc.ConfirmationStatusType
+}
+
+// mockHistory serves getSignaturesForAddress for a single address,
+// whose entries (oldest first) the tests change between polls.
+type mockHistory struct {
+	mu      sync.Mutex
+	entries []mockEntry
+	// The options of each getSignaturesForAddress request.
+	requests []map[string]interface{}
+}
+
+func (m *mockHistory) set(entries ...mockEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = entries
+}
+
+func (m *mockHistory) takeRequests() []map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := m.requests
+	m.requests = nil
+	return out
+}
+
+func (m *mockHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params []interface{}   `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getSignaturesForAddress" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	opts := req.Params[1].(map[string]interface{})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, opts)
+
+	limit := int(opts["limit"].(float64))
+	start := len(m.entries) - 1
+	if before, ok := opts["before"]; ok {
+		for i, entry := range m.entries {
+			if entry.sig.String() == before {
+				start = i - 1
+			}
+		}
+	}
+	var out []string
+	for i := start; i >= 0 && len(out) < limit; i-- {
+		entry := m.entries[i]
+		if until, ok := opts["until"]; ok && entry.sig.String() == until {
+			break
+		}
+		if opts["commitment"] == string(rpc.CommitmentFinalized) && entry.status != rpc.ConfirmationStatusFinalized {
+			continue
+		}
+		out = append(out, fmt.Sprintf(
+			`{"signature":%q,"slot":%d,"err":null,"memo":null,"blockTime":null,"confirmationStatus":%q}`,
+			entry.sig, 100+i, entry.status,
+		))
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":[%s]}`, req.ID, strings.Join(out, ","))
+}
+
+func finalized(n byte) mockEntry {
+	return mockEntry{sig: solana.Signature{n}, status: rpc.ConfirmationStatusFinalized}
+}
+
+func confirmed(n byte) mockEntry {
+	return mockEntry{sig: solana.Signature{n}, status: rpc.ConfirmationStatusConfirmed}
+}
+
+// countingStore counts the saves.
+type countingStore struct {
+	*MemoryCursorStore
+	saves int
+}
+
+func (s *countingStore) Save(ctx context.Context, address solana.PublicKey, sig solana.Signature) error {
+	s.saves++
+	return s.MemoryCursorStore.Save(ctx, address, sig)
+}
+
+// recorder is a handler that records the processed signatures.
+type recorder struct {
+	mu        sync.Mutex
+	processed []solana.Signature
+	failOn    solana.Signature
+}
+
+func (r *recorder) handle(ctx context.Context, sig *rpc.TransactionSignature) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if sig.Signature == r.failOn {
+		return errors.New("handler failed")
+	}
+	r.processed = append(r.processed, sig.Signature)
+	return nil
+}
+
+func (r *recorder) take() []solana.Signature {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := r.processed
+	r.processed = nil
+	return out
+}
+
+func sigs(entries ...mockEntry) []solana.Signature {
+	var out []solana.Signature
+	for _, entry := range entries {
+		out = append(out, entry.sig)
+	}
+	return out
+}
+
+func TestAddressTailer_emptyFirstRun(t *testing.T) {
+	history := &mockHistory{}
+	server := httptest.NewServer(history)
+	defer server.Close()
+
+	store := &countingStore{MemoryCursorStore: NewMemoryCursorStore()}
+	rec := &recorder{}
+	address := solana.NewWallet().PublicKey()
+	tailer := NewAddressTailer(rpc.New(server.URL), address, &Options{Store: store}, rec.handle)
+	ctx := context.Background()
+
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Empty(t, rec.take())
+	assert.Equal(t, 0, store.saves)
+	cursor, err := tailer.Cursor(ctx)
+	require.NoError(t, err)
+	assert.True(t, cursor.IsZero())
+
+	requests := history.takeRequests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, map[string]interface{}{
+		"limit":      float64(MaxPageSize),
+		"commitment": string(rpc.CommitmentConfirmed),
+	}, requests[0])
+
+	// The first transactions.
+	history.set(finalized(1), confirmed(2))
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Equal(t, sigs(finalized(1), confirmed(2)), rec.take())
+	cursor, err = tailer.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, finalized(1).sig, cursor)
+}
+
+func TestAddressTailer_cursorNotAdvancingPastUnfinalized(t *testing.T) {
+	history := &mockHistory{}
+	server := httptest.NewServer(history)
+	defer server.Close()
+
+	store := NewMemoryCursorStore()
+	rec := &recorder{}
+	var dropped []solana.Signature
+	address := solana.NewWallet().PublicKey()
+	tailer := NewAddressTailer(
+		rpc.New(server.URL),
+		address,
+		&Options{
+			Store:     store,
+			PageSize:  2,
+			OnDropped: func(sig *rpc.TransactionSignature) { dropped = append(dropped, sig.Signature) },
+		},
+		rec.handle,
+	)
+	ctx := context.Background()
+	requireCursor := func(expected solana.Signature) {
+		t.Helper()
+		cursor, err := store.Load(ctx, address)
+		require.NoError(t, err)
+		require.Equal(t, expected, cursor)
+	}
+
+	// A finalized signature after an unfinalized one doesn't move the cursor either.
+	history.set(finalized(1), finalized(2), confirmed(3), finalized(4), confirmed(5))
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Equal(t, sigs(finalized(1), finalized(2), confirmed(3), finalized(4), confirmed(5)), rec.take())
+	requireCursor(finalized(2).sig)
+	// Paginated backwards.
+	assert.Len(t, history.takeRequests(), 3)
+
+	// Nothing new: the unfinalized signatures are listed again (after the cursor),
+	// but are not processed again.
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Empty(t, rec.take())
+	requireCursor(finalized(2).sig)
+	requests := history.takeRequests()
+	require.NotEmpty(t, requests)
+	assert.Equal(t, finalized(2).sig.String(), requests[0]["until"])
+
+	// 3 is finalized, 5 is dropped (its fork was abandoned) and 6 is new.
+	history.set(finalized(1), finalized(2), finalized(3), finalized(4), confirmed(6))
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Equal(t, sigs(confirmed(6)), rec.take())
+	assert.Equal(t, sigs(confirmed(5)), dropped)
+	requireCursor(finalized(4).sig)
+
+	// 6 is finalized: the cursor advances, without processing it again.
+	history.set(finalized(1), finalized(2), finalized(3), finalized(4), finalized(6))
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Empty(t, rec.take())
+	requireCursor(finalized(6).sig)
+	assert.Len(t, dropped, 1)
+
+	// A new tailer resumes from the stored cursor.
+	history.set(finalized(1), finalized(2), finalized(3), finalized(4), finalized(6), finalized(7))
+	history.takeRequests()
+	resumed := NewAddressTailer(rpc.New(server.URL), address, &Options{Store: store}, rec.handle)
+	require.NoError(t, resumed.Poll(ctx))
+	assert.Equal(t, sigs(finalized(7)), rec.take())
+	requireCursor(finalized(7).sig)
+	assert.Equal(t, finalized(6).sig.String(), history.takeRequests()[0]["until"])
+}
+
+func TestAddressTailer_handlerError(t *testing.T) {
+	history := &mockHistory{}
+	server := httptest.NewServer(history)
+	defer server.Close()
+
+	rec := &recorder{failOn: finalized(3).sig}
+	address := solana.NewWallet().PublicKey()
+	tailer := NewAddressTailer(rpc.New(server.URL), address, nil, rec.handle)
+	ctx := context.Background()
+
+	history.set(finalized(1), finalized(2), finalized(3), finalized(4))
+	require.EqualError(t, tailer.Poll(ctx), "handler failed")
+	assert.Equal(t, sigs(finalized(1), finalized(2)), rec.take())
+	cursor, err := tailer.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, finalized(2).sig, cursor)
+
+	// Retried by the next poll.
+	rec.failOn = solana.Signature{}
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Equal(t, sigs(finalized(3), finalized(4)), rec.take())
+	cursor, err = tailer.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, finalized(4).sig, cursor)
+}
+
+func TestAddressTailer_skipHistory(t *testing.T) {
+	history := &mockHistory{}
+	server := httptest.NewServer(history)
+	defer server.Close()
+
+	rec := &recorder{}
+	address := solana.NewWallet().PublicKey()
+	tailer := NewAddressTailer(rpc.New(server.URL), address, &Options{SkipHistory: true}, rec.handle)
+	ctx := context.Background()
+
+	history.set(finalized(1), finalized(2), confirmed(3))
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Empty(t, rec.take())
+	cursor, err := tailer.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, finalized(2).sig, cursor)
+
+	require.NoError(t, tailer.Poll(ctx))
+	assert.Equal(t, sigs(confirmed(3)), rec.take())
+}
+
+// mockLogsServer confirms a single logsSubscribe request,
+// then sends a notification for each value received on the returned channel.
+func mockLogsServer(t *testing.T) (url string, notify chan<- solana.Signature, closer func()) {
+	const subID = 7
+	signatures := make(chan solana.Signature)
+	done := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+		assert.Equal(t, "logsSubscribe", request.Method)
+		require.NoError(t, conn.WriteMessage(
+			websocket.TextMessage,
+			[]byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%s}`, subID, request.ID)),
+		))
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signatures:
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":1},"value":{"signature":%q,"err":null,"logs":[]}},"subscription":%d}}`,
+					sig, subID,
+				))))
+			}
+		}
+	}))
+	return "ws" + strings.TrimPrefix(server.URL, "http"), signatures, func() {
+		close(done)
+		server.Close()
+	}
+}
+
+func TestAddressTailer_Run_logsFastPath(t *testing.T) {
+	history := &mockHistory{}
+	server := httptest.NewServer(history)
+	defer server.Close()
+	wsURL, notify, closeWS := mockLogsServer(t)
+	defer closeWS()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsClient, err := ws.Connect(ctx, wsURL)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	rec := &recorder{}
+	var errs []error
+	tailer := NewAddressTailer(
+		rpc.New(server.URL),
+		solana.NewWallet().PublicKey(),
+		&Options{
+			// Only the notifications trigger the polls after the first one.
+			Interval: time.Hour,
+			WS:       wsClient,
+			OnError:  func(err error) { errs = append(errs, err) },
+		},
+		rec.handle,
+	)
+	done := make(chan error, 1)
+	go func() { done <- tailer.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return len(history.takeRequests()) == 1 }, time.Second, 5*time.Millisecond)
+
+	history.set(confirmed(1))
+	notify <- confirmed(1).sig
+	require.Eventually(t, func() bool {
+		processed := rec.take()
+		if len(processed) == 0 {
+			return false
+		}
+		assert.Equal(t, sigs(confirmed(1)), processed)
+		return true
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, errs)
+}
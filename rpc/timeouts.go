@@ -0,0 +1,129 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// SetMethodTimeouts sets the default timeout of the requests of each RPC method
+// (e.g. {"getProgramAccounts": 30 * time.Second}), replacing the previous ones.
+// A timeout is applied only when the context of the call has no deadline;
+// the requests of the other methods are only limited by the timeout of the HTTP client.
+// A batch is limited by the greatest timeout of its methods,
+// and only if all of them have one.
+func (cl *Client) SetMethodTimeouts(timeouts map[string]time.Duration) {
+	copied := make(map[string]time.Duration, len(timeouts))
+	for method, timeout := range timeouts {
+		if timeout > 0 {
+			copied[method] = timeout
+		}
+	}
+	cl.rpcClient.timeouts.set(copied)
+}
+
+type methodTimeouts struct {
+	mu       sync.RWMutex
+	timeouts map[string]time.Duration
+}
+
+func (t *methodTimeouts) set(timeouts map[string]time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timeouts = timeouts
+}
+
+func (t *methodTimeouts) get(method string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	timeout, ok := t.timeouts[method]
+	return timeout, ok
+}
+
+// withTimeout derives a context with the timeout of the methods,
+// if the context has no deadline.
+func (t *methodTimeouts) withTimeout(ctx context.Context, methods ...string) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || len(methods) == 0 {
+		return ctx, func() {}
+	}
+	var max time.Duration
+	for _, method := range methods {
+		timeout, ok := t.get(method)
+		if !ok {
+			return ctx, func() {}
+		}
+		if timeout > max {
+			max = timeout
+		}
+	}
+	return context.WithTimeout(ctx, max)
+}
+
+// timeoutRPCClient applies the method timeouts to the calls of a JSONRPCClient.
+type timeoutRPCClient struct {
+	JSONRPCClient
+	timeouts *methodTimeouts
+}
+
+func newTimeoutRPCClient(rpcClient JSONRPCClient) *timeoutRPCClient {
+	return &timeoutRPCClient{
+		JSONRPCClient: rpcClient,
+		timeouts:      &methodTimeouts{},
+	}
+}
+
+func (c *timeoutRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	ctx, cancel := c.timeouts.withTimeout(ctx, method)
+	defer cancel()
+	return c.JSONRPCClient.CallForInto(ctx, out, method, params)
+}
+
+func (c *timeoutRPCClient) CallWithCallback(
+	ctx context.Context,
+	method string,
+	params []interface{},
+	callback func(*http.Request, *http.Response) error,
+) error {
+	ctx, cancel := c.timeouts.withTimeout(ctx, method)
+	defer cancel()
+	return c.JSONRPCClient.CallWithCallback(ctx, method, params, callback)
+}
+
+func (c *timeoutRPCClient) CallBatch(
+	ctx context.Context,
+	requests jsonrpc.RPCRequests,
+) (jsonrpc.RPCResponses, error) {
+	methods := make([]string, 0, len(requests))
+	for _, request := range requests {
+		methods = append(methods, request.Method)
+	}
+	ctx, cancel := c.timeouts.withTimeout(ctx, methods...)
+	defer cancel()
+	return c.JSONRPCClient.CallBatch(ctx, requests)
+}
+
+// Close closes the wrapped client.
+func (c *timeoutRPCClient) Close() error {
+	if closer, ok := c.JSONRPCClient.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSlowJSONRPC responds to getProgramAccounts after `delay` (or when the request is canceled),
+// and to the other methods immediately.
+func mockSlowJSONRPC(t *testing.T, delay time.Duration) (*httptest.Server, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var request struct {
+			ID     stdjson.RawMessage `json:"id"`
+			Method string             `json:"method"`
+		}
+		if err := stdjson.NewDecoder(req.Body).Decode(&request); err != nil {
+			// A batch.
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return
+			}
+			rw.Write([]byte(`[{"jsonrpc":"2.0","id":0,"result":[]},{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":1}}]`))
+			return
+		}
+		switch request.Method {
+		case "getProgramAccounts":
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return
+			}
+			rw.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":[]}`))
+		default:
+			rw.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":{"context":{"slot":1},"value":19039980000}}`))
+		}
+	}))
+	return server, server.Close
+}
+
+func TestClient_SetMethodTimeouts(t *testing.T) {
+	server, closer := mockSlowJSONRPC(t, 300*time.Millisecond)
+	defer closer()
+	client := New(server.URL)
+	program := solana.SystemProgramID
+
+	// No timeouts by default.
+	_, err := client.GetProgramAccounts(context.Background(), program)
+	require.NoError(t, err)
+
+	client.SetMethodTimeouts(map[string]time.Duration{
+		"getProgramAccounts": 20 * time.Millisecond,
+		"getBalance":         time.Second,
+	})
+
+	start := time.Now()
+	_, err = client.GetProgramAccounts(context.Background(), program)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(250*time.Millisecond))
+
+	// The fast methods are not affected.
+	balance, err := client.GetBalance(context.Background(), program, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(19039980000), balance.Value)
+
+	// The deadline of the caller takes precedence.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.GetProgramAccounts(ctx, program)
+	require.NoError(t, err)
+
+	// A batch is limited only if all its methods have a timeout.
+	batch := jsonrpc.RPCRequests{
+		{JSONRPC: "2.0", ID: 0, Method: "getProgramAccounts", Params: []interface{}{program}},
+		{JSONRPC: "2.0", ID: 1, Method: "getBalance", Params: []interface{}{program}},
+	}
+	_, err = client.RPCCallBatch(context.Background(), batch)
+	require.NoError(t, err, "limited by the timeout of getBalance")
+	batch[1].Method = "getSlot"
+	_, err = client.RPCCallBatch(context.Background(), batch)
+	require.NoError(t, err, "getSlot has no timeout")
+
+	client.SetMethodTimeouts(map[string]time.Duration{
+		"getProgramAccounts": 20 * time.Millisecond,
+		"getSlot":            20 * time.Millisecond,
+	})
+	_, err = client.RPCCallBatch(context.Background(), batch)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Reset.
+	client.SetMethodTimeouts(nil)
+	_, err = client.GetProgramAccounts(context.Background(), program)
+	require.NoError(t, err)
+}
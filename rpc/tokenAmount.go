@@ -0,0 +1,72 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// BigInt returns the raw amount of tokens (i.e. Amount, ignoring decimals).
+// Unlike UiAmount, it's exact for any amount.
+func (a UiTokenAmount) BigInt() (*big.Int, error) {
+	if a.Amount == "" || strings.TrimLeft(a.Amount, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid token amount %q: not an unsigned integer", a.Amount)
+	}
+	amount, ok := new(big.Int).SetString(a.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid token amount %q", a.Amount)
+	}
+	return amount, nil
+}
+
+// Uint64 returns the raw amount of tokens (i.e. Amount, ignoring decimals),
+// or an error if it doesn't fit into a uint64.
+func (a UiTokenAmount) Uint64() (uint64, error) {
+	amount, err := a.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !amount.IsUint64() {
+		return 0, fmt.Errorf("token amount %s overflows uint64", a.Amount)
+	}
+	return amount.Uint64(), nil
+}
+
+// Decimal returns the exact amount of tokens accounting for decimals,
+// computed from Amount and Decimals without going through a float,
+// e.g. "1.5" for an Amount of "1500000" with 6 decimals.
+// Trailing zeros of the fraction are dropped (like in UiAmountString).
+func (a UiTokenAmount) Decimal() (string, error) {
+	amount, err := a.BigInt()
+	if err != nil {
+		return "", err
+	}
+	digits := amount.String()
+	if a.Decimals == 0 {
+		return digits, nil
+	}
+	decimals := int(a.Decimals)
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole := digits[:len(digits)-decimals]
+	fraction := strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		return whole, nil
+	}
+	return whole + "." + fraction, nil
+}
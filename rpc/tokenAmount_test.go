@@ -0,0 +1,73 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUiTokenAmount(t *testing.T) {
+	tests := []struct {
+		amount   UiTokenAmount
+		decimal  string
+		uint64   uint64
+		overflow bool
+	}{
+		{amount: UiTokenAmount{Amount: "1500000", Decimals: 6}, decimal: "1.5", uint64: 1500000},
+		{amount: UiTokenAmount{Amount: "1", Decimals: 9}, decimal: "0.000000001", uint64: 1},
+		{amount: UiTokenAmount{Amount: "0", Decimals: 6}, decimal: "0", uint64: 0},
+		{amount: UiTokenAmount{Amount: "2000000", Decimals: 6}, decimal: "2", uint64: 2000000},
+		{amount: UiTokenAmount{Amount: "42", Decimals: 0}, decimal: "42", uint64: 42},
+		{amount: UiTokenAmount{Amount: "18446744073709551615", Decimals: 0}, decimal: "18446744073709551615", uint64: 18446744073709551615},
+		{amount: UiTokenAmount{Amount: "18446744073709551616", Decimals: 0}, decimal: "18446744073709551616", overflow: true},
+		{amount: UiTokenAmount{Amount: "123456789012345678901234567890", Decimals: 9}, decimal: "123456789012345678901.23456789", overflow: true},
+	}
+	for _, test := range tests {
+		t.Run(test.amount.Amount, func(t *testing.T) {
+			decimal, err := test.amount.Decimal()
+			require.NoError(t, err)
+			assert.Equal(t, test.decimal, decimal)
+
+			amount, err := test.amount.BigInt()
+			require.NoError(t, err)
+			expected, _ := new(big.Int).SetString(test.amount.Amount, 10)
+			assert.Equal(t, 0, expected.Cmp(amount))
+
+			value, err := test.amount.Uint64()
+			if test.overflow {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "overflows uint64")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.uint64, value)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, amount := range []string{"", "-1", "1.5", "1e9", " 1"} {
+			_, err := UiTokenAmount{Amount: amount}.BigInt()
+			assert.Error(t, err, amount)
+			_, err = UiTokenAmount{Amount: amount}.Uint64()
+			assert.Error(t, err, amount)
+			_, err = UiTokenAmount{Amount: amount}.Decimal()
+			assert.Error(t, err, amount)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultTokenDecimalsCacheSize is the max number of mints
+// whose decimals are cached by a Client.
+const DefaultTokenDecimalsCacheSize = 10000
+
+// TokenDecimals returns the number of decimals of the provided token mint.
+// The decimals of a mint can't change, so they are fetched (with getTokenSupply)
+// only the first time, and then cached by the client;
+// the least recently used mints are evicted when the cache is full.
+func (cl *Client) TokenDecimals(
+	ctx context.Context,
+	mint solana.PublicKey,
+) (uint8, error) {
+	if cl.tokenDecimals != nil {
+		if decimals, ok := cl.tokenDecimals.get(mint); ok {
+			return decimals, nil
+		}
+	}
+	supply, err := cl.GetTokenSupply(ctx, mint, "")
+	if err != nil {
+		return 0, err
+	}
+	if supply == nil || supply.Value == nil {
+		return 0, fmt.Errorf("no token supply returned for mint %s", mint)
+	}
+	if cl.tokenDecimals != nil {
+		cl.tokenDecimals.add(mint, supply.Value.Decimals)
+	}
+	return supply.Value.Decimals, nil
+}
+
+// tokenDecimalsCache is a concurrency-safe LRU cache of mint decimals.
+type tokenDecimalsCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List // front is the most recently used
+	entries map[solana.PublicKey]*list.Element
+}
+
+type tokenDecimalsEntry struct {
+	mint     solana.PublicKey
+	decimals uint8
+}
+
+func newTokenDecimalsCache(max int) *tokenDecimalsCache {
+	return &tokenDecimalsCache{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[solana.PublicKey]*list.Element),
+	}
+}
+
+func (c *tokenDecimalsCache) get(mint solana.PublicKey) (uint8, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[mint]
+	if !ok {
+		return 0, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*tokenDecimalsEntry).decimals, true
+}
+
+func (c *tokenDecimalsCache) add(mint solana.PublicKey, decimals uint8) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[mint]; ok {
+		elem.Value.(*tokenDecimalsEntry).decimals = decimals
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[mint] = c.order.PushFront(&tokenDecimalsEntry{mint: mint, decimals: decimals})
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenDecimalsEntry).mint)
+	}
+}
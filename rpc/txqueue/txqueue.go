@@ -0,0 +1,438 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package txqueue sends many independent transactions concurrently,
+// in priority order, taking care of their recent blockhash (or durable nonce),
+// signing, rebroadcasting and confirmation.
+package txqueue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	DefaultConcurrency = 4
+	// How long a blockhash returned by getLatestBlockhash is used for new transactions.
+	DefaultBlockhashMaxAge = 20 * time.Second
+	DefaultEventBufferSize = 256
+)
+
+type EventType string
+
+const (
+	// The item was added to the queue.
+	EventQueued EventType = "queued"
+	// The transaction was built and signed, and is being sent.
+	EventSent EventType = "sent"
+	// The transaction was confirmed (at the commitment of the rebroadcast options).
+	EventConfirmed EventType = "confirmed"
+	// The deadline of the item passed, or the blockhash of the transaction expired,
+	// before the transaction was confirmed.
+	EventExpired EventType = "expired"
+	// The transaction could not be built, signed or sent, or it failed on chain.
+	EventFailed EventType = "failed"
+)
+
+// Event reports a step of the lifecycle of an item.
+// Every item gets EventQueued, then (unless it expires or fails before being sent)
+// EventSent, and then exactly one of EventConfirmed, EventExpired or EventFailed.
+type Event struct {
+	// The ID returned by Enqueue.
+	ID   uint64
+	Type EventType
+	// The signature of the transaction; zero before EventSent.
+	Signature solana.Signature
+	// Why the item expired or failed.
+	// When the blockhash expired, it wraps solana.ErrBlockhashExpired;
+	// when the deadline of the item passed, it wraps context.DeadlineExceeded.
+	Err error
+}
+
+// Item is a bundle of instructions sent in its own transaction.
+type Item struct {
+	Instructions []solana.Instruction
+	// The signers of the transaction; the nonce authority
+	// doesn't need to be included.
+	Signers []solana.PrivateKey
+	// The fee payer. Defaults to the first signer.
+	Payer solana.PublicKey
+	// The items with a higher priority are sent first;
+	// the items with the same priority are sent in the order they were enqueued.
+	Priority int
+	// If set, the item expires if the transaction is not confirmed by then
+	// (including the time spent in the queue, or waiting for a nonce account).
+	Deadline time.Time
+}
+
+// NonceAccount is a durable nonce account managed by the queue.
+type NonceAccount struct {
+	Address   solana.PublicKey
+	Authority solana.PrivateKey
+}
+
+type Options struct {
+	// Max number of items processed at the same time. Defaults to DefaultConcurrency.
+	Concurrency int
+	// If set, every transaction uses a durable nonce from this pool
+	// instead of a recent blockhash. A nonce account is used by one
+	// transaction at a time; when all of them are in use, the items wait
+	// for one to be released (or for their deadline).
+	NonceAccounts []NonceAccount
+	// How long a recent blockhash is reused. Defaults to DefaultBlockhashMaxAge.
+	BlockhashMaxAge time.Duration
+	// How the transactions are sent and confirmed; see rpc.Client.SendAndRebroadcast.
+	// The commitment is also used to get the blockhashes and the nonces.
+	Rebroadcast rpc.RebroadcastOpts
+	// Size of the buffer of the Events channel. Defaults to DefaultEventBufferSize.
+	EventBufferSize int
+}
+
+type queuedItem struct {
+	id uint64
+	Item
+}
+
+// itemHeap orders the items by priority, then by ID.
+type itemHeap []*queuedItem
+
+func (h itemHeap) Len() int { return len(h) }
+func (h itemHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].id < h[j].id
+}
+func (h itemHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *itemHeap) Push(x interface{}) { *h = append(*h, x.(*queuedItem)) }
+func (h *itemHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
+
+type cachedBlockhash struct {
+	hash                 solana.Hash
+	lastValidBlockHeight uint64
+	fetchedAt            time.Time
+}
+
+// Queue is a priority queue of transactions.
+// The methods are safe for concurrent use.
+type Queue struct {
+	client *rpc.Client
+	opts   Options
+	events chan Event
+
+	mu     sync.Mutex
+	items  itemHeap
+	nextID uint64
+	// Signaled when an item is enqueued.
+	wake chan struct{}
+
+	blockhashMu sync.Mutex
+	blockhash   *cachedBlockhash
+
+	// The nonce accounts that are not in use.
+	nonces chan NonceAccount
+}
+
+// New creates a queue; opts can be nil.
+// The items are processed only while Run is running.
+func New(client *rpc.Client, opts *Options) *Queue {
+	q := &Queue{
+		client: client,
+		wake:   make(chan struct{}, 1),
+	}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.Concurrency <= 0 {
+		q.opts.Concurrency = DefaultConcurrency
+	}
+	if q.opts.BlockhashMaxAge <= 0 {
+		q.opts.BlockhashMaxAge = DefaultBlockhashMaxAge
+	}
+	if q.opts.Rebroadcast.Commitment == "" {
+		q.opts.Rebroadcast.Commitment = rpc.CommitmentConfirmed
+	}
+	if q.opts.EventBufferSize <= 0 {
+		q.opts.EventBufferSize = DefaultEventBufferSize
+	}
+	q.events = make(chan Event, q.opts.EventBufferSize)
+	q.nonces = make(chan NonceAccount, len(q.opts.NonceAccounts))
+	for _, nonce := range q.opts.NonceAccounts {
+		q.nonces <- nonce
+	}
+	return q
+}
+
+// Events returns the channel on which the lifecycle events of the items are sent.
+// The events must be received: once the buffer is full, the queue blocks.
+func (q *Queue) Events() <-chan Event {
+	return q.events
+}
+
+// Enqueue adds the item to the queue, and returns its ID.
+func (q *Queue) Enqueue(item Item) uint64 {
+	q.mu.Lock()
+	q.nextID++
+	id := q.nextID
+	q.mu.Unlock()
+
+	// Sent before the item can be processed, so that it is always the first event.
+	q.events <- Event{ID: id, Type: EventQueued}
+
+	q.mu.Lock()
+	heap.Push(&q.items, &queuedItem{id: id, Item: item})
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return id
+}
+
+// Len returns the number of items waiting to be processed.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func (q *Queue) pop() *queuedItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil
+	}
+	return heap.Pop(&q.items).(*queuedItem)
+}
+
+// Run processes the items, up to Concurrency at a time, until the context is done.
+// The items still in the queue are kept, and processed by the next Run;
+// the items being processed when the context is done fail with the context error.
+func (q *Queue) Run(ctx context.Context) error {
+	sem := make(chan struct{}, q.opts.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		var item *queuedItem
+		for {
+			// Leave the items in the queue once the context is done.
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if item = q.pop(); item != nil {
+				break
+			}
+			select {
+			case <-q.wake:
+			case <-ctx.Done():
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			q.process(ctx, item)
+		}()
+	}
+}
+
+func (q *Queue) process(ctx context.Context, item *queuedItem) {
+	itemCtx := ctx
+	if !item.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		itemCtx, cancel = context.WithDeadline(ctx, item.Deadline)
+		defer cancel()
+	}
+	// Reports the item as expired if its own deadline passed.
+	fail := func(sig solana.Signature, err error) {
+		event := Event{ID: item.id, Type: EventFailed, Signature: sig, Err: err}
+		if ctx.Err() == nil && itemCtx.Err() != nil {
+			event.Type = EventExpired
+			event.Err = fmt.Errorf("deadline of item %d passed: %w", item.id, itemCtx.Err())
+		}
+		q.events <- event
+	}
+
+	if itemCtx.Err() != nil {
+		fail(solana.Signature{}, itemCtx.Err())
+		return
+	}
+
+	var nonce *NonceAccount
+	if len(q.opts.NonceAccounts) > 0 {
+		select {
+		case n := <-q.nonces:
+			nonce = &n
+			defer func() { q.nonces <- n }()
+		case <-itemCtx.Done():
+			fail(solana.Signature{}, itemCtx.Err())
+			return
+		}
+	}
+
+	tx, err := q.buildTransaction(itemCtx, item, nonce)
+	if err != nil {
+		fail(solana.Signature{}, err)
+		return
+	}
+	sig := tx.Signatures[0]
+	q.events <- Event{ID: item.id, Type: EventSent, Signature: sig}
+
+	_, _, err = q.client.SendAndRebroadcast(itemCtx, tx, q.opts.Rebroadcast)
+	switch {
+	case err == nil:
+		q.events <- Event{ID: item.id, Type: EventConfirmed, Signature: sig}
+	case errors.Is(err, solana.ErrBlockhashExpired):
+		q.invalidateBlockhash(tx.Message.RecentBlockhash)
+		q.events <- Event{ID: item.id, Type: EventExpired, Signature: sig, Err: err}
+	default:
+		fail(sig, err)
+	}
+}
+
+// buildTransaction builds and signs the transaction of the item, with the
+// nonce of the provided account (if not nil) or a recent blockhash.
+func (q *Queue) buildTransaction(ctx context.Context, item *queuedItem, nonce *NonceAccount) (*solana.Transaction, error) {
+	if len(item.Signers) == 0 {
+		return nil, errors.New("item has no signers")
+	}
+	payer := item.Payer
+	if payer.IsZero() {
+		payer = item.Signers[0].PublicKey()
+	}
+
+	instructions := item.Instructions
+	var recentBlockhash solana.Hash
+	var lastValidBlockHeight uint64
+	if nonce != nil {
+		value, err := q.getNonce(ctx, nonce.Address)
+		if err != nil {
+			return nil, err
+		}
+		recentBlockhash = value
+		// The nonce instruction must be the first one.
+		instructions = append([]solana.Instruction{
+			system.NewAdvanceNonceAccountInstruction(
+				nonce.Address,
+				solana.SysVarRecentBlockHashesPubkey,
+				nonce.Authority.PublicKey(),
+			).Build(),
+		}, instructions...)
+	} else {
+		var err error
+		recentBlockhash, lastValidBlockHeight, err = q.getBlockhash(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := solana.NewTransaction(
+		instructions,
+		recentBlockhash,
+		solana.TransactionPayer(payer),
+		solana.TransactionLastValidBlockHeight(lastValidBlockHeight),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build transaction: %w", err)
+	}
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if nonce != nil && key.Equals(nonce.Authority.PublicKey()) {
+			return &nonce.Authority
+		}
+		for i := range item.Signers {
+			if key.Equals(item.Signers[i].PublicKey()) {
+				return &item.Signers[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// getBlockhash returns the cached blockhash, or a new one
+// if the cached one is older than BlockhashMaxAge.
+func (q *Queue) getBlockhash(ctx context.Context) (solana.Hash, uint64, error) {
+	q.blockhashMu.Lock()
+	defer q.blockhashMu.Unlock()
+	if q.blockhash != nil && time.Since(q.blockhash.fetchedAt) < q.opts.BlockhashMaxAge {
+		return q.blockhash.hash, q.blockhash.lastValidBlockHeight, nil
+	}
+	out, err := q.client.GetLatestBlockhash(ctx, q.opts.Rebroadcast.Commitment)
+	if err != nil {
+		return solana.Hash{}, 0, fmt.Errorf("unable to get latest blockhash: %w", err)
+	}
+	if out.Value == nil {
+		return solana.Hash{}, 0, errors.New("unable to get latest blockhash: no value")
+	}
+	q.blockhash = &cachedBlockhash{
+		hash:                 out.Value.Blockhash,
+		lastValidBlockHeight: out.Value.LastValidBlockHeight,
+		fetchedAt:            time.Now(),
+	}
+	return q.blockhash.hash, q.blockhash.lastValidBlockHeight, nil
+}
+
+// invalidateBlockhash makes the next transactions use a new blockhash,
+// if the cached one is the provided (expired) one.
+func (q *Queue) invalidateBlockhash(expired solana.Hash) {
+	q.blockhashMu.Lock()
+	defer q.blockhashMu.Unlock()
+	if q.blockhash != nil && q.blockhash.hash.Equals(expired) {
+		q.blockhash = nil
+	}
+}
+
+// getNonce returns the current nonce of the nonce account.
+func (q *Queue) getNonce(ctx context.Context, address solana.PublicKey) (solana.Hash, error) {
+	out, err := q.client.GetAccountInfoWithOpts(ctx, address, &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: q.opts.Rebroadcast.Commitment,
+	})
+	if err != nil {
+		return solana.Hash{}, fmt.Errorf("unable to get nonce account %s: %w", address, err)
+	}
+	var account system.NonceAccount
+	if err := bin.NewBinDecoder(out.Value.Data.GetBinary()).Decode(&account); err != nil {
+		return solana.Hash{}, fmt.Errorf("unable to decode nonce account %s: %w", address, err)
+	}
+	if account.State != 1 {
+		return solana.Hash{}, fmt.Errorf("nonce account %s is not initialized", address)
+	}
+	return solana.Hash(account.Nonce), nil
+}
@@ -0,0 +1,396 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txqueue
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCluster serves the methods used by the queue; the tests decide
+// the status of each sent transaction with `status`.
+type mockCluster struct {
+	mu                   sync.Mutex
+	blockhash            solana.Hash
+	lastValidBlockHeight uint64
+	blockHeight          uint64
+	nonceAccount         solana.PublicKey
+	nonceAuthority       solana.PublicKey
+	nonce                solana.Hash
+	blockhashRequests    int
+	sent                 map[solana.Signature]*solana.Transaction
+	// Returns "" if not landed (yet), "confirmed", or "failed".
+	status func(tx *solana.Transaction) string
+	// Called on each sendTransaction.
+	onSend func(tx *solana.Transaction)
+}
+
+func newMockCluster(t *testing.T) (*mockCluster, *rpc.Client) {
+	m := &mockCluster{
+		blockhash:            solana.Hash(solana.NewWallet().PublicKey()),
+		lastValidBlockHeight: 150,
+		blockHeight:          100,
+		sent:                 make(map[solana.Signature]*solana.Transaction),
+		status:               func(*solana.Transaction) string { return "confirmed" },
+	}
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+	return m, rpc.New(server.URL)
+}
+
+func (m *mockCluster) update(f func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f()
+}
+
+func (m *mockCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params []interface{}   `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result string
+	switch req.Method {
+	case "getLatestBlockhash":
+		m.blockhashRequests++
+		result = fmt.Sprintf(
+			`{"context":{"slot":1},"value":{"blockhash":%q,"lastValidBlockHeight":%d}}`,
+			m.blockhash,
+			m.lastValidBlockHeight,
+		)
+	case "getBlockHeight":
+		result = fmt.Sprintf("%d", m.blockHeight)
+	case "getAccountInfo":
+		if req.Params[0] != m.nonceAccount.String() {
+			result = `{"context":{"slot":1},"value":null}`
+			break
+		}
+		buf := new(bytes.Buffer)
+		bin.NewBinEncoder(buf).Encode(system.NonceAccount{
+			State:            1,
+			AuthorizedPubkey: m.nonceAuthority,
+			Nonce:            solana.PublicKey(m.nonce),
+		})
+		result = fmt.Sprintf(
+			`{"context":{"slot":1},"value":{"data":[%q,"base64"],"executable":false,"lamports":1447680,"owner":%q,"rentEpoch":0}}`,
+			base64.StdEncoding.EncodeToString(buf.Bytes()),
+			solana.SystemProgramID,
+		)
+	case "sendTransaction":
+		data, _ := base64.StdEncoding.DecodeString(req.Params[0].(string))
+		tx, err := solana.TransactionFromBytes(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := m.sent[tx.Signatures[0]]; !ok && m.onSend != nil {
+			m.onSend(tx)
+		}
+		m.sent[tx.Signatures[0]] = tx
+		result = fmt.Sprintf("%q", tx.Signatures[0])
+	case "getSignatureStatuses":
+		var statuses []string
+		for _, sig := range req.Params[0].([]interface{}) {
+			tx, ok := m.sent[solana.MustSignatureFromBase58(sig.(string))]
+			status := ""
+			if ok {
+				status = m.status(tx)
+			}
+			switch status {
+			case "confirmed":
+				statuses = append(statuses, `{"slot":1,"confirmations":null,"err":null,"confirmationStatus":"confirmed"}`)
+			case "failed":
+				statuses = append(statuses, `{"slot":1,"confirmations":null,"err":{"InstructionError":[0,"Custom"]},"confirmationStatus":"confirmed"}`)
+			default:
+				statuses = append(statuses, "null")
+			}
+		}
+		result = fmt.Sprintf(`{"context":{"slot":1},"value":[%s]}`, strings.Join(statuses, ","))
+	default:
+		http.Error(w, "unexpected method "+req.Method, http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+}
+
+func (m *mockCluster) transaction(sig solana.Signature) *solana.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sent[sig]
+}
+
+func testOptions() *Options {
+	return &Options{
+		Rebroadcast: rpc.RebroadcastOpts{Interval: 5 * time.Millisecond},
+	}
+}
+
+func transferItem(from solana.PrivateKey, priority int) Item {
+	return Item{
+		Instructions: []solana.Instruction{
+			system.NewTransferInstruction(1, from.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		},
+		Signers:  []solana.PrivateKey{from},
+		Priority: priority,
+	}
+}
+
+// nextEvent returns the next event, failing the test after a while.
+func nextEvent(t *testing.T, q *Queue) Event {
+	t.Helper()
+	select {
+	case event := <-q.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event")
+		return Event{}
+	}
+}
+
+func runQueue(t *testing.T, q *Queue) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+	})
+}
+
+func TestQueue_priorityAndBlockhashCache(t *testing.T) {
+	cluster, client := newMockCluster(t)
+	opts := testOptions()
+	opts.Concurrency = 1
+	q := New(client, opts)
+
+	signer := solana.NewWallet().PrivateKey
+	first := q.Enqueue(transferItem(signer, 0))
+	urgent := q.Enqueue(transferItem(signer, 5))
+	last := q.Enqueue(transferItem(signer, 0))
+	for _, id := range []uint64{first, urgent, last} {
+		assert.Equal(t, Event{ID: id, Type: EventQueued}, nextEvent(t, q))
+	}
+	assert.Equal(t, 3, q.Len())
+
+	runQueue(t, q)
+
+	// One at a time, by priority.
+	for _, id := range []uint64{urgent, first, last} {
+		sent := nextEvent(t, q)
+		assert.Equal(t, id, sent.ID)
+		assert.Equal(t, EventSent, sent.Type)
+		assert.Equal(t, Event{ID: id, Type: EventConfirmed, Signature: sent.Signature}, nextEvent(t, q))
+
+		tx := cluster.transaction(sent.Signature)
+		require.NotNil(t, tx)
+		assert.Equal(t, cluster.blockhash, tx.Message.RecentBlockhash)
+		assert.Equal(t, signer.PublicKey(), tx.Message.AccountKeys[0])
+		assert.NoError(t, tx.VerifySignatures())
+	}
+	assert.Equal(t, 0, q.Len())
+	// The blockhash is cached.
+	assert.Equal(t, 1, cluster.blockhashRequests)
+}
+
+func TestQueue_blockhashExpiry(t *testing.T) {
+	cluster, client := newMockCluster(t)
+	q := New(client, testOptions())
+
+	// The first transaction never lands, and its blockhash expires while it is rebroadcast.
+	expired := cluster.blockhash
+	fresh := solana.Hash(solana.NewWallet().PublicKey())
+	cluster.update(func() {
+		cluster.status = func(tx *solana.Transaction) string {
+			if tx.Message.RecentBlockhash.Equals(fresh) {
+				return "confirmed"
+			}
+			return ""
+		}
+		cluster.onSend = func(tx *solana.Transaction) {
+			cluster.blockHeight = 151
+			cluster.blockhash = fresh
+			cluster.lastValidBlockHeight = 300
+		}
+	})
+	runQueue(t, q)
+
+	signer := solana.NewWallet().PrivateKey
+	id := q.Enqueue(transferItem(signer, 0))
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	sent := nextEvent(t, q)
+	assert.Equal(t, EventSent, sent.Type)
+	event := nextEvent(t, q)
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, EventExpired, event.Type)
+	assert.Equal(t, sent.Signature, event.Signature)
+	assert.True(t, errors.Is(event.Err, solana.ErrBlockhashExpired), event.Err)
+	assert.Equal(t, expired, cluster.transaction(sent.Signature).Message.RecentBlockhash)
+
+	// The expired blockhash is not reused.
+	id = q.Enqueue(transferItem(signer, 0))
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	sent = nextEvent(t, q)
+	assert.Equal(t, EventSent, sent.Type)
+	assert.Equal(t, Event{ID: id, Type: EventConfirmed, Signature: sent.Signature}, nextEvent(t, q))
+	assert.Equal(t, fresh, cluster.transaction(sent.Signature).Message.RecentBlockhash)
+	assert.Equal(t, 2, cluster.blockhashRequests)
+}
+
+func TestQueue_noncePool(t *testing.T) {
+	cluster, client := newMockCluster(t)
+	nonce := NonceAccount{
+		Address:   solana.NewWallet().PublicKey(),
+		Authority: solana.NewWallet().PrivateKey,
+	}
+	firstNonce := solana.Hash(solana.NewWallet().PublicKey())
+	secondNonce := solana.Hash(solana.NewWallet().PublicKey())
+	land := make(chan struct{})
+	cluster.update(func() {
+		cluster.nonceAccount = nonce.Address
+		cluster.nonceAuthority = nonce.Authority.PublicKey()
+		cluster.nonce = firstNonce
+		cluster.status = func(*solana.Transaction) string {
+			select {
+			case <-land:
+				return "confirmed"
+			default:
+				return ""
+			}
+		}
+	})
+
+	opts := testOptions()
+	opts.Concurrency = 3
+	opts.NonceAccounts = []NonceAccount{nonce}
+	q := New(client, opts)
+	runQueue(t, q)
+
+	signer := solana.NewWallet().PrivateKey
+	first := q.Enqueue(transferItem(signer, 0))
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	sent := nextEvent(t, q)
+	assert.Equal(t, Event{ID: first, Type: EventSent, Signature: sent.Signature}, sent)
+
+	// The only nonce account is in use: the next items wait for it,
+	// and the one with a deadline expires without being sent.
+	impatient := transferItem(signer, 10)
+	impatient.Deadline = time.Now().Add(50 * time.Millisecond)
+	impatientID := q.Enqueue(impatient)
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	patient := q.Enqueue(transferItem(signer, 0))
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+
+	event := nextEvent(t, q)
+	assert.Equal(t, impatientID, event.ID)
+	assert.Equal(t, EventExpired, event.Type)
+	assert.True(t, errors.Is(event.Err, context.DeadlineExceeded), event.Err)
+	assert.True(t, event.Signature.IsZero())
+
+	// The first transaction lands (and advances the nonce): the nonce account is released.
+	cluster.update(func() { cluster.nonce = secondNonce })
+	close(land)
+	assert.Equal(t, Event{ID: first, Type: EventConfirmed, Signature: sent.Signature}, nextEvent(t, q))
+	second := nextEvent(t, q)
+	assert.Equal(t, patient, second.ID)
+	assert.Equal(t, EventSent, second.Type)
+	assert.Equal(t, Event{ID: patient, Type: EventConfirmed, Signature: second.Signature}, nextEvent(t, q))
+
+	for sig, expectedNonce := range map[solana.Signature]solana.Hash{
+		sent.Signature:   firstNonce,
+		second.Signature: secondNonce,
+	} {
+		tx := cluster.transaction(sig)
+		require.NotNil(t, tx)
+		assert.Equal(t, expectedNonce, tx.Message.RecentBlockhash)
+		assert.NoError(t, tx.VerifySignatures())
+		// The nonce is advanced by the first instruction.
+		program, err := tx.Message.Program(tx.Message.Instructions[0].ProgramIDIndex)
+		require.NoError(t, err)
+		assert.Equal(t, solana.SystemProgramID, program)
+		accounts, err := tx.Message.Instructions[0].ResolveInstructionAccounts(&tx.Message)
+		require.NoError(t, err)
+		assert.Equal(t, nonce.Address, accounts[0].PublicKey)
+		assert.Equal(t, nonce.Authority.PublicKey(), accounts[2].PublicKey)
+		assert.True(t, accounts[2].IsSigner)
+	}
+	// Nonces are used instead of blockhashes.
+	assert.Equal(t, 0, cluster.blockhashRequests)
+}
+
+func TestQueue_failures(t *testing.T) {
+	cluster, client := newMockCluster(t)
+	cluster.update(func() {
+		cluster.status = func(*solana.Transaction) string { return "failed" }
+	})
+	q := New(client, testOptions())
+	runQueue(t, q)
+
+	// Fails on chain.
+	id := q.Enqueue(transferItem(solana.NewWallet().PrivateKey, 0))
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	sent := nextEvent(t, q)
+	assert.Equal(t, EventSent, sent.Type)
+	event := nextEvent(t, q)
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, EventFailed, event.Type)
+	assert.Equal(t, sent.Signature, event.Signature)
+	assert.Contains(t, event.Err.Error(), "InstructionError")
+
+	// Can't be signed: fails before being sent.
+	item := transferItem(solana.NewWallet().PrivateKey, 0)
+	item.Signers = []solana.PrivateKey{solana.NewWallet().PrivateKey}
+	id = q.Enqueue(item)
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	event = nextEvent(t, q)
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, EventFailed, event.Type)
+	assert.True(t, event.Signature.IsZero())
+	assert.Contains(t, event.Err.Error(), "unable to sign transaction")
+
+	// The deadline already passed.
+	item = transferItem(solana.NewWallet().PrivateKey, 0)
+	item.Deadline = time.Now().Add(-time.Second)
+	id = q.Enqueue(item)
+	assert.Equal(t, EventQueued, nextEvent(t, q).Type)
+	event = nextEvent(t, q)
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, EventExpired, event.Type)
+	assert.True(t, errors.Is(event.Err, context.DeadlineExceeded), event.Err)
+}
@@ -20,34 +20,82 @@ package rpc
 import (
 	"encoding/base64"
 	stdjson "encoding/json"
+	"errors"
 	"fmt"
 
 	bin "github.com/gagliardetto/binary"
+	"github.com/mr-tron/base58"
 
 	"github.com/gagliardetto/solana-go"
 )
 
 type Context struct {
 	Slot uint64 `json:"slot"`
+
+	// The version of the RPC API of the node (e.g. "1.18.1"),
+	// if returned by the node.
+	APIVersion string `json:"apiVersion,omitempty"`
 }
 
+// RPCContext is embedded in the results of the methods that return
+// their value along with the context in which it was evaluated,
+// i.e. `{"context": {...}, "value": ...}`.
 type RPCContext struct {
 	Context Context `json:"context,omitempty"`
 }
 
+// GetContext returns the context in which the value of the result was evaluated.
+func (c RPCContext) GetContext() Context {
+	return c.Context
+}
+
+// ResultWithContext is implemented by all the results that embed RPCContext.
+type ResultWithContext interface {
+	GetContext() Context
+}
+
+// withContext is the envelope shared by the results with a context:
+// it decodes the context into the RPCContext of the result,
+// and the value into Value, a pointer to the Value field of the result.
+type withContext struct {
+	*RPCContext
+	Value interface{}
+}
+
+func (w withContext) UnmarshalJSON(data []byte) error {
+	var in struct {
+		Context Context            `json:"context"`
+		Value   stdjson.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	w.Context = in.Context
+	if len(in.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(in.Value, w.Value)
+}
+
 type GetBalanceResult struct {
 	RPCContext
 	Value uint64 `json:"value"`
 }
 
+func (res *GetBalanceResult) UnmarshalJSON(data []byte) error {
+	return withContext{&res.RPCContext, &res.Value}.UnmarshalJSON(data)
+}
+
 type GetRecentBlockhashResult struct {
 	RPCContext
 	Value *BlockhashResult `json:"value"`
 }
 
 type BlockhashResult struct {
-	Blockhash     solana.Hash   `json:"blockhash"`
-	FeeCalculator FeeCalculator `json:"feeCalculator"`
+	Blockhash solana.Hash `json:"blockhash"`
+	// DEPRECATED: nil if the node doesn't return it;
+	// use Client.LamportsPerSignature instead.
+	FeeCalculator *FeeCalculator `json:"feeCalculator,omitempty"`
 }
 
 type FeeCalculator struct {
@@ -132,7 +180,12 @@ func (twm TransactionWithMeta) MustGetTransaction() *solana.Transaction {
 	return tx
 }
 
+// GetTransaction decodes the transaction; it returns an error if there is none,
+// e.g. in a block requested with TransactionDetailsSignatures.
 func (twm TransactionWithMeta) GetTransaction() (*solana.Transaction, error) {
+	if twm.Transaction == nil {
+		return nil, fmt.Errorf("transaction is nil")
+	}
 	tx := new(solana.Transaction)
 	err := tx.UnmarshalWithDecoder(bin.NewBinDecoder(twm.Transaction.GetBinary()))
 	if err != nil {
@@ -167,6 +220,7 @@ type UiTokenAmount struct {
 	Decimals uint8 `json:"decimals"`
 
 	// DEPRECATED: Token amount as a float, accounting for decimals.
+	// It's rounded for large amounts: use Decimal, BigInt or Uint64 instead.
 	UiAmount *float64 `json:"uiAmount"`
 
 	// Token amount as a string, accounting for decimals.
@@ -256,6 +310,10 @@ type GetAccountInfoResult struct {
 	Value *Account `json:"value"`
 }
 
+func (res *GetAccountInfoResult) UnmarshalJSON(data []byte) error {
+	return withContext{&res.RPCContext, &res.Value}.UnmarshalJSON(data)
+}
+
 // GetBinary returns the binary representation of the account data.
 func (a *GetAccountInfoResult) GetBinary() []byte {
 	if a == nil {
@@ -297,6 +355,11 @@ type Account struct {
 	RentEpoch uint64 `json:"rentEpoch"`
 }
 
+// encodingLegacyBinary is the encoding of the data returned as a bare base58 string:
+// by older nodes, for the "binary" encoding, and for the accounts that
+// can't be parsed when "jsonParsed" is requested.
+const encodingLegacyBinary solana.EncodingType = "binary"
+
 type DataBytesOrJSON struct {
 	rawDataEncoding solana.EncodingType
 	asDecodedBinary solana.Data
@@ -322,14 +385,37 @@ func DataBytesOrJSONFromBytes(data []byte) *DataBytesOrJSON {
 	}
 }
 
+// MarshalJSON encodes the data as returned by the RPC, so that
+// unmarshaling and marshaling again gives an equivalent payload:
+// the parsed object for "jsonParsed", the `["<data>", "<encoding>"]` tuple
+// for the binary encodings (base64 if the encoding is unknown),
+// the base58 string of the older nodes, and null if there is no data.
 func (dt DataBytesOrJSON) MarshalJSON() ([]byte, error) {
-	if dt.rawDataEncoding == solana.EncodingJSONParsed || dt.rawDataEncoding == solana.EncodingJSON {
+	switch dt.rawDataEncoding {
+	case solana.EncodingJSONParsed, solana.EncodingJSON:
+		if dt.asJSON == nil {
+			return []byte("null"), nil
+		}
 		return json.Marshal(dt.asJSON)
+	case encodingLegacyBinary:
+		return json.Marshal(base58.Encode(dt.asDecodedBinary.Content))
+	case "":
+		if dt.asDecodedBinary.Encoding == "" && dt.asDecodedBinary.Content == nil {
+			return []byte("null"), nil
+		}
+	}
+	data := dt.asDecodedBinary
+	switch data.Encoding {
+	case solana.EncodingBase58, solana.EncodingBase64, solana.EncodingBase64Zstd:
+	default:
+		data.Encoding = solana.EncodingBase64
 	}
-	return json.Marshal(dt.asDecodedBinary)
+	return json.Marshal(data)
 }
 
 func (wrap *DataBytesOrJSON) UnmarshalJSON(data []byte) error {
+	// Don't keep anything from a previous value.
+	*wrap = DataBytesOrJSON{}
 	if len(data) == 0 || (len(data) == 4 && string(data) == "null") {
 		// TODO: is this an error?
 		return nil
@@ -356,6 +442,23 @@ func (wrap *DataBytesOrJSON) UnmarshalJSON(data []byte) error {
 			wrap.asJSON = data
 			wrap.rawDataEncoding = solana.EncodingJSONParsed
 		}
+	case '"':
+		// A base58 string, from an older node.
+		{
+			var encoded string
+			if err := json.Unmarshal(data, &encoded); err != nil {
+				return err
+			}
+			content, err := base58.Decode(encoded)
+			if err != nil {
+				return fmt.Errorf("unable to decode base58 data: %w", err)
+			}
+			wrap.asDecodedBinary = solana.Data{
+				Encoding: solana.EncodingBase58,
+				Content:  content,
+			}
+			wrap.rawDataEncoding = encodingLegacyBinary
+		}
 	default:
 		return fmt.Errorf("unknown kind: %v", data)
 	}
@@ -365,10 +468,27 @@ func (wrap *DataBytesOrJSON) UnmarshalJSON(data []byte) error {
 
 // GetBinary returns the decoded bytes if the encoding is
 // "base58", "base64", or "base64+zstd".
+// For JSON data (see GetRawJSON) it returns no bytes: use GetBinaryOrErr
+// to get an error instead.
 func (dt *DataBytesOrJSON) GetBinary() []byte {
 	return dt.asDecodedBinary.Content
 }
 
+// ErrNotBinaryData is returned by DataBytesOrJSON.GetBinaryOrErr
+// when the data is JSON, so it has no binary representation.
+var ErrNotBinaryData = errors.New("account data is JSON, not binary")
+
+// GetBinaryOrErr is like GetBinary, but it returns ErrNotBinaryData if the data is JSON
+// (e.g. with the "jsonParsed" encoding, when the node has a parser for the account),
+// instead of no bytes, which would be decoded into a zero value.
+func (dt *DataBytesOrJSON) GetBinaryOrErr() ([]byte, error) {
+	switch dt.rawDataEncoding {
+	case solana.EncodingJSONParsed, solana.EncodingJSON:
+		return nil, ErrNotBinaryData
+	}
+	return dt.asDecodedBinary.Content, nil
+}
+
 // GetRawJSON returns a stdjson.RawMessage when the data
 // encoding is "jsonParsed".
 func (dt *DataBytesOrJSON) GetRawJSON() stdjson.RawMessage {
@@ -391,10 +511,19 @@ type GetProgramAccountsOpts struct {
 	// Filter results using various filter objects;
 	// account must meet all filter criteria to be included in results.
 	Filters []RPCFilter `json:"filters,omitempty"`
+
+	// The minimum slot that the request can be evaluated at.
+	// This parameter is optional.
+	MinContextSlot *uint64 `json:"minContextSlot,omitempty"`
 }
 
 type GetProgramAccountsResult []*KeyedAccount
 
+type GetProgramAccountsWithContextResult struct {
+	RPCContext
+	Value GetProgramAccountsResult `json:"value"`
+}
+
 type KeyedAccount struct {
 	Pubkey  solana.PublicKey `json:"pubkey"`
 	Account *Account         `json:"account"`
@@ -477,6 +606,9 @@ type ParsedTransactionMeta struct {
 	// Array of string log messages or omitted if log message
 	// recording was not yet enabled during this transaction
 	LogMessages []string `json:"logMessages"`
+
+	// Addresses loaded from address lookup tables (versioned transactions only).
+	LoadedAddresses LoadedAddresses `json:"loadedAddresses"`
 }
 
 type ParsedInnerInstruction struct {
@@ -484,16 +616,51 @@ type ParsedInnerInstruction struct {
 	Instructions []*ParsedInstruction `json:"instructions"`
 }
 
+// ParsedAccountSource tells where an account of a parsed message comes from.
+type ParsedAccountSource string
+
+const (
+	// The account is one of the account keys of the message.
+	ParsedAccountSourceTransaction ParsedAccountSource = "transaction"
+	// The account was loaded from an address lookup table.
+	ParsedAccountSourceLookupTable ParsedAccountSource = "lookupTable"
+)
+
 type ParsedMessageAccount struct {
 	PublicKey solana.PublicKey `json:"pubkey"`
 	Signer    bool             `json:"signer"`
 	Writable  bool             `json:"writable"`
+
+	// Not returned by older nodes.
+	Source ParsedAccountSource `json:"source,omitempty"`
+}
+
+// UnmarshalJSON accepts both the object form of the account
+// and the plain pubkey string returned by older nodes
+// (in which case Signer and Writable are false).
+func (acc *ParsedMessageAccount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*acc = ParsedMessageAccount{}
+		return json.Unmarshal(data, &acc.PublicKey)
+	}
+	type account ParsedMessageAccount
+	var out account
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*acc = ParsedMessageAccount(out)
+	return nil
 }
 
 type ParsedMessage struct {
+	// Includes the accounts loaded from address lookup tables
+	// (see ParsedMessageAccount.Source).
 	AccountKeys     []ParsedMessageAccount `json:"accountKeys"`
 	Instructions    []*ParsedInstruction   `json:"instructions"`
 	RecentBlockHash string                 `json:"recentBlockhash"`
+
+	// Present for versioned transactions only.
+	AddressTableLookups []solana.MessageAddressTableLookup `json:"addressTableLookups,omitempty"`
 }
 
 type ParsedInstruction struct {
@@ -16,10 +16,12 @@ package rpc
 
 import (
 	stdjson "encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestData_base64_zstd(t *testing.T) {
@@ -113,3 +115,82 @@ func TestData_DataBytesOrJSONFromBytes(t *testing.T) {
 	out := dataBytesOrJSON.GetBinary()
 	assert.Equal(t, in, out)
 }
+
+func TestData_GetBinaryOrErr(t *testing.T) {
+	for in, expected := range map[string][]byte{
+		`["dGVzdA==","base64"]`: []byte("test"),
+		`["","base64"]`:         {},
+		`"3yZe7d"`:              []byte("test"),
+		`null`:                  nil,
+	} {
+		var data DataBytesOrJSON
+		require.NoError(t, data.UnmarshalJSON([]byte(in)), in)
+		out, err := data.GetBinaryOrErr()
+		require.NoError(t, err, in)
+		assert.Equal(t, expected, out, in)
+	}
+
+	var data DataBytesOrJSON
+	require.NoError(t, data.UnmarshalJSON([]byte(`{"parsed":{"type":"account"},"program":"spl-token","space":165}`)))
+	assert.Empty(t, data.GetBinary())
+	_, err := data.GetBinaryOrErr()
+	assert.True(t, errors.Is(err, ErrNotBinaryData), err)
+}
+
+func TestData_roundTrip(t *testing.T) {
+	for _, in := range []string{
+		`["dGVzdA==","base64"]`,
+		`["","base64"]`,
+		`["3yZe7d","base58"]`,
+		`{"parsed":{"info":{"isNative":false,"mint":"So11111111111111111111111111111111111111112","tokenAmount":{"amount":"1","decimals":9}},"type":"account"},"program":"spl-token","space":165}`,
+		`null`,
+	} {
+		var data DataBytesOrJSON
+		assert.NoError(t, json.Unmarshal([]byte(in), &data), in)
+		out, err := json.Marshal(data)
+		assert.NoError(t, err, in)
+		assert.JSONEq(t, in, string(out))
+	}
+
+	// A fetched account.
+	in := `{"data":["AQIDBA==","base64"],"executable":false,"lamports":1461600,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","rentEpoch":361}`
+	var account Account
+	assert.NoError(t, json.Unmarshal([]byte(in), &account))
+	out, err := json.Marshal(account)
+	assert.NoError(t, err)
+	assert.JSONEq(t, in, string(out))
+
+	// The previous value is not kept.
+	var data DataBytesOrJSON
+	assert.NoError(t, json.Unmarshal([]byte(`{"parsed":{}}`), &data))
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &data))
+	out, err = json.Marshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(out))
+
+	// Built from bytes.
+	out, err = json.Marshal(DataBytesOrJSONFromBytes([]byte{1, 2, 3, 4}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["AQIDBA==","base64"]`, string(out))
+}
+
+func TestConfirmationStatusType_IsAtLeast(t *testing.T) {
+	for commitment, expected := range map[CommitmentType][4]bool{
+		// "", processed, confirmed, finalized
+		CommitmentProcessed: {false, true, true, true},
+		CommitmentConfirmed: {false, false, true, true},
+		CommitmentFinalized: {false, false, false, true},
+		CommitmentRecent:    {false, true, true, true},
+		CommitmentMax:       {false, false, false, true},
+		"":                  {false, false, true, true},
+	} {
+		for i, status := range []ConfirmationStatusType{
+			"",
+			ConfirmationStatusProcessed,
+			ConfirmationStatusConfirmed,
+			ConfirmationStatusFinalized,
+		} {
+			assert.Equal(t, expected[i], status.IsAtLeast(commitment), "%q at %q", status, commitment)
+		}
+	}
+}
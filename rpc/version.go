@@ -0,0 +1,75 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+const modulePath = "github.com/gagliardetto/solana-go"
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version returns the version of the solana-go module the program was built with
+// (e.g. "v1.10.0"), read from the build info of the program,
+// or "devel" if it's not available (e.g. in the tests of this module).
+func Version() string {
+	versionOnce.Do(func() {
+		version = "devel"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		module := &info.Main
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+				break
+			}
+		}
+		if module.Path != modulePath {
+			return
+		}
+		if module.Replace != nil && module.Replace.Version != "" {
+			module = module.Replace
+		}
+		if module.Version != "" && module.Version != "(devel)" {
+			version = module.Version
+		}
+	})
+	return version
+}
+
+// DefaultUserAgent is the User-Agent header of the requests
+// of the clients of this package and of the ws package, unless configured
+// otherwise (see ClientOptions.UserAgent): "solana-go/<Version()>".
+func DefaultUserAgent() string {
+	return "solana-go/" + Version()
+}
+
+// WithUserAgent returns a copy of ctx with which the requests are sent
+// with the provided User-Agent header, instead of the one of the client,
+// e.g. to identify the tenant of a multi-tenant proxy.
+// It also applies to the handshake of ws.Connect.
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return jsonrpc.WithUserAgent(ctx, userAgent)
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	// The tests of the module have no version.
+	assert.Equal(t, "devel", Version())
+	assert.Equal(t, "solana-go/devel", DefaultUserAgent())
+}
+
+func TestClient_userAgent(t *testing.T) {
+	userAgents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		userAgents <- req.Header.Get("User-Agent")
+		rw.Write([]byte(`{"jsonrpc":"2.0","result":"ok","id":0}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		client    *Client
+		ctx       context.Context
+		userAgent string
+	}{
+		{name: "default", client: New(server.URL), ctx: context.Background(), userAgent: DefaultUserAgent()},
+		{
+			name:      "option",
+			client:    NewWithOptions(server.URL, &ClientOptions{UserAgent: "indexer/1.0"}),
+			ctx:       context.Background(),
+			userAgent: "indexer/1.0",
+		},
+		{
+			name:      "header",
+			client:    NewWithOptions(server.URL, &ClientOptions{UserAgent: "indexer/1.0", Headers: map[string]string{"User-Agent": "proxy/2.0"}}),
+			ctx:       context.Background(),
+			userAgent: "proxy/2.0",
+		},
+		{
+			name:      "context",
+			client:    NewWithOptions(server.URL, &ClientOptions{UserAgent: "indexer/1.0"}),
+			ctx:       WithUserAgent(context.Background(), "tenant-42"),
+			userAgent: "tenant-42",
+		},
+		{name: "failover", client: NewWithCustomRPCClient(NewFailoverRPCClient([]string{server.URL}, nil)), ctx: context.Background(), userAgent: DefaultUserAgent()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.client.GetHealth(test.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.userAgent, <-userAgents)
+		})
+	}
+}
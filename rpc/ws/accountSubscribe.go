@@ -15,6 +15,9 @@
 package ws
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
@@ -48,16 +51,47 @@ func (cl *Client) AccountSubscribeWithOpts(
 	commitment rpc.CommitmentType,
 	encoding solana.EncodingType,
 ) (*AccountSubscription, error) {
+	return cl.AccountSubscribeWithConfig(
+		account,
+		&AccountSubscribeConfig{
+			Commitment: commitment,
+			Encoding:   encoding,
+		},
+	)
+}
+
+type AccountSubscribeConfig struct {
+	// (optional)
+	Commitment rpc.CommitmentType
+	// Encoding of the account data: "base64" (default), "base64+zstd", "base58" or "jsonParsed".
+	Encoding solana.EncodingType
+	// (optional) Limit the returned account data;
+	// only available for the "base58", "base64" or "base64+zstd" encodings.
+	DataSlice *rpc.DataSlice
+}
+
+// AccountSubscribeWithConfig subscribes to an account to receive notifications
+// when the lamports or data for a given account public key changes;
+// config can be nil.
+func (cl *Client) AccountSubscribeWithConfig(
+	account solana.PublicKey,
+	config *AccountSubscribeConfig,
+) (*AccountSubscription, error) {
 
 	params := []interface{}{account.String()}
 	conf := map[string]interface{}{
 		"encoding": "base64",
 	}
-	if commitment != "" {
-		conf["commitment"] = commitment
-	}
-	if encoding != "" {
-		conf["encoding"] = encoding
+	if config != nil {
+		if config.Commitment != "" {
+			conf["commitment"] = config.Commitment
+		}
+		if config.Encoding != "" {
+			conf["encoding"] = config.Encoding
+		}
+		if config.DataSlice != nil {
+			conf["dataSlice"] = config.DataSlice
+		}
 	}
 
 	genSub, err := cl.subscribe(
@@ -95,3 +129,144 @@ func (sw *AccountSubscription) Recv() (*AccountResult, error) {
 func (sw *AccountSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *AccountSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
+
+// AccountSnapshotSubscription is an account subscription whose first message
+// is the state of the account (see AccountSubscribeWithSnapshot).
+type AccountSnapshotSubscription struct {
+	sub      *AccountSubscription
+	snapshot *AccountResult
+	// The notifications received before the snapshot was fetched.
+	pending []*AccountResult
+	// The context slot of the snapshot.
+	slot uint64
+}
+
+// AccountSubscribeWithSnapshot subscribes to an account like AccountSubscribeWithConfig,
+// and fetches the current state of the account with the RPC client once the subscription
+// is confirmed by the node; config can be nil.
+//
+// The first message of the subscription is that snapshot, followed only by the notifications
+// with a slot strictly greater than the context slot of the snapshot: the changes up to
+// that slot are in the snapshot, so that no change is missed or received twice.
+// To make sure of it, the snapshot is fetched with a minContextSlot of the current slot
+// (or of the slot of the first notification, if one was already received).
+// If the account doesn't exist, the snapshot has no lamports and no data,
+// like the notification of a closed account.
+func (cl *Client) AccountSubscribeWithSnapshot(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	account solana.PublicKey,
+	config *AccountSubscribeConfig,
+) (*AccountSnapshotSubscription, error) {
+	if config == nil {
+		config = &AccountSubscribeConfig{}
+	}
+	sub, err := cl.AccountSubscribeWithConfig(account, config)
+	if err != nil {
+		return nil, err
+	}
+	out := &AccountSnapshotSubscription{sub: sub}
+	if err := out.fetchSnapshot(ctx, rpcClient, account, config); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *AccountSnapshotSubscription) fetchSnapshot(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	account solana.PublicKey,
+	config *AccountSubscribeConfig,
+) error {
+	select {
+	case <-s.sub.sub.confirmed:
+	case err := <-s.sub.sub.err:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	minSlot, err := rpcClient.GetSlot(ctx, config.Commitment)
+	if err != nil {
+		return fmt.Errorf("unable to get the current slot: %w", err)
+	}
+	// The snapshot must not be older than the notifications already received.
+drain:
+	for {
+		select {
+		case res := <-s.sub.sub.stream:
+			s.pending = append(s.pending, res.(*AccountResult))
+		default:
+			break drain
+		}
+	}
+	if len(s.pending) != 0 && s.pending[0].Context.Slot > minSlot {
+		minSlot = s.pending[0].Context.Slot
+	}
+
+	encoding := config.Encoding
+	if encoding == "" {
+		encoding = solana.EncodingBase64
+	}
+	res, err := rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{account}, &rpc.GetMultipleAccountsOpts{
+		Encoding:       encoding,
+		Commitment:     config.Commitment,
+		DataSlice:      config.DataSlice,
+		MinContextSlot: &minSlot,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to fetch the account: %w", err)
+	}
+	if len(res.Value) != 1 {
+		return fmt.Errorf("expected 1 account, got %d", len(res.Value))
+	}
+	s.snapshot = &AccountResult{}
+	s.snapshot.Context.Slot = res.Context.Slot
+	if res.Value[0] != nil {
+		s.snapshot.Value.Account = *res.Value[0]
+	} else {
+		s.snapshot.Value.Data = rpc.DataBytesOrJSONFromBytes([]byte{})
+	}
+	s.slot = res.Context.Slot
+	return nil
+}
+
+// Recv returns the snapshot of the account, then its notifications
+// with a slot greater than the slot of the snapshot.
+func (s *AccountSnapshotSubscription) Recv() (*AccountResult, error) {
+	if s.snapshot != nil {
+		snapshot := s.snapshot
+		s.snapshot = nil
+		return snapshot, nil
+	}
+	for {
+		var res *AccountResult
+		if len(s.pending) != 0 {
+			res, s.pending = s.pending[0], s.pending[1:]
+		} else {
+			var err error
+			res, err = s.sub.Recv()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if res.Context.Slot > s.slot {
+			return res, nil
+		}
+	}
+}
+
+func (s *AccountSnapshotSubscription) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// Stats returns the number of pending and dropped notifications.
+func (s *AccountSnapshotSubscription) Stats() SubscriptionStats {
+	return s.sub.Stats()
+}
@@ -67,6 +67,10 @@ type BlockSubscribeOpts struct {
 
 	// Whether to populate the rewards array. If parameter not provided, the default includes rewards.
 	Rewards *bool
+
+	// Max transaction version to return in responses.
+	// If the requested block contains a transaction with a higher version, an error will be returned.
+	MaxSupportedTransactionVersion *uint64
 }
 
 // NOTE: Unstable, disabled by default
@@ -76,10 +80,16 @@ type BlockSubscribeOpts struct {
 // **This subscription is unstable and only available if the validator was started
 // with the `--rpc-pubsub-enable-block-subscription` flag. The format of this
 // subscription may change in the future**
+//
+// Returns ErrUnstableDisabled unless the client was created
+// with `Options.EnableUnstable`.
 func (cl *Client) BlockSubscribe(
 	filter BlockSubscribeFilter,
 	opts *BlockSubscribeOpts,
 ) (*BlockSubscription, error) {
+	if !cl.enableUnstable {
+		return nil, ErrUnstableDisabled
+	}
 	var params []interface{}
 	if filter != nil {
 		switch v := filter.(type) {
@@ -114,6 +124,9 @@ func (cl *Client) BlockSubscribe(
 		if opts.Rewards != nil {
 			obj["rewards"] = opts.Rewards
 		}
+		if opts.MaxSupportedTransactionVersion != nil {
+			obj["maxSupportedTransactionVersion"] = *opts.MaxSupportedTransactionVersion
+		}
 		if len(obj) > 0 {
 			params = append(params, obj)
 		}
@@ -153,3 +166,8 @@ func (sw *BlockSubscription) Recv() (*BlockResult, error) {
 func (sw *BlockSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *BlockSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -18,11 +18,17 @@
 package ws
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,6 +36,9 @@ import (
 	"github.com/gorilla/rpc/v2/json2"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
 type result interface{}
@@ -43,8 +52,22 @@ type Client struct {
 	subscriptionByRequestID map[uint64]*Subscription
 	subscriptionByWSSubID   map[uint64]*Subscription
 	reconnectOnErr          bool
+	enableUnstable          bool
+	buffer                  BufferOptions
+	bufferByMethod          map[string]BufferOptions
+	readLimit               int64
+	compression             bool
+	stats                   *connStats
+
+	pingsMu sync.Mutex
+	// The pings waiting for a pong, by payload.
+	pings map[string]chan struct{}
 }
 
+// ErrUnstableDisabled is returned when subscribing to an unstable
+// subscription without enabling them with `Options.EnableUnstable`.
+var ErrUnstableDisabled = errors.New("unstable subscriptions are disabled; enable them with Options.EnableUnstable")
+
 const (
 	// Time allowed to write a message to the peer.
 	writeWait = 10 * time.Second
@@ -68,21 +91,51 @@ func ConnectWithOptions(ctx context.Context, rpcEndpoint string, opt *Options) (
 		rpcURL:                  rpcEndpoint,
 		subscriptionByRequestID: map[uint64]*Subscription{},
 		subscriptionByWSSubID:   map[uint64]*Subscription{},
+		pings:                   map[string]chan struct{}{},
+		readLimit:               DefaultReadLimit,
+		stats:                   &connStats{},
 	}
 
+	netDialer := &net.Dialer{}
 	dialer := &websocket.Dialer{
 		Proxy:             http.ProxyFromEnvironment,
 		HandshakeTimeout:  DefaultHandshakeTimeout,
 		EnableCompression: true,
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := netDialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &countingConn{Conn: conn, stats: c.stats}, nil
+		},
 	}
 
 	if opt != nil && opt.HandshakeTimeout > 0 {
 		dialer.HandshakeTimeout = opt.HandshakeTimeout
 	}
+	if opt != nil {
+		c.enableUnstable = opt.EnableUnstable
+		c.buffer = opt.Buffer
+		c.bufferByMethod = opt.BufferByMethod
+		if opt.ReadLimit != 0 {
+			c.readLimit = opt.ReadLimit
+		}
+		dialer.EnableCompression = !opt.DisableCompression
+	}
 
-	var httpHeader http.Header = nil
+	httpHeader := http.Header{}
 	if opt != nil && opt.HttpHeader != nil && len(opt.HttpHeader) > 0 {
-		httpHeader = opt.HttpHeader
+		httpHeader = opt.HttpHeader.Clone()
+	}
+	if httpHeader.Get("User-Agent") == "" {
+		if opt != nil && opt.UserAgent != "" {
+			httpHeader.Set("User-Agent", opt.UserAgent)
+		} else {
+			httpHeader.Set("User-Agent", rpc.DefaultUserAgent())
+		}
+	}
+	if userAgent, ok := jsonrpc.UserAgentFromContext(ctx); ok {
+		httpHeader.Set("User-Agent", userAgent)
 	}
 	var resp *http.Response
 	c.conn, resp, err = dialer.DialContext(ctx, rpcEndpoint, httpHeader)
@@ -96,10 +149,17 @@ func ConnectWithOptions(ctx context.Context, rpcEndpoint string, opt *Options) (
 		return nil, err
 	}
 
+	c.compression = strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+	if c.readLimit > 0 {
+		// The limit of the connection applies to the (compressed) frames;
+		// the size of the decompressed messages is checked by receiveMessages.
+		c.conn.SetReadLimit(c.readLimit)
+	}
+
 	c.connCtx, c.connCtxCancel = context.WithCancel(context.Background())
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(c.handlePong)
 	go func() {
-		c.conn.SetReadDeadline(time.Now().Add(pongWait))
-		c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
 		ticker := time.NewTicker(pingPeriod)
 		for {
 			select {
@@ -124,6 +184,66 @@ func (c *Client) sendPing() {
 	}
 }
 
+// handlePong is called by the goroutine that reads the messages.
+func (c *Client) handlePong(appData string) error {
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.pingsMu.Lock()
+	defer c.pingsMu.Unlock()
+	if pong, ok := c.pings[appData]; ok {
+		close(pong)
+		delete(c.pings, appData)
+	}
+	return nil
+}
+
+// Ping sends a ping frame to the node, and waits for its pong;
+// it returns the round-trip time.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	payload := strconv.FormatUint(rand.Uint64(), 10)
+	pong := make(chan struct{})
+	c.pingsMu.Lock()
+	c.pings[payload] = pong
+	c.pingsMu.Unlock()
+	defer func() {
+		c.pingsMu.Lock()
+		delete(c.pings, payload)
+		c.pingsMu.Unlock()
+	}()
+
+	c.lock.Lock()
+	start := time.Now()
+	c.conn.SetWriteDeadline(start.Add(writeWait))
+	err := c.conn.WriteMessage(websocket.PingMessage, []byte(payload))
+	c.lock.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("unable to send ping: %w", err)
+	}
+
+	select {
+	case <-pong:
+		return time.Since(start), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-c.connCtx.Done():
+		return 0, errors.New("connection closed")
+	}
+}
+
+// ActiveSubscriptions returns the subscriptions of the client, ordered by request ID;
+// e.g. to find the streams that stopped receiving notifications.
+func (c *Client) ActiveSubscriptions() []SubscriptionInfo {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	out := make([]SubscriptionInfo, 0, len(c.subscriptionByRequestID))
+	for _, sub := range c.subscriptionByRequestID {
+		out = append(out, sub.info())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].RequestID < out[j].RequestID
+	})
+	return out
+}
+
 func (c *Client) Close() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -131,18 +251,46 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
+// messageBufferPool holds the buffers used to read the incoming messages;
+// some notifications (e.g. full blocks) are several megabytes,
+// and allocating a new buffer for each one is wasteful.
+var messageBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
 func (c *Client) receiveMessages() {
 	for {
 		select {
 		case <-c.connCtx.Done():
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, reader, err := c.conn.NextReader()
 			if err != nil {
 				c.closeAllSubscription(err)
 				return
 			}
-			c.handleMessage(message)
+			if c.readLimit > 0 {
+				reader = io.LimitReader(reader, c.readLimit+1)
+			}
+			buf := messageBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			if _, err := buf.ReadFrom(reader); err != nil {
+				messageBufferPool.Put(buf)
+				c.closeAllSubscription(err)
+				return
+			}
+			if c.readLimit > 0 && int64(buf.Len()) > c.readLimit {
+				messageBufferPool.Put(buf)
+				c.closeAllSubscription(fmt.Errorf("message larger than %d bytes: %w", c.readLimit, websocket.ErrReadLimit))
+				return
+			}
+			c.stats.messageReceived(buf.Len())
+			// NOTE: the message is only valid until handleMessage returns;
+			// the decoders must not retain it.
+			c.handleMessage(buf.Bytes())
+			messageBufferPool.Put(buf)
 		}
 	}
 }
@@ -204,6 +352,11 @@ func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 	}
 	callBack.subID = subID
 	c.subscriptionByWSSubID[subID] = callBack
+	select {
+	case <-callBack.confirmed:
+	default:
+		close(callBack.confirmed)
+	}
 
 	zlog.Debug("registered ws subscription",
 		zap.Uint64("subscription_id", subID),
@@ -228,6 +381,8 @@ func (c *Client) handleSubscriptionMessage(subID uint64, message []byte) {
 		return
 	}
 
+	sub.received(time.Now())
+
 	// Decode the message using the subscription-provided decoderFunc.
 	result, err := sub.decoderFunc(message)
 	if err != nil {
@@ -236,18 +391,15 @@ func (c *Client) handleSubscriptionMessage(subID uint64, message []byte) {
 		return
 	}
 
-	// this cannot be blocking or else
-	// we  will no read any other message
-	if len(sub.stream) >= cap(sub.stream) {
-		zlog.Warn("closing ws client subscription... not consuming fast en ought",
+	// Unless the policy is Block, this cannot be blocking or else
+	// we will not read any other message.
+	if !sub.deliver(result, c.connCtx.Done()) {
+		zlog.Warn("closing ws client subscription... not consuming fast enough",
 			zap.Uint64("request_id", sub.req.ID),
 		)
-		c.closeSubscription(sub.req.ID, fmt.Errorf("reached channel max capacity %d", len(sub.stream)))
+		c.closeSubscription(sub.req.ID, fmt.Errorf("reached channel max capacity %d", cap(sub.stream)))
 		return
 	}
-
-	sub.stream <- result
-	return
 }
 
 func (c *Client) closeAllSubscription(err error) {
@@ -256,6 +408,7 @@ func (c *Client) closeAllSubscription(err error) {
 
 	for _, sub := range c.subscriptionByRequestID {
 		sub.err <- err
+		sub.markClosed()
 	}
 
 	c.subscriptionByRequestID = map[uint64]*Subscription{}
@@ -272,6 +425,7 @@ func (c *Client) closeSubscription(reqID uint64, err error) {
 	}
 
 	sub.err <- err
+	sub.markClosed()
 
 	err = c.unsubscribe(sub.subID, sub.unsubscribeMethod)
 	if err != nil {
@@ -322,6 +476,7 @@ func (c *Client) subscribe(
 		},
 		unsubscribeMethod,
 		decoderFunc,
+		c.bufferOptions(subscriptionMethod),
 	)
 
 	c.subscriptionByRequestID[req.ID] = sub
@@ -337,6 +492,15 @@ func (c *Client) subscribe(
 	return sub, nil
 }
 
+// bufferOptions returns the buffer options for the subscriptions
+// with the provided method.
+func (c *Client) bufferOptions(subscriptionMethod string) BufferOptions {
+	if opts, ok := c.bufferByMethod[subscriptionMethod]; ok {
+		return opts
+	}
+	return c.buffer
+}
+
 func decodeResponseFromReader(r io.Reader, reply interface{}) (err error) {
 	var c *response
 	if err := json.NewDecoder(r).Decode(&c); err != nil {
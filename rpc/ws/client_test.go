@@ -20,14 +20,20 @@ package ws
 import (
 	"context"
 	"encoding/base64"
+	stdjson "encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/text"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -156,3 +162,769 @@ func Test_SlotSubscribe(t *testing.T) {
 	fmt.Println("data received: ", data.Parent)
 	return
 }
+
+// mockWSServer accepts a single subscription request, confirms it
+// with the provided subscription ID and then sends the notifications.
+func mockWSServer(
+	t *testing.T,
+	subID uint64,
+	notifications ...[]byte,
+) (url string, requests <-chan map[string]interface{}, closer func()) {
+	return mockWSServerWithUpgrader(t, websocket.Upgrader{}, subID, notifications...)
+}
+
+// mockWSServerWithUpgrader is like mockWSServer, with the provided upgrader
+// (e.g. to enable the compression).
+func mockWSServerWithUpgrader(
+	t *testing.T,
+	upgrader websocket.Upgrader,
+	subID uint64,
+	notifications ...[]byte,
+) (url string, requests <-chan map[string]interface{}, closer func()) {
+	return newMockWSServer(t, upgrader, nil, subID, notifications...)
+}
+
+// newMockWSServer is like mockWSServerWithUpgrader, but if ready is not nil,
+// the notifications are sent only once it is closed.
+func newMockWSServer(
+	t *testing.T,
+	upgrader websocket.Upgrader,
+	ready <-chan struct{},
+	subID uint64,
+	notifications ...[]byte,
+) (url string, requests <-chan map[string]interface{}, closer func()) {
+	reqs := make(chan map[string]interface{}, 1)
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			// The client closed the connection without subscribing.
+			return
+		}
+		var body map[string]interface{}
+		require.NoError(t, stdjson.Unmarshal(msg, &body))
+		reqs <- body
+		var id struct {
+			ID stdjson.RawMessage `json:"id"`
+		}
+		require.NoError(t, stdjson.Unmarshal(msg, &id))
+
+		require.NoError(t, conn.WriteMessage(
+			websocket.TextMessage,
+			[]byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%s}`, subID, id.ID)),
+		))
+		if ready != nil {
+			<-ready
+		}
+		for _, notification := range notifications {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, notification))
+		}
+		<-done
+	}))
+	return "ws" + strings.TrimPrefix(server.URL, "http"), reqs, func() {
+		close(done)
+		server.Close()
+	}
+}
+
+func Test_BlockSubscribe(t *testing.T) {
+	fixture, err := ioutil.ReadFile("testdata/blockNotification.json")
+	require.NoError(t, err)
+
+	url, requests, closer := mockWSServer(t, 14, fixture, fixture)
+	defer closer()
+
+	c, err := ConnectWithOptions(context.Background(), url, &Options{EnableUnstable: true})
+	require.NoError(t, err)
+	defer c.Close()
+
+	program := solana.MustPublicKeyFromBase58("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	rewards := false
+	version := uint64(0)
+	sub, err := c.BlockSubscribe(
+		NewBlockSubscribeFilterMentionsAccountOrProgram(program),
+		&BlockSubscribeOpts{
+			Commitment:                     rpc.CommitmentConfirmed,
+			Encoding:                       solana.EncodingBase64,
+			TransactionDetails:             rpc.TransactionDetailsFull,
+			Rewards:                        &rewards,
+			MaxSupportedTransactionVersion: &version,
+		},
+	)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	req := <-requests
+	require.Equal(t, "blockSubscribe", req["method"])
+	require.Equal(t,
+		[]interface{}{
+			map[string]interface{}{
+				"mentionsAccountOrProgram": program.String(),
+			},
+			map[string]interface{}{
+				"commitment":                     "confirmed",
+				"encoding":                       "base64",
+				"transactionDetails":             "full",
+				"rewards":                        false,
+				"maxSupportedTransactionVersion": float64(0),
+			},
+		},
+		req["params"],
+	)
+
+	// Receive twice: the read buffer is reused across messages,
+	// and the first result must not be affected by the second message.
+	first, err := sub.Recv()
+	require.NoError(t, err)
+	second, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	got := first
+	require.Equal(t, uint64(112301554), got.Context.Slot)
+	require.Equal(t, uint64(112301554), got.Value.Slot)
+	require.Nil(t, got.Value.Err)
+
+	block := got.Value.Block
+	require.NotNil(t, block)
+	require.Equal(t, solana.MustHashFromBase58("6ojMHjctdqfB55JDpEpqfHnP96fiaHEcvzEQ2NNcxzHP"), block.Blockhash)
+	require.Equal(t, solana.MustHashFromBase58("GJp125YAN4ufCSUvZJVdCyWQJ7RPWMmwxoyUQySydZA"), block.PreviousBlockhash)
+	require.Equal(t, uint64(112301553), block.ParentSlot)
+	require.Equal(t, uint64(101210751), *block.BlockHeight)
+	require.Equal(t, solana.UnixTimeSeconds(1658474522), *block.BlockTime)
+	require.Len(t, block.Rewards, 1)
+	require.Equal(t, rpc.RewardTypeFee, block.Rewards[0].RewardType)
+
+	require.Len(t, block.Transactions, 1)
+	txWithMeta := block.Transactions[0]
+	require.Equal(t, rpc.TransactionVersion(0), txWithMeta.Version)
+	require.Equal(t, uint64(10000), txWithMeta.Meta.Fee)
+	require.Equal(t, uint64(7223), *txWithMeta.Meta.ComputeUnitsConsumed)
+	require.Len(t, txWithMeta.Meta.LoadedAddresses.Writable, 2)
+
+	tx, err := txWithMeta.GetTransaction()
+	require.NoError(t, err)
+	require.True(t, tx.Message.IsVersioned())
+	require.Equal(t,
+		"2nMjR8mdczMJZZ1XeQ5Y37GxfrRQmaV74eypnD9ggpQMmaWfETq9C5DoGKha4bMamu9tFQQArBAgxzQ5vnng1ZdG",
+		tx.Signatures[0].String(),
+	)
+	require.Equal(t, solana.MustHashFromBase58("BAx74QRmMwhnTytrPoG5ogw2BQn4CdhB14jxJnbDMUS7"), tx.Message.RecentBlockhash)
+}
+
+func Test_UnstableSubscriptionsDisabled(t *testing.T) {
+	url, _, closer := mockWSServer(t, 1)
+	defer closer()
+
+	c, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.VoteSubscribe()
+	require.ErrorIs(t, err, ErrUnstableDisabled)
+	_, err = c.SlotsUpdatesSubscribe()
+	require.ErrorIs(t, err, ErrUnstableDisabled)
+	_, err = c.BlockSubscribe(NewBlockSubscribeFilterAll(), nil)
+	require.ErrorIs(t, err, ErrUnstableDisabled)
+}
+
+func Test_VoteSubscribe(t *testing.T) {
+	notification := []byte(`{"jsonrpc":"2.0","method":"voteNotification","params":{"result":{"votePubkey":"Vote111111111111111111111111111111111111111","slots":[1,2],"hash":"BAx74QRmMwhnTytrPoG5ogw2BQn4CdhB14jxJnbDMUS7","timestamp":1658474522,"signature":"2nMjR8mdczMJZZ1XeQ5Y37GxfrRQmaV74eypnD9ggpQMmaWfETq9C5DoGKha4bMamu9tFQQArBAgxzQ5vnng1ZdG"},"subscription":3}}`)
+	url, requests, closer := mockWSServer(t, 3, notification)
+	defer closer()
+
+	c, err := ConnectWithOptions(context.Background(), url, &Options{EnableUnstable: true})
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.VoteSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Equal(t, "voteSubscribe", (<-requests)["method"])
+
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	timestamp := solana.UnixTimeSeconds(1658474522)
+	require.Equal(t,
+		&VoteResult{
+			VotePubkey: solana.VoteProgramID,
+			Hash:       solana.MustHashFromBase58("BAx74QRmMwhnTytrPoG5ogw2BQn4CdhB14jxJnbDMUS7"),
+			Slots:      []uint64{1, 2},
+			Timestamp:  &timestamp,
+			Signature:  solana.MustSignatureFromBase58("2nMjR8mdczMJZZ1XeQ5Y37GxfrRQmaV74eypnD9ggpQMmaWfETq9C5DoGKha4bMamu9tFQQArBAgxzQ5vnng1ZdG"),
+		},
+		got,
+	)
+}
+
+func Test_SlotsUpdatesSubscribe(t *testing.T) {
+	notifications := [][]byte{
+		[]byte(`{"jsonrpc":"2.0","method":"slotsUpdatesNotification","params":{"result":{"parent":75,"slot":76,"timestamp":1625081266243,"type":"createdBank"},"subscription":5}}`),
+		[]byte(`{"jsonrpc":"2.0","method":"slotsUpdatesNotification","params":{"result":{"slot":76,"timestamp":1625081266300,"type":"frozen","stats":{"numTransactionEntries":3,"numSuccessfulTransactions":10,"numFailedTransactions":1,"maxTransactionsPerEntry":5}},"subscription":5}}`),
+		[]byte(`{"jsonrpc":"2.0","method":"slotsUpdatesNotification","params":{"result":{"slot":77,"timestamp":1625081266400,"type":"dead","err":"boom"},"subscription":5}}`),
+		[]byte(`{"jsonrpc":"2.0","method":"slotsUpdatesNotification","params":{"result":{"slot":78,"timestamp":1625081266500,"type":"somethingNew","extra":42},"subscription":5}}`),
+	}
+	url, requests, closer := mockWSServer(t, 5, notifications...)
+	defer closer()
+
+	c, err := ConnectWithOptions(context.Background(), url, &Options{EnableUnstable: true})
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.SlotsUpdatesSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Equal(t, "slotsUpdatesSubscribe", (<-requests)["method"])
+
+	{
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, SlotsUpdatesCreatedBank, got.Type)
+		require.Equal(t, uint64(75), got.Parent)
+		require.Equal(t, uint64(76), got.Slot)
+		require.Equal(t, solana.UnixTimeMilliseconds(1625081266243), *got.Timestamp)
+	}
+	{
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, SlotsUpdatesFrozen, got.Type)
+		require.Equal(t,
+			&BankStats{
+				NumTransactionEntries:     3,
+				NumSuccessfulTransactions: 10,
+				NumFailedTransactions:     1,
+				MaxTransactionsPerEntry:   5,
+			},
+			got.Stats,
+		)
+	}
+	{
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, SlotsUpdatesDead, got.Type)
+		require.Equal(t, "boom", got.Err)
+	}
+	{
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.False(t, got.Type.IsKnown())
+		require.Equal(t, uint64(78), got.Slot)
+		require.JSONEq(t,
+			`{"slot":78,"timestamp":1625081266500,"type":"somethingNew","extra":42}`,
+			string(got.Raw),
+		)
+	}
+}
+
+// newStalledSubscription returns a subscription whose consumer never reads.
+func newStalledSubscription(policy BufferPolicy, drops *[]uint64) *Subscription {
+	return newSubscription(
+		&request{Method: "accountSubscribe"},
+		func(error) {},
+		"accountUnsubscribe",
+		nil,
+		BufferOptions{
+			Size:   2,
+			Policy: policy,
+			OnDrop: func(method string, dropped uint64) {
+				if method != "accountSubscribe" {
+					panic(method)
+				}
+				*drops = append(*drops, dropped)
+			},
+		},
+	)
+}
+
+func drain(sub *Subscription) []result {
+	var out []result
+	for {
+		select {
+		case d := <-sub.stream:
+			out = append(out, d)
+		default:
+			return out
+		}
+	}
+}
+
+func Test_BufferPolicies(t *testing.T) {
+	cancel := make(chan struct{})
+
+	t.Run("CloseOnFull", func(t *testing.T) {
+		var drops []uint64
+		sub := newStalledSubscription(CloseOnFull, &drops)
+		require.True(t, sub.deliver(1, cancel))
+		require.True(t, sub.deliver(2, cancel))
+		require.False(t, sub.deliver(3, cancel))
+		require.Equal(t, SubscriptionStats{Pending: 2}, sub.Stats())
+		require.Equal(t, []result{1, 2}, drain(sub))
+		require.Empty(t, drops)
+	})
+	t.Run("DropNewest", func(t *testing.T) {
+		var drops []uint64
+		sub := newStalledSubscription(DropNewest, &drops)
+		for i := 1; i <= 5; i++ {
+			require.True(t, sub.deliver(i, cancel))
+		}
+		require.Equal(t, SubscriptionStats{Pending: 2, Dropped: 3}, sub.Stats())
+		require.Equal(t, []result{1, 2}, drain(sub))
+		require.Equal(t, []uint64{1, 2, 3}, drops)
+	})
+	t.Run("DropOldest", func(t *testing.T) {
+		var drops []uint64
+		sub := newStalledSubscription(DropOldest, &drops)
+		for i := 1; i <= 5; i++ {
+			require.True(t, sub.deliver(i, cancel))
+		}
+		require.Equal(t, SubscriptionStats{Pending: 2, Dropped: 3}, sub.Stats())
+		require.Equal(t, []result{4, 5}, drain(sub))
+		require.Equal(t, []uint64{1, 2, 3}, drops)
+	})
+	t.Run("CoalesceLatest", func(t *testing.T) {
+		var drops []uint64
+		sub := newStalledSubscription(CoalesceLatest, &drops)
+		for i := 1; i <= 5; i++ {
+			require.True(t, sub.deliver(i, cancel))
+		}
+		require.Equal(t, SubscriptionStats{Pending: 1, Dropped: 4}, sub.Stats())
+		require.Equal(t, []result{5}, drain(sub))
+		require.Equal(t, []uint64{1, 2, 3, 4}, drops)
+	})
+	t.Run("Block", func(t *testing.T) {
+		var drops []uint64
+		sub := newStalledSubscription(Block, &drops)
+		require.True(t, sub.deliver(1, cancel))
+		require.True(t, sub.deliver(2, cancel))
+
+		delivered := make(chan struct{})
+		go func() {
+			sub.deliver(3, cancel)
+			close(delivered)
+		}()
+		select {
+		case <-delivered:
+			t.Fatal("deliver didn't block on a full buffer")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// The consumer makes room: the blocked notification is delivered.
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, 1, got)
+		select {
+		case <-delivered:
+		case <-time.After(time.Second):
+			t.Fatal("deliver is still blocked")
+		}
+		require.Equal(t, SubscriptionStats{Pending: 2}, sub.Stats())
+
+		// Closing the subscription releases a blocked delivery.
+		delivered = make(chan struct{})
+		go func() {
+			sub.deliver(4, cancel)
+			close(delivered)
+		}()
+		sub.markClosed()
+		select {
+		case <-delivered:
+		case <-time.After(time.Second):
+			t.Fatal("deliver is still blocked after close")
+		}
+		require.Equal(t, []result{2, 3}, drain(sub))
+		require.Empty(t, drops)
+	})
+}
+
+func Test_AccountSubscribeCoalesceLatest(t *testing.T) {
+	var notifications [][]byte
+	for i := 1; i <= 5; i++ {
+		notifications = append(notifications, []byte(fmt.Sprintf(
+			`{"jsonrpc":"2.0","method":"accountNotification","params":{"result":{"context":{"slot":%d},"value":{"lamports":%d,"data":["","base64"],"owner":"11111111111111111111111111111111","executable":false,"rentEpoch":0}},"subscription":7}}`,
+			100+i, i,
+		)))
+	}
+	url, _, closer := mockWSServer(t, 7, notifications...)
+	defer closer()
+
+	dropped := make(chan uint64, len(notifications))
+	c, err := ConnectWithOptions(context.Background(), url, &Options{
+		BufferByMethod: map[string]BufferOptions{
+			"accountSubscribe": {
+				Policy: CoalesceLatest,
+				OnDrop: func(method string, n uint64) {
+					dropped <- n
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.AccountSubscribe(solana.SystemProgramID, "")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// Stalled consumer: wait for all the notifications to be received.
+	for i := uint64(1); i <= 4; i++ {
+		select {
+		case n := <-dropped:
+			require.Equal(t, i, n)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for drop %d", i)
+		}
+	}
+	require.Equal(t, SubscriptionStats{Pending: 1, Dropped: 4}, sub.Stats())
+
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(105), got.Context.Slot)
+	require.Equal(t, uint64(5), got.Value.Lamports)
+}
+
+func Test_AccountSubscribeWithConfig(t *testing.T) {
+	notification := []byte(`{"jsonrpc":"2.0","method":"accountNotification","params":{"result":{"context":{"slot":101},"value":{"lamports":5,"data":["AQID","base64"],"owner":"11111111111111111111111111111111","executable":false,"rentEpoch":0}},"subscription":7}}`)
+	url, requests, closer := mockWSServer(t, 7, notification)
+	defer closer()
+
+	c, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer c.Close()
+	require.Empty(t, c.ActiveSubscriptions())
+
+	offset, length := uint64(8), uint64(3)
+	start := time.Now()
+	sub, err := c.AccountSubscribeWithConfig(solana.SystemProgramID, &AccountSubscribeConfig{
+		Commitment: rpc.CommitmentConfirmed,
+		Encoding:   solana.EncodingBase64Zstd,
+		DataSlice:  &rpc.DataSlice{Offset: &offset, Length: &length},
+	})
+	require.NoError(t, err)
+	request := <-requests
+	require.Equal(t, "accountSubscribe", request["method"])
+	require.Equal(t,
+		[]interface{}{
+			solana.SystemProgramID.String(),
+			map[string]interface{}{
+				"commitment": "confirmed",
+				"encoding":   "base64+zstd",
+				"dataSlice":  map[string]interface{}{"offset": float64(8), "length": float64(3)},
+			},
+		},
+		request["params"],
+	)
+
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), got.Context.Slot)
+	require.Equal(t, []byte{1, 2, 3}, got.Value.Data.GetBinary())
+
+	active := c.ActiveSubscriptions()
+	require.Len(t, active, 1)
+	info := active[0]
+	require.Equal(t, uint64(7), info.ID)
+	require.NotZero(t, info.RequestID)
+	require.Equal(t, "accountSubscribe", info.Method)
+	require.Equal(t, solana.SystemProgramID.String(), info.Params[0])
+	require.Equal(t, uint64(1), info.MessagesReceived)
+	require.False(t, info.LastMessageAt.Before(start))
+	require.Equal(t, SubscriptionStats{}, info.SubscriptionStats)
+
+	sub.Unsubscribe()
+	require.Empty(t, c.ActiveSubscriptions())
+}
+
+func accountNotification(slot uint64, lamports uint64) []byte {
+	return []byte(fmt.Sprintf(
+		`{"jsonrpc":"2.0","method":"accountNotification","params":{"result":{"context":{"slot":%d},"value":{"lamports":%d,"data":["","base64"],"owner":"11111111111111111111111111111111","executable":false,"rentEpoch":0}},"subscription":7}}`,
+		slot, lamports,
+	))
+}
+
+func Test_AccountSubscribeWithSnapshot(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		snapshot string
+		lamports uint64
+	}{
+		{name: "existing account", snapshot: `{"lamports":10,"data":["AQID","base64"],"owner":"11111111111111111111111111111111","executable":false,"rentEpoch":0}`, lamports: 10},
+		{name: "missing account", snapshot: `null`, lamports: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// The notifications are sent once the snapshot was fetched.
+			fetched := make(chan struct{})
+			var rpcRequests []map[string]interface{}
+			rpcServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				var body map[string]interface{}
+				require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&body))
+				rpcRequests = append(rpcRequests, body)
+				switch body["method"] {
+				case "getSlot":
+					fmt.Fprint(rw, `{"jsonrpc":"2.0","id":0,"result":98}`)
+				case "getMultipleAccounts":
+					fmt.Fprintf(rw, `{"jsonrpc":"2.0","id":0,"result":{"context":{"slot":100},"value":[%s]}}`, test.snapshot)
+					close(fetched)
+				}
+			}))
+			defer rpcServer.Close()
+			url, _, closer := newMockWSServer(t, websocket.Upgrader{}, fetched, 7,
+				accountNotification(99, 1),
+				accountNotification(100, 2),
+				accountNotification(101, 3),
+				accountNotification(101, 4),
+				accountNotification(102, 5),
+			)
+			defer closer()
+
+			c, err := Connect(context.Background(), url)
+			require.NoError(t, err)
+			defer c.Close()
+
+			account := solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+			sub, err := c.AccountSubscribeWithSnapshot(context.Background(), rpc.New(rpcServer.URL), account, &AccountSubscribeConfig{
+				Commitment: rpc.CommitmentConfirmed,
+			})
+			require.NoError(t, err)
+			defer sub.Unsubscribe()
+
+			require.Len(t, rpcRequests, 2)
+			require.Equal(t, []interface{}{map[string]interface{}{"commitment": "confirmed"}}, rpcRequests[0]["params"])
+			require.Equal(t,
+				[]interface{}{
+					[]interface{}{account.String()},
+					map[string]interface{}{"commitment": "confirmed", "encoding": "base64", "minContextSlot": float64(98)},
+				},
+				rpcRequests[1]["params"],
+			)
+
+			snapshot, err := sub.Recv()
+			require.NoError(t, err)
+			require.Equal(t, uint64(100), snapshot.Context.Slot)
+			require.Equal(t, test.lamports, snapshot.Value.Lamports)
+			require.NotNil(t, snapshot.Value.Data)
+
+			// Only the notifications after the slot of the snapshot.
+			var slots, lamports []uint64
+			for i := 0; i < 3; i++ {
+				got, err := sub.Recv()
+				require.NoError(t, err)
+				slots = append(slots, got.Context.Slot)
+				lamports = append(lamports, got.Value.Lamports)
+			}
+			require.Equal(t, []uint64{101, 101, 102}, slots)
+			require.Equal(t, []uint64{3, 4, 5}, lamports)
+		})
+	}
+}
+
+func Test_AccountSnapshotSubscription_pending(t *testing.T) {
+	// Notifications received before the snapshot was fetched.
+	sub := &AccountSnapshotSubscription{
+		snapshot: &AccountResult{},
+		slot:     100,
+	}
+	for _, slot := range []uint64{100, 101} {
+		res := &AccountResult{}
+		res.Context.Slot = slot
+		sub.pending = append(sub.pending, res)
+	}
+	first, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), first.Context.Slot)
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), got.Context.Slot)
+	require.Empty(t, sub.pending)
+}
+
+func Test_ProgramSubscribeWithConfig(t *testing.T) {
+	url, requests, closer := mockWSServer(t, 8)
+	defer closer()
+
+	c, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	length := uint64(0)
+	sub, err := c.ProgramSubscribeWithConfig(solana.TokenProgramID, &ProgramSubscribeConfig{
+		Commitment: rpc.CommitmentProcessed,
+		DataSlice:  &rpc.DataSlice{Length: &length},
+		Filters:    []rpc.RPCFilter{{DataSize: 165}},
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Equal(t,
+		[]interface{}{
+			solana.TokenProgramID.String(),
+			map[string]interface{}{
+				"commitment": "processed",
+				"encoding":   "base64",
+				"dataSlice":  map[string]interface{}{"length": float64(0)},
+				"filters":    []interface{}{map[string]interface{}{"dataSize": float64(165)}},
+			},
+		},
+		(<-requests)["params"],
+	)
+	active := c.ActiveSubscriptions()
+	require.Len(t, active, 1)
+	require.Equal(t, "programSubscribe", active[0].Method)
+	require.Equal(t, uint64(0), active[0].MessagesReceived)
+	require.True(t, active[0].LastMessageAt.IsZero())
+}
+
+// mockPingServer reads (and discards) the messages of the client;
+// if `pong` is false, it doesn't answer the pings.
+func mockPingServer(t *testing.T, pong bool) (url string, closer func()) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		if !pong {
+			conn.SetPingHandler(func(string) error { return nil })
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	return "ws" + strings.TrimPrefix(server.URL, "http"), server.Close
+}
+
+func Test_Ping(t *testing.T) {
+	t.Run("pong", func(t *testing.T) {
+		url, closer := mockPingServer(t, true)
+		defer closer()
+		c, err := Connect(context.Background(), url)
+		require.NoError(t, err)
+		defer c.Close()
+
+		for i := 0; i < 3; i++ {
+			latency, err := c.Ping(context.Background())
+			require.NoError(t, err)
+			require.Greater(t, int64(latency), int64(0))
+		}
+		c.pingsMu.Lock()
+		require.Empty(t, c.pings)
+		c.pingsMu.Unlock()
+	})
+	t.Run("no pong", func(t *testing.T) {
+		url, closer := mockPingServer(t, false)
+		defer closer()
+		c, err := Connect(context.Background(), url)
+		require.NoError(t, err)
+		defer c.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = c.Ping(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestConnect_userAgent(t *testing.T) {
+	userAgents := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		userAgents <- req.Header.Get("User-Agent")
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		opt       *Options
+		userAgent string
+	}{
+		{name: "default", ctx: context.Background(), userAgent: rpc.DefaultUserAgent()},
+		{name: "option", ctx: context.Background(), opt: &Options{UserAgent: "indexer/1.0"}, userAgent: "indexer/1.0"},
+		{
+			name:      "header",
+			ctx:       context.Background(),
+			opt:       &Options{UserAgent: "indexer/1.0", HttpHeader: http.Header{"User-Agent": {"proxy/2.0"}}},
+			userAgent: "proxy/2.0",
+		},
+		{
+			name:      "context",
+			ctx:       rpc.WithUserAgent(context.Background(), "tenant-42"),
+			opt:       &Options{UserAgent: "indexer/1.0"},
+			userAgent: "tenant-42",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ConnectWithOptions(test.ctx, url, test.opt)
+			require.NoError(t, err)
+			c.Close()
+			require.Equal(t, test.userAgent, <-userAgents)
+		})
+	}
+}
+
+func TestConnect_compressionAndReadLimit(t *testing.T) {
+	// A 2 MiB notification, which compresses very well.
+	padding := strings.Repeat("0", 2<<20)
+	notification := []byte(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":41,"root":10,"slot":42,"padding":"` + padding + `"},"subscription":7}}`)
+
+	t.Run("compressed", func(t *testing.T) {
+		url, _, closer := mockWSServerWithUpgrader(t, websocket.Upgrader{EnableCompression: true}, 7, notification)
+		defer closer()
+		c, err := Connect(context.Background(), url)
+		require.NoError(t, err)
+		defer c.Close()
+
+		sub, err := c.SlotSubscribe()
+		require.NoError(t, err)
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(42), got.Slot)
+
+		stats := c.ConnStats()
+		require.True(t, stats.Compression)
+		require.Equal(t, uint64(2), stats.MessagesReceived) // The confirmation and the notification.
+		require.Equal(t, uint64(len(notification)), stats.LargestMessage)
+		require.Greater(t, stats.BytesReceived, uint64(len(notification)))
+		require.Less(t, stats.WireBytesReceived, stats.BytesReceived/10)
+	})
+
+	t.Run("compression disabled", func(t *testing.T) {
+		url, _, closer := mockWSServerWithUpgrader(t, websocket.Upgrader{EnableCompression: true}, 7, notification)
+		defer closer()
+		c, err := ConnectWithOptions(context.Background(), url, &Options{DisableCompression: true})
+		require.NoError(t, err)
+		defer c.Close()
+
+		sub, err := c.SlotSubscribe()
+		require.NoError(t, err)
+		_, err = sub.Recv()
+		require.NoError(t, err)
+
+		stats := c.ConnStats()
+		require.False(t, stats.Compression)
+		require.Greater(t, stats.WireBytesReceived, stats.BytesReceived)
+	})
+
+	t.Run("larger than the read limit", func(t *testing.T) {
+		url, _, closer := mockWSServerWithUpgrader(t, websocket.Upgrader{EnableCompression: true}, 7, notification)
+		defer closer()
+		// The compressed frame is within the limit, the message is not.
+		c, err := ConnectWithOptions(context.Background(), url, &Options{ReadLimit: 1 << 20})
+		require.NoError(t, err)
+		defer c.Close()
+
+		sub, err := c.SlotSubscribe()
+		require.NoError(t, err)
+		_, err = sub.Recv()
+		require.ErrorIs(t, err, websocket.ErrReadLimit)
+		require.Equal(t, uint64(1), c.ConnStats().MessagesReceived)
+	})
+}
@@ -23,7 +23,13 @@ import (
 )
 
 func main() {
-	client, err := ws.Connect(context.Background(), rpc.MainNetBeta_WS)
+	client, err := ws.ConnectWithOptions(
+		context.Background(),
+		rpc.MainNetBeta_WS,
+		&ws.Options{
+			EnableUnstable: true,
+		},
+	)
 	if err != nil {
 		panic(err)
 	}
@@ -119,3 +119,8 @@ func (sw *LogSubscription) Recv() (*LogResult, error) {
 func (sw *LogSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *LogSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
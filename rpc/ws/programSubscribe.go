@@ -48,19 +48,53 @@ func (cl *Client) ProgramSubscribeWithOpts(
 	encoding solana.EncodingType,
 	filters []rpc.RPCFilter,
 ) (*ProgramSubscription, error) {
+	return cl.ProgramSubscribeWithConfig(
+		programID,
+		&ProgramSubscribeConfig{
+			Commitment: commitment,
+			Encoding:   encoding,
+			Filters:    filters,
+		},
+	)
+}
+
+type ProgramSubscribeConfig struct {
+	// (optional)
+	Commitment rpc.CommitmentType
+	// Encoding of the account data: "base64" (default), "base64+zstd", "base58" or "jsonParsed".
+	Encoding solana.EncodingType
+	// (optional) Limit the returned account data;
+	// only available for the "base58", "base64" or "base64+zstd" encodings.
+	DataSlice *rpc.DataSlice
+	// (optional) Filter on accounts, implicit AND between filters.
+	Filters []rpc.RPCFilter
+}
+
+// ProgramSubscribeWithConfig subscribes to a program to receive notifications
+// when the lamports or data for a given account owned by the program changes;
+// config can be nil.
+func (cl *Client) ProgramSubscribeWithConfig(
+	programID solana.PublicKey,
+	config *ProgramSubscribeConfig,
+) (*ProgramSubscription, error) {
 
 	params := []interface{}{programID.String()}
 	conf := map[string]interface{}{
 		"encoding": "base64",
 	}
-	if commitment != "" {
-		conf["commitment"] = commitment
-	}
-	if encoding != "" {
-		conf["encoding"] = encoding
-	}
-	if filters != nil && len(filters) > 0 {
-		conf["filters"] = filters
+	if config != nil {
+		if config.Commitment != "" {
+			conf["commitment"] = config.Commitment
+		}
+		if config.Encoding != "" {
+			conf["encoding"] = config.Encoding
+		}
+		if config.DataSlice != nil {
+			conf["dataSlice"] = config.DataSlice
+		}
+		if len(config.Filters) > 0 {
+			conf["filters"] = config.Filters
+		}
 	}
 
 	genSub, err := cl.subscribe(
@@ -98,3 +132,8 @@ func (sw *ProgramSubscription) Recv() (*ProgramResult, error) {
 func (sw *ProgramSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *ProgramSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -54,3 +54,8 @@ func (sw *RootSubscription) Recv() (*RootResult, error) {
 func (sw *RootSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *RootSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -109,3 +109,8 @@ func (sw *SignatureSubscription) RecvWithTimeout(timeout time.Duration) (*Signat
 func (sw *SignatureSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *SignatureSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
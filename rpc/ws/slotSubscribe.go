@@ -57,3 +57,8 @@ func (sw *SlotSubscription) Recv() (*SlotResult, error) {
 func (sw *SlotSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *SlotSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
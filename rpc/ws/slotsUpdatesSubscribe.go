@@ -14,10 +14,14 @@
 
 package ws
 
-import "github.com/gagliardetto/solana-go"
+import (
+	stdjson "encoding/json"
+
+	"github.com/gagliardetto/solana-go"
+)
 
 type SlotsUpdatesResult struct {
-	// The parent slot.
+	// The parent slot (only for "createdBank" updates).
 	Parent uint64 `json:"parent"`
 	// The newly updated slot.
 	Slot uint64 `json:"slot"`
@@ -27,6 +31,23 @@ type SlotsUpdatesResult struct {
 	Type SlotsUpdatesType `json:"type"`
 	// Extra stats provided when a bank is frozen.
 	Stats *BankStats `json:"stats"`
+	// The error (only for "dead" updates).
+	Err string `json:"err,omitempty"`
+
+	// The raw JSON of the update; useful for update types
+	// that are not known to this package (see SlotsUpdatesType.IsKnown).
+	Raw stdjson.RawMessage `json:"-"`
+}
+
+func (res *SlotsUpdatesResult) UnmarshalJSON(data []byte) error {
+	type alias SlotsUpdatesResult
+	var out alias
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*res = SlotsUpdatesResult(out)
+	res.Raw = append(stdjson.RawMessage(nil), data...)
+	return nil
 }
 
 type BankStats struct {
@@ -48,12 +69,34 @@ const (
 	SlotsUpdatesRoot                   SlotsUpdatesType = "root"
 )
 
+// IsKnown returns true if the update type is one of the types known to this package.
+func (typ SlotsUpdatesType) IsKnown() bool {
+	switch typ {
+	case SlotsUpdatesFirstShredReceived,
+		SlotsUpdatesCompleted,
+		SlotsUpdatesCreatedBank,
+		SlotsUpdatesFrozen,
+		SlotsUpdatesDead,
+		SlotsUpdatesOptimisticConfirmation,
+		SlotsUpdatesRoot:
+		return true
+	default:
+		return false
+	}
+}
+
 // SlotsUpdatesSubscribe (UNSTABLE) subscribes to receive a notification
 // from the validator on a variety of updates on every slot.
 //
 // This subscription is unstable; the format of this subscription
 // may change in the future and it may not always be supported.
+//
+// Returns ErrUnstableDisabled unless the client was created
+// with `Options.EnableUnstable`.
 func (cl *Client) SlotsUpdatesSubscribe() (*SlotsUpdatesSubscription, error) {
+	if !cl.enableUnstable {
+		return nil, ErrUnstableDisabled
+	}
 	genSub, err := cl.subscribe(
 		nil,
 		nil,
@@ -89,3 +132,8 @@ func (sw *SlotsUpdatesSubscription) Recv() (*SlotsUpdatesResult, error) {
 func (sw *SlotsUpdatesSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *SlotsUpdatesSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 github.com/gagliardetto
+// This file has been modified by github.com/gagliardetto
+//
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// ConnStats describes the traffic of the connection of a client,
+// e.g. to monitor the bandwidth saved by the compression.
+type ConnStats struct {
+	// Whether the permessage-deflate extension was negotiated with the node.
+	Compression bool
+	// Number of messages received.
+	MessagesReceived uint64
+	// Total size of the messages received, after decompression.
+	BytesReceived uint64
+	// Total number of bytes read from the network, i.e. the frames as sent by the node
+	// (compressed, if Compression is true), and the TLS overhead for wss endpoints.
+	WireBytesReceived uint64
+	// Size of the largest message received, after decompression.
+	LargestMessage uint64
+}
+
+// connStats holds the counters of ConnStats.
+type connStats struct {
+	// Accessed atomically.
+	messagesReceived  uint64
+	bytesReceived     uint64
+	wireBytesReceived uint64
+	largestMessage    uint64
+}
+
+func (s *connStats) messageReceived(size int) {
+	atomic.AddUint64(&s.messagesReceived, 1)
+	atomic.AddUint64(&s.bytesReceived, uint64(size))
+	for {
+		largest := atomic.LoadUint64(&s.largestMessage)
+		if uint64(size) <= largest || atomic.CompareAndSwapUint64(&s.largestMessage, largest, uint64(size)) {
+			return
+		}
+	}
+}
+
+// countingConn counts the bytes read from the network.
+type countingConn struct {
+	net.Conn
+	stats *connStats
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(&c.stats.wireBytesReceived, uint64(n))
+	return n, err
+}
+
+// ConnStats returns the traffic counters of the connection.
+func (c *Client) ConnStats() ConnStats {
+	return ConnStats{
+		Compression:       c.compression,
+		MessagesReceived:  atomic.LoadUint64(&c.stats.messagesReceived),
+		BytesReceived:     atomic.LoadUint64(&c.stats.bytesReceived),
+		WireBytesReceived: atomic.LoadUint64(&c.stats.wireBytesReceived),
+		LargestMessage:    atomic.LoadUint64(&c.stats.largestMessage),
+	}
+}
@@ -17,14 +17,120 @@
 
 package ws
 
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBufferSize is the default number of notifications buffered
+// for each subscription.
+const DefaultBufferSize = 200_000
+
+// BufferPolicy defines what happens when a notification is received
+// and the buffer of the subscription is full (i.e. the consumer is too slow).
+type BufferPolicy int
+
+const (
+	// CloseOnFull closes the subscription with an error (default).
+	CloseOnFull BufferPolicy = iota
+	// DropOldest drops the oldest buffered notification to make room for the new one.
+	DropOldest
+	// DropNewest drops the new notification.
+	DropNewest
+	// Block waits for the consumer to make room in the buffer.
+	// NOTE: the client reads all the subscriptions from the same connection,
+	// so a stalled consumer stalls all of them (and the server might disconnect the client).
+	Block
+	// CoalesceLatest only keeps the latest notification: a new notification
+	// replaces the one that wasn't consumed yet (the buffer size is ignored).
+	// Useful for account subscriptions, where only the latest state matters.
+	CoalesceLatest
+)
+
+func (p BufferPolicy) String() string {
+	switch p {
+	case CloseOnFull:
+		return "CloseOnFull"
+	case DropOldest:
+		return "DropOldest"
+	case DropNewest:
+		return "DropNewest"
+	case Block:
+		return "Block"
+	case CoalesceLatest:
+		return "CoalesceLatest"
+	default:
+		return "Unknown"
+	}
+}
+
+// BufferOptions configures the notification buffer of a subscription.
+type BufferOptions struct {
+	// Number of notifications buffered; defaults to DefaultBufferSize.
+	Size int
+	// What to do when the buffer is full; defaults to CloseOnFull.
+	Policy BufferPolicy
+	// If set, called each time a notification is dropped, with the subscription
+	// method (e.g. "accountSubscribe") and the number of notifications
+	// dropped so far by the subscription.
+	// It is called from the goroutine that reads the messages, so it must not block.
+	OnDrop func(method string, dropped uint64)
+}
+
+func (opts BufferOptions) size() int {
+	if opts.Policy == CoalesceLatest {
+		return 1
+	}
+	if opts.Size <= 0 {
+		return DefaultBufferSize
+	}
+	return opts.Size
+}
+
+// SubscriptionStats can be used to detect a lagging consumer.
+type SubscriptionStats struct {
+	// Number of notifications received and not consumed yet.
+	Pending int
+	// Number of notifications dropped because the buffer was full.
+	Dropped uint64
+}
+
+// SubscriptionInfo describes a subscription of a client.
+type SubscriptionInfo struct {
+	// The subscription ID assigned by the node; zero until the subscription is confirmed.
+	ID uint64
+	// ID of the subscription request.
+	RequestID uint64
+	// The subscription method, e.g. "accountSubscribe".
+	Method string
+	// The params of the subscription request, including the configuration object.
+	Params []interface{}
+	// Number of notifications received (including the dropped ones).
+	MessagesReceived uint64
+	// When the last notification was received; zero if none was received yet.
+	LastMessageAt time.Time
+	SubscriptionStats
+}
+
 type Subscription struct {
 	req               *request
 	subID             uint64
 	stream            chan result
 	err               chan error
 	closeFunc         func(err error)
+	subscribeMethod   string
 	unsubscribeMethod string
 	decoderFunc       decoderFunc
+	buffer            BufferOptions
+	dropped           uint64
+	closed            chan struct{}
+	closeOnce         sync.Once
+	// Closed when the node confirms the subscription.
+	confirmed chan struct{}
+	// Accessed atomically.
+	messagesReceived uint64
+	lastMessageAt    int64 // Unix nanoseconds.
 }
 
 type decoderFunc func([]byte) (interface{}, error)
@@ -34,15 +140,20 @@ func newSubscription(
 	closeFunc func(err error),
 	unsubscribeMethod string,
 	decoderFunc decoderFunc,
+	buffer BufferOptions,
 ) *Subscription {
 	return &Subscription{
 		req:               req,
 		subID:             0,
-		stream:            make(chan result, 200_000),
+		stream:            make(chan result, buffer.size()),
 		err:               make(chan error, 100_000),
 		closeFunc:         closeFunc,
+		subscribeMethod:   req.Method,
 		unsubscribeMethod: unsubscribeMethod,
 		decoderFunc:       decoderFunc,
+		buffer:            buffer,
+		closed:            make(chan struct{}),
+		confirmed:         make(chan struct{}),
 	}
 }
 
@@ -55,6 +166,37 @@ func (s *Subscription) Recv() (interface{}, error) {
 	}
 }
 
+// Stats returns the number of pending and dropped notifications.
+func (s *Subscription) Stats() SubscriptionStats {
+	return SubscriptionStats{
+		Pending: len(s.stream),
+		Dropped: atomic.LoadUint64(&s.dropped),
+	}
+}
+
+func (s *Subscription) received(at time.Time) {
+	atomic.AddUint64(&s.messagesReceived, 1)
+	atomic.StoreInt64(&s.lastMessageAt, at.UnixNano())
+}
+
+// info must be called with the lock of the client held (for the subID).
+func (s *Subscription) info() SubscriptionInfo {
+	out := SubscriptionInfo{
+		ID:                s.subID,
+		RequestID:         s.req.ID,
+		Method:            s.subscribeMethod,
+		MessagesReceived:  atomic.LoadUint64(&s.messagesReceived),
+		SubscriptionStats: s.Stats(),
+	}
+	if params, ok := s.req.Params.([]interface{}); ok {
+		out.Params = append([]interface{}(nil), params...)
+	}
+	if at := atomic.LoadInt64(&s.lastMessageAt); at != 0 {
+		out.LastMessageAt = time.Unix(0, at)
+	}
+	return out
+}
+
 func (s *Subscription) Unsubscribe() {
 	s.unsubscribe(nil)
 }
@@ -62,3 +204,61 @@ func (s *Subscription) Unsubscribe() {
 func (s *Subscription) unsubscribe(err error) {
 	s.closeFunc(err)
 }
+
+// markClosed unblocks a deliver waiting for room in the buffer.
+func (s *Subscription) markClosed() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
+
+func (s *Subscription) drop() {
+	dropped := atomic.AddUint64(&s.dropped, 1)
+	if s.buffer.OnDrop != nil {
+		s.buffer.OnDrop(s.subscribeMethod, dropped)
+	}
+}
+
+// deliver buffers the notification according to the buffer policy.
+// It returns false if the buffer is full and the policy is CloseOnFull.
+// It must be called from one goroutine at a time.
+func (s *Subscription) deliver(res result, cancel <-chan struct{}) bool {
+	switch s.buffer.Policy {
+	case Block:
+		select {
+		case s.stream <- res:
+		case <-s.closed:
+		case <-cancel:
+		}
+		return true
+	case DropNewest:
+		select {
+		case s.stream <- res:
+		default:
+			s.drop()
+		}
+		return true
+	case DropOldest, CoalesceLatest:
+		for {
+			select {
+			case s.stream <- res:
+				return true
+			default:
+			}
+			// The consumer might take the oldest notification in the meantime,
+			// in which case there is nothing to drop.
+			select {
+			case <-s.stream:
+				s.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case s.stream <- res:
+			return true
+		default:
+			return false
+		}
+	}
+}
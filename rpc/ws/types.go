@@ -66,6 +66,30 @@ type params struct {
 type Options struct {
 	HttpHeader       http.Header
 	HandshakeTimeout time.Duration
+	// UserAgent is the User-Agent header of the handshake, to identify the client
+	// to the RPC provider (default: rpc.DefaultUserAgent()).
+	// A User-Agent in HttpHeader takes precedence, and so does the one set
+	// on the context of ConnectWithOptions with rpc.WithUserAgent.
+	UserAgent string
+	// EnableUnstable enables the subscriptions that are marked
+	// as unstable upstream (blockSubscribe, voteSubscribe, slotsUpdatesSubscribe).
+	EnableUnstable bool
+	// Buffer configures the notification buffer of the subscriptions.
+	Buffer BufferOptions
+	// BufferByMethod overrides Buffer for the subscriptions with the provided
+	// subscription method, e.g. {"accountSubscribe": {Policy: CoalesceLatest}}.
+	BufferByMethod map[string]BufferOptions
+	// ReadLimit is the max size of a message received from the node, after decompression
+	// (default: DefaultReadLimit); when a message is larger, the subscriptions are closed
+	// with an error wrapping websocket.ErrReadLimit. A negative value disables the limit.
+	ReadLimit int64
+	// DisableCompression disables the negotiation of the permessage-deflate extension,
+	// which is otherwise offered to the node (see Client.ConnStats).
+	DisableCompression bool
 }
 
 var DefaultHandshakeTimeout = 45 * time.Second
+
+// DefaultReadLimit is the default max size of a message received from the node;
+// the notifications of full blocks can be several megabytes.
+const DefaultReadLimit = 50 << 20 // 50 MiB
@@ -19,12 +19,16 @@ import (
 )
 
 type VoteResult struct {
+	// The vote account address.
+	VotePubkey solana.PublicKey `json:"votePubkey"`
 	// The vote hash.
 	Hash solana.Hash `json:"hash"`
 	// The slots covered by the vote.
 	Slots []uint64 `json:"slots"`
 	// The timestamp of the vote.
 	Timestamp *solana.UnixTimeSeconds `json:"timestamp,omitempty"`
+	// The signature of the transaction that contained this vote.
+	Signature solana.Signature `json:"signature"`
 }
 
 // VoteSubscribe (UNSTABLE, disabled by default) subscribes
@@ -35,7 +39,13 @@ type VoteResult struct {
 // This subscription is unstable and only available if the validator
 // was started with the --rpc-pubsub-enable-vote-subscription flag.
 // The format of this subscription may change in the future.
+//
+// Returns ErrUnstableDisabled unless the client was created
+// with `Options.EnableUnstable`.
 func (cl *Client) VoteSubscribe() (*VoteSubscription, error) {
+	if !cl.enableUnstable {
+		return nil, ErrUnstableDisabled
+	}
 	genSub, err := cl.subscribe(
 		nil,
 		nil,
@@ -71,3 +81,8 @@ func (sw *VoteSubscription) Recv() (*VoteResult, error) {
 func (sw *VoteSubscription) Unsubscribe() {
 	sw.sub.Unsubscribe()
 }
+
+// Stats returns the number of pending and dropped notifications.
+func (sw *VoteSubscription) Stats() SubscriptionStats {
+	return sw.sub.Stats()
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package solanatest provides deterministic keypairs and fixtures
+// (accounts, transactions) for the tests of code that uses solana-go.
+//
+// The fixtures are built from the provided values only,
+// so the same inputs always give the same outputs;
+// the functions panic on invalid inputs.
+package solanatest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// RentExemptTokenAccountLamports is the minimum balance
+	// for rent exemption of a token account (165 bytes).
+	RentExemptTokenAccountLamports uint64 = 2039280
+
+	// FixtureRentEpoch is the rent epoch of the account fixtures.
+	FixtureRentEpoch uint64 = 361
+)
+
+// KeypairFromSeedString returns the keypair whose ed25519 seed
+// is the sha256 hash of the string, e.g. KeypairFromSeedString("alice"):
+// the same string always gives the same keypair.
+func KeypairFromSeedString(seed string) solana.PrivateKey {
+	hash := sha256.Sum256([]byte(seed))
+	return solana.PrivateKey(ed25519.NewKeyFromSeed(hash[:]))
+}
+
+// PublicKeyFromSeedString returns the public key of KeypairFromSeedString(seed).
+func PublicKeyFromSeedString(seed string) solana.PublicKey {
+	return KeypairFromSeedString(seed).PublicKey()
+}
+
+// FundedAccountFixture returns a system account (without data) holding the lamports,
+// e.g. the account of a wallet.
+func FundedAccountFixture(lamports uint64) *rpc.Account {
+	return &rpc.Account{
+		Lamports:  lamports,
+		Owner:     solana.SystemProgramID,
+		Data:      rpc.DataBytesOrJSONFromBytes([]byte{}),
+		RentEpoch: FixtureRentEpoch,
+	}
+}
+
+// TokenAccountFixture returns an initialized token account of the mint
+// (owned by the token program, and rent exempt) holding the amount of tokens.
+// Its address is usually the associated token account of the owner
+// (see solana.FindAssociatedTokenAddress).
+func TokenAccountFixture(mint solana.PublicKey, owner solana.PublicKey, amount uint64) *rpc.Account {
+	buf := new(bytes.Buffer)
+	err := bin.NewBinEncoder(buf).Encode(token.Account{
+		Mint:   mint,
+		Owner:  owner,
+		Amount: amount,
+		State:  token.Initialized,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &rpc.Account{
+		Lamports:  RentExemptTokenAccountLamports,
+		Owner:     solana.TokenProgramID,
+		Data:      rpc.DataBytesOrJSONFromBytes(buf.Bytes()),
+		RentEpoch: FixtureRentEpoch,
+	}
+}
+
+// SignedTransferTxFixture returns a transaction, paid and signed by from,
+// that transfers the lamports to the recipient.
+func SignedTransferTxFixture(from solana.PrivateKey, to solana.PublicKey, lamports uint64, blockhash solana.Hash) *solana.Transaction {
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(lamports, from.PublicKey(), to).Build(),
+		},
+		blockhash,
+		solana.TransactionPayer(from.PublicKey()),
+	)
+	if err != nil {
+		panic(err)
+	}
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(from.PublicKey()) {
+			return &from
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return tx
+}
+
+// AccountJSON returns the account as returned by the RPC (e.g. the "value"
+// of getAccountInfo), to be used in the responses of a mock RPC server.
+func AccountJSON(account *rpc.Account) string {
+	out, err := json.Marshal(account)
+	if err != nil {
+		panic(err)
+	}
+	return string(out)
+}
+
+// KeyedAccountJSON returns the account with its address as returned by the RPC
+// (e.g. an item of the result of getProgramAccounts).
+func KeyedAccountJSON(pubkey solana.PublicKey, account *rpc.Account) string {
+	out, err := json.Marshal(rpc.KeyedAccount{Pubkey: pubkey, Account: account})
+	if err != nil {
+		panic(err)
+	}
+	return string(out)
+}
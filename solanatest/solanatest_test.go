@@ -0,0 +1,92 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solanatest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeypairFromSeedString(t *testing.T) {
+	alice := KeypairFromSeedString("alice")
+	assert.Equal(t, alice, KeypairFromSeedString("alice"))
+	assert.NotEqual(t, alice, KeypairFromSeedString("bob"))
+	// A valid keypair.
+	_, err := solana.PrivateKeyFromBytes(alice)
+	require.NoError(t, err)
+	assert.Equal(t, alice.PublicKey(), PublicKeyFromSeedString("alice"))
+	// Stable across versions.
+	assert.Equal(t, "FPP21sbqhr2LPjSnJkw5NBetPubeFG4PsQFBxHj8noTq", alice.PublicKey().String())
+}
+
+func TestTokenAccountFixture(t *testing.T) {
+	mint := PublicKeyFromSeedString("mint")
+	owner := PublicKeyFromSeedString("alice")
+	account := TokenAccountFixture(mint, owner, 1000)
+	assert.Equal(t, solana.TokenProgramID, account.Owner)
+	assert.Equal(t, RentExemptTokenAccountLamports, account.Lamports)
+	require.Len(t, account.Data.GetBinary(), token.ACCOUNT_SIZE)
+
+	var decoded token.Account
+	require.NoError(t, bin.NewBinDecoder(account.Data.GetBinary()).Decode(&decoded))
+	assert.Equal(t, token.Account{Mint: mint, Owner: owner, Amount: 1000, State: token.Initialized}, decoded)
+
+	// As returned by the RPC.
+	var fromJSON rpc.Account
+	require.NoError(t, json.Unmarshal([]byte(AccountJSON(account)), &fromJSON))
+	assert.Equal(t, account, &fromJSON)
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(AccountJSON(account)), &raw))
+	assert.Equal(t, []interface{}{base64.StdEncoding.EncodeToString(account.Data.GetBinary()), "base64"}, raw["data"])
+
+	var keyed rpc.KeyedAccount
+	require.NoError(t, json.Unmarshal([]byte(KeyedAccountJSON(owner, account)), &keyed))
+	assert.Equal(t, owner, keyed.Pubkey)
+	assert.Equal(t, account, keyed.Account)
+}
+
+func TestFundedAccountFixture(t *testing.T) {
+	account := FundedAccountFixture(5 * solana.LAMPORTS_PER_SOL)
+	assert.Equal(t, 5*solana.LAMPORTS_PER_SOL, account.Lamports)
+	assert.Equal(t, solana.SystemProgramID, account.Owner)
+	assert.Empty(t, account.Data.GetBinary())
+}
+
+func TestSignedTransferTxFixture(t *testing.T) {
+	alice := KeypairFromSeedString("alice")
+	bob := PublicKeyFromSeedString("bob")
+	blockhash := solana.Hash{1, 2, 3}
+	tx := SignedTransferTxFixture(alice, bob, 42, blockhash)
+	require.NoError(t, tx.VerifySignatures())
+	assert.Equal(t, tx, SignedTransferTxFixture(alice, bob, 42, blockhash))
+
+	require.Len(t, tx.Message.Instructions, 1)
+	accounts, err := tx.Message.Instructions[0].ResolveInstructionAccounts(&tx.Message)
+	require.NoError(t, err)
+	decoded, err := system.DecodeInstruction(accounts, tx.Message.Instructions[0].Data)
+	require.NoError(t, err)
+	transfer := decoded.Impl.(*system.Transfer)
+	assert.Equal(t, uint64(42), *transfer.Lamports)
+	assert.Equal(t, bob, transfer.GetRecipientAccount().PublicKey)
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+// TokenInfo is what a summary needs to know about a token.
+type TokenInfo struct {
+	Decimals uint8
+	// E.g. "USDC"; optional.
+	Symbol string
+}
+
+// SummaryContext is the context used to summarize the instructions.
+// The zero value (and nil) is a valid context, where nothing can be resolved.
+type SummaryContext struct {
+	// Optional. Returns the info of the token of the provided mint, or of the provided
+	// token account (for the instructions that don't reference the mint, e.g. the token Transfer);
+	// ok is false if the token is unknown.
+	ResolveToken func(mintOrTokenAccount PublicKey) (info TokenInfo, ok bool)
+}
+
+// Token returns the info of the token of the mint or token account, if it can be resolved.
+func (ctx *SummaryContext) Token(mintOrTokenAccount PublicKey) (TokenInfo, bool) {
+	if ctx == nil || ctx.ResolveToken == nil {
+		return TokenInfo{}, false
+	}
+	return ctx.ResolveToken(mintOrTokenAccount)
+}
+
+// Summarizer is implemented by the (decoded) instructions that can be
+// described in a single line, e.g. "Transfer 1.5 USDC from <source> to <destination>".
+// Summary returns an empty string if the instruction has no summary.
+type Summarizer interface {
+	Summary(ctx *SummaryContext) string
+}
+
+// Summaries returns the summary of each instruction of the transaction
+// (decoded with the registered instruction decoders), in order;
+// the summary is empty for the instructions that can't be decoded or summarized.
+func (tx *Transaction) Summaries(ctx *SummaryContext) []string {
+	out := make([]string, len(tx.Message.Instructions))
+	for i, inst := range tx.Message.Instructions {
+		out[i] = tx.summarize(inst, ctx)
+	}
+	return out
+}
+
+func (tx *Transaction) summarize(inst CompiledInstruction, ctx *SummaryContext) string {
+	programID, err := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+	if err != nil {
+		return ""
+	}
+	accounts, err := inst.ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		return ""
+	}
+	decoded, err := DecodeInstruction(programID, accounts, inst.Data)
+	if err != nil {
+		return ""
+	}
+	if summarizer, ok := decoded.(Summarizer); ok {
+		return summarizer.Summary(ctx)
+	}
+	return ""
+}
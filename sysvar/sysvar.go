@@ -0,0 +1,112 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sysvar decodes the data of the sysvar accounts
+// (see the solana.SysVar*Pubkey addresses).
+package sysvar
+
+import (
+	"fmt"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+const (
+	// Size of the data of the Clock sysvar account.
+	ClockSize = 40
+	// Size of the data of the Rent sysvar account.
+	RentSize = 17
+
+	// The bytes of an account that are not in its data (e.g. its lamports and owner),
+	// but that its rent is paid for.
+	AccountStorageOverhead = 128
+)
+
+// Clock is the data of the Clock sysvar account (solana.SysVarClockPubkey).
+type Clock struct {
+	// The current slot.
+	Slot uint64
+	// The timestamp of the first slot in this epoch.
+	EpochStartTimestamp int64
+	// The current epoch.
+	Epoch uint64
+	// The future epoch for which the leader schedule has most recently been calculated.
+	LeaderScheduleEpoch uint64
+	// The approximate real world time of the current slot, as Unix timestamp (seconds since the Unix epoch).
+	UnixTimestamp int64
+}
+
+// Time returns the UnixTimestamp as a time.Time.
+func (c *Clock) Time() time.Time {
+	return time.Unix(c.UnixTimestamp, 0)
+}
+
+// Rent is the data of the Rent sysvar account (solana.SysVarRentPubkey).
+type Rent struct {
+	// Rental rate, in lamports per byte-year.
+	LamportsPerByteYear uint64
+	// Number of years of rent that an account must hold to be exempt from rent.
+	ExemptionThreshold float64
+	// The percentage of collected rent that is burned.
+	BurnPercent uint8
+}
+
+// MinimumBalance returns the minimum balance (in lamports) for an account
+// with the provided size of data to be rent exempt; it is the same value
+// as returned by the getMinimumBalanceForRentExemption RPC method:
+// (AccountStorageOverhead + dataSize) * LamportsPerByteYear * ExemptionThreshold,
+// truncated to an integer.
+func (r *Rent) MinimumBalance(dataSize uint64) uint64 {
+	bytes := AccountStorageOverhead + dataSize
+	return uint64(float64(bytes*r.LamportsPerByteYear) * r.ExemptionThreshold)
+}
+
+// DecodeClock decodes the data of the Clock sysvar account.
+func DecodeClock(data []byte) (*Clock, error) {
+	if len(data) != ClockSize {
+		return nil, fmt.Errorf("invalid Clock sysvar size: expected %d bytes, got %d", ClockSize, len(data))
+	}
+	out := new(Clock)
+	if err := bin.NewBinDecoder(data).Decode(out); err != nil {
+		return nil, fmt.Errorf("unable to decode Clock sysvar: %w", err)
+	}
+	return out, nil
+}
+
+// DecodeRent decodes the data of the Rent sysvar account.
+func DecodeRent(data []byte) (*Rent, error) {
+	if len(data) != RentSize {
+		return nil, fmt.Errorf("invalid Rent sysvar size: expected %d bytes, got %d", RentSize, len(data))
+	}
+	out := new(Rent)
+	if err := bin.NewBinDecoder(data).Decode(out); err != nil {
+		return nil, fmt.Errorf("unable to decode Rent sysvar: %w", err)
+	}
+	return out, nil
+}
+
+// DecodeAccount decodes the data of the sysvar account at the provided address:
+// it returns a *Clock or a *Rent.
+func DecodeAccount(address solana.PublicKey, data []byte) (interface{}, error) {
+	switch address {
+	case solana.SysVarClockPubkey:
+		return DecodeClock(data)
+	case solana.SysVarRentPubkey:
+		return DecodeRent(data)
+	default:
+		return nil, fmt.Errorf("no decoder for sysvar account %s", address)
+	}
+}
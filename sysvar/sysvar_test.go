@@ -0,0 +1,84 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysvar
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustBase64(t *testing.T, s string) []byte {
+	data, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodeClock(t *testing.T) {
+	data := mustBase64(t, "AAAAEAAAAABAV1dmAAAAAG0CAAAAAAAAbgIAAAAAAAAF9llmAAAAAA==")
+
+	clock, err := DecodeClock(data)
+	require.NoError(t, err)
+	assert.Equal(t, &Clock{
+		Slot:                268435456,
+		EpochStartTimestamp: 1717000000,
+		Epoch:               621,
+		LeaderScheduleEpoch: 622,
+		UnixTimestamp:       1717171717,
+	}, clock)
+	assert.Equal(t, time.Unix(1717171717, 0), clock.Time())
+
+	decoded, err := DecodeAccount(solana.SysVarClockPubkey, data)
+	require.NoError(t, err)
+	assert.Equal(t, clock, decoded)
+
+	_, err = DecodeClock(data[:39])
+	assert.EqualError(t, err, "invalid Clock sysvar size: expected 40 bytes, got 39")
+}
+
+func TestDecodeRent(t *testing.T) {
+	// The mainnet-beta Rent.
+	data := mustBase64(t, "mA0AAAAAAAAAAAAAAAAAQDI=")
+
+	rent, err := DecodeRent(data)
+	require.NoError(t, err)
+	assert.Equal(t, &Rent{
+		LamportsPerByteYear: 3480,
+		ExemptionThreshold:  2,
+		BurnPercent:         50,
+	}, rent)
+	assert.Equal(t, uint64(890880), rent.MinimumBalance(0))
+	// A token account.
+	assert.Equal(t, uint64(2039280), rent.MinimumBalance(165))
+	// A mint.
+	assert.Equal(t, uint64(1461600), rent.MinimumBalance(82))
+
+	// The result is truncated, as by the node.
+	fractional := &Rent{LamportsPerByteYear: 3, ExemptionThreshold: 1.5}
+	assert.Equal(t, uint64(580), fractional.MinimumBalance(1))
+
+	decoded, err := DecodeAccount(solana.SysVarRentPubkey, data)
+	require.NoError(t, err)
+	assert.Equal(t, rent, decoded)
+
+	_, err = DecodeRent(append(data, 0))
+	assert.EqualError(t, err, "invalid Rent sysvar size: expected 17 bytes, got 18")
+	_, err = DecodeAccount(solana.SysVarFeesPubkey, data)
+	assert.EqualError(t, err, "no decoder for sysvar account SysvarFees111111111111111111111111111111111")
+}
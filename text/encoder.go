@@ -180,7 +180,7 @@ func (e *Encoder) ToWriter(s string, indent bool, c *color.Color) (err error) {
 			return err
 		}
 	}
-	if c != nil {
+	if c != nil && !DisableColors {
 		s = c.Sprintf("%s", s)
 	}
 	_, err = e.output.Write([]byte(s))
@@ -15,6 +15,7 @@
 package format
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/davecgh/go-spew/spew"
@@ -47,6 +48,91 @@ func Account(name string, pubKey solana.PublicKey) string {
 	return Shakespeare(name) + ": " + text.ColorizeBG(pubKey.String())
 }
 
+// FullPubkeys makes ShortPubkey return the full pubkeys.
+var FullPubkeys = false
+
+// ShortPubkey returns the first 4 and the last 3 characters of the pubkey
+// (e.g. "4wBq…S6j"), colored like the full pubkey would be;
+// it returns the full pubkey if FullPubkeys is set.
+func ShortPubkey(pubKey solana.PublicKey) string {
+	str := pubKey.String()
+	short := str
+	if !FullPubkeys && len(str) > 7 {
+		short = str[:4] + "…" + str[len(str)-3:]
+	}
+	if DisableColors {
+		return short
+	}
+	return text.StringToColorBG(str)(short)
+}
+
+// Lamports renders the amount in SOL and in lamports,
+// e.g. "1.5 SOL (1,500,000,000 lamports)".
+func Lamports(lamports uint64) string {
+	sol := TokenAmount(strconv.FormatUint(lamports, 10), 9)
+	return Lime(sol+" SOL") + " (" + groupThousands(strconv.FormatUint(lamports, 10)) + " lamports)"
+}
+
+// LamportsParam is like Param, but renders the value with Lamports.
+func LamportsParam(name string, lamports uint64) string {
+	return Shakespeare(name) + ": " + Lamports(lamports)
+}
+
+// TokenAmount returns the decimal representation of the raw (integer) amount
+// of a token with the provided decimals, without trailing zeros,
+// e.g. TokenAmount("1500000", 6) is "1.5".
+// If raw is not an unsigned integer, it's returned as is.
+func TokenAmount(raw string, decimals uint8) string {
+	if raw == "" || strings.TrimLeft(raw, "0123456789") != "" {
+		return raw
+	}
+	raw = strings.TrimLeft(raw, "0")
+	if decimals == 0 {
+		if raw == "" {
+			return "0"
+		}
+		return raw
+	}
+	if len(raw) <= int(decimals) {
+		raw = strings.Repeat("0", int(decimals)-len(raw)+1) + raw
+	}
+	whole := raw[:len(raw)-int(decimals)]
+	fraction := strings.TrimRight(raw[len(raw)-int(decimals):], "0")
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
+
+// TokenAmountParam is like Param, but renders the value with TokenAmount,
+// followed by the raw amount.
+func TokenAmountParam(name string, amount uint64, decimals uint8) string {
+	raw := strconv.FormatUint(amount, 10)
+	return Shakespeare(name) + ": " + Lime(TokenAmount(raw, decimals)) + " (" + raw + ")"
+}
+
+// Slot renders the slot with thousands separators, e.g. "123,456,789".
+func Slot(slot uint64) string {
+	return groupThousands(strconv.FormatUint(slot, 10))
+}
+
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var out strings.Builder
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+	out.WriteString(digits[:head])
+	for i := head; i < len(digits); i += 3 {
+		out.WriteByte(',')
+		out.WriteString(digits[i : i+3])
+	}
+	return out.String()
+}
+
 func MetaIfSetByIndex(name string, metaSlice solana.AccountMetaSlice, index int) string {
 	if metaSlice == nil {
 		return Meta(name, nil)
@@ -94,3 +180,48 @@ func foreachLine(str string, transform sf) (out string) {
 	}
 	return
 }
+
+// The Summary* functions render the parts of the instruction summaries
+// (see solana.Summarizer), without colors.
+
+// SummaryMintAmount renders a token amount, e.g. "1.5 USDC".
+// The decimals are the ones of the instruction if not nil (e.g. TransferChecked),
+// otherwise they are resolved with the context; the token is named by its symbol
+// if it can be resolved, otherwise by its mint.
+// Without decimals, the raw amount is rendered, e.g. "1500000 base units of <mint>".
+func SummaryMintAmount(ctx *solana.SummaryContext, amount uint64, mint solana.PublicKey, decimals *uint8) string {
+	info, ok := ctx.Token(mint)
+	if decimals != nil {
+		info.Decimals = *decimals
+	}
+	name := info.Symbol
+	if name == "" {
+		name = mint.String()
+	}
+	raw := strconv.FormatUint(amount, 10)
+	if !ok && decimals == nil {
+		return raw + " base units of " + name
+	}
+	return TokenAmount(raw, info.Decimals) + " " + name
+}
+
+// SummaryTokenAccountAmount renders a token amount for the instructions
+// that don't reference the mint: the token is resolved from the token account,
+// e.g. "1.5 USDC"; if it can't be resolved, the raw amount is rendered, e.g. "1500000 base units".
+func SummaryTokenAccountAmount(ctx *solana.SummaryContext, amount uint64, tokenAccount solana.PublicKey) string {
+	raw := strconv.FormatUint(amount, 10)
+	info, ok := ctx.Token(tokenAccount)
+	if !ok {
+		return raw + " base units"
+	}
+	out := TokenAmount(raw, info.Decimals)
+	if info.Symbol != "" {
+		out += " " + info.Symbol
+	}
+	return out
+}
+
+// SummaryLamports renders the amount in SOL, e.g. "1.5 SOL".
+func SummaryLamports(lamports uint64) string {
+	return TokenAmount(strconv.FormatUint(lamports, 10), 9) + " SOL"
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/stretchr/testify/require"
+)
+
+func withColors(t *testing.T, enabled bool) {
+	prev := text.DisableColors
+	text.DisableColors = !enabled
+	t.Cleanup(func() { text.DisableColors = prev })
+}
+
+func TestFormatters_golden(t *testing.T) {
+	withColors(t, false)
+	pubkey := solana.MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+
+	var lines []string
+	for _, lamports := range []uint64{0, 1, 10, 1_000_000_000, 1_500_000_000, 2_039_280, 18446744073709551615} {
+		lines = append(lines, "Lamports: "+Lamports(lamports))
+	}
+	for _, tc := range []struct {
+		raw      string
+		decimals uint8
+	}{
+		{"0", 0},
+		{"0", 6},
+		{"1500000", 6},
+		{"1", 9},
+		{"000123", 2},
+		{"100", 2},
+		{"18446744073709551615", 9},
+		{"not-a-number", 6},
+	} {
+		lines = append(lines, "TokenAmount: "+TokenAmount(tc.raw, tc.decimals))
+	}
+	for _, slot := range []uint64{0, 999, 1000, 123456789, 1234567890} {
+		lines = append(lines, "Slot: "+Slot(slot))
+	}
+	lines = append(lines, "ShortPubkey: "+ShortPubkey(pubkey))
+	FullPubkeys = true
+	lines = append(lines, "ShortPubkey (full): "+ShortPubkey(pubkey))
+	FullPubkeys = false
+	lines = append(lines, LamportsParam("Lamports", 5000))
+	lines = append(lines, TokenAmountParam("Amount", 1234500, 4))
+
+	golden, err := ioutil.ReadFile("testdata/formatters.golden")
+	require.NoError(t, err)
+	require.Equal(t, string(golden), strings.Join(lines, "\n")+"\n")
+}
+
+func TestFormatters_colors(t *testing.T) {
+	pubkey := solana.MustPublicKeyFromBase58("4wBqpZM9xaSheZzJSMawUKKwhdpChKbZ5eu5ky4Vigw")
+
+	withColors(t, true)
+	require.Contains(t, Lamports(1), "\033[")
+	require.Contains(t, ShortPubkey(pubkey), "\033[")
+	require.Contains(t, ShortPubkey(pubkey), "4wBq…igw")
+	// Same colors as the full pubkey.
+	FullPubkeys = true
+	full := ShortPubkey(pubkey)
+	FullPubkeys = false
+	require.Equal(t, text.ColorizeBG(pubkey.String()), full)
+
+	text.DisableColors = true
+	require.Equal(t, "0.000000001 SOL (1 lamports)", Lamports(1))
+	require.Equal(t, "4wBq…igw", ShortPubkey(pubkey))
+}
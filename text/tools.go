@@ -19,11 +19,15 @@ import (
 	"hash"
 	"hash/fnv"
 	"math"
+	"os"
 	"strings"
 	"sync"
 )
 
-var DisableColors = false
+// DisableColors disables the colors of all the helpers of this package
+// (and of the `format` package, and of the Encoder).
+// It is true by default when the NO_COLOR environment variable is set (see https://no-color.org).
+var DisableColors = os.Getenv("NO_COLOR") != ""
 
 func S(a ...interface{}) string {
 	return fmt.Sprint(a...)
@@ -102,6 +106,9 @@ func Yellow(str string) string {
 }
 
 func YellowBG(str string) string {
+	if DisableColors {
+		return str
+	}
 	return Black(BgString(str, 255, 255, 0))
 }
 
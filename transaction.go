@@ -19,13 +19,16 @@ package solana
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"sort"
 
 	"github.com/davecgh/go-spew/spew"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/treeout"
+	"github.com/mr-tron/base58"
 	"go.uber.org/zap"
 
 	"github.com/gagliardetto/solana-go/text"
@@ -40,6 +43,31 @@ type Transaction struct {
 
 	// Defines the content of the transaction.
 	Message Message `json:"message"`
+
+	// The last block height at which the recent blockhash of the message
+	// is still valid (as returned by getLatestBlockhash); zero if unknown.
+	// It is not part of the wire format.
+	LastValidBlockHeight uint64 `json:"-"`
+}
+
+// ErrBlockhashExpired is returned when a transaction is not sent
+// because its recent blockhash has already expired.
+var ErrBlockhashExpired = errors.New("blockhash expired")
+
+// IsExpired returns true if the recent blockhash of the transaction
+// is no longer valid at the provided block height.
+// It always returns false if the LastValidBlockHeight is not known.
+func (tx *Transaction) IsExpired(currentBlockHeight uint64) bool {
+	return tx.LastValidBlockHeight != 0 && currentBlockHeight > tx.LastValidBlockHeight
+}
+
+// SetRecentBlockhash replaces the recent blockhash (and its last valid block height)
+// of the transaction. The existing signatures are removed,
+// as they are not valid for the new message; sign the transaction again.
+func (tx *Transaction) SetRecentBlockhash(recentBlockHash Hash, lastValidBlockHeight uint64) {
+	tx.Message.RecentBlockhash = recentBlockHash
+	tx.LastValidBlockHeight = lastValidBlockHeight
+	tx.Signatures = nil
 }
 
 // UnmarshalBase64 decodes a base64 encoded transaction.
@@ -83,6 +111,12 @@ func TransactionFromDecoder(decoder *bin.Decoder) (*Transaction, error) {
 	return out, nil
 }
 
+// TransactionFromBytes decodes a transaction from its wire format.
+// It is safe to use on untrusted input: malformed data returns an error.
+func TransactionFromBytes(data []byte) (*Transaction, error) {
+	return TransactionFromDecoder(bin.NewBinDecoder(data))
+}
+
 // MustTransactionFromDecoder decodes a transaction from a decoder.
 // Panics on error.
 func MustTransactionFromDecoder(decoder *bin.Decoder) *Transaction {
@@ -108,6 +142,11 @@ type CompiledInstruction struct {
 	Data Base58 `json:"data"`
 }
 
+// ResolveInstructionAccounts returns the accounts of the instruction.
+// The account indexes are into all the account keys of the message: the static keys,
+// followed by the writable and then readonly addresses loaded from address tables;
+// so the lookups of a versioned message must be resolved beforehand
+// (see Message.SetLoadedAddresses, or Message.SetAddressTables).
 func (ci *CompiledInstruction) ResolveInstructionAccounts(message *Message) ([]*AccountMeta, error) {
 	out := make([]*AccountMeta, len(ci.Accounts))
 	metas, err := message.AccountMetaList()
@@ -115,12 +154,19 @@ func (ci *CompiledInstruction) ResolveInstructionAccounts(message *Message) ([]*
 		return nil, err
 	}
 	for i, acct := range ci.Accounts {
+		if int(acct) >= len(metas) {
+			return nil, fmt.Errorf("account index %d out of range (%d accounts)", acct, len(metas))
+		}
 		out[i] = metas[acct]
 	}
 
 	return out, nil
 }
 
+// Instruction is implemented by the instructions of all the programs
+// (and by GenericInstruction, for ad-hoc instructions).
+// The programs' instruction builders can be checked for compliance
+// with the programs/instructiontest package.
 type Instruction interface {
 	ProgramID() PublicKey     // the programID the instruction acts on
 	Accounts() []*AccountMeta // returns the list of accounts the instructions requires
@@ -132,8 +178,9 @@ type TransactionOption interface {
 }
 
 type transactionOptions struct {
-	payer         PublicKey
-	addressTables map[PublicKey]PublicKeySlice // [tablePubkey]addresses
+	payer                PublicKey
+	addressTables        map[PublicKey]PublicKeySlice // [tablePubkey]addresses
+	lastValidBlockHeight uint64
 }
 
 type transactionOptionFunc func(opts *transactionOptions)
@@ -150,12 +197,19 @@ func TransactionAddressTables(tables map[PublicKey]PublicKeySlice) TransactionOp
 	return transactionOptionFunc(func(opts *transactionOptions) { opts.addressTables = tables })
 }
 
+// TransactionLastValidBlockHeight sets the LastValidBlockHeight of the transaction,
+// i.e. the `lastValidBlockHeight` returned by getLatestBlockhash with the recent blockhash.
+func TransactionLastValidBlockHeight(height uint64) TransactionOption {
+	return transactionOptionFunc(func(opts *transactionOptions) { opts.lastValidBlockHeight = height })
+}
+
 var debugNewTransaction = false
 
 type TransactionBuilder struct {
-	instructions    []Instruction
-	recentBlockHash Hash
-	opts            []TransactionOption
+	instructions      []Instruction
+	recentBlockHash   Hash
+	opts              []TransactionOption
+	computeUnitMargin *computeUnitMargin
 }
 
 // NewTransactionBuilder creates a new instruction builder.
@@ -164,6 +218,7 @@ func NewTransactionBuilder() *TransactionBuilder {
 }
 
 // AddInstruction adds the provided instruction to the builder.
+// Instructions are compiled in the same order they are added.
 func (builder *TransactionBuilder) AddInstruction(instruction Instruction) *TransactionBuilder {
 	builder.instructions = append(builder.instructions, instruction)
 	return builder
@@ -175,6 +230,13 @@ func (builder *TransactionBuilder) SetRecentBlockHash(recentBlockHash Hash) *Tra
 	return builder
 }
 
+// SetLastValidBlockHeight sets the last block height at which
+// the recent blockhash is valid (see Transaction.IsExpired).
+func (builder *TransactionBuilder) SetLastValidBlockHeight(height uint64) *TransactionBuilder {
+	builder.opts = append(builder.opts, TransactionLastValidBlockHeight(height))
+	return builder
+}
+
 // WithOpt adds a TransactionOption.
 func (builder *TransactionBuilder) WithOpt(opt TransactionOption) *TransactionBuilder {
 	builder.opts = append(builder.opts, opt)
@@ -188,13 +250,47 @@ func (builder *TransactionBuilder) SetFeePayer(feePayer PublicKey) *TransactionB
 	return builder
 }
 
+// WithComputeUnitMargin makes Build simulate the transaction with the simulator
+// (e.g. an *rpc.Client, see Transaction.EstimateComputeUnits), and set its compute unit limit
+// to the units consumed plus the margin (a percentage of them, e.g. 10),
+// capped at MaxComputeUnitLimit, with a SetComputeUnitLimit instruction
+// that is the first instruction of the transaction
+// (and replaces the SetComputeUnitLimit instructions added to the builder, if any).
+// The transaction is simulated with the max limit, and with the most recent blockhash.
+func (builder *TransactionBuilder) WithComputeUnitMargin(simulator ComputeUnitsSimulator, percent uint64) *TransactionBuilder {
+	builder.computeUnitMargin = &computeUnitMargin{simulator: simulator, percent: percent}
+	return builder
+}
+
 // Build builds and returns a *Transaction.
+// An error is returned if the message of the transaction
+// is not valid (see Message.Validate), e.g. if the recent blockhash is not set.
 func (builder *TransactionBuilder) Build() (*Transaction, error) {
-	return NewTransaction(
-		builder.instructions,
+	return builder.BuildWithContext(context.Background())
+}
+
+// BuildWithContext is like Build, with the context used to simulate the transaction
+// with WithComputeUnitMargin.
+func (builder *TransactionBuilder) BuildWithContext(ctx context.Context) (*Transaction, error) {
+	if builder.computeUnitMargin != nil {
+		return builder.buildWithComputeUnitMargin(ctx)
+	}
+	return builder.build(builder.instructions)
+}
+
+func (builder *TransactionBuilder) build(instructions []Instruction, opts ...TransactionOption) (*Transaction, error) {
+	tx, err := NewTransaction(
+		instructions,
 		builder.recentBlockHash,
-		builder.opts...,
+		append(builder.opts[:len(builder.opts):len(builder.opts)], opts...)...,
 	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Message.Validate(); err != nil {
+		return nil, err
+	}
+	return tx, nil
 }
 
 type addressTablePubkeyWithIndex struct {
@@ -202,6 +298,9 @@ type addressTablePubkeyWithIndex struct {
 	index        uint8
 }
 
+// NewTransaction compiles the provided instructions into a new transaction.
+// The order of the instructions is preserved exactly as provided:
+// `message.instructions[i]` always corresponds to `instructions[i]`.
 func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...TransactionOption) (*Transaction, error) {
 	if len(instructions) == 0 {
 		return nil, fmt.Errorf("requires at-least one instruction to create a transaction")
@@ -278,9 +377,17 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 			uniqAccounts[index].IsWritable = uniqAccounts[index].IsWritable || acc.IsWritable
 			continue
 		}
-		uniqAccounts = append(uniqAccounts, acc)
+		// A copy, so that merging the flags doesn't modify the accounts of the instructions.
+		uniqAccount := *acc
+		uniqAccounts = append(uniqAccounts, &uniqAccount)
 		uniqAccountsMap[acc.PublicKey] = uint64(len(uniqAccounts) - 1)
 	}
+	// An account can have become writable (e.g. a readonly signer in an instruction,
+	// and a writable non-signer in another one): sort again, so that the accounts
+	// are partitioned as described by the header.
+	sort.SliceStable(uniqAccounts, func(i, j int) bool {
+		return uniqAccounts[i].less(uniqAccounts[j])
+	})
 
 	if debugNewTransaction {
 		zlog.Debug("unique account sorted", zap.Int("account_count", len(uniqAccounts)))
@@ -381,7 +488,15 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 	if len(lookupsMap) > 0 {
 		lookups := make([]MessageAddressTableLookup, 0, len(lookupsMap))
 
-		for tablePubKey, l := range lookupsMap {
+		// Iterate the tables in a fixed order, so that the same inputs
+		// always produce the same message.
+		tablePubKeys := make(PublicKeySlice, 0, len(lookupsMap))
+		for tablePubKey := range lookupsMap {
+			tablePubKeys = append(tablePubKeys, tablePubKey)
+		}
+		tablePubKeys.Sort()
+		for _, tablePubKey := range tablePubKeys {
+			l := lookupsMap[tablePubKey]
 			lookupsWritableKeys = append(lookupsWritableKeys, l.Writable...)
 			lookupsReadOnlyKeys = append(lookupsReadOnlyKeys, l.Readonly...)
 
@@ -401,17 +516,17 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 	}
 
 	var idx uint16
-	accountKeyIndex := make(map[string]uint16, len(message.AccountKeys)+len(lookupsWritableKeys)+len(lookupsReadOnlyKeys))
+	accountKeyIndex := make(map[PublicKey]uint16, len(message.AccountKeys)+len(lookupsWritableKeys)+len(lookupsReadOnlyKeys))
 	for _, acc := range message.AccountKeys {
-		accountKeyIndex[acc.String()] = idx
+		accountKeyIndex[acc] = idx
 		idx++
 	}
 	for _, acc := range lookupsWritableKeys {
-		accountKeyIndex[acc.String()] = idx
+		accountKeyIndex[acc] = idx
 		idx++
 	}
 	for _, acc := range lookupsReadOnlyKeys {
-		accountKeyIndex[acc.String()] = idx
+		accountKeyIndex[acc] = idx
 		idx++
 	}
 
@@ -424,24 +539,45 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 	}
 
 	for txIdx, instruction := range instructions {
-		accounts = instruction.Accounts()
-		accountIndex := make([]uint16, len(accounts))
-		for idx, acc := range accounts {
-			accountIndex[idx] = accountKeyIndex[acc.PublicKey.String()]
-		}
-		data, err := instruction.Data()
+		compiled, err := CompileInstruction(instruction, accountKeyIndex)
 		if err != nil {
-			return nil, fmt.Errorf("unable to encode instructions [%d]: %w", txIdx, err)
+			return nil, fmt.Errorf("unable to compile instructions [%d]: %w", txIdx, err)
 		}
-		message.Instructions = append(message.Instructions, CompiledInstruction{
-			ProgramIDIndex: accountKeyIndex[instruction.ProgramID().String()],
-			Accounts:       accountIndex,
-			Data:           data,
-		})
+		message.Instructions = append(message.Instructions, compiled)
 	}
 
 	return &Transaction{
-		Message: message,
+		Message:              message,
+		LastValidBlockHeight: options.lastValidBlockHeight,
+	}, nil
+}
+
+// CompileInstruction compiles the instruction for a message whose accounts
+// have the provided indexes: the program ID and the accounts of the instruction
+// are replaced by their indexes. An error is returned if one of them
+// is not in the index, or if the data of the instruction can't be encoded.
+func CompileInstruction(inst Instruction, accountIndex map[PublicKey]uint16) (CompiledInstruction, error) {
+	programIDIndex, ok := accountIndex[inst.ProgramID()]
+	if !ok {
+		return CompiledInstruction{}, fmt.Errorf("program ID %s is not in the account index", inst.ProgramID())
+	}
+	accounts := inst.Accounts()
+	indexes := make([]uint16, len(accounts))
+	for i, account := range accounts {
+		index, ok := accountIndex[account.PublicKey]
+		if !ok {
+			return CompiledInstruction{}, fmt.Errorf("account %d (%s) is not in the account index", i, account.PublicKey)
+		}
+		indexes[i] = index
+	}
+	data, err := inst.Data()
+	if err != nil {
+		return CompiledInstruction{}, fmt.Errorf("unable to encode instruction data: %w", err)
+	}
+	return CompiledInstruction{
+		ProgramIDIndex: programIDIndex,
+		Accounts:       indexes,
+		Data:           data,
 	}, nil
 }
 
@@ -475,7 +611,7 @@ func (tx Transaction) MarshalWithEncoder(encoder *bin.Encoder) error {
 
 func (tx *Transaction) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
 	{
-		numSignatures, err := decoder.ReadCompactU16()
+		numSignatures, err := readCompactU16(decoder)
 		if err != nil {
 			return fmt.Errorf("unable to read numSignatures: %w", err)
 		}
@@ -500,22 +636,32 @@ func (tx *Transaction) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
 	return nil
 }
 
+// PartialSign signs the transaction with the keys returned by the getter
+// (nil for the keys it doesn't have).
+// If the transaction already has a slot for each required signature
+// (e.g. it was decoded from the wire format), the signatures are set in their slots,
+// replacing the existing ones; otherwise they are appended.
 func (tx *Transaction) PartialSign(getter privateKeyGetter) (out []Signature, err error) {
 	messageContent, err := tx.Message.MarshalBinary()
 	if err != nil {
 		return nil, fmt.Errorf("unable to encode message for signing: %w", err)
 	}
 	signerKeys := tx.Message.signerKeys()
+	inPlace := len(tx.Signatures) >= len(signerKeys)
 
 	signedSignatures := []Signature{}
-	for _, key := range signerKeys {
+	for i, key := range signerKeys {
 		privateKey := getter(key)
 		if privateKey != nil {
 			s, err := privateKey.Sign(messageContent)
 			if err != nil {
 				return nil, fmt.Errorf("failed to signed with key %q: %w", key.String(), err)
 			}
-			signedSignatures = append(signedSignatures, s)
+			if inPlace {
+				tx.Signatures[i] = s
+			} else {
+				signedSignatures = append(signedSignatures, s)
+			}
 		}
 	}
 	tx.Signatures = append(tx.Signatures, signedSignatures...)
@@ -533,7 +679,13 @@ func (tx *Transaction) Sign(getter privateKeyGetter) (out []Signature, err error
 }
 
 func (tx *Transaction) EncodeTree(encoder *text.TreeEncoder) (int, error) {
-	tx.EncodeToTree(encoder)
+	return tx.EncodeTreeWithSummaryContext(encoder, nil)
+}
+
+// EncodeTreeWithSummaryContext is like EncodeTree, but the summaries
+// of the instructions (see Summarizer) are rendered with the provided context.
+func (tx *Transaction) EncodeTreeWithSummaryContext(encoder *text.TreeEncoder, ctx *SummaryContext) (int, error) {
+	tx.EncodeToTreeWithSummaryContext(encoder, ctx)
 	return encoder.WriteString(encoder.Tree.String())
 }
 
@@ -548,16 +700,94 @@ func (tx *Transaction) String() string {
 	return buf.String()
 }
 
-func (tx Transaction) ToBase64() (string, error) {
-	out, err := tx.MarshalBinary()
+// ErrMissingSignatures is returned (wrapped) by ToBase64 and ToBase58
+// when the transaction doesn't have all the required signatures.
+var ErrMissingSignatures = errors.New("transaction is missing required signatures")
+
+type EncodeOption interface {
+	apply(opts *encodeOptions)
+}
+
+type encodeOptions struct {
+	allowUnsigned bool
+}
+
+type encodeOptionFunc func(opts *encodeOptions)
+
+func (f encodeOptionFunc) apply(opts *encodeOptions) {
+	f(opts)
+}
+
+// EncodeAllowUnsigned allows encoding a transaction that is not (fully) signed,
+// e.g. to simulate it with `sigVerify: false`.
+// The missing signatures are encoded as zero signatures.
+func EncodeAllowUnsigned() EncodeOption {
+	return encodeOptionFunc(func(opts *encodeOptions) { opts.allowUnsigned = true })
+}
+
+// MissingSigners returns the keys of the required signers
+// that don't have a signature in the transaction.
+func (tx Transaction) MissingSigners() []PublicKey {
+	var missing []PublicKey
+	for i, key := range tx.Message.signerKeys() {
+		if i >= len(tx.Signatures) || tx.Signatures[i].IsZero() {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+func (tx Transaction) marshalBinaryWithOpts(opts ...EncodeOption) ([]byte, error) {
+	options := encodeOptions{}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if missing := tx.MissingSigners(); len(missing) > 0 {
+		if !options.allowUnsigned {
+			return nil, fmt.Errorf("%w: %s", ErrMissingSignatures, PublicKeySlice(missing).ToBase58())
+		}
+		if numRequired := int(tx.Message.Header.NumRequiredSignatures); len(tx.Signatures) < numRequired {
+			signatures := make([]Signature, numRequired)
+			copy(signatures, tx.Signatures)
+			tx.Signatures = signatures
+		}
+	}
+	return tx.MarshalBinary()
+}
+
+// ToBase64 serializes the transaction and encodes it to base64,
+// as expected by `sendTransaction` and `simulateTransaction`.
+// By default, an error is returned if any of the required signatures is missing
+// (see EncodeAllowUnsigned).
+func (tx Transaction) ToBase64(opts ...EncodeOption) (string, error) {
+	out, err := tx.marshalBinaryWithOpts(opts...)
 	if err != nil {
 		return "", err
 	}
 	return base64.StdEncoding.EncodeToString(out), nil
 }
 
-func (tx Transaction) MustToBase64() string {
-	out, err := tx.ToBase64()
+func (tx Transaction) MustToBase64(opts ...EncodeOption) string {
+	out, err := tx.ToBase64(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// ToBase58 serializes the transaction and encodes it to base58.
+// By default, an error is returned if any of the required signatures is missing
+// (see EncodeAllowUnsigned).
+func (tx Transaction) ToBase58(opts ...EncodeOption) (string, error) {
+	out, err := tx.marshalBinaryWithOpts(opts...)
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(out), nil
+}
+
+func (tx Transaction) MustToBase58(opts ...EncodeOption) string {
+	out, err := tx.ToBase58(opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -565,6 +795,12 @@ func (tx Transaction) MustToBase64() string {
 }
 
 func (tx *Transaction) EncodeToTree(parent treeout.Branches) {
+	tx.EncodeToTreeWithSummaryContext(parent, nil)
+}
+
+// EncodeToTreeWithSummaryContext is like EncodeToTree, but the summaries
+// of the instructions (see Summarizer) are rendered with the provided context.
+func (tx *Transaction) EncodeToTreeWithSummaryContext(parent treeout.Branches, ctx *SummaryContext) {
 	parent.ParentFunc(func(txTree treeout.Branches) {
 		txTree.Child(fmt.Sprintf("Signatures[len=%v]", len(tx.Signatures))).ParentFunc(func(signaturesBranch treeout.Branches) {
 			for _, sig := range tx.Signatures {
@@ -589,15 +825,24 @@ func (tx *Transaction) EncodeToTree(parent treeout.Branches) {
 				}
 				decodedInstruction, err := DecodeInstruction(progKey, accounts, inst.Data)
 				if err == nil {
+					if summarizer, ok := decodedInstruction.(Summarizer); ok {
+						if summary := summarizer.Summary(ctx); summary != "" {
+							message.Child(text.Bold("Summary") + ": " + summary)
+						}
+					}
 					if enToTree, ok := decodedInstruction.(text.EncodableToTree); ok {
 						enToTree.EncodeToTree(message)
 					} else {
 						message.Child(spew.Sdump(decodedInstruction))
 					}
 				} else {
+					programName := "<unknown>"
+					if name, ok := ProgramName(progKey); ok {
+						programName = name
+					}
 					// TODO: log error?
 					message.Child(fmt.Sprintf(text.RedBG("cannot decode instruction for %s program: %s"), progKey, err)).
-						Child(text.IndigoBG("Program") + ": " + text.Bold("<unknown>") + " " + text.ColorizeBG(progKey.String())).
+						Child(text.IndigoBG("Program") + ": " + text.Bold(programName) + " " + text.ColorizeBG(progKey.String())).
 						//
 						ParentFunc(func(programBranch treeout.Branches) {
 							programBranch.Child(text.Purple(text.Bold("Instruction")) + ": " + text.Bold("<unknown>")).
@@ -0,0 +1,127 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"encoding/base64"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// Run with:
+//
+//	go test -run ^$ -fuzz FuzzTransactionFromBytes .
+//	go test -run ^$ -fuzz FuzzDecodeCompactU16 .
+//	go test -run ^$ -fuzz FuzzPeekTransaction .
+func FuzzTransactionFromBytes(f *testing.F) {
+	for _, b64 := range []string{
+		// legacy
+		"AfjEs3XhTc3hrxEvlnMPkm/cocvAUbFNbCl00qKnrFue6J53AhEqIFmcJJlJW3EDP5RmcMz+cNTTcZHW/WJYwAcBAAEDO8hh4VddzfcO5jbCt95jryl6y8ff65UcgukHNLWH+UQGgxCGGpgyfQVQV02EQYqm4QwzUt2qf9f1gVLM7rI4hwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6ANIF55zOZWROWRkeh+lExxZBnKFqbvIxZDLE7EijjoBAgIAAQwCAAAAOTAAAAAAAAA=",
+		// v0
+		"ARZsk8+AvvT9onUT8FU1VRaiC8Sp+FKveOwhdPoigWHA+MGNcIOqbow6mwSILEYvvyOB/fi3UQ/xKQCjEtxBRgIBAAIFKIX92BRrkgEfrLEXAvXtw7OgPPhHU+62C8DB5QPoMgNSbKXgdub0sr7Yp3Nvdrsp6SDoJ4gdoyRad2AV+Japj0dRtYW4OxE78FvRZTeqHFy2My/m12/afGIPS8iUnMGlBqfVFxjHdMkoVmOYaR1etoteuKObS21cc1VbIQAAAAC/jt8clGtWu0PSX5i4e2vlERcwCmEmGvn5+U7telqAiK4hdAN78GteFjqtJrxLXxpVNKsu1lfdcFPXa/Kcg4e5AQQEAQADAicmMiQQAiGujz0xoTQSQCgAMPOroDk5F0hQ/BgzEkBBvVKWIY41EkA=",
+	} {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx, err := TransactionFromBytes(data)
+		if err != nil {
+			return
+		}
+		if len(data) > 1280-40-8 {
+			// Larger than a packet; some of the methods below are
+			// quadratic in the number of accounts.
+			return
+		}
+		// Whatever was decoded must be safe to use.
+		tx.Message.AccountMetaList()
+		tx.Message.Signers()
+		tx.MarshalBinary()
+	})
+}
+
+func FuzzDecodeCompactU16(f *testing.F) {
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x7f})
+	f.Add([]byte{0x80, 0x01})
+	f.Add([]byte{0xff, 0xff, 0x03})
+	f.Add([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value, size, err := DecodeCompactU16(data)
+		if err != nil {
+			return
+		}
+		if value < 0 || value > 0xffff {
+			t.Fatalf("decoded value %d is out of the u16 range", value)
+		}
+		if size < 1 || size > 3 || size > len(data) {
+			t.Fatalf("invalid size %d for %d bytes", size, len(data))
+		}
+		var encoded []byte
+		bin.EncodeCompactU16Length(&encoded, value)
+		if string(encoded) != string(data[:size]) {
+			t.Fatalf("%x decoded to %d, which encodes to %x", data[:size], value, encoded)
+		}
+	})
+}
+
+func FuzzPeekTransaction(f *testing.F) {
+	for _, b64 := range peekTransactions {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+		// Truncated in the middle of the prefix.
+		f.Add(data[:len(data)/3])
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x01})
+	f.Add([]byte{0xff, 0xff, 0x03})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		peek, err := PeekTransaction(data)
+		if err != nil {
+			return
+		}
+		if peek.NumSignatures < 1 {
+			t.Fatalf("peeked %d signatures", peek.NumSignatures)
+		}
+		// The peek must agree with the full decoding.
+		tx, err := TransactionFromBytes(data)
+		if err != nil {
+			return
+		}
+		if len(tx.Signatures) != peek.NumSignatures ||
+			tx.Signatures[0] != peek.Signature ||
+			tx.Message.AccountKeys[0] != peek.FeePayer ||
+			tx.Message.RecentBlockhash != peek.RecentBlockhash ||
+			(tx.Message.GetVersion() == MessageVersionV0) != peek.Versioned {
+			t.Fatalf("peek %+v doesn't match the decoded transaction", peek)
+		}
+	})
+}
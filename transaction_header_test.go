@@ -0,0 +1,306 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireValidHeader checks that the header of the message matches the partitioning
+// of its account keys, and that each compiled instruction references accounts
+// with at least the privileges requested by the instruction.
+func requireValidHeader(t *testing.T, tx *Transaction, instructions []Instruction) {
+	t.Helper()
+	msg := tx.Message
+	h := msg.Header
+	numKeys := len(msg.AccountKeys)
+	require.GreaterOrEqual(t, int(h.NumRequiredSignatures), 1, "the fee payer must sign")
+	require.LessOrEqual(t, int(h.NumRequiredSignatures)+int(h.NumReadonlyUnsignedAccounts), numKeys)
+	require.Less(t, int(h.NumReadonlySignedAccounts), int(h.NumRequiredSignatures), "the fee payer must be writable")
+
+	// The fee payer is a writable signer, at index 0.
+	require.True(t, msg.IsSignerIndex(0))
+	require.True(t, msg.IsWritableIndex(0))
+
+	// No duplicates.
+	seen := make(map[PublicKey]bool)
+	for _, key := range msg.AccountKeys {
+		require.False(t, seen[key], "duplicate account %s", key)
+		seen[key] = true
+	}
+
+	require.Len(t, msg.Instructions, len(instructions))
+	for i, inst := range instructions {
+		compiled := msg.Instructions[i]
+		programID, err := msg.Program(compiled.ProgramIDIndex)
+		require.NoError(t, err)
+		require.Equal(t, inst.ProgramID(), programID)
+
+		require.Len(t, compiled.Accounts, len(inst.Accounts()))
+		for j, meta := range inst.Accounts() {
+			index := int(compiled.Accounts[j])
+			require.Less(t, index, numKeys)
+			require.Equal(t, meta.PublicKey, msg.AccountKeys[index])
+			if meta.IsSigner {
+				require.True(t, msg.IsSignerIndex(index), "instruction %d account %d (%s) must be a signer", i, j, meta.PublicKey)
+			}
+			if meta.IsWritable {
+				require.True(t, msg.IsWritableIndex(index), "instruction %d account %d (%s) must be writable", i, j, meta.PublicKey)
+			}
+		}
+	}
+}
+
+// requireAccountMetas checks the signer and writable flags of all the accounts,
+// as derived from the header.
+func requireAccountMetas(t *testing.T, tx *Transaction, expected []*AccountMeta) {
+	t.Helper()
+	metas, err := tx.Message.AccountMetaList()
+	require.NoError(t, err)
+	require.Equal(t, AccountMetaSlice(expected), metas)
+}
+
+func TestNewTransaction_header_feePayer(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	other := NewWallet().PublicKey()
+	programID := NewWallet().PublicKey()
+
+	for _, tc := range []struct {
+		name string
+		meta *AccountMeta
+	}{
+		{"not in the instructions", nil},
+		{"readonly non-signer", Meta(payer)},
+		{"writable non-signer", Meta(payer).WRITE()},
+		{"readonly signer", Meta(payer).SIGNER()},
+		{"writable signer", Meta(payer).SIGNER().WRITE()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			accounts := []*AccountMeta{Meta(other).SIGNER()}
+			if tc.meta != nil {
+				accounts = append(accounts, tc.meta)
+			}
+			instructions := []Instruction{
+				&testTransactionInstructions{accounts: accounts, programID: programID},
+			}
+			tx, err := NewTransaction(instructions, Hash{}, TransactionPayer(payer))
+			require.NoError(t, err)
+
+			requireValidHeader(t, tx, instructions)
+			requireAccountMetas(t, tx, []*AccountMeta{
+				Meta(payer).SIGNER().WRITE(),
+				Meta(other).SIGNER(),
+				Meta(programID),
+			})
+			assert.Equal(t, MessageHeader{
+				NumRequiredSignatures:       2,
+				NumReadonlySignedAccounts:   1,
+				NumReadonlyUnsignedAccounts: 1,
+			}, tx.Message.Header)
+		})
+	}
+}
+
+func TestNewTransaction_header_readonlySigner(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	multisig := NewWallet().PublicKey()
+	coSigner1 := NewWallet().PublicKey()
+	coSigner2 := NewWallet().PublicKey()
+	destination := NewWallet().PublicKey()
+	programID := NewWallet().PublicKey()
+
+	// E.g. a transfer from a token account owned by a multisig:
+	// the co-signers sign, but are not written.
+	instructions := []Instruction{
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				Meta(multisig).WRITE(),
+				Meta(destination).WRITE(),
+				Meta(payer),
+				Meta(coSigner1).SIGNER(),
+				Meta(coSigner2).SIGNER(),
+			},
+			programID: programID,
+		},
+	}
+	tx, err := NewTransaction(instructions, Hash{}, TransactionPayer(payer))
+	require.NoError(t, err)
+
+	requireValidHeader(t, tx, instructions)
+	requireAccountMetas(t, tx, []*AccountMeta{
+		Meta(payer).SIGNER().WRITE(),
+		Meta(coSigner1).SIGNER(),
+		Meta(coSigner2).SIGNER(),
+		Meta(multisig).WRITE(),
+		Meta(destination).WRITE(),
+		Meta(programID),
+	})
+	assert.Equal(t, MessageHeader{
+		NumRequiredSignatures:       3,
+		NumReadonlySignedAccounts:   2,
+		NumReadonlyUnsignedAccounts: 1,
+	}, tx.Message.Header)
+}
+
+func TestNewTransaction_header_programIDsAndSysvars(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	account := NewWallet().PublicKey()
+	programA := NewWallet().PublicKey()
+	programB := NewWallet().PublicKey()
+
+	instructions := []Instruction{
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				Meta(account).WRITE(),
+				Meta(SysVarRentPubkey),
+				Meta(SysVarClockPubkey),
+			},
+			programID: programA,
+		},
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				Meta(account).WRITE(),
+				// A program passed as an account to another program.
+				Meta(programA),
+				Meta(SysVarClockPubkey),
+			},
+			programID: programB,
+		},
+		// Same program again.
+		&testTransactionInstructions{
+			accounts:  []*AccountMeta{Meta(account)},
+			programID: programA,
+		},
+	}
+	tx, err := NewTransaction(instructions, Hash{}, TransactionPayer(payer))
+	require.NoError(t, err)
+
+	requireValidHeader(t, tx, instructions)
+	requireAccountMetas(t, tx, []*AccountMeta{
+		Meta(payer).SIGNER().WRITE(),
+		Meta(account).WRITE(),
+		Meta(SysVarRentPubkey),
+		Meta(SysVarClockPubkey),
+		Meta(programA),
+		Meta(programB),
+	})
+	assert.Equal(t, MessageHeader{
+		NumRequiredSignatures:       1,
+		NumReadonlySignedAccounts:   0,
+		NumReadonlyUnsignedAccounts: 4,
+	}, tx.Message.Header)
+}
+
+func TestNewTransaction_header_mergesPrivileges(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	signerA := NewWallet().PublicKey()
+	signerB := NewWallet().PublicKey()
+	account := NewWallet().PublicKey()
+	programID := NewWallet().PublicKey()
+
+	// signerB is a readonly signer in the first instruction,
+	// and a writable non-signer in the second one: it must be a writable signer.
+	// The same for account, readonly then writable.
+	first := []*AccountMeta{
+		Meta(signerA).SIGNER(),
+		Meta(signerB).SIGNER(),
+		Meta(account),
+	}
+	second := []*AccountMeta{
+		Meta(signerB).WRITE(),
+		Meta(account).WRITE(),
+		Meta(payer),
+	}
+	instructions := []Instruction{
+		&testTransactionInstructions{accounts: first, programID: programID},
+		&testTransactionInstructions{accounts: second, programID: programID},
+	}
+	tx, err := NewTransaction(instructions, Hash{}, TransactionPayer(payer))
+	require.NoError(t, err)
+
+	requireValidHeader(t, tx, instructions)
+	requireAccountMetas(t, tx, []*AccountMeta{
+		Meta(payer).SIGNER().WRITE(),
+		Meta(signerB).SIGNER().WRITE(),
+		Meta(signerA).SIGNER(),
+		Meta(account).WRITE(),
+		Meta(programID),
+	})
+
+	// The account metas of the instructions are not modified.
+	assert.Equal(t, []*AccountMeta{Meta(signerA).SIGNER(), Meta(signerB).SIGNER(), Meta(account)}, first)
+	assert.Equal(t, []*AccountMeta{Meta(signerB).WRITE(), Meta(account).WRITE(), Meta(payer)}, second)
+}
+
+func TestNewTransaction_header_random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	keys := make([]PublicKey, 12)
+	for i := range keys {
+		keys[i] = NewWallet().PublicKey()
+	}
+	programs := []PublicKey{NewWallet().PublicKey(), NewWallet().PublicKey(), SystemProgramID}
+
+	for iteration := 0; iteration < 500; iteration++ {
+		var instructions []Instruction
+		for i := 1 + rng.Intn(4); i > 0; i-- {
+			var accounts []*AccountMeta
+			for j := rng.Intn(6); j > 0; j-- {
+				accounts = append(accounts, &AccountMeta{
+					PublicKey:  keys[rng.Intn(len(keys))],
+					IsSigner:   rng.Intn(3) == 0,
+					IsWritable: rng.Intn(2) == 0,
+				})
+			}
+			instructions = append(instructions, &testTransactionInstructions{
+				accounts:  accounts,
+				programID: programs[rng.Intn(len(programs))],
+			})
+		}
+		payer := keys[rng.Intn(len(keys))]
+
+		tx, err := NewTransaction(instructions, Hash{}, TransactionPayer(payer))
+		require.NoError(t, err)
+		require.Equal(t, payer, tx.Message.AccountKeys[0])
+		requireValidHeader(t, tx, instructions)
+
+		// Only the requested privileges are granted (besides the fee payer's).
+		requested := make(map[PublicKey]AccountMeta)
+		for _, inst := range instructions {
+			for _, meta := range inst.Accounts() {
+				m := requested[meta.PublicKey]
+				m.IsSigner = m.IsSigner || meta.IsSigner
+				m.IsWritable = m.IsWritable || meta.IsWritable
+				requested[meta.PublicKey] = m
+			}
+		}
+		for i, key := range tx.Message.AccountKeys[1:] {
+			index := i + 1
+			assert.Equal(t, requested[key].IsSigner, tx.Message.IsSignerIndex(index), "signer %s", key)
+			assert.Equal(t, requested[key].IsWritable, tx.Message.IsWritableIndex(index), "writable %s", key)
+		}
+
+		// The message survives a round trip.
+		data, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		decoded, err := TransactionFromBytes(data)
+		require.NoError(t, err)
+		require.Equal(t, tx.Message.Header, decoded.Message.Header)
+		require.Equal(t, tx.Message.AccountKeys, decoded.Message.AccountKeys)
+	}
+}
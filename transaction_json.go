@@ -0,0 +1,154 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"fmt"
+)
+
+// canonicalTransaction mirrors the shape (and the key order) of the
+// `transaction` object returned by the RPC when using the `json` encoding.
+// The RPC sorts the keys of its objects, so the fields are in alphabetical order.
+type canonicalTransaction struct {
+	Message    *canonicalMessage `json:"message"`
+	Signatures []Signature       `json:"signatures"`
+}
+
+type canonicalMessage struct {
+	AccountKeys         []PublicKey                   `json:"accountKeys"`
+	AddressTableLookups []canonicalAddressTableLookup `json:"addressTableLookups,omitempty"`
+	Header              canonicalMessageHeader        `json:"header"`
+	Instructions        []canonicalInstruction        `json:"instructions"`
+	RecentBlockhash     Hash                          `json:"recentBlockhash"`
+}
+
+type canonicalMessageHeader struct {
+	NumReadonlySignedAccounts   uint8 `json:"numReadonlySignedAccounts"`
+	NumReadonlyUnsignedAccounts uint8 `json:"numReadonlyUnsignedAccounts"`
+	NumRequiredSignatures       uint8 `json:"numRequiredSignatures"`
+}
+
+type canonicalInstruction struct {
+	Accounts       []uint16 `json:"accounts"`
+	Data           Base58   `json:"data"`
+	ProgramIDIndex uint16   `json:"programIdIndex"`
+}
+
+type canonicalAddressTableLookup struct {
+	AccountKey      PublicKey       `json:"accountKey"`
+	ReadonlyIndexes Uint8SliceAsNum `json:"readonlyIndexes"`
+	WritableIndexes Uint8SliceAsNum `json:"writableIndexes"`
+}
+
+// MarshalJSONCanonical encodes the transaction to the same JSON shape
+// returned by the RPC for the `json` encoding (e.g. by `getTransaction`):
+// signatures and hashes as base58 strings, account indexes as numbers,
+// and instruction data as base58.
+// The output is deterministic, and can be diffed against explorer/RPC output.
+func (tx *Transaction) MarshalJSONCanonical() ([]byte, error) {
+	msg := &tx.Message
+	out := canonicalTransaction{
+		Signatures: tx.Signatures,
+		Message: &canonicalMessage{
+			Header: canonicalMessageHeader{
+				NumRequiredSignatures:       msg.Header.NumRequiredSignatures,
+				NumReadonlySignedAccounts:   msg.Header.NumReadonlySignedAccounts,
+				NumReadonlyUnsignedAccounts: msg.Header.NumReadonlyUnsignedAccounts,
+			},
+			AccountKeys:     msg.getStaticKeys(),
+			RecentBlockhash: msg.RecentBlockhash,
+			Instructions:    make([]canonicalInstruction, len(msg.Instructions)),
+		},
+	}
+	if out.Signatures == nil {
+		out.Signatures = make([]Signature, 0)
+	}
+	if out.Message.AccountKeys == nil {
+		out.Message.AccountKeys = make([]PublicKey, 0)
+	}
+	for i, inst := range msg.Instructions {
+		out.Message.Instructions[i] = canonicalInstruction{
+			Accounts:       inst.Accounts,
+			Data:           inst.Data,
+			ProgramIDIndex: inst.ProgramIDIndex,
+		}
+		if out.Message.Instructions[i].Accounts == nil {
+			out.Message.Instructions[i].Accounts = make([]uint16, 0)
+		}
+	}
+	if msg.IsVersioned() {
+		out.Message.AddressTableLookups = make([]canonicalAddressTableLookup, len(msg.AddressTableLookups))
+		for i, lookup := range msg.AddressTableLookups {
+			out.Message.AddressTableLookups[i] = canonicalAddressTableLookup{
+				AccountKey:      lookup.AccountKey,
+				ReadonlyIndexes: lookup.ReadonlyIndexes,
+				WritableIndexes: lookup.WritableIndexes,
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// TransactionFromJSON decodes a transaction from the JSON shape returned
+// by the RPC for the `json` encoding; it is the inverse of `MarshalJSONCanonical`.
+// A message that contains the `addressTableLookups` field is decoded as a v0 message.
+func TransactionFromJSON(data []byte) (*Transaction, error) {
+	var in struct {
+		Signatures []Signature `json:"signatures"`
+		Message    *struct {
+			canonicalMessage
+			AddressTableLookups *[]canonicalAddressTableLookup `json:"addressTableLookups"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("unable to decode transaction JSON: %w", err)
+	}
+	if in.Message == nil {
+		return nil, fmt.Errorf("transaction JSON has no message")
+	}
+
+	tx := &Transaction{
+		Signatures: in.Signatures,
+		Message: Message{
+			AccountKeys: in.Message.AccountKeys,
+			Header: MessageHeader{
+				NumRequiredSignatures:       in.Message.Header.NumRequiredSignatures,
+				NumReadonlySignedAccounts:   in.Message.Header.NumReadonlySignedAccounts,
+				NumReadonlyUnsignedAccounts: in.Message.Header.NumReadonlyUnsignedAccounts,
+			},
+			RecentBlockhash: in.Message.RecentBlockhash,
+			Instructions:    make([]CompiledInstruction, len(in.Message.Instructions)),
+		},
+	}
+	for i, inst := range in.Message.Instructions {
+		tx.Message.Instructions[i] = CompiledInstruction{
+			ProgramIDIndex: inst.ProgramIDIndex,
+			Accounts:       inst.Accounts,
+			Data:           inst.Data,
+		}
+	}
+	if in.Message.AddressTableLookups != nil {
+		lookups := make([]MessageAddressTableLookup, len(*in.Message.AddressTableLookups))
+		for i, lookup := range *in.Message.AddressTableLookups {
+			lookups[i] = MessageAddressTableLookup{
+				AccountKey:      lookup.AccountKey,
+				WritableIndexes: lookup.WritableIndexes,
+				ReadonlyIndexes: lookup.ReadonlyIndexes,
+			}
+		}
+		tx.Message.SetAddressTableLookups(lookups)
+	}
+	return tx, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	stdjson "encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction_MarshalJSONCanonical(t *testing.T) {
+	// A getTransaction response of mainnet-beta (slot 83311386) with the json encoding,
+	// as in the GetTransaction test of the rpc package.
+	response, err := ioutil.ReadFile("testdata/transaction-legacy.json")
+	require.NoError(t, err)
+	var result struct {
+		Transaction stdjson.RawMessage `json:"transaction"`
+	}
+	require.NoError(t, stdjson.Unmarshal(response, &result))
+	golden := result.Transaction
+
+	tx, err := TransactionFromJSON(golden)
+	require.NoError(t, err)
+	require.False(t, tx.Message.IsVersioned())
+
+	got, err := tx.MarshalJSONCanonical()
+	require.NoError(t, err)
+	require.Equal(t, string(golden), string(got))
+
+	// Must be stable across calls.
+	again, err := tx.MarshalJSONCanonical()
+	require.NoError(t, err)
+	require.Equal(t, got, again)
+}
+
+func TestTransaction_MarshalJSONCanonical_v0(t *testing.T) {
+	txB64 := "Alkhq/BfGdBeok4oBP21xAwT4oO/R5PvkKqbCTq4sHHRsto+uDQCFcdp8hXh1g5D3mTh8GAJW8xE+EDD27f9IweTkH2Afiu4h5aM+Xbo0mklc0/Vi1xawd7SZVbstXDLtWdoJaf4Zt+20F/SasURzw/P4dkD+Q6BjgUNHT+vg5gOgAIBAQgaJV0Ch/DG6XwNcizWbI7STLgSbIOrg0Dl67Oo30WU1uA/NIbYLPRmuLarIJ4J0CcN3IWEm4Gf8675KhnXef2LaDXzjFgWVSbAO2yyTF6dK1oO3gTExie957LXDwu6oJMAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAVKU1qZKSEGTSTocWDaOHx8NbXdvJK7geQfqEBBBUSN1LfoiB9oYLDSHJL9rjAlchZhn+fd/23ACfq0oIGla54pt5JT0MdBTJhQI+z7dnVsisw2xWwW+vFSTs97l0tJPxmv9kxpXbHYZFenDpT2s6CT75/9QNFVTkHFLMK+UG6VlyFnQmYh1aMkGtq3c6TIOsk32S6XMUnN9DQgFGQq4lwEAwIAAgwCAAAAgJaYAAAAAAADAgAFDAIAAACAlpgAAAAAAAMCAAYMAgAAAICWmAAAAAAABAAMSGVsbG8gRmFiaW8hAX5s37FH6IeB4QeMYxD4LtpXf1DaupH/ro7W+kEQnofaAgECAQA="
+	tx := new(Transaction)
+	require.NoError(t, tx.UnmarshalBase64(txB64))
+
+	got, err := tx.MarshalJSONCanonical()
+	require.NoError(t, err)
+	// The lookups come right after the account keys, as the RPC sorts the keys.
+	require.Contains(t, string(got), `"addressTableLookups":[{"accountKey":"9WWfC3y4uCNofr2qEFHSVUXkCxW99JiYkMWmSZvVt8j3","readonlyIndexes":[0],"writableIndexes":[1,2]}],"header":`)
+
+	// The inverse must produce the same transaction.
+	parsed, err := TransactionFromJSON(got)
+	require.NoError(t, err)
+	require.True(t, parsed.Message.IsVersioned())
+	require.Equal(t, txB64, parsed.MustToBase64())
+}
@@ -107,6 +107,178 @@ func TestNewTransaction(t *testing.T) {
 	})
 }
 
+func TestCompileInstruction(t *testing.T) {
+	payer := MustPublicKeyFromBase58("A9QnpgfhCkmiBSjgBuWk76Wo3HxzxvDopUq9x6UUMmjn")
+	recipient := MustPublicKeyFromBase58("9hFtYBYmBJCVguRYs9pBTWKYAFoKfjYR7zBPpEkVsmD")
+	index := map[PublicKey]uint16{
+		payer:           0,
+		recipient:       1,
+		SystemProgramID: 2,
+	}
+	inst := NewInstruction(
+		SystemProgramID,
+		AccountMetaSlice{Meta(recipient).WRITE(), Meta(payer).SIGNER().WRITE(), Meta(recipient)},
+		[]byte{0xaa, 0xbb},
+	)
+
+	compiled, err := CompileInstruction(inst, index)
+	require.NoError(t, err)
+	assert.Equal(t, CompiledInstruction{
+		ProgramIDIndex: 2,
+		Accounts:       []uint16{1, 0, 1},
+		Data:           Base58{0xaa, 0xbb},
+	}, compiled)
+
+	// Same as the compilation of NewTransaction.
+	tx, err := NewTransaction([]Instruction{inst}, Hash{}, TransactionPayer(payer))
+	require.NoError(t, err)
+	txIndex := make(map[PublicKey]uint16)
+	for i, key := range tx.Message.AccountKeys {
+		txIndex[key] = uint16(i)
+	}
+	compiled, err = CompileInstruction(inst, txIndex)
+	require.NoError(t, err)
+	assert.Equal(t, tx.Message.Instructions[0], compiled)
+
+	t.Run("missing account", func(t *testing.T) {
+		_, err := CompileInstruction(inst, map[PublicKey]uint16{payer: 0, SystemProgramID: 2})
+		require.EqualError(t, err, "account 0 (9hFtYBYmBJCVguRYs9pBTWKYAFoKfjYR7zBPpEkVsmD) is not in the account index")
+	})
+
+	t.Run("missing program", func(t *testing.T) {
+		_, err := CompileInstruction(inst, map[PublicKey]uint16{payer: 0, recipient: 1})
+		require.EqualError(t, err, "program ID 11111111111111111111111111111111 is not in the account index")
+	})
+}
+
+func TestNewTransaction_preservesInstructionOrder(t *testing.T) {
+	payer := MustPublicKeyFromBase58("A9QnpgfhCkmiBSjgBuWk76Wo3HxzxvDopUq9x6UUMmjn")
+	newAccount := MustPublicKeyFromBase58("9hFtYBYmBJCVguRYs9pBTWKYAFoKfjYR7zBPpEkVsmD")
+	tokenProgram := MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+
+	// create-then-initialize-then-transfer: the order matters.
+	instructions := []Instruction{
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: payer, IsSigner: true, IsWritable: true},
+				{PublicKey: newAccount, IsSigner: true, IsWritable: true},
+			},
+			data:      []byte{0x01},
+			programID: SystemProgramID,
+		},
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: newAccount, IsSigner: false, IsWritable: true},
+				{PublicKey: SysVarRentPubkey, IsSigner: false, IsWritable: false},
+			},
+			data:      []byte{0x02},
+			programID: tokenProgram,
+		},
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: payer, IsSigner: true, IsWritable: true},
+				{PublicKey: newAccount, IsSigner: false, IsWritable: true},
+			},
+			data:      []byte{0x03},
+			programID: SystemProgramID,
+		},
+	}
+
+	trx, err := NewTransactionBuilder().
+		AddInstruction(instructions[0]).
+		AddInstruction(instructions[1]).
+		AddInstruction(instructions[2]).
+		SetRecentBlockHash(MustHashFromBase58("GcgVK9buRA7YepZh3zXuS399GJAESCisLnLDBCmR5Aoj")).
+		SetFeePayer(payer).
+		Build()
+	require.NoError(t, err)
+
+	assertOrder := func(t *testing.T, tx *Transaction) {
+		require.Len(t, tx.Message.Instructions, len(instructions))
+		for i, compiled := range tx.Message.Instructions {
+			expected := instructions[i]
+
+			programID, err := tx.Message.Program(compiled.ProgramIDIndex)
+			require.NoError(t, err)
+			assert.Equal(t, expected.ProgramID(), programID)
+
+			data, err := expected.Data()
+			require.NoError(t, err)
+			assert.Equal(t, Base58(data), compiled.Data)
+
+			require.Len(t, compiled.Accounts, len(expected.Accounts()))
+			for j, accIndex := range compiled.Accounts {
+				key, err := tx.Message.Account(accIndex)
+				require.NoError(t, err)
+				assert.Equal(t, expected.Accounts()[j].PublicKey, key)
+			}
+		}
+	}
+
+	t.Run("compiled", func(t *testing.T) {
+		assertOrder(t, trx)
+	})
+
+	t.Run("serialized and deserialized", func(t *testing.T) {
+		raw, err := trx.MarshalBinary()
+		require.NoError(t, err)
+
+		decoded, err := TransactionFromDecoder(bin.NewBinDecoder(raw))
+		require.NoError(t, err)
+		assertOrder(t, decoded)
+	})
+}
+
+func TestNewTransaction_addressTableLookupsOrder(t *testing.T) {
+	payer := MustPublicKeyFromBase58("A9QnpgfhCkmiBSjgBuWk76Wo3HxzxvDopUq9x6UUMmjn")
+	tableA := MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	tableB := MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	tableC := MustPublicKeyFromBase58("9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin")
+	inA := MustPublicKeyFromBase58("9hFtYBYmBJCVguRYs9pBTWKYAFoKfjYR7zBPpEkVsmD")
+	inB := MustPublicKeyFromBase58("GcgVK9buRA7YepZh3zXuS399GJAESCisLnLDBCmR5Aoj")
+	inC := MustPublicKeyFromBase58("SysvarRent111111111111111111111111111111111")
+
+	tables := map[PublicKey]PublicKeySlice{
+		tableC: {inC},
+		tableB: {inB},
+		tableA: {inA},
+	}
+	instruction := &testTransactionInstructions{
+		accounts: []*AccountMeta{
+			{PublicKey: payer, IsSigner: true, IsWritable: true},
+			{PublicKey: inC, IsSigner: false, IsWritable: true},
+			{PublicKey: inB, IsSigner: false, IsWritable: true},
+			{PublicKey: inA, IsSigner: false, IsWritable: true},
+		},
+		data:      []byte{0x01},
+		programID: SystemProgramID,
+	}
+
+	var first []byte
+	for i := 0; i < 20; i++ {
+		trx, err := NewTransaction(
+			[]Instruction{instruction},
+			MustHashFromBase58("GcgVK9buRA7YepZh3zXuS399GJAESCisLnLDBCmR5Aoj"),
+			TransactionPayer(payer),
+			TransactionAddressTables(tables),
+		)
+		require.NoError(t, err)
+
+		lookups := trx.Message.GetAddressTableLookups()
+		require.Len(t, lookups, 3)
+		assert.Equal(t, tableA, lookups[0].AccountKey)
+		assert.Equal(t, tableB, lookups[1].AccountKey)
+		assert.Equal(t, tableC, lookups[2].AccountKey)
+
+		raw, err := trx.Message.MarshalBinary()
+		require.NoError(t, err)
+		if first == nil {
+			first = raw
+		}
+		require.Equal(t, first, raw)
+	}
+}
+
 func TestPartialSignTransaction(t *testing.T) {
 	signers := []PrivateKey{
 		NewWallet().PrivateKey,
@@ -139,6 +311,31 @@ func TestPartialSignTransaction(t *testing.T) {
 	})
 	require.NoError(t, err)
 	assert.Equal(t, len(signatures), 1)
+
+	t.Run("decoded transaction", func(t *testing.T) {
+		// A decoded transaction has a (zero) slot for each signature:
+		// the second signer's signature goes in its slot.
+		raw, err := trx.ToBase64(EncodeAllowUnsigned())
+		require.NoError(t, err)
+		data, err := base64.StdEncoding.DecodeString(raw)
+		require.NoError(t, err)
+		decoded, err := TransactionFromBytes(data)
+		require.NoError(t, err)
+		decoded.Signatures[0] = Signature{}
+
+		signatures, err := decoded.PartialSign(func(key PublicKey) *PrivateKey {
+			if key.Equals(signers[1].PublicKey()) {
+				return &signers[1]
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, signatures, 2)
+		assert.True(t, signatures[0].IsZero())
+		message, err := decoded.Message.MarshalBinary()
+		require.NoError(t, err)
+		assert.True(t, signatures[1].Verify(signers[1].PublicKey(), message))
+	})
 }
 
 func TestSignTransaction(t *testing.T) {
@@ -189,6 +386,95 @@ func TestSignTransaction(t *testing.T) {
 	})
 }
 
+func TestTransaction_ToBase64_ToBase58(t *testing.T) {
+	signers := []PrivateKey{
+		NewWallet().PrivateKey,
+		NewWallet().PrivateKey,
+	}
+	instructions := []Instruction{
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: signers[0].PublicKey(), IsSigner: true, IsWritable: false},
+				{PublicKey: signers[1].PublicKey(), IsSigner: true, IsWritable: true},
+			},
+			data:      []byte{0xaa, 0xbb},
+			programID: MustPublicKeyFromBase58("11111111111111111111111111111111"),
+		},
+	}
+
+	blockhash, err := HashFromBase58("A9QnpgfhCkmiBSjgBuWk76Wo3HxzxvDopUq9x6UUMmjn")
+	require.NoError(t, err)
+
+	trx, err := NewTransaction(instructions, blockhash)
+	require.NoError(t, err)
+
+	t.Run("should reject unsigned", func(t *testing.T) {
+		_, err := trx.ToBase64()
+		require.ErrorIs(t, err, ErrMissingSignatures)
+		require.Contains(t, err.Error(), signers[0].PublicKey().String())
+		require.Contains(t, err.Error(), signers[1].PublicKey().String())
+
+		_, err = trx.ToBase58()
+		require.ErrorIs(t, err, ErrMissingSignatures)
+	})
+
+	t.Run("should encode unsigned when allowed", func(t *testing.T) {
+		b64, err := trx.ToBase64(EncodeAllowUnsigned())
+		require.NoError(t, err)
+		b58, err := trx.ToBase58(EncodeAllowUnsigned())
+		require.NoError(t, err)
+		require.Empty(t, trx.Signatures)
+
+		raw, err := base64.StdEncoding.DecodeString(b64)
+		require.NoError(t, err)
+		require.Equal(t, base58.Encode(raw), b58)
+
+		decoded, err := TransactionFromDecoder(bin.NewBinDecoder(raw))
+		require.NoError(t, err)
+		require.Equal(t, []Signature{{}, {}}, decoded.Signatures)
+		require.Equal(t, trx.Message.RecentBlockhash, decoded.Message.RecentBlockhash)
+	})
+
+	t.Run("should reject partially signed", func(t *testing.T) {
+		partial := *trx
+		_, err := partial.PartialSign(func(key PublicKey) *PrivateKey {
+			if key.Equals(signers[0].PublicKey()) {
+				return &signers[0]
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = partial.ToBase64()
+		require.ErrorIs(t, err, ErrMissingSignatures)
+		require.NotContains(t, err.Error(), signers[0].PublicKey().String())
+		require.Contains(t, err.Error(), signers[1].PublicKey().String())
+	})
+
+	t.Run("should encode signed", func(t *testing.T) {
+		_, err := trx.Sign(func(key PublicKey) *PrivateKey {
+			for _, signer := range signers {
+				if key.Equals(signer.PublicKey()) {
+					return &signer
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		raw, err := trx.MarshalBinary()
+		require.NoError(t, err)
+
+		b64, err := trx.ToBase64()
+		require.NoError(t, err)
+		require.Equal(t, base64.StdEncoding.EncodeToString(raw), b64)
+
+		b58, err := trx.ToBase58()
+		require.NoError(t, err)
+		require.Equal(t, base58.Encode(raw), b58)
+	})
+}
+
 func TestTransactionDecode(t *testing.T) {
 	encoded := "AfjEs3XhTc3hrxEvlnMPkm/cocvAUbFNbCl00qKnrFue6J53AhEqIFmcJJlJW3EDP5RmcMz+cNTTcZHW/WJYwAcBAAEDO8hh4VddzfcO5jbCt95jryl6y8ff65UcgukHNLWH+UQGgxCGGpgyfQVQV02EQYqm4QwzUt2qf9f1gVLM7rI4hwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6ANIF55zOZWROWRkeh+lExxZBnKFqbvIxZDLE7EijjoBAgIAAQwCAAAAOTAAAAAAAAA="
 	data, err := base64.StdEncoding.DecodeString(encoded)
@@ -314,3 +600,62 @@ func BenchmarkTransactionVerifySignatures(b *testing.B) {
 		tx.VerifySignatures()
 	}
 }
+
+func TestTransactionFromBytes_malformed(t *testing.T) {
+	valid, err := base64.StdEncoding.DecodeString("AfjEs3XhTc3hrxEvlnMPkm/cocvAUbFNbCl00qKnrFue6J53AhEqIFmcJJlJW3EDP5RmcMz+cNTTcZHW/WJYwAcBAAEDO8hh4VddzfcO5jbCt95jryl6y8ff65UcgukHNLWH+UQGgxCGGpgyfQVQV02EQYqm4QwzUt2qf9f1gVLM7rI4hwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6ANIF55zOZWROWRkeh+lExxZBnKFqbvIxZDLE7EijjoBAgIAAQwCAAAAOTAAAAAAAAA=")
+	require.NoError(t, err)
+	tx, err := TransactionFromBytes(valid)
+	require.NoError(t, err)
+	require.Len(t, tx.Signatures, 1)
+
+	// Every truncation of a valid transaction is an error, not a panic.
+	for i := 0; i < len(valid); i++ {
+		_, err := TransactionFromBytes(valid[:i])
+		require.Error(t, err, "truncated at %d", i)
+	}
+
+	malformed := map[string][]byte{
+		// A signature count that overflows an int with an unbounded compact-u16 decoder.
+		"negative length": {0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01},
+		// 0xffff signatures, but nothing after.
+		"huge length": {0xff, 0xff, 0x03},
+		// A v0 prefix with an unknown version.
+		"unknown version": append([]byte{0x00, 0x81}, make([]byte, 64)...),
+	}
+	for name, data := range malformed {
+		_, err := TransactionFromBytes(data)
+		require.Error(t, err, name)
+	}
+}
+
+func TestTransaction_IsExpired(t *testing.T) {
+	payer := NewWallet().PrivateKey
+	trx, err := NewTransactionBuilder().
+		AddInstruction(NewInstruction(
+			SystemProgramID,
+			AccountMetaSlice{Meta(payer.PublicKey()).WRITE().SIGNER()},
+			[]byte{0x01},
+		)).
+		SetRecentBlockHash(Hash{1}).
+		SetLastValidBlockHeight(1000).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), trx.LastValidBlockHeight)
+
+	require.False(t, trx.IsExpired(999))
+	// The blockhash is still valid at its last valid block height.
+	require.False(t, trx.IsExpired(1000))
+	require.True(t, trx.IsExpired(1001))
+
+	// Unknown last valid block height.
+	require.False(t, (&Transaction{}).IsExpired(1001))
+
+	_, err = trx.Sign(func(key PublicKey) *PrivateKey { return &payer })
+	require.NoError(t, err)
+	require.Len(t, trx.Signatures, 1)
+
+	trx.SetRecentBlockhash(Hash{2}, 2000)
+	require.Equal(t, Hash{2}, trx.Message.RecentBlockhash)
+	require.False(t, trx.IsExpired(1001))
+	require.Empty(t, trx.Signatures)
+}
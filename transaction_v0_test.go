@@ -118,3 +118,71 @@ func TestTransactionV0(t *testing.T) {
 		require.Equal(t, txB64, encoded)
 	}
 }
+
+func TestMessage_SetLoadedAddresses(t *testing.T) {
+	key := func() PublicKey { return NewWallet().PublicKey() }
+	payer, program := key(), key()
+	tableA, tableB := key(), key()
+	writableA, readonlyA := key(), key()
+	writableB, readonlyB2, readonlyB5 := key(), key(), key()
+
+	newMessage := func() *Message {
+		msg := &Message{
+			Header: MessageHeader{
+				NumRequiredSignatures:       1,
+				NumReadonlyUnsignedAccounts: 1,
+			},
+			AccountKeys: PublicKeySlice{payer, program},
+			Instructions: []CompiledInstruction{
+				{ProgramIDIndex: 1, Accounts: []uint16{0, 2, 3, 4, 5, 6}},
+			},
+		}
+		msg.SetAddressTableLookups([]MessageAddressTableLookup{
+			{AccountKey: tableA, WritableIndexes: []uint8{3}, ReadonlyIndexes: []uint8{0}},
+			{AccountKey: tableB, WritableIndexes: []uint8{1}, ReadonlyIndexes: []uint8{2, 5}},
+		})
+		return msg
+	}
+
+	msg := newMessage()
+	_, err := msg.Instructions[0].ResolveInstructionAccounts(msg)
+	require.Error(t, err, "the lookups are not resolved")
+
+	// The writable addresses of all the lookups, then the readonly ones.
+	require.NoError(t, msg.SetLoadedAddresses(
+		PublicKeySlice{writableA, writableB},
+		PublicKeySlice{readonlyA, readonlyB2, readonlyB5},
+	))
+	require.Equal(t,
+		PublicKeySlice{payer, program, writableA, writableB, readonlyA, readonlyB2, readonlyB5},
+		PublicKeySlice(msg.AccountKeys),
+	)
+	keys, err := msg.GetAllKeys()
+	require.NoError(t, err)
+	require.Equal(t, PublicKeySlice(msg.AccountKeys), keys)
+
+	accounts, err := msg.Instructions[0].ResolveInstructionAccounts(msg)
+	require.NoError(t, err)
+	require.Equal(t, []*AccountMeta{
+		Meta(payer).WRITE().SIGNER(),
+		Meta(writableA).WRITE(),
+		Meta(writableB).WRITE(),
+		Meta(readonlyA),
+		Meta(readonlyB2),
+		Meta(readonlyB5),
+	}, accounts)
+	programID, err := msg.Program(msg.Instructions[0].ProgramIDIndex)
+	require.NoError(t, err)
+	require.Equal(t, program, programID)
+
+	// Resolving again is a no-op.
+	require.NoError(t, msg.SetLoadedAddresses(nil, nil))
+	require.Len(t, msg.AccountKeys, 7)
+
+	err = newMessage().SetLoadedAddresses(PublicKeySlice{writableA}, PublicKeySlice{readonlyA, readonlyB2, readonlyB5})
+	require.EqualError(t, err, "loaded addresses (1 writable, 3 readonly) don't match the address table lookups")
+
+	msg.Instructions[0].Accounts = append(msg.Instructions[0].Accounts, 7)
+	_, err = msg.Instructions[0].ResolveInstructionAccounts(msg)
+	require.EqualError(t, err, "account index 7 out of range (7 accounts)")
+}